@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
@@ -27,10 +28,12 @@ type CreateParams struct {
 
 // ExtractParams holds parameters for archive extraction
 type ExtractParams struct {
-	Archive  string `pos:"true" help:"Archive file to extract"`
-	Output   string `short:"o" optional:"true" help:"Output directory (default: current directory)" default:"."`
-	Verbose  bool   `short:"v" optional:"true" help:"Verbose output - list files as they are extracted"`
-	Password string `short:"p" optional:"true" help:"Password for encrypted archives (zip, 7z, rar)"`
+	Archive         string `pos:"true" help:"Archive file to extract"`
+	Output          string `short:"o" optional:"true" help:"Output directory (default: current directory)" default:"."`
+	Verbose         bool   `short:"v" optional:"true" help:"Verbose output - list files as they are extracted"`
+	Password        string `short:"p" optional:"true" help:"Password for encrypted archives (zip, 7z, rar)"`
+	NoPreserveTimes bool   `optional:"true" help:"Don't restore modification times from the archive"`
+	Touch           bool   `short:"m" optional:"true" help:"Set modification times of all extracted entries to the current time"`
 }
 
 // ListParams holds parameters for listing archive contents
@@ -44,7 +47,7 @@ func Cmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "archive",
 		Short: "Create and extract archive files",
-		Long: `Create, extract, and list archive files in various formats.
+		Long: `Create, extract, list, and convert archive files in various formats.
 
 Supported formats:
   - tar         Plain tar archive
@@ -65,6 +68,7 @@ ZIP archives can be created with password protection using the -p flag (AES encr
 	cmd.AddCommand(createCmd())
 	cmd.AddCommand(extractCmd())
 	cmd.AddCommand(listCmd())
+	cmd.AddCommand(convertCmd())
 
 	return cmd
 }
@@ -140,11 +144,15 @@ func extractCmd() *cobra.Command {
 The archive format is auto-detected from the file contents.
 For encrypted archives (zip, 7z, rar), use the -p flag to specify the password.
 
+Modification times are restored from the archive entries. Use --no-preserve-times
+to leave them as written, or --touch to set them all to the current time.
+
 Examples:
   tofu archive extract backup.tar.gz
   tofu archive extract -o /tmp/output project.zip
   tofu archive extract -v archive.7z
-  tofu archive extract -p mypassword secret.zip`,
+  tofu archive extract -p mypassword secret.zip
+  tofu archive extract --touch build-cache.tar.gz`,
 		ParamEnrich: common.DefaultParamEnricher(),
 		InitFunc: func(params *ExtractParams, cmd *cobra.Command) error {
 			cmd.Aliases = []string{"x"}
@@ -315,6 +323,7 @@ func runArchiveExtract(params *ExtractParams) error {
 	if err != nil {
 		return fmt.Errorf("invalid output directory: %s", params.Output)
 	}
+	times := newModTimeSetter(params)
 	err = extractor.Extract(ctx, archiveReader, func(ctx context.Context, f archives.FileInfo) error {
 		// Sanitize the path
 		destPath := filepath.Join(absOutputRootDir, filepath.Clean(f.NameInArchive))
@@ -335,7 +344,11 @@ func runArchiveExtract(params *ExtractParams) error {
 
 		// Handle directories
 		if f.IsDir() {
-			return os.MkdirAll(destPathAbs, f.Mode())
+			if err := os.MkdirAll(destPathAbs, f.Mode()); err != nil {
+				return err
+			}
+			times.deferDir(destPathAbs, f.ModTime())
+			return nil
 		}
 
 		// Ensure parent directory exists
@@ -353,19 +366,88 @@ func runArchiveExtract(params *ExtractParams) error {
 		if err != nil {
 			return err
 		}
-		defer outFile.Close()
 
 		srcFile, err := f.Open()
 		if err != nil {
+			outFile.Close()
 			return err
 		}
 		defer srcFile.Close()
 
 		_, err = io.Copy(outFile, srcFile)
-		return err
+		if closeErr := outFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+
+		return times.set(destPathAbs, f.ModTime())
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	return times.applyDirs()
+}
+
+// modTimeSetter restores modification times of extracted entries according
+// to the extract params. Directory times are applied last, since extracting
+// files into a directory would otherwise bump its modification time again.
+type modTimeSetter struct {
+	preserve bool
+	touch    bool
+	now      time.Time
+	dirs     []dirModTime
+}
+
+type dirModTime struct {
+	path    string
+	modTime time.Time
+}
+
+func newModTimeSetter(params *ExtractParams) *modTimeSetter {
+	return &modTimeSetter{
+		preserve: !params.NoPreserveTimes,
+		touch:    params.Touch,
+		now:      time.Now(),
+	}
+}
+
+// resolve returns the time an entry should get, or false to leave it as is.
+func (m *modTimeSetter) resolve(archived time.Time) (time.Time, bool) {
+	switch {
+	case m.touch:
+		return m.now, true
+	case !m.preserve || archived.IsZero():
+		return time.Time{}, false
+	default:
+		return archived, true
+	}
+}
+
+func (m *modTimeSetter) set(path string, archived time.Time) error {
+	modTime, ok := m.resolve(archived)
+	if !ok {
+		return nil
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		return fmt.Errorf("cannot set modification time of %s: %w", path, err)
+	}
+	return nil
+}
+
+func (m *modTimeSetter) deferDir(path string, archived time.Time) {
+	m.dirs = append(m.dirs, dirModTime{path: path, modTime: archived})
+}
+
+func (m *modTimeSetter) applyDirs() error {
+	for _, d := range m.dirs {
+		if err := m.set(d.path, d.modTime); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func runArchiveList(params *ListParams) error {
@@ -657,6 +739,7 @@ func extractEncryptedZip(params *ExtractParams) error {
 		}
 	}
 
+	times := newModTimeSetter(params)
 	for _, f := range zr.File {
 		// Set password if file is encrypted
 		if f.IsEncrypted() {
@@ -690,6 +773,7 @@ func extractEncryptedZip(params *ExtractParams) error {
 			if err := os.MkdirAll(destPathAbs, mode|0700); err != nil {
 				return err
 			}
+			times.deferDir(destPathAbs, f.FileInfo().ModTime())
 			continue
 		}
 
@@ -711,14 +795,20 @@ func extractEncryptedZip(params *ExtractParams) error {
 		}
 
 		_, err = io.Copy(outFile, rc)
-		outFile.Close()
+		if closeErr := outFile.Close(); err == nil {
+			err = closeErr
+		}
 		rc.Close()
 		if err != nil {
 			return err
 		}
+
+		if err := times.set(destPathAbs, f.FileInfo().ModTime()); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return times.applyDirs()
 }
 
 func listEncryptedZip(params *ListParams) error {
@@ -4,6 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/yeka/zip"
 )
 
 func TestCmd(t *testing.T) {
@@ -886,3 +889,214 @@ func testArchiveSymlinkPreservation(t *testing.T, format string) {
 		t.Errorf("expected directory symlink target 'subdir', got '%s'", dirTarget)
 	}
 }
+
+func TestArchiveExtract_PreservesModTime(t *testing.T) {
+	dir := t.TempDir()
+	srcDir := filepath.Join(dir, "src")
+	os.MkdirAll(srcDir, 0755)
+
+	srcFile := filepath.Join(srcDir, "old.txt")
+	os.WriteFile(srcFile, []byte("old content"), 0644)
+	archivedTime := time.Date(2020, 5, 17, 12, 34, 56, 0, time.UTC)
+	if err := os.Chtimes(srcFile, archivedTime, archivedTime); err != nil {
+		t.Fatalf("failed to set source mtime: %v", err)
+	}
+
+	archivePath := filepath.Join(dir, "archive.tar")
+	if err := runArchiveCreate(&CreateParams{Output: archivePath, Files: []string{srcFile}}); err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+
+	// Default: modification time is restored
+	extractDir := filepath.Join(dir, "preserved")
+	if err := runArchiveExtract(&ExtractParams{Archive: archivePath, Output: extractDir}); err != nil {
+		t.Fatalf("failed to extract archive: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(extractDir, "old.txt"))
+	if err != nil {
+		t.Fatalf("extracted file missing: %v", err)
+	}
+	if !info.ModTime().Equal(archivedTime) {
+		t.Errorf("expected mtime %v, got %v", archivedTime, info.ModTime())
+	}
+
+	// --no-preserve-times: left as written
+	extractDir = filepath.Join(dir, "not-preserved")
+	if err := runArchiveExtract(&ExtractParams{Archive: archivePath, Output: extractDir, NoPreserveTimes: true}); err != nil {
+		t.Fatalf("failed to extract archive: %v", err)
+	}
+	info, err = os.Stat(filepath.Join(extractDir, "old.txt"))
+	if err != nil {
+		t.Fatalf("extracted file missing: %v", err)
+	}
+	if info.ModTime().Equal(archivedTime) {
+		t.Errorf("expected mtime not to be restored with --no-preserve-times")
+	}
+
+	// --touch: set to now
+	before := time.Now().Add(-time.Second)
+	extractDir = filepath.Join(dir, "touched")
+	if err := runArchiveExtract(&ExtractParams{Archive: archivePath, Output: extractDir, Touch: true}); err != nil {
+		t.Fatalf("failed to extract archive: %v", err)
+	}
+	info, err = os.Stat(filepath.Join(extractDir, "old.txt"))
+	if err != nil {
+		t.Fatalf("extracted file missing: %v", err)
+	}
+	if info.ModTime().Before(before) {
+		t.Errorf("expected mtime to be now with --touch, got %v", info.ModTime())
+	}
+}
+
+func TestArchiveExtract_PreservesDirModTime(t *testing.T) {
+	dir := t.TempDir()
+	srcDir := filepath.Join(dir, "tree")
+	os.MkdirAll(srcDir, 0755)
+	os.WriteFile(filepath.Join(srcDir, "inner.txt"), []byte("inner"), 0644)
+
+	archivedTime := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(srcDir, archivedTime, archivedTime); err != nil {
+		t.Fatalf("failed to set source dir mtime: %v", err)
+	}
+
+	archivePath := filepath.Join(dir, "archive.tar.gz")
+	if err := runArchiveCreate(&CreateParams{Output: archivePath, Files: []string{srcDir}}); err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+
+	extractDir := filepath.Join(dir, "extracted")
+	if err := runArchiveExtract(&ExtractParams{Archive: archivePath, Output: extractDir}); err != nil {
+		t.Fatalf("failed to extract archive: %v", err)
+	}
+
+	// The directory's time must survive files being written into it
+	info, err := os.Stat(filepath.Join(extractDir, "tree"))
+	if err != nil {
+		t.Fatalf("extracted dir missing: %v", err)
+	}
+	if !info.ModTime().Equal(archivedTime) {
+		t.Errorf("expected dir mtime %v, got %v", archivedTime, info.ModTime())
+	}
+}
+
+func TestEncryptedZip_PreservesModTime(t *testing.T) {
+	dir := t.TempDir()
+	srcFile := filepath.Join(dir, "secret.txt")
+	os.WriteFile(srcFile, []byte("secret"), 0644)
+
+	archivePath := filepath.Join(dir, "encrypted.zip")
+	password := "testpassword123"
+	if err := runArchiveCreate(&CreateParams{Output: archivePath, Files: []string{srcFile}, Password: password, Encryption: "aes256"}); err != nil {
+		t.Fatalf("failed to create encrypted archive: %v", err)
+	}
+
+	// Read the modification time recorded in the archive entry
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	archivedTime := zr.File[0].FileInfo().ModTime()
+	zr.Close()
+
+	extractDir := filepath.Join(dir, "extracted")
+	if err := runArchiveExtract(&ExtractParams{Archive: archivePath, Output: extractDir, Password: password}); err != nil {
+		t.Fatalf("failed to extract encrypted archive: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(extractDir, "secret.txt"))
+	if err != nil {
+		t.Fatalf("extracted file missing: %v", err)
+	}
+	if !info.ModTime().Equal(archivedTime) {
+		t.Errorf("expected mtime %v, got %v", archivedTime, info.ModTime())
+	}
+}
+
+func TestArchiveConvert_TarGzToZip(t *testing.T) {
+	dir := t.TempDir()
+	srcDir := filepath.Join(dir, "src")
+	os.MkdirAll(filepath.Join(srcDir, "subdir", "deeper"), 0755)
+
+	expected := map[string]string{
+		"file1.txt":               "content of file 1",
+		"file2.txt":               "content of file 2",
+		"subdir/file3.txt":        "content of file 3",
+		"subdir/deeper/file4.txt": "content of file 4",
+		"subdir/deeper/empty.txt": "",
+	}
+	for name, content := range expected {
+		os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644)
+	}
+
+	tarGzPath := filepath.Join(dir, "archive.tar.gz")
+	if err := runArchiveCreate(&CreateParams{Output: tarGzPath, Files: []string{srcDir}}); err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+
+	zipPath := filepath.Join(dir, "archive.zip")
+	if err := runArchiveConvert(&ConvertParams{Input: tarGzPath, Output: zipPath}); err != nil {
+		t.Fatalf("failed to convert archive: %v", err)
+	}
+
+	// The output must really be a zip, not just named like one
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("converted archive is not a zip: %v", err)
+	}
+	zr.Close()
+
+	extractDir := filepath.Join(dir, "extracted")
+	if err := runArchiveExtract(&ExtractParams{Archive: zipPath, Output: extractDir}); err != nil {
+		t.Fatalf("failed to extract converted archive: %v", err)
+	}
+
+	got := map[string]string{}
+	root := filepath.Join(extractDir, "src")
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		got[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read extracted files: %v", err)
+	}
+	if len(got) != len(expected) {
+		t.Errorf("expected %d files, got %d: %v", len(expected), len(got), got)
+	}
+	for name, content := range expected {
+		if c, ok := got[name]; !ok {
+			t.Errorf("expected %s in converted archive", name)
+		} else if c != content {
+			t.Errorf("expected %s to contain %q, got %q", name, content, c)
+		}
+	}
+}
+
+func TestArchiveConvert_Errors(t *testing.T) {
+	dir := t.TempDir()
+	srcFile := filepath.Join(dir, "file.txt")
+	os.WriteFile(srcFile, []byte("content"), 0644)
+	archivePath := filepath.Join(dir, "archive.tar")
+	if err := runArchiveCreate(&CreateParams{Output: archivePath, Files: []string{srcFile}}); err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+
+	if err := runArchiveConvert(&ConvertParams{Input: archivePath, Output: archivePath}); err == nil {
+		t.Error("expected error when converting an archive onto itself")
+	}
+
+	sevenZipPath := filepath.Join(dir, "archive.7z")
+	if err := runArchiveConvert(&ConvertParams{Input: archivePath, Output: sevenZipPath}); err == nil {
+		t.Error("expected error for 7z output")
+	}
+	if _, err := os.Stat(sevenZipPath); !os.IsNotExist(err) {
+		t.Error("expected no output file to be left behind")
+	}
+}
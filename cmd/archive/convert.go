@@ -0,0 +1,184 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/mholt/archives"
+	"github.com/spf13/cobra"
+)
+
+// ConvertParams holds parameters for converting between archive formats
+type ConvertParams struct {
+	Input    string `pos:"true" help:"Archive file to convert"`
+	Output   string `pos:"true" help:"Output archive file name (format auto-detected from extension)"`
+	Verbose  bool   `short:"v" optional:"true" help:"Verbose output - list files as they are converted"`
+	Format   string `short:"f" optional:"true" help:"Output archive format (tar, tar.gz, tar.bz2, tar.xz, tar.zst, zip). Overrides extension detection." alts:"tar,tar.gz,tar.bz2,tar.xz,tar.zst,zip"`
+	Password string `short:"p" optional:"true" help:"Password for encrypted input archives (7z, rar)"`
+}
+
+func convertCmd() *cobra.Command {
+	return boa.CmdT[ConvertParams]{
+		Use:   "convert",
+		Short: "Convert an archive to another format",
+		Long: `Convert an archive to another format, or recompress it with another codec.
+
+Entries are streamed from the input archive straight into the output archive,
+without extracting anything to disk. Input formats are the ones extract
+supports; output formats the ones create supports, except encrypted ZIP.
+
+Examples:
+  tofu archive convert backup.tar.gz backup.tar.zst
+  tofu archive convert project.zip project.tar.xz
+  tofu archive convert -p mypassword photos.7z photos.zip`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		InitFunc: func(params *ConvertParams, cmd *cobra.Command) error {
+			cmd.Aliases = []string{"conv"}
+			return nil
+		},
+		RunFunc: func(params *ConvertParams, cmd *cobra.Command, args []string) {
+			if err := runArchiveConvert(params); err != nil {
+				fmt.Fprintf(os.Stderr, "archive: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func runArchiveConvert(params *ConvertParams) error {
+	if sameFile(params.Input, params.Output) {
+		return fmt.Errorf("input and output are the same file")
+	}
+
+	outFormat, err := getArchiveFormat(params.Output, params.Format)
+	if err != nil {
+		return err
+	}
+	archiver, ok := outFormat.(archives.ArchiverAsync)
+	if !ok {
+		return fmt.Errorf("format does not support archive creation")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Open the input archive
+	archiveFile, err := os.Open(params.Input)
+	if err != nil {
+		return fmt.Errorf("cannot open archive: %w", err)
+	}
+	defer archiveFile.Close()
+
+	format, reader, err := archives.Identify(ctx, params.Input, archiveFile)
+	if err != nil {
+		return fmt.Errorf("cannot identify archive format: %w", err)
+	}
+
+	// Apply password to formats that support it
+	if params.Password != "" {
+		switch f := format.(type) {
+		case archives.Zip:
+			return fmt.Errorf("converting encrypted ZIP archives is not supported, extract it first")
+		case archives.SevenZip:
+			f.Password = params.Password
+			format = f
+		case archives.Rar:
+			f.Password = params.Password
+			format = f
+		}
+	}
+
+	extractor, ok := format.(archives.Extractor)
+	if !ok {
+		return fmt.Errorf("format does not support extraction")
+	}
+
+	// For formats that need seeking (zip, 7z), we need to use the file directly
+	var archiveReader io.Reader = reader
+	switch format.(type) {
+	case archives.Zip, archives.SevenZip:
+		archiveFile.Seek(0, io.SeekStart)
+		archiveReader = archiveFile
+	}
+
+	outFile, err := os.Create(params.Output)
+	if err != nil {
+		return fmt.Errorf("cannot create output file: %w", err)
+	}
+
+	err = convertEntries(ctx, extractor, archiveReader, archiver, outFile, params.Verbose)
+	if closeErr := outFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(params.Output) // Clean up partial file
+		return fmt.Errorf("failed to convert archive: %w", err)
+	}
+	return nil
+}
+
+// convertEntries chains the extractor into the archiver, one entry at a
+// time. An entry can only be read while the extractor's handler runs, so
+// the handler hands it to the archiver and waits until it has been written.
+func convertEntries(ctx context.Context, extractor archives.Extractor, input io.Reader, archiver archives.ArchiverAsync, output io.Writer, verbose bool) error {
+	jobs := make(chan archives.ArchiveAsyncJob)
+	done := make(chan struct{})
+	var archiveErr error
+	go func() {
+		defer close(done)
+		archiveErr = archiver.ArchiveAsync(ctx, output, jobs)
+	}()
+
+	errArchiverStopped := errors.New("archiver stopped")
+	extractErr := extractor.Extract(ctx, input, func(ctx context.Context, f archives.FileInfo) error {
+		if verbose {
+			fmt.Printf("a %s\n", f.NameInArchive)
+		}
+
+		// Format specific headers of the input mean nothing to the output
+		// format, and archivers add the trailing slash of directories
+		// themselves where their format wants one.
+		f.Header = nil
+		f.NameInArchive = strings.TrimSuffix(f.NameInArchive, "/")
+
+		result := make(chan error, 1)
+		select {
+		case jobs <- archives.ArchiveAsyncJob{File: f, Result: result}:
+		case <-done:
+			return errArchiverStopped
+		}
+		select {
+		case err := <-result:
+			return err
+		case <-done:
+			return errArchiverStopped
+		}
+	})
+	close(jobs)
+	<-done
+
+	if archiveErr != nil {
+		return archiveErr
+	}
+	return extractErr
+}
+
+// sameFile reports whether a and b refer to the same existing file, to
+// refuse overwriting the input while reading it.
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA == nil && errB == nil {
+		return os.SameFile(infoA, infoB)
+	}
+	absA, _ := filepath.Abs(a)
+	absB, _ := filepath.Abs(b)
+	return absA == absB
+}
@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type AgendaParams struct {
+	Days int      `short:"d" help:"Number of days to list, starting today." default:"7"`
+	ICS  []string `name:"ics" help:"ICS file or URL to list events from (repeatable). Default is the ics list in calendar.json in the config dir." optional:"true"`
+}
+
+func agendaCmd() *cobra.Command {
+	return boa.CmdT[AgendaParams]{
+		Use:         "agenda",
+		Short:       "List upcoming events from ICS calendars",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *AgendaParams, cmd *cobra.Command, args []string) {
+			if err := runAgenda(params, time.Now(), newLoader(), os.Stdout, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "calendar: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+// runAgenda lists the events of the coming days, grouped by day. All-day
+// events are listed first, on every day they span.
+func runAgenda(params *AgendaParams, now time.Time, l *loader, stdout, stderr io.Writer) error {
+	if params.Days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+	sources, err := loadSources(l, params.ICS, stderr)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no calendars, use --ics or list them in %s", configPath())
+	}
+
+	from := startOfDay(now)
+	to := from.AddDate(0, 0, params.Days)
+	occurrences := occurrencesIn(sources, from, to)
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	printed := false
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		var today []occurrence
+		for _, o := range occurrences {
+			if onDay(o, day, next, day.Equal(from)) {
+				today = append(today, o)
+			}
+		}
+		if len(today) == 0 {
+			continue
+		}
+
+		if printed {
+			fmt.Fprintln(w)
+		}
+		printed = true
+		fmt.Fprintln(w, day.Format("Mon 2006-01-02"))
+		for _, o := range today {
+			fmt.Fprintf(w, "  %s\t%s\t[%s]\n", eventTime(o), o.Summary, o.Calendar)
+		}
+	}
+	if !printed {
+		fmt.Fprintf(stdout, "No events in the next %d days\n", params.Days)
+		return nil
+	}
+	return w.Flush()
+}
+
+// onDay reports whether o is listed on the day [day, next). All-day events
+// are listed on every day they span, timed events on the day they start, or
+// the first day if they started before it.
+func onDay(o occurrence, day, next time.Time, first bool) bool {
+	if o.AllDay {
+		return o.Start.Before(next) && o.End.After(day)
+	}
+	if first && o.Start.Before(day) {
+		return true
+	}
+	return !o.Start.Before(day) && o.Start.Before(next)
+}
+
+func eventTime(o occurrence) string {
+	if o.AllDay {
+		return "all day"
+	}
+	start, end := o.Start.Local(), o.End.Local()
+	if !end.After(start) {
+		return start.Format("15:04")
+	}
+	return start.Format("15:04") + "-" + end.Format("15:04")
+}
@@ -2,6 +2,8 @@ package calendar
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
@@ -10,25 +12,32 @@ import (
 )
 
 type Params struct {
-	Month int `short:"m" help:"Month (1-12). Default is current month." default:"0"`
-	Year  int `short:"y" help:"Year. Default is current year." default:"0"`
+	Month int      `short:"m" help:"Month (1-12). Default is current month." default:"0"`
+	Year  int      `short:"y" help:"Year. Default is current year." default:"0"`
+	ICS   []string `name:"ics" help:"ICS file or URL to show events from (repeatable). Default is the ics list in calendar.json in the config dir." optional:"true"`
 }
 
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
-		Use:         "calendar",
-		Short:       "Display a calendar",
-		Long:        "Display a terminal calendar with today highlighted.",
+		Use:   "calendar",
+		Short: "Display a calendar",
+		Long: `Display a terminal calendar with today highlighted.
+
+With --ics, or a list of ICS files and URLs in calendar.json in the config
+dir, days with events are marked with a *. Use the agenda subcommand to list
+the events.`,
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds:     []*cobra.Command{agendaCmd()},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			Run(params)
+			if err := Run(params, time.Now(), newLoader(), os.Stdout, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "calendar: %v\n", err)
+				os.Exit(1)
+			}
 		},
 	}.ToCobra()
 }
 
-func Run(params *Params) {
-	now := time.Now()
-
+func Run(params *Params, now time.Time, l *loader, stdout, stderr io.Writer) error {
 	month := time.Month(params.Month)
 	year := params.Year
 
@@ -38,17 +47,14 @@ func Run(params *Params) {
 	if params.Year == 0 {
 		year = now.Year()
 	}
+	if month < 1 || month > 12 {
+		return fmt.Errorf("invalid month %d, expected 1-12", params.Month)
+	}
 
 	// Is this the current month?
 	isCurrentMonth := month == now.Month() && year == now.Year()
 	today := now.Day()
 
-	// Print header
-	title := fmt.Sprintf("%s %d", month.String(), year)
-	padding := (20 - len(title)) / 2
-	fmt.Printf("%*s%s\n", padding, "", title)
-	fmt.Println("Su Mo Tu We Th Fr Sa")
-
 	// Get first day of month
 	firstDay := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
 	startWeekday := int(firstDay.Weekday())
@@ -57,28 +63,67 @@ func Run(params *Params) {
 	lastDay := firstDay.AddDate(0, 1, -1)
 	daysInMonth := lastDay.Day()
 
+	sources, err := loadSources(l, params.ICS, stderr)
+	if err != nil {
+		return err
+	}
+	eventDays := daysWithEvents(occurrencesIn(sources, firstDay, firstDay.AddDate(0, 1, 0)), firstDay)
+
+	// Print header
+	title := fmt.Sprintf("%s %d", month.String(), year)
+	padding := (20 - len(title)) / 2
+	fmt.Fprintf(stdout, "%*s%s\n", padding, "", title)
+	fmt.Fprintln(stdout, "Su Mo Tu We Th Fr Sa")
+
 	// Print leading spaces
 	for i := 0; i < startWeekday; i++ {
-		fmt.Print("   ")
+		fmt.Fprint(stdout, "   ")
 	}
 
 	// Print days
 	for day := 1; day <= daysInMonth; day++ {
+		marker := " "
+		if eventDays[day] {
+			marker = "*"
+		}
 		if isCurrentMonth && day == today {
 			// Highlight today with reverse video
-			fmt.Printf("\033[7m%2d\033[0m ", day)
+			fmt.Fprintf(stdout, "\033[7m%2d\033[0m%s", day, marker)
 		} else {
-			fmt.Printf("%2d ", day)
+			fmt.Fprintf(stdout, "%2d%s", day, marker)
 		}
 
 		// New line after Saturday
 		if (startWeekday+day)%7 == 0 {
-			fmt.Println()
+			fmt.Fprintln(stdout)
 		}
 	}
 
 	// Final newline if needed
 	if (startWeekday+daysInMonth)%7 != 0 {
-		fmt.Println()
+		fmt.Fprintln(stdout)
+	}
+
+	if len(sources) > 0 {
+		fmt.Fprintln(stdout, "\n* has events")
+	}
+	return nil
+}
+
+// daysWithEvents returns the days of the month starting at firstDay that have
+// an event, counting every day a multi-day event spans.
+func daysWithEvents(occurrences []occurrence, firstDay time.Time) map[int]bool {
+	days := map[int]bool{}
+	nextMonth := firstDay.AddDate(0, 1, 0)
+	for _, o := range occurrences {
+		for day := startOfDay(o.Start); day.Before(nextMonth); day = day.AddDate(0, 0, 1) {
+			if day.After(o.Start) && !day.Before(o.End) {
+				break
+			}
+			if !day.Before(firstDay) {
+				days[day.Day()] = true
+			}
+		}
 	}
+	return days
 }
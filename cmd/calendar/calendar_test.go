@@ -0,0 +1,212 @@
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+const teamICS = `BEGIN:VCALENDAR
+X-WR-CALNAME:Team
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup
+DTSTART:20260302T091500
+DTEND:20260302T093000
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE
+END:VEVENT
+BEGIN:VEVENT
+UID:offsite
+SUMMARY:Offsite
+DTSTART;VALUE=DATE:20260305
+DTEND;VALUE=DATE:20260307
+END:VEVENT
+END:VCALENDAR
+`
+
+func testLoader(t *testing.T) *loader {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	return &loader{
+		client: &http.Client{Timeout: 5 * time.Second},
+		cache:  &common.FileCache{Dir: t.TempDir(), TTL: time.Minute},
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunMarksDaysWithEvents(t *testing.T) {
+	l := testLoader(t)
+	path := writeFile(t, "team.ics", teamICS)
+	var out, errOut bytes.Buffer
+
+	err := Run(&Params{Month: 3, Year: 2026, ICS: []string{path}}, local(2026, 4, 1, 12, 0), l, &out, &errOut)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := strings.Join([]string{
+		"     March 2026",
+		"Su Mo Tu We Th Fr Sa",
+		" 1  2* 3  4* 5* 6* 7 ",
+		" 8  9*10 11*12 13 14 ",
+		"15 16*17 18*19 20 21 ",
+		"22 23*24 25*26 27 28 ",
+		"29 30*31 ",
+		"",
+		"* has events",
+		"",
+	}, "\n")
+	if out.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out.String())
+	}
+	if errOut.Len() != 0 {
+		t.Errorf("Unexpected warnings: %s", errOut.String())
+	}
+}
+
+func TestRunWithoutCalendars(t *testing.T) {
+	l := testLoader(t)
+	var out bytes.Buffer
+	if err := Run(&Params{Month: 2, Year: 2026}, local(2026, 2, 10, 12, 0), l, &out, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "*") {
+		t.Errorf("Expected no markers, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "\033[7m10\033[0m ") {
+		t.Errorf("Expected today highlighted, got %q", out.String())
+	}
+	if err := Run(&Params{Month: 13}, local(2026, 2, 10, 12, 0), l, &out, &out); err == nil {
+		t.Error("Expected error for month 13")
+	}
+}
+
+func TestRunAgenda(t *testing.T) {
+	l := testLoader(t)
+	team := writeFile(t, "team.ics", teamICS)
+	personal := writeFile(t, "personal.ics", `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:dentist
+SUMMARY:Dentist
+DTSTART:20260304T080000
+DTEND:20260304T090000
+END:VEVENT
+END:VCALENDAR
+`)
+	var out, errOut bytes.Buffer
+
+	params := &AgendaParams{Days: 7, ICS: []string{team, personal, filepath.Join(t.TempDir(), "missing.ics")}}
+	if err := runAgenda(params, local(2026, 3, 3, 15, 0), l, &out, &errOut); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `Wed 2026-03-04
+  08:00-09:00  Dentist  [personal]
+  09:15-09:30  Standup  [Team]
+
+Thu 2026-03-05
+  all day  Offsite  [Team]
+
+Fri 2026-03-06
+  all day  Offsite  [Team]
+
+Mon 2026-03-09
+  09:15-09:30  Standup  [Team]
+`
+	if out.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out.String())
+	}
+	if !strings.Contains(errOut.String(), "calendar: warning: ") || !strings.Contains(errOut.String(), "missing.ics") {
+		t.Errorf("Expected a warning for the missing file, got %q", errOut.String())
+	}
+
+	out.Reset()
+	if err := runAgenda(&AgendaParams{Days: 1, ICS: []string{team}}, local(2026, 3, 3, 15, 0), l, &out, &errOut); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "No events in the next 1 days\n"; out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+}
+
+func TestRunAgendaConfig(t *testing.T) {
+	l := testLoader(t)
+	var out bytes.Buffer
+	if err := runAgenda(&AgendaParams{Days: 7}, local(2026, 3, 3, 15, 0), l, &out, &out); err == nil {
+		t.Error("Expected error without calendars")
+	}
+
+	team := writeFile(t, "team.ics", teamICS)
+	if err := os.MkdirAll(filepath.Dir(configPath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath(), []byte(fmt.Sprintf(`{"ics": [%q]}`, team)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runAgenda(&AgendaParams{Days: 7}, local(2026, 3, 3, 15, 0), l, &out, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Offsite") {
+		t.Errorf("Expected events from the configured calendar, got:\n%s", out.String())
+	}
+}
+
+func TestLoadURL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/cal/holidays.ics" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, strings.Replace(teamICS, "X-WR-CALNAME:Team\n", "", 1))
+	}))
+	defer server.Close()
+	l := testLoader(t)
+
+	for range 2 {
+		src, err := l.load(server.URL + "/cal/holidays.ics")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if src.Name != "holidays" || len(src.Events) != 2 {
+			t.Errorf("Unexpected calendar: %+v", src)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected the second load from the cache, got %d requests", requests)
+	}
+
+	if _, err := l.load(server.URL + "/missing.ics"); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+}
+
+func TestSourceName(t *testing.T) {
+	tests := map[string]string{
+		"work.ics":                           "work",
+		"/home/me/cals/family.ics":           "family",
+		"https://example.com/cal/team.ics":   "team",
+		"webcal://example.com/feeds/holiday": "holiday",
+		"https://example.com/":               "example.com",
+	}
+	for src, want := range tests {
+		if got := sourceName(src); got != want {
+			t.Errorf("sourceName(%q): expected %q, got %q", src, want, got)
+		}
+	}
+}
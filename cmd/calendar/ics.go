@@ -0,0 +1,553 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// event is a VEVENT from an ICS file. Times are in the event's own time
+// zone, or local time for floating and all-day events.
+type event struct {
+	UID          string
+	Summary      string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Rule         *recurrence
+	ExDates      []time.Time
+	RecurrenceID time.Time
+	Cancelled    bool
+
+	// A DURATION, applied at the end of the VEVENT since it may come
+	// before DTSTART
+	durationDays int
+	duration     time.Duration
+}
+
+// occurrence is a single instance of an event within a time window.
+type occurrence struct {
+	Summary  string
+	Start    time.Time
+	End      time.Time
+	AllDay   bool
+	Calendar string
+}
+
+// contentLine is a property line like DTSTART;TZID=Europe/Stockholm:2026...
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseICS parses the events of an ICS calendar, and its X-WR-CALNAME if set.
+func parseICS(r io.Reader) ([]*event, string, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var events []*event
+	var calName string
+	var current *event
+	depth := 0 // nesting inside the VEVENT, e.g. VALARM
+	for i, raw := range lines {
+		line, ok := parseContentLine(raw)
+		if !ok {
+			continue
+		}
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VEVENT") && current == nil:
+			current = &event{}
+			continue
+		case line.name == "BEGIN" && current != nil:
+			depth++
+			continue
+		case line.name == "END" && current != nil && depth > 0:
+			depth--
+			continue
+		case line.name == "END" && strings.EqualFold(line.value, "VEVENT") && current != nil:
+			if !current.Start.IsZero() {
+				if current.End.IsZero() && (current.durationDays != 0 || current.duration != 0) {
+					current.End = current.Start.AddDate(0, 0, current.durationDays).Add(current.duration)
+				}
+				if current.End.IsZero() || !current.End.After(current.Start) {
+					current.End = defaultEnd(current)
+				}
+				events = append(events, current)
+			}
+			current = nil
+			continue
+		case line.name == "X-WR-CALNAME" && current == nil:
+			calName = unescapeText(line.value)
+			continue
+		}
+		if current == nil || depth > 0 {
+			continue
+		}
+
+		if err := current.setProperty(line); err != nil {
+			return nil, "", fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return events, calName, nil
+}
+
+// defaultEnd is the end of an event without a valid DTEND or DURATION: the
+// next day for all-day events, else the start.
+func defaultEnd(e *event) time.Time {
+	if e.AllDay {
+		return e.Start.AddDate(0, 0, 1)
+	}
+	return e.Start
+}
+
+func (e *event) setProperty(line contentLine) error {
+	var err error
+	switch line.name {
+	case "UID":
+		e.UID = line.value
+	case "SUMMARY":
+		e.Summary = unescapeText(line.value)
+	case "STATUS":
+		e.Cancelled = strings.EqualFold(line.value, "CANCELLED")
+	case "DTSTART":
+		e.Start, e.AllDay, err = parseICSTime(line)
+	case "DTEND":
+		e.End, _, err = parseICSTime(line)
+	case "DURATION":
+		e.durationDays, e.duration, err = parseICSDuration(line.value)
+	case "RRULE":
+		e.Rule, err = parseRRule(line.value)
+	case "EXDATE":
+		for value := range strings.SplitSeq(line.value, ",") {
+			var t time.Time
+			if t, _, err = parseICSTime(contentLine{params: line.params, value: value}); err != nil {
+				return err
+			}
+			e.ExDates = append(e.ExDates, t)
+		}
+	case "RECURRENCE-ID":
+		e.RecurrenceID, _, err = parseICSTime(line)
+	}
+	return err
+}
+
+// unfoldLines joins continuation lines, which start with a space or tab.
+func unfoldLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseContentLine(raw string) (contentLine, bool) {
+	// The value starts at the first colon outside a quoted parameter value
+	inQuotes := false
+	colon := -1
+	for i, c := range raw {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return contentLine{}, false
+	}
+
+	parts := strings.Split(raw[:colon], ";")
+	line := contentLine{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: raw[colon+1:]}
+	for _, p := range parts[1:] {
+		if key, value, ok := strings.Cut(p, "="); ok {
+			line.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return line, true
+}
+
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseICSTime parses a DATE or DATE-TIME value. All-day dates and floating
+// times are in local time. Unknown TZIDs, such as Windows zone names, also
+// fall back to local time.
+func parseICSTime(line contentLine) (time.Time, bool, error) {
+	value := strings.TrimSpace(line.value)
+	if line.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+
+	if utc, ok := strings.CutSuffix(value, "Z"); ok {
+		t, err := time.ParseInLocation("20060102T150405", utc, time.UTC)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, false, nil
+	}
+	loc := time.Local
+	if tzid := line.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t, false, nil
+}
+
+// parseICSDuration parses durations like P1D, PT1H30M or P2W into days, which
+// follow the wall clock across DST changes, and an exact duration.
+func parseICSDuration(s string) (int, time.Duration, error) {
+	invalid := fmt.Errorf("invalid duration %q", s)
+	sign := 1
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	rest, ok := strings.CutPrefix(s, "P")
+	if !ok || rest == "" {
+		return 0, 0, invalid
+	}
+
+	var days int
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, c := range rest {
+		switch {
+		case c >= '0' && c <= '9':
+			num += string(c)
+			continue
+		case c == 'T' && !inTime && num == "":
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, 0, invalid
+		}
+		num = ""
+		switch {
+		case c == 'W' && !inTime:
+			days += 7 * n
+		case c == 'D' && !inTime:
+			days += n
+		case c == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+		case c == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+		case c == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+		default:
+			return 0, 0, invalid
+		}
+	}
+	if num != "" {
+		return 0, 0, invalid
+	}
+	return sign * days, time.Duration(sign) * d, nil
+}
+
+// recurrence is the supported subset of an RRULE.
+type recurrence struct {
+	Freq       string // DAILY, WEEKLY, MONTHLY or YEARLY
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []weekdayNum
+	ByMonthDay []int
+}
+
+// weekdayNum is a BYDAY value like MO, or 1MO and -1FR in monthly rules.
+type weekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseRRule(s string) (*recurrence, error) {
+	r := &recurrence{Interval: 1}
+	for part := range strings.SplitSeq(s, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+		case "INTERVAL":
+			if r.Interval, err = strconv.Atoi(value); err == nil && r.Interval < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			r.Until, _, err = parseICSTime(contentLine{value: value})
+		case "BYDAY":
+			for day := range strings.SplitSeq(value, ",") {
+				name := strings.TrimLeft(day, "+-0123456789")
+				wd, ok := icsWeekdays[strings.ToUpper(name)]
+				if !ok {
+					err = fmt.Errorf("unknown day %q", day)
+					break
+				}
+				n := 0
+				if prefix := day[:len(day)-len(name)]; prefix != "" {
+					if n, err = strconv.Atoi(prefix); err != nil {
+						break
+					}
+				}
+				r.ByDay = append(r.ByDay, weekdayNum{N: n, Weekday: wd})
+			}
+		case "BYMONTHDAY":
+			for day := range strings.SplitSeq(value, ",") {
+				var n int
+				if n, err = strconv.Atoi(day); err != nil {
+					break
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid RRULE %s: %w", part, err)
+		}
+	}
+	return r, nil
+}
+
+// maxPeriods bounds the expansion of rules that never produce an instance in
+// the window, e.g. a monthly rule on the 31st of February.
+const maxPeriods = 100000
+
+// occurrences returns the instances of the event that overlap [from, to).
+func (e *event) occurrences(from, to time.Time) []occurrence {
+	overlaps := func(start, end time.Time) bool {
+		if !end.After(start) {
+			// Zero-length events overlap the instant they happen at
+			return !start.Before(from) && start.Before(to)
+		}
+		return start.Before(to) && end.After(from)
+	}
+	instance := func(start time.Time) occurrence {
+		var end time.Time
+		if e.AllDay {
+			days := int(e.End.Sub(e.Start).Round(24*time.Hour) / (24 * time.Hour))
+			end = start.AddDate(0, 0, days)
+		} else {
+			end = start.Add(e.End.Sub(e.Start))
+		}
+		return occurrence{Summary: e.Summary, Start: start, End: end, AllDay: e.AllDay}
+	}
+
+	if e.Rule == nil || !e.Rule.supported() {
+		if o := instance(e.Start); overlaps(o.Start, o.End) {
+			return []occurrence{o}
+		}
+		return nil
+	}
+
+	var result []occurrence
+	count := 0
+	for period := 0; period < maxPeriods; period++ {
+		periodStart, candidates := e.Rule.candidates(e.Start, period)
+		if !periodStart.Before(to) {
+			break
+		}
+		for _, start := range candidates {
+			if start.Before(e.Start) {
+				continue
+			}
+			if !e.Rule.Until.IsZero() && start.After(e.Rule.Until) {
+				return result
+			}
+			count++
+			if e.Rule.Count > 0 && count > e.Rule.Count {
+				return result
+			}
+			if !start.Before(to) {
+				return result
+			}
+			if slices.ContainsFunc(e.ExDates, start.Equal) {
+				continue
+			}
+			if o := instance(start); overlaps(o.Start, o.End) {
+				result = append(result, o)
+			}
+		}
+	}
+	return result
+}
+
+func (r *recurrence) supported() bool {
+	switch r.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+		return true
+	}
+	return false
+}
+
+// candidates returns the start of the nth period of the rule, and the
+// instance starts in it in order, at the wall-clock time of start.
+func (r *recurrence) candidates(start time.Time, n int) (time.Time, []time.Time) {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	}
+	step := n * r.Interval
+
+	switch r.Freq {
+	case "DAILY":
+		t := start.AddDate(0, 0, step)
+		return t, []time.Time{t}
+
+	case "WEEKLY":
+		// Weeks start on Monday, the RFC 5545 default
+		monday := at(start.Year(), start.Month(), start.Day()-(int(start.Weekday())+6)%7).AddDate(0, 0, 7*step)
+		if len(r.ByDay) == 0 {
+			return monday, []time.Time{start.AddDate(0, 0, 7*step)}
+		}
+		var starts []time.Time
+		for _, wd := range r.ByDay {
+			starts = append(starts, monday.AddDate(0, 0, (int(wd.Weekday)+6)%7))
+		}
+		slices.SortFunc(starts, time.Time.Compare)
+		return monday, slices.CompactFunc(starts, time.Time.Equal)
+
+	case "MONTHLY":
+		first := at(start.Year(), start.Month(), 1).AddDate(0, step, 0)
+		daysInMonth := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		var days []int
+		switch {
+		case len(r.ByMonthDay) > 0:
+			for _, d := range r.ByMonthDay {
+				if d < 0 {
+					d = daysInMonth + d + 1
+				}
+				days = append(days, d)
+			}
+		case len(r.ByDay) > 0:
+			for _, wd := range r.ByDay {
+				days = append(days, monthWeekdays(first, daysInMonth, wd)...)
+			}
+		default:
+			days = []int{start.Day()}
+		}
+		slices.Sort(days)
+		var starts []time.Time
+		for _, d := range slices.Compact(days) {
+			// Months without the day are skipped, as RFC 5545 requires
+			if d >= 1 && d <= daysInMonth {
+				starts = append(starts, at(first.Year(), first.Month(), d))
+			}
+		}
+		return first, starts
+
+	default: // YEARLY
+		year := start.Year() + step
+		jan1 := at(year, time.January, 1)
+		t := at(year, start.Month(), start.Day())
+		if t.Month() != start.Month() {
+			// Feb 29 in a non-leap year
+			return jan1, nil
+		}
+		return jan1, []time.Time{t}
+	}
+}
+
+// monthWeekdays returns the days of the month matching a BYDAY value: every
+// such weekday, or only the Nth, counting from the end if N is negative.
+func monthWeekdays(first time.Time, daysInMonth int, wd weekdayNum) []int {
+	var days []int
+	for d := 1 + (int(wd.Weekday)-int(first.Weekday())+7)%7; d <= daysInMonth; d += 7 {
+		days = append(days, d)
+	}
+	switch {
+	case wd.N == 0:
+		return days
+	case wd.N > 0 && wd.N <= len(days):
+		return []int{days[wd.N-1]}
+	case wd.N < 0 && -wd.N <= len(days):
+		return []int{days[len(days)+wd.N]}
+	}
+	return nil
+}
+
+// expandEvents returns the occurrences of the events in [from, to), sorted by
+// start. Instances moved or cancelled with a RECURRENCE-ID replace those of
+// the recurring event.
+func expandEvents(events []*event, calendar string, from, to time.Time) []occurrence {
+	overridden := map[string][]time.Time{}
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			overridden[e.UID] = append(overridden[e.UID], e.RecurrenceID)
+		}
+	}
+
+	var result []occurrence
+	for _, e := range events {
+		if e.Cancelled {
+			continue
+		}
+		if e.Rule != nil && e.RecurrenceID.IsZero() && len(overridden[e.UID]) > 0 {
+			master := *e
+			master.ExDates = append(slices.Clone(e.ExDates), overridden[e.UID]...)
+			e = &master
+		}
+		for _, o := range e.occurrences(from, to) {
+			o.Calendar = calendar
+			result = append(result, o)
+		}
+	}
+	sortOccurrences(result)
+	return result
+}
+
+// sortOccurrences sorts by start, with all-day events first on their day.
+func sortOccurrences(occurrences []occurrence) {
+	slices.SortStableFunc(occurrences, func(a, b occurrence) int {
+		if c := startOfDay(a.Start).Compare(startOfDay(b.Start)); c != 0 {
+			return c
+		}
+		if a.AllDay != b.AllDay {
+			if a.AllDay {
+				return -1
+			}
+			return 1
+		}
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Summary, b.Summary)
+	})
+}
+
+// startOfDay returns the start of t's day in local time.
+func startOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
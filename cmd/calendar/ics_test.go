@@ -0,0 +1,282 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParseICS(t *testing.T, body string) []*event {
+	t.Helper()
+	ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.ReplaceAll(strings.TrimSpace(body), "\n", "\r\n") + "\r\nEND:VCALENDAR\r\n"
+	events, _, err := parseICS(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return events
+}
+
+func local(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.Local)
+}
+
+func starts(occurrences []occurrence) []string {
+	var result []string
+	for _, o := range occurrences {
+		result = append(result, o.Start.Local().Format("2006-01-02 15:04"))
+	}
+	return result
+}
+
+func TestParseICS(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"X-WR-CALNAME:Team",
+		"BEGIN:VEVENT",
+		"UID:1",
+		"SUMMARY:Planning\\, with a very long",
+		"  folded title",
+		"DTSTART;TZID=Europe/Stockholm:20260316T100000",
+		"DURATION:PT1H30M",
+		"BEGIN:VALARM",
+		"SUMMARY:Alarm",
+		"TRIGGER:-PT15M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:2",
+		"SUMMARY:Offsite",
+		"DTSTART;VALUE=DATE:20260318",
+		"DTEND;VALUE=DATE:20260320",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:3",
+		"SUMMARY:Deploy",
+		"DTSTART:20260316T120000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, name, err := parseICS(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "Team" {
+		t.Errorf("Expected %q, got %q", "Team", name)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+
+	planning := events[0]
+	if want := "Planning, with a very long folded title"; planning.Summary != want {
+		t.Errorf("Expected %q, got %q", want, planning.Summary)
+	}
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	if want := time.Date(2026, 3, 16, 10, 0, 0, 0, stockholm); !planning.Start.Equal(want) {
+		t.Errorf("Expected start %v, got %v", want, planning.Start)
+	}
+	if got := planning.End.Sub(planning.Start); got != 90*time.Minute {
+		t.Errorf("Expected 1h30m, got %v", got)
+	}
+
+	offsite := events[1]
+	if !offsite.AllDay || !offsite.Start.Equal(local(2026, 3, 18, 0, 0)) || !offsite.End.Equal(local(2026, 3, 20, 0, 0)) {
+		t.Errorf("Unexpected all-day event: %+v", offsite)
+	}
+
+	deploy := events[2]
+	if want := time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC); !deploy.Start.Equal(want) || !deploy.End.Equal(want) {
+		t.Errorf("Expected a zero-length event at %v, got %+v", want, deploy)
+	}
+}
+
+func TestParseICSDuration(t *testing.T) {
+	tests := map[string]struct {
+		days int
+		dur  time.Duration
+	}{
+		"P1D":      {1, 0},
+		"P2W":      {14, 0},
+		"PT1H30M":  {0, 90 * time.Minute},
+		"P1DT12H":  {1, 12 * time.Hour},
+		"-PT15M":   {0, -15 * time.Minute},
+		"PT0S":     {0, 0},
+		"P1DT2H3S": {1, 2*time.Hour + 3*time.Second},
+	}
+	for input, want := range tests {
+		days, dur, err := parseICSDuration(input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", input, err)
+			continue
+		}
+		if days != want.days || dur != want.dur {
+			t.Errorf("%s: expected %d days %v, got %d days %v", input, want.days, want.dur, days, dur)
+		}
+	}
+	for _, input := range []string{"", "P", "1D", "PT1D", "P1H", "PT5"} {
+		if _, _, err := parseICSDuration(input); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start string
+		from  time.Time
+		to    time.Time
+		want  []string
+	}{
+		{
+			name:  "daily with interval",
+			rule:  "FREQ=DAILY;INTERVAL=2",
+			start: "20260301T090000",
+			from:  local(2026, 3, 10, 0, 0),
+			to:    local(2026, 3, 16, 0, 0),
+			want:  []string{"2026-03-11 09:00", "2026-03-13 09:00", "2026-03-15 09:00"},
+		},
+		{
+			name:  "weekly on weekdays",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			start: "20260304T093000", // a Wednesday
+			from:  local(2026, 3, 1, 0, 0),
+			to:    local(2026, 3, 10, 0, 0),
+			want:  []string{"2026-03-04 09:30", "2026-03-06 09:30", "2026-03-09 09:30"},
+		},
+		{
+			name:  "weekly with count",
+			rule:  "FREQ=WEEKLY;COUNT=3",
+			start: "20260302T100000",
+			from:  local(2026, 1, 1, 0, 0),
+			to:    local(2026, 12, 1, 0, 0),
+			want:  []string{"2026-03-02 10:00", "2026-03-09 10:00", "2026-03-16 10:00"},
+		},
+		{
+			name:  "biweekly until",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;UNTIL=20260401T000000Z",
+			start: "20260302T100000",
+			from:  local(2026, 1, 1, 0, 0),
+			to:    local(2026, 12, 1, 0, 0),
+			want:  []string{"2026-03-02 10:00", "2026-03-16 10:00", "2026-03-30 10:00"},
+		},
+		{
+			name:  "monthly on the last friday",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: "20260130T160000",
+			from:  local(2026, 1, 1, 0, 0),
+			to:    local(2026, 5, 1, 0, 0),
+			want:  []string{"2026-01-30 16:00", "2026-02-27 16:00", "2026-03-27 16:00", "2026-04-24 16:00"},
+		},
+		{
+			name:  "monthly on the 31st skips short months",
+			rule:  "FREQ=MONTHLY",
+			start: "20260131T080000",
+			from:  local(2026, 1, 1, 0, 0),
+			to:    local(2026, 6, 1, 0, 0),
+			want:  []string{"2026-01-31 08:00", "2026-03-31 08:00", "2026-05-31 08:00"},
+		},
+		{
+			name:  "monthly on the last day",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			start: "20260131T080000",
+			from:  local(2026, 1, 1, 0, 0),
+			to:    local(2026, 12, 1, 0, 0),
+			want:  []string{"2026-01-31 08:00", "2026-02-28 08:00", "2026-03-31 08:00"},
+		},
+		{
+			name:  "yearly",
+			rule:  "FREQ=YEARLY",
+			start: "20200229T120000",
+			from:  local(2026, 1, 1, 0, 0),
+			to:    local(2029, 1, 1, 0, 0),
+			want:  []string{"2028-02-29 12:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := mustParseICS(t, "BEGIN:VEVENT\nUID:x\nSUMMARY:x\nDTSTART:"+tt.start+"\nDURATION:PT30M\nRRULE:"+tt.rule+"\nEND:VEVENT")
+			got := starts(expandEvents(events, "test", tt.from, tt.to))
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExpandEventsExceptions(t *testing.T) {
+	events := mustParseICS(t, `
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup
+DTSTART:20260302T091500
+DTEND:20260302T093000
+RRULE:FREQ=DAILY;COUNT=5
+EXDATE:20260303T091500
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup (moved)
+RECURRENCE-ID:20260304T091500
+DTSTART:20260304T130000
+DTEND:20260304T131500
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup
+RECURRENCE-ID:20260305T091500
+DTSTART:20260305T091500
+STATUS:CANCELLED
+END:VEVENT`)
+
+	from, to := local(2026, 3, 1, 0, 0), local(2026, 3, 31, 0, 0)
+	got := expandEvents(events, "work", from, to)
+	want := []string{"2026-03-02 09:15", "2026-03-04 13:00", "2026-03-06 09:15"}
+	if strings.Join(starts(got), ", ") != strings.Join(want, ", ") {
+		t.Fatalf("Expected %q, got %q", want, starts(got))
+	}
+	if got[1].Summary != "Standup (moved)" || got[1].End.Sub(got[1].Start) != 15*time.Minute {
+		t.Errorf("Expected the moved instance, got %+v", got[1])
+	}
+	if got[0].Calendar != "work" {
+		t.Errorf("Expected %q, got %q", "work", got[0].Calendar)
+	}
+
+	// Expanding again doesn't accumulate the overrides
+	if again := expandEvents(events, "work", from, to); len(again) != 3 || len(events[0].ExDates) != 1 {
+		t.Errorf("Expected the same result again, got %q with %d exdates", starts(again), len(events[0].ExDates))
+	}
+}
+
+func TestExpandEventsAllDayFirst(t *testing.T) {
+	events := mustParseICS(t, `
+BEGIN:VEVENT
+UID:a
+SUMMARY:Review
+DTSTART:20260318T080000
+DTEND:20260318T090000
+END:VEVENT
+BEGIN:VEVENT
+UID:b
+SUMMARY:Offsite
+DTSTART;VALUE=DATE:20260317
+DTEND;VALUE=DATE:20260319
+RRULE:FREQ=WEEKLY;COUNT=2
+END:VEVENT`)
+
+	got := expandEvents(events, "work", local(2026, 3, 18, 0, 0), local(2026, 3, 25, 0, 0))
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 occurrences, got %+v", got)
+	}
+	if !got[0].AllDay || got[0].Start.Day() != 17 {
+		t.Errorf("Expected the ongoing all-day event first, got %+v", got[0])
+	}
+	if got[1].Summary != "Review" || got[2].Summary != "Offsite" || got[2].End.Day() != 26 {
+		t.Errorf("Unexpected order: %+v", got)
+	}
+}
@@ -0,0 +1,159 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+// calendarConfig is the optional calendar.json in the config dir, listing
+// the ICS sources to show when --ics isn't given.
+type calendarConfig struct {
+	ICS []string `json:"ics"`
+}
+
+func configPath() string {
+	return filepath.Join(common.ConfigDir(), "calendar.json")
+}
+
+func loadConfig(path string) (calendarConfig, error) {
+	var cfg calendarConfig
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// cacheTTL is how long a fetched calendar is reused, so that showing the
+// calendar repeatedly doesn't download it every time.
+const cacheTTL = 15 * time.Minute
+
+// calendarSource is a parsed ICS file or URL.
+type calendarSource struct {
+	Name   string
+	Events []*event
+}
+
+type loader struct {
+	client *http.Client
+	cache  *common.FileCache
+}
+
+func newLoader() *loader {
+	return &loader{
+		client: &http.Client{Timeout: 10 * time.Second},
+		cache:  &common.FileCache{Dir: filepath.Join(common.CacheDir(), "calendar"), TTL: cacheTTL},
+	}
+}
+
+func isURL(src string) bool {
+	return strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "webcal://")
+}
+
+// load reads an ICS file, or fetches an ICS URL through the cache. webcal://
+// URLs are fetched over https.
+func (l *loader) load(src string) (*calendarSource, error) {
+	var data []byte
+	var err error
+	if isURL(src) {
+		data, err = l.fetch(strings.Replace(src, "webcal://", "https://", 1))
+	} else {
+		data, err = os.ReadFile(src)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events, name, err := parseICS(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = sourceName(src)
+	}
+	return &calendarSource{Name: name, Events: events}, nil
+}
+
+func (l *loader) fetch(rawURL string) ([]byte, error) {
+	if data, ok := l.cache.Get(rawURL); ok {
+		return data, nil
+	}
+
+	resp, err := l.client.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch calendar: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", resp.Request.URL.Host, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// A failed cache write only means fetching again next time
+	_ = l.cache.Put(rawURL, data)
+	return data, nil
+}
+
+// sourceName names a calendar without an X-WR-CALNAME after its file, like
+// "work" for work.ics or https://example.com/cal/work.ics.
+func sourceName(src string) string {
+	if u, err := url.Parse(src); err == nil && isURL(src) {
+		if base := strings.TrimSuffix(filepath.Base(u.Path), filepath.Ext(u.Path)); base != "" && base != "." && base != "/" {
+			return base
+		}
+		return u.Host
+	}
+	return strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+}
+
+// loadSources loads the --ics sources, or those in the config file if none
+// are given. A source that can't be loaded is reported as a warning, so the
+// others are still shown.
+func loadSources(l *loader, ics []string, stderr io.Writer) ([]*calendarSource, error) {
+	if len(ics) == 0 {
+		cfg, err := loadConfig(configPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", configPath(), err)
+		}
+		ics = cfg.ICS
+	}
+
+	var sources []*calendarSource
+	for _, src := range ics {
+		s, err := l.load(src)
+		if err != nil {
+			fmt.Fprintf(stderr, "calendar: warning: %s: %v\n", src, err)
+			continue
+		}
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
+
+// occurrencesIn returns the occurrences of all sources in [from, to).
+func occurrencesIn(sources []*calendarSource, from, to time.Time) []occurrence {
+	var result []occurrence
+	for _, s := range sources {
+		result = append(result, expandEvents(s.Events, s.Name, from, to)...)
+	}
+	sortOccurrences(result)
+	return result
+}
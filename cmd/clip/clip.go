@@ -5,20 +5,39 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/atotto/clipboard"
 	"github.com/gigurra/tofu/cmd/common"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
 	clipboardWriteAll = clipboard.WriteAll
 	clipboardReadAll  = clipboard.ReadAll
+	openStore         = defaultStore
 )
 
 type Params struct {
-	Paste bool `short:"p" help:"Paste from clipboard to standard output."`
+	Paste   bool `short:"p" help:"Paste from clipboard to standard output."`
+	History bool `help:"Record this copy in the clip history, even if history is not enabled."`
+}
+
+type PasteParams struct {
+	Index int `pos:"true" optional:"true" help:"Paste this history entry instead, 1 being the most recent, and copy it to the clipboard again."`
+}
+
+type ClearParams struct{}
+
+type HistoryParams struct {
+	Index   int  `pos:"true" optional:"true" help:"Copy this entry again without the picker, 1 being the most recent."`
+	List    bool `short:"l" help:"List the history instead of showing the picker."`
+	Enable  bool `help:"Record every copy in the history from now on."`
+	Disable bool `help:"Stop recording copies in the history."`
+	Size    int  `optional:"true" help:"Keep this many copies in the history, dropping the oldest. Default is 100."`
+	Clear   bool `help:"Delete all recorded history."`
 }
 
 func Cmd() *cobra.Command {
@@ -29,8 +48,17 @@ func Cmd() *cobra.Command {
 
 If [text] is provided, it is copied to the clipboard.
 If no arguments are provided, reads from standard input until EOF.
-Use -p or --paste to paste content from the clipboard to standard output.`,
+Use the paste subcommand, or -p/--paste, to write the clipboard to standard output.
+
+Copies are only recorded in the history when it has been enabled with
+'clip history --enable', or for a single copy with --history.`,
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds: []*cobra.Command{
+			pasteCmd(),
+			clearCmd(),
+			historyCmd(),
+			watchCmd(),
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if err := runClip(params, args, os.Stdin, os.Stdout); err != nil {
 				fmt.Fprintf(os.Stderr, "clip: %v\n", err)
@@ -40,17 +68,61 @@ Use -p or --paste to paste content from the clipboard to standard output.`,
 	}.ToCobra()
 }
 
+func pasteCmd() *cobra.Command {
+	return boa.CmdT[PasteParams]{
+		Use:         "paste [index]",
+		Short:       "Write the clipboard, or an earlier copy, to standard output",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *PasteParams, cmd *cobra.Command, args []string) {
+			if err := runPaste(params, openStore(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "clip: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func clearCmd() *cobra.Command {
+	return boa.CmdT[ClearParams]{
+		Use:         "clear",
+		Short:       "Empty the clipboard",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *ClearParams, cmd *cobra.Command, args []string) {
+			if err := clipboardWriteAll(""); err != nil {
+				fmt.Fprintf(os.Stderr, "clip: failed to clear clipboard: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func historyCmd() *cobra.Command {
+	return boa.CmdT[HistoryParams]{
+		Use:   "history [index]",
+		Short: "Pick an earlier copy from the clip history",
+		Long: `Show the clip history in an interactive picker. Type to search, and press
+enter to copy the selected entry to the clipboard again.
+
+Nothing is recorded until the history is enabled with --enable. The history
+keeps the last 100 copies of up to 1 MiB each, or as many as set with --size,
+in the tofu config directory.`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *HistoryParams, cmd *cobra.Command, args []string) {
+			interactive := term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+			if err := runHistory(params, openStore(), interactive, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "clip: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
 func runClip(params *Params, args []string, stdin io.Reader, stdout io.Writer) error {
 	if params.Paste {
 		if len(args) > 0 {
 			return fmt.Errorf("cannot use arguments with --paste")
 		}
-		text, err := clipboardReadAll()
-		if err != nil {
-			return err
-		}
-		fmt.Fprint(stdout, text)
-		return nil
+		return runPaste(&PasteParams{}, openStore(), stdout)
 	}
 
 	// Copy mode
@@ -70,5 +142,149 @@ func runClip(params *Params, args []string, stdin io.Reader, stdout io.Writer) e
 		return fmt.Errorf("failed to write to clipboard: %w", err)
 	}
 
+	return recordCopy(openStore(), params.History, []byte(text))
+}
+
+// runPaste writes the clipboard to stdout, or with an index, a history entry,
+// which is also copied to the clipboard again.
+func runPaste(params *PasteParams, store *historyStore, stdout io.Writer) error {
+	if params.Index != 0 {
+		entry, err := store.entry(params.Index)
+		if err != nil {
+			return err
+		}
+		if err := copyEntry(store, entry); err != nil {
+			return err
+		}
+		_, err = stdout.Write(entry.Data)
+		return err
+	}
+
+	text, err := clipboardReadAll()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, text)
+	return err
+}
+
+// recordCopy adds a copy to the history if it is enabled, or force is set.
+func recordCopy(store *historyStore, force bool, data []byte) error {
+	if !force {
+		cfg, err := store.loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to read clip settings: %w", err)
+		}
+		if !cfg.History {
+			return nil
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) > maxHistoryEntrySize {
+		fmt.Fprintf(os.Stderr, "clip: not recorded in history, larger than %s\n", formatSize(maxHistoryEntrySize))
+		return nil
+	}
+	if err := store.add(data, time.Now()); err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
 	return nil
 }
+
+func runHistory(params *HistoryParams, store *historyStore, interactive bool, stdout io.Writer) error {
+	if params.Enable && params.Disable {
+		return fmt.Errorf("--enable and --disable are mutually exclusive")
+	}
+	if params.Size < 0 || params.Size > historySizeLimit {
+		return fmt.Errorf("--size must be between 1 and %d", historySizeLimit)
+	}
+	if params.Enable || params.Disable || params.Size > 0 {
+		cfg, err := store.loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to read clip settings: %w", err)
+		}
+		if params.Enable || params.Disable {
+			cfg.History = params.Enable
+		}
+		if params.Size > 0 {
+			cfg.Size = params.Size
+		}
+		if err := store.saveConfig(cfg); err != nil {
+			return fmt.Errorf("failed to save clip settings: %w", err)
+		}
+		if params.Enable {
+			fmt.Fprintln(stdout, "Clip history enabled")
+		} else if params.Disable {
+			fmt.Fprintln(stdout, "Clip history disabled")
+		}
+	}
+	if params.Size > 0 {
+		// A smaller size applies to the copies already recorded
+		entries, err := store.load()
+		if err == nil && len(entries) > 0 {
+			err = store.save(entries)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to resize history: %w", err)
+		}
+		fmt.Fprintf(stdout, "Clip history keeps the last %d copies\n", params.Size)
+	}
+	if params.Clear {
+		if err := store.clear(); err != nil {
+			return fmt.Errorf("failed to clear history: %w", err)
+		}
+		fmt.Fprintln(stdout, "Clip history cleared")
+	}
+	if params.Enable || params.Disable || params.Size > 0 || params.Clear {
+		return nil
+	}
+
+	if params.Index != 0 {
+		entry, err := store.entry(params.Index)
+		if err != nil {
+			return err
+		}
+		return copyEntry(store, entry)
+	}
+
+	entries, err := store.load()
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+	// Newest first, so that index 1 is the most recent copy
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	if len(entries) == 0 {
+		cfg, err := store.loadConfig()
+		if err == nil && !cfg.History {
+			fmt.Fprintln(stdout, "Clip history is empty. Enable it with: tofu clip history --enable")
+		} else {
+			fmt.Fprintln(stdout, "Clip history is empty")
+		}
+		return nil
+	}
+
+	v := newView(entries)
+	if params.List || !interactive {
+		_, err := io.WriteString(stdout, v.render(120, 0, false))
+		return err
+	}
+
+	entry, ok, err := pick(v)
+	if err != nil || !ok {
+		return err
+	}
+	return copyEntry(store, entry)
+}
+
+// copyEntry copies a history entry to the clipboard again, moving it to the
+// top of the history while the history is enabled.
+func copyEntry(store *historyStore, entry historyEntry) error {
+	if err := clipboardWriteAll(string(entry.Data)); err != nil {
+		return fmt.Errorf("failed to write to clipboard: %w", err)
+	}
+	return recordCopy(store, false, entry.Data)
+}
@@ -0,0 +1,154 @@
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+const (
+	// maxHistoryEntries is how many copies are kept by default, oldest
+	// dropped first.
+	maxHistoryEntries = 100
+	// historySizeLimit bounds --size, keeping the history file a manageable
+	// size even with large copies.
+	historySizeLimit = 1000
+	// maxHistoryEntrySize is the largest copy recorded. Larger copies still
+	// reach the clipboard but are left out of the history.
+	maxHistoryEntrySize = 1 << 20
+)
+
+// historyEntry is one recorded copy. Data is a []byte so it is stored
+// base64-encoded, keeping binary content intact.
+type historyEntry struct {
+	Time time.Time `json:"time"`
+	Data []byte    `json:"data"`
+}
+
+// clipConfig holds persistent clip settings.
+type clipConfig struct {
+	History bool `json:"history"`
+	// Size is how many copies the history keeps, or 0 for maxHistoryEntries.
+	Size int `json:"size,omitempty"`
+}
+
+func (c clipConfig) historySize() int {
+	if c.Size > 0 {
+		return c.Size
+	}
+	return maxHistoryEntries
+}
+
+// historyStore reads and writes the history and settings files. Tests point
+// it at a temporary directory.
+type historyStore struct {
+	dir string
+}
+
+func defaultStore() *historyStore {
+	return &historyStore{dir: common.ConfigDir()}
+}
+
+func (s *historyStore) configPath() string  { return filepath.Join(s.dir, "clip.json") }
+func (s *historyStore) historyPath() string { return filepath.Join(s.dir, "clip_history.json") }
+
+func (s *historyStore) loadConfig() (clipConfig, error) {
+	var cfg clipConfig
+	_, err := common.ReadJSONFile(s.configPath(), &cfg)
+	return cfg, err
+}
+
+func (s *historyStore) saveConfig(cfg clipConfig) error {
+	return common.WriteJSONFile(s.configPath(), cfg)
+}
+
+// load returns the recorded copies, oldest first.
+func (s *historyStore) load() ([]historyEntry, error) {
+	var entries []historyEntry
+	_, err := common.ReadJSONFile(s.historyPath(), &entries)
+	return entries, err
+}
+
+// add records a copy, dropping the oldest entries beyond the history size.
+// Copying the same content again moves it to the end instead of duplicating it.
+func (s *historyStore) add(data []byte, now time.Time) error {
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	entries = removeEntry(entries, data)
+	entries = append(entries, historyEntry{Time: now, Data: data})
+	return s.save(entries)
+}
+
+// save writes entries, keeping only the newest that fit the history size.
+func (s *historyStore) save(entries []historyEntry) error {
+	cfg, err := s.loadConfig()
+	if err != nil {
+		return err
+	}
+	if size := cfg.historySize(); len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+	// Written private to the user, as copies may hold passwords
+	return common.WriteJSONFile(s.historyPath(), entries)
+}
+
+// entry returns the copy at index, 1 being the most recent.
+func (s *historyStore) entry(index int) (historyEntry, error) {
+	entries, err := s.load()
+	if err != nil {
+		return historyEntry{}, fmt.Errorf("failed to read history: %w", err)
+	}
+	if index < 1 || index > len(entries) {
+		return historyEntry{}, fmt.Errorf("no history entry %d (have %d)", index, len(entries))
+	}
+	return entries[len(entries)-index], nil
+}
+
+func (s *historyStore) clear() error {
+	err := os.Remove(s.historyPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func removeEntry(entries []historyEntry, data []byte) []historyEntry {
+	result := entries[:0]
+	for _, e := range entries {
+		if string(e.Data) != string(data) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// preview renders an entry on a single line, at most width runes wide.
+func (e historyEntry) preview(width int) string {
+	if !utf8.Valid(e.Data) || strings.ContainsRune(string(e.Data), 0) {
+		return "<binary, " + formatSize(len(e.Data)) + ">"
+	}
+	text := strings.Join(strings.Fields(string(e.Data)), " ")
+	if r := []rune(text); len(r) > width {
+		text = string(r[:width-1]) + "…"
+	}
+	return text
+}
+
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
@@ -0,0 +1,372 @@
+package clip
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+// TestMain keeps tests away from the real history in the config directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "clip-test")
+	if err != nil {
+		panic(err)
+	}
+	openStore = func() *historyStore { return &historyStore{dir: dir} }
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// mockClipboard replaces the clipboard for the duration of a test.
+func mockClipboard(t *testing.T) *string {
+	var content string
+	originalWrite, originalRead := clipboardWriteAll, clipboardReadAll
+	clipboardWriteAll = func(text string) error { content = text; return nil }
+	clipboardReadAll = func() (string, error) { return content, nil }
+	t.Cleanup(func() { clipboardWriteAll, clipboardReadAll = originalWrite, originalRead })
+	return &content
+}
+
+func TestRecordCopy_OnlyWhenEnabled(t *testing.T) {
+	store := &historyStore{dir: t.TempDir()}
+
+	if err := recordCopy(store, false, []byte("secret")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(store.historyPath()); !os.IsNotExist(err) {
+		t.Fatalf("Expected no history file while disabled, got %v", err)
+	}
+
+	// --history records a single copy without enabling it
+	if err := recordCopy(store, true, []byte("once")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg, _ := store.loadConfig(); cfg.History {
+		t.Error("Expected --history to leave the setting disabled")
+	}
+
+	if err := runHistory(&HistoryParams{Enable: true}, store, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := recordCopy(store, false, []byte("twice")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err := store.load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 2 || string(entries[0].Data) != "once" || string(entries[1].Data) != "twice" {
+		t.Errorf("Expected [once twice], got %v", entries)
+	}
+}
+
+func TestHistoryStore_AddBoundedAndDeduplicated(t *testing.T) {
+	store := &historyStore{dir: t.TempDir()}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range maxHistoryEntries + 5 {
+		if err := store.add([]byte(strings.Repeat("x", i+1)), start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	entries, _ := store.load()
+	if len(entries) != maxHistoryEntries {
+		t.Fatalf("Expected %d entries, got %d", maxHistoryEntries, len(entries))
+	}
+	if len(entries[0].Data) != 6 {
+		t.Errorf("Expected the oldest 5 entries dropped, first is %d bytes", len(entries[0].Data))
+	}
+
+	// Copying existing content again moves it to the end
+	if err := store.add([]byte("xxxxxx"), start.Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	entries, _ = store.load()
+	if len(entries) != maxHistoryEntries || string(entries[len(entries)-1].Data) != "xxxxxx" || len(entries[0].Data) != 7 {
+		t.Errorf("Expected xxxxxx moved to the end without duplication")
+	}
+}
+
+func TestRecordCopy_BinaryAndSizeCap(t *testing.T) {
+	store := &historyStore{dir: t.TempDir()}
+	binary := []byte{0x00, 0xff, 0xfe, 'a', '\n', 0x80}
+
+	if err := recordCopy(store, true, binary); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := recordCopy(store, true, make([]byte, maxHistoryEntrySize+1)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, _ := store.load()
+	if len(entries) != 1 {
+		t.Fatalf("Expected only the binary entry, got %d entries", len(entries))
+	}
+	if !bytes.Equal(entries[0].Data, binary) {
+		t.Errorf("Expected %v, got %v", binary, entries[0].Data)
+	}
+	if p := entries[0].preview(40); p != "<binary, 6 bytes>" {
+		t.Errorf("Expected binary preview, got %q", p)
+	}
+}
+
+func TestRunClip_RecordsWhenEnabled(t *testing.T) {
+	mockClipboard(t)
+	store := openStore()
+	t.Cleanup(func() {
+		_ = store.saveConfig(clipConfig{})
+		_ = store.clear()
+	})
+
+	if err := runClip(&Params{}, []string{"not", "recorded"}, strings.NewReader(""), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if entries, _ := store.load(); len(entries) != 0 {
+		t.Fatalf("Expected no history while disabled, got %d entries", len(entries))
+	}
+
+	_ = store.saveConfig(clipConfig{History: true})
+	if err := runClip(&Params{}, nil, strings.NewReader("from stdin"), &bytes.Buffer{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	entries, _ := store.load()
+	if len(entries) != 1 || string(entries[0].Data) != "from stdin" {
+		t.Errorf("Expected [from stdin], got %v", entries)
+	}
+}
+
+func TestRunHistory_IndexAndList(t *testing.T) {
+	clipboard := mockClipboard(t)
+	store := &historyStore{dir: t.TempDir()}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	_ = store.saveConfig(clipConfig{History: true})
+	for i, text := range []string{"first", "second", "third"} {
+		_ = store.add([]byte(text), start.Add(time.Duration(i)*time.Minute))
+	}
+
+	var stdout bytes.Buffer
+	if err := runHistory(&HistoryParams{}, store, false, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 6 || !strings.HasSuffix(lines[3], "third") || !strings.HasSuffix(lines[5], "first") {
+		t.Errorf("Expected newest first listing, got:\n%s", stdout.String())
+	}
+
+	if err := runHistory(&HistoryParams{Index: 3}, store, false, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *clipboard != "first" {
+		t.Errorf("Expected %q, got %q", "first", *clipboard)
+	}
+	entries, _ := store.load()
+	if string(entries[len(entries)-1].Data) != "first" {
+		t.Error("Expected the re-copied entry to become the most recent")
+	}
+
+	if err := runHistory(&HistoryParams{Index: 4}, store, false, &stdout); err == nil {
+		t.Error("Expected error for out of range index")
+	}
+
+	stdout.Reset()
+	if err := runHistory(&HistoryParams{Clear: true}, store, false, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if entries, _ := store.load(); len(entries) != 0 {
+		t.Errorf("Expected empty history after --clear, got %d entries", len(entries))
+	}
+}
+
+func TestRunHistory_IndexWhileDisabled(t *testing.T) {
+	clipboard := mockClipboard(t)
+	store := &historyStore{dir: t.TempDir()}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	_ = store.saveConfig(clipConfig{History: true})
+	for i, text := range []string{"first", "second"} {
+		_ = store.add([]byte(text), start.Add(time.Duration(i)*time.Minute))
+	}
+	if err := runHistory(&HistoryParams{Disable: true}, store, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Copying an old entry again still works, but isn't recorded
+	if err := runHistory(&HistoryParams{Index: 2}, store, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *clipboard != "first" {
+		t.Errorf("Expected %q, got %q", "first", *clipboard)
+	}
+	entries, _ := store.load()
+	if len(entries) != 2 || string(entries[0].Data) != "first" || !entries[0].Time.Equal(start) {
+		t.Errorf("Expected the history unchanged while disabled, got %v", entries)
+	}
+}
+
+func TestRunPaste_Index(t *testing.T) {
+	clipboard := mockClipboard(t)
+	store := &historyStore{dir: t.TempDir()}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	_ = store.saveConfig(clipConfig{History: true})
+	for i, text := range []string{"first", "second", "third"} {
+		_ = store.add([]byte(text), start.Add(time.Duration(i)*time.Minute))
+	}
+	*clipboard = "current"
+
+	var stdout bytes.Buffer
+	if err := runPaste(&PasteParams{}, store, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stdout.String() != "current" {
+		t.Errorf("Expected %q, got %q", "current", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := runPaste(&PasteParams{Index: 2}, store, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stdout.String() != "second" || *clipboard != "second" {
+		t.Errorf("Expected %q pasted and copied, got %q and %q", "second", stdout.String(), *clipboard)
+	}
+	if e, _ := store.entry(1); string(e.Data) != "second" {
+		t.Errorf("Expected the pasted entry to become the most recent, got %q", e.Data)
+	}
+
+	if err := runPaste(&PasteParams{Index: 4}, store, &stdout); err == nil {
+		t.Error("Expected error for out of range index")
+	}
+}
+
+func TestRunHistory_Size(t *testing.T) {
+	store := &historyStore{dir: t.TempDir()}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	_ = store.saveConfig(clipConfig{History: true})
+	for i := range 5 {
+		_ = store.add([]byte(strings.Repeat("x", i+1)), start.Add(time.Duration(i)*time.Minute))
+	}
+
+	var stdout bytes.Buffer
+	if err := runHistory(&HistoryParams{Size: 3}, store, false, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "Clip history keeps the last 3 copies\n"; stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+	if cfg, _ := store.loadConfig(); !cfg.History || cfg.Size != 3 {
+		t.Errorf("Expected history still enabled with size 3, got %+v", cfg)
+	}
+	entries, _ := store.load()
+	if len(entries) != 3 || string(entries[0].Data) != "xxx" {
+		t.Fatalf("Expected the newest 3 entries kept, got %d", len(entries))
+	}
+
+	_ = store.add([]byte("new"), start.Add(time.Hour))
+	if entries, _ := store.load(); len(entries) != 3 || string(entries[2].Data) != "new" {
+		t.Errorf("Expected the size to apply to new copies, got %d entries", len(entries))
+	}
+
+	// Disabling keeps the size
+	if err := runHistory(&HistoryParams{Disable: true}, store, false, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg, _ := store.loadConfig(); cfg.History || cfg.Size != 3 {
+		t.Errorf("Expected history disabled with size 3, got %+v", cfg)
+	}
+
+	for _, size := range []int{-1, historySizeLimit + 1} {
+		if err := runHistory(&HistoryParams{Size: size}, store, false, &stdout); err == nil {
+			t.Errorf("Expected error for --size %d", size)
+		}
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	store := &historyStore{dir: t.TempDir()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Each poll reads the next value, the first being the clipboard at start
+	values := []string{"before", "before", "one", "one", "", "two\n", "one", "one"}
+	originalRead := clipboardReadAll
+	clipboardReadAll = func() (string, error) {
+		if len(values) == 0 {
+			cancel()
+			return "one", nil
+		}
+		v := values[0]
+		values = values[1:]
+		return v, nil
+	}
+	t.Cleanup(func() { clipboardReadAll = originalRead })
+
+	var stdout, stderr bytes.Buffer
+	if err := runWatch(ctx, &WatchParams{Interval: 0.001, Echo: true}, store, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "one\ntwo\none\n"; stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+	if !strings.HasSuffix(stderr.String(), "Recorded 3 copies\n") {
+		t.Errorf("Expected a summary, got %q", stderr.String())
+	}
+
+	// The history keeps distinct copies, the latest last
+	entries, _ := store.load()
+	var got []string
+	for _, e := range entries {
+		got = append(got, string(e.Data))
+	}
+	if strings.Join(got, "|") != "two\n|one" {
+		t.Errorf("Expected [two one], got %q", got)
+	}
+
+	if err := runWatch(ctx, &WatchParams{Interval: 0}, store, &stdout, &stderr); err == nil {
+		t.Error("Expected error for a zero interval")
+	}
+}
+
+func TestView_Keys(t *testing.T) {
+	v := newView([]historyEntry{
+		{Data: []byte("kubectl get pods")},
+		{Data: []byte("git status")},
+		{Data: []byte("kubectl logs")},
+	})
+
+	for _, k := range []common.Key{"K", "u", "b"} {
+		v.handleKey(k)
+	}
+	if len(v.matches) != 2 {
+		t.Fatalf("Expected 2 case-insensitive matches, got %d", len(v.matches))
+	}
+	v.handleKey(common.KeyDown)
+	if e, _ := v.selectedEntry(); string(e.Data) != "kubectl logs" {
+		t.Errorf("Expected %q selected, got %q", "kubectl logs", e.Data)
+	}
+	v.handleKey(common.KeyDown)
+	if e, _ := v.selectedEntry(); string(e.Data) != "kubectl logs" {
+		t.Errorf("Expected selection to stop at the last match, got %q", e.Data)
+	}
+
+	v.handleKey("x")
+	if _, ok := v.selectedEntry(); ok || v.handleKey(common.KeyEnter) != actionNone {
+		t.Error("Expected nothing to pick without matches")
+	}
+	v.handleKey(common.KeyBackspace)
+	if v.handleKey(common.KeyEnter) != actionPick {
+		t.Error("Expected enter to pick")
+	}
+
+	// esc clears the search first, then quits
+	if v.handleKey(common.KeyEsc) != actionNone || v.filter != "" || len(v.matches) != 3 {
+		t.Error("Expected esc to clear the search")
+	}
+	if v.handleKey(common.KeyEsc) != actionQuit {
+		t.Error("Expected esc to quit with an empty search")
+	}
+}
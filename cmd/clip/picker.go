@@ -0,0 +1,184 @@
+package clip
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gigurra/tofu/cmd/common"
+	"golang.org/x/term"
+)
+
+type action int
+
+const (
+	actionNone action = iota
+	actionQuit        // exit without picking
+	actionPick        // copy the selected entry and exit
+)
+
+// view holds the interactive state of the history picker. Entries are
+// newest first; typed characters narrow them down.
+type view struct {
+	entries  []historyEntry
+	filter   string
+	matches  []int // indexes into entries that match the filter
+	selected int   // index into matches
+}
+
+func newView(entries []historyEntry) *view {
+	v := &view{entries: entries}
+	v.applyFilter()
+	return v
+}
+
+func (v *view) applyFilter() {
+	v.matches = v.matches[:0]
+	needle := strings.ToLower(v.filter)
+	for i, e := range v.entries {
+		if needle == "" || strings.Contains(strings.ToLower(string(e.Data)), needle) {
+			v.matches = append(v.matches, i)
+		}
+	}
+	v.selected = max(0, min(v.selected, len(v.matches)-1))
+}
+
+// selectedEntry returns the selected entry, if any entry matches the filter.
+func (v *view) selectedEntry() (historyEntry, bool) {
+	if v.selected < len(v.matches) {
+		return v.entries[v.matches[v.selected]], true
+	}
+	return historyEntry{}, false
+}
+
+func (v *view) handleKey(k common.Key) action {
+	switch k {
+	case common.KeyCtrlC:
+		return actionQuit
+	case common.KeyEsc:
+		if v.filter == "" {
+			return actionQuit
+		}
+		v.filter = ""
+		v.applyFilter()
+	case common.KeyUp:
+		if v.selected > 0 {
+			v.selected--
+		}
+	case common.KeyDown:
+		if v.selected < len(v.matches)-1 {
+			v.selected++
+		}
+	case common.KeyEnter:
+		if _, ok := v.selectedEntry(); ok {
+			return actionPick
+		}
+	case common.KeyBackspace:
+		if v.filter != "" {
+			v.filter = v.filter[:len(v.filter)-1]
+			v.applyFilter()
+		}
+	default:
+		v.filter += string(k)
+		v.applyFilter()
+	}
+	return actionNone
+}
+
+// render draws the full screen, limited to height lines and width columns.
+// A height of 0 renders every entry, without the search and help lines.
+// Lines are separated by "\n"; the caller adapts them for raw mode terminals.
+func (v *view) render(width, height int, interactive bool) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Clipboard history (%d entries)\n", len(v.entries))
+	if interactive {
+		fmt.Fprintf(&sb, "Search: %s█\n", v.filter)
+		sb.WriteString("↑/↓: select  enter: copy  esc: clear search/quit\n")
+	}
+	sb.WriteString("\n")
+	used := strings.Count(sb.String(), "\n")
+
+	const timeFormat = "2006-01-02 15:04"
+	// Index and time columns, and the gaps between columns
+	previewWidth := max(10, width-len(strconv.Itoa(len(v.entries)))-len(timeFormat)-6)
+
+	table := [][]string{{"#", "COPIED", "CONTENT"}}
+	for _, i := range v.matches {
+		e := v.entries[i]
+		table = append(table, []string{strconv.Itoa(i + 1), e.Time.Local().Format(timeFormat), e.preview(previewWidth)})
+	}
+
+	space := len(v.matches)
+	if height > 0 {
+		space = max(1, height-used-1)
+	}
+	offset := 0
+	if v.selected >= space {
+		offset = v.selected - space + 1
+	}
+	selected := -1
+	if interactive {
+		selected = v.selected
+	}
+	common.WriteTable(&sb, table, selected, offset, space)
+	return sb.String()
+}
+
+// pick shows the picker on the terminal and returns the chosen entry.
+func pick(v *view) (historyEntry, bool, error) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return historyEntry{}, false, fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+
+	// Alternate screen, hidden cursor
+	fmt.Print("\033[?1049h\033[?25l")
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	keyCh := make(chan []common.Key)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			if n > 0 {
+				keyCh <- common.DecodeKeys(buf[:n])
+			}
+		}
+	}()
+
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		out := strings.ReplaceAll(v.render(width, height, true), "\n", "\033[K\r\n")
+		fmt.Print("\033[?7l\033[H" + out + "\033[J\033[?7h")
+
+		select {
+		case <-sigCh:
+			return historyEntry{}, false, nil
+		case keys := <-keyCh:
+			for _, k := range keys {
+				switch v.handleKey(k) {
+				case actionQuit:
+					return historyEntry{}, false, nil
+				case actionPick:
+					e, _ := v.selectedEntry()
+					return e, true, nil
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package clip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type WatchParams struct {
+	Interval float64 `short:"i" optional:"true" help:"Check the clipboard every N seconds." default:"0.5"`
+	Echo     bool    `short:"e" help:"Also write each new copy to standard output, one per line."`
+}
+
+func watchCmd() *cobra.Command {
+	return boa.CmdT[WatchParams]{
+		Use:   "watch",
+		Short: "Record every change of the clipboard in the history",
+		Long: `Watch the clipboard and record each new copy in the clip history, until
+stopped with Ctrl+C. This works whether or not the history is enabled, and
+collects copies made in any application.
+
+Copying the same content twice in a row is recorded once.`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *WatchParams, cmd *cobra.Command, args []string) {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err := runWatch(ctx, params, openStore(), os.Stdout, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "clip: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+// runWatch polls the clipboard until ctx is done, adding each change to the
+// history as soon as it is seen. What is on the clipboard when it starts was
+// copied before, so it isn't recorded.
+func runWatch(ctx context.Context, params *WatchParams, store *historyStore, stdout, stderr io.Writer) error {
+	if params.Interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	last, err := clipboardReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	fmt.Fprintln(stderr, "Watching the clipboard, press Ctrl+C to stop")
+
+	ticker := time.NewTicker(time.Duration(params.Interval * float64(time.Second)))
+	defer ticker.Stop()
+	recorded := 0
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(stderr, "Recorded %d copies\n", recorded)
+			return nil
+		case <-ticker.C:
+		}
+
+		text, err := clipboardReadAll()
+		if err != nil {
+			return fmt.Errorf("failed to read clipboard: %w", err)
+		}
+		if text == last {
+			continue
+		}
+		last = text
+		if text == "" {
+			continue
+		}
+		if len(text) > maxHistoryEntrySize {
+			fmt.Fprintf(stderr, "clip: not recorded in history, larger than %s\n", formatSize(maxHistoryEntrySize))
+			continue
+		}
+		if err := store.add([]byte(text), time.Now()); err != nil {
+			return fmt.Errorf("failed to record history: %w", err)
+		}
+		recorded++
+		if params.Echo {
+			if !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+			if _, err := io.WriteString(stdout, text); err != nil {
+				return err
+			}
+		}
+	}
+}
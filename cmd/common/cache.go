@@ -0,0 +1,41 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileCache stores data on disk, one file per key, so commands that fetch
+// from the network can reuse responses across invocations. Entries older
+// than TTL count as missing.
+type FileCache struct {
+	Dir string
+	TTL time.Duration
+}
+
+// Path is the file the entry for key is stored in. Keys are hashed, so any
+// string, like a URL, can be used.
+func (c *FileCache) Path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+}
+
+func (c *FileCache) Get(key string) ([]byte, bool) {
+	p := c.Path(key)
+	info, err := os.Stat(p)
+	if err != nil || time.Since(info.ModTime()) > c.TTL {
+		return nil, false
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (c *FileCache) Put(key string, data []byte) error {
+	return WriteFileAtomic(c.Path(key), data)
+}
@@ -0,0 +1,32 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCache(t *testing.T) {
+	cache := &FileCache{Dir: filepath.Join(t.TempDir(), "sub"), TTL: time.Minute}
+	if _, ok := cache.Get("https://example.com/a"); ok {
+		t.Error("Expected a miss for an empty cache")
+	}
+	if err := cache.Put("https://example.com/a", []byte("a")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, ok := cache.Get("https://example.com/a"); !ok || string(data) != "a" {
+		t.Errorf("Expected cached %q, got %q (%v)", "a", data, ok)
+	}
+	if _, ok := cache.Get("https://example.com/b"); ok {
+		t.Error("Expected a miss for another key")
+	}
+
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(cache.Path("https://example.com/a"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("https://example.com/a"); ok {
+		t.Error("Expected a miss for an expired entry")
+	}
+}
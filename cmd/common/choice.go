@@ -0,0 +1,103 @@
+package common
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// Choice is a value to pick at random, with its relative weight.
+type Choice struct {
+	Value  string
+	Weight float64
+}
+
+// ParseChoice reads a value:weight argument. An argument without a numeric
+// weight after its last ':' is all value, with weight 1, so values like URLs
+// or 12:30 still work.
+func ParseChoice(arg string) (Choice, error) {
+	c := Choice{Value: arg, Weight: 1}
+	if i := strings.LastIndex(arg, ":"); i >= 0 {
+		if w, err := strconv.ParseFloat(arg[i+1:], 64); err == nil {
+			if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+				return c, fmt.Errorf("invalid weight in %q, must be a non-negative number", arg)
+			}
+			c = Choice{Value: arg[:i], Weight: w}
+		}
+	}
+	return c, nil
+}
+
+// SampleChoices picks count values, each with a probability proportional to
+// its weight. With unique, picked values are taken out of the running.
+func SampleChoices(rng *rand.Rand, choices []Choice, count int, unique bool) ([]string, error) {
+	available, total, uniform := 0, 0.0, true
+	for _, c := range choices {
+		uniform = uniform && c.Weight == 1
+		if c.Weight > 0 {
+			available++
+			total += c.Weight
+		}
+	}
+	if available == 0 {
+		return nil, fmt.Errorf("at least one value needs a weight above 0")
+	}
+	if unique && count > available {
+		return nil, fmt.Errorf("cannot pick %d unique values from %d with a weight above 0", count, available)
+	}
+
+	picked := make([]string, 0, count)
+	if uniform {
+		// All weights are 1, so large lists can be shuffled in linear time
+		order := make([]int, len(choices))
+		for i := range order {
+			order[i] = i
+		}
+		for k := range count {
+			if !unique {
+				picked = append(picked, choices[rng.IntN(len(choices))].Value)
+				continue
+			}
+			j := k + rng.IntN(len(order)-k)
+			order[k], order[j] = order[j], order[k]
+			picked = append(picked, choices[order[k]].Value)
+		}
+		return picked, nil
+	}
+
+	choices = append([]Choice(nil), choices...)
+	for range count {
+		target := rng.Float64() * total
+		i := -1
+		for j, c := range choices {
+			if c.Weight == 0 {
+				continue
+			}
+			i = j
+			if target < c.Weight {
+				break
+			}
+			target -= c.Weight
+		}
+		picked = append(picked, choices[i].Value)
+		if unique {
+			total -= choices[i].Weight
+			choices[i].Weight = 0
+		}
+	}
+	return picked, nil
+}
+
+// CryptoSource is a math/rand source backed by crypto/rand, for picks that
+// must not be predictable.
+type CryptoSource struct{}
+
+func (CryptoSource) Uint64() uint64 {
+	var b [8]byte
+	_, _ = crand.Read(b[:]) // never fails, see crypto/rand.Read
+	return binary.LittleEndian.Uint64(b[:])
+}
@@ -0,0 +1,57 @@
+package common
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestParseChoice(t *testing.T) {
+	var got []Choice
+	for _, arg := range []string{"control:70", "variant:0.5", "plain", "https://example.com", "12:30", "zero:0"} {
+		c, err := ParseChoice(arg)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", arg, err)
+		}
+		got = append(got, c)
+	}
+	want := []Choice{{"control", 70}, {"variant", 0.5}, {"plain", 1}, {"https://example.com", 1}, {"12", 30}, {"zero", 0}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	for _, arg := range []string{"a:-1", "a:NaN", "a:+Inf"} {
+		if _, err := ParseChoice(arg); err == nil {
+			t.Errorf("Expected error for %q", arg)
+		}
+	}
+}
+
+func TestSampleChoices(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	choices := []Choice{{"a", 1}, {"b", 100}, {"never", 0}}
+	picked, err := SampleChoices(rng, choices, 2, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	slices.Sort(picked)
+	if !slices.Equal(picked, []string{"a", "b"}) {
+		t.Errorf("Expected a and b, got %q", picked)
+	}
+	if choices[0].Weight != 1 {
+		t.Error("Expected the given choices to be left unchanged")
+	}
+
+	if _, err := SampleChoices(rng, []Choice{{"a", 0}, {"b", 0}}, 1, false); err == nil {
+		t.Error("Expected error when no value has a weight")
+	}
+	_, err = SampleChoices(rng, choices, 3, true)
+	if err == nil || err.Error() != "cannot pick 3 unique values from 2 with a weight above 0" {
+		t.Errorf("Expected a count error, got %v", err)
+	}
+
+	// Crypto randomness works as a source too
+	picked, err = SampleChoices(rand.New(CryptoSource{}), []Choice{{"x", 1}, {"y", 1}}, 2, true)
+	if err != nil || len(picked) != 2 || picked[0] == picked[1] {
+		t.Errorf("Expected x and y in some order, got %q (%v)", picked, err)
+	}
+}
@@ -1,7 +1,13 @@
 package common
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseSize_Bytes(t *testing.T) {
@@ -164,3 +170,55 @@ func TestParseSize_Invalid(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{5 * time.Minute, "5m"},
+		{3 * time.Hour, "3h"},
+		{47 * time.Hour, "47h"},
+		{72 * time.Hour, "3d"},
+	}
+	for _, tt := range tests {
+		if got := FormatAge(tt.d); got != tt.expected {
+			t.Errorf("FormatAge(%v): expected %q, got %q", tt.d, tt.expected, got)
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	n := 0
+	err := Resample(ctx, 20*time.Millisecond, &out, false, "--\n", func(w io.Writer) error {
+		n++
+		_, err := fmt.Fprintf(w, "sample %d\n", n)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "sample 1\n--\nsample 2\n") {
+		t.Errorf("Expected samples separated by --, got %q", out.String())
+	}
+
+	out.Reset()
+	err = Resample(ctx, time.Hour, &out, true, "--\n", func(w io.Writer) error {
+		_, err := io.WriteString(w, "screen\n")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out.String() != "\033[H\033[2Jscreen\n" {
+		t.Errorf("Expected one cleared screen after ctx is done, got %q", out.String())
+	}
+
+	want := fmt.Errorf("failed")
+	if err := Resample(context.Background(), time.Millisecond, &out, false, "", func(io.Writer) error { return want }); err != want {
+		t.Errorf("Expected %v, got %v", want, err)
+	}
+}
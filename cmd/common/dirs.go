@@ -9,6 +9,11 @@ func CacheDir() string {
 	return filepath.Join(cacheHome(), "tofu")
 }
 
+// ConfigDir is where tofu commands persist user settings.
+func ConfigDir() string {
+	return filepath.Join(configHome(), "tofu")
+}
+
 // https://specifications.freedesktop.org/basedir/latest/#variables
 func cacheHome() string {
 	dir := os.Getenv("XDG_CACHE_HOME")
@@ -21,3 +26,15 @@ func cacheHome() string {
 	}
 	return dir
 }
+
+func configHome() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return dir
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != content {
+			t.Errorf("Expected %q, got %q", content, data)
+		}
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the written file, got %d entries", len(entries))
+	}
+}
+
+func TestJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "state.json")
+	var got map[string]int
+	if found, err := ReadJSONFile(path, &got); found || err != nil {
+		t.Errorf("Expected a missing file to be not found without error, got %v, %v", found, err)
+	}
+
+	if err := WriteJSONFile(path, map[string]int{"laps": 3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if found, err := ReadJSONFile(path, &got); !found || err != nil || got["laps"] != 3 {
+		t.Errorf("Expected to read back laps=3, got %v (%v, %v)", got, found, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadJSONFile(path, &got); err == nil || !strings.Contains(err.Error(), "corrupt file") {
+		t.Errorf("Expected a corrupt file error, got %v", err)
+	}
+}
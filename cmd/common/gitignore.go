@@ -0,0 +1,166 @@
+package common
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitIgnore matches paths against .gitignore rules. Rules are loaded per
+// directory with LoadDir, typically while walking down from the root, so that
+// nested .gitignore files take precedence over their parents like in git.
+// All paths are relative to the root and use '/' as separator.
+type GitIgnore struct {
+	root  string
+	rules []gitIgnoreRule
+}
+
+type gitIgnoreRule struct {
+	base     string   // directory of the .gitignore file, "" for the root
+	segments []string // pattern split on '/'
+	anchored bool     // pattern is relative to base rather than matching at any depth
+	dirOnly  bool     // pattern had a trailing '/'
+	negate   bool     // pattern started with '!'
+}
+
+// NewGitIgnore creates an empty matcher for the tree rooted at root.
+func NewGitIgnore(root string) *GitIgnore {
+	return &GitIgnore{root: root}
+}
+
+// LoadDir reads the .gitignore file in relDir, if there is one, and adds its
+// rules. Parent directories should be loaded before their children.
+func (g *GitIgnore) LoadDir(relDir string) error {
+	relDir = cleanRelPath(relDir)
+	f, err := os.Open(filepath.Join(g.root, filepath.FromSlash(relDir), ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseGitIgnoreLine(scanner.Text(), relDir); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+	return scanner.Err()
+}
+
+// AddPatterns adds rules as if they were lines of a .gitignore file in relDir.
+func (g *GitIgnore) AddPatterns(relDir string, lines ...string) {
+	relDir = cleanRelPath(relDir)
+	for _, line := range lines {
+		if rule, ok := parseGitIgnoreLine(line, relDir); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+}
+
+// Ignored reports whether relPath is ignored. The last matching rule wins,
+// and a '!' rule re-includes a previously ignored path.
+func (g *GitIgnore) Ignored(relPath string, isDir bool) bool {
+	relPath = cleanRelPath(relPath)
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.matches(relPath, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func cleanRelPath(p string) string {
+	p = path.Clean(filepath.ToSlash(p))
+	if p == "." || p == "/" {
+		return ""
+	}
+	return strings.TrimPrefix(p, "/")
+}
+
+func parseGitIgnoreLine(line, base string) (gitIgnoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitIgnoreRule{}, false
+	}
+	// Trailing spaces are ignored unless escaped
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " ")
+	}
+
+	rule := gitIgnoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	// A slash anywhere but at the end anchors the pattern to base
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return gitIgnoreRule{}, false
+	}
+
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+func (r gitIgnoreRule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	rel := relPath
+	if r.base != "" {
+		var ok bool
+		if rel, ok = strings.CutPrefix(relPath, r.base+"/"); !ok {
+			return false
+		}
+	}
+
+	if !r.anchored {
+		// Patterns without a slash match the name at any depth
+		matched, _ := path.Match(r.segments[0], path.Base(rel))
+		return matched
+	}
+	return matchGlobSegments(r.segments, strings.Split(rel, "/"))
+}
+
+// matchGlobSegments matches path segments against pattern segments, where a
+// "**" segment matches zero or more path segments.
+func matchGlobSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			// Trailing "**" matches everything inside, but not the directory itself
+			return len(segments) > 0
+		}
+		for i := 0; i <= len(segments); i++ {
+			if matchGlobSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+		return false
+	}
+	return matchGlobSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitIgnore_Patterns(t *testing.T) {
+	g := NewGitIgnore(t.TempDir())
+	g.AddPatterns("",
+		"# comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/root-only.txt",
+		"docs/**/*.tmp",
+		"vendor/**",
+	)
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"app.log", false, true},
+		{"sub/dir/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"sub/build", true, true},
+		{"build", false, false}, // dir-only pattern
+		{"root-only.txt", false, true},
+		{"sub/root-only.txt", false, false},
+		{"docs/a.tmp", false, true},
+		{"docs/x/y/a.tmp", false, true},
+		{"other/a.tmp", false, false},
+		{"vendor", true, false},
+		{"vendor/lib.go", false, true},
+		{"main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := g.Ignored(tt.path, tt.isDir); got != tt.expected {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.expected)
+		}
+	}
+}
+
+func TestGitIgnore_NestedFilesTakePrecedence(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.txt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sub", ".gitignore"), []byte("!important.txt\n/local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGitIgnore(root)
+	if err := g.LoadDir(""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := g.LoadDir("sub"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Directories without a .gitignore are fine
+	if err := g.LoadDir("missing"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"notes.txt", true},
+		{"sub/notes.txt", true},
+		{"sub/important.txt", false},
+		{"important.txt", true}, // negation only applies below sub/
+		{"sub/local", true},
+		{"local", false},
+	}
+	for _, tt := range tests {
+		if got := g.Ignored(tt.path, false); got != tt.expected {
+			t.Errorf("Ignored(%q) = %v, want %v", tt.path, got, tt.expected)
+		}
+	}
+}
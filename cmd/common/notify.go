@@ -0,0 +1,52 @@
+package common
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Notify shows a desktop notification using the platform's own tools:
+// notify-send on Linux, osascript on macOS and PowerShell on Windows. It is
+// best effort and doesn't wait for the notification to be dismissed; an error
+// means the tool could not be started, e.g. on a headless machine.
+func Notify(title, message string) error {
+	name, args := notifyCommand(runtime.GOOS, title, message)
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+func notifyCommand(goos, title, message string) (string, []string) {
+	switch goos {
+	case "darwin":
+		script := "display notification " + appleScriptString(message) + " with title " + appleScriptString(title)
+		return "osascript", []string{"-e", script}
+	case "windows":
+		// A balloon tip only shows while its process is alive
+		script := strings.Join([]string{
+			"Add-Type -AssemblyName System.Windows.Forms",
+			"$n = New-Object System.Windows.Forms.NotifyIcon",
+			"$n.Icon = [System.Drawing.SystemIcons]::Information",
+			"$n.Visible = $true",
+			"$n.ShowBalloonTip(5000, " + powerShellString(title) + ", " + powerShellString(message) + ", 'Info')",
+			"Start-Sleep -Seconds 6",
+			"$n.Dispose()",
+		}, "; ")
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", script}
+	default:
+		return "notify-send", []string{title, message}
+	}
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,24 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNotifyCommand(t *testing.T) {
+	name, args := notifyCommand("linux", "Title", `it's "done"`)
+	if name != "notify-send" || len(args) != 2 || args[0] != "Title" || args[1] != `it's "done"` {
+		t.Errorf("Unexpected linux command: %s %q", name, args)
+	}
+
+	name, args = notifyCommand("darwin", "Title", `it's "done" \o/`)
+	want := `display notification "it's \"done\" \\o/" with title "Title"`
+	if name != "osascript" || len(args) != 2 || args[1] != want {
+		t.Errorf("Expected osascript -e %q, got %s %q", want, name, args)
+	}
+
+	name, args = notifyCommand("windows", "Title", `it's "done"`)
+	if name != "powershell" || !strings.Contains(args[len(args)-1], `ShowBalloonTip(5000, 'Title', 'it''s "done"', 'Info')`) {
+		t.Errorf("Unexpected windows command: %s %q", name, args)
+	}
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+)
+
+// Resample writes a sample to w right away and then every interval, until ctx
+// is done. With clear the screen is cleared before each sample, as on a
+// terminal, and otherwise between is written between samples, so piped output
+// keeps every sample. Each sample is written at once, so a slow sample doesn't
+// leave the screen half drawn.
+func Resample(ctx context.Context, interval time.Duration, w io.Writer, clear bool, between string, sample func(w io.Writer) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for i := 0; ; i++ {
+		var buf bytes.Buffer
+		if clear {
+			buf.WriteString("\033[H\033[2J")
+		} else if i > 0 {
+			buf.WriteString(between)
+		}
+		if err := sample(&buf); err != nil {
+			return err
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"strings"
+)
+
+// Key is a key press read from a raw mode terminal: a printable character,
+// or one of the named keys below.
+type Key string
+
+const (
+	KeyUp        Key = "up"
+	KeyDown      Key = "down"
+	KeyEnter     Key = "enter"
+	KeyEsc       Key = "esc"
+	KeyBackspace Key = "backspace"
+	KeyCtrlC     Key = "ctrl+c"
+)
+
+// DecodeKeys splits one read from a raw mode terminal into keys. Terminals
+// send escape sequences such as arrow keys in a single write.
+func DecodeKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		switch {
+		case len(b) >= 3 && b[0] == 27 && (b[1] == '[' || b[1] == 'O'):
+			switch b[2] {
+			case 'A':
+				keys = append(keys, KeyUp)
+			case 'B':
+				keys = append(keys, KeyDown)
+			}
+			b = b[3:]
+			continue
+		case b[0] == 27:
+			keys = append(keys, KeyEsc)
+		case b[0] == 13 || b[0] == 10:
+			keys = append(keys, KeyEnter)
+		case b[0] == 127 || b[0] == 8:
+			keys = append(keys, KeyBackspace)
+		case b[0] == 3:
+			keys = append(keys, KeyCtrlC)
+		case b[0] >= 32 && b[0] < 127:
+			keys = append(keys, Key(b[:1]))
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// WriteTable writes the header row and up to limit data rows starting at
+// offset, padding columns to equal width. The selected data row (an index
+// into all data rows, -1 for none) is shown in reverse video.
+func WriteTable(sb *strings.Builder, table [][]string, selected, offset, limit int) {
+	widths := make([]int, len(table[0]))
+	for _, row := range table {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	format := func(row []string) string {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell + strings.Repeat(" ", widths[i]-len([]rune(cell)))
+		}
+		return strings.TrimRight(strings.Join(cells, "   "), " ")
+	}
+
+	sb.WriteString(format(table[0]) + "\n")
+	data := table[1:]
+	for i := offset; i < len(data) && i < offset+limit; i++ {
+		line := format(data[i])
+		if i == selected {
+			line = "\033[7m" + line + "\033[0m"
+		}
+		sb.WriteString(line + "\n")
+	}
+}
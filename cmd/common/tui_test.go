@@ -0,0 +1,35 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	got := DecodeKeys([]byte("\x1b[Aj\x1b[B\r/x\x7f\x1b\x03"))
+	expected := []Key{KeyUp, "j", KeyDown, KeyEnter, "/", "x", KeyBackspace, KeyEsc, KeyCtrlC}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Key %d: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	table := [][]string{
+		{"NAME", "CPU"},
+		{"web", "1000m"},
+		{"api-server", "5m"},
+		{"cron", "0m"},
+	}
+
+	var sb strings.Builder
+	WriteTable(&sb, table, 1, 1, 1)
+	expected := "NAME         CPU\n\033[7mapi-server   5m\033[0m\n"
+	if sb.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, sb.String())
+	}
+}
@@ -2,24 +2,30 @@ package count
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
+	"github.com/rivo/uniseg"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 type Params struct {
 	Files      []string `pos:"true" optional:"true" help:"Files to count. If none specified or -, read from standard input." default:"-"`
 	Lines      bool     `short:"l" help:"Print the line count." optional:"true"`
 	Words      bool     `short:"w" help:"Print the word count." optional:"true"`
-	Chars      bool     `short:"c" help:"Print the character count." optional:"true"`
-	Bytes      bool     `short:"b" help:"Print the byte count." optional:"true"`
-	MaxLine    bool     `short:"L" help:"Print the length of the longest line." optional:"true"`
+	Chars      bool     `short:"m" help:"Print the character count (UTF-8 aware)." optional:"true"`
+	Bytes      bool     `short:"c" help:"Print the byte count." optional:"true"`
+	MaxLine    bool     `short:"L" help:"Print the length of the longest line, in characters (alias: --longest-line)." optional:"true"`
+	Graphemes  bool     `short:"g" help:"Count characters as user-perceived characters (grapheme clusters), so an emoji with modifiers or a letter with combining accents is one. Implies -m unless other counts are selected." optional:"true"`
 	TotalOnly  bool     `short:"t" help:"Print only the total (when multiple files)." optional:"true"`
 	NoFilename bool     `short:"n" help:"Never print filenames." optional:"true"`
 }
@@ -37,10 +43,15 @@ func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
 		Use:         "count",
 		Short:       "Count lines, words, and characters",
-		Long:        "Count lines, words, characters, and bytes in files. Similar to wc but with clearer flags.",
+		Long:        "Count lines, words, characters, and bytes in files. Flags and output layout follow wc, so scripts parsing wc output can switch over.",
 		ParamEnrich: common.DefaultParamEnricher(),
+		PostCreateFunc: func(params *Params, cmd *cobra.Command) error {
+			// Accept the GNU wc long name and --longest-line for -L
+			cmd.Flags().SetNormalizeFunc(normalizeFlagName)
+			return nil
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			if err := runCount(params); err != nil {
+			if err := runCount(params, os.Stdin, os.Stdout); err != nil {
 				fmt.Fprintf(os.Stderr, "count: %v\n", err)
 				os.Exit(1)
 			}
@@ -48,13 +59,23 @@ func Cmd() *cobra.Command {
 	}.ToCobra()
 }
 
-func runCount(params *Params) error {
-	// If no specific flags set, default to lines, words, and chars (like wc)
+func normalizeFlagName(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	switch name {
+	case "longest-line", "max-line-length":
+		name = "max-line"
+	}
+	return pflag.NormalizedName(name)
+}
+
+func runCount(params *Params, stdin io.Reader, stdout io.Writer) error {
+	// If no specific flags set, default to lines, words, and bytes (like wc)
 	showAll := !params.Lines && !params.Words && !params.Chars && !params.Bytes && !params.MaxLine
-	if showAll {
+	if showAll && params.Graphemes {
+		params.Chars = true
+	} else if showAll {
 		params.Lines = true
 		params.Words = true
-		params.Chars = true
+		params.Bytes = true
 	}
 
 	var results []CountResult
@@ -66,26 +87,24 @@ func runCount(params *Params) error {
 		files = []string{"-"}
 	}
 
+	readsStdin := false
 	for _, file := range files {
-		var reader io.Reader
-		var filename string
+		var result CountResult
+		var err error
 
 		if file == "-" {
-			reader = os.Stdin
-			filename = "-"
+			readsStdin = true
+			result, err = countReader(stdin, "-", params)
 		} else {
-			f, err := os.Open(file)
-			if err != nil {
-				return fmt.Errorf("cannot open %s: %w", file, err)
+			f, openErr := os.Open(file)
+			if openErr != nil {
+				return fmt.Errorf("cannot open %s: %w", file, openErr)
 			}
-			defer f.Close()
-			reader = f
-			filename = file
+			result, err = countReader(f, file, params)
+			f.Close()
 		}
-
-		result, err := countReader(reader, filename, params)
 		if err != nil {
-			return fmt.Errorf("error reading %s: %w", filename, err)
+			return fmt.Errorf("error reading %s: %w", file, err)
 		}
 
 		results = append(results, result)
@@ -98,22 +117,21 @@ func runCount(params *Params) error {
 		}
 	}
 
-	// Determine if we should print filenames
-	showFilename := len(results) > 1 && !params.NoFilename
-	if params.NoFilename {
-		showFilename = false
-	}
+	// Like wc: name every file, except when the only input is stdin
+	showFilename := !params.NoFilename && (len(results) > 1 || files[0] != "-")
+
+	width := columnWidth(append(results, total), params, readsStdin)
 
 	// Print results
 	if !params.TotalOnly {
 		for _, result := range results {
-			printResult(result, params, showFilename)
+			printResult(stdout, result, params, showFilename, width)
 		}
 	}
 
 	// Print total if multiple files
 	if len(results) > 1 {
-		printResult(total, params, showFilename)
+		printResult(stdout, total, params, showFilename, width)
 	}
 
 	return nil
@@ -122,81 +140,150 @@ func runCount(params *Params) error {
 func countReader(reader io.Reader, filename string, params *Params) (CountResult, error) {
 	result := CountResult{Filename: filename}
 
-	// Read entire content if we need chars (for UTF-8) or max line length
-	// Otherwise use line-by-line scanning for efficiency
-	if params.Chars || params.MaxLine {
-		content, err := io.ReadAll(reader)
+	// Lines and bytes only: no need to decode the input
+	if !params.Words && !params.Chars && !params.MaxLine {
+		buf := make([]byte, 32*1024)
+		var last byte
+		for {
+			n, err := reader.Read(buf)
+			if n > 0 {
+				result.Bytes += int64(n)
+				result.Lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+				last = buf[n-1]
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return result, err
+			}
+		}
+		// A trailing line without newline still counts as a line
+		if result.Bytes > 0 && last != '\n' {
+			result.Lines++
+		}
+		return result, nil
+	}
+
+	br := bufio.NewReader(reader)
+	inWord := false
+	lineLen := 0
+	atLineStart := true
+
+	// With --graphemes, each line is collected and segmented as a whole.
+	// Clusters never span lines, as there is always a break after \n.
+	var line []byte
+	endLine := func() {
+		if !params.Graphemes {
+			return
+		}
+		result.Chars += int64(uniseg.GraphemeClusterCount(string(line)))
+		if params.MaxLine {
+			text := strings.TrimSuffix(string(line), "\n")
+			lineLen = uniseg.GraphemeClusterCount(strings.ReplaceAll(text, "\r", ""))
+		}
+		line = line[:0]
+	}
+
+	for {
+		r, size, err := br.ReadRune()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			return result, err
 		}
 
-		result.Bytes = int64(len(content))
-		result.Chars = int64(utf8.RuneCount(content))
+		// Invalid UTF-8 bytes are returned one at a time and count as one
+		// character each, matching utf8.RuneCount
+		result.Bytes += int64(size)
+		if params.Graphemes {
+			line = utf8.AppendRune(line, r)
+		} else {
+			result.Chars++
+		}
 
-		// Count lines and words, track max line length
-		scanner := bufio.NewScanner(strings.NewReader(string(content)))
-		for scanner.Scan() {
-			line := scanner.Text()
+		if r == '\n' {
+			endLine()
 			result.Lines++
-			result.Words += int64(countWords(line))
-			lineLen := utf8.RuneCountInString(line)
-			if lineLen > result.MaxLine {
-				result.MaxLine = lineLen
+			result.MaxLine = max(result.MaxLine, lineLen)
+			lineLen = 0
+			atLineStart = true
+		} else {
+			atLineStart = false
+			if r != '\r' {
+				lineLen++
 			}
 		}
 
-		// Check if content ends without newline (still count as a line if non-empty)
-		if len(content) > 0 && content[len(content)-1] != '\n' {
-			// Scanner already counted it, no adjustment needed
-		}
-	} else {
-		// Efficient streaming mode for just lines/words/bytes
-		scanner := bufio.NewScanner(reader)
-		for scanner.Scan() {
-			line := scanner.Bytes()
-			result.Lines++
-			result.Words += int64(countWordsBytes(line))
-			result.Bytes += int64(len(line) + 1) // +1 for newline
-		}
-		if err := scanner.Err(); err != nil {
-			return result, err
+		if unicode.IsSpace(r) {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			result.Words++
 		}
 	}
 
-	return result, nil
-}
-
-func countWords(s string) int {
-	return len(strings.Fields(s))
-}
+	// A trailing line without newline still counts as a line
+	if !atLineStart {
+		endLine()
+		result.Lines++
+		result.MaxLine = max(result.MaxLine, lineLen)
+	}
 
-func countWordsBytes(b []byte) int {
-	return len(strings.Fields(string(b)))
+	return result, nil
 }
 
-func printResult(result CountResult, params *Params, showFilename bool) {
-	var parts []string
-
+// selectedCounts returns the enabled counts of a result, in wc column order.
+func selectedCounts(result CountResult, params *Params) []int64 {
+	var counts []int64
 	if params.Lines {
-		parts = append(parts, fmt.Sprintf("%8d", result.Lines))
+		counts = append(counts, result.Lines)
 	}
 	if params.Words {
-		parts = append(parts, fmt.Sprintf("%8d", result.Words))
+		counts = append(counts, result.Words)
 	}
 	if params.Chars {
-		parts = append(parts, fmt.Sprintf("%8d", result.Chars))
+		counts = append(counts, result.Chars)
 	}
 	if params.Bytes {
-		parts = append(parts, fmt.Sprintf("%8d", result.Bytes))
+		counts = append(counts, result.Bytes)
 	}
 	if params.MaxLine {
-		parts = append(parts, fmt.Sprintf("%8d", result.MaxLine))
+		counts = append(counts, int64(result.MaxLine))
+	}
+	return counts
+}
+
+// columnWidth returns the width all count columns are right-aligned to: wide
+// enough for the largest number printed. As in wc, multi-column output that
+// reads from stdin uses a minimum width of 7 since sizes aren't known upfront.
+func columnWidth(results []CountResult, params *Params, readsStdin bool) int {
+	width := 1
+	columns := 0
+	for _, result := range results {
+		counts := selectedCounts(result, params)
+		columns = len(counts)
+		for _, c := range counts {
+			width = max(width, len(strconv.FormatInt(c, 10)))
+		}
+	}
+	if readsStdin && columns > 1 {
+		width = max(width, 7)
+	}
+	return width
+}
+
+func printResult(w io.Writer, result CountResult, params *Params, showFilename bool, width int) {
+	var parts []string
+	for _, c := range selectedCounts(result, params) {
+		parts = append(parts, fmt.Sprintf("%*d", width, c))
 	}
 
-	output := strings.Join(parts, "")
+	output := strings.Join(parts, " ")
 	if showFilename {
 		output += " " + result.Filename
 	}
 
-	fmt.Println(output)
+	fmt.Fprintln(w, output)
 }
@@ -1,32 +1,13 @@
 package count
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
 
-func TestCountWords(t *testing.T) {
-	tests := []struct {
-		input string
-		want  int
-	}{
-		{"", 0},
-		{"hello", 1},
-		{"hello world", 2},
-		{"  hello   world  ", 2},
-		{"one two three four five", 5},
-		{"\ttab\tseparated\twords", 3},
-		{"newline\nwords", 2},
-	}
-
-	for _, tt := range tests {
-		got := countWords(tt.input)
-		if got != tt.want {
-			t.Errorf("countWords(%q) = %d, want %d", tt.input, got, tt.want)
-		}
-	}
-}
-
 func TestCountReader(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -190,3 +171,160 @@ func TestDefaultBehavior(t *testing.T) {
 		t.Error("expected showAll to be true when no flags set")
 	}
 }
+
+func TestCountCharsVersusBytes(t *testing.T) {
+	params := &Params{Chars: true, Bytes: true}
+	result, err := countReader(strings.NewReader("ñandú ☃\n"), "test", params)
+	if err != nil {
+		t.Fatalf("countReader() error = %v", err)
+	}
+	if result.Chars != 8 {
+		t.Errorf("Chars = %d, want 8", result.Chars)
+	}
+	if result.Bytes != 12 {
+		t.Errorf("Bytes = %d, want 12", result.Bytes)
+	}
+}
+
+func TestCountLinesBytesFastPath(t *testing.T) {
+	params := &Params{Lines: true, Bytes: true}
+	result, err := countReader(strings.NewReader("a\r\nb\r\nc"), "test", params)
+	if err != nil {
+		t.Fatalf("countReader() error = %v", err)
+	}
+	if result.Lines != 3 {
+		t.Errorf("Lines = %d, want 3", result.Lines)
+	}
+	if result.Bytes != 7 {
+		t.Errorf("Bytes = %d, want 7", result.Bytes)
+	}
+}
+
+func TestRunCountStdin(t *testing.T) {
+	var stdout bytes.Buffer
+	params := &Params{Files: []string{"-"}}
+	if err := runCount(params, strings.NewReader("hello world\nfoo\n"), &stdout); err != nil {
+		t.Fatalf("runCount() error = %v", err)
+	}
+
+	// Default columns are lines, words, bytes; stdin has no filename column
+	expected := "      2       3      16\n"
+	if stdout.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestRunCountMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	file1 := filepath.Join(dir, "a.txt")
+	file2 := filepath.Join(dir, "b.txt")
+	if err := os.WriteFile(file1, []byte("one two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file2, []byte(strings.Repeat("word ", 200)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	params := &Params{Files: []string{file1, file2}, Lines: true, Words: true, Bytes: true}
+	if err := runCount(params, nil, &stdout); err != nil {
+		t.Fatalf("runCount() error = %v", err)
+	}
+
+	expected := "   1    2    8 " + file1 + "\n" +
+		"   1  200 1001 " + file2 + "\n" +
+		"   2  202 1009 total\n"
+	if stdout.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, stdout.String())
+	}
+}
+
+func TestRunCountSingleFileShowsName(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(file, []byte("x\ny\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	if err := runCount(&Params{Files: []string{file}, Lines: true}, nil, &stdout); err != nil {
+		t.Fatalf("runCount() error = %v", err)
+	}
+	if stdout.String() != "2 "+file+"\n" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := runCount(&Params{Files: []string{file}, Lines: true, NoFilename: true}, nil, &stdout); err != nil {
+		t.Fatalf("runCount() error = %v", err)
+	}
+	if stdout.String() != "2\n" {
+		t.Errorf("unexpected output with --no-filename %q", stdout.String())
+	}
+}
+
+func TestNormalizeFlagName(t *testing.T) {
+	tests := map[string]string{
+		"longest-line":    "max-line",
+		"max-line-length": "max-line",
+		"max-line":        "max-line",
+		"lines":           "lines",
+	}
+	for name, want := range tests {
+		if got := string(normalizeFlagName(nil, name)); got != want {
+			t.Errorf("normalizeFlagName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCountGraphemes(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantRunes     int64
+		wantGraphemes int64
+		wantMax       int
+	}{
+		{"ascii", "hello\n", 6, 6, 5},
+		// e + combining acute accent, and n + combining tilde
+		{"combining marks", "cafe\u0301 man\u0303ana\n", 14, 12, 11},
+		// Thumbs up with skin tone, and a family joined with ZWJs
+		{"emoji", "\U0001F44D\U0001F3FD \U0001F468\u200d\U0001F469\u200d\U0001F467\n", 9, 4, 3},
+		// Two regional indicators make one flag
+		{"flag", "\U0001F1F8\U0001F1EA", 2, 1, 1},
+		{"crlf", "a\r\nb\r\n", 6, 4, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runes, err := countReader(strings.NewReader(tt.input), "test", &Params{Chars: true})
+			if err != nil {
+				t.Fatalf("countReader() error = %v", err)
+			}
+			graphemes, err := countReader(strings.NewReader(tt.input), "test", &Params{Chars: true, MaxLine: true, Graphemes: true})
+			if err != nil {
+				t.Fatalf("countReader() error = %v", err)
+			}
+
+			if runes.Chars != tt.wantRunes {
+				t.Errorf("Chars = %d, want %d", runes.Chars, tt.wantRunes)
+			}
+			if graphemes.Chars != tt.wantGraphemes {
+				t.Errorf("Chars with graphemes = %d, want %d", graphemes.Chars, tt.wantGraphemes)
+			}
+			if graphemes.MaxLine != tt.wantMax {
+				t.Errorf("MaxLine with graphemes = %d, want %d", graphemes.MaxLine, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRunCountGraphemesImpliesChars(t *testing.T) {
+	var stdout bytes.Buffer
+	params := &Params{Files: []string{"-"}, Graphemes: true}
+	if err := runCount(params, strings.NewReader("cafe\u0301\n"), &stdout); err != nil {
+		t.Fatalf("runCount() error = %v", err)
+	}
+	if expected := "5\n"; stdout.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stdout.String())
+	}
+}
@@ -1,11 +1,13 @@
 package cron
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
@@ -24,6 +26,11 @@ func Cmd() *cobra.Command {
 		Short:       "Explain and validate cron expressions",
 		Long:        "Parse cron expressions and show human-readable explanations with upcoming execution times.",
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds: []*cobra.Command{
+			explainCmd(),
+			nextCmd(),
+			lintCmd(),
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if err := runCron(params); err != nil {
 				fmt.Fprintf(os.Stderr, "cron: %v\n", err)
@@ -51,12 +58,16 @@ type CronExpr struct {
 	Month      *CronField
 	DayOfWeek  *CronField
 	HasSeconds bool
+	Alias      string // e.g. "@daily", if the expression was an alias
+	Reboot     bool   // @reboot, which runs at startup and has no schedule
 }
 
+const timeFormat = "Mon, 02 Jan 2006 15:04:05 MST"
+
 func runCron(params *Params) error {
 	expr, err := parseCronExpression(params.Expression)
 	if err != nil {
-		return err
+		return withPointer(params.Expression, err)
 	}
 
 	if params.Validate {
@@ -66,6 +77,13 @@ func runCron(params *Params) error {
 
 	// Print explanation
 	fmt.Println("Expression:", params.Expression)
+	fmt.Println("Description:", describeCron(expr))
+	if note := cronNote(expr); note != "" {
+		fmt.Println("Note:", note)
+	}
+	if expr.Reboot {
+		return nil
+	}
 	fmt.Println()
 	fmt.Println("Schedule:")
 	fmt.Println(explainCron(expr))
@@ -76,74 +94,126 @@ func runCron(params *Params) error {
 		fmt.Printf("Next %d execution times:\n", params.Next)
 		times := getNextExecutions(expr, time.Now(), params.Next)
 		for i, t := range times {
-			fmt.Printf("  %d. %s\n", i+1, t.Format("Mon, 02 Jan 2006 15:04:05 MST"))
+			fmt.Printf("  %d. %s\n", i+1, t.Format(timeFormat))
 		}
 	}
 
 	return nil
 }
 
+// cronAliases are the nicknames supported by common cron implementations.
+// @reboot has no schedule and is handled separately.
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// FieldError reports which field of an expression is invalid.
+type FieldError struct {
+	Index int // position among the expression's fields, from 0
+	Name  string
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("field %d (%s) %q: %v", e.Index+1, e.Name, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+type fieldSpec struct {
+	name     string
+	min, max int
+}
+
+var (
+	secondSpec = fieldSpec{"second", 0, 59}
+	fieldSpecs = []fieldSpec{
+		{"minute", 0, 59},
+		{"hour", 0, 23},
+		{"day-of-month", 1, 31},
+		{"month", 1, 12},
+		{"day-of-week", 0, 6},
+	}
+)
+
 func parseCronExpression(expr string) (*CronExpr, error) {
 	fields := strings.Fields(expr)
 
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		alias := strings.ToLower(fields[0])
+		if alias == "@reboot" {
+			return &CronExpr{Alias: alias, Reboot: true}, nil
+		}
+		spec, ok := cronAliases[alias]
+		if !ok {
+			return nil, fmt.Errorf("unknown alias %s (valid: @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly, @reboot)", fields[0])
+		}
+		cron, err := parseCronExpression(spec)
+		if err != nil {
+			return nil, err
+		}
+		cron.Alias = alias
+		return cron, nil
+	}
+
 	if len(fields) < 5 || len(fields) > 6 {
 		return nil, fmt.Errorf("invalid cron expression: expected 5 or 6 fields, got %d", len(fields))
 	}
 
 	cron := &CronExpr{}
-	idx := 0
+	specs := fieldSpecs
+	targets := []**CronField{&cron.Minute, &cron.Hour, &cron.DayOfMonth, &cron.Month, &cron.DayOfWeek}
 
 	// Handle optional seconds field (6-field cron)
 	if len(fields) == 6 {
 		cron.HasSeconds = true
-		second, err := parseField(fields[idx], "second", 0, 59)
-		if err != nil {
-			return nil, err
-		}
-		cron.Second = second
-		idx++
-	}
-
-	// Minute
-	minute, err := parseField(fields[idx], "minute", 0, 59)
-	if err != nil {
-		return nil, err
+		specs = append([]fieldSpec{secondSpec}, specs...)
+		targets = append([]**CronField{&cron.Second}, targets...)
 	}
-	cron.Minute = minute
-	idx++
 
-	// Hour
-	hour, err := parseField(fields[idx], "hour", 0, 23)
-	if err != nil {
-		return nil, err
+	for i, field := range fields {
+		parsed, err := parseField(field, specs[i].name, specs[i].min, specs[i].max)
+		if err != nil {
+			return nil, &FieldError{Index: i, Name: specs[i].name, Field: field, Err: err}
+		}
+		*targets[i] = parsed
 	}
-	cron.Hour = hour
-	idx++
 
-	// Day of month
-	dom, err := parseField(fields[idx], "day-of-month", 1, 31)
-	if err != nil {
-		return nil, err
-	}
-	cron.DayOfMonth = dom
-	idx++
+	return cron, nil
+}
 
-	// Month
-	month, err := parseField(fields[idx], "month", 1, 12)
-	if err != nil {
-		return nil, err
+// withPointer adds the expression to a FieldError, with the offending field
+// underlined, so that the mistake is easy to spot in long expressions.
+func withPointer(expr string, err error) error {
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		return err
 	}
-	cron.Month = month
-	idx++
-
-	// Day of week
-	dow, err := parseField(fields[idx], "day-of-week", 0, 6)
-	if err != nil {
-		return nil, err
+	offset, inField, index := 0, false, -1
+	for i, r := range expr {
+		if unicode.IsSpace(r) {
+			inField = false
+			continue
+		}
+		if !inField {
+			inField = true
+			index++
+			if index == fieldErr.Index {
+				offset = i
+				break
+			}
+		}
 	}
-	cron.DayOfWeek = dow
-
-	return cron, nil
+	return fmt.Errorf("%w\n  %s\n  %s%s", err, expr, strings.Repeat(" ", offset), strings.Repeat("^", len(fieldErr.Field)))
 }
 
 func parseField(field, name string, min, max int) (*CronField, error) {
@@ -392,9 +462,30 @@ func detectStep(values []int) int {
 	return step
 }
 
+// getNextExecutions returns the next count times after from that match expr,
+// looking at most a year ahead. Times are matched against the wall clock in
+// from's location. When a DST change repeats an hour, a schedule with fixed
+// hours fires only on the first pass, and times skipped by a DST change
+// never fire.
 func getNextExecutions(expr *CronExpr, from time.Time, count int) []time.Time {
+	if expr.Reboot {
+		return nil
+	}
+
+	seconds := []int{0}
+	if expr.HasSeconds {
+		seconds = expr.Second.Values
+		if seconds == nil {
+			seconds = make([]int, 60)
+			for i := range seconds {
+				seconds[i] = i
+			}
+		}
+	}
+
 	var results []time.Time
-	current := from.Truncate(time.Minute).Add(time.Minute)
+	var lastWall time.Time
+	current := from.Truncate(time.Minute)
 
 	// Limit iterations to prevent infinite loops
 	maxIterations := 366 * 24 * 60 // One year of minutes
@@ -403,8 +494,20 @@ func getNextExecutions(expr *CronExpr, from time.Time, count int) []time.Time {
 	for len(results) < count && iterations < maxIterations {
 		iterations++
 
-		if matchesCron(expr, current) {
-			results = append(results, current)
+		for _, sec := range seconds {
+			t := current.Add(time.Duration(sec) * time.Second)
+			if !t.After(from) || !matchesCron(expr, t) {
+				continue
+			}
+			wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+			if expr.Hour.Values != nil && !wall.After(lastWall) {
+				continue
+			}
+			lastWall = wall
+			results = append(results, t)
+			if len(results) == count {
+				break
+			}
 		}
 		current = current.Add(time.Minute)
 	}
@@ -430,6 +533,11 @@ func matchesCron(expr *CronExpr, t time.Time) bool {
 		return false
 	}
 
+	return matchesDay(expr, t)
+}
+
+// matchesDay reports whether expr runs on t's day, ignoring the time of day.
+func matchesDay(expr *CronExpr, t time.Time) bool {
 	// Check month
 	if !matchesField(expr.Month, int(t.Month())) {
 		return false
@@ -0,0 +1,257 @@
+package cron
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type ExplainParams struct {
+	Expression string `pos:"true" help:"Cron expression to explain (5 or 6 fields, or an alias like @daily)."`
+	Next       int    `short:"n" optional:"true" help:"Also list the next N fire times."`
+	TZ         string `name:"tz" optional:"true" help:"Time zone for --next, e.g. Europe/Stockholm or UTC. Defaults to local time."`
+}
+
+func explainCmd() *cobra.Command {
+	return boa.CmdT[ExplainParams]{
+		Use:         "explain",
+		Short:       "Describe a cron expression in plain English",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *ExplainParams, cmd *cobra.Command, args []string) {
+			if err := runExplain(params, time.Now(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "cron: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func runExplain(params *ExplainParams, now time.Time, stdout io.Writer) error {
+	if params.Next < 0 {
+		return fmt.Errorf("--next can't be negative")
+	}
+	expr, err := parseCronExpression(params.Expression)
+	if err != nil {
+		return withPointer(params.Expression, err)
+	}
+	loc, err := loadZone(params.TZ)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, describeCron(expr))
+	if note := cronNote(expr); note != "" {
+		fmt.Fprintln(stdout, "Note:", note)
+	}
+	if params.Next == 0 || expr.Reboot {
+		return nil
+	}
+
+	times := getNextExecutions(expr, now.In(loc), params.Next)
+	fmt.Fprintf(stdout, "\nNext %d fire times (%s):\n", len(times), loc)
+	for _, t := range times {
+		fmt.Fprintf(stdout, "  %s\n", t.Format(timeFormat))
+	}
+	return nil
+}
+
+// cronNote points out parts of an expression that not every cron supports.
+func cronNote(expr *CronExpr) string {
+	switch {
+	case expr.Reboot:
+		return "@reboot runs once when cron starts, usually at boot, and has no scheduled times."
+	case expr.HasSeconds:
+		return "the first of the 6 fields is seconds. Standard crontab only supports 5 fields."
+	}
+	return ""
+}
+
+// describeCron renders the schedule as an English sentence, such as
+// "Every 15 minutes, between 02:00 and 06:59, on Monday through Friday".
+func describeCron(expr *CronExpr) string {
+	if expr.Reboot {
+		return "At system startup"
+	}
+
+	parts := describeTime(expr)
+	if days := describeDays(expr); days != "" {
+		parts = append(parts, days)
+	}
+	if expr.Month.Values != nil {
+		parts = append(parts, "in "+describeList(expr.Month))
+	}
+
+	sentence := strings.Join(parts, ", ")
+	return strings.ToUpper(sentence[:1]) + sentence[1:]
+}
+
+// maxClockTimes is how many hours are listed as clock times ("at 09:00 and
+// 17:00") before falling back to describing the minute and hour separately.
+const maxClockTimes = 6
+
+func describeTime(expr *CronExpr) []string {
+	second := expr.Second
+	if !expr.HasSeconds {
+		second = &CronField{Name: "second", Min: 0, Max: 59, Values: []int{0}}
+	}
+	minute, hour := expr.Minute, expr.Hour
+
+	// A fixed second and minute in a few hours reads best as clock times
+	if len(second.Values) == 1 && len(minute.Values) == 1 && hour.Values != nil && len(hour.Values) <= maxClockTimes {
+		times := make([]string, len(hour.Values))
+		for i, h := range hour.Values {
+			times[i] = fmt.Sprintf("%02d:%02d", h, minute.Values[0])
+			if second.Values[0] != 0 {
+				times[i] += fmt.Sprintf(":%02d", second.Values[0])
+			}
+		}
+		return []string{"at " + joinWords(times)}
+	}
+
+	var parts []string
+	secondsEvery := false
+	if second.Values == nil || len(second.Values) != 1 || second.Values[0] != 0 {
+		phrase := describeUnit(second)
+		secondsEvery = strings.HasPrefix(phrase, "every")
+		parts = append(parts, phrase)
+	}
+	if !(secondsEvery && isEvery(minute)) {
+		phrase := describeUnit(minute)
+		if strings.HasPrefix(phrase, "at ") && isEvery(hour) {
+			phrase += " past every hour"
+		}
+		parts = append(parts, phrase)
+	}
+	if phrase := describeHours(hour); phrase != "" {
+		parts = append(parts, phrase)
+	}
+	return parts
+}
+
+// describeUnit describes a second or minute field, such as "every 15
+// minutes" or "at minutes 0 and 20".
+func describeUnit(f *CronField) string {
+	values := f.Values
+	switch {
+	case isEvery(f):
+		return "every " + f.Name
+	case fullStep(f) > 0:
+		return fmt.Sprintf("every %d %ss", fullStep(f), f.Name)
+	case len(values) == 1:
+		return fmt.Sprintf("at %s %d", f.Name, values[0])
+	case !isContinuousRange(values) && detectStep(values) > 0 && len(values) > 2:
+		return fmt.Sprintf("every %d %ss from %s %d through %d", detectStep(values), f.Name, f.Name, values[0], values[len(values)-1])
+	}
+	return fmt.Sprintf("at %ss %s", f.Name, describeList(f))
+}
+
+func describeHours(f *CronField) string {
+	values := f.Values
+	switch {
+	case isEvery(f):
+		return ""
+	case fullStep(f) > 0:
+		return fmt.Sprintf("every %d hours", fullStep(f))
+	case len(values) == 1:
+		return fmt.Sprintf("between %02d:00 and %02d:59", values[0], values[0])
+	case isContinuousRange(values):
+		return fmt.Sprintf("between %02d:00 and %02d:59", values[0], values[len(values)-1])
+	}
+	return "during hours " + describeList(f)
+}
+
+func describeDays(expr *CronExpr) string {
+	var dom, dow string
+	if f := expr.DayOfMonth; f.Values != nil {
+		month := "every month"
+		if expr.Month.Values != nil {
+			month = "the month"
+		}
+		dom = "on the " + describeList(f) + " of " + month
+	}
+	if f := expr.DayOfWeek; f.Values != nil {
+		dow = "on " + describeList(f)
+	}
+	switch {
+	case dom != "" && dow != "":
+		// Cron fires when either day field matches
+		return dom + " or " + dow
+	case dom != "":
+		return dom
+	}
+	return dow
+}
+
+// describeList lists the values of a restricted field, naming ranges of three
+// or more values like "Monday through Friday".
+func describeList(f *CronField) string {
+	format := func(v int) string {
+		switch f.Name {
+		case "month", "day-of-week":
+			return formatValue(v, f.Name)
+		case "hour":
+			return fmt.Sprintf("%02d:00", v)
+		case "day-of-month":
+			return ordinal(v)
+		}
+		return strconv.Itoa(v)
+	}
+
+	values := f.Values
+	if len(values) > 2 && isContinuousRange(values) {
+		return format(values[0]) + " through " + format(values[len(values)-1])
+	}
+	words := make([]string, len(values))
+	for i, v := range values {
+		words[i] = format(v)
+	}
+	return joinWords(words)
+}
+
+// ordinal formats a day of the month like "1st", "22nd" or "13th".
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// isEvery reports whether a field matches every value, such as * or */1.
+func isEvery(f *CronField) bool {
+	return f.Values == nil || len(f.Values) == f.Max-f.Min+1
+}
+
+// fullStep returns the step of a field like */15, which steps through the
+// whole range from its minimum, or 0 for other fields.
+func fullStep(f *CronField) int {
+	values := f.Values
+	if len(values) < 2 || values[0] != f.Min {
+		return 0
+	}
+	step := detectStep(values)
+	if step == 0 || values[len(values)-1]+step <= f.Max {
+		return 0
+	}
+	return step
+}
+
+func joinWords(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
@@ -0,0 +1,137 @@
+package cron
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribeCron(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "Every minute"},
+		{"*/15 2-6 * * 1-5", "Every 15 minutes, between 02:00 and 06:59, on Monday through Friday"},
+		{"0 9 * * 1-5", "At 09:00, on Monday through Friday"},
+		{"30 9,17 * * *", "At 09:30 and 17:30"},
+		{"0 9-17 * * *", "At minute 0, between 09:00 and 17:59"},
+		{"5 * * * *", "At minute 5 past every hour"},
+		{"0,20 * * * *", "At minutes 0 and 20 past every hour"},
+		{"5-35/10 * * * *", "Every 10 minutes from minute 5 through 35"},
+		{"*/10 */2 * * *", "Every 10 minutes, every 2 hours"},
+		{"0 0 1,15 * *", "At 00:00, on the 1st and 15th of every month"},
+		{"0 0 1 * 1", "At 00:00, on the 1st of every month or on Monday"},
+		{"30 2 1 * *", "At 02:30, on the 1st of every month"},
+		{"0 0 1-7 * *", "At 00:00, on the 1st through 7th of every month"},
+		{"0 0 22,23 6 *", "At 00:00, on the 22nd and 23rd of the month, in June"},
+		{"0 12 * jan-mar mon,wed,fri", "At 12:00, on Monday, Wednesday and Friday, in January through March"},
+		{"@daily", "At 00:00"},
+		{"@hourly", "At minute 0 past every hour"},
+		{"@yearly", "At 00:00, on the 1st of the month, in January"},
+		{"@reboot", "At system startup"},
+		{"30 0 4 * * *", "At 04:00:30"},
+		{"*/10 * * * * *", "Every 10 seconds"},
+		{"* 0 * * * *", "Every second, at minute 0 past every hour"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := parseCronExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := describeCron(expr); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunExplain_Note(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := runExplain(&ExplainParams{Expression: "0 */5 * * * *"}, time.Now(), &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "Every 5 minutes\nNote: the first of the 6 fields is seconds. Standard crontab only supports 5 fields.\n"
+	if stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+}
+
+func TestRunExplain_Next(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	var stdout bytes.Buffer
+	params := &ExplainParams{Expression: "30 2 1 * *", Next: 2, TZ: "Europe/Stockholm"}
+	if err := runExplain(params, now, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `At 02:30, on the 1st of every month
+
+Next 2 fire times (Europe/Stockholm):
+  Sun, 01 Nov 2026 02:30:00 CET
+  Tue, 01 Dec 2026 02:30:00 CET
+`
+	if stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+
+	for _, bad := range []*ExplainParams{
+		{Expression: "* * * * *", Next: -1},
+		{Expression: "* * * * *", TZ: "Nowhere/City"},
+	} {
+		if err := runExplain(bad, now, &stdout); err == nil {
+			t.Errorf("Expected error for %+v", bad)
+		}
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 22: "22nd", 31: "31st"} {
+		if got := ordinal(n); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestParseCronExpression_FieldError(t *testing.T) {
+	expr := "*/15  2-66 * * 1-5"
+	_, err := parseCronExpression(expr)
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("Expected a FieldError, got %v", err)
+	}
+	if fieldErr.Index != 1 || fieldErr.Name != "hour" || fieldErr.Field != "2-66" {
+		t.Errorf("Expected field 2 (hour) 2-66, got %+v", fieldErr)
+	}
+
+	want := `field 2 (hour) "2-66": value out of range in hour: 66 (must be 0-23)` + "\n" +
+		"  */15  2-66 * * 1-5\n" +
+		"        ^^^^"
+	if got := withPointer(expr, err).Error(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// Seconds shift the field positions
+	_, err = parseCronExpression("0 0 0 * 13 *")
+	if !errors.As(err, &fieldErr) || fieldErr.Index != 4 || fieldErr.Name != "month" {
+		t.Errorf("Expected field 5 (month), got %v", err)
+	}
+}
+
+func TestParseCronExpression_Aliases(t *testing.T) {
+	expr, err := parseCronExpression("@Weekly")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expr.Alias != "@weekly" || len(expr.DayOfWeek.Values) != 1 || expr.DayOfWeek.Values[0] != 0 {
+		t.Errorf("Expected @weekly to run on Sundays, got %+v", expr)
+	}
+
+	if _, err := parseCronExpression("@fortnightly"); err == nil || !strings.Contains(err.Error(), "unknown alias") {
+		t.Errorf("Expected unknown alias error, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package cron
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type LintParams struct {
+	File    string `pos:"true" help:"Crontab file to check, or - for stdin."`
+	Seconds bool   `short:"s" optional:"true" help:"Entries have 6 schedule fields, starting with seconds."`
+	Expand  bool   `short:"e" optional:"true" help:"Print the crontab with aliases like @daily rewritten to explicit fields. Problems are reported on stderr."`
+}
+
+func lintCmd() *cobra.Command {
+	return boa.CmdT[LintParams]{
+		Use:         "lint",
+		Short:       "Check a crontab file for errors and duplicate entries",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *LintParams, cmd *cobra.Command, args []string) {
+			if err := runLint(params, time.Now(), os.Stdin, os.Stdout, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "cron: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+// envAssignment matches crontab lines like MAILTO=ops@example.com.
+var envAssignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+
+// crontabEntry is a valid schedule line of a crontab.
+type crontabEntry struct {
+	Line    int
+	Expr    *CronExpr
+	Command string
+}
+
+type lintIssue struct {
+	Line    int
+	Warning bool
+	Message string
+}
+
+// runLint checks every line of a crontab. Errors make it fail, while
+// warnings, such as duplicate entries, are only reported.
+func runLint(params *LintParams, now time.Time, stdin io.Reader, stdout, stderr io.Writer) error {
+	name, r := params.File, stdin
+	if name == "-" {
+		name = "stdin"
+	} else {
+		f, err := os.Open(params.File)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	missingNewline := lines[len(lines)-1] != ""
+	if !missingNewline {
+		lines = lines[:len(lines)-1]
+	}
+
+	var entries []crontabEntry
+	var issues []lintIssue
+	for i, raw := range lines {
+		raw = strings.TrimSuffix(raw, "\r")
+		entry, expanded, err := lintLine(raw, params.Seconds)
+		if err != nil {
+			issues = append(issues, lintIssue{Line: i + 1, Message: err.Error()})
+			continue
+		}
+		lines[i] = expanded
+		if entry == nil {
+			continue
+		}
+		entry.Line = i + 1
+		if strings.Contains(strings.ReplaceAll(entry.Command, `\%`, ""), "%") {
+			issues = append(issues, lintIssue{Line: i + 1, Warning: true, Message: `unescaped % in the command, cron turns it into a newline; escape it as \%`})
+		}
+		entries = append(entries, *entry)
+	}
+	if missingNewline && len(lines) > 0 {
+		issues = append(issues, lintIssue{Line: len(lines), Warning: true, Message: "no newline at the end of the file, some cron implementations ignore the last line"})
+	}
+	issues = append(issues, findOverlaps(entries, now)...)
+	slices.SortStableFunc(issues, func(a, b lintIssue) int { return a.Line - b.Line })
+
+	report := stdout
+	if params.Expand {
+		report = stderr
+		for _, line := range lines {
+			fmt.Fprintln(stdout, line)
+		}
+	}
+
+	errorCount := 0
+	for _, issue := range issues {
+		level := "warning"
+		if !issue.Warning {
+			level = "error"
+			errorCount++
+		}
+		fmt.Fprintf(report, "%s:%d: %s: %s\n", name, issue.Line, level, issue.Message)
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("%s: %d errors, %d warnings", name, errorCount, len(issues)-errorCount)
+	}
+	if !params.Expand {
+		fmt.Fprintf(report, "%s: %d entries, %d warnings\n", name, len(entries), len(issues))
+	}
+	return nil
+}
+
+// lintLine parses a crontab line, returning its entry, or nil for comments,
+// blank lines and variable assignments, and the line with any alias
+// expanded.
+func lintLine(raw string, seconds bool) (*crontabEntry, string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || envAssignment.MatchString(trimmed) {
+		return nil, raw, nil
+	}
+
+	n := len(fieldSpecs)
+	if seconds {
+		n++
+	}
+	if strings.HasPrefix(trimmed, "@") {
+		n = 1
+	}
+	fields, rest := splitFields(raw, n)
+	command := strings.TrimSpace(rest)
+
+	expr, err := parseCronExpression(strings.Join(fields, " "))
+	if err != nil {
+		if five, _ := splitFields(raw, n-1); seconds && n > 1 {
+			if _, err5 := parseCronExpression(strings.Join(five, " ")); err5 == nil {
+				return nil, raw, fmt.Errorf("expected 6 schedule fields with --seconds, this entry has 5")
+			}
+		}
+		return nil, raw, withPointer(trimmed, err)
+	}
+	if command == "" {
+		return nil, raw, fmt.Errorf("missing command after the schedule")
+	}
+
+	expanded := raw
+	if expr.Alias != "" && !expr.Reboot {
+		spec := cronAliases[expr.Alias]
+		if seconds {
+			spec = "0 " + spec
+		}
+		indent := raw[:len(raw)-len(strings.TrimLeftFunc(raw, unicode.IsSpace))]
+		expanded = indent + spec + rest
+	}
+	return &crontabEntry{Expr: expr, Command: command}, expanded, nil
+}
+
+// splitFields splits off the first n whitespace-separated fields of line,
+// returning the rest of the line as is.
+func splitFields(line string, n int) ([]string, string) {
+	var fields []string
+	rest := line
+	for len(fields) < n {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+	return fields, rest
+}
+
+// findOverlaps warns about entries running the same command as an earlier
+// entry, either on the same schedule or at some shared time, which runs the
+// command twice.
+func findOverlaps(entries []crontabEntry, now time.Time) []lintIssue {
+	var issues []lintIssue
+	for j, b := range entries {
+		for _, a := range entries[:j] {
+			if strings.Join(strings.Fields(a.Command), " ") != strings.Join(strings.Fields(b.Command), " ") {
+				continue
+			}
+			if a.Expr.Reboot || b.Expr.Reboot {
+				if a.Expr.Reboot && b.Expr.Reboot {
+					issues = append(issues, lintIssue{Line: b.Line, Warning: true, Message: fmt.Sprintf("duplicate of line %d", a.Line)})
+					break
+				}
+				continue
+			}
+			if sameSchedule(a.Expr, b.Expr) {
+				issues = append(issues, lintIssue{Line: b.Line, Warning: true, Message: fmt.Sprintf("duplicate of line %d", a.Line)})
+				break
+			}
+			if t, ok := firstOverlap(a.Expr, b.Expr, now); ok {
+				issues = append(issues, lintIssue{Line: b.Line, Warning: true, Message: fmt.Sprintf(
+					"overlaps line %d, which runs the same command, e.g. both run at %s", a.Line, t.Format("Mon, 02 Jan 2006 15:04:05"))})
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// scheduleFields returns the fields of expr from seconds to day of week,
+// with a fixed 0 seconds for 5-field expressions.
+func scheduleFields(expr *CronExpr) []*CronField {
+	second := expr.Second
+	if !expr.HasSeconds {
+		second = &CronField{Name: secondSpec.name, Min: secondSpec.min, Max: secondSpec.max, Values: []int{0}}
+	}
+	return []*CronField{second, expr.Minute, expr.Hour, expr.DayOfMonth, expr.Month, expr.DayOfWeek}
+}
+
+// sameSchedule compares the values of the fields, so that @daily and
+// "0 0 * * *" are the same schedule. Wildcards only equal wildcards, since
+// restricting both day fields changes how they combine.
+func sameSchedule(a, b *CronExpr) bool {
+	fa, fb := scheduleFields(a), scheduleFields(b)
+	for i := range fa {
+		if (fa[i].Values == nil) != (fb[i].Values == nil) || !slices.Equal(fieldValues(fa[i]), fieldValues(fb[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValues returns the sorted values a field matches, expanding
+// wildcards.
+func fieldValues(f *CronField) []int {
+	if f.Values == nil {
+		values := make([]int, 0, f.Max-f.Min+1)
+		for v := f.Min; v <= f.Max; v++ {
+			values = append(values, v)
+		}
+		return values
+	}
+	values := slices.Clone(f.Values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
+
+// overlapDays is how far ahead overlaps are looked for, 4 years so that
+// schedules on Feb 29 are included.
+const overlapDays = 4*365 + 1
+
+// firstOverlap returns the first time from now's day on when both a and b
+// run.
+func firstOverlap(a, b *CronExpr, now time.Time) (time.Time, bool) {
+	fa, fb := scheduleFields(a), scheduleFields(b)
+	var shared [3]int // the first shared second, minute and hour
+	for i := range shared {
+		va, vb := fieldValues(fa[i]), fieldValues(fb[i])
+		j := slices.IndexFunc(va, func(v int) bool {
+			_, found := slices.BinarySearch(vb, v)
+			return found
+		})
+		if j < 0 {
+			return time.Time{}, false
+		}
+		shared[i] = va[j]
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for range overlapDays {
+		if matchesDay(a, day) && matchesDay(b, day) {
+			return time.Date(day.Year(), day.Month(), day.Day(), shared[2], shared[1], shared[0], 0, day.Location()), true
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}, false
+}
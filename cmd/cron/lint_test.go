@@ -0,0 +1,146 @@
+package cron
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var lintNow = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+func runLintString(t *testing.T, params LintParams, crontab string) (string, string, error) {
+	t.Helper()
+	params.File = "-"
+	var stdout, stderr bytes.Buffer
+	err := runLint(&params, lintNow, strings.NewReader(crontab), &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestRunLint_Valid(t *testing.T) {
+	crontab := `# m h dom mon dow command
+SHELL=/bin/bash
+MAILTO = ops@example.com
+
+*/15 * * * * /usr/local/bin/poll
+0 3 * * 1-5  /usr/local/bin/backup --full
+@reboot      /usr/local/bin/start-agent
+@weekly      /usr/local/bin/rotate
+`
+	stdout, _, err := runLintString(t, LintParams{}, crontab)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "stdin: 4 entries, 0 warnings\n"; stdout != want {
+		t.Errorf("Expected %q, got %q", want, stdout)
+	}
+}
+
+func TestRunLint_Errors(t *testing.T) {
+	crontab := `0 9 * * * /bin/ok
+0 25 * * * /bin/hour
+@dayly /bin/alias
+*/5 * * *
+0 9 * * 1-5
+`
+	stdout, _, err := runLintString(t, LintParams{}, crontab)
+	if err == nil || err.Error() != "stdin: 4 errors, 0 warnings" {
+		t.Errorf("Expected 4 errors, got %v", err)
+	}
+	for _, want := range []string{
+		"stdin:2: error: field 2 (hour) \"25\": value out of range in hour: 25 (must be 0-23)\n  0 25 * * * /bin/hour\n    ^^\n",
+		"stdin:3: error: unknown alias @dayly",
+		"stdin:4: error: invalid cron expression: expected 5 or 6 fields, got 4",
+		"stdin:5: error: missing command after the schedule",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "stdin:1:") {
+		t.Errorf("Expected line 1 to be valid, got:\n%s", stdout)
+	}
+}
+
+func TestRunLint_Overlaps(t *testing.T) {
+	crontab := `@daily /bin/backup
+0 0 * * *   /bin/backup
+*/30 * * * * /bin/sync  --all
+0 * * * * /bin/sync --all
+0 * * * * /bin/other
+0 12 * * 1 /bin/report
+0 12 * * 2 /bin/report
+@reboot /bin/agent
+@reboot /bin/agent
+date +%Y-%m-%d
+5 4 * * * date +%Y >> /tmp/log
+5 4 * * * date +\%Y`
+	stdout, _, err := runLintString(t, LintParams{}, crontab)
+	if err == nil || !strings.Contains(err.Error(), "1 errors, 5 warnings") {
+		t.Errorf("Expected 1 error and 5 warnings, got %v\n%s", err, stdout)
+	}
+	for _, want := range []string{
+		"stdin:2: warning: duplicate of line 1\n",
+		"stdin:4: warning: overlaps line 3, which runs the same command, e.g. both run at Mon, 02 Mar 2026 00:00:00\n",
+		"stdin:9: warning: duplicate of line 8\n",
+		"stdin:11: warning: unescaped % in the command",
+		"stdin:12: warning: no newline at the end of the file",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout)
+		}
+	}
+	for _, unwanted := range []string{"stdin:5:", "stdin:7:"} {
+		if strings.Contains(stdout, unwanted) {
+			t.Errorf("Expected no issue on %s in:\n%s", unwanted, stdout)
+		}
+	}
+}
+
+func TestRunLint_Expand(t *testing.T) {
+	crontab := "# jobs\n@hourly /bin/a\n  @DAILY\t/bin/b  # nightly\n@reboot /bin/c\n0 1 * * * /bin/d\n"
+	stdout, stderr, err := runLintString(t, LintParams{Expand: true}, crontab)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "# jobs\n0 * * * * /bin/a\n  0 0 * * *\t/bin/b  # nightly\n@reboot /bin/c\n0 1 * * * /bin/d\n"
+	if stdout != want {
+		t.Errorf("Expected %q, got %q", want, stdout)
+	}
+	if stderr != "" {
+		t.Errorf("Expected no problems, got %q", stderr)
+	}
+}
+
+func TestRunLint_Seconds(t *testing.T) {
+	crontab := "*/10 * * * * * /bin/poll\n@monthly /bin/report\n0 9 * * 1 /bin/weekly\n"
+	stdout, stderr, err := runLintString(t, LintParams{Seconds: true, Expand: true}, crontab)
+	if err == nil {
+		t.Fatal("Expected an error for the 5-field entry")
+	}
+	if want := "stdin:3: error: expected 6 schedule fields with --seconds, this entry has 5\n"; stderr != want {
+		t.Errorf("Expected %q, got %q", want, stderr)
+	}
+	if !strings.Contains(stdout, "0 0 0 1 * * /bin/report\n") {
+		t.Errorf("Expected @monthly expanded with seconds, got:\n%s", stdout)
+	}
+}
+
+func TestRunLint_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crontab")
+	if err := os.WriteFile(path, []byte("0 0 * * * /bin/true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := runLint(&LintParams{File: path}, lintNow, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := path + ": 1 entries, 0 warnings\n"; stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+	if err := runLint(&LintParams{File: path + ".missing"}, lintNow, nil, &stdout, &stderr); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
@@ -0,0 +1,104 @@
+package cron
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type NextParams struct {
+	Expression string `pos:"true" help:"Cron expression (5 or 6 fields, or an alias like @daily)."`
+	Count      int    `short:"n" help:"Number of fire times to list." default:"5"`
+	From       string `optional:"true" help:"List fire times after this time instead of now, e.g. 2026-03-28 or '2026-03-28 22:00'."`
+	TZ         string `name:"tz" optional:"true" help:"Time zone to evaluate the expression in, e.g. Europe/Stockholm or UTC. Defaults to local time."`
+}
+
+// fromLayouts are the accepted --from formats, tried in order.
+var fromLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func nextCmd() *cobra.Command {
+	return boa.CmdT[NextParams]{
+		Use:         "next",
+		Short:       "List the next fire times of a cron expression",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *NextParams, cmd *cobra.Command, args []string) {
+			if err := runNext(params, time.Now(), os.Stdout, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "cron: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+// runNext prints one fire time per line. Notes go to stderr, so the output
+// stays easy to use in scripts.
+func runNext(params *NextParams, now time.Time, stdout, stderr io.Writer) error {
+	if params.Count < 1 {
+		return fmt.Errorf("-n must be at least 1")
+	}
+	expr, err := parseCronExpression(params.Expression)
+	if err != nil {
+		return withPointer(params.Expression, err)
+	}
+	if expr.Reboot {
+		return fmt.Errorf("@reboot runs at system startup and has no scheduled times")
+	}
+
+	loc, err := loadZone(params.TZ)
+	if err != nil {
+		return err
+	}
+	from := now.In(loc)
+	if params.From != "" {
+		if from, err = parseFrom(params.From, loc); err != nil {
+			return err
+		}
+	}
+
+	if note := cronNote(expr); note != "" {
+		fmt.Fprintln(stderr, "Note:", note)
+	}
+	times := getNextExecutions(expr, from, params.Count)
+	for _, t := range times {
+		fmt.Fprintln(stdout, t.Format(timeFormat))
+	}
+	if len(times) < params.Count {
+		fmt.Fprintf(stderr, "(only %d fire times in the year after %s)\n", len(times), from.Format(timeFormat))
+	}
+	return nil
+}
+
+// loadZone loads a --tz time zone, defaulting to local time.
+func loadZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// parseFrom parses a --from time. Times without a zone offset are taken to
+// be in loc.
+func parseFrom(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range fromLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --from time %q, expected e.g. 2026-03-28, '2026-03-28 22:00' or 2026-03-28T22:00:00Z", s)
+}
@@ -0,0 +1,118 @@
+package cron
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // named zones must not depend on the test machine
+)
+
+func runNextLines(t *testing.T, params *NextParams) []string {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if err := runNext(params, time.Now(), &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+}
+
+func TestRunNext_DST(t *testing.T) {
+	tests := []struct {
+		name   string
+		params NextParams
+		want   []string
+	}{
+		{
+			// 02:00-02:59 doesn't exist on 2026-03-29 in Stockholm
+			"daily job in skipped hour",
+			NextParams{Expression: "30 2 * * *", Count: 3, From: "2026-03-28", TZ: "Europe/Stockholm"},
+			[]string{
+				"Sat, 28 Mar 2026 02:30:00 CET",
+				"Mon, 30 Mar 2026 02:30:00 CEST",
+				"Tue, 31 Mar 2026 02:30:00 CEST",
+			},
+		},
+		{
+			"interval across spring forward",
+			NextParams{Expression: "*/30 * * * *", Count: 3, From: "2026-03-29 01:00", TZ: "Europe/Stockholm"},
+			[]string{
+				"Sun, 29 Mar 2026 01:30:00 CET",
+				"Sun, 29 Mar 2026 03:00:00 CEST",
+				"Sun, 29 Mar 2026 03:30:00 CEST",
+			},
+		},
+		{
+			// 02:00-02:59 happens twice on 2026-10-25 in Stockholm
+			"daily job in repeated hour fires once",
+			NextParams{Expression: "30 2 * * *", Count: 2, From: "2026-10-24 12:00", TZ: "Europe/Stockholm"},
+			[]string{
+				"Sun, 25 Oct 2026 02:30:00 CEST",
+				"Mon, 26 Oct 2026 02:30:00 CET",
+			},
+		},
+		{
+			"interval across fall back fires in both passes",
+			NextParams{Expression: "0,30 * * * *", Count: 5, From: "2026-10-25 01:45", TZ: "Europe/Stockholm"},
+			[]string{
+				"Sun, 25 Oct 2026 02:00:00 CEST",
+				"Sun, 25 Oct 2026 02:30:00 CEST",
+				"Sun, 25 Oct 2026 02:00:00 CET",
+				"Sun, 25 Oct 2026 02:30:00 CET",
+				"Sun, 25 Oct 2026 03:00:00 CET",
+			},
+		},
+		{
+			"weekdays in New York across spring forward",
+			NextParams{Expression: "0 9 * * 1-5", Count: 3, From: "2026-03-06T15:00:00Z", TZ: "America/New_York"},
+			[]string{
+				"Mon, 09 Mar 2026 09:00:00 EDT",
+				"Tue, 10 Mar 2026 09:00:00 EDT",
+				"Wed, 11 Mar 2026 09:00:00 EDT",
+			},
+		},
+		{
+			"seconds",
+			NextParams{Expression: "*/20 59 23 31 12 *", Count: 4, From: "2026-12-31 23:59:20", TZ: "UTC"},
+			[]string{
+				"Thu, 31 Dec 2026 23:59:40 UTC",
+				"Fri, 31 Dec 2027 23:59:00 UTC",
+				"Fri, 31 Dec 2027 23:59:20 UTC",
+				"Fri, 31 Dec 2027 23:59:40 UTC",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runNextLines(t, &tt.params)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestRunNext_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		params NextParams
+		want   string
+	}{
+		{"reboot", NextParams{Expression: "@reboot", Count: 1}, "no scheduled times"},
+		{"bad zone", NextParams{Expression: "* * * * *", Count: 1, TZ: "Mars/Olympus"}, "invalid time zone"},
+		{"bad from", NextParams{Expression: "* * * * *", Count: 1, From: "yesterday"}, "invalid --from"},
+		{"bad count", NextParams{Expression: "* * * * *", Count: 0}, "-n must be"},
+		{"bad field", NextParams{Expression: "* * * * 8", Count: 1}, "field 5 (day-of-week)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := runNext(&tt.params, time.Now(), &stdout, &stderr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
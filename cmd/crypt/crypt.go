@@ -1,16 +1,21 @@
 package crypt
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"filippo.io/age"
 	"github.com/GiGurra/boa/pkg/boa"
@@ -27,26 +32,32 @@ const (
 	opensslKeySize    = 32     // AES-256
 	opensslIVSize     = 16     // AES block size
 	opensslIterations = 600000 // PBKDF2 iterations (modern recommendation)
+	opensslMACKeySize = 32     // HMAC-SHA256 key for --authenticate
+	opensslMACSize    = sha256.Size
 )
 
 type EncryptParams struct {
-	Files    []string `pos:"true" help:"Files to encrypt"`
-	Output   string   `short:"o" optional:"true" help:"Output file (only valid with single input file)"`
-	Password string   `short:"p" optional:"true" help:"Encryption password (will prompt if not provided)"`
-	Format   string   `short:"f" optional:"true" help:"Output format: age (default, modern), openssl (compatible with openssl enc)." default:"age" alts:"age,openssl"`
-	Keep     bool     `short:"k" optional:"true" help:"Keep original files after encryption." default:"false"`
-	Force    bool     `short:"F" optional:"true" help:"Overwrite output files if they exist." default:"false"`
-	Verbose  bool     `short:"v" optional:"true" help:"Verbose output."`
+	Files        []string `pos:"true" help:"Files to encrypt"`
+	Output       string   `short:"o" optional:"true" help:"Output file (only valid with single input file)"`
+	Password     string   `short:"p" optional:"true" help:"Encryption password (will prompt if not provided)"`
+	Format       string   `short:"f" optional:"true" help:"Output format: age (default, modern), openssl (compatible with openssl enc)." default:"age" alts:"age,openssl"`
+	Keep         bool     `short:"k" optional:"true" help:"Keep original files after encryption." default:"false"`
+	Force        bool     `short:"F" optional:"true" help:"Overwrite output files if they exist." default:"false"`
+	Verbose      bool     `short:"v" optional:"true" help:"Verbose output."`
+	Authenticate bool     `optional:"true" help:"Append an HMAC-SHA256 over the ciphertext (openssl format only). Not readable by openssl itself." default:"false"`
+	Progress     bool     `optional:"true" help:"Show a progress bar on stderr while processing each file." default:"false"`
 }
 
 type DecryptParams struct {
-	Files    []string `pos:"true" help:"Files to decrypt"`
-	Output   string   `short:"o" optional:"true" help:"Output file (only valid with single input file)"`
-	Password string   `short:"p" optional:"true" help:"Decryption password (will prompt if not provided)"`
-	Format   string   `short:"f" optional:"true" help:"Input format: auto (default), age, openssl." default:"auto" alts:"auto,age,openssl"`
-	Keep     bool     `short:"k" optional:"true" help:"Keep encrypted files after decryption." default:"false"`
-	Force    bool     `short:"F" optional:"true" help:"Overwrite output files if they exist." default:"false"`
-	Verbose  bool     `short:"v" optional:"true" help:"Verbose output."`
+	Files        []string `pos:"true" help:"Files to decrypt"`
+	Output       string   `short:"o" optional:"true" help:"Output file (only valid with single input file)"`
+	Password     string   `short:"p" optional:"true" help:"Decryption password (will prompt if not provided)"`
+	Format       string   `short:"f" optional:"true" help:"Input format: auto (default), age, openssl." default:"auto" alts:"auto,age,openssl"`
+	Keep         bool     `short:"k" optional:"true" help:"Keep encrypted files after decryption." default:"false"`
+	Force        bool     `short:"F" optional:"true" help:"Overwrite output files if they exist." default:"false"`
+	Verbose      bool     `short:"v" optional:"true" help:"Verbose output."`
+	Authenticate bool     `optional:"true" help:"Verify and strip the HMAC-SHA256 added by 'encrypt --authenticate' (openssl format only)." default:"false"`
+	Progress     bool     `optional:"true" help:"Show a progress bar on stderr while processing each file." default:"false"`
 }
 
 func Cmd() *cobra.Command {
@@ -64,6 +75,10 @@ Supported formats:
 The 'age' format is recommended for security. Use 'openssl' for compatibility
 with systems that only have OpenSSL available.
 
+Output is written to a temporary file that is only renamed into place once
+complete, so a failed or interrupted (Ctrl+C) run never leaves a truncated
+file behind.
+
 Examples:
   tofu crypt encrypt secret.txt                    # age format (default)
   tofu crypt encrypt -f openssl secret.txt         # openssl compatible
@@ -107,14 +122,16 @@ Examples:
   tofu crypt encrypt secret.txt
   tofu crypt encrypt -p mypassword document.pdf
   tofu crypt encrypt -f openssl -o backup.enc important.txt
-  tofu crypt encrypt -k file1.txt file2.txt`,
+  tofu crypt encrypt -f openssl --authenticate secret.txt
+  tofu crypt encrypt -k file1.txt file2.txt
+  tofu crypt encrypt --progress large-backup.tar`,
 		ParamEnrich: common.DefaultParamEnricher(),
 		InitFunc: func(params *EncryptParams, cmd *cobra.Command) error {
 			cmd.Aliases = []string{"e", "enc"}
 			return nil
 		},
 		RunFunc: func(params *EncryptParams, cmd *cobra.Command, args []string) {
-			if err := runEncrypt(params); err != nil {
+			if err := runEncrypt(context.Background(), params); err != nil {
 				fmt.Fprintf(os.Stderr, "crypt: %v\n", err)
 				os.Exit(1)
 			}
@@ -143,7 +160,7 @@ Examples:
 			return nil
 		},
 		RunFunc: func(params *DecryptParams, cmd *cobra.Command, args []string) {
-			if err := runDecrypt(params); err != nil {
+			if err := runDecrypt(context.Background(), params); err != nil {
 				fmt.Fprintf(os.Stderr, "crypt: %v\n", err)
 				os.Exit(1)
 			}
@@ -151,7 +168,7 @@ Examples:
 	}.ToCobra()
 }
 
-func runEncrypt(params *EncryptParams) error {
+func runEncrypt(ctx context.Context, params *EncryptParams) error {
 	if len(params.Files) == 0 {
 		return errors.New("no files specified")
 	}
@@ -165,6 +182,9 @@ func runEncrypt(params *EncryptParams) error {
 	if format != "age" && format != "openssl" {
 		return fmt.Errorf("unknown format: %s (use age or openssl)", params.Format)
 	}
+	if params.Authenticate && format != "openssl" {
+		return errors.New("--authenticate is only supported with the openssl format")
+	}
 
 	// Get password
 	password, err := getPassword(params.Password, true)
@@ -172,6 +192,10 @@ func runEncrypt(params *EncryptParams) error {
 		return err
 	}
 
+	// Only after the prompt, which must stay interruptible by Ctrl+C
+	ctx, cancel := interruptContext(ctx)
+	defer cancel()
+
 	// Determine file extension
 	ext := ".age"
 	if format == "openssl" {
@@ -195,18 +219,22 @@ func runEncrypt(params *EncryptParams) error {
 			fmt.Printf("encrypting %s -> %s (%s format)\n", inputPath, outputPath, format)
 		}
 
+		progress := progressOutput(params.Progress)
 		var encryptErr error
 		if format == "age" {
-			encryptErr = encryptFileAge(inputPath, outputPath, password)
+			encryptErr = encryptFileAge(ctx, inputPath, outputPath, password, progress)
 		} else {
-			encryptErr = encryptFileOpenSSL(inputPath, outputPath, password)
+			encryptErr = encryptFileOpenSSL(ctx, inputPath, outputPath, password, params.Authenticate, progress)
 		}
 
 		if encryptErr != nil {
 			return fmt.Errorf("failed to encrypt %s: %w", inputPath, encryptErr)
 		}
 
-		// Remove original if not keeping
+		// Remove original if not keeping, unless interrupted after the output was written
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if !params.Keep {
 			if err := os.Remove(inputPath); err != nil {
 				return fmt.Errorf("failed to remove original file %s: %w", inputPath, err)
@@ -217,7 +245,7 @@ func runEncrypt(params *EncryptParams) error {
 	return nil
 }
 
-func runDecrypt(params *DecryptParams) error {
+func runDecrypt(ctx context.Context, params *DecryptParams) error {
 	if len(params.Files) == 0 {
 		return errors.New("no files specified")
 	}
@@ -232,6 +260,10 @@ func runDecrypt(params *DecryptParams) error {
 		return err
 	}
 
+	// Only after the prompt, which must stay interruptible by Ctrl+C
+	ctx, cancel := interruptContext(ctx)
+	defer cancel()
+
 	for _, inputPath := range params.Files {
 		// Detect or use specified format
 		format := strings.ToLower(params.Format)
@@ -259,11 +291,16 @@ func runDecrypt(params *DecryptParams) error {
 			fmt.Printf("decrypting %s -> %s (%s format)\n", inputPath, outputPath, format)
 		}
 
+		if params.Authenticate && format != "openssl" {
+			return fmt.Errorf("--authenticate is only supported with the openssl format (%s is %s)", inputPath, format)
+		}
+
+		progress := progressOutput(params.Progress)
 		var decryptErr error
 		if format == "age" {
-			decryptErr = decryptFileAge(inputPath, outputPath, password)
+			decryptErr = decryptFileAge(ctx, inputPath, outputPath, password, progress)
 		} else if format == "openssl" {
-			decryptErr = decryptFileOpenSSL(inputPath, outputPath, password)
+			decryptErr = decryptFileOpenSSL(ctx, inputPath, outputPath, password, params.Authenticate, progress)
 		} else {
 			return fmt.Errorf("unknown format: %s", format)
 		}
@@ -272,7 +309,10 @@ func runDecrypt(params *DecryptParams) error {
 			return fmt.Errorf("failed to decrypt %s: %w", inputPath, decryptErr)
 		}
 
-		// Remove encrypted file if not keeping
+		// Remove encrypted file if not keeping, unless interrupted after the output was written
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if !params.Keep {
 			if err := os.Remove(inputPath); err != nil {
 				return fmt.Errorf("failed to remove encrypted file %s: %w", inputPath, err)
@@ -360,241 +400,202 @@ func getPassword(provided string, confirm bool) (string, error) {
 // Age format implementation
 // ============================================================================
 
-func encryptFileAge(inputPath, outputPath, password string) error {
-	// Read input file
-	plaintext, err := os.ReadFile(inputPath)
-	if err != nil {
-		return fmt.Errorf("cannot read input file: %w", err)
-	}
-
+func encryptFileAge(ctx context.Context, inputPath, outputPath, password string, progress io.Writer) error {
 	// Create scrypt recipient (for passphrase encryption)
 	recipient, err := age.NewScryptRecipient(password)
 	if err != nil {
 		return fmt.Errorf("failed to create recipient: %w", err)
 	}
 
-	// Get original file permissions
-	info, err := os.Stat(inputPath)
-	if err != nil {
-		return fmt.Errorf("cannot stat input file: %w", err)
-	}
-
-	// Ensure parent directory exists
-	if dir := filepath.Dir(outputPath); dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("cannot create output directory: %w", err)
+	return transformFile(ctx, inputPath, outputPath, progress, func(in io.Reader, out io.Writer) error {
+		// Create encrypted writer
+		w, err := age.Encrypt(out, recipient)
+		if err != nil {
+			return fmt.Errorf("failed to initialize encryption: %w", err)
 		}
-	}
-
-	// Create output file
-	outFile, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
-	if err != nil {
-		return fmt.Errorf("cannot create output file: %w", err)
-	}
-	defer outFile.Close()
-
-	// Create encrypted writer
-	w, err := age.Encrypt(outFile, recipient)
-	if err != nil {
-		os.Remove(outputPath)
-		return fmt.Errorf("failed to initialize encryption: %w", err)
-	}
-
-	// Write plaintext
-	if _, err := w.Write(plaintext); err != nil {
-		os.Remove(outputPath)
-		return fmt.Errorf("failed to write encrypted data: %w", err)
-	}
 
-	// Close to finalize encryption
-	if err := w.Close(); err != nil {
-		os.Remove(outputPath)
-		return fmt.Errorf("failed to finalize encryption: %w", err)
-	}
+		// Stream plaintext through it
+		if _, err := io.Copy(w, in); err != nil {
+			return fmt.Errorf("failed to write encrypted data: %w", err)
+		}
 
-	return nil
+		// Close to finalize encryption
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("failed to finalize encryption: %w", err)
+		}
+		return nil
+	})
 }
 
-func decryptFileAge(inputPath, outputPath, password string) error {
-	// Open input file
-	inFile, err := os.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("cannot open input file: %w", err)
-	}
-	defer inFile.Close()
-
+func decryptFileAge(ctx context.Context, inputPath, outputPath, password string, progress io.Writer) error {
 	// Create scrypt identity (for passphrase decryption)
 	identity, err := age.NewScryptIdentity(password)
 	if err != nil {
 		return fmt.Errorf("failed to create identity: %w", err)
 	}
 
-	// Create decrypted reader
-	r, err := age.Decrypt(inFile, identity)
-	if err != nil {
-		return errors.New("decryption failed: wrong password or corrupted file")
-	}
-
-	// Read all decrypted data
-	plaintext, err := io.ReadAll(r)
-	if err != nil {
-		return fmt.Errorf("failed to read decrypted data: %w", err)
-	}
-
-	// Get original file permissions
-	info, err := os.Stat(inputPath)
-	if err != nil {
-		return fmt.Errorf("cannot stat input file: %w", err)
-	}
-
-	// Ensure parent directory exists
-	if dir := filepath.Dir(outputPath); dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("cannot create output directory: %w", err)
+	return transformFile(ctx, inputPath, outputPath, progress, func(in io.Reader, out io.Writer) error {
+		// Create decrypted reader
+		r, err := age.Decrypt(in, identity)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.New("decryption failed: wrong password or corrupted file")
 		}
-	}
-
-	// Write output file
-	if err := os.WriteFile(outputPath, plaintext, info.Mode()); err != nil {
-		return fmt.Errorf("cannot write output file: %w", err)
-	}
 
-	return nil
+		// Stream decrypted data. age authenticates each chunk as it goes, and
+		// the output is discarded unless the whole file checks out.
+		if _, err := io.Copy(out, r); err != nil {
+			return fmt.Errorf("failed to read decrypted data: %w", err)
+		}
+		return nil
+	})
 }
 
 // ============================================================================
 // OpenSSL format implementation
 // Compatible with: openssl enc -aes-256-cbc -pbkdf2 -iter 600000
+//
+// With authenticate set, an HMAC-SHA256 tag over header, salt and ciphertext
+// is appended (encrypt-then-MAC). Such files are no longer readable by openssl.
 // ============================================================================
 
-func encryptFileOpenSSL(inputPath, outputPath, password string) error {
-	// Read input file
-	plaintext, err := os.ReadFile(inputPath)
-	if err != nil {
-		return fmt.Errorf("cannot read input file: %w", err)
-	}
-
-	// Generate random salt
-	salt := make([]byte, opensslSaltSize)
-	if _, err := rand.Read(salt); err != nil {
-		return fmt.Errorf("failed to generate salt: %w", err)
-	}
-
-	// Derive key and IV using PBKDF2
-	key, iv := deriveKeyAndIV([]byte(password), salt)
-
-	// Create AES cipher
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return fmt.Errorf("failed to create cipher: %w", err)
-	}
-
-	// Pad plaintext to block size (PKCS7)
-	plaintext = pkcs7Pad(plaintext, aes.BlockSize)
-
-	// Encrypt using CBC mode
-	ciphertext := make([]byte, len(plaintext))
-	mode := cipher.NewCBCEncrypter(block, iv)
-	mode.CryptBlocks(ciphertext, plaintext)
+func encryptFileOpenSSL(ctx context.Context, inputPath, outputPath, password string, authenticate bool, progress io.Writer) error {
+	return transformFile(ctx, inputPath, outputPath, progress, func(in io.Reader, out io.Writer) error {
+		// Read input file
+		plaintext, err := io.ReadAll(in)
+		if err != nil {
+			return fmt.Errorf("cannot read input file: %w", err)
+		}
 
-	// Build output: "Salted__" + salt + ciphertext
-	output := make([]byte, 0, len(opensslSaltHeader)+opensslSaltSize+len(ciphertext))
-	output = append(output, []byte(opensslSaltHeader)...)
-	output = append(output, salt...)
-	output = append(output, ciphertext...)
+		// Generate random salt
+		salt := make([]byte, opensslSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
 
-	// Get original file permissions
-	info, err := os.Stat(inputPath)
-	if err != nil {
-		return fmt.Errorf("cannot stat input file: %w", err)
-	}
+		// Derive key and IV using PBKDF2
+		key, iv, macKey := deriveOpenSSLKeys([]byte(password), salt, authenticate)
 
-	// Ensure parent directory exists
-	if dir := filepath.Dir(outputPath); dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("cannot create output directory: %w", err)
+		// Create AES cipher
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return fmt.Errorf("failed to create cipher: %w", err)
 		}
-	}
 
-	// Write output file
-	if err := os.WriteFile(outputPath, output, info.Mode()); err != nil {
-		return fmt.Errorf("cannot write output file: %w", err)
-	}
+		// Pad plaintext to block size (PKCS7)
+		plaintext = pkcs7Pad(plaintext, aes.BlockSize)
+
+		// Encrypt using CBC mode
+		ciphertext := make([]byte, len(plaintext))
+		mode := cipher.NewCBCEncrypter(block, iv)
+		mode.CryptBlocks(ciphertext, plaintext)
+
+		// Build output: "Salted__" + salt + ciphertext
+		output := make([]byte, 0, len(opensslSaltHeader)+opensslSaltSize+len(ciphertext))
+		output = append(output, []byte(opensslSaltHeader)...)
+		output = append(output, salt...)
+		output = append(output, ciphertext...)
+		if authenticate {
+			output = append(output, computeMAC(macKey, output)...)
+		}
 
-	return nil
+		if _, err := out.Write(output); err != nil {
+			return fmt.Errorf("cannot write output file: %w", err)
+		}
+		return nil
+	})
 }
 
-func decryptFileOpenSSL(inputPath, outputPath, password string) error {
-	// Read input file
-	data, err := os.ReadFile(inputPath)
-	if err != nil {
-		return fmt.Errorf("cannot read input file: %w", err)
-	}
-
-	// Verify header
-	headerLen := len(opensslSaltHeader) + opensslSaltSize
-	if len(data) < headerLen {
-		return errors.New("invalid openssl encrypted file: too short")
-	}
+func decryptFileOpenSSL(ctx context.Context, inputPath, outputPath, password string, authenticate bool, progress io.Writer) error {
+	return transformFile(ctx, inputPath, outputPath, progress, func(in io.Reader, out io.Writer) error {
+		// Read input file
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return fmt.Errorf("cannot read input file: %w", err)
+		}
 
-	if string(data[:len(opensslSaltHeader)]) != opensslSaltHeader {
-		return errors.New("invalid openssl encrypted file: missing salt header")
-	}
+		// Verify header
+		headerLen := len(opensslSaltHeader) + opensslSaltSize
+		if len(data) < headerLen {
+			return errors.New("invalid openssl encrypted file: too short")
+		}
 
-	// Extract salt and ciphertext
-	salt := data[len(opensslSaltHeader):headerLen]
-	ciphertext := data[headerLen:]
+		if string(data[:len(opensslSaltHeader)]) != opensslSaltHeader {
+			return errors.New("invalid openssl encrypted file: missing salt header")
+		}
 
-	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
-		return errors.New("invalid openssl encrypted file: invalid ciphertext length")
-	}
+		// Extract salt and ciphertext
+		salt := data[len(opensslSaltHeader):headerLen]
+		ciphertext := data[headerLen:]
 
-	// Derive key and IV using PBKDF2
-	key, iv := deriveKeyAndIV([]byte(password), salt)
+		// Derive key and IV using PBKDF2
+		key, iv, macKey := deriveOpenSSLKeys([]byte(password), salt, authenticate)
 
-	// Create AES cipher
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return fmt.Errorf("failed to create cipher: %w", err)
-	}
+		// Verify and strip the MAC before touching the ciphertext
+		if authenticate {
+			if len(ciphertext) < opensslMACSize {
+				return errors.New("invalid openssl encrypted file: missing authentication tag")
+			}
+			tagStart := len(data) - opensslMACSize
+			if !hmac.Equal(data[tagStart:], computeMAC(macKey, data[:tagStart])) {
+				return errors.New("authentication failed: wrong password or file has been modified")
+			}
+			ciphertext = data[headerLen:tagStart]
+		}
 
-	// Decrypt using CBC mode
-	plaintext := make([]byte, len(ciphertext))
-	mode := cipher.NewCBCDecrypter(block, iv)
-	mode.CryptBlocks(plaintext, ciphertext)
+		if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return errors.New("invalid openssl encrypted file: invalid ciphertext length")
+		}
 
-	// Remove PKCS7 padding
-	plaintext, err = pkcs7Unpad(plaintext)
-	if err != nil {
-		return errors.New("decryption failed: wrong password or corrupted file")
-	}
+		// Create AES cipher
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return fmt.Errorf("failed to create cipher: %w", err)
+		}
 
-	// Get original file permissions
-	info, err := os.Stat(inputPath)
-	if err != nil {
-		return fmt.Errorf("cannot stat input file: %w", err)
-	}
+		// Decrypt using CBC mode
+		plaintext := make([]byte, len(ciphertext))
+		mode := cipher.NewCBCDecrypter(block, iv)
+		mode.CryptBlocks(plaintext, ciphertext)
 
-	// Ensure parent directory exists
-	if dir := filepath.Dir(outputPath); dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("cannot create output directory: %w", err)
+		// Remove PKCS7 padding
+		plaintext, err = pkcs7Unpad(plaintext)
+		if err != nil {
+			return errors.New("decryption failed: wrong password or corrupted file")
 		}
-	}
 
-	// Write output file
-	if err := os.WriteFile(outputPath, plaintext, info.Mode()); err != nil {
-		return fmt.Errorf("cannot write output file: %w", err)
-	}
+		if _, err := out.Write(plaintext); err != nil {
+			return fmt.Errorf("cannot write output file: %w", err)
+		}
+		return nil
+	})
+}
 
-	return nil
+// deriveOpenSSLKeys derives the AES key, IV and (if withMAC) an HMAC key.
+// PBKDF2 output is prefix-stable, so key and IV are identical to what
+// openssl derives; the MAC key is simply taken from the bytes that follow.
+func deriveOpenSSLKeys(password, salt []byte, withMAC bool) (key, iv, macKey []byte) {
+	size := opensslKeySize + opensslIVSize
+	if withMAC {
+		size += opensslMACKeySize
+	}
+	// Derive key material using PBKDF2 with SHA-256
+	derived := pbkdf2.Key(password, salt, opensslIterations, size, sha256.New)
+	key = derived[:opensslKeySize]
+	iv = derived[opensslKeySize : opensslKeySize+opensslIVSize]
+	if withMAC {
+		macKey = derived[opensslKeySize+opensslIVSize:]
+	}
+	return key, iv, macKey
 }
 
-// deriveKeyAndIV derives a key and IV from password and salt using PBKDF2
-func deriveKeyAndIV(password, salt []byte) (key, iv []byte) {
-	// Derive key+IV material using PBKDF2 with SHA-256
-	derived := pbkdf2.Key(password, salt, opensslIterations, opensslKeySize+opensslIVSize, sha256.New)
-	return derived[:opensslKeySize], derived[opensslKeySize:]
+// computeMAC returns HMAC-SHA256 of data under macKey
+func computeMAC(macKey, data []byte) []byte {
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(data)
+	return mac.Sum(nil)
 }
 
 // pkcs7Pad pads data to a multiple of blockSize using PKCS7
@@ -627,3 +628,167 @@ func pkcs7Unpad(data []byte) ([]byte, error) {
 
 	return data[:len(data)-padding], nil
 }
+
+// ============================================================================
+// Streaming, progress and cancellation
+// ============================================================================
+
+// interruptContext returns a context that is cancelled on SIGINT or SIGTERM,
+// so an interrupted run gets to remove its partial output before exiting.
+// It replaces the default handling, so it must not be active while blocked
+// on something that doesn't watch the context, like the password prompt.
+func interruptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(sigCh)
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// progressOutput returns where progress bars are drawn, or nil when disabled.
+func progressOutput(enabled bool) io.Writer {
+	if !enabled {
+		return nil
+	}
+	return os.Stderr
+}
+
+// transformFile streams inputPath through transform into outputPath, which
+// gets the permissions of the input. The output is written to a temporary
+// file next to it and only renamed into place when transform succeeds, so
+// errors and cancellation never leave a truncated file that looks complete.
+// Reading the input fails once ctx is cancelled. With progress set, a bar
+// for the bytes read so far is drawn on it.
+func transformFile(ctx context.Context, inputPath, outputPath string, progress io.Writer, transform func(in io.Reader, out io.Writer) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	inFile, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("cannot open input file: %w", err)
+	}
+	defer inFile.Close()
+
+	// Get original file permissions
+	info, err := inFile.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot stat input file: %w", err)
+	}
+
+	in := &cancelReader{ctx: ctx, r: inFile}
+	if progress != nil {
+		in.bar = &progressBar{out: progress, name: filepath.Base(inputPath), total: info.Size()}
+		defer in.bar.finish()
+	}
+
+	// Ensure parent directory exists
+	dir := filepath.Dir(outputPath)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("cannot create output directory: %w", err)
+		}
+	}
+
+	tmpFile, err := os.CreateTemp(dir, "."+filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create output file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if err := transform(in, tmpFile); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("cannot write output file: %w", err)
+	}
+	// The openssl format reads all input before doing the work, so an
+	// interrupt during it isn't noticed by the reader
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("cannot set output file permissions: %w", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return fmt.Errorf("cannot write output file: %w", err)
+	}
+	return nil
+}
+
+// cancelReader stops reading once ctx is cancelled and feeds the optional
+// progress bar.
+type cancelReader struct {
+	ctx context.Context
+	r   io.Reader
+	bar *progressBar
+}
+
+func (c *cancelReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	if c.bar != nil {
+		c.bar.add(n)
+	}
+	return n, err
+}
+
+// progressBar draws the bytes of a file processed so far on a single line,
+// redrawn at most every 100ms.
+type progressBar struct {
+	out      io.Writer
+	name     string
+	total    int64
+	done     int64
+	lastDraw time.Time
+}
+
+func (p *progressBar) add(n int) {
+	p.done += int64(n)
+	if time.Since(p.lastDraw) >= 100*time.Millisecond {
+		p.draw()
+	}
+}
+
+func (p *progressBar) draw() {
+	p.lastDraw = time.Now()
+	percent := 100.0
+	if p.total > 0 {
+		percent = min(100, float64(p.done)/float64(p.total)*100)
+	}
+	const barWidth = 30
+	filled := int(percent / 100 * barWidth)
+	fmt.Fprintf(p.out, "\r%s [%s%s] %5.1f%% %s/%s", p.name,
+		strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled),
+		percent, formatBytes(p.done), formatBytes(p.total))
+}
+
+// finish draws the final state and ends the line.
+func (p *progressBar) finish() {
+	p.draw()
+	fmt.Fprintln(p.out)
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -4,6 +4,7 @@ package crypt
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -67,7 +68,7 @@ func TestInteropTofuEncryptAgeDecrypt(t *testing.T) {
 	}
 
 	// Encrypt with tofu
-	if err := encryptFileAge(inputFile, encFile, password); err != nil {
+	if err := encryptFileAge(context.Background(), inputFile, encFile, password, nil); err != nil {
 		t.Fatalf("tofu encryption failed: %v", err)
 	}
 
@@ -116,7 +117,7 @@ func TestInteropAgeEncryptTofuDecrypt(t *testing.T) {
 	}
 
 	// Decrypt with tofu
-	if err := decryptFileAge(encFile, decFile, password); err != nil {
+	if err := decryptFileAge(context.Background(), encFile, decFile, password, nil); err != nil {
 		t.Fatalf("tofu decryption failed: %v", err)
 	}
 
@@ -155,7 +156,7 @@ func TestInteropAgeBinaryData(t *testing.T) {
 
 		os.WriteFile(inputFile, content, 0644)
 
-		if err := encryptFileAge(inputFile, encFile, password); err != nil {
+		if err := encryptFileAge(context.Background(), inputFile, encFile, password, nil); err != nil {
 			t.Fatalf("tofu encryption failed: %v", err)
 		}
 
@@ -182,7 +183,7 @@ func TestInteropAgeBinaryData(t *testing.T) {
 			t.Fatalf("age encryption failed: %v\nOutput: %s", err, output)
 		}
 
-		if err := decryptFileAge(encFile, decFile, password); err != nil {
+		if err := decryptFileAge(context.Background(), encFile, decFile, password, nil); err != nil {
 			t.Fatalf("tofu decryption failed: %v", err)
 		}
 
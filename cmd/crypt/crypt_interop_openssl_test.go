@@ -4,6 +4,7 @@ package crypt
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -35,7 +36,7 @@ func TestInteropTofuEncryptOpenSSLDecrypt(t *testing.T) {
 	}
 
 	// Encrypt with tofu
-	if err := encryptFileOpenSSL(inputFile, encFile, password); err != nil {
+	if err := encryptFileOpenSSL(context.Background(), inputFile, encFile, password, false, nil); err != nil {
 		t.Fatalf("tofu encryption failed: %v", err)
 	}
 
@@ -91,7 +92,7 @@ func TestInteropOpenSSLEncryptTofuDecrypt(t *testing.T) {
 	}
 
 	// Decrypt with tofu
-	if err := decryptFileOpenSSL(encFile, decFile, password); err != nil {
+	if err := decryptFileOpenSSL(context.Background(), encFile, decFile, password, false, nil); err != nil {
 		t.Fatalf("tofu decryption failed: %v", err)
 	}
 
@@ -127,7 +128,7 @@ func TestInteropOpenSSLTofuRoundtripLargeFile(t *testing.T) {
 		os.WriteFile(inputFile, content, 0644)
 
 		// tofu encrypt
-		if err := encryptFileOpenSSL(inputFile, encFile, password); err != nil {
+		if err := encryptFileOpenSSL(context.Background(), inputFile, encFile, password, false, nil); err != nil {
 			t.Fatalf("tofu encryption failed: %v", err)
 		}
 
@@ -150,7 +151,7 @@ func TestInteropOpenSSLTofuRoundtripLargeFile(t *testing.T) {
 		}
 
 		// tofu decrypt
-		if err := decryptFileOpenSSL(reencFile, finalFile, password); err != nil {
+		if err := decryptFileOpenSSL(context.Background(), reencFile, finalFile, password, false, nil); err != nil {
 			t.Fatalf("tofu decryption failed: %v", err)
 		}
 
@@ -183,7 +184,7 @@ func TestInteropOpenSSLBinaryData(t *testing.T) {
 
 		os.WriteFile(inputFile, content, 0644)
 
-		if err := encryptFileOpenSSL(inputFile, encFile, password); err != nil {
+		if err := encryptFileOpenSSL(context.Background(), inputFile, encFile, password, false, nil); err != nil {
 			t.Fatalf("tofu encryption failed: %v", err)
 		}
 
@@ -216,7 +217,7 @@ func TestInteropOpenSSLBinaryData(t *testing.T) {
 			t.Fatalf("openssl encryption failed: %v\nOutput: %s", err, output)
 		}
 
-		if err := decryptFileOpenSSL(encFile, decFile, password); err != nil {
+		if err := decryptFileOpenSSL(context.Background(), encFile, decFile, password, false, nil); err != nil {
 			t.Fatalf("tofu decryption failed: %v", err)
 		}
 
@@ -243,7 +244,7 @@ func TestInteropOpenSSLEmptyFile(t *testing.T) {
 
 		os.WriteFile(inputFile, content, 0644)
 
-		if err := encryptFileOpenSSL(inputFile, encFile, password); err != nil {
+		if err := encryptFileOpenSSL(context.Background(), inputFile, encFile, password, false, nil); err != nil {
 			t.Fatalf("tofu encryption failed: %v", err)
 		}
 
@@ -276,7 +277,7 @@ func TestInteropOpenSSLEmptyFile(t *testing.T) {
 			t.Fatalf("openssl encryption failed: %v\nOutput: %s", err, output)
 		}
 
-		if err := decryptFileOpenSSL(encFile, decFile, password); err != nil {
+		if err := decryptFileOpenSSL(context.Background(), encFile, decFile, password, false, nil); err != nil {
 			t.Fatalf("tofu decryption failed: %v", err)
 		}
 
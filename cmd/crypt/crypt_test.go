@@ -2,6 +2,9 @@ package crypt
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -35,7 +38,7 @@ func TestEncryptDecryptRoundtripAge(t *testing.T) {
 			password := "testpassword123"
 
 			// Encrypt
-			if err := encryptFileAge(inputFile, encFile, password); err != nil {
+			if err := encryptFileAge(context.Background(), inputFile, encFile, password, nil); err != nil {
 				t.Fatalf("encryption failed: %v", err)
 			}
 
@@ -55,7 +58,7 @@ func TestEncryptDecryptRoundtripAge(t *testing.T) {
 			}
 
 			// Decrypt
-			if err := decryptFileAge(encFile, decFile, password); err != nil {
+			if err := decryptFileAge(context.Background(), encFile, decFile, password, nil); err != nil {
 				t.Fatalf("decryption failed: %v", err)
 			}
 
@@ -99,7 +102,7 @@ func TestEncryptDecryptRoundtripOpenSSL(t *testing.T) {
 			password := "testpassword123"
 
 			// Encrypt
-			if err := encryptFileOpenSSL(inputFile, encFile, password); err != nil {
+			if err := encryptFileOpenSSL(context.Background(), inputFile, encFile, password, false, nil); err != nil {
 				t.Fatalf("encryption failed: %v", err)
 			}
 
@@ -115,7 +118,7 @@ func TestEncryptDecryptRoundtripOpenSSL(t *testing.T) {
 			}
 
 			// Decrypt
-			if err := decryptFileOpenSSL(encFile, decFile, password); err != nil {
+			if err := decryptFileOpenSSL(context.Background(), encFile, decFile, password, false, nil); err != nil {
 				t.Fatalf("decryption failed: %v", err)
 			}
 
@@ -144,12 +147,12 @@ func TestDecryptWrongPasswordAge(t *testing.T) {
 	}
 
 	// Encrypt with one password
-	if err := encryptFileAge(inputFile, encFile, "correctpassword"); err != nil {
+	if err := encryptFileAge(context.Background(), inputFile, encFile, "correctpassword", nil); err != nil {
 		t.Fatalf("encryption failed: %v", err)
 	}
 
 	// Try to decrypt with wrong password
-	err := decryptFileAge(encFile, decFile, "wrongpassword")
+	err := decryptFileAge(context.Background(), encFile, decFile, "wrongpassword", nil)
 	if err == nil {
 		t.Error("decryption should fail with wrong password")
 	}
@@ -167,12 +170,12 @@ func TestDecryptWrongPasswordOpenSSL(t *testing.T) {
 	}
 
 	// Encrypt with one password
-	if err := encryptFileOpenSSL(inputFile, encFile, "correctpassword"); err != nil {
+	if err := encryptFileOpenSSL(context.Background(), inputFile, encFile, "correctpassword", false, nil); err != nil {
 		t.Fatalf("encryption failed: %v", err)
 	}
 
 	// Try to decrypt with wrong password
-	err := decryptFileOpenSSL(encFile, decFile, "wrongpassword")
+	err := decryptFileOpenSSL(context.Background(), encFile, decFile, "wrongpassword", false, nil)
 	if err == nil {
 		t.Error("decryption should fail with wrong password")
 	}
@@ -245,7 +248,7 @@ func TestRunEncryptValidation(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Test no files specified
-	err := runEncrypt(&EncryptParams{
+	err := runEncrypt(context.Background(), &EncryptParams{
 		Password: "test",
 		Format:   "age",
 	})
@@ -259,7 +262,7 @@ func TestRunEncryptValidation(t *testing.T) {
 	os.WriteFile(file1, []byte("test1"), 0644)
 	os.WriteFile(file2, []byte("test2"), 0644)
 
-	err = runEncrypt(&EncryptParams{
+	err = runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{file1, file2},
 		Output:   "single.enc",
 		Password: "test",
@@ -270,7 +273,7 @@ func TestRunEncryptValidation(t *testing.T) {
 	}
 
 	// Test unknown format
-	err = runEncrypt(&EncryptParams{
+	err = runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{file1},
 		Password: "test",
 		Format:   "unknown",
@@ -284,7 +287,7 @@ func TestRunDecryptValidation(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	// Test no files specified
-	err := runDecrypt(&DecryptParams{
+	err := runDecrypt(context.Background(), &DecryptParams{
 		Password: "test",
 		Format:   "auto",
 	})
@@ -298,7 +301,7 @@ func TestRunDecryptValidation(t *testing.T) {
 	os.WriteFile(file1, []byte("test1"), 0644)
 	os.WriteFile(file2, []byte("test2"), 0644)
 
-	err = runDecrypt(&DecryptParams{
+	err = runDecrypt(context.Background(), &DecryptParams{
 		Files:    []string{file1, file2},
 		Output:   "single.txt",
 		Password: "test",
@@ -315,7 +318,7 @@ func TestOutputFileNamingAge(t *testing.T) {
 	inputFile := filepath.Join(tmpDir, "document.pdf")
 	os.WriteFile(inputFile, []byte("pdf content"), 0644)
 
-	err := runEncrypt(&EncryptParams{
+	err := runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{inputFile},
 		Password: "test",
 		Format:   "age",
@@ -333,7 +336,7 @@ func TestOutputFileNamingAge(t *testing.T) {
 	// Test decrypt removes .age
 	os.Remove(inputFile)
 
-	err = runDecrypt(&DecryptParams{
+	err = runDecrypt(context.Background(), &DecryptParams{
 		Files:    []string{encFile},
 		Password: "test",
 		Format:   "auto",
@@ -354,7 +357,7 @@ func TestOutputFileNamingOpenSSL(t *testing.T) {
 	inputFile := filepath.Join(tmpDir, "document.pdf")
 	os.WriteFile(inputFile, []byte("pdf content"), 0644)
 
-	err := runEncrypt(&EncryptParams{
+	err := runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{inputFile},
 		Password: "test",
 		Format:   "openssl",
@@ -372,7 +375,7 @@ func TestOutputFileNamingOpenSSL(t *testing.T) {
 	// Test decrypt removes .enc
 	os.Remove(inputFile)
 
-	err = runDecrypt(&DecryptParams{
+	err = runDecrypt(context.Background(), &DecryptParams{
 		Files:    []string{encFile},
 		Password: "test",
 		Format:   "auto",
@@ -396,7 +399,7 @@ func TestForceOverwrite(t *testing.T) {
 	os.WriteFile(encFile, []byte("existing"), 0644)
 
 	// Without force, should fail
-	err := runEncrypt(&EncryptParams{
+	err := runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{inputFile},
 		Password: "test",
 		Format:   "age",
@@ -408,7 +411,7 @@ func TestForceOverwrite(t *testing.T) {
 	}
 
 	// With force, should succeed
-	err = runEncrypt(&EncryptParams{
+	err = runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{inputFile},
 		Password: "test",
 		Format:   "age",
@@ -428,7 +431,7 @@ func TestKeepOriginalFiles(t *testing.T) {
 	os.WriteFile(inputFile, []byte("test content"), 0644)
 
 	// With keep=true, original should remain
-	err := runEncrypt(&EncryptParams{
+	err := runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{inputFile},
 		Password: "test",
 		Format:   "age",
@@ -446,7 +449,7 @@ func TestKeepOriginalFiles(t *testing.T) {
 	inputFile2 := filepath.Join(tmpDir, "input2.txt")
 	os.WriteFile(inputFile2, []byte("test content 2"), 0644)
 
-	err = runEncrypt(&EncryptParams{
+	err = runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{inputFile2},
 		Password: "test",
 		Format:   "age",
@@ -461,7 +464,7 @@ func TestKeepOriginalFiles(t *testing.T) {
 	}
 
 	// For decrypt
-	err = runDecrypt(&DecryptParams{
+	err = runDecrypt(context.Background(), &DecryptParams{
 		Files:    []string{encFile},
 		Password: "test",
 		Format:   "auto",
@@ -481,7 +484,7 @@ func TestEncryptNonexistentFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	nonexistent := filepath.Join(tmpDir, "doesnotexist.txt")
 
-	err := runEncrypt(&EncryptParams{
+	err := runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{nonexistent},
 		Password: "test",
 		Format:   "age",
@@ -504,7 +507,7 @@ func TestMultipleFilesEncryption(t *testing.T) {
 		os.WriteFile(f, []byte("content "+string(rune('A'+i))), 0644)
 	}
 
-	err := runEncrypt(&EncryptParams{
+	err := runEncrypt(context.Background(), &EncryptParams{
 		Files:    files,
 		Password: "test",
 		Format:   "age",
@@ -529,7 +532,7 @@ func TestMultipleFilesEncryption(t *testing.T) {
 	}
 
 	// Decrypt all
-	err = runDecrypt(&DecryptParams{
+	err = runDecrypt(context.Background(), &DecryptParams{
 		Files:    encFiles,
 		Password: "test",
 		Format:   "auto",
@@ -564,7 +567,7 @@ func TestEncryptPreservesFilePermissions(t *testing.T) {
 	}
 
 	// Encrypt
-	if err := encryptFileAge(inputFile, encFile, "password"); err != nil {
+	if err := encryptFileAge(context.Background(), inputFile, encFile, "password", nil); err != nil {
 		t.Fatalf("encryption failed: %v", err)
 	}
 
@@ -658,7 +661,7 @@ func TestDecryptCorruptedOpenSSL(t *testing.T) {
 				t.Fatalf("failed to write corrupted file: %v", err)
 			}
 
-			err := decryptFileOpenSSL(encFile, decFile, "password")
+			err := decryptFileOpenSSL(context.Background(), encFile, decFile, "password", false, nil)
 			if err == nil {
 				t.Error("decryption should fail for corrupted file")
 			}
@@ -676,7 +679,7 @@ func TestAutoFormatDetectionIntegration(t *testing.T) {
 	ageDec := filepath.Join(tmpDir, "age_dec.txt")
 	os.WriteFile(ageInput, content, 0644)
 
-	err := runEncrypt(&EncryptParams{
+	err := runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{ageInput},
 		Password: "test",
 		Format:   "age",
@@ -687,7 +690,7 @@ func TestAutoFormatDetectionIntegration(t *testing.T) {
 	}
 
 	// Decrypt with auto detection
-	err = runDecrypt(&DecryptParams{
+	err = runDecrypt(context.Background(), &DecryptParams{
 		Files:    []string{ageEnc},
 		Output:   ageDec,
 		Password: "test",
@@ -709,7 +712,7 @@ func TestAutoFormatDetectionIntegration(t *testing.T) {
 	opensslDec := filepath.Join(tmpDir, "openssl_dec.txt")
 	os.WriteFile(opensslInput, content, 0644)
 
-	err = runEncrypt(&EncryptParams{
+	err = runEncrypt(context.Background(), &EncryptParams{
 		Files:    []string{opensslInput},
 		Password: "test",
 		Format:   "openssl",
@@ -720,7 +723,7 @@ func TestAutoFormatDetectionIntegration(t *testing.T) {
 	}
 
 	// Decrypt with auto detection
-	err = runDecrypt(&DecryptParams{
+	err = runDecrypt(context.Background(), &DecryptParams{
 		Files:    []string{opensslEnc},
 		Output:   opensslDec,
 		Password: "test",
@@ -736,3 +739,257 @@ func TestAutoFormatDetectionIntegration(t *testing.T) {
 		t.Error("openssl auto-detect decryption content mismatch")
 	}
 }
+
+func TestAuthenticatedOpenSSL(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "secret.txt")
+	encFile := filepath.Join(tmpDir, "secret.txt.enc")
+	decFile := filepath.Join(tmpDir, "decrypted.txt")
+
+	content := []byte("This is an authenticated secret message")
+	if err := os.WriteFile(inputFile, content, 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+
+	if err := encryptFileOpenSSL(context.Background(), inputFile, encFile, "password", true, nil); err != nil {
+		t.Fatalf("encryption failed: %v", err)
+	}
+
+	encContent, err := os.ReadFile(encFile)
+	if err != nil {
+		t.Fatalf("failed to read encrypted file: %v", err)
+	}
+	if !strings.HasPrefix(string(encContent), opensslSaltHeader) {
+		t.Error("encrypted file should start with Salted__ header")
+	}
+
+	// Unmodified file decrypts
+	if err := decryptFileOpenSSL(context.Background(), encFile, decFile, "password", true, nil); err != nil {
+		t.Fatalf("decryption failed: %v", err)
+	}
+	decContent, err := os.ReadFile(decFile)
+	if err != nil {
+		t.Fatalf("failed to read decrypted file: %v", err)
+	}
+	if !bytes.Equal(decContent, content) {
+		t.Errorf("decrypted content doesn't match original: got %q, want %q", decContent, content)
+	}
+
+	// Wrong password is rejected by the MAC
+	if err := decryptFileOpenSSL(context.Background(), encFile, decFile, "wrong", true, nil); err == nil {
+		t.Error("decryption should fail with wrong password")
+	}
+
+	// Flipping a single bit anywhere must be detected
+	headerLen := len(opensslSaltHeader) + opensslSaltSize
+	for _, pos := range []int{len(opensslSaltHeader), headerLen, len(encContent) - opensslMACSize - 1, len(encContent) - 1} {
+		tampered := bytes.Clone(encContent)
+		tampered[pos] ^= 0x01
+		tamperedFile := filepath.Join(tmpDir, "tampered.enc")
+		if err := os.WriteFile(tamperedFile, tampered, 0644); err != nil {
+			t.Fatalf("failed to write tampered file: %v", err)
+		}
+
+		err := decryptFileOpenSSL(context.Background(), tamperedFile, decFile, "password", true, nil)
+		if err == nil {
+			t.Errorf("decryption should fail for bit flipped at offset %d", pos)
+		} else if !strings.Contains(err.Error(), "authentication failed") {
+			t.Errorf("expected authentication failure at offset %d, got: %v", pos, err)
+		}
+	}
+
+	// Truncated file without a full tag is rejected
+	truncatedFile := filepath.Join(tmpDir, "truncated.enc")
+	if err := os.WriteFile(truncatedFile, encContent[:headerLen+opensslMACSize-1], 0644); err != nil {
+		t.Fatalf("failed to write truncated file: %v", err)
+	}
+	if err := decryptFileOpenSSL(context.Background(), truncatedFile, decFile, "password", true, nil); err == nil {
+		t.Error("decryption should fail for truncated file")
+	}
+}
+
+func TestAuthenticatedOpenSSLKeepsKeyAndIV(t *testing.T) {
+	// The MAC key is appended to the PBKDF2 output, so key and IV must stay
+	// identical to the unauthenticated (openssl compatible) derivation.
+	salt := []byte("12345678")
+	key, iv, macKey := deriveOpenSSLKeys([]byte("password"), salt, false)
+	authKey, authIV, authMACKey := deriveOpenSSLKeys([]byte("password"), salt, true)
+
+	if macKey != nil {
+		t.Error("expected no MAC key without authentication")
+	}
+	if len(authMACKey) != opensslMACKeySize {
+		t.Errorf("expected MAC key of %d bytes, got %d", opensslMACKeySize, len(authMACKey))
+	}
+	if !bytes.Equal(key, authKey) || !bytes.Equal(iv, authIV) {
+		t.Error("authenticated derivation should not change key or IV")
+	}
+}
+
+func TestRunEncryptAuthenticateRequiresOpenSSL(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+
+	err := runEncrypt(context.Background(), &EncryptParams{
+		Files:        []string{inputFile},
+		Password:     "password",
+		Format:       "age",
+		Authenticate: true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "--authenticate") {
+		t.Errorf("expected --authenticate error for age format, got: %v", err)
+	}
+}
+
+// cancelOnWrite cancels its context as soon as a progress bar is drawn,
+// simulating Ctrl+C in the middle of a file.
+type cancelOnWrite struct {
+	cancel context.CancelFunc
+}
+
+func (c cancelOnWrite) Write(p []byte) (int, error) {
+	c.cancel()
+	return len(p), nil
+}
+
+func TestCancelLeavesNoPartialOutput(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 256*1024) // 4MB
+
+	tests := []struct {
+		name    string
+		encrypt bool
+		run     func(ctx context.Context, in, out string, progress io.Writer) error
+	}{
+		{"encrypt age", true, func(ctx context.Context, in, out string, progress io.Writer) error {
+			return encryptFileAge(ctx, in, out, "password", progress)
+		}},
+		{"encrypt openssl", true, func(ctx context.Context, in, out string, progress io.Writer) error {
+			return encryptFileOpenSSL(ctx, in, out, "password", false, progress)
+		}},
+		{"decrypt age", false, func(ctx context.Context, in, out string, progress io.Writer) error {
+			return decryptFileAge(ctx, in, out, "password", progress)
+		}},
+		{"decrypt openssl", false, func(ctx context.Context, in, out string, progress io.Writer) error {
+			return decryptFileOpenSSL(ctx, in, out, "password", false, progress)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			inputFile := filepath.Join(tmpDir, "input")
+			outputFile := filepath.Join(tmpDir, "output")
+
+			if err := os.WriteFile(inputFile, content, 0644); err != nil {
+				t.Fatalf("failed to write input file: %v", err)
+			}
+			if !tt.encrypt {
+				// Decrypt a real ciphertext, so it fails by cancellation only
+				plainFile := filepath.Join(tmpDir, "plain")
+				os.Rename(inputFile, plainFile)
+				encrypt := encryptFileAge
+				if strings.HasSuffix(tt.name, "openssl") {
+					encrypt = func(ctx context.Context, in, out, password string, progress io.Writer) error {
+						return encryptFileOpenSSL(ctx, in, out, password, false, progress)
+					}
+				}
+				if err := encrypt(context.Background(), plainFile, inputFile, "password", nil); err != nil {
+					t.Fatalf("encryption failed: %v", err)
+				}
+				os.Remove(plainFile)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			err := tt.run(ctx, inputFile, outputFile, cancelOnWrite{cancel})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected cancellation error, got %v", err)
+			}
+
+			entries, _ := os.ReadDir(tmpDir)
+			if len(entries) != 1 || entries[0].Name() != "input" {
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				t.Errorf("expected only the input file to remain, got %v", names)
+			}
+		})
+	}
+}
+
+func TestCancelAfterReadLeavesNoOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "input")
+	if err := os.WriteFile(inputFile, []byte("secret"), 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+
+	// Like the openssl format, read everything before being interrupted
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := transformFile(ctx, inputFile, filepath.Join(tmpDir, "output"), nil, func(in io.Reader, out io.Writer) error {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		cancel()
+		_, err = out.Write(data)
+		return err
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation error, got %v", err)
+	}
+
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 1 || entries[0].Name() != "input" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected only the input file to remain, got %v", names)
+	}
+}
+
+func TestRunEncryptCancelledKeepsOriginal(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte("secret"), 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := runEncrypt(ctx, &EncryptParams{Files: []string{inputFile}, Password: "password", Format: "age"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation error, got %v", err)
+	}
+	if _, err := os.Stat(inputFile); err != nil {
+		t.Errorf("original file should be kept when cancelled: %v", err)
+	}
+	if _, err := os.Stat(inputFile + ".age"); !os.IsNotExist(err) {
+		t.Errorf("no output should be written when cancelled")
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "input.txt")
+	encFile := filepath.Join(tmpDir, "input.txt.enc")
+	if err := os.WriteFile(inputFile, bytes.Repeat([]byte("A"), 2048), 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+
+	var progress bytes.Buffer
+	if err := encryptFileOpenSSL(context.Background(), inputFile, encFile, "password", false, &progress); err != nil {
+		t.Fatalf("encryption failed: %v", err)
+	}
+
+	expected := "\rinput.txt [==============================] 100.0% 2.0 KB/2.0 KB\n"
+	if got := progress.String(); !strings.HasSuffix(got, expected) {
+		t.Errorf("expected progress to end with %q, got %q", expected, got)
+	}
+}
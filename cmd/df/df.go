@@ -21,6 +21,11 @@ type Params struct {
 	Type    string   `short:"t" help:"Limit listing to filesystems of a specific type." default:""`
 	Sort    string   `short:"S" help:"Sort by: 'used', 'available', 'percent', or 'name' (default)." default:"name" alts:"name,used,available,percent"`
 	Reverse bool     `short:"r" help:"Reverse the sort order." optional:"true"`
+	Total   bool     `help:"Print a grand total row summing all listed filesystems." optional:"true"`
+
+	ExcludeType []string `short:"x" optional:"true" help:"Skip filesystems of this type (repeatable)."`
+	Output      string   `optional:"true" help:"Comma-separated columns to print: source, fstype, size, used, avail, pcent, itotal, iused, iavail, ipcent, target."`
+	Json        bool     `short:"j" help:"Output in JSON format." optional:"true"`
 }
 
 type FilesystemInfo struct {
@@ -56,6 +61,17 @@ func Cmd() *cobra.Command {
 }
 
 func Run(params *Params) error {
+	var columns []outputColumn
+	if params.Output != "" {
+		if params.Json {
+			return fmt.Errorf("--output cannot be combined with --json")
+		}
+		var err error
+		if columns, err = parseOutputColumns(params.Output); err != nil {
+			return err
+		}
+	}
+
 	var fsInfos []FilesystemInfo
 
 	if len(params.Paths) == 0 || (len(params.Paths) == 1 && params.Paths[0] == "") {
@@ -73,6 +89,9 @@ func Run(params *Params) error {
 				_, _ = fmt.Fprintf(os.Stderr, "df: cannot access '%s': %v\n", path, err)
 				continue
 			}
+			if slices.Contains(params.ExcludeType, info.FSType) {
+				continue
+			}
 			fsInfos = append(fsInfos, info)
 		}
 	}
@@ -84,8 +103,20 @@ func Run(params *Params) error {
 	// Sort the results
 	sortFilesystems(fsInfos, params.Sort, params.Reverse)
 
+	// The total row always comes last, regardless of sort order
+	if params.Total {
+		fsInfos = append(fsInfos, totalRow(fsInfos))
+	}
+
 	// Print output
-	printOutput(fsInfos, params)
+	switch {
+	case params.Json:
+		return printJSON(os.Stdout, fsInfos)
+	case columns != nil:
+		printColumns(os.Stdout, fsInfos, columns, params.Human)
+	default:
+		printOutput(fsInfos, params)
+	}
 
 	return nil
 }
@@ -121,6 +152,11 @@ func getAllFilesystems(params *Params) ([]FilesystemInfo, error) {
 			continue
 		}
 
+		// Filter: skip excluded filesystem types if -x is specified
+		if slices.Contains(params.ExcludeType, mount.FSType) {
+			continue
+		}
+
 		info, err := getFilesystemInfoForMount(mount)
 		if err != nil {
 			// Skip filesystems we can't stat (permission denied, etc.)
@@ -263,6 +299,26 @@ func statToFilesystemInfo(stat interface{}, device, mountPoint, fsType string) F
 	return info
 }
 
+// totalRow sums the listed filesystems like `df --total`. Percentages are
+// recomputed from the summed values rather than averaged.
+func totalRow(infos []FilesystemInfo) FilesystemInfo {
+	total := FilesystemInfo{Filesystem: "total", MountPoint: "-"}
+	for _, info := range infos {
+		total.Size += info.Size
+		total.Used += info.Used
+		total.Available += info.Available
+		total.IUsed += info.IUsed
+		total.IAvailable += info.IAvailable
+	}
+	if total.Size > 0 {
+		total.Percent = float64(total.Used) / float64(total.Size) * 100
+	}
+	if inodes := total.IUsed + total.IAvailable; inodes > 0 {
+		total.IPercent = float64(total.IUsed) / float64(inodes) * 100
+	}
+	return total
+}
+
 func sortFilesystems(infos []FilesystemInfo, sortBy string, reverse bool) {
 	slices.SortFunc(infos, func(a, b FilesystemInfo) int {
 		var cmp int
@@ -0,0 +1,39 @@
+package df
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTotalRow(t *testing.T) {
+	infos := []FilesystemInfo{
+		{Filesystem: "/dev/sda1", Size: 1000, Used: 250, Available: 700, Percent: 25, IUsed: 10, IAvailable: 90, IPercent: 10, MountPoint: "/"},
+		{Filesystem: "/dev/sdb1", Size: 3000, Used: 2750, Available: 200, Percent: 91.7, IUsed: 300, IAvailable: 100, IPercent: 75, MountPoint: "/home"},
+	}
+
+	total := totalRow(infos)
+
+	if total.Filesystem != "total" || total.MountPoint != "-" {
+		t.Errorf("Expected total/- labels, got %q %q", total.Filesystem, total.MountPoint)
+	}
+	if total.Size != 4000 || total.Used != 3000 || total.Available != 900 {
+		t.Errorf("Expected sums 4000/3000/900, got %d/%d/%d", total.Size, total.Used, total.Available)
+	}
+	if total.IUsed != 310 || total.IAvailable != 190 {
+		t.Errorf("Expected inode sums 310/190, got %d/%d", total.IUsed, total.IAvailable)
+	}
+	// Recomputed from the sums, not the average of the row percentages (58.35)
+	if math.Abs(total.Percent-75) > 1e-9 {
+		t.Errorf("Expected 75%% used, got %v", total.Percent)
+	}
+	if math.Abs(total.IPercent-62) > 1e-9 {
+		t.Errorf("Expected 62%% inodes used, got %v", total.IPercent)
+	}
+}
+
+func TestTotalRow_Empty(t *testing.T) {
+	total := totalRow(nil)
+	if total.Size != 0 || total.Percent != 0 || total.IPercent != 0 {
+		t.Errorf("Expected empty total, got %+v", total)
+	}
+}
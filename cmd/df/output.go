@@ -0,0 +1,154 @@
+package df
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// outputColumn is a column selectable with --output, named like the
+// corresponding coreutils df field.
+type outputColumn struct {
+	name   string
+	header string
+	// numeric columns are right-aligned
+	numeric bool
+	value   func(info FilesystemInfo, human bool) string
+}
+
+var outputColumns = []outputColumn{
+	{name: "source", header: "Filesystem", value: func(info FilesystemInfo, _ bool) string { return info.Filesystem }},
+	{name: "fstype", header: "Type", value: func(info FilesystemInfo, _ bool) string { return info.FSType }},
+	{name: "size", header: "1K-blocks", numeric: true, value: func(info FilesystemInfo, human bool) string { return formatBlocks(info.Size, human) }},
+	{name: "used", header: "Used", numeric: true, value: func(info FilesystemInfo, human bool) string { return formatBlocks(info.Used, human) }},
+	{name: "avail", header: "Avail", numeric: true, value: func(info FilesystemInfo, human bool) string { return formatBlocks(info.Available, human) }},
+	{name: "pcent", header: "Use%", numeric: true, value: func(info FilesystemInfo, _ bool) string { return fmt.Sprintf("%.0f%%", info.Percent) }},
+	{name: "itotal", header: "Inodes", numeric: true, value: func(info FilesystemInfo, _ bool) string { return strconv.FormatUint(info.IUsed+info.IAvailable, 10) }},
+	{name: "iused", header: "IUsed", numeric: true, value: func(info FilesystemInfo, _ bool) string { return strconv.FormatUint(info.IUsed, 10) }},
+	{name: "iavail", header: "IFree", numeric: true, value: func(info FilesystemInfo, _ bool) string { return strconv.FormatUint(info.IAvailable, 10) }},
+	{name: "ipcent", header: "IUse%", numeric: true, value: func(info FilesystemInfo, _ bool) string { return fmt.Sprintf("%.0f%%", info.IPercent) }},
+	{name: "target", header: "Mounted on", value: func(info FilesystemInfo, _ bool) string { return info.MountPoint }},
+}
+
+// parseOutputColumns parses a comma-separated --output list, keeping the
+// order given by the user.
+func parseOutputColumns(spec string) ([]outputColumn, error) {
+	var columns []outputColumn
+	for name := range strings.SplitSeq(spec, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, col := range outputColumns {
+			if col.name == name {
+				columns = append(columns, col)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid --output column %q (valid: %s)", name, validColumnNames())
+		}
+	}
+	return columns, nil
+}
+
+func validColumnNames() string {
+	names := make([]string, len(outputColumns))
+	for i, col := range outputColumns {
+		names[i] = col.name
+	}
+	return strings.Join(names, ", ")
+}
+
+func formatBlocks(bytes uint64, human bool) string {
+	if human {
+		return formatHumanReadable(bytes)
+	}
+	return strconv.FormatUint(bytes/1024, 10)
+}
+
+// printColumns prints the selected columns, each sized to its widest value.
+// Text columns are left-aligned and numeric ones right-aligned.
+func printColumns(w io.Writer, infos []FilesystemInfo, columns []outputColumn, human bool) {
+	rows := make([][]string, 0, len(infos)+1)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.header
+		if col.name == "size" && human {
+			header[i] = "Size"
+		}
+	}
+	rows = append(rows, header)
+	for _, info := range infos {
+		row := make([]string, len(columns))
+		for i, col := range columns {
+			row[i] = col.value(info, human)
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(columns))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteByte(' ')
+			}
+			switch {
+			case columns[i].numeric:
+				fmt.Fprintf(&line, "%*s", widths[i], cell)
+			case i == len(row)-1:
+				// No trailing padding after the last column
+				line.WriteString(cell)
+			default:
+				fmt.Fprintf(&line, "%-*s", widths[i], cell)
+			}
+		}
+		fmt.Fprintln(w, line.String())
+	}
+}
+
+// filesystemJSON is the --json representation of a filesystem. Sizes are in
+// bytes, and field names follow the --output column names.
+type filesystemJSON struct {
+	Source string  `json:"source"`
+	FSType string  `json:"fstype"`
+	Size   uint64  `json:"size"`
+	Used   uint64  `json:"used"`
+	Avail  uint64  `json:"avail"`
+	Pcent  float64 `json:"pcent"`
+	ITotal uint64  `json:"itotal"`
+	IUsed  uint64  `json:"iused"`
+	IAvail uint64  `json:"iavail"`
+	IPcent float64 `json:"ipcent"`
+	Target string  `json:"target"`
+}
+
+func printJSON(w io.Writer, infos []FilesystemInfo) error {
+	output := make([]filesystemJSON, len(infos))
+	for i, info := range infos {
+		output[i] = filesystemJSON{
+			Source: info.Filesystem,
+			FSType: info.FSType,
+			Size:   info.Size,
+			Used:   info.Used,
+			Avail:  info.Available,
+			Pcent:  info.Percent,
+			ITotal: info.IUsed + info.IAvailable,
+			IUsed:  info.IUsed,
+			IAvail: info.IAvailable,
+			IPcent: info.IPercent,
+			Target: info.MountPoint,
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
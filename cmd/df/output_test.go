@@ -0,0 +1,93 @@
+package df
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+var testInfos = []FilesystemInfo{
+	{Filesystem: "/dev/sda1", FSType: "ext4", Size: 1024000, Used: 256000, Available: 716800, Percent: 25, IUsed: 10, IAvailable: 90, IPercent: 10, MountPoint: "/"},
+	{Filesystem: "tmpfs", FSType: "tmpfs", Size: 2048, Used: 0, Available: 2048, MountPoint: "/run/user/1000"},
+}
+
+func TestParseOutputColumns(t *testing.T) {
+	columns, err := parseOutputColumns("target, pcent,source")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, col := range columns {
+		names = append(names, col.name)
+	}
+	if len(names) != 3 || names[0] != "target" || names[1] != "pcent" || names[2] != "source" {
+		t.Errorf("Expected [target pcent source], got %v", names)
+	}
+
+	if _, err := parseOutputColumns("source,bogus"); err == nil {
+		t.Error("Expected error for unknown column")
+	}
+	if _, err := parseOutputColumns(""); err == nil {
+		t.Error("Expected error for empty column list")
+	}
+}
+
+func TestPrintColumns(t *testing.T) {
+	columns, err := parseOutputColumns("source,fstype,size,pcent,target")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	printColumns(&buf, testInfos, columns, false)
+
+	expected := "" +
+		"Filesystem Type  1K-blocks Use% Mounted on\n" +
+		"/dev/sda1  ext4       1000  25% /\n" +
+		"tmpfs      tmpfs         2   0% /run/user/1000\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	columns, _ = parseOutputColumns("size,used")
+	printColumns(&buf, testInfos[:1], columns, true)
+	expected = "" +
+		" Size Used\n" +
+		"1000K 250K\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printJSON(&buf, testInfos); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var decoded []filesystemJSON
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(decoded))
+	}
+	first := decoded[0]
+	if first.Source != "/dev/sda1" || first.FSType != "ext4" || first.Target != "/" {
+		t.Errorf("Unexpected labels: %+v", first)
+	}
+	if first.Size != 1024000 || first.Used != 256000 || first.Avail != 716800 || first.Pcent != 25 {
+		t.Errorf("Unexpected sizes: %+v", first)
+	}
+	if first.ITotal != 100 || first.IUsed != 10 || first.IAvail != 90 || first.IPcent != 10 {
+		t.Errorf("Unexpected inodes: %+v", first)
+	}
+}
+
+func TestRun_OutputWithJSON(t *testing.T) {
+	err := Run(&Params{Output: "source", Json: true})
+	if err == nil {
+		t.Error("Expected error combining --output and --json")
+	}
+}
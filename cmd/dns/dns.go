@@ -5,22 +5,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net"
+	"net/http"
 	"os"
 	"sort"
 	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 type Params struct {
 	Hostname string   `pos:"true" help:"Hostname to lookup"`
-	Server   string   `short:"s" help:"DNS server to use. Use 'os' for OS resolver, or IP address (e.g. 8.8.8.8)" default:"os" alts:"os,8.8.8.8,1.1.1.1" strict:"false"`
-	Types    []string `short:"t" help:"Record types to query. Use 'all' for all types. Default: A,AAAA,CNAME" default:"A,AAAA,CNAME" alts:"A,AAAA,CNAME,MX,TXT,NS,PTR,all"`
+	Server   string   `short:"s" help:"DNS server to use. Use 'os' for OS resolver, or IP address with optional port (e.g. 8.8.8.8, 1.1.1.1:53)" default:"os" alts:"os,8.8.8.8,1.1.1.1" strict:"false"`
+	Types    []string `short:"t" help:"Record types to query. Use 'all' for all types. Default: A,AAAA,CNAME, or PTR when looking up an IP address" default:"A,AAAA,CNAME" alts:"A,AAAA,CNAME,MX,TXT,NS,SOA,SRV,PTR,all"`
+	Doh      string   `name:"doh" optional:"true" help:"Send queries over DNS-over-HTTPS (RFC 8484) instead. A bare --doh uses Cloudflare, or give an endpoint with --doh=URL, e.g. --doh=https://dns.google/dns-query" alts:"https://cloudflare-dns.com/dns-query,https://dns.google/dns-query,https://dns.quad9.net/dns-query" strict:"false"`
 	Timeout  int      `long:"timeout" help:"Timeout in seconds for DNS queries" default:"2"`
 	Json     bool     `short:"j" help:"Output in JSON format."`
 }
@@ -30,16 +35,41 @@ type MXRecord struct {
 	Host string `json:"host"`
 }
 
+type SRVRecord struct {
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Port     uint16 `json:"port"`
+	Target   string `json:"target"`
+}
+
+type SOARecord struct {
+	MName   string `json:"mname"`
+	RName   string `json:"rname"`
+	Serial  uint32 `json:"serial"`
+	Refresh uint32 `json:"refresh"`
+	Retry   uint32 `json:"retry"`
+	Expire  uint32 `json:"expire"`
+	MinTTL  uint32 `json:"minttl"`
+}
+
+// DNSOutput is the result of a lookup. Transport is os, udp or doh, and RTT
+// the time all queries took, in milliseconds. AD is whether the DoH resolver
+// validated every answer with DNSSEC, and unknown with other transports.
 type DNSOutput struct {
-	Server   string     `json:"server"`
-	Hostname string     `json:"hostname"`
-	A        []string   `json:"a,omitempty"`
-	AAAA     []string   `json:"aaaa,omitempty"`
-	CNAME    string     `json:"cname,omitempty"`
-	MX       []MXRecord `json:"mx,omitempty"`
-	TXT      []string   `json:"txt,omitempty"`
-	NS       []string   `json:"ns,omitempty"`
-	PTR      []string   `json:"ptr,omitempty"`
+	Server    string      `json:"server"`
+	Transport string      `json:"transport"`
+	RTT       float64     `json:"rtt_ms"`
+	AD        *bool       `json:"ad,omitempty"`
+	Hostname  string      `json:"hostname"`
+	A         []string    `json:"a,omitempty"`
+	AAAA      []string    `json:"aaaa,omitempty"`
+	CNAME     string      `json:"cname,omitempty"`
+	MX        []MXRecord  `json:"mx,omitempty"`
+	TXT       []string    `json:"txt,omitempty"`
+	NS        []string    `json:"ns,omitempty"`
+	SOA       *SOARecord  `json:"soa,omitempty"`
+	SRV       []SRVRecord `json:"srv,omitempty"`
+	PTR       []string    `json:"ptr,omitempty"`
 }
 
 func Cmd() *cobra.Command {
@@ -47,19 +77,62 @@ func Cmd() *cobra.Command {
 		Use:         "dns",
 		Short:       "Lookup DNS records",
 		ParamEnrich: common.DefaultParamEnricher(),
+		PostCreateFunc: func(params *Params, cmd *cobra.Command) error {
+			// Accept --type as used by dig/host
+			cmd.Flags().SetNormalizeFunc(normalizeFlagName)
+			// A bare --doh uses a well-known resolver
+			if f := cmd.Flags().Lookup("doh"); f != nil {
+				f.NoOptDefVal = defaultDoH
+			}
+			return nil
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if params.Hostname == "" {
 				_ = cmd.Help()
 				return
 			}
+			if strings.HasPrefix(params.Hostname, "https://") {
+				fmt.Fprintln(os.Stderr, "dns: give the DoH endpoint as --doh=URL")
+				os.Exit(1)
+			}
+			if params.Doh != "" && strings.ToLower(params.Server) != "os" {
+				fmt.Fprintln(os.Stderr, "dns: --doh and --server cannot be combined")
+				os.Exit(1)
+			}
+			params.Types = defaultTypes(params.Hostname, params.Types, cmd.Flags().Changed("types"))
 			runDns(params, os.Stdout)
 		},
 	}.ToCobra()
 }
 
+func normalizeFlagName(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "type" {
+		name = "types"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// defaultTypes switches the default query to a reverse (PTR) lookup when the
+// argument is an IP address and no record types were given explicitly.
+func defaultTypes(hostname string, types []string, explicit bool) []string {
+	if !explicit && net.ParseIP(hostname) != nil {
+		return []string{"PTR"}
+	}
+	return types
+}
+
+// serverAddress adds the default DNS port to a server address if it has none.
+func serverAddress(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+}
+
 func runDns(params *Params, stdout io.Writer) {
 	var resolver *net.Resolver
 	var serverName string
+	var server string
 
 	useOS := strings.ToLower(params.Server) == "os"
 
@@ -67,11 +140,7 @@ func runDns(params *Params, stdout io.Writer) {
 		resolver = net.DefaultResolver
 		serverName = "OS"
 	} else {
-		// Ensure port is present
-		server := params.Server
-		if !strings.Contains(server, ":") {
-			server = server + ":53"
-		}
+		server = serverAddress(params.Server)
 		serverName = server
 
 		resolver = &net.Resolver{
@@ -85,10 +154,22 @@ func runDns(params *Params, stdout io.Writer) {
 		}
 	}
 
+	transport := "udp"
+	if useOS {
+		transport = "os"
+	}
+	if params.Doh != "" {
+		serverName, transport = params.Doh, "doh"
+	}
+
 	output := DNSOutput{
-		Server:   serverName,
-		Hostname: params.Hostname,
+		Server:    serverName,
+		Transport: transport,
+		Hostname:  params.Hostname,
 	}
+	start := time.Now()
+	// With DoH, the answers are authenticated if every response had AD set
+	authenticated, answered := true, false
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(params.Timeout)*time.Second)
 	defer cancel()
@@ -106,7 +187,37 @@ func runDns(params *Params, stdout io.Writer) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
+	dohClient := &http.Client{}
+
 	for _, recordType := range typesToQuery {
+		if params.Doh != "" {
+			if _, ok := dohTypes[recordType]; !ok {
+				continue
+			}
+			// PTR lookups only make sense for IP addresses, not hostnames
+			if recordType == "PTR" && net.ParseIP(params.Hostname) == nil {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := lookupDoH(ctx, dohClient, params.Doh, params.Hostname, recordType)
+				mu.Lock()
+				if result != nil {
+					addDoHAnswers(&output, params.Hostname, result.Answers)
+					authenticated = authenticated && result.Authenticated
+					answered = true
+				}
+				if err != nil {
+					errorsMu.Lock()
+					errors = append(errors, recordError{recordTitle(recordType), err})
+					errorsMu.Unlock()
+				}
+				mu.Unlock()
+			}()
+			continue
+		}
+
 		switch recordType {
 		case "A":
 			wg.Add(1)
@@ -275,6 +386,56 @@ func runDns(params *Params, stdout io.Writer) {
 				mu.Unlock()
 			}()
 
+		case "SOA":
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Neither the Go resolver nor libc expose SOA lookups, so query
+				// the server directly
+				soaServer := server
+				var soa *SOARecord
+				var err error
+				if useOS {
+					soaServer, err = systemNameserver(resolvConfPath)
+				}
+				if err == nil {
+					soa, err = lookupSOA(ctx, soaServer, params.Hostname)
+				}
+				mu.Lock()
+				if err == nil {
+					output.SOA = soa
+				} else {
+					errorsMu.Lock()
+					errors = append(errors, recordError{"SOA Record", err})
+					errorsMu.Unlock()
+				}
+				mu.Unlock()
+			}()
+
+		case "SRV":
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, srvs, err := resolver.LookupSRV(ctx, "", "", params.Hostname)
+				mu.Lock()
+				if err == nil {
+					// LookupSRV already sorts by priority and randomizes by weight
+					for _, srv := range srvs {
+						output.SRV = append(output.SRV, SRVRecord{
+							Priority: srv.Priority,
+							Weight:   srv.Weight,
+							Port:     srv.Port,
+							Target:   srv.Target,
+						})
+					}
+				} else {
+					errorsMu.Lock()
+					errors = append(errors, recordError{"SRV Records", err})
+					errorsMu.Unlock()
+				}
+				mu.Unlock()
+			}()
+
 		case "PTR":
 			// PTR lookups only make sense for IP addresses, not hostnames
 			if net.ParseIP(params.Hostname) == nil {
@@ -298,6 +459,10 @@ func runDns(params *Params, stdout io.Writer) {
 	}
 
 	wg.Wait()
+	output.RTT = math.Round(float64(time.Since(start).Microseconds())/10) / 100
+	if transport == "doh" && answered {
+		output.AD = &authenticated
+	}
 
 	// Print errors for non-JSON output
 	if !params.Json {
@@ -317,6 +482,15 @@ func runDns(params *Params, stdout io.Writer) {
 
 func outputDnsPlain(stdout io.Writer, params *Params, output DNSOutput) {
 	fmt.Fprintf(stdout, "Server:  %s\n", output.Server)
+	if output.Transport == "doh" {
+		via := "DNS-over-HTTPS"
+		if output.AD != nil && *output.AD {
+			via += ", AD set (DNSSEC validated by the resolver)"
+		} else if output.AD != nil {
+			via += ", AD not set (not validated)"
+		}
+		fmt.Fprintf(stdout, "Via:     %s\n", via)
+	}
 	fmt.Fprintf(stdout, "Address: %s\n\n", output.Hostname)
 
 	typesToQuery := parseTypes(params.Types)
@@ -348,9 +522,12 @@ func outputDnsPlain(stdout io.Writer, params *Params, output DNSOutput) {
 		case "MX":
 			if len(output.MX) > 0 {
 				fmt.Fprintln(stdout, "MX Records:")
+				w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "  PRIORITY\tHOST")
 				for _, mx := range output.MX {
-					fmt.Fprintf(stdout, "  %d %s\n", mx.Pref, mx.Host)
+					fmt.Fprintf(w, "  %d\t%s\n", mx.Pref, mx.Host)
 				}
+				_ = w.Flush()
 				fmt.Fprintln(stdout)
 			}
 		case "TXT":
@@ -369,6 +546,31 @@ func outputDnsPlain(stdout io.Writer, params *Params, output DNSOutput) {
 				}
 				fmt.Fprintln(stdout)
 			}
+		case "SOA":
+			if output.SOA != nil {
+				fmt.Fprintln(stdout, "SOA Record:")
+				w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintf(w, "  Primary NS:\t%s\n", output.SOA.MName)
+				fmt.Fprintf(w, "  Responsible:\t%s\n", output.SOA.RName)
+				fmt.Fprintf(w, "  Serial:\t%d\n", output.SOA.Serial)
+				fmt.Fprintf(w, "  Refresh:\t%d\n", output.SOA.Refresh)
+				fmt.Fprintf(w, "  Retry:\t%d\n", output.SOA.Retry)
+				fmt.Fprintf(w, "  Expire:\t%d\n", output.SOA.Expire)
+				fmt.Fprintf(w, "  Minimum TTL:\t%d\n", output.SOA.MinTTL)
+				_ = w.Flush()
+				fmt.Fprintln(stdout)
+			}
+		case "SRV":
+			if len(output.SRV) > 0 {
+				fmt.Fprintln(stdout, "SRV Records:")
+				w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "  PRIORITY\tWEIGHT\tPORT\tTARGET")
+				for _, srv := range output.SRV {
+					fmt.Fprintf(w, "  %d\t%d\t%d\t%s\n", srv.Priority, srv.Weight, srv.Port, srv.Target)
+				}
+				_ = w.Flush()
+				fmt.Fprintln(stdout)
+			}
 		case "PTR":
 			if len(output.PTR) > 0 {
 				fmt.Fprintln(stdout, "PTR Records:")
@@ -382,7 +584,7 @@ func outputDnsPlain(stdout io.Writer, params *Params, output DNSOutput) {
 }
 
 func parseTypes(types []string) []string {
-	all := []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "PTR"}
+	all := []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SOA", "SRV", "PTR"}
 	if len(types) == 0 {
 		return []string{"A", "AAAA", "CNAME"}
 	}
@@ -2,8 +2,18 @@ package dns
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 func TestDnsCmd_Structure(t *testing.T) {
@@ -60,3 +70,377 @@ func TestRunDns_SpecificServer(t *testing.T) {
 		t.Errorf("Expected output to show server 1.1.1.1:53, got:\n%s", output)
 	}
 }
+
+func TestDefaultTypes(t *testing.T) {
+	defaults := []string{"A", "AAAA", "CNAME"}
+	tests := []struct {
+		hostname string
+		explicit bool
+		expected []string
+	}{
+		{"example.com", false, defaults},
+		{"8.8.8.8", false, []string{"PTR"}},
+		{"2001:4860:4860::8888", false, []string{"PTR"}},
+		{"8.8.8.8", true, defaults},
+	}
+
+	for _, tt := range tests {
+		got := defaultTypes(tt.hostname, defaults, tt.explicit)
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("defaultTypes(%q, explicit=%v) = %v, expected %v", tt.hostname, tt.explicit, got, tt.expected)
+		}
+	}
+}
+
+func TestServerAddress(t *testing.T) {
+	tests := map[string]string{
+		"8.8.8.8":                "8.8.8.8:53",
+		"8.8.8.8:5353":           "8.8.8.8:5353",
+		"2001:4860:4860::8888":   "[2001:4860:4860::8888]:53",
+		"[2001:4860:4860::8888]": "[2001:4860:4860::8888]:53",
+		"[::1]:5353":             "[::1]:5353",
+	}
+	for input, expected := range tests {
+		if got := serverAddress(input); got != expected {
+			t.Errorf("serverAddress(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestParseTypes_All(t *testing.T) {
+	got := strings.Join(parseTypes([]string{"all"}), ",")
+	expected := "A,AAAA,CNAME,MX,TXT,NS,SOA,SRV,PTR"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestNormalizeFlagName(t *testing.T) {
+	if got := normalizeFlagName(nil, "type"); got != "types" {
+		t.Errorf("Expected --type to normalize to types, got %q", got)
+	}
+	if got := normalizeFlagName(nil, "server"); got != "server" {
+		t.Errorf("Expected server to be unchanged, got %q", got)
+	}
+}
+
+func TestOutputDnsPlain_Columns(t *testing.T) {
+	params := &Params{Types: []string{"MX", "SRV", "SOA"}}
+	output := DNSOutput{
+		Server:   "OS",
+		Hostname: "example.com",
+		MX:       []MXRecord{{Pref: 10, Host: "mx1.example.com."}, {Pref: 20, Host: "mx2.example.com."}},
+		SRV:      []SRVRecord{{Priority: 0, Weight: 5, Port: 5060, Target: "sip.example.com."}},
+		SOA:      &SOARecord{MName: "ns1.example.com.", RName: "hostmaster.example.com.", Serial: 2024010101, Refresh: 7200, Retry: 3600, Expire: 1209600, MinTTL: 300},
+	}
+
+	var buf bytes.Buffer
+	outputDnsPlain(&buf, params, output)
+	got := buf.String()
+
+	for _, want := range []string{
+		"  PRIORITY  HOST\n  10        mx1.example.com.\n  20        mx2.example.com.\n",
+		"  PRIORITY  WEIGHT  PORT  TARGET\n  0         5       5060  sip.example.com.\n",
+		"  Primary NS:   ns1.example.com.\n",
+		"  Serial:       2024010101\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestSystemNameserver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	content := "# generated\nsearch example.com\nnameserver fe80::1%eth0\nnameserver 10.0.0.2\nnameserver 10.0.0.3\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := systemNameserver(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server != "10.0.0.2:53" {
+		t.Errorf("Expected %q, got %q", "10.0.0.2:53", server)
+	}
+
+	if _, err := systemNameserver(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing resolv.conf")
+	}
+}
+
+// startFakeDNS answers every query on a local UDP socket using respond.
+func startFakeDNS(t *testing.T, respond func(q dnsmessage.Message) dnsmessage.Message) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen on UDP: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var q dnsmessage.Message
+			if err := q.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			resp := respond(q)
+			resp.ID = q.ID
+			resp.Response = true
+			resp.Questions = q.Questions
+			packed, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestLookupSOA(t *testing.T) {
+	soaResource := func(q dnsmessage.Message) dnsmessage.Resource {
+		return dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("example.com."), Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET, TTL: 300},
+			Body: &dnsmessage.SOAResource{
+				NS:      dnsmessage.MustNewName("ns1.example.com."),
+				MBox:    dnsmessage.MustNewName("hostmaster.example.com."),
+				Serial:  42,
+				Refresh: 7200,
+				Retry:   3600,
+				Expire:  1209600,
+				MinTTL:  300,
+			},
+		}
+	}
+
+	t.Run("answer", func(t *testing.T) {
+		server := startFakeDNS(t, func(q dnsmessage.Message) dnsmessage.Message {
+			return dnsmessage.Message{Answers: []dnsmessage.Resource{soaResource(q)}}
+		})
+		soa, err := lookupSOA(context.Background(), server, "example.com")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if soa.MName != "ns1.example.com." || soa.RName != "hostmaster.example.com." || soa.Serial != 42 || soa.MinTTL != 300 {
+			t.Errorf("Unexpected SOA record: %+v", soa)
+		}
+	})
+
+	t.Run("authority", func(t *testing.T) {
+		server := startFakeDNS(t, func(q dnsmessage.Message) dnsmessage.Message {
+			return dnsmessage.Message{Authorities: []dnsmessage.Resource{soaResource(q)}}
+		})
+		soa, err := lookupSOA(context.Background(), server, "www.example.com")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if soa.MName != "ns1.example.com." {
+			t.Errorf("Expected SOA from authority section, got %+v", soa)
+		}
+	})
+
+	t.Run("nxdomain", func(t *testing.T) {
+		server := startFakeDNS(t, func(q dnsmessage.Message) dnsmessage.Message {
+			return dnsmessage.Message{Header: dnsmessage.Header{RCode: dnsmessage.RCodeNameError}}
+		})
+		_, err := lookupSOA(context.Background(), server, "missing.example.com")
+		var dnsErr *net.DNSError
+		if !isDnsError(err, &dnsErr) || !dnsErr.IsNotFound {
+			t.Errorf("Expected not-found DNS error, got %v", err)
+		}
+	})
+}
+
+func TestReverseAddr(t *testing.T) {
+	tests := map[string]string{
+		"8.8.4.4":     "4.4.8.8.in-addr.arpa.",
+		"192.0.2.1":   "1.2.0.192.in-addr.arpa.",
+		"2001:db8::1": "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+	}
+	for input, expected := range tests {
+		got, err := reverseAddr(input)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got != expected {
+			t.Errorf("reverseAddr(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+	if _, err := reverseAddr("example.com"); err == nil {
+		t.Error("Expected error for hostname")
+	}
+}
+
+// startFakeDoH serves RFC 8484 queries, answering each with the records of
+// respond for its name and type. A nil response answers NXDOMAIN.
+func startFakeDoH(t *testing.T, authenticated bool, respond func(name string, qtype dnsmessage.Type) []dnsmessage.Resource) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var q dnsmessage.Message
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/dns-message" || q.Unpack(body) != nil || len(q.Questions) != 1 {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		question := q.Questions[0]
+		resp := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: q.ID, Response: true, RecursionAvailable: true, AuthenticData: authenticated && q.AuthenticData},
+			Questions: q.Questions,
+		}
+		records := respond(question.Name.String(), question.Type)
+		if records == nil {
+			resp.RCode = dnsmessage.RCodeNameError
+		}
+		for _, rr := range records {
+			rr.Header.Class = dnsmessage.ClassINET
+			if _, ok := rr.Body.(*dnsmessage.SOAResource); ok && question.Type != dnsmessage.TypeSOA {
+				resp.Authorities = append(resp.Authorities, rr)
+			} else {
+				resp.Answers = append(resp.Answers, rr)
+			}
+		}
+		packed, err := resp.Pack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(packed)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func rr(name string, qtype dnsmessage.Type, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	return dnsmessage.Resource{Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: qtype, TTL: 300}, Body: body}
+}
+
+func TestRunDns_DoH(t *testing.T) {
+	records := map[string][]dnsmessage.Resource{
+		"example.com./A": {rr("example.com.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{93, 184, 216, 34}})},
+		"example.com./MX": {
+			rr("example.com.", dnsmessage.TypeMX, &dnsmessage.MXResource{Pref: 20, MX: dnsmessage.MustNewName("mx2.example.com.")}),
+			rr("example.com.", dnsmessage.TypeMX, &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mx1.example.com.")}),
+		},
+		"example.com./TXT": {rr("example.com.", dnsmessage.TypeTXT, &dnsmessage.TXTResource{TXT: []string{"v=spf1 ", "-all"}})},
+		"example.com./SOA": {rr("example.com.", dnsmessage.TypeSOA, &dnsmessage.SOAResource{
+			NS: dnsmessage.MustNewName("ns.icann.org."), MBox: dnsmessage.MustNewName("noc.dns.icann.org."),
+			Serial: 2024081401, Refresh: 7200, Retry: 3600, Expire: 1209600, MinTTL: 3600,
+		})},
+		// AAAA lookups return NOERROR without answers, which is not an error
+		"example.com./AAAA":          {},
+		"_sip._tcp.example.com./SRV": {rr("_sip._tcp.example.com.", dnsmessage.TypeSRV, &dnsmessage.SRVResource{Priority: 10, Weight: 60, Port: 5060, Target: dnsmessage.MustNewName("sip.example.com.")})},
+		"4.4.8.8.in-addr.arpa./PTR":  {rr("4.4.8.8.in-addr.arpa.", dnsmessage.TypePTR, &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("dns.google.")})},
+	}
+	respond := func(name string, qtype dnsmessage.Type) []dnsmessage.Resource {
+		for key, rrs := range records {
+			if key == name+"/"+strings.TrimPrefix(qtype.String(), "Type") {
+				return rrs
+			}
+		}
+		return nil
+	}
+	srv := startFakeDoH(t, true, respond)
+
+	run := func(hostname string, types ...string) string {
+		var buf bytes.Buffer
+		runDns(&Params{Hostname: hostname, Server: "os", Doh: srv.URL, Types: types, Timeout: 2}, &buf)
+		return buf.String()
+	}
+
+	out := run("example.com", "A", "AAAA", "MX", "TXT", "SOA")
+	for _, want := range []string{
+		"Server:  " + srv.URL + "\nVia:     DNS-over-HTTPS, AD set (DNSSEC validated by the resolver)\nAddress: example.com\n",
+		"A Records:\n  93.184.216.34\n",
+		"  PRIORITY  HOST\n  10        mx1.example.com.\n  20        mx2.example.com.\n",
+		"TXT Records:\n  v=spf1 -all\n",
+		"  Primary NS:   ns.icann.org.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "AAAA") || strings.Contains(out, "Error") {
+		t.Errorf("Expected missing records to be silent, got:\n%s", out)
+	}
+
+	out = run("_sip._tcp.example.com", "SRV")
+	if !strings.Contains(out, "  10        60      5060  sip.example.com.\n") {
+		t.Errorf("Expected SRV record, got:\n%s", out)
+	}
+
+	out = run("8.8.4.4", "PTR")
+	if !strings.Contains(out, "PTR Records:\n  dns.google.\n") {
+		t.Errorf("Expected PTR record, got:\n%s", out)
+	}
+
+	out = run("missing.example.com", "A")
+	if strings.Contains(out, "A Records") || strings.Contains(out, "Error") {
+		t.Errorf("Expected no A records and no error for NXDOMAIN, got:\n%s", out)
+	}
+
+	var buf bytes.Buffer
+	runDns(&Params{Hostname: "example.com", Server: "os", Doh: srv.URL, Types: []string{"MX"}, Timeout: 2, Json: true}, &buf)
+	var output DNSOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output.Transport != "doh" || output.AD == nil || !*output.AD || output.RTT <= 0 || len(output.MX) != 2 {
+		t.Errorf("Unexpected JSON output:\n%s", buf.String())
+	}
+
+	// Without the AD bit, the answers weren't validated
+	unvalidated := startFakeDoH(t, false, respond)
+	buf.Reset()
+	runDns(&Params{Hostname: "example.com", Server: "os", Doh: unvalidated.URL, Types: []string{"A"}, Timeout: 2}, &buf)
+	if !strings.Contains(buf.String(), "Via:     DNS-over-HTTPS, AD not set (not validated)\n") {
+		t.Errorf("Expected AD not set, got:\n%s", buf.String())
+	}
+}
+
+func TestRunDns_DoHErrors(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	for path, want := range map[string]string{
+		"/slow":  "A Records:\n  Error: DoH request to " + host + " timed out\n",
+		"/html":  "A Records:\n  Error: DoH server " + host + ` did not return a DNS message, got Content-Type "text/html"` + "\n",
+		"/error": "A Records:\n  Error: DoH server " + host + " returned HTTP 503 Service Unavailable\n",
+	} {
+		var buf bytes.Buffer
+		runDns(&Params{Hostname: "example.com", Server: "os", Doh: srv.URL + path, Types: []string{"A"}, Timeout: 1, Json: false}, &buf)
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%s: expected %q, got:\n%s", path, want, buf.String())
+		}
+	}
+
+	// Other transports don't know about AD
+	var buf bytes.Buffer
+	runDns(&Params{Hostname: "example.com", Server: "os", Types: []string{"SRV"}, Timeout: 1, Json: true}, &buf)
+	if strings.Contains(buf.String(), `"ad"`) || !strings.Contains(buf.String(), `"transport": "os"`) {
+		t.Errorf("Unexpected JSON output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,220 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// defaultDoH is the resolver used for a bare --doh.
+const defaultDoH = "https://cloudflare-dns.com/dns-query"
+
+// dnsMessageType is the media type of RFC 8484 requests and responses.
+const dnsMessageType = "application/dns-message"
+
+// maxDoHResponse bounds the response read, the largest a DNS message can be.
+const maxDoHResponse = 65535
+
+// DNS record types queried over DoH
+var dohTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"NS":    dnsmessage.TypeNS,
+	"CNAME": dnsmessage.TypeCNAME,
+	"SOA":   dnsmessage.TypeSOA,
+	"PTR":   dnsmessage.TypePTR,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"SRV":   dnsmessage.TypeSRV,
+}
+
+// dohResult is the answer to a DoH query. Authenticated is the AD bit, set
+// when the resolver validated the answer with DNSSEC.
+type dohResult struct {
+	Answers       []dnsmessage.Resource
+	Authenticated bool
+}
+
+// recordTitle is the section title used for errors of a record type.
+func recordTitle(recordType string) string {
+	switch recordType {
+	case "CNAME":
+		return "CNAME"
+	case "SOA":
+		return "SOA Record"
+	default:
+		return recordType + " Records"
+	}
+}
+
+// queryDoH sends a single RFC 8484 DNS-over-HTTPS query and returns the
+// answers of the requested type. SOA records from the authority section
+// count as answers, like dig shows them for names that are not a zone apex.
+func queryDoH(ctx context.Context, client *http.Client, endpoint, name, recordType string) (*dohResult, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid DoH URL %q", endpoint)
+	}
+	fqdn := name
+	if !strings.HasSuffix(fqdn, ".") {
+		fqdn += "."
+	}
+	qname, err := dnsmessage.NewName(fqdn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name %q: %w", name, err)
+	}
+
+	// The ID is 0 as RFC 8484 asks of DoH clients, since HTTP already pairs
+	// responses with requests. That keeps GET requests cacheable, though these
+	// queries are POSTed. AD asks the resolver to report whether it validated
+	// the answer (RFC 6840).
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{RecursionDesired: true, AuthenticData: true},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: dohTypes[recordType], Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u.String(), bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dnsMessageType)
+	req.Header.Set("Accept", dnsMessageType)
+
+	resp, err := client.Do(req)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("DoH request to %s timed out", u.Host)
+	} else if err != nil {
+		return nil, fmt.Errorf("DoH request to %s failed: %w", u.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH server %s returned HTTP %s", u.Host, resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != dnsMessageType {
+		return nil, fmt.Errorf("DoH server %s did not return a DNS message, got Content-Type %q", u.Host, resp.Header.Get("Content-Type"))
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDoHResponse))
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("DoH request to %s timed out", u.Host)
+	} else if err != nil {
+		return nil, fmt.Errorf("DoH request to %s failed: %w", u.Host, err)
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(body); err != nil {
+		return nil, fmt.Errorf("invalid DoH response from %s: %w", u.Host, err)
+	}
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, &net.DNSError{Err: "no such host", Name: name, Server: u.Host, IsNotFound: true}
+	default:
+		return nil, &net.DNSError{Err: "server returned " + msg.RCode.String(), Name: name, Server: u.Host}
+	}
+
+	wantType := dohTypes[recordType]
+	records := msg.Answers
+	if recordType == "SOA" {
+		records = append(records, msg.Authorities...)
+	}
+	result := &dohResult{Authenticated: msg.AuthenticData}
+	for _, rr := range records {
+		if rr.Header.Type == wantType {
+			result.Answers = append(result.Answers, rr)
+		}
+	}
+	if len(result.Answers) == 0 {
+		return result, &net.DNSError{Err: "no such host", Name: name, Server: u.Host, IsNotFound: true}
+	}
+	return result, nil
+}
+
+// lookupDoH queries one record type for hostname over DoH.
+func lookupDoH(ctx context.Context, client *http.Client, endpoint, hostname, recordType string) (*dohResult, error) {
+	name := hostname
+	if recordType == "PTR" {
+		var err error
+		if name, err = reverseAddr(hostname); err != nil {
+			return nil, err
+		}
+	}
+	return queryDoH(ctx, client, endpoint, name, recordType)
+}
+
+// addDoHAnswers adds DoH answers to output, in the same shape as the
+// resolver based lookups.
+func addDoHAnswers(output *DNSOutput, hostname string, answers []dnsmessage.Resource) {
+	for _, rr := range answers {
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			output.A = append(output.A, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			output.AAAA = append(output.AAAA, net.IP(body.AAAA[:]).String())
+		case *dnsmessage.CNAMEResource:
+			if cname := body.CNAME.String(); cname != hostname && cname != hostname+"." {
+				output.CNAME = cname
+			}
+		case *dnsmessage.MXResource:
+			output.MX = append(output.MX, MXRecord{Pref: body.Pref, Host: body.MX.String()})
+		case *dnsmessage.TXTResource:
+			output.TXT = append(output.TXT, strings.Join(body.TXT, ""))
+		case *dnsmessage.NSResource:
+			output.NS = append(output.NS, body.NS.String())
+		case *dnsmessage.SOAResource:
+			if output.SOA == nil {
+				output.SOA = &SOARecord{
+					MName:   body.NS.String(),
+					RName:   body.MBox.String(),
+					Serial:  body.Serial,
+					Refresh: body.Refresh,
+					Retry:   body.Retry,
+					Expire:  body.Expire,
+					MinTTL:  body.MinTTL,
+				}
+			}
+		case *dnsmessage.SRVResource:
+			output.SRV = append(output.SRV, SRVRecord{Priority: body.Priority, Weight: body.Weight, Port: body.Port, Target: body.Target.String()})
+		case *dnsmessage.PTRResource:
+			output.PTR = append(output.PTR, body.PTR.String())
+		}
+	}
+	sort.Slice(output.MX, func(i, j int) bool { return output.MX[i].Pref < output.MX[j].Pref })
+	sort.SliceStable(output.SRV, func(i, j int) bool { return output.SRV[i].Priority < output.SRV[j].Priority })
+}
+
+// reverseAddr returns the in-addr.arpa or ip6.arpa name for a PTR lookup.
+func reverseAddr(addr string) (string, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return "", fmt.Errorf("not an IP address: %s", addr)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+	const hexDigits = "0123456789abcdef"
+	var sb strings.Builder
+	for i := len(ip) - 1; i >= 0; i-- {
+		sb.WriteByte(hexDigits[ip[i]&0xf])
+		sb.WriteByte('.')
+		sb.WriteByte(hexDigits[ip[i]>>4])
+		sb.WriteByte('.')
+	}
+	sb.WriteString("ip6.arpa.")
+	return sb.String(), nil
+}
@@ -0,0 +1,114 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// resolvConfPath is where the system nameservers are read from for SOA
+// lookups with the OS resolver
+const resolvConfPath = "/etc/resolv.conf"
+
+// systemNameserver returns the address of the first nameserver in resolvConf.
+func systemNameserver(resolvConf string) (string, error) {
+	f, err := os.Open(resolvConf)
+	if err != nil {
+		return "", fmt.Errorf("cannot determine system nameserver, use --server: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" && net.ParseIP(fields[1]) != nil {
+			return net.JoinHostPort(fields[1], "53"), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("no nameserver in " + resolvConf + ", use --server")
+}
+
+// lookupSOA sends a single SOA query for name to server over UDP. When name
+// is not a zone apex, the SOA of the enclosing zone is taken from the
+// authority section, like dig shows it.
+func lookupSOA(ctx context.Context, server, name string) (*SOARecord, error) {
+	fqdn := name
+	if !strings.HasSuffix(fqdn, ".") {
+		fqdn += "."
+	}
+	qname, err := dnsmessage.NewName(fqdn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name %q: %w", name, err)
+	}
+
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Uint32()), RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(packed); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 4096)
+	var resp dnsmessage.Message
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// Ignore stray responses to other queries
+		if err := resp.Unpack(buf[:n]); err == nil && resp.ID == query.ID && resp.Response {
+			break
+		}
+	}
+
+	switch resp.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, &net.DNSError{Err: "no such host", Name: name, Server: server, IsNotFound: true}
+	default:
+		return nil, &net.DNSError{Err: "server returned " + resp.RCode.String(), Name: name, Server: server}
+	}
+
+	for _, rr := range append(resp.Answers, resp.Authorities...) {
+		if soa, ok := rr.Body.(*dnsmessage.SOAResource); ok {
+			return &SOARecord{
+				MName:   soa.NS.String(),
+				RName:   soa.MBox.String(),
+				Serial:  soa.Serial,
+				Refresh: soa.Refresh,
+				Retry:   soa.Retry,
+				Expire:  soa.Expire,
+				MinTTL:  soa.MinTTL,
+			}, nil
+		}
+	}
+	return nil, &net.DNSError{Err: "no SOA record found", Name: name, Server: server, IsNotFound: true}
+}
@@ -5,9 +5,12 @@ import (
 	"io/fs"
 	"math"
 	"os"
+	"path"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
@@ -23,9 +26,12 @@ type Params struct {
 	Bytes        bool     `short:"b" help:"Apparent size in bytes (equivalent to --apparent-size --block-size=1)." optional:"true"`
 	ApparentSize bool     `help:"Print apparent sizes rather than disk usage." optional:"true"`
 	Kilobytes    bool     `short:"k" help:"Print in kilobytes." optional:"true"`
-	Sort         string   `short:"S" help:"Sort by: 'size' (largest last), 'name', or 'none' (fastest, streams output)." default:"size" alts:"size,name,none"`
+	Sort         string   `short:"S" help:"Sort by: 'size' (largest last), 'name', 'time' (newest last, implies --time), or 'none' (fastest, streams output)." default:"size" alts:"size,name,time,none"`
 	Reverse      bool     `short:"r" help:"Reverse the sort order." optional:"true"`
 	IgnoreGit    bool     `help:"Respect .gitignore files." optional:"true"`
+	Time         string   `optional:"true" help:"Show the time of the last modification of any file in each directory. Use --time=atime or --time=ctime for access or status change time instead." alts:"mtime,atime,ctime"`
+	Exclude      []string `optional:"true" help:"Skip files and directories matching this glob, like .git or node_modules (repeatable). Matched against the name, or the path below the argument if the glob has a '/'."`
+	Threshold    string   `short:"t" optional:"true" help:"Exclude entries smaller than SIZE if positive, or larger than SIZE if negative. Accepts suffixes like 10M, 1.5G or 500KB."`
 }
 
 type DirNode struct {
@@ -34,29 +40,51 @@ type DirNode struct {
 	ChildDirs  []*DirNode
 	ChildFiles []FileNode // files at this level (only used when --all)
 	TotalSize  int64      // calculated later
+	Time       time.Time  // newest time of the directory itself and anything below it
 }
 
 type FileNode struct {
 	Path string
 	Size int64
+	Time time.Time
 }
 
 // Entry represents a flattened entry (file or directory) for global sorting
 type Entry struct {
 	Path string
 	Size int64
+	Time time.Time
+}
+
+// printOptions controls how sizes and times are printed
+type printOptions struct {
+	blockSize int64
+	human     bool
+	showTime  bool
+	threshold int64 // bytes, see Params.Threshold; 0 prints everything
 }
 
 func Cmd() *cobra.Command {
 	cmd := boa.CmdT[Params]{
-		Use:         "du",
-		Short:       "Estimate file and directory space usage",
-		Long:        "Estimate file and directory space usage, like the Unix du command but cross-platform.",
+		Use:   "du",
+		Short: "Estimate file and directory space usage",
+		Long: `Estimate file and directory space usage, like the Unix du command but cross-platform.
+
+Sorting by size puts the largest entries last, like 'du | sort -h', so they
+are the ones left on screen when the output scrolls. Use -r to list the
+largest first.`,
 		ParamEnrich: common.DefaultParamEnricher(),
 		InitFunc: func(params *Params, cmd *cobra.Command) error {
 			cmd.Flags().BoolP("help", "", false, "help for du")
 			return nil
 		},
+		PostCreateFunc: func(params *Params, cmd *cobra.Command) error {
+			// Like GNU du, a bare --time shows modification times
+			if f := cmd.Flags().Lookup("time"); f != nil {
+				f.NoOptDefVal = "mtime"
+			}
+			return nil
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if err := Run(params); err != nil {
 				_, _ = fmt.Fprintf(os.Stderr, "du: %v\n", err)
@@ -84,24 +112,37 @@ func Run(params *Params) error {
 		maxDepth = 0
 	}
 
+	timeKind := params.Time
+	if params.Sort == "time" && timeKind == "" {
+		timeKind = "mtime"
+	}
+	opts := printOptions{blockSize: blockSize, human: params.Human, showTime: timeKind != ""}
+	if params.Threshold != "" {
+		threshold, err := parseSize(params.Threshold)
+		if err != nil {
+			return fmt.Errorf("invalid --threshold: %w", err)
+		}
+		opts.threshold = threshold
+	}
+
 	for _, path := range params.Paths {
 		// Streaming mode: print as we go, no tree building
 		if params.Sort == "none" {
-			onFile := func(filePath string, depth int, size int64) {
+			onFile := func(filePath string, depth int, size int64, t time.Time) {
 				if maxDepth == -1 || depth <= maxDepth {
-					printSize(size, blockSize, params.Human, filePath)
+					printSize(size, t, opts, filePath)
 				}
 			}
-			onFinish := func(nodePath string, depth int, totalSize int64) {
+			onFinish := func(nodePath string, depth int, totalSize int64, t time.Time) {
 				if maxDepth == -1 || depth <= maxDepth {
-					printSize(totalSize, blockSize, params.Human, nodePath)
+					printSize(totalSize, t, opts, nodePath)
 				}
 			}
-			var fileCallback func(string, int, int64)
+			var fileCallback func(string, int, int64, time.Time)
 			if params.All {
 				fileCallback = onFile
 			}
-			_, err := walkDir(path, apparentSize, params.All, onFinish, fileCallback)
+			_, err := walkDir(path, apparentSize, params.All, timeKind, params.Exclude, onFinish, fileCallback)
 			if err != nil {
 				_, _ = fmt.Fprintf(os.Stderr, "du: error reading '%s': %v\n", path, err)
 			}
@@ -109,23 +150,23 @@ func Run(params *Params) error {
 		}
 
 		// Tree mode: build tree, then print
-		rootNode, err := walkDir(path, apparentSize, params.All, nil, nil)
+		rootNode, err := walkDir(path, apparentSize, params.All, timeKind, params.Exclude, nil, nil)
 		if err != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "du: error reading '%s': %v\n", path, err)
 			continue
 		}
 		pruneNodesToMaxDepth(rootNode, maxDepth, 0)
 
-		if params.Sort == "size" {
-			// For size sorting, flatten into a list for global sort
+		if params.Sort == "size" || params.Sort == "time" {
+			// For size and time sorting, flatten into a list for global sort
 			entries := flattenTree(rootNode, params.All)
 			sortEntries(entries, params.Sort, params.Reverse)
 			for _, e := range entries {
-				printSize(e.Size, blockSize, params.Human, e.Path)
+				printSize(e.Size, e.Time, opts, e.Path)
 			}
 		} else {
 			// For name sorting or no explicit sort, use hierarchical output
-			printNodes(rootNode, blockSize, params.Human, params.All)
+			printNodes(rootNode, opts, params.All)
 		}
 	}
 
@@ -143,7 +184,7 @@ func flattenTreeRecursive(node *DirNode, includeFiles bool, entries *[]Entry) {
 	// Add files at this level
 	if includeFiles {
 		for _, f := range node.ChildFiles {
-			*entries = append(*entries, Entry{Path: f.Path, Size: f.Size})
+			*entries = append(*entries, Entry{Path: f.Path, Size: f.Size, Time: f.Time})
 		}
 	}
 
@@ -153,7 +194,7 @@ func flattenTreeRecursive(node *DirNode, includeFiles bool, entries *[]Entry) {
 	}
 
 	// Add this directory itself
-	*entries = append(*entries, Entry{Path: node.Path, Size: node.TotalSize})
+	*entries = append(*entries, Entry{Path: node.Path, Size: node.TotalSize, Time: node.Time})
 }
 
 // sortEntries sorts a flat list of entries by the given criteria
@@ -166,6 +207,13 @@ func sortEntries(entries []Entry, sortBy string, reverse bool) {
 			}
 			return int(i.Size - j.Size)
 		})
+	case "time":
+		slices.SortFunc(entries, func(i, j Entry) int {
+			if reverse {
+				return j.Time.Compare(i.Time)
+			}
+			return i.Time.Compare(j.Time)
+		})
 	case "name":
 		slices.SortFunc(entries, func(i, j Entry) int {
 			if reverse {
@@ -188,10 +236,13 @@ func pruneNodesToMaxDepth(node *DirNode, maxDepth int, currentDepth int) {
 
 // walkDir walks a directory tree and either builds a tree structure (when onFinish is nil)
 // or streams output via the callback (when onFinish is provided).
-// In streaming mode, onFinish is called with (path, depth, totalSize) for each directory.
+// In streaming mode, onFinish is called with (path, depth, totalSize, time) for each directory.
 // When all is true and onFile is provided (streaming), onFile is called for each file with depth.
 // When all is true and onFile is nil (tree mode), files are stored in ChildFiles.
-func walkDir(rootPath string, apparentSize bool, all bool, onFinish func(path string, depth int, totalSize int64), onFile func(path string, depth int, size int64)) (*DirNode, error) {
+// Each directory's time is the newest timeKind time (see fileTime) found in it, recursively.
+// Entries matching an exclude glob (see isExcluded) are skipped, and excluded directories
+// are not descended into, so neither counts towards any total.
+func walkDir(rootPath string, apparentSize bool, all bool, timeKind string, exclude []string, onFinish func(path string, depth int, totalSize int64, t time.Time), onFile func(path string, depth int, size int64, t time.Time)) (*DirNode, error) {
 	// Normalize path to handle trailing slashes (e.g., "./" -> ".")
 	rootPath = filepath.Clean(rootPath)
 	streaming := onFinish != nil
@@ -221,6 +272,7 @@ func walkDir(rootPath string, apparentSize bool, all bool, onFinish func(path st
 	rootNode := &DirNode{
 		Path:      rootPath,
 		LevelSize: getDirSize(rootInfo),
+		Time:      fileTime(rootInfo, timeKind),
 	}
 
 	// Stack-based approach: since WalkDir is depth-first, we use a stack
@@ -247,7 +299,19 @@ func walkDir(rootPath string, apparentSize bool, all bool, onFinish func(path st
 		}
 
 		if streaming {
-			onFinish(node.Path, entry.depth, node.TotalSize)
+			onFinish(node.Path, entry.depth, node.TotalSize, node.Time)
+		}
+	}
+
+	// Helper to pass a finished directory's totals up to its parent
+	propagate := func(finished stackEntry) {
+		parent := stack[len(stack)-1].node
+		// Add finished total to parent's LevelSize (for streaming mode where we don't keep ChildDirs)
+		if streaming {
+			parent.LevelSize += finished.node.TotalSize
+		}
+		if finished.node.Time.After(parent.Time) {
+			parent.Time = finished.node.Time
 		}
 	}
 
@@ -261,6 +325,13 @@ func walkDir(rootPath string, apparentSize bool, all bool, onFinish func(path st
 			return nil // Root already on stack
 		}
 
+		if isExcluded(rootPath, path, exclude) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		parentPath := filepath.Dir(path)
 
 		// Pop finished directories until stack top is our parent
@@ -268,10 +339,8 @@ func walkDir(rootPath string, apparentSize bool, all bool, onFinish func(path st
 			finalizeDir(stack[len(stack)-1])
 			finished := stack[len(stack)-1]
 			stack = stack[:len(stack)-1]
-
-			// Add finished total to parent's LevelSize (for streaming mode where we don't keep ChildDirs)
-			if streaming && len(stack) > 0 {
-				stack[len(stack)-1].node.LevelSize += finished.node.TotalSize
+			if len(stack) > 0 {
+				propagate(finished)
 			}
 		}
 
@@ -291,6 +360,7 @@ func walkDir(rootPath string, apparentSize bool, all bool, onFinish func(path st
 			node := &DirNode{
 				Path:      path,
 				LevelSize: getDirSize(dirInfo),
+				Time:      fileTime(dirInfo, timeKind),
 			}
 			// Only build tree structure when not streaming
 			if !streaming {
@@ -305,15 +375,19 @@ func walkDir(rootPath string, apparentSize bool, all bool, onFinish func(path st
 			}
 			fileSize := getFileSize(fileInfo)
 			parent.node.LevelSize += fileSize
+			t := fileTime(fileInfo, timeKind)
+			if t.After(parent.node.Time) {
+				parent.node.Time = t
+			}
 
 			// Handle --all flag: track or stream file entries
 			if all {
 				if onFile != nil {
 					// Streaming mode: print file immediately with parent's depth
-					onFile(path, parent.depth, fileSize)
+					onFile(path, parent.depth, fileSize, t)
 				} else {
 					// Tree mode: store file for later printing
-					parent.node.ChildFiles = append(parent.node.ChildFiles, FileNode{Path: path, Size: fileSize})
+					parent.node.ChildFiles = append(parent.node.ChildFiles, FileNode{Path: path, Size: fileSize, Time: t})
 				}
 			}
 		}
@@ -330,9 +404,8 @@ func walkDir(rootPath string, apparentSize bool, all bool, onFinish func(path st
 		finalizeDir(stack[len(stack)-1])
 		finished := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
-
-		if streaming && len(stack) > 0 {
-			stack[len(stack)-1].node.LevelSize += finished.node.TotalSize
+		if len(stack) > 0 {
+			propagate(finished)
 		}
 	}
 
@@ -342,30 +415,96 @@ func walkDir(rootPath string, apparentSize bool, all bool, onFinish func(path st
 	return rootNode, nil
 }
 
-func printNodes(node *DirNode, blockSize int64, human bool, all bool) {
+// isExcluded reports whether entryPath matches one of the exclude globs.
+// Globs with a '/' are matched against the path relative to rootPath, others
+// against the name only.
+func isExcluded(rootPath, entryPath string, exclude []string) bool {
+	name := filepath.Base(entryPath)
+	for _, pattern := range exclude {
+		if strings.Contains(pattern, "/") {
+			rel, err := filepath.Rel(rootPath, entryPath)
+			if err != nil {
+				continue
+			}
+			if matched, _ := path.Match(strings.Trim(pattern, "/"), filepath.ToSlash(rel)); matched {
+				return true
+			}
+		} else if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func printNodes(node *DirNode, opts printOptions, all bool) {
 	// Print files at this level first (if --all)
 	if all {
 		for _, f := range node.ChildFiles {
-			printSize(f.Size, blockSize, human, f.Path)
+			printSize(f.Size, f.Time, opts, f.Path)
 		}
 	}
 
 	// Recursively print child directories
 	for _, child := range node.ChildDirs {
-		printNodes(child, blockSize, human, all)
+		printNodes(child, opts, all)
 	}
 
 	// Print this directory's total last
-	printSize(node.TotalSize, blockSize, human, node.Path)
+	printSize(node.TotalSize, node.Time, opts, node.Path)
 }
 
-func printSize(size int64, blockSize int64, human bool, path string) {
-	if human {
-		fmt.Printf("%s\t%s\n", formatHumanReadable(size), path)
+func printSize(size int64, t time.Time, opts printOptions, path string) {
+	if (opts.threshold > 0 && size < opts.threshold) || (opts.threshold < 0 && size > -opts.threshold) {
+		return
+	}
+
+	var sizeStr string
+	if opts.human {
+		sizeStr = formatHumanReadable(size)
+	} else {
+		blocks := (size + opts.blockSize - 1) / opts.blockSize // Round up
+		sizeStr = fmt.Sprintf("%d", blocks)
+	}
+	if opts.showTime {
+		// Same format as GNU du --time
+		fmt.Printf("%s\t%s\t%s\n", sizeStr, t.Format("2006-01-02 15:04"), path)
 	} else {
-		blocks := (size + blockSize - 1) / blockSize // Round up
-		fmt.Printf("%d\t%s\n", blocks, path)
+		fmt.Printf("%s\t%s\n", sizeStr, path)
+	}
+}
+
+// sizeSuffixes are the multipliers for parseSize. Like GNU du, single
+// letters and KiB style suffixes are powers of 1024 and KB style suffixes
+// powers of 1000.
+var sizeSuffixes = map[string]float64{
+	"":  1,
+	"b": 1,
+	"k": 1 << 10, "kib": 1 << 10, "kb": 1e3,
+	"m": 1 << 20, "mib": 1 << 20, "mb": 1e6,
+	"g": 1 << 30, "gib": 1 << 30, "gb": 1e9,
+	"t": 1 << 40, "tib": 1 << 40, "tb": 1e12,
+	"p": 1 << 50, "pib": 1 << 50, "pb": 1e15,
+}
+
+// parseSize parses a size in bytes with an optional suffix, like "10M",
+// "1.5G", "500KB" or "-1G".
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	numEnd := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	if numEnd == -1 {
+		numEnd = len(s)
+	}
+	value, err := strconv.ParseFloat(s[:numEnd], 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a size", s)
+	}
+	multiplier, ok := sizeSuffixes[strings.ToLower(strings.TrimSpace(s[numEnd:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown size suffix in %q", s)
 	}
+	return int64(math.Round(value * multiplier)), nil
 }
 
 func formatHumanReadable(bytes int64) string {
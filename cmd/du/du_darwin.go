@@ -0,0 +1,22 @@
+//go:build darwin
+
+package du
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// fileTime returns the access, status change or modification time of a file.
+func fileTime(info fs.FileInfo, kind string) time.Time {
+	if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+		switch kind {
+		case "atime":
+			return time.Unix(sys.Atimespec.Sec, sys.Atimespec.Nsec)
+		case "ctime":
+			return time.Unix(sys.Ctimespec.Sec, sys.Ctimespec.Nsec)
+		}
+	}
+	return info.ModTime()
+}
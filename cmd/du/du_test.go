@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 // captureOutput captures stdout during function execution
@@ -60,7 +61,7 @@ func TestWalkDir_TreeStructure(t *testing.T) {
 	})
 
 	// Call walkDir directly in tree mode
-	rootNode, err := walkDir(dir, true, true, nil, nil)
+	rootNode, err := walkDir(dir, true, true, "", nil, nil, nil)
 	if err != nil {
 		t.Fatalf("walkDir failed: %v", err)
 	}
@@ -92,7 +93,7 @@ func TestFlattenAndSort(t *testing.T) {
 	})
 
 	// Build tree with apparentSize=false to match Run behavior
-	rootNode, err := walkDir(dir, false, true, nil, nil)
+	rootNode, err := walkDir(dir, false, true, "", nil, nil, nil)
 	if err != nil {
 		t.Fatalf("walkDir failed: %v", err)
 	}
@@ -507,3 +508,231 @@ func TestDu_Summarize_IgnoresAllFlag(t *testing.T) {
 		t.Errorf("expected 3 lines (2 files + root dir) with -s -a, got %d: %v", len(lines), lines)
 	}
 }
+
+// setTimes sets the modification time of paths relative to dir. Directories
+// are updated after their contents, since writing a file touches its parent.
+func setTimes(t *testing.T, dir string, times map[string]time.Time) {
+	t.Helper()
+	for path, mtime := range times {
+		if err := os.Chtimes(filepath.Join(dir, path), mtime, mtime); err != nil {
+			t.Fatalf("failed to set times of %s: %v", path, err)
+		}
+	}
+}
+
+func TestWalkDir_DirectoryTimeIsNewestFile(t *testing.T) {
+	dir := setupTestDir(t, map[string]string{
+		"a/old.txt":     "old",
+		"a/new.txt":     "new",
+		"a/deep/x.txt":  "x",
+		"b/only.txt":    "only",
+		"top-level.txt": "top",
+	})
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	newest := base.Add(48 * time.Hour)
+	setTimes(t, dir, map[string]time.Time{
+		"a/old.txt":     base,
+		"a/new.txt":     newest,
+		"a/deep/x.txt":  base.Add(time.Hour),
+		"b/only.txt":    base.Add(2 * time.Hour),
+		"top-level.txt": base,
+	})
+	setTimes(t, dir, map[string]time.Time{"a/deep": base, "a": base, "b": base, ".": base})
+
+	rootNode, err := walkDir(dir, true, false, "mtime", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("walkDir failed: %v", err)
+	}
+
+	times := map[string]time.Time{}
+	for _, e := range flattenTree(rootNode, false) {
+		rel, _ := filepath.Rel(dir, e.Path)
+		times[rel] = e.Time
+	}
+	expected := map[string]time.Time{
+		".":      newest,
+		"a":      newest,
+		"a/deep": base.Add(time.Hour),
+		"b":      base.Add(2 * time.Hour),
+	}
+	for path, want := range expected {
+		if !times[filepath.FromSlash(path)].Equal(want) {
+			t.Errorf("expected time of %s to be %v, got %v", path, want, times[filepath.FromSlash(path)])
+		}
+	}
+}
+
+func TestDu_TimeColumnAndSort(t *testing.T) {
+	dir := setupTestDir(t, map[string]string{
+		"stale/big.txt":  strings.Repeat("x", 10000),
+		"fresh/tiny.txt": "x",
+	})
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	setTimes(t, dir, map[string]time.Time{
+		"stale/big.txt":  base,
+		"fresh/tiny.txt": base.Add(24 * time.Hour),
+	})
+	setTimes(t, dir, map[string]time.Time{"stale": base, "fresh": base, ".": base})
+
+	for _, sortBy := range []string{"time", "none"} {
+		output := captureOutput(func() {
+			Run(&Params{Paths: []string{dir}, MaxDepth: -1, Bytes: true, Time: "mtime", Sort: sortBy})
+		})
+		if !strings.Contains(output, "10000\t2024-01-01 12:00\t"+filepath.Join(dir, "stale")+"\n") {
+			t.Errorf("expected stale dir with its file's time (sort %s), got:\n%s", sortBy, output)
+		}
+		if !strings.Contains(output, "\t2024-01-02 12:00\t"+dir+"\n") {
+			t.Errorf("expected root with newest time (sort %s), got:\n%s", sortBy, output)
+		}
+	}
+
+	// Sorting by time implies --time and puts the newest last
+	output := captureOutput(func() {
+		Run(&Params{Paths: []string{dir}, MaxDepth: -1, Sort: "time"})
+	})
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "stale") || !strings.Contains(lines[0], "2024-01-01 12:00") {
+		t.Errorf("expected stale dir first with its time, got %v", lines)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"100B", 100},
+		{"10K", 10 * 1024},
+		{"10k", 10 * 1024},
+		{"1.5M", 1536 * 1024},
+		{"2G", 2 << 30},
+		{"2GiB", 2 << 30},
+		{"500KB", 500000},
+		{"1 MB", 1000000},
+		{"-1M", -(1 << 20)},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.input)
+		if err != nil {
+			t.Errorf("parseSize(%q): unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "M", "10X", "ten", "1.2.3K"} {
+		if _, err := parseSize(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestDu_Threshold(t *testing.T) {
+	dir := setupTestDir(t, map[string]string{
+		"big/file.bin":   strings.Repeat("x", 3000),
+		"small/file.txt": strings.Repeat("x", 10),
+	})
+
+	run := func(threshold string) string {
+		return captureOutput(func() {
+			if err := Run(&Params{Paths: []string{dir}, Bytes: true, Sort: "name", MaxDepth: -1, All: true, Threshold: threshold}); err != nil {
+				t.Errorf("Run failed: %v", err)
+			}
+		})
+	}
+
+	// Only entries of at least 2K, parents included as their totals are large
+	out := run("2K")
+	for _, want := range []string{"3000\t" + filepath.Join(dir, "big", "file.bin") + "\n", "3000\t" + filepath.Join(dir, "big") + "\n", "3010\t" + dir + "\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "small") {
+		t.Errorf("Expected small entries excluded, got:\n%s", out)
+	}
+
+	// Negative thresholds keep only entries of at most that size
+	out = run("-100")
+	if strings.TrimSpace(out) != "10\t"+filepath.Join(dir, "small", "file.txt")+"\n10\t"+filepath.Join(dir, "small") {
+		t.Errorf("Expected only small entries, got:\n%s", out)
+	}
+
+	if err := Run(&Params{Paths: []string{dir}, Sort: "name", MaxDepth: -1, Threshold: "lots"}); err == nil {
+		t.Error("Expected error for invalid threshold")
+	}
+}
+
+func TestDu_Exclude(t *testing.T) {
+	dir := setupTestDir(t, map[string]string{
+		"src/main.go":               strings.Repeat("x", 100),
+		"src/debug.log":             strings.Repeat("x", 50),
+		"node_modules/pkg/index.js": strings.Repeat("x", 4000),
+		"web/node_modules/x.js":     strings.Repeat("x", 4000),
+		"web/build/out.js":          strings.Repeat("x", 300),
+		"build/out.js":              strings.Repeat("x", 200),
+	})
+
+	output := captureOutput(func() {
+		Run(&Params{
+			Paths:    []string{dir},
+			Bytes:    true,
+			All:      true,
+			Sort:     "name",
+			MaxDepth: -1,
+			Exclude:  []string{"node_modules", "*.log", "web/build"},
+		})
+	})
+
+	for _, excluded := range []string{"node_modules", "debug.log", filepath.Join("web", "build")} {
+		if strings.Contains(output, excluded) {
+			t.Errorf("Expected %s excluded, got:\n%s", excluded, output)
+		}
+	}
+	// A '/' glob is relative to the argument, so the top level build is kept
+	for _, want := range []string{
+		"100\t" + filepath.Join(dir, "src") + "\n",
+		"0\t" + filepath.Join(dir, "web") + "\n",
+		"200\t" + filepath.Join(dir, "build") + "\n",
+		"300\t" + dir + "\n", // excluded subtrees don't count towards totals
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	// Streaming mode prunes the same way
+	output = captureOutput(func() {
+		Run(&Params{Paths: []string{dir}, Bytes: true, Sort: "none", MaxDepth: 0, Exclude: []string{"node_modules", "*.log", "web/build"}})
+	})
+	if output != "300\t"+dir+"\n" {
+		t.Errorf("Expected streamed total of 300, got %q", output)
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	root := filepath.Join("proj")
+	tests := []struct {
+		path    string
+		exclude []string
+		want    bool
+	}{
+		{filepath.Join(root, ".git"), []string{".git"}, true},
+		{filepath.Join(root, "a", ".git"), []string{".git"}, true},
+		{filepath.Join(root, "a", "b.tmp"), []string{"*.tmp"}, true},
+		{filepath.Join(root, "a", "b.go"), []string{"*.tmp"}, false},
+		{filepath.Join(root, "a", "dist"), []string{"a/dist"}, true},
+		{filepath.Join(root, "b", "a", "dist"), []string{"a/dist"}, false},
+		{filepath.Join(root, "b", "a", "dist"), []string{"*/a/dist/"}, true},
+		{filepath.Join(root, "x"), nil, false},
+	}
+	for _, tt := range tests {
+		if got := isExcluded(root, tt.path, tt.exclude); got != tt.want {
+			t.Errorf("isExcluded(%q, %q) = %v, want %v", tt.path, tt.exclude, got, tt.want)
+		}
+	}
+}
@@ -4,6 +4,8 @@ package du
 
 import (
 	"io/fs"
+	"syscall"
+	"time"
 )
 
 // getDiskUsage returns estimated disk usage in bytes.
@@ -16,3 +18,12 @@ func getDiskUsage(info fs.FileInfo) int64 {
 	// Round up to 4096-byte clusters (typical NTFS cluster size)
 	return ((size + 4095) / 4096) * 4096
 }
+
+// fileTime returns the access or modification time of a file. Windows has no
+// status change time, so ctime falls back to the modification time.
+func fileTime(info fs.FileInfo, kind string) time.Time {
+	if sys, ok := info.Sys().(*syscall.Win32FileAttributeData); ok && kind == "atime" {
+		return time.Unix(0, sys.LastAccessTime.Nanoseconds())
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,200 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type DiffParams struct {
+	Files         []string `pos:"true" help:"Snapshot or dotenv file to compare the current environment against, or two files to compare with each other."`
+	ShowValues    bool     `help:"Show variable values instead of redacting them." optional:"true"`
+	Match         string   `short:"m" help:"Only compare variables whose key name matches this regular expression." optional:"true"`
+	SecretPattern string   `help:"Mask the values of variables whose key name matches this regular expression, also with --show-values. Empty to mask nothing." default:"(?i)(secret|token|passw(or)?d|credential|api_?key|private_?key|auth)"`
+}
+
+func diffCmd() *cobra.Command {
+	return boa.CmdT[DiffParams]{
+		Use:   "diff <file> [file2]",
+		Short: "Compare the current environment against a snapshot or dotenv file",
+		Long: `Show variables that were added, removed or changed in the current environment
+compared to a snapshot saved with 'tofu env save' or any dotenv file. With two
+files, show what changed from the first to the second instead.
+
+Values are redacted unless --show-values is given, and the values of variables
+whose name matches --secret-pattern are masked even then.`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *DiffParams, cmd *cobra.Command, args []string) {
+			if err := runDiff(params, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "env diff: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func runDiff(params *DiffParams, w io.Writer) error {
+	if len(params.Files) == 0 || len(params.Files) > 2 {
+		return fmt.Errorf("expected one or two files, got %d", len(params.Files))
+	}
+	match, err := compileMatch(params.Match)
+	if err != nil {
+		return err
+	}
+	secret, err := compileSecretPattern(params.SecretPattern)
+	if err != nil {
+		return err
+	}
+
+	before, err := readDotenvFile(params.Files[0], os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	var after map[string]string
+	if len(params.Files) == 2 {
+		file, err := readDotenvFile(params.Files[1], os.LookupEnv)
+		if err != nil {
+			return err
+		}
+		after = file.Values
+	} else {
+		after = make(map[string]string)
+		for _, entry := range os.Environ() {
+			key, value := splitEnvEntry(entry)
+			if key != "" {
+				after[key] = value
+			}
+		}
+	}
+
+	changes := diffEnv(before.Values, after)
+	if match != nil {
+		filtered := changes[:0]
+		for _, c := range changes {
+			if match.MatchString(c.Key) {
+				filtered = append(filtered, c)
+			}
+		}
+		changes = filtered
+	}
+
+	if secret != nil {
+		for i, c := range changes {
+			if secret.MatchString(c.Key) {
+				changes[i].Masked = true
+			}
+		}
+	}
+
+	printEnvChanges(w, changes, params.ShowValues)
+	return nil
+}
+
+// compileSecretPattern compiles --secret-pattern. A nil regexp masks nothing.
+func compileSecretPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --secret-pattern: %w", err)
+	}
+	return re, nil
+}
+
+type envChangeKind int
+
+const (
+	envAdded envChangeKind = iota
+	envRemoved
+	envChanged
+)
+
+type envChange struct {
+	Key      string
+	Kind     envChangeKind
+	OldValue string
+	NewValue string
+	// Masked hides the values even when they are shown
+	Masked bool
+}
+
+// diffEnv compares two variable sets and returns the differences sorted by
+// key. Added and removed are relative to the before set.
+func diffEnv(before, after map[string]string) []envChange {
+	var changes []envChange
+	for key, oldValue := range before {
+		newValue, exists := after[key]
+		if !exists {
+			changes = append(changes, envChange{Key: key, Kind: envRemoved, OldValue: oldValue})
+		} else if newValue != oldValue {
+			changes = append(changes, envChange{Key: key, Kind: envChanged, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	for key, newValue := range after {
+		if _, exists := before[key]; !exists {
+			changes = append(changes, envChange{Key: key, Kind: envAdded, NewValue: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+func printEnvChanges(w io.Writer, changes []envChange, showValues bool) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No differences")
+		return
+	}
+
+	var added, removed, changed int
+	for _, c := range changes {
+		if c.Masked {
+			c.OldValue, c.NewValue = maskValue(c.OldValue), maskValue(c.NewValue)
+		}
+		switch c.Kind {
+		case envAdded:
+			added++
+			if showValues {
+				fmt.Fprintf(w, "+ %s=%s\n", c.Key, c.NewValue)
+			} else {
+				fmt.Fprintf(w, "+ %s\n", c.Key)
+			}
+		case envRemoved:
+			removed++
+			if showValues {
+				fmt.Fprintf(w, "- %s=%s\n", c.Key, c.OldValue)
+			} else {
+				fmt.Fprintf(w, "- %s\n", c.Key)
+			}
+		case envChanged:
+			changed++
+			if showValues {
+				fmt.Fprintf(w, "~ %s: %q -> %q\n", c.Key, c.OldValue, c.NewValue)
+			} else {
+				fmt.Fprintf(w, "~ %s\n", c.Key)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d added, %d removed, %d changed\n", added, removed, changed)
+}
+
+// maskedValue replaces the value of a secret variable when values are shown.
+const maskedValue = "****"
+
+func maskValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return maskedValue
+}
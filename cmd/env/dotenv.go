@@ -0,0 +1,224 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var dotenvKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// dotenvFile holds the variables of a parsed dotenv file in file order.
+type dotenvFile struct {
+	Keys   []string
+	Values map[string]string
+}
+
+// lookupFunc looks up the value of a variable referenced as ${NAME}.
+type lookupFunc func(key string) (string, bool)
+
+func readDotenvFile(path string, lookup lookupFunc) (*dotenvFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	parsed, err := parseDotenv(f, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return parsed, nil
+}
+
+// parseDotenv parses KEY=VALUE lines. Blank lines and # comments are skipped,
+// an optional leading "export " is ignored, single-quoted values are taken
+// literally and double-quoted values support \n, \t, \", \\ and \$ escapes.
+// Later assignments of the same key override earlier ones.
+//
+// Unquoted and double-quoted values expand ${NAME} to the value of a variable
+// assigned earlier in the file, or else to lookup(NAME), or else to "".
+func parseDotenv(r io.Reader, lookup lookupFunc) (*dotenvFile, error) {
+	result := &dotenvFile{Values: make(map[string]string)}
+	expand := func(key string) string {
+		if value, ok := result.Values[key]; ok {
+			return value
+		}
+		if lookup != nil {
+			value, _ := lookup(key)
+			return value
+		}
+		return ""
+	}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, rawValue, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || !dotenvKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+
+		value, err := parseDotenvValue(strings.TrimSpace(rawValue), expand)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if _, exists := result.Values[key]; !exists {
+			result.Keys = append(result.Keys, key)
+		}
+		result.Values[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func parseDotenvValue(raw string, expand func(string) string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+	case '"':
+		var sb strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			switch {
+			case c == '"':
+				return sb.String(), nil
+			case c == '$':
+				value, n, err := expandReference(raw[i:], expand)
+				if err != nil {
+					return "", err
+				}
+				sb.WriteString(value)
+				i += n - 1
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 't':
+					sb.WriteByte('\t')
+				case 'r':
+					sb.WriteByte('\r')
+				default:
+					sb.WriteByte(raw[i])
+				}
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+	}
+
+	// Unquoted: strip trailing inline comments
+	if idx := strings.Index(raw, " #"); idx >= 0 {
+		raw = strings.TrimSpace(raw[:idx])
+	}
+	var sb strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '$' {
+			sb.WriteByte(raw[i])
+			continue
+		}
+		value, n, err := expandReference(raw[i:], expand)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(value)
+		i += n - 1
+	}
+	return sb.String(), nil
+}
+
+// expandReference expands the ${NAME} reference that s starts with, returning
+// its value and length. A $ not followed by { is kept as is.
+func expandReference(s string, expand func(string) string) (string, int, error) {
+	if !strings.HasPrefix(s, "${") {
+		return "$", 1, nil
+	}
+	end := strings.IndexByte(s, '}')
+	if end < 0 {
+		return "", 0, fmt.Errorf("unterminated ${ reference")
+	}
+	name := s[2:end]
+	if !dotenvKeyPattern.MatchString(name) {
+		return "", 0, fmt.Errorf("invalid variable reference ${%s}", name)
+	}
+	return expand(name), end + 1, nil
+}
+
+// loadDotenvFiles reads the files in order into one set of variables. Later
+// files override earlier ones and can reference their variables, as well as
+// the current environment.
+func loadDotenvFiles(paths []string) (*dotenvFile, error) {
+	merged := &dotenvFile{Values: make(map[string]string)}
+	lookup := func(key string) (string, bool) {
+		if value, ok := merged.Values[key]; ok {
+			return value, true
+		}
+		return os.LookupEnv(key)
+	}
+	for _, path := range paths {
+		file, err := readDotenvFile(path, lookup)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range file.Keys {
+			if _, exists := merged.Values[key]; !exists {
+				merged.Keys = append(merged.Keys, key)
+			}
+			merged.Values[key] = file.Values[key]
+		}
+	}
+	return merged, nil
+}
+
+// entries returns the variables as KEY=VALUE entries in file order.
+func (f *dotenvFile) entries() []string {
+	entries := make([]string, len(f.Keys))
+	for i, key := range f.Keys {
+		entries[i] = key + "=" + f.Values[key]
+	}
+	return entries
+}
+
+var dotenvPlainValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// dotenvQuote formats a value for a dotenv file, double-quoting and escaping
+// it when it contains anything beyond a conservative set of safe characters.
+func dotenvQuote(value string) string {
+	if dotenvPlainValue.MatchString(value) {
+		return value
+	}
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`$`, `\$`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	)
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -3,8 +3,10 @@ package env
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"regexp"
 	"runtime"
 	"sort"
 	"strings"
@@ -16,8 +18,9 @@ import (
 
 type Params struct {
 	Command []string `pos:"true" optional:"true" help:"Command to run with modified environment."`
-	Format  string   `short:"f" help:"Output format (plain, json, shell, powershell)." default:"plain" alts:"plain,json,shell,powershell"`
+	Format  string   `short:"f" help:"Output format (plain, json, shell, powershell, dotenv)." default:"plain" alts:"plain,json,shell,powershell,dotenv"`
 	Filter  string   `help:"Filter variables by prefix (case-insensitive)." optional:"true"`
+	Match   string   `short:"m" help:"Filter variables by a regular expression matched against the key name." optional:"true"`
 	Sort    bool     `short:"s" help:"Sort variables alphabetically." default:"true"`
 	Keys    bool     `short:"k" help:"Show only variable names (keys)." optional:"true"`
 	Values  bool     `short:"v" help:"Show only variable values." optional:"true"`
@@ -25,7 +28,10 @@ type Params struct {
 	Set     string   `help:"Set an environment variable (format: KEY=VALUE) and run command." optional:"true"`
 	Unset   string   `short:"u" help:"Unset an environment variable and run command." optional:"true"`
 	Export  bool     `short:"e" help:"Output in export format for shell sourcing." optional:"true"`
+	JSON    bool     `name:"json" help:"Output as JSON (shorthand for --format json)." optional:"true"`
+	Dotenv  bool     `help:"Output in dotenv format (shorthand for --format dotenv)." optional:"true"`
 	NoEmpty bool     `help:"Hide variables with empty values." optional:"true"`
+	File    []string `help:"Load variables from a dotenv file, expanding ${VAR} references (repeatable). Lists only the file's variables, or runs the command with them." optional:"true"`
 }
 
 func Cmd() *cobra.Command {
@@ -34,6 +40,11 @@ func Cmd() *cobra.Command {
 		Short:       "Cross-platform environment variable management",
 		Long:        "List, get, set, or filter environment variables. Works consistently across Windows, macOS, and Linux.",
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds: []*cobra.Command{
+			diffCmd(),
+			runCmd(),
+			saveCmd(),
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if err := runEnv(params); err != nil {
 				fmt.Fprintf(os.Stderr, "env: %v\n", err)
@@ -44,6 +55,23 @@ func Cmd() *cobra.Command {
 }
 
 func runEnv(params *Params) error {
+	// Handle --file: list the file's variables, or add them to the
+	// environment for the options below
+	if len(params.File) > 0 {
+		file, err := loadDotenvFiles(params.File)
+		if err != nil {
+			return err
+		}
+		if len(params.Command) == 0 && params.Get == "" && params.Set == "" && params.Unset == "" {
+			return listEntriesTo(params, file.entries(), os.Stdout)
+		}
+		for _, key := range file.Keys {
+			if err := os.Setenv(key, file.Values[key]); err != nil {
+				return fmt.Errorf("failed to set %s: %w", key, err)
+			}
+		}
+	}
+
 	// Handle --get: retrieve a single variable
 	if params.Get != "" {
 		value, exists := os.LookupEnv(params.Get)
@@ -113,18 +141,44 @@ func runWithEnv(args []string) error {
 	return cmd.Run()
 }
 
+// splitEnvEntry splits a KEY=VALUE entry as returned by os.Environ.
+func splitEnvEntry(entry string) (string, string) {
+	key, value, _ := strings.Cut(entry, "=")
+	return key, value
+}
+
+// compileMatch compiles the --match pattern. A nil regexp matches everything.
+func compileMatch(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --match pattern: %w", err)
+	}
+	return re, nil
+}
+
 func listEnv(params *Params) error {
-	envVars := os.Environ()
+	return listEnvTo(params, os.Stdout)
+}
+
+func listEnvTo(params *Params, w io.Writer) error {
+	return listEntriesTo(params, os.Environ(), w)
+}
+
+// listEntriesTo lists KEY=VALUE entries filtered and formatted as in params.
+func listEntriesTo(params *Params, envVars []string, w io.Writer) error {
+	match, err := compileMatch(params.Match)
+	if err != nil {
+		return err
+	}
+
 	envMap := make(map[string]string)
 	var keys []string
 
 	for _, env := range envVars {
-		parts := strings.SplitN(env, "=", 2)
-		key := parts[0]
-		value := ""
-		if len(parts) > 1 {
-			value = parts[1]
-		}
+		key, value := splitEnvEntry(env)
 
 		// Apply filter
 		if params.Filter != "" {
@@ -133,6 +187,10 @@ func listEnv(params *Params) error {
 			}
 		}
 
+		if match != nil && !match.MatchString(key) {
+			continue
+		}
+
 		// Skip empty values if requested
 		if params.NoEmpty && value == "" {
 			continue
@@ -147,9 +205,14 @@ func listEnv(params *Params) error {
 		sort.Strings(keys)
 	}
 
-	// Handle export format override
+	// Handle format shorthand overrides
 	format := params.Format
-	if params.Export {
+	switch {
+	case params.JSON:
+		format = "json"
+	case params.Dotenv:
+		format = "dotenv"
+	case params.Export:
 		if runtime.GOOS == "windows" {
 			format = "powershell"
 		} else {
@@ -160,30 +223,32 @@ func listEnv(params *Params) error {
 	// Output based on format
 	switch format {
 	case "json":
-		return outputJSON(envMap, keys, params)
+		return outputJSON(w, envMap, keys, params)
 	case "shell":
-		return outputShell(envMap, keys, params)
+		return outputShell(w, envMap, keys, params)
 	case "powershell":
-		return outputPowershell(envMap, keys, params)
+		return outputPowershell(w, envMap, keys, params)
+	case "dotenv":
+		return outputDotenv(w, envMap, keys, params)
 	default:
-		return outputPlain(envMap, keys, params)
+		return outputPlain(w, envMap, keys, params)
 	}
 }
 
-func outputPlain(envMap map[string]string, keys []string, params *Params) error {
+func outputPlain(w io.Writer, envMap map[string]string, keys []string, params *Params) error {
 	for _, key := range keys {
 		if params.Keys && !params.Values {
-			fmt.Println(key)
+			fmt.Fprintln(w, key)
 		} else if params.Values && !params.Keys {
-			fmt.Println(envMap[key])
+			fmt.Fprintln(w, envMap[key])
 		} else {
-			fmt.Printf("%s=%s\n", key, envMap[key])
+			fmt.Fprintf(w, "%s=%s\n", key, envMap[key])
 		}
 	}
 	return nil
 }
 
-func outputJSON(envMap map[string]string, keys []string, params *Params) error {
+func outputJSON(w io.Writer, envMap map[string]string, keys []string, params *Params) error {
 	var output interface{}
 
 	if params.Keys && !params.Values {
@@ -203,37 +268,54 @@ func outputJSON(envMap map[string]string, keys []string, params *Params) error {
 		output = orderedMap
 	}
 
-	encoder := json.NewEncoder(os.Stdout)
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(output)
 }
 
-func outputShell(envMap map[string]string, keys []string, params *Params) error {
+func outputShell(w io.Writer, envMap map[string]string, keys []string, params *Params) error {
 	for _, key := range keys {
 		if params.Keys && !params.Values {
-			fmt.Println(key)
+			fmt.Fprintln(w, key)
 		} else if params.Values && !params.Keys {
-			fmt.Printf("%q\n", envMap[key])
+			fmt.Fprintf(w, "%q\n", envMap[key])
 		} else {
-			// Escape for shell
-			value := strings.ReplaceAll(envMap[key], "'", "'\"'\"'")
-			fmt.Printf("export %s='%s'\n", key, value)
+			fmt.Fprintf(w, "export %s=%s\n", key, shellQuote(envMap[key]))
 		}
 	}
 	return nil
 }
 
-func outputPowershell(envMap map[string]string, keys []string, params *Params) error {
+func outputPowershell(w io.Writer, envMap map[string]string, keys []string, params *Params) error {
 	for _, key := range keys {
 		if params.Keys && !params.Values {
-			fmt.Println(key)
+			fmt.Fprintln(w, key)
 		} else if params.Values && !params.Keys {
-			fmt.Printf("%q\n", envMap[key])
+			fmt.Fprintf(w, "%q\n", envMap[key])
 		} else {
 			// Escape for PowerShell
 			value := strings.ReplaceAll(envMap[key], "'", "''")
-			fmt.Printf("$env:%s = '%s'\n", key, value)
+			fmt.Fprintf(w, "$env:%s = '%s'\n", key, value)
 		}
 	}
 	return nil
 }
+
+func outputDotenv(w io.Writer, envMap map[string]string, keys []string, params *Params) error {
+	for _, key := range keys {
+		if params.Keys && !params.Values {
+			fmt.Fprintln(w, key)
+		} else if params.Values && !params.Keys {
+			fmt.Fprintln(w, dotenvQuote(envMap[key]))
+		} else {
+			fmt.Fprintf(w, "%s=%s\n", key, dotenvQuote(envMap[key]))
+		}
+	}
+	return nil
+}
+
+// shellQuote wraps a value in single quotes so that a POSIX shell reads it
+// back verbatim. Embedded single quotes are closed, escaped and reopened.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -1,7 +1,13 @@
 package env
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -202,3 +208,442 @@ func TestEnvCmd(t *testing.T) {
 		t.Errorf("expected Name()='env', got '%s'", cmd.Name())
 	}
 }
+
+func TestListEnvMatch(t *testing.T) {
+	os.Setenv("TOFU_MATCH_ONE", "1")
+	os.Setenv("TOFU_MATCH_TWO", "2")
+	os.Setenv("TOFU_NOMATCH", "3")
+	defer os.Unsetenv("TOFU_MATCH_ONE")
+	defer os.Unsetenv("TOFU_MATCH_TWO")
+	defer os.Unsetenv("TOFU_NOMATCH")
+
+	formats := []string{"plain", "json", "shell", "dotenv"}
+	for _, format := range formats {
+		var out bytes.Buffer
+		params := &Params{Format: format, Match: "^TOFU_MATCH_", Sort: true}
+		if err := listEnvTo(params, &out); err != nil {
+			t.Fatalf("listEnvTo with format %s failed: %v", format, err)
+		}
+		if !strings.Contains(out.String(), "TOFU_MATCH_ONE") || !strings.Contains(out.String(), "TOFU_MATCH_TWO") {
+			t.Errorf("format %s: expected matching keys, got %q", format, out.String())
+		}
+		if strings.Contains(out.String(), "TOFU_NOMATCH") {
+			t.Errorf("format %s: expected TOFU_NOMATCH to be filtered out, got %q", format, out.String())
+		}
+	}
+}
+
+func TestListEnvMatchInvalid(t *testing.T) {
+	err := listEnvTo(&Params{Match: "("}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "invalid --match") {
+		t.Errorf("expected invalid --match error, got %v", err)
+	}
+}
+
+func TestListEnvExport(t *testing.T) {
+	os.Setenv("TOFU_EXPORT_TEST", "it's a test")
+	defer os.Unsetenv("TOFU_EXPORT_TEST")
+
+	var out bytes.Buffer
+	params := &Params{Format: "shell", Filter: "TOFU_EXPORT_TEST", Sort: true}
+	if err := listEnvTo(params, &out); err != nil {
+		t.Fatalf("listEnvTo failed: %v", err)
+	}
+	expected := "export TOFU_EXPORT_TEST='it'\\''s a test'\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestListEnvJSONAndDotenvFlags(t *testing.T) {
+	os.Setenv("TOFU_SHORTHAND_TEST", "a b")
+	defer os.Unsetenv("TOFU_SHORTHAND_TEST")
+
+	var out bytes.Buffer
+	if err := listEnvTo(&Params{Format: "plain", JSON: true, Filter: "TOFU_SHORTHAND_TEST"}, &out); err != nil {
+		t.Fatalf("listEnvTo --json failed: %v", err)
+	}
+	var parsed map[string]string
+	if err := json.Unmarshal(out.Bytes(), &parsed); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", out.String(), err)
+	}
+	if parsed["TOFU_SHORTHAND_TEST"] != "a b" {
+		t.Errorf("unexpected JSON output: %v", parsed)
+	}
+
+	out.Reset()
+	if err := listEnvTo(&Params{Format: "plain", Dotenv: true, Filter: "TOFU_SHORTHAND_TEST"}, &out); err != nil {
+		t.Fatalf("listEnvTo --dotenv failed: %v", err)
+	}
+	if out.String() != "TOFU_SHORTHAND_TEST=\"a b\"\n" {
+		t.Errorf("unexpected dotenv output: %q", out.String())
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"simple", "'simple'"},
+		{"", "''"},
+		{"it's", `'it'\''s'`},
+		{"$HOME `x`", "'$HOME `x`'"},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.input); got != tt.expected {
+			t.Errorf("shellQuote(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestDotenvRoundTrip(t *testing.T) {
+	values := []string{"plain", "with space", `quo"te`, "multi\nline", "$HOME", `back\slash`, ""}
+
+	var sb strings.Builder
+	for i, v := range values {
+		sb.WriteString("KEY" + string(rune('A'+i)) + "=" + dotenvQuote(v) + "\n")
+	}
+
+	parsed, err := parseDotenv(strings.NewReader(sb.String()), nil)
+	if err != nil {
+		t.Fatalf("parseDotenv failed: %v", err)
+	}
+	for i, v := range values {
+		key := "KEY" + string(rune('A'+i))
+		if parsed.Values[key] != v {
+			t.Errorf("%s: expected %q, got %q", key, v, parsed.Values[key])
+		}
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	input := `# comment
+export FOO=bar
+SINGLE='literal $x'
+INLINE=value # trailing comment
+
+EMPTY=
+`
+	parsed, err := parseDotenv(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("parseDotenv failed: %v", err)
+	}
+	expected := map[string]string{
+		"FOO":    "bar",
+		"SINGLE": "literal $x",
+		"INLINE": "value",
+		"EMPTY":  "",
+	}
+	for k, v := range expected {
+		if parsed.Values[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, parsed.Values[k])
+		}
+	}
+	if len(parsed.Keys) != len(expected) {
+		t.Errorf("expected %d keys, got %v", len(expected), parsed.Keys)
+	}
+
+	if _, err := parseDotenv(strings.NewReader("not a pair\n"), nil); err == nil {
+		t.Error("expected error for line without '='")
+	}
+}
+
+func TestDiffEnv(t *testing.T) {
+	before := map[string]string{"SAME": "1", "CHANGED": "old", "REMOVED": "x"}
+	after := map[string]string{"SAME": "1", "CHANGED": "new", "ADDED": "y"}
+
+	changes := diffEnv(before, after)
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", changes)
+	}
+	expected := []envChange{
+		{Key: "ADDED", Kind: envAdded, NewValue: "y"},
+		{Key: "CHANGED", Kind: envChanged, OldValue: "old", NewValue: "new"},
+		{Key: "REMOVED", Kind: envRemoved, OldValue: "x"},
+	}
+	for i, c := range expected {
+		if changes[i] != c {
+			t.Errorf("change %d: expected %+v, got %+v", i, c, changes[i])
+		}
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	os.Setenv("TOFU_DIFF_SAME", "same")
+	os.Setenv("TOFU_DIFF_CHANGED", "secret-new")
+	os.Setenv("TOFU_DIFF_ADDED", "added")
+	defer os.Unsetenv("TOFU_DIFF_SAME")
+	defer os.Unsetenv("TOFU_DIFF_CHANGED")
+	defer os.Unsetenv("TOFU_DIFF_ADDED")
+
+	file := filepath.Join(t.TempDir(), ".env")
+	content := "TOFU_DIFF_SAME=same\nTOFU_DIFF_CHANGED=secret-old\nTOFU_DIFF_REMOVED=gone\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runDiff(&DiffParams{Files: []string{file}, Match: "^TOFU_DIFF_"}, &out); err != nil {
+		t.Fatalf("runDiff failed: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"+ TOFU_DIFF_ADDED\n", "- TOFU_DIFF_REMOVED\n", "~ TOFU_DIFF_CHANGED\n", "1 added, 1 removed, 1 changed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got %q", want, got)
+		}
+	}
+	if strings.Contains(got, "secret") || strings.Contains(got, "TOFU_DIFF_SAME") {
+		t.Errorf("expected values redacted and unchanged keys hidden, got %q", got)
+	}
+
+	out.Reset()
+	if err := runDiff(&DiffParams{Files: []string{file}, Match: "^TOFU_DIFF_", ShowValues: true}, &out); err != nil {
+		t.Fatalf("runDiff failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `~ TOFU_DIFF_CHANGED: "secret-old" -> "secret-new"`) {
+		t.Errorf("expected values shown, got %q", out.String())
+	}
+}
+
+func TestOverrideEnv(t *testing.T) {
+	base := []string{"KEEP=1", "DROP=2", "REPLACE=old", "EMPTY="}
+	env, err := overrideEnv(base, []string{"DROP", "MISSING"}, []string{"REPLACE=new", "ADDED=a=b", "ADDED=c"})
+	if err != nil {
+		t.Fatalf("overrideEnv failed: %v", err)
+	}
+	expected := []string{"KEEP=1", "EMPTY=", "REPLACE=new", "ADDED=c"}
+	if strings.Join(env, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %q, got %q", expected, env)
+	}
+
+	for _, tc := range []struct{ unset, set []string }{
+		{set: []string{"NOEQUALS"}},
+		{set: []string{"=value"}},
+		{unset: []string{""}},
+		{unset: []string{"A=B"}},
+	} {
+		if _, err := overrideEnv(base, tc.unset, tc.set); err == nil {
+			t.Errorf("Expected error for unset %q set %q", tc.unset, tc.set)
+		}
+	}
+}
+
+// TestEnvRunHelper is not a real test. It's the child process for
+// TestRunCommand, printing the environment it was started with.
+func TestEnvRunHelper(t *testing.T) {
+	if os.Getenv("TOFU_ENV_RUN_HELPER") != "1" {
+		return
+	}
+	for _, kv := range os.Environ() {
+		fmt.Println(kv)
+	}
+	os.Exit(0)
+}
+
+func TestRunCommand(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip("Could not find executable path, skipping test")
+	}
+	t.Setenv("TOFU_ENV_RUN_HELPER", "1")
+	t.Setenv("TOFU_RUN_UNSET_ME", "present")
+	t.Setenv("TOFU_RUN_OVERRIDE", "old")
+
+	var stdout bytes.Buffer
+	params := &RunParams{
+		Command: []string{exe, "-test.run=^TestEnvRunHelper$"},
+		Unset:   []string{"TOFU_RUN_UNSET_ME"},
+		Set:     []string{"TOFU_RUN_OVERRIDE=new", "TOFU_RUN_ADDED=added value"},
+	}
+	if err := runCommand(params, nil, &stdout, os.Stderr); err != nil {
+		t.Fatalf("runCommand failed: %v", err)
+	}
+
+	childEnv := strings.Split(stdout.String(), "\n")
+	has := func(kv string) bool { return slices.Contains(childEnv, kv) }
+	if !has("TOFU_RUN_OVERRIDE=new") || has("TOFU_RUN_OVERRIDE=old") {
+		t.Errorf("Expected TOFU_RUN_OVERRIDE=new in child env, got:\n%s", stdout.String())
+	}
+	if !has("TOFU_RUN_ADDED=added value") {
+		t.Errorf("Expected TOFU_RUN_ADDED in child env, got:\n%s", stdout.String())
+	}
+	if strings.Contains(stdout.String(), "TOFU_RUN_UNSET_ME") {
+		t.Errorf("Expected TOFU_RUN_UNSET_ME absent from child env, got:\n%s", stdout.String())
+	}
+
+	// The parent environment is untouched
+	if os.Getenv("TOFU_RUN_UNSET_ME") != "present" || os.Getenv("TOFU_RUN_OVERRIDE") != "old" {
+		t.Error("Expected the parent environment to be unchanged")
+	}
+
+	if err := runCommand(&RunParams{}, nil, &stdout, os.Stderr); err == nil {
+		t.Error("Expected error without a command")
+	}
+}
+
+func TestParseDotenvExpansion(t *testing.T) {
+	input := `HOST=localhost
+URL=http://${HOST}:${PORT}/x
+QUOTED="${HOST} \${HOST}"
+SINGLE='${HOST}'
+MISSING=a${NOPE}b
+DOLLAR=cost $5
+`
+	lookup := func(key string) (string, bool) {
+		if key == "PORT" {
+			return "8080", true
+		}
+		return "", false
+	}
+	parsed, err := parseDotenv(strings.NewReader(input), lookup)
+	if err != nil {
+		t.Fatalf("parseDotenv failed: %v", err)
+	}
+	expected := map[string]string{
+		"URL":     "http://localhost:8080/x",
+		"QUOTED":  "localhost ${HOST}",
+		"SINGLE":  "${HOST}",
+		"MISSING": "ab",
+		"DOLLAR":  "cost $5",
+	}
+	for k, v := range expected {
+		if parsed.Values[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, parsed.Values[k])
+		}
+	}
+
+	for _, bad := range []string{"A=${B\n", "A=\"${B-C}\"\n"} {
+		if _, err := parseDotenv(strings.NewReader(bad), nil); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestLoadDotenvFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	if err := os.WriteFile(base, []byte("NAME=app\nPORT=80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(local, []byte("PORT=8080\nADDR=${NAME}:${PORT} in ${TOFU_LOAD_TEST}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TOFU_LOAD_TEST", "test")
+
+	file, err := loadDotenvFiles([]string{base, local})
+	if err != nil {
+		t.Fatalf("loadDotenvFiles failed: %v", err)
+	}
+	expected := []string{"NAME=app", "PORT=8080", "ADDR=app:8080 in test"}
+	if strings.Join(file.entries(), "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected %q, got %q", expected, file.entries())
+	}
+
+	if _, err := loadDotenvFiles([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
+
+func TestListEnvFileExport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(file, []byte("B=it's\nA=${B} here\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadDotenvFiles([]string{file})
+	if err != nil {
+		t.Fatalf("loadDotenvFiles failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	params := &Params{Format: "shell", Sort: true}
+	if err := listEntriesTo(params, loaded.entries(), &out); err != nil {
+		t.Fatalf("listEntriesTo failed: %v", err)
+	}
+	expected := "export A='it'\\''s here'\nexport B='it'\\''s'\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestRunCommandWithFile(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip("Could not find executable path, skipping test")
+	}
+	t.Setenv("TOFU_ENV_RUN_HELPER", "1")
+	file := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(file, []byte("TOFU_FILE_A=from file\nTOFU_FILE_B=${TOFU_FILE_A}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	params := &RunParams{
+		Command: []string{exe, "-test.run=^TestEnvRunHelper$"},
+		File:    []string{file},
+		Set:     []string{"TOFU_FILE_A=overridden"},
+	}
+	if err := runCommand(params, nil, &stdout, os.Stderr); err != nil {
+		t.Fatalf("runCommand failed: %v", err)
+	}
+	childEnv := strings.Split(stdout.String(), "\n")
+	if !slices.Contains(childEnv, "TOFU_FILE_A=overridden") || !slices.Contains(childEnv, "TOFU_FILE_B=from file") {
+		t.Errorf("Expected the file's variables, with --set winning, got:\n%s", stdout.String())
+	}
+}
+
+func TestSaveAndDiffSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	before := filepath.Join(dir, "before.env")
+	after := filepath.Join(dir, "after.env")
+
+	t.Setenv("TOFU_SNAP_SAME", "same")
+	t.Setenv("TOFU_SNAP_CHANGED", "it's $old")
+	t.Setenv("TOFU_SNAP_API_TOKEN", "hunter2")
+	t.Setenv("TOFU_SNAP_REMOVED", "gone")
+	var out bytes.Buffer
+	if err := runSave(&SaveParams{File: before, Match: "^TOFU_SNAP_"}, &out, &out); err != nil {
+		t.Fatalf("runSave failed: %v", err)
+	}
+	if out.String() != "Saved 4 variables to "+before+"\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+	if info, err := os.Stat(before); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0600) {
+		t.Errorf("Expected a file only readable by the owner, got %v, %v", info, err)
+	}
+
+	t.Setenv("TOFU_SNAP_CHANGED", "new")
+	t.Setenv("TOFU_SNAP_API_TOKEN", "hunter3")
+	os.Unsetenv("TOFU_SNAP_REMOVED")
+	t.Setenv("TOFU_SNAP_ADDED", "added")
+	if err := runSave(&SaveParams{File: after, Match: "^TOFU_SNAP_"}, &out, &out); err != nil {
+		t.Fatalf("runSave failed: %v", err)
+	}
+
+	// Two snapshots compare with each other, as does one with the environment
+	for _, files := range [][]string{{before, after}, {before}} {
+		out.Reset()
+		params := &DiffParams{Files: files, Match: "^TOFU_SNAP_", ShowValues: true, SecretPattern: "(?i)token"}
+		if err := runDiff(params, &out); err != nil {
+			t.Fatalf("runDiff failed: %v", err)
+		}
+		expected := `+ TOFU_SNAP_ADDED=added
+~ TOFU_SNAP_API_TOKEN: "****" -> "****"
+~ TOFU_SNAP_CHANGED: "it's $old" -> "new"
+- TOFU_SNAP_REMOVED=gone
+
+1 added, 1 removed, 2 changed
+`
+		if out.String() != expected {
+			t.Errorf("%d files: expected:\n%s\ngot:\n%s", len(files), expected, out.String())
+		}
+	}
+
+	if err := runDiff(&DiffParams{Files: []string{before, after, after}}, &out); err == nil {
+		t.Error("Expected error for three files")
+	}
+	if err := runDiff(&DiffParams{Files: []string{before}, SecretPattern: "("}, &out); err == nil {
+		t.Error("Expected error for an invalid --secret-pattern")
+	}
+}
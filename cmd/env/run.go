@@ -0,0 +1,137 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type RunParams struct {
+	Command []string `pos:"true" help:"Command to run, after --."`
+	Set     []string `short:"s" help:"Set a variable for the command, as KEY=VALUE (repeatable)." optional:"true"`
+	Unset   []string `short:"u" help:"Remove a variable from the command's environment (repeatable)." optional:"true"`
+	File    []string `help:"Load variables from a dotenv file before --unset and --set, expanding ${VAR} references (repeatable)." optional:"true"`
+}
+
+func runCmd() *cobra.Command {
+	return boa.CmdT[RunParams]{
+		Use:   "run [flags] -- <command> [args...]",
+		Short: "Run a command with variables set or unset",
+		Long: `Run a command with the current environment minus the --unset variables plus the
+--set ones, like 'env -u NAME NAME2=value command' but on every platform.
+Variables from --file dotenv files are added first. The current shell is not
+affected. The command's exit code is passed through.
+
+Examples:
+  tofu env run --unset HTTP_PROXY --unset HTTPS_PROXY -- curl https://example.com
+  tofu env run --set NODE_ENV=production --set PORT=8080 -- npm start
+  tofu env run --file .env --file .env.local -- npm start`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *RunParams, cmd *cobra.Command, args []string) {
+			if err := runCommand(params, os.Stdin, os.Stdout, os.Stderr); err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					os.Exit(exitErr.ExitCode())
+				}
+				fmt.Fprintf(os.Stderr, "env run: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func runCommand(params *RunParams, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(params.Command) == 0 {
+		return fmt.Errorf("no command specified")
+	}
+
+	base := os.Environ()
+	if len(params.File) > 0 {
+		file, err := loadDotenvFiles(params.File)
+		if err != nil {
+			return err
+		}
+		if base, err = overrideEnv(base, nil, file.entries()); err != nil {
+			return err
+		}
+	}
+
+	env, err := overrideEnv(base, params.Unset, params.Set)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(params.Command[0], params.Command[1:]...)
+	cmd.Env = env
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// overrideEnv returns base without the unset keys and with the set
+// KEY=VALUE entries, which replace existing values. Keys are case
+// insensitive on Windows, like the environment itself.
+func overrideEnv(base []string, unset []string, set []string) ([]string, error) {
+	type entry struct{ key, value string }
+	var sets []entry
+	for _, s := range set {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid format for --set %q, expected KEY=VALUE", s)
+		}
+		sets = append(sets, entry{key, value})
+	}
+	for _, key := range unset {
+		if key == "" || strings.Contains(key, "=") {
+			return nil, fmt.Errorf("invalid variable name for --unset %q", key)
+		}
+	}
+
+	removed := func(key string) bool {
+		for _, k := range unset {
+			if sameEnvKey(k, key) {
+				return true
+			}
+		}
+		for _, e := range sets {
+			if sameEnvKey(e.key, key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	env := make([]string, 0, len(base)+len(sets))
+	for _, kv := range base {
+		if key, _ := splitEnvEntry(kv); !removed(key) {
+			env = append(env, kv)
+		}
+	}
+	for i, e := range sets {
+		// A later --set of the same key wins
+		overridden := false
+		for _, later := range sets[i+1:] {
+			overridden = overridden || sameEnvKey(later.key, e.key)
+		}
+		if !overridden {
+			env = append(env, e.key+"="+e.value)
+		}
+	}
+	return env, nil
+}
+
+func sameEnvKey(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
@@ -0,0 +1,66 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type SaveParams struct {
+	File  string `pos:"true" help:"File to save the snapshot to."`
+	Match string `short:"m" help:"Only save variables whose key name matches this regular expression." optional:"true"`
+}
+
+func saveCmd() *cobra.Command {
+	return boa.CmdT[SaveParams]{
+		Use:   "save <file>",
+		Short: "Save the current environment as a snapshot for env diff",
+		Long: `Save the current environment to a dotenv file, to compare against later with
+'tofu env diff', e.g. from another shell or a CI job. The file is only readable
+by you, as it may contain secrets.`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *SaveParams, cmd *cobra.Command, args []string) {
+			if err := runSave(params, os.Stdout, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "env save: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func runSave(params *SaveParams, stdout, stderr io.Writer) error {
+	match, err := compileMatch(params.Match)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	count := 0
+	entries := os.Environ()
+	sort.Strings(entries)
+	for _, entry := range entries {
+		key, value := splitEnvEntry(entry)
+		if match != nil && !match.MatchString(key) {
+			continue
+		}
+		// Such as Windows' hidden per-drive variables "=C:"
+		if !dotenvKeyPattern.MatchString(key) {
+			fmt.Fprintf(stderr, "env save: skipping %q, not a valid dotenv key\n", key)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s=%s\n", key, dotenvQuote(value))
+		count++
+	}
+
+	if err := os.WriteFile(params.File, []byte(sb.String()), 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Saved %d variables to %s\n", count, params.File)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -38,6 +39,8 @@ type Params struct {
 	WorkDir    string       `short:"c" help:"The working directory to start the search from." default:"."`
 	Types      []FsItemType `short:"t" help:"Types of file system items to search for (file,dir,all)." default:"all" alts:"file,dir,all"`
 	Quiet      bool         `short:"q" help:"Suppress error messages." default:"false"`
+	Path       string       `optional:"true" help:"Only list items whose whole path (as printed) matches this glob pattern, e.g. '*/build/*'."`
+	IPath      string       `name:"ipath" optional:"true" help:"Like --path, but case-insensitive."`
 }
 
 func Cmd() *cobra.Command {
@@ -52,6 +55,11 @@ func Cmd() *cobra.Command {
 			if !ExistsAccessibleDir(params.WorkDir) {
 				return fmt.Errorf("working directory does not exist or is not accessible: %s", params.WorkDir)
 			}
+			for _, pattern := range []string{params.Path, params.IPath} {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+				}
+			}
 			return nil
 		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
@@ -121,6 +129,12 @@ func Run(params *Params, stdout, stderr io.Writer) {
 					panic(fmt.Errorf("unsupported search type: %s", params.SearchType))
 				}
 			}
+			if params.Path != "" && !MatchPath(path, params.Path, false) {
+				return nil
+			}
+			if params.IPath != "" && !MatchPath(path, params.IPath, true) {
+				return nil
+			}
 			fmt.Fprintln(stdout, path)
 		}
 		return nil
@@ -167,6 +181,18 @@ func MatchSuffix(tot, suffix string, ignoreCase bool) bool {
 	return strings.HasSuffix(tot, suffix)
 }
 
+// MatchPath reports whether the whole path matches the glob pattern, using
+// path.Match semantics with '/' as separator on all platforms.
+func MatchPath(filePath, pattern string, ignoreCase bool) bool {
+	filePath = filepath.ToSlash(filePath)
+	if ignoreCase {
+		filePath = strings.ToLower(filePath)
+		pattern = strings.ToLower(pattern)
+	}
+	matched, err := path.Match(pattern, filePath)
+	return err == nil && matched
+}
+
 func ExistsAccessibleDir(path string) bool {
 	st, err := os.Stat(path)
 	if err != nil {
@@ -528,3 +528,75 @@ func TestExistsAccessibleDir(t *testing.T) {
 		t.Errorf("Expected ExistsAccessibleDir to return false for file")
 	}
 }
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		pattern    string
+		ignoreCase bool
+		expected   bool
+	}{
+		{"nested match", "src/build/out.o", "*/build/*", false, true},
+		{"base name only does not match", "src/build/out.o", "out.o", false, false},
+		{"star does not cross separators", "a/src/build/out.o", "*/build/*", false, false},
+		{"case sensitive mismatch", "src/Build/out.o", "*/build/*", false, false},
+		{"case insensitive match", "src/Build/OUT.o", "*/build/out.*", true, true},
+		{"character class", "logs/app1.log", "logs/app[0-9].log", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MatchPath(tt.path, tt.pattern, tt.ignoreCase)
+			if result != tt.expected {
+				t.Errorf("MatchPath(%q, %q, %v) = %v, expected %v", tt.path, tt.pattern, tt.ignoreCase, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunFind_PathGlob(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+
+	for _, dir := range []string{"src/build", "src/lib", "docs/Build"} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	for _, file := range []string{"src/build/out.o", "src/lib/build.go", "docs/Build/index.html"} {
+		if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	params := &Params{
+		WorkDir: ".",
+		Types:   []FsItemType{FsItemTypeFile},
+		Path:    "*/build/*",
+	}
+
+	var stdout, stderr bytes.Buffer
+	Run(params, &stdout, &stderr)
+
+	expected := filepath.Join("src", "build", "out.o") + "\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+
+	params.Path = ""
+	params.IPath = "*/build/*"
+	stdout.Reset()
+	Run(params, &stdout, &stderr)
+
+	output := stdout.String()
+	if !strings.Contains(output, filepath.Join("docs", "Build", "index.html")) {
+		t.Errorf("Expected case-insensitive match of docs/Build/index.html, got %q", output)
+	}
+	if !strings.Contains(output, filepath.Join("src", "build", "out.o")) {
+		t.Errorf("Expected match of src/build/out.o, got %q", output)
+	}
+	if strings.Contains(output, "build.go") {
+		t.Errorf("Did not expect src/lib/build.go to match, got %q", output)
+	}
+}
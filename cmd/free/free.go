@@ -1,18 +1,27 @@
 package free
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
 	"github.com/shirou/gopsutil/v4/mem"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 type Params struct {
-	MegaBytes bool `short:"m" help:"Display output in megabytes."`
-	GigaBytes bool `short:"g" help:"Display output in gigabytes."`
+	MegaBytes bool    `short:"m" help:"Display output in megabytes."`
+	GigaBytes bool    `short:"g" help:"Display output in gigabytes."`
+	Json      bool    `short:"j" help:"Output in JSON format, with sizes in bytes."`
+	Watch     float64 `short:"w" optional:"true" help:"Resample every N seconds until stopped, as newline-delimited JSON with --json when not on a terminal."`
 }
 
 func Cmd() *cobra.Command {
@@ -20,10 +29,16 @@ func Cmd() *cobra.Command {
 		Use:   "free",
 		Short: "Display amount of free and used memory in the system",
 		Long: `Display the total, used, and free amount of physical and swap memory in the system.
-By default, the output is in kilobytes. Use -m for megabytes or -g for gigabytes.`,
+By default, the output is in kilobytes. Use -m for megabytes or -g for gigabytes.
+
+With -w SECONDS, memory is resampled at that interval until stopped with
+Ctrl+C. On a terminal the screen is cleared between samples; when piped, every
+sample is kept, with --json as one JSON object per line.`,
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			if err := runFree(params); err != nil {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err := runFree(ctx, params, os.Stdout, term.IsTerminal(int(os.Stdout.Fd()))); err != nil {
 				fmt.Fprintf(os.Stderr, "free: %v\n", err)
 				os.Exit(1)
 			}
@@ -31,7 +46,60 @@ By default, the output is in kilobytes. Use -m for megabytes or -g for gigabytes
 	}.ToCobra()
 }
 
-func runFree(params *Params) error {
+// memoryJSON is the JSON output, with all sizes in bytes. Only the fields
+// that all platforms have are included.
+type memoryJSON struct {
+	Mem struct {
+		Total     uint64 `json:"total"`
+		Used      uint64 `json:"used"`
+		Free      uint64 `json:"free"`
+		Shared    uint64 `json:"shared"`
+		BuffCache uint64 `json:"buff_cache"`
+		Available uint64 `json:"available"`
+	} `json:"mem"`
+	Swap struct {
+		Total uint64 `json:"total"`
+		Used  uint64 `json:"used"`
+		Free  uint64 `json:"free"`
+	} `json:"swap"`
+}
+
+func newMemoryJSON(virtualMem *mem.VirtualMemoryStat, swapMem *mem.SwapMemoryStat) memoryJSON {
+	var m memoryJSON
+	m.Mem.Total = virtualMem.Total
+	m.Mem.Used = virtualMem.Used
+	m.Mem.Free = virtualMem.Free
+	m.Mem.Shared = virtualMem.Shared
+	m.Mem.BuffCache = virtualMem.Buffers + virtualMem.Cached
+	m.Mem.Available = virtualMem.Available
+	m.Swap.Total = swapMem.Total
+	m.Swap.Used = swapMem.Used
+	m.Swap.Free = swapMem.Free
+	return m
+}
+
+// runFree prints memory usage once, or every --watch seconds until ctx is
+// done. clear is set when stdout is a terminal.
+func runFree(ctx context.Context, params *Params, stdout io.Writer, clear bool) error {
+	if params.Watch < 0 {
+		return fmt.Errorf("--watch must be positive")
+	}
+	if params.Watch == 0 {
+		return printFree(stdout, params, true)
+	}
+	between := "\n"
+	if params.Json {
+		between = ""
+	}
+	interval := time.Duration(params.Watch * float64(time.Second))
+	return common.Resample(ctx, interval, stdout, clear, between, func(w io.Writer) error {
+		// Piped samples are one JSON object per line
+		return printFree(w, params, clear)
+	})
+}
+
+// printFree prints one sample of memory usage. JSON is indented when pretty.
+func printFree(w io.Writer, params *Params, pretty bool) error {
 	virtualMem, err := mem.VirtualMemory()
 	if err != nil {
 		return fmt.Errorf("failed to get virtual memory info: %w", err)
@@ -42,6 +110,14 @@ func runFree(params *Params) error {
 		return fmt.Errorf("failed to get swap memory info: %w", err)
 	}
 
+	if params.Json {
+		enc := json.NewEncoder(w)
+		if pretty {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(newMemoryJSON(virtualMem, swapMem))
+	}
+
 	unitFactor := float64(1)
 	unitLabel := ""
 
@@ -56,7 +132,7 @@ func runFree(params *Params) error {
 		unitLabel = "KiB"
 	}
 
-	printMemoryInfo(os.Stdout, virtualMem, swapMem, unitFactor, unitLabel)
+	printMemoryInfo(w, virtualMem, swapMem, unitFactor, unitLabel)
 
 	return nil
 }
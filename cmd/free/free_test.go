@@ -2,11 +2,14 @@ package free
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
 	"os"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shirou/gopsutil/v4/mem"
 )
@@ -67,7 +70,7 @@ func TestFreeCommand(t *testing.T) {
 			r, w, _ := os.Pipe()
 			os.Stdout = w
 
-			err := runFree(tc.params)
+			err := runFree(context.Background(), tc.params, os.Stdout, false)
 			w.Close()
 			os.Stdout = oldStdout // Restore original Stdout
 			io.Copy(&stdoutBuf, r)
@@ -116,3 +119,51 @@ func getDummySwapMemoryStat() *mem.SwapMemoryStat {
 // without changing the cmd_free.go implementation to accept an interface.
 // For this test, we are relying on gopsutil to work correctly and
 // checking output formatting, assuming it returns valid data.
+
+func TestNewMemoryJSON(t *testing.T) {
+	out, err := json.Marshal(newMemoryJSON(getDummyVirtualMemoryStat(), getDummySwapMemoryStat()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `{"mem":{"total":17179869184,"used":8589934592,"free":4294967296,"shared":1073741824,"buff_cache":1610612736,"available":6442450944},` +
+		`"swap":{"total":4294967296,"used":2147483648,"free":2147483648}}`
+	if string(out) != want {
+		t.Errorf("Expected %s, got %s", want, out)
+	}
+}
+
+func TestRunFree_WatchJSON(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	if err := runFree(ctx, &Params{Json: true, Watch: 0.1}, &out, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("Expected several samples, got %q", out.String())
+	}
+	for _, line := range lines {
+		var m memoryJSON
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Errorf("Expected a JSON object per line, got %q: %v", line, err)
+		} else if m.Mem.Total == 0 {
+			t.Errorf("Expected a total, got %q", line)
+		}
+	}
+}
+
+func TestRunFree_WatchClearsTerminal(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	if err := runFree(ctx, &Params{Watch: 0.1}, &out, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "\033[H\033[2J") {
+		t.Errorf("Expected the screen to be cleared first, got %q", out.String())
+	}
+	if err := runFree(ctx, &Params{Watch: -1}, &out, true); err == nil || err.Error() != "--watch must be positive" {
+		t.Errorf("Expected a --watch error, got %v", err)
+	}
+}
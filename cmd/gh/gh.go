@@ -4,6 +4,7 @@ import (
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/gh/listrepos"
 	"github.com/gigurra/tofu/cmd/gh/open"
+	"github.com/gigurra/tofu/cmd/gh/prs"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +15,7 @@ func Cmd() *cobra.Command {
 		SubCmds: []*cobra.Command{
 			listrepos.Cmd(),
 			open.Cmd(),
+			prs.Cmd(),
 		},
 	}.ToCobra()
 }
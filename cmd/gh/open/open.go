@@ -44,7 +44,7 @@ func run(params *Params) error {
 
 	// Check if it's a full URL (with scheme)
 	if isURL(target) {
-		return openBrowser(target)
+		return OpenBrowser(target)
 	}
 
 	// Check if it's a local path that exists
@@ -59,13 +59,13 @@ func run(params *Params) error {
 			if err != nil {
 				return err
 			}
-			return openBrowser(url)
+			return OpenBrowser(url)
 		}
 	}
 
 	// Path doesn't exist - check if it looks like a repo URL without scheme
 	if looksLikeRepoURL(target) {
-		return openBrowser("https://" + target)
+		return OpenBrowser("https://" + target)
 	}
 
 	return fmt.Errorf("path not found and not a valid URL: %s", target)
@@ -137,7 +137,8 @@ func gitRemoteToHTTPS(remoteURL string) string {
 	return remoteURL
 }
 
-func openBrowser(url string) error {
+// OpenBrowser opens url in the default web browser without waiting for it.
+func OpenBrowser(url string) error {
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
@@ -0,0 +1,385 @@
+package prs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/GiGurra/cmder"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/gigurra/tofu/cmd/gh/open"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+type Params struct {
+	Owner string `short:"o" optional:"true" help:"GitHub organization or user whose repositories to show PRs for (default: your own repositories)"`
+}
+
+func Cmd() *cobra.Command {
+	return boa.CmdT[Params]{
+		Use:         "prs",
+		Short:       "Dashboard of open pull requests",
+		Long:        "Show open pull requests across your repositories, or those of an organization, with review and CI status. Sort with s/r, filter with /, press enter to open a PR in the browser, a to approve and c to comment. Prints the table once when stdin is not a terminal. Requires gh CLI to be installed and authenticated (GITHUB_TOKEN works too).",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
+			if err := run(params, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+type pullRequest struct {
+	Repo      string
+	Number    int
+	Title     string
+	Author    string
+	URL       string
+	CreatedAt time.Time
+	Draft     bool
+	Review    string // APPROVED, CHANGES_REQUESTED, REVIEW_REQUIRED or empty
+	CI        string // SUCCESS, FAILURE, ERROR, PENDING, EXPECTED or empty
+}
+
+// ref is the short form used by GitHub, owner/repo#123.
+func (pr pullRequest) ref() string {
+	return pr.Repo + "#" + strconv.Itoa(pr.Number)
+}
+
+func (pr pullRequest) reviewStatus() string {
+	if pr.Draft {
+		return "draft"
+	}
+	switch pr.Review {
+	case "APPROVED":
+		return "approved"
+	case "CHANGES_REQUESTED":
+		return "changes requested"
+	case "REVIEW_REQUIRED":
+		return "review required"
+	default:
+		return "-"
+	}
+}
+
+func (pr pullRequest) ciStatus() string {
+	switch pr.CI {
+	case "SUCCESS":
+		return "passing"
+	case "FAILURE", "ERROR":
+		return "failing"
+	case "PENDING", "EXPECTED":
+		return "pending"
+	default:
+		return "-"
+	}
+}
+
+// searchQuery pages through open PRs with the search API, which covers all
+// repositories of a user or organization in one query. gh api --paginate
+// fills in $endCursor.
+const searchQuery = `query($q: String!, $endCursor: String) {
+  search(query: $q, type: ISSUE, first: 100, after: $endCursor) {
+    pageInfo { hasNextPage endCursor }
+    nodes {
+      ... on PullRequest {
+        number
+        title
+        url
+        createdAt
+        isDraft
+        reviewDecision
+        author { login }
+        repository { nameWithOwner }
+        commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+      }
+    }
+  }
+}`
+
+// searchPage is one page of the searchQuery response.
+type searchPage struct {
+	Data struct {
+		Search struct {
+			Nodes []struct {
+				Number         int       `json:"number"`
+				Title          string    `json:"title"`
+				URL            string    `json:"url"`
+				CreatedAt      time.Time `json:"createdAt"`
+				IsDraft        bool      `json:"isDraft"`
+				ReviewDecision string    `json:"reviewDecision"`
+				Author         *struct {
+					Login string `json:"login"`
+				} `json:"author"`
+				Repository struct {
+					NameWithOwner string `json:"nameWithOwner"`
+				} `json:"repository"`
+				Commits struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup *struct {
+								State string `json:"state"`
+							} `json:"statusCheckRollup"`
+						} `json:"commit"`
+					} `json:"nodes"`
+				} `json:"commits"`
+			} `json:"nodes"`
+		} `json:"search"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// parseSearchPages parses the output of gh api graphql --paginate, which is
+// one JSON document per page.
+func parseSearchPages(data []byte) ([]pullRequest, error) {
+	var prs []pullRequest
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var page searchPage
+		if err := dec.Decode(&page); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse pull requests: %w", err)
+		}
+		if len(page.Errors) > 0 {
+			return nil, fmt.Errorf("GitHub API error: %s", page.Errors[0].Message)
+		}
+
+		for _, n := range page.Data.Search.Nodes {
+			// Search results that aren't pull requests come back empty
+			if n.Number == 0 {
+				continue
+			}
+			pr := pullRequest{
+				Repo:      n.Repository.NameWithOwner,
+				Number:    n.Number,
+				Title:     n.Title,
+				URL:       n.URL,
+				CreatedAt: n.CreatedAt,
+				Draft:     n.IsDraft,
+				Review:    n.ReviewDecision,
+				Author:    "ghost", // deleted users
+			}
+			if n.Author != nil {
+				pr.Author = n.Author.Login
+			}
+			if len(n.Commits.Nodes) > 0 && n.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
+				pr.CI = n.Commits.Nodes[0].Commit.StatusCheckRollup.State
+			}
+			prs = append(prs, pr)
+		}
+	}
+	return prs, nil
+}
+
+func fetchPRs(ctx context.Context, owner string) ([]pullRequest, error) {
+	result := cmder.New("gh", "api", "graphql", "--paginate",
+		"-f", "query="+searchQuery,
+		"-f", "q=is:pr is:open archived:false user:"+owner).
+		WithAttemptTimeout(60 * time.Second).
+		Run(ctx)
+	if result.Err != nil {
+		msg := strings.TrimSpace(result.Combined)
+		if msg == "" {
+			msg = result.Err.Error()
+		}
+		return nil, fmt.Errorf("failed to fetch pull requests: %s", msg)
+	}
+	return parseSearchPages([]byte(result.StdOut))
+}
+
+func currentUser(ctx context.Context) (string, error) {
+	result := cmder.New("gh", "api", "user", "--jq", ".login").
+		WithAttemptTimeout(10 * time.Second).
+		Run(ctx)
+	login := strings.TrimSpace(result.StdOut)
+	if result.Err != nil || login == "" {
+		return "", errors.New("failed to get the current GitHub user, is gh authenticated? (gh auth login)")
+	}
+	return login, nil
+}
+
+func approve(ctx context.Context, pr pullRequest) error {
+	return ghPR(ctx, "review", pr, "--approve")
+}
+
+func comment(ctx context.Context, pr pullRequest, body string) error {
+	return ghPR(ctx, "comment", pr, "--body", body)
+}
+
+func ghPR(ctx context.Context, subcommand string, pr pullRequest, args ...string) error {
+	cmdArgs := append([]string{"gh", "pr", subcommand, strconv.Itoa(pr.Number), "-R", pr.Repo}, args...)
+	result := cmder.New(cmdArgs...).
+		WithAttemptTimeout(30 * time.Second).
+		Run(ctx)
+	if result.Err != nil {
+		if msg := strings.TrimSpace(result.Combined); msg != "" {
+			return errors.New(msg)
+		}
+		return result.Err
+	}
+	return nil
+}
+
+func run(params *Params, stdout io.Writer) error {
+	if err := checkGh(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	owner := params.Owner
+	if owner == "" {
+		var err error
+		if owner, err = currentUser(ctx); err != nil {
+			return err
+		}
+	}
+	v := &view{scope: owner}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		prs, err := fetchPRs(ctx, owner)
+		if err != nil {
+			return err
+		}
+		v.setPRs(prs, nil, time.Now())
+		_, err = io.WriteString(stdout, v.render(0, false, time.Now()))
+		return err
+	}
+
+	return runInteractive(ctx, v, owner, stdout)
+}
+
+// runInteractive shows the view until q or ctrl+c, refreshing it on demand.
+// bubbletea and lipgloss are not dependencies of tofu, so the terminal is
+// driven directly, with keys and tables handled by cmd/common/tui.go.
+func runInteractive(ctx context.Context, v *view, owner string, stdout io.Writer) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+
+	// Alternate screen, hidden cursor
+	fmt.Fprint(stdout, "\033[?1049h\033[?25l")
+	defer fmt.Fprint(stdout, "\033[?25h\033[?1049l")
+
+	keyCh := make(chan []common.Key)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			if n > 0 {
+				keyCh <- common.DecodeKeys(buf[:n])
+			}
+		}
+	}()
+
+	// Load in the background so keys stay responsive, it can take a while
+	// for owners with many repositories
+	type loadResult struct {
+		prs []pullRequest
+		err error
+	}
+	resultCh := make(chan loadResult, 1)
+	load := func() {
+		go func() {
+			prs, err := fetchPRs(ctx, owner)
+			select {
+			case resultCh <- loadResult{prs, err}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+	v.loading = true
+	load()
+
+	draw := func() {
+		_, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			height = 24
+		}
+		screen := v.render(height, true, time.Now())
+		screen = strings.ReplaceAll(screen, "\n", "\033[K\r\n")
+		// Let the terminal cut long lines instead of wrapping them
+		fmt.Fprint(stdout, "\033[?7l\033[H"+screen+"\033[J\033[?7h")
+	}
+
+	for {
+		draw()
+
+		select {
+		case <-sigCh:
+			return nil
+		case res := <-resultCh:
+			v.setPRs(res.prs, res.err, time.Now())
+		case keys := <-keyCh:
+			for _, k := range keys {
+				act := v.handleKey(k)
+				pr, _ := v.selectedPR()
+				switch act {
+				case actionQuit:
+					return nil
+				case actionOpen:
+					if err := open.OpenBrowser(pr.URL); err != nil {
+						v.status = "Failed to open browser: " + err.Error()
+					}
+				case actionApprove:
+					v.status = "Approving " + pr.ref() + "..."
+					draw()
+					if err := approve(ctx, pr); err != nil {
+						v.status = "Failed to approve " + pr.ref() + ": " + err.Error()
+					} else {
+						v.status = "Approved " + pr.ref()
+					}
+				case actionComment:
+					v.status = "Commenting on " + pr.ref() + "..."
+					draw()
+					if err := comment(ctx, pr, v.input); err != nil {
+						v.status = "Failed to comment on " + pr.ref() + ": " + err.Error()
+					} else {
+						v.status = "Commented on " + pr.ref()
+					}
+					v.input = ""
+				case actionReload:
+					load()
+				}
+			}
+		}
+	}
+}
+
+func checkGh() error {
+	result := cmder.New("gh", "version").
+		WithAttemptTimeout(5 * time.Second).
+		Run(context.Background())
+	if result.Err != nil {
+		if result.Combined != "" {
+			return fmt.Errorf("gh CLI not found or not working: %w\n%s", result.Err, result.Combined)
+		}
+		return fmt.Errorf("gh CLI not found or not working: %w", result.Err)
+	}
+	return nil
+}
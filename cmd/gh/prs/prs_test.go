@@ -0,0 +1,166 @@
+package prs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+// Two pages, as printed by gh api graphql --paginate
+const samplePages = `{"data":{"search":{"pageInfo":{"hasNextPage":true,"endCursor":"Y3Vyc29yOjE="},"nodes":[
+  {"number":12,"title":"Add retries","url":"https://github.com/acme/api/pull/12","createdAt":"2026-10-14T10:00:00Z",
+   "isDraft":false,"reviewDecision":"APPROVED","author":{"login":"alice"},"repository":{"nameWithOwner":"acme/api"},
+   "commits":{"nodes":[{"commit":{"statusCheckRollup":{"state":"SUCCESS"}}}]}},
+  {}
+]}}}
+{"data":{"search":{"pageInfo":{"hasNextPage":false,"endCursor":"Y3Vyc29yOjI="},"nodes":[
+  {"number":3,"title":"WIP: new landing page","url":"https://github.com/acme/web/pull/3","createdAt":"2026-10-16T09:30:00Z",
+   "isDraft":true,"reviewDecision":null,"author":null,"repository":{"nameWithOwner":"acme/web"},
+   "commits":{"nodes":[{"commit":{"statusCheckRollup":null}}]}},
+  {"number":7,"title":"Fix login redirect","url":"https://github.com/acme/web/pull/7","createdAt":"2026-10-01T08:00:00Z",
+   "isDraft":false,"reviewDecision":"CHANGES_REQUESTED","author":{"login":"bob"},"repository":{"nameWithOwner":"acme/web"},
+   "commits":{"nodes":[{"commit":{"statusCheckRollup":{"state":"FAILURE"}}}]}}
+]}}}
+`
+
+var now = time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+func samplePRs(t *testing.T) []pullRequest {
+	t.Helper()
+	prs, err := parseSearchPages([]byte(samplePages))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return prs
+}
+
+func refs(prs []pullRequest) string {
+	var s []string
+	for _, pr := range prs {
+		s = append(s, pr.ref())
+	}
+	return strings.Join(s, ",")
+}
+
+func TestParseSearchPages(t *testing.T) {
+	prs := samplePRs(t)
+	if got := refs(prs); got != "acme/api#12,acme/web#3,acme/web#7" {
+		t.Fatalf("Expected PRs from both pages, got %s", got)
+	}
+
+	api := prs[0]
+	if api.Author != "alice" || api.reviewStatus() != "approved" || api.ciStatus() != "passing" || api.URL != "https://github.com/acme/api/pull/12" {
+		t.Errorf("Unexpected PR: %+v", api)
+	}
+	draft := prs[1]
+	if draft.Author != "ghost" || draft.reviewStatus() != "draft" || draft.ciStatus() != "-" {
+		t.Errorf("Expected draft by deleted user without CI, got %+v", draft)
+	}
+	if prs[2].reviewStatus() != "changes requested" || prs[2].ciStatus() != "failing" {
+		t.Errorf("Unexpected PR: %+v", prs[2])
+	}
+
+	_, err := parseSearchPages([]byte(`{"errors":[{"message":"Could not resolve to a User with the login of 'nope'."}]}`))
+	if err == nil || !strings.Contains(err.Error(), "Could not resolve") {
+		t.Errorf("Expected GitHub API error, got %v", err)
+	}
+}
+
+func TestView_SortAndFilter(t *testing.T) {
+	v := &view{}
+	v.setPRs(samplePRs(t), nil, now)
+
+	if got := refs(v.visibleRows()); got != "acme/web#3,acme/api#12,acme/web#7" {
+		t.Errorf("Expected newest first, got %s", got)
+	}
+
+	v.handleKey("r")
+	if got := refs(v.visibleRows()); got != "acme/web#7,acme/api#12,acme/web#3" {
+		t.Errorf("Expected oldest first, got %s", got)
+	}
+
+	v.handleKey("s") // repo
+	if got := refs(v.visibleRows()); got != "acme/api#12,acme/web#3,acme/web#7" {
+		t.Errorf("Expected sort by repo, got %s", got)
+	}
+
+	for _, k := range []common.Key{"/", "B", "O", "B", common.KeyEnter} {
+		v.handleKey(k)
+	}
+	if got := refs(v.visibleRows()); got != "acme/web#7" {
+		t.Errorf("Expected filter on author, got %s", got)
+	}
+	v.handleKey(common.KeyEsc)
+	if len(v.visibleRows()) != 3 {
+		t.Errorf("Expected esc to clear the filter")
+	}
+}
+
+func TestView_Actions(t *testing.T) {
+	v := &view{}
+	v.setPRs(samplePRs(t), nil, now)
+
+	v.handleKey(common.KeyDown)
+	if pr, _ := v.selectedPR(); pr.ref() != "acme/api#12" {
+		t.Errorf("Expected acme/api#12 selected, got %s", pr.ref())
+	}
+	if act := v.handleKey(common.KeyEnter); act != actionOpen {
+		t.Errorf("Expected enter to open the PR, got %v", act)
+	}
+
+	// Approval needs confirmation
+	v.handleKey("a")
+	if act := v.handleKey("n"); act != actionNone || v.status != "Approval cancelled" {
+		t.Errorf("Expected approval to be cancelled, got %v %q", act, v.status)
+	}
+	v.handleKey("a")
+	if act := v.handleKey("y"); act != actionApprove {
+		t.Errorf("Expected approval, got %v", act)
+	}
+
+	// Keys go into the comment while typing it, even q
+	v.handleKey("c")
+	for _, k := range []common.Key{"L", "G", "T", "M", "x", common.KeyBackspace, "q"} {
+		if act := v.handleKey(k); act != actionNone {
+			t.Fatalf("Expected no action while typing, got %v", act)
+		}
+	}
+	if act := v.handleKey(common.KeyEnter); act != actionComment || v.input != "LGTMq" {
+		t.Errorf("Expected comment %q, got %v %q", "LGTMq", act, v.input)
+	}
+
+	// Reloading is ignored while a load is running
+	if act := v.handleKey("R"); act != actionReload {
+		t.Errorf("Expected reload, got %v", act)
+	}
+	if act := v.handleKey("R"); act != actionNone {
+		t.Errorf("Expected no second reload while loading, got %v", act)
+	}
+	if act := v.handleKey("q"); act != actionQuit {
+		t.Errorf("Expected quit, got %v", act)
+	}
+}
+
+func TestView_Render(t *testing.T) {
+	v := &view{scope: "acme"}
+	v.setPRs(samplePRs(t), nil, now)
+
+	expected := `Open pull requests: acme   Sort: AGE ↑   Updated: 10:00:00
+
+REPO       #    TITLE                   AUTHOR   REVIEW              CI        AGE
+acme/web   3    WIP: new landing page   ghost    draft               -         30m
+acme/api   12   Add retries             alice    approved            passing   2d
+acme/web   7    Fix login redirect      bob      changes requested   failing   15d
+`
+	if got := v.render(0, false, now); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	v.handleKey("c")
+	v.handleKey("h")
+	if got := v.render(0, true, now); !strings.Contains(got, "Comment on acme/web#3: h█") {
+		t.Errorf("Expected comment prompt, got:\n%s", got)
+	}
+}
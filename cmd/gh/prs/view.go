@@ -0,0 +1,333 @@
+package prs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+type sortColumn int
+
+const (
+	sortByAge sortColumn = iota
+	sortByRepo
+	sortByAuthor
+	sortByReview
+	sortByCI
+)
+
+var sortColumnNames = []string{"AGE", "REPO", "AUTHOR", "REVIEW", "CI"}
+
+// sortState is the current sort column and direction. Cycling to a new
+// column resets the direction to ascending, which for age is newest first.
+type sortState struct {
+	column sortColumn
+	desc   bool
+}
+
+func (s *sortState) next() {
+	s.column = (s.column + 1) % sortColumn(len(sortColumnNames))
+	s.desc = false
+}
+
+func (s sortState) String() string {
+	arrow := "↑"
+	if s.desc {
+		arrow = "↓"
+	}
+	return sortColumnNames[s.column] + " " + arrow
+}
+
+type inputMode int
+
+const (
+	modeNormal  inputMode = iota
+	modeFilter            // typing a filter after '/'
+	modeComment           // typing a comment after 'c'
+	modeApprove           // waiting for y/n after 'a'
+)
+
+type action int
+
+const (
+	actionNone action = iota
+	actionQuit
+	actionOpen    // open the selected PR in the browser
+	actionApprove // approve the selected PR
+	actionComment // post view.input as a comment on the selected PR
+	actionReload
+)
+
+// view holds the interactive state of the pull request table.
+type view struct {
+	scope    string // whose repositories are shown
+	prs      []pullRequest
+	err      error
+	loading  bool
+	updated  time.Time
+	sort     sortState
+	filter   string
+	mode     inputMode
+	input    string // comment being typed
+	status   string // result of the last action
+	selected int    // index into visibleRows
+}
+
+// setPRs replaces the table contents after a (re)load.
+func (v *view) setPRs(prs []pullRequest, err error, now time.Time) {
+	v.loading = false
+	v.err = err
+	if err == nil {
+		v.prs, v.updated = prs, now
+	}
+	v.clampSelection()
+}
+
+// visibleRows returns the filtered and sorted pull requests.
+func (v *view) visibleRows() []pullRequest {
+	filter := strings.ToLower(v.filter)
+	var rows []pullRequest
+	for _, pr := range v.prs {
+		haystack := strings.ToLower(pr.Repo + " " + pr.Title + " " + pr.Author)
+		if filter == "" || strings.Contains(haystack, filter) {
+			rows = append(rows, pr)
+		}
+	}
+
+	less := func(a, b pullRequest) bool {
+		switch v.sort.column {
+		case sortByAge:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+		case sortByAuthor:
+			if a.Author != b.Author {
+				return strings.ToLower(a.Author) < strings.ToLower(b.Author)
+			}
+		case sortByReview:
+			if a.reviewStatus() != b.reviewStatus() {
+				return a.reviewStatus() < b.reviewStatus()
+			}
+		case sortByCI:
+			if a.ciStatus() != b.ciStatus() {
+				return a.ciStatus() < b.ciStatus()
+			}
+		}
+		if a.Repo != b.Repo {
+			return strings.ToLower(a.Repo) < strings.ToLower(b.Repo)
+		}
+		return a.Number < b.Number
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if v.sort.desc {
+			return less(rows[j], rows[i])
+		}
+		return less(rows[i], rows[j])
+	})
+	return rows
+}
+
+func (v *view) clampSelection() {
+	n := len(v.visibleRows())
+	if v.selected >= n {
+		v.selected = n - 1
+	}
+	if v.selected < 0 {
+		v.selected = 0
+	}
+}
+
+// selectedPR returns the highlighted pull request, if any.
+func (v *view) selectedPR() (pullRequest, bool) {
+	rows := v.visibleRows()
+	if v.selected < len(rows) {
+		return rows[v.selected], true
+	}
+	return pullRequest{}, false
+}
+
+func (v *view) handleKey(k common.Key) action {
+	if k == common.KeyCtrlC {
+		return actionQuit
+	}
+
+	switch v.mode {
+	case modeFilter:
+		switch k {
+		case common.KeyEnter:
+			v.mode = modeNormal
+		case common.KeyEsc:
+			v.mode = modeNormal
+			v.filter = ""
+		case common.KeyBackspace:
+			if v.filter != "" {
+				v.filter = v.filter[:len(v.filter)-1]
+			}
+		case common.KeyUp, common.KeyDown:
+		default:
+			v.filter += string(k)
+		}
+		v.clampSelection()
+		return actionNone
+
+	case modeComment:
+		switch k {
+		case common.KeyEnter:
+			v.mode = modeNormal
+			if strings.TrimSpace(v.input) != "" {
+				return actionComment
+			}
+		case common.KeyEsc:
+			v.mode = modeNormal
+			v.status = "Comment cancelled"
+		case common.KeyBackspace:
+			if v.input != "" {
+				v.input = v.input[:len(v.input)-1]
+			}
+		case common.KeyUp, common.KeyDown:
+		default:
+			v.input += string(k)
+		}
+		return actionNone
+
+	case modeApprove:
+		v.mode = modeNormal
+		if k == "y" || k == "Y" {
+			return actionApprove
+		}
+		v.status = "Approval cancelled"
+		return actionNone
+	}
+
+	switch k {
+	case "q":
+		return actionQuit
+	case common.KeyUp, "k":
+		if v.selected > 0 {
+			v.selected--
+		}
+	case common.KeyDown, "j":
+		v.selected++
+		v.clampSelection()
+	case common.KeyEnter:
+		if _, ok := v.selectedPR(); ok {
+			return actionOpen
+		}
+	case "/":
+		v.mode = modeFilter
+	case common.KeyEsc:
+		v.filter = ""
+		v.clampSelection()
+	case "s":
+		v.sort.next()
+	case "r":
+		v.sort.desc = !v.sort.desc
+	case "a":
+		if _, ok := v.selectedPR(); ok {
+			v.mode = modeApprove
+		}
+	case "c":
+		if _, ok := v.selectedPR(); ok {
+			v.mode = modeComment
+			v.input = ""
+		}
+	case "R":
+		if !v.loading {
+			v.loading = true
+			return actionReload
+		}
+	}
+	return actionNone
+}
+
+// render draws the full screen, limited to height lines. Lines are separated
+// by "\n"; the caller adapts them for raw mode terminals.
+func (v *view) render(height int, interactive bool, now time.Time) string {
+	var sb strings.Builder
+
+	header := fmt.Sprintf("Open pull requests: %s   Sort: %s", v.scope, v.sort)
+	if v.filter != "" || v.mode == modeFilter {
+		header += "   Filter: " + v.filter
+		if v.mode == modeFilter {
+			header += "█"
+		}
+	}
+	if v.loading {
+		header += "   Loading..."
+	} else if !v.updated.IsZero() {
+		header += "   Updated: " + v.updated.Format("15:04:05")
+	}
+	sb.WriteString(header + "\n")
+
+	if interactive {
+		pr, _ := v.selectedPR()
+		switch v.mode {
+		case modeComment:
+			sb.WriteString(fmt.Sprintf("Comment on %s: %s█   (enter: send  esc: cancel)\n", pr.ref(), v.input))
+		case modeApprove:
+			sb.WriteString(fmt.Sprintf("Approve %s? (y/n)\n", pr.ref()))
+		default:
+			sb.WriteString("↑/↓: select  enter: open  /: filter  s: sort  r: reverse  a: approve  c: comment  R: reload  q: quit\n")
+		}
+		if v.status != "" {
+			sb.WriteString(v.status + "\n")
+		}
+	}
+	sb.WriteString("\n")
+	used := strings.Count(sb.String(), "\n")
+
+	if v.err != nil {
+		sb.WriteString(v.err.Error() + "\n")
+		return sb.String()
+	}
+
+	rows := v.visibleRows()
+	if len(rows) == 0 {
+		if v.prs == nil && v.loading {
+			sb.WriteString("Loading...\n")
+		} else {
+			sb.WriteString("No open pull requests\n")
+		}
+		return sb.String()
+	}
+
+	table := [][]string{{"REPO", "#", "TITLE", "AUTHOR", "REVIEW", "CI", "AGE"}}
+	for _, pr := range rows {
+		table = append(table, []string{
+			pr.Repo,
+			fmt.Sprint(pr.Number),
+			truncate(pr.Title, 60),
+			pr.Author,
+			pr.reviewStatus(),
+			pr.ciStatus(),
+			common.FormatAge(now.Sub(pr.CreatedAt)),
+		})
+	}
+
+	// Scroll so the selected row stays visible below the column headers
+	space := len(rows)
+	if height > 0 {
+		space = max(1, height-used-1)
+	}
+	offset := 0
+	if v.selected >= space {
+		offset = v.selected - space + 1
+	}
+	selected := -1
+	if interactive {
+		selected = v.selected
+	}
+	common.WriteTable(&sb, table, selected, offset, space)
+	return sb.String()
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
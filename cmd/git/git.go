@@ -2,7 +2,9 @@ package git
 
 import (
 	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/git/prunebranches"
 	"github.com/gigurra/tofu/cmd/git/sync"
+	"github.com/gigurra/tofu/cmd/git/worktree"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +14,8 @@ func Cmd() *cobra.Command {
 		Short: "Git utilities",
 		SubCmds: []*cobra.Command{
 			sync.Cmd(),
+			prunebranches.Cmd(),
+			worktree.Cmd(),
 		},
 	}.ToCobra()
 }
@@ -0,0 +1,394 @@
+package prunebranches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/GiGurra/cmder"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+type Params struct {
+	Dir         string `pos:"true" optional:"true" help:"Any directory inside the repository (defaults to current directory)" default:"."`
+	SquashAware bool   `name:"squash-aware" help:"Also detect branches that were squash merged, by comparing patch ids"`
+	DryRun      bool   `short:"n" name:"dry-run" help:"Print the branches that would be deleted without deleting them"`
+	Yes         bool   `short:"y" help:"Delete all merged branches without asking"`
+}
+
+func Cmd() *cobra.Command {
+	return boa.CmdT[Params]{
+		Use:   "prune-branches [dir]",
+		Short: "Interactively clean up local branches",
+		Long: `List local branches with their upstream status (gone, ahead/behind) and
+whether they are merged into the default branch, and delete the ones you
+select.
+
+A branch counts as merged when its tip is an ancestor of the default
+branch (origin's if there is one). With --squash-aware, branches whose
+combined changes were squash merged also count, found by comparing the
+patch id of the branch squashed into one commit with those on the default
+branch. The default branch and branches checked out in a worktree are
+never deleted.
+
+Keys:
+  space  select or unselect the highlighted branch
+  a      select all merged branches
+  d      delete the selected branches, after confirmation. Branches git
+         considers unmerged need a second confirmation to force delete.
+  q      quit
+
+Examples:
+  tofu git prune-branches                 # Pick branches to delete
+  tofu git prune-branches --squash-aware  # Include squash merged branches
+  tofu git prune-branches --dry-run       # Show what --yes would delete
+  tofu git prune-branches --yes           # Delete all merged branches`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
+			if err := run(params, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+type branch struct {
+	Name     string
+	Current  bool
+	Upstream string // empty if not tracking
+	Gone     bool   // the upstream branch was deleted
+	Ahead    int
+	Behind   int
+	Date     time.Time // of the last commit
+	Subject  string
+	Worktree string // path if checked out in a worktree
+
+	Merged       bool // tip is reachable from the default branch
+	SquashMerged bool // changes are on the default branch as one squashed commit
+}
+
+// candidate reports whether --yes would delete the branch.
+func (b branch) candidate() bool {
+	return b.Merged || b.SquashMerged
+}
+
+// git runs a git command in dir and returns its stdout.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	result := cmder.New(append([]string{"git", "-C", dir}, args...)...).
+		WithAttemptTimeout(30 * time.Second).
+		Run(ctx)
+	if result.Err != nil {
+		if msg := strings.TrimSpace(result.Combined); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", result.Err
+	}
+	return result.StdOut, nil
+}
+
+// defaultBranch returns the name of the default branch and the ref to
+// compare branches against, origin's copy of it if there is one since the
+// local one may be behind.
+func defaultBranch(ctx context.Context, dir string) (name, base string, err error) {
+	if out, err := git(ctx, dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		// origin/main -> main
+		ref := strings.TrimSpace(out)
+		return strings.TrimPrefix(ref, "origin/"), ref, nil
+	}
+	for _, name := range []string{"main", "master", "develop", "trunk"} {
+		if _, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+name); err == nil {
+			return name, "origin/" + name, nil
+		}
+		if _, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+name); err == nil {
+			return name, name, nil
+		}
+	}
+	return "", "", errors.New("could not determine default branch")
+}
+
+// branchFormat lists the fields of git for-each-ref output, NUL separated.
+const branchFormat = "%(refname:short)%00%(HEAD)%00%(upstream:short)%00%(upstream:track)%00%(committerdate:unix)%00%(worktreepath)%00%(subject)"
+
+var trackPattern = regexp.MustCompile(`(ahead|behind) (\d+)`)
+
+// parseBranches parses git for-each-ref output in branchFormat.
+func parseBranches(out string) []branch {
+	var branches []branch
+	for line := range strings.SplitSeq(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 7 {
+			continue
+		}
+		b := branch{
+			Name:     fields[0],
+			Current:  fields[1] == "*",
+			Upstream: fields[2],
+			Gone:     fields[3] == "[gone]",
+			Worktree: fields[5],
+			Subject:  fields[6],
+		}
+		for _, m := range trackPattern.FindAllStringSubmatch(fields[3], -1) {
+			n, _ := strconv.Atoi(m[2])
+			if m[1] == "ahead" {
+				b.Ahead = n
+			} else {
+				b.Behind = n
+			}
+		}
+		if unix, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+			b.Date = time.Unix(unix, 0)
+		}
+		branches = append(branches, b)
+	}
+	return branches
+}
+
+// listBranches lists the local branches with their merge status against
+// base. The merge checks run in parallel, one git process per branch.
+func listBranches(ctx context.Context, dir, defaultName, base string, squashAware bool) ([]branch, error) {
+	out, err := git(ctx, dir, "for-each-ref", "--format="+branchFormat, "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	branches := parseBranches(out)
+
+	var wg sync.WaitGroup
+	for i := range branches {
+		b := &branches[i]
+		if b.Name == defaultName {
+			continue
+		}
+		wg.Go(func() {
+			b.Merged = isAncestor(ctx, dir, b.Name, base)
+			if !b.Merged && squashAware {
+				b.SquashMerged = isSquashMerged(ctx, dir, b.Name, base)
+			}
+		})
+	}
+	wg.Wait()
+	return branches, nil
+}
+
+func isAncestor(ctx context.Context, dir, ref, base string) bool {
+	_, err := git(ctx, dir, "merge-base", "--is-ancestor", ref, base)
+	return err == nil
+}
+
+// isSquashMerged squashes the branch into a single commit on top of its merge
+// base with base, without touching any refs, and checks with git cherry
+// whether a commit with the same patch id is on base.
+func isSquashMerged(ctx context.Context, dir, ref, base string) bool {
+	mergeBase, err := git(ctx, dir, "merge-base", base, ref)
+	if err != nil {
+		return false
+	}
+	// The commit is never referenced, so any identity works for it
+	squashed, err := git(ctx, dir, "-c", "user.name=tofu", "-c", "user.email=tofu@localhost",
+		"commit-tree", ref+"^{tree}", "-p", strings.TrimSpace(mergeBase), "-m", "squash")
+	if err != nil {
+		return false
+	}
+	// Prints "- <sha>" if an equivalent commit is on base, "+ <sha>" if not
+	out, err := git(ctx, dir, "cherry", base, strings.TrimSpace(squashed))
+	return err == nil && strings.HasPrefix(strings.TrimSpace(out), "-")
+}
+
+// errNotMerged is returned by deleteBranch when git refuses to delete a
+// branch it considers unmerged.
+var errNotMerged = errors.New("not fully merged")
+
+func deleteBranch(ctx context.Context, dir, name string, force bool) error {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	_, err := git(ctx, dir, "branch", flag, name)
+	if err != nil && strings.Contains(err.Error(), "not fully merged") {
+		return errNotMerged
+	}
+	return err
+}
+
+// loader lists branches of one repository, for reloading after deletes.
+type loader struct {
+	dir         string
+	defaultName string
+	base        string
+	squashAware bool
+}
+
+func (l loader) load(ctx context.Context) ([]branch, error) {
+	return listBranches(ctx, l.dir, l.defaultName, l.base, l.squashAware)
+}
+
+func run(params *Params, stdout io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := filepath.Abs(params.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	if _, err := git(ctx, dir, "rev-parse", "--git-dir"); err != nil {
+		return fmt.Errorf("not inside a git repository: %s", dir)
+	}
+	defaultName, base, err := defaultBranch(ctx, dir)
+	if err != nil {
+		return err
+	}
+	l := loader{dir: dir, defaultName: defaultName, base: base, squashAware: params.SquashAware}
+	branches, err := l.load(ctx)
+	if err != nil {
+		return err
+	}
+	v := &view{defaultBranch: defaultName, base: base}
+	v.setBranches(branches, time.Now())
+
+	if params.DryRun || params.Yes {
+		return runBatch(ctx, v, dir, params.DryRun, stdout)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		_, err := io.WriteString(stdout, v.render(0, false))
+		return err
+	}
+	return runInteractive(ctx, v, l, stdout)
+}
+
+// runBatch deletes all merged branches, or with dryRun prints them.
+// Squash merged branches need a force delete since git can't tell they are
+// merged.
+func runBatch(ctx context.Context, v *view, dir string, dryRun bool, stdout io.Writer) error {
+	var deleted, failed int
+	for _, b := range v.branches {
+		if !b.candidate() || v.protected(b) != "" {
+			continue
+		}
+		reason := "merged"
+		if b.SquashMerged {
+			reason = "squash merged"
+		}
+		if dryRun {
+			fmt.Fprintf(stdout, "Would delete %s (%s)\n", b.Name, reason)
+			deleted++
+			continue
+		}
+		if err := deleteBranch(ctx, dir, b.Name, true); err != nil {
+			fmt.Fprintf(stdout, "Failed to delete %s: %v\n", b.Name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "Deleted %s (%s)\n", b.Name, reason)
+		deleted++
+	}
+
+	switch {
+	case deleted == 0 && failed == 0:
+		fmt.Fprintln(stdout, "No merged branches to delete")
+	case failed > 0:
+		return fmt.Errorf("failed to delete %d branch(es)", failed)
+	}
+	return nil
+}
+
+func runInteractive(ctx context.Context, v *view, l loader, stdout io.Writer) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+
+	// Alternate screen, hidden cursor
+	fmt.Fprint(stdout, "\033[?1049h\033[?25l")
+	defer fmt.Fprint(stdout, "\033[?25h\033[?1049l")
+
+	keyCh := make(chan []common.Key)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			if n > 0 {
+				keyCh <- common.DecodeKeys(buf[:n])
+			}
+		}
+	}()
+
+	draw := func() {
+		_, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			height = 24
+		}
+		screen := strings.ReplaceAll(v.render(height, true), "\n", "\033[K\r\n")
+		fmt.Fprint(stdout, "\033[?7l\033[H"+screen+"\033[J\033[?7h")
+	}
+
+	// deleteSelected deletes the given branches and reloads. Branches git
+	// refuses to delete as unmerged are handed back to the view, which asks
+	// whether to force delete them.
+	deleteSelected := func(names []string, force bool) {
+		v.status = fmt.Sprintf("Deleting %d branch(es)...", len(names))
+		draw()
+		var deleted, unmerged, failures []string
+		for _, name := range names {
+			switch err := deleteBranch(ctx, l.dir, name, force); {
+			case errors.Is(err, errNotMerged):
+				unmerged = append(unmerged, name)
+			case err != nil:
+				failures = append(failures, name+": "+err.Error())
+			default:
+				deleted = append(deleted, name)
+			}
+		}
+
+		v.status = fmt.Sprintf("Deleted %d branch(es)", len(deleted))
+		if len(failures) > 0 {
+			v.status += ". Failed: " + strings.Join(failures, ", ")
+		}
+		if branches, err := l.load(ctx); err != nil {
+			v.status += ". Failed to reload: " + err.Error()
+		} else {
+			v.setBranches(branches, time.Now())
+		}
+		v.askForce(unmerged)
+	}
+
+	for {
+		draw()
+
+		select {
+		case <-sigCh:
+			return nil
+		case keys := <-keyCh:
+			for _, k := range keys {
+				switch v.handleKey(k) {
+				case actionQuit:
+					return nil
+				case actionDelete:
+					deleteSelected(v.selectedNames(), false)
+				case actionForceDelete:
+					deleteSelected(v.unmerged, true)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package prunebranches
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+var now = time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+// sampleBranches is git for-each-ref output in branchFormat, with merge
+// status filled in as listBranches would.
+func sampleBranches() []branch {
+	line := func(daysAgo int, fields ...string) string {
+		date := strconv.FormatInt(now.Add(-time.Duration(daysAgo)*24*time.Hour).Unix(), 10)
+		fields = append(fields[:4], append([]string{date}, fields[4:]...)...)
+		return strings.Join(fields, "\x00")
+	}
+	out := strings.Join([]string{
+		line(10, "feature/login", "", "origin/feature/login", "[gone]", "", "Add login page"),
+		line(3, "fix-typo", "", "origin/fix-typo", "[ahead 1, behind 2]", "", "Fix typo in README"),
+		line(0, "main", "*", "origin/main", "", "/src/api", "Release 1.2"),
+		line(40, "spike", "", "", "", "/src/api-spike", "Try a new parser"),
+		line(5, "wip", "", "", "", "", "WIP"),
+	}, "\n") + "\n"
+	branches := parseBranches(out)
+	branches[0].SquashMerged = true
+	branches[1].Merged = true
+	return branches
+}
+
+func sampleView() *view {
+	v := &view{defaultBranch: "main", base: "origin/main"}
+	v.setBranches(sampleBranches(), now)
+	return v
+}
+
+func TestParseBranches(t *testing.T) {
+	branches := sampleBranches()
+	if len(branches) != 5 {
+		t.Fatalf("Expected 5 branches, got %d", len(branches))
+	}
+	if b := branches[0]; b.Name != "feature/login" || !b.Gone || b.Upstream != "origin/feature/login" || b.Subject != "Add login page" {
+		t.Errorf("Unexpected gone branch: %+v", b)
+	}
+	if b := branches[1]; b.Ahead != 1 || b.Behind != 2 || b.Gone {
+		t.Errorf("Expected ahead 1, behind 2, got %+v", b)
+	}
+	if b := branches[2]; !b.Current || b.Worktree != "/src/api" || !b.Date.Equal(now) {
+		t.Errorf("Unexpected current branch: %+v", b)
+	}
+}
+
+func TestView_Render(t *testing.T) {
+	v := sampleView()
+
+	expected := `Local branches   Default: main (compared against origin/main)
+
+BRANCH          UPSTREAM                      STATUS                           AGE   SUBJECT
+feature/login   origin/feature/login (gone)   squash merged                    10d   Add login page
+fix-typo        origin/fix-typo ↑1 ↓2         merged                           3d    Fix typo in README
+main            origin/main                   default                          0m    Release 1.2
+spike           -                             worktree api-spike, not merged   40d   Try a new parser
+wip             -                             not merged                       5d    WIP
+`
+	if got := v.render(0, false); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+
+	v.handleKey(common.KeyDown)
+	v.handleKey(" ")
+	got := v.render(0, true)
+	if !strings.Contains(got, "Selected: 1\n") || !strings.Contains(got, "\033[7m[x]   fix-typo") || !strings.Contains(got, "\n      main ") {
+		t.Errorf("Expected fix-typo checked and no checkbox for main, got:\n%s", got)
+	}
+}
+
+func TestView_Keys(t *testing.T) {
+	v := sampleView()
+
+	// Protected branches can't be selected
+	v.selected = 2
+	v.handleKey(" ")
+	if len(v.checked) != 0 || v.status != "main can't be deleted (default branch)" {
+		t.Errorf("Expected main to be protected, got %v %q", v.checked, v.status)
+	}
+	v.handleKey("j")
+	v.handleKey(" ")
+	if v.status != "spike can't be deleted (worktree branch)" {
+		t.Errorf("Expected worktree branch to be protected, got %q", v.status)
+	}
+
+	v.handleKey("d")
+	if v.mode != modeNormal || v.status != "Select branches to delete with space first" {
+		t.Errorf("Expected nothing to delete, got %q", v.status)
+	}
+
+	// a selects the merged ones, space toggles
+	v.handleKey("a")
+	v.handleKey("j")
+	v.handleKey(" ")
+	if got := strings.Join(v.selectedNames(), ","); got != "feature/login,fix-typo,wip" {
+		t.Errorf("Expected merged branches and wip selected, got %s", got)
+	}
+	v.handleKey(" ")
+	if got := strings.Join(v.selectedNames(), ","); got != "feature/login,fix-typo" {
+		t.Errorf("Expected wip unselected, got %s", got)
+	}
+
+	// Deleting needs confirmation
+	v.handleKey("d")
+	if got := v.render(0, true); !strings.Contains(got, "Delete 2 branch(es): feature/login, fix-typo? (y/n)") {
+		t.Errorf("Expected confirmation prompt, got:\n%s", got)
+	}
+	if act := v.handleKey("n"); act != actionNone || v.status != "Deletion cancelled" {
+		t.Errorf("Expected deletion to be cancelled, got %v %q", act, v.status)
+	}
+	v.handleKey("d")
+	if act := v.handleKey("y"); act != actionDelete {
+		t.Errorf("Expected delete, got %v", act)
+	}
+
+	// Unmerged ones need a second confirmation to force delete
+	v.askForce([]string{"feature/login"})
+	if got := v.render(0, true); !strings.Contains(got, "1 branch(es) are not fully merged: feature/login. Force delete? (y/n)") {
+		t.Errorf("Expected force prompt, got:\n%s", got)
+	}
+	if act := v.handleKey("y"); act != actionForceDelete {
+		t.Errorf("Expected force delete, got %v", act)
+	}
+	v.askForce([]string{"feature/login"})
+	if act := v.handleKey("n"); act != actionNone || v.status != "Kept 1 unmerged branch(es)" || v.unmerged != nil {
+		t.Errorf("Expected force delete to be declined, got %v %q", act, v.status)
+	}
+
+	// Deleted branches are unchecked after reloading
+	v.setBranches(sampleBranches()[1:], now)
+	if got := strings.Join(v.selectedNames(), ","); got != "fix-typo" {
+		t.Errorf("Expected only fix-typo still selected, got %s", got)
+	}
+
+	if act := v.handleKey("q"); act != actionQuit {
+		t.Errorf("Expected quit, got %v", act)
+	}
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	gitCmd(t, dir, "add", name)
+	gitCmd(t, dir, "commit", "-q", "-m", "Add "+name)
+}
+
+// setupRepo clones a repository with these local branches:
+//
+//	merged    merged into origin/main, upstream deleted
+//	squashed  two commits squash merged into origin/main, upstream deleted
+//	wip       unmerged, no upstream
+//	tree      checked out in another worktree
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping")
+	}
+	for _, kv := range [][2]string{{"GIT_AUTHOR_NAME", "test"}, {"GIT_AUTHOR_EMAIL", "test@example.com"},
+		{"GIT_COMMITTER_NAME", "test"}, {"GIT_COMMITTER_EMAIL", "test@example.com"}} {
+		t.Setenv(kv[0], kv[1])
+	}
+	root := t.TempDir()
+
+	upstream := filepath.Join(root, "upstream")
+	gitCmd(t, root, "init", "-q", "-b", "main", upstream)
+	commitFile(t, upstream, "README", "hello")
+	repo := filepath.Join(root, "repo")
+	gitCmd(t, root, "clone", "-q", upstream, repo)
+
+	for _, name := range []string{"merged", "squashed"} {
+		gitCmd(t, repo, "checkout", "-q", "-b", name, "main")
+		commitFile(t, repo, name+"1", "one")
+		if name == "squashed" {
+			commitFile(t, repo, name+"2", "two")
+		}
+		gitCmd(t, repo, "push", "-q", "-u", "origin", name)
+	}
+	gitCmd(t, repo, "checkout", "-q", "-b", "wip", "main")
+	commitFile(t, repo, "wip", "wip")
+	gitCmd(t, repo, "checkout", "-q", "main")
+	gitCmd(t, repo, "worktree", "add", "-q", "-b", "tree", filepath.Join(root, "tree"))
+
+	gitCmd(t, upstream, "merge", "-q", "--ff-only", "merged")
+	gitCmd(t, upstream, "merge", "-q", "--squash", "squashed")
+	gitCmd(t, upstream, "commit", "-q", "-m", "Squashed")
+	gitCmd(t, upstream, "branch", "-q", "-D", "merged", "squashed")
+	gitCmd(t, repo, "fetch", "-q", "--prune")
+	return repo
+}
+
+func TestListBranches_Git(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+
+	name, base, err := defaultBranch(ctx, repo)
+	if err != nil || name != "main" || base != "origin/main" {
+		t.Fatalf("Expected main compared against origin/main, got %q %q %v", name, base, err)
+	}
+
+	status := func(squashAware bool) map[string]string {
+		branches, err := listBranches(ctx, repo, name, base, squashAware)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		v := &view{defaultBranch: name}
+		got := map[string]string{}
+		for _, b := range branches {
+			got[b.Name] = v.mergeStatus(b) + " " + upstreamStatus(b)
+		}
+		return got
+	}
+
+	expected := map[string]string{
+		"main":     "default origin/main ↑0 ↓2",
+		"merged":   "merged origin/merged (gone)",
+		"squashed": "not merged origin/squashed (gone)",
+		"tree":     "worktree tree, merged -",
+		"wip":      "not merged -",
+	}
+	for _, squashAware := range []bool{false, true} {
+		if squashAware {
+			expected["squashed"] = "squash merged origin/squashed (gone)"
+		}
+		got := status(squashAware)
+		for branch, want := range expected {
+			if got[branch] != want {
+				t.Errorf("squashAware=%v: expected %s to be %q, got %q", squashAware, branch, want, got[branch])
+			}
+		}
+	}
+}
+
+func TestRun_DryRunAndYes(t *testing.T) {
+	repo := setupRepo(t)
+	ctx := context.Background()
+
+	var stdout bytes.Buffer
+	if err := run(&Params{Dir: repo, SquashAware: true, DryRun: true}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "Would delete merged (merged)\nWould delete squashed (squash merged)\n"
+	if got := stdout.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	// git itself considers squashed unmerged
+	if err := deleteBranch(ctx, repo, "squashed", false); !errors.Is(err, errNotMerged) {
+		t.Errorf("Expected errNotMerged, got %v", err)
+	}
+
+	stdout.Reset()
+	if err := run(&Params{Dir: repo, SquashAware: true, Yes: true}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = "Deleted merged (merged)\nDeleted squashed (squash merged)\n"
+	if got := stdout.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	stdout.Reset()
+	if err := run(&Params{Dir: repo, Yes: true}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := stdout.String(); got != "No merged branches to delete\n" {
+		t.Errorf("Expected nothing left to delete, got %q", got)
+	}
+}
@@ -0,0 +1,263 @@
+package prunebranches
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+type inputMode int
+
+const (
+	modeNormal  inputMode = iota
+	modeConfirm           // waiting for y/n after 'd'
+	modeForce             // waiting for y/n to force delete unmerged branches
+)
+
+type action int
+
+const (
+	actionNone action = iota
+	actionQuit
+	actionDelete      // delete the checked branches
+	actionForceDelete // force delete view.unmerged
+)
+
+// view holds the interactive state of the branch list.
+type view struct {
+	defaultBranch string
+	base          string // ref branches are compared against
+	branches      []branch
+	now           time.Time
+	checked       map[string]bool
+	unmerged      []string // branches git refused to delete, for modeForce
+	mode          inputMode
+	status        string // result of the last action
+	selected      int    // highlighted row
+}
+
+// setBranches replaces the list after a (re)load. Checked branches that
+// are gone, such as deleted ones, are unchecked.
+func (v *view) setBranches(branches []branch, now time.Time) {
+	v.branches, v.now = branches, now
+	checked := map[string]bool{}
+	for _, b := range branches {
+		if v.checked[b.Name] {
+			checked[b.Name] = true
+		}
+	}
+	v.checked = checked
+	v.selected = max(0, min(v.selected, len(v.branches)-1))
+}
+
+// protected returns why a branch can't be deleted, or "" if it can.
+func (v *view) protected(b branch) string {
+	switch {
+	case b.Name == v.defaultBranch:
+		return "default"
+	case b.Current:
+		return "current"
+	case b.Worktree != "":
+		return "worktree"
+	}
+	return ""
+}
+
+// selectedNames returns the checked branches in list order.
+func (v *view) selectedNames() []string {
+	var names []string
+	for _, b := range v.branches {
+		if v.checked[b.Name] {
+			names = append(names, b.Name)
+		}
+	}
+	return names
+}
+
+// askForce switches to asking whether to force delete the branches git
+// refused to delete as unmerged.
+func (v *view) askForce(names []string) {
+	v.unmerged = names
+	if len(names) > 0 {
+		v.mode = modeForce
+	}
+}
+
+func (v *view) handleKey(k common.Key) action {
+	if k == common.KeyCtrlC {
+		return actionQuit
+	}
+
+	switch v.mode {
+	case modeConfirm:
+		v.mode = modeNormal
+		if k == "y" || k == "Y" {
+			return actionDelete
+		}
+		v.status = "Deletion cancelled"
+		return actionNone
+
+	case modeForce:
+		v.mode = modeNormal
+		if k == "y" || k == "Y" {
+			return actionForceDelete
+		}
+		v.status = fmt.Sprintf("Kept %d unmerged branch(es)", len(v.unmerged))
+		v.unmerged = nil
+		return actionNone
+	}
+
+	switch k {
+	case "q", common.KeyEsc:
+		return actionQuit
+	case common.KeyUp, "k":
+		if v.selected > 0 {
+			v.selected--
+		}
+	case common.KeyDown, "j":
+		if v.selected < len(v.branches)-1 {
+			v.selected++
+		}
+	case " ":
+		if v.selected >= len(v.branches) {
+			break
+		}
+		b := v.branches[v.selected]
+		if reason := v.protected(b); reason != "" {
+			v.status = fmt.Sprintf("%s can't be deleted (%s branch)", b.Name, reason)
+			break
+		}
+		v.checked[b.Name] = !v.checked[b.Name]
+		if !v.checked[b.Name] {
+			delete(v.checked, b.Name)
+		}
+	case "a":
+		for _, b := range v.branches {
+			if b.candidate() && v.protected(b) == "" {
+				v.checked[b.Name] = true
+			}
+		}
+	case "d":
+		if len(v.checked) == 0 {
+			v.status = "Select branches to delete with space first"
+		} else {
+			v.mode = modeConfirm
+		}
+	}
+	return actionNone
+}
+
+// render draws the full screen, limited to height lines. Lines are separated
+// by "\n"; the caller adapts them for raw mode terminals.
+func (v *view) render(height int, interactive bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Local branches   Default: %s", v.defaultBranch))
+	if v.base != v.defaultBranch {
+		sb.WriteString(fmt.Sprintf(" (compared against %s)", v.base))
+	}
+	if interactive {
+		sb.WriteString(fmt.Sprintf("   Selected: %d", len(v.checked)))
+	}
+	sb.WriteString("\n")
+
+	if interactive {
+		switch v.mode {
+		case modeConfirm:
+			names := v.selectedNames()
+			sb.WriteString(fmt.Sprintf("Delete %d branch(es): %s? (y/n)\n", len(names), strings.Join(names, ", ")))
+		case modeForce:
+			sb.WriteString(fmt.Sprintf("%d branch(es) are not fully merged: %s. Force delete? (y/n)\n", len(v.unmerged), strings.Join(v.unmerged, ", ")))
+		default:
+			sb.WriteString("↑/↓: move  space: select  a: select merged  d: delete  q: quit\n")
+		}
+		if v.status != "" {
+			sb.WriteString(v.status + "\n")
+		}
+	}
+	sb.WriteString("\n")
+	used := strings.Count(sb.String(), "\n")
+
+	if len(v.branches) == 0 {
+		sb.WriteString("No local branches\n")
+		return sb.String()
+	}
+
+	header := []string{"BRANCH", "UPSTREAM", "STATUS", "AGE", "SUBJECT"}
+	if interactive {
+		header = append([]string{""}, header...)
+	}
+	table := [][]string{header}
+	for _, b := range v.branches {
+		row := []string{b.Name, upstreamStatus(b), v.mergeStatus(b), common.FormatAge(v.now.Sub(b.Date)), truncate(b.Subject, 50)}
+		if interactive {
+			box := "[ ]"
+			if v.checked[b.Name] {
+				box = "[x]"
+			} else if v.protected(b) != "" {
+				box = ""
+			}
+			row = append([]string{box}, row...)
+		}
+		table = append(table, row)
+	}
+
+	space := len(v.branches)
+	if height > 0 {
+		space = max(1, height-used-1)
+	}
+	offset := 0
+	if v.selected >= space {
+		offset = v.selected - space + 1
+	}
+	selected := -1
+	if interactive {
+		selected = v.selected
+	}
+	common.WriteTable(&sb, table, selected, offset, space)
+	return sb.String()
+}
+
+func upstreamStatus(b branch) string {
+	switch {
+	case b.Upstream == "":
+		return "-"
+	case b.Gone:
+		return b.Upstream + " (gone)"
+	case b.Ahead > 0 || b.Behind > 0:
+		return fmt.Sprintf("%s ↑%d ↓%d", b.Upstream, b.Ahead, b.Behind)
+	default:
+		return b.Upstream
+	}
+}
+
+func (v *view) mergeStatus(b branch) string {
+	var parts []string
+	if reason := v.protected(b); reason != "" {
+		if reason == "worktree" {
+			reason = "worktree " + filepath.Base(b.Worktree)
+		}
+		parts = append(parts, reason)
+	}
+	switch {
+	case b.Name == v.defaultBranch:
+	case b.Merged:
+		parts = append(parts, "merged")
+	case b.SquashMerged:
+		parts = append(parts, "squash merged")
+	default:
+		parts = append(parts, "not merged")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,233 @@
+package worktree
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+type inputMode int
+
+const (
+	modeNormal  inputMode = iota
+	modeBranch            // typing a branch name after 'n'
+	modeConfirm           // waiting for y/n after 'd'
+)
+
+type action int
+
+const (
+	actionNone   action = iota
+	actionQuit          // exit without picking
+	actionPick          // print the selected worktree path and exit
+	actionCreate        // create a worktree for the branch in view.input
+	actionRemove        // remove the selected worktree
+	actionReload
+)
+
+// view holds the interactive state of the worktree list.
+type view struct {
+	current   string // top level of the worktree we were started in
+	worktrees []worktree
+	mode      inputMode
+	input     string // branch name being typed
+	status    string // result of the last action
+	selected  int
+}
+
+// setWorktrees replaces the list after a (re)load, selecting the current
+// worktree the first time.
+func (v *view) setWorktrees(worktrees []worktree) {
+	first := v.worktrees == nil
+	v.worktrees = worktrees
+	if first {
+		v.selectPath(v.current)
+	}
+	v.selected = max(0, min(v.selected, len(v.worktrees)-1))
+}
+
+func (v *view) selectPath(path string) {
+	for i, wt := range v.worktrees {
+		if samePath(wt.Path, path) {
+			v.selected = i
+			return
+		}
+	}
+}
+
+func (v *view) mainPath() string {
+	if len(v.worktrees) > 0 {
+		return v.worktrees[0].Path
+	}
+	return v.current
+}
+
+func (v *view) selectedWorktree() (worktree, bool) {
+	if v.selected < len(v.worktrees) {
+		return v.worktrees[v.selected], true
+	}
+	return worktree{}, false
+}
+
+func (v *view) handleKey(k common.Key) action {
+	if k == common.KeyCtrlC {
+		return actionQuit
+	}
+
+	switch v.mode {
+	case modeBranch:
+		switch k {
+		case common.KeyEnter:
+			v.mode = modeNormal
+			v.input = strings.TrimSpace(v.input)
+			if v.input != "" {
+				return actionCreate
+			}
+		case common.KeyEsc:
+			v.mode = modeNormal
+			v.input = ""
+		case common.KeyBackspace:
+			if v.input != "" {
+				v.input = v.input[:len(v.input)-1]
+			}
+		case common.KeyUp, common.KeyDown:
+		default:
+			v.input += string(k)
+		}
+		return actionNone
+
+	case modeConfirm:
+		v.mode = modeNormal
+		if k == "y" || k == "Y" {
+			return actionRemove
+		}
+		v.status = "Removal cancelled"
+		return actionNone
+	}
+
+	switch k {
+	case "q", common.KeyEsc:
+		return actionQuit
+	case common.KeyUp, "k":
+		if v.selected > 0 {
+			v.selected--
+		}
+	case common.KeyDown, "j":
+		if v.selected < len(v.worktrees)-1 {
+			v.selected++
+		}
+	case common.KeyEnter:
+		if wt, ok := v.selectedWorktree(); ok && !wt.Prunable {
+			return actionPick
+		}
+	case "n":
+		v.mode = modeBranch
+		v.input = ""
+	case "d":
+		wt, ok := v.selectedWorktree()
+		switch {
+		case !ok:
+		case wt.IsMain:
+			v.status = "The main worktree can't be removed"
+		case samePath(wt.Path, v.current):
+			v.status = "Can't remove the worktree you are in"
+		case wt.Locked:
+			v.status = wt.Path + " is locked, unlock it with git worktree unlock"
+		default:
+			v.mode = modeConfirm
+		}
+	case "R":
+		return actionReload
+	}
+	return actionNone
+}
+
+// render draws the full screen, limited to height lines. Lines are separated
+// by "\n"; the caller adapts them for raw mode terminals.
+func (v *view) render(height int, interactive bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Worktrees of %s\n", v.mainPath()))
+	if interactive {
+		wt, _ := v.selectedWorktree()
+		switch v.mode {
+		case modeBranch:
+			sb.WriteString(fmt.Sprintf("New worktree branch: %s█   (enter: create  esc: cancel)\n", v.input))
+		case modeConfirm:
+			prompt := fmt.Sprintf("Remove worktree %s?", wt.Path)
+			if wt.Dirty {
+				prompt += " Its uncommitted changes will be lost!"
+			}
+			sb.WriteString(prompt + " (y/n)\n")
+		default:
+			sb.WriteString("↑/↓: select  enter: pick  n: new  d: remove  R: reload  q: quit\n")
+		}
+		if v.status != "" {
+			sb.WriteString(v.status + "\n")
+		}
+	}
+	sb.WriteString("\n")
+	used := strings.Count(sb.String(), "\n")
+
+	table := [][]string{{"", "BRANCH", "AHEAD/BEHIND", "STATUS", "PATH"}}
+	for _, wt := range v.worktrees {
+		marker := ""
+		if samePath(wt.Path, v.current) {
+			marker = "*"
+		}
+		table = append(table, []string{marker, wt.name(), aheadBehind(wt), wtStatus(wt), wt.Path})
+	}
+
+	space := len(v.worktrees)
+	if height > 0 {
+		space = max(1, height-used-1)
+	}
+	offset := 0
+	if v.selected >= space {
+		offset = v.selected - space + 1
+	}
+	selected := -1
+	if interactive {
+		selected = v.selected
+	}
+	common.WriteTable(&sb, table, selected, offset, space)
+	return sb.String()
+}
+
+func aheadBehind(wt worktree) string {
+	if !wt.Upstream {
+		return "-"
+	}
+	return fmt.Sprintf("↑%d ↓%d", wt.Ahead, wt.Behind)
+}
+
+func wtStatus(wt worktree) string {
+	var parts []string
+	switch {
+	case wt.Prunable:
+		parts = append(parts, "missing")
+	case wt.Bare:
+		parts = append(parts, "bare")
+	case wt.Dirty:
+		parts = append(parts, "dirty")
+	default:
+		parts = append(parts, "clean")
+	}
+	if wt.Locked {
+		parts = append(parts, "locked")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// samePath compares paths the way git reports them, which may differ from
+// ours in symlinks (such as /tmp on macOS).
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, errA := filepath.EvalSymlinks(a)
+	rb, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && ra == rb
+}
@@ -0,0 +1,383 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/GiGurra/cmder"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+type Params struct {
+	Dir string `pos:"true" optional:"true" help:"Any directory inside the repository or one of its worktrees (defaults to current directory)" default:"."`
+}
+
+func Cmd() *cobra.Command {
+	return boa.CmdT[Params]{
+		Use:   "worktree [dir]",
+		Short: "Interactive manager for the worktrees of a git repo",
+		Long: `List the worktrees of a repository with their branch, ahead/behind counts
+against the upstream branch, and whether they have uncommitted changes.
+Works from inside any worktree of the repository, not just the main one.
+
+Keys:
+  enter  print the path of the selected worktree and exit
+  n      create a worktree, prompting for a branch name. An existing branch
+         is checked out, otherwise a new branch is created from HEAD. The
+         worktree is placed next to the main one, as <repo>-<branch>.
+  d      remove the selected worktree, after confirmation. The branch is kept.
+  R      reload
+  q      quit
+
+Prints the list once when stdin is not a terminal.
+
+Examples:
+  tofu git worktree                 # Manage worktrees of the current repo
+  cd "$(tofu git worktree pick)"    # Jump to a worktree`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds:     []*cobra.Command{pickCmd()},
+		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
+			path, err := run(params, os.Stdout, os.Stdout)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if path != "" {
+				fmt.Println(path)
+			}
+		},
+	}.ToCobra()
+}
+
+func pickCmd() *cobra.Command {
+	return boa.CmdT[Params]{
+		Use:         "pick [dir]",
+		Short:       "Pick a worktree and print its path",
+		Long:        `Same as tofu git worktree, but draws on stderr so that only the selected path ends up on stdout, for use as cd "$(tofu git worktree pick)". Exits non-zero if nothing was picked.`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
+			if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stderr.Fd())) {
+				fmt.Fprintf(os.Stderr, "Error: pick needs a terminal\n")
+				os.Exit(1)
+			}
+			path, err := run(params, os.Stdout, os.Stderr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if path == "" {
+				os.Exit(1)
+			}
+			fmt.Println(path)
+		},
+	}.ToCobra()
+}
+
+// worktree is one entry of git worktree list, plus its status.
+type worktree struct {
+	Path     string
+	Head     string // commit hash
+	Branch   string // empty when detached
+	Bare     bool
+	Locked   bool
+	Prunable bool // the directory is gone
+	IsMain   bool
+
+	// Filled in by loadStatus
+	Dirty    bool
+	Ahead    int
+	Behind   int
+	Upstream bool // whether the branch tracks an upstream branch
+}
+
+func (wt worktree) name() string {
+	if wt.Branch != "" {
+		return wt.Branch
+	}
+	if wt.Bare {
+		return "(bare)"
+	}
+	if len(wt.Head) >= 7 {
+		return "(detached " + wt.Head[:7] + ")"
+	}
+	return "(detached)"
+}
+
+// parseWorktrees parses the output of git worktree list --porcelain. The
+// first entry is always the main worktree.
+func parseWorktrees(out string) []worktree {
+	var worktrees []worktree
+	var current *worktree
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, worktree{Path: value})
+			current = &worktrees[len(worktrees)-1]
+		case "HEAD":
+			if current != nil {
+				current.Head = value
+			}
+		case "branch":
+			if current != nil {
+				current.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "bare":
+			if current != nil {
+				current.Bare = true
+			}
+		case "locked":
+			if current != nil {
+				current.Locked = true
+			}
+		case "prunable":
+			if current != nil {
+				current.Prunable = true
+			}
+		}
+	}
+	if len(worktrees) > 0 {
+		worktrees[0].IsMain = true
+	}
+	return worktrees
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	result := cmder.New(append([]string{"git", "-C", dir}, args...)...).
+		WithAttemptTimeout(30 * time.Second).
+		Run(ctx)
+	if result.Err != nil {
+		if msg := strings.TrimSpace(result.Combined); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", result.Err
+	}
+	return result.StdOut, nil
+}
+
+// listWorktrees lists the worktrees of the repository dir belongs to, with
+// their status. The status of each worktree is loaded in parallel.
+func listWorktrees(ctx context.Context, dir string) ([]worktree, error) {
+	out, err := git(ctx, dir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	worktrees := parseWorktrees(out)
+
+	var wg sync.WaitGroup
+	for i := range worktrees {
+		wt := &worktrees[i]
+		if wt.Bare || wt.Prunable {
+			continue
+		}
+		wg.Go(func() { loadStatus(ctx, wt) })
+	}
+	wg.Wait()
+	return worktrees, nil
+}
+
+func loadStatus(ctx context.Context, wt *worktree) {
+	if out, err := git(ctx, wt.Path, "status", "--porcelain"); err == nil {
+		wt.Dirty = strings.TrimSpace(out) != ""
+	}
+	if wt.Branch == "" {
+		return
+	}
+	// Prints "<behind>\t<ahead>", fails when there is no upstream
+	out, err := git(ctx, wt.Path, "rev-list", "--left-right", "--count", "@{upstream}...HEAD")
+	if err != nil {
+		return
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 2 {
+		wt.Behind, _ = strconv.Atoi(fields[0])
+		wt.Ahead, _ = strconv.Atoi(fields[1])
+		wt.Upstream = true
+	}
+}
+
+// currentWorktree returns the top level directory of the worktree dir is in.
+func currentWorktree(ctx context.Context, dir string) (string, error) {
+	out, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not inside a git repository: %s", dir)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// worktreePath places a new worktree next to the main one, as
+// <repo>-<branch>, with slashes in the branch name replaced.
+func worktreePath(mainPath, branch string) string {
+	name := filepath.Base(mainPath) + "-" + strings.ReplaceAll(branch, "/", "-")
+	return filepath.Join(filepath.Dir(mainPath), name)
+}
+
+// addWorktree checks out branch in a new worktree next to the main one,
+// creating the branch from HEAD of dir if it doesn't exist.
+func addWorktree(ctx context.Context, dir, mainPath, branch string) (string, error) {
+	if _, err := git(ctx, dir, "check-ref-format", "--branch", branch); err != nil {
+		return "", fmt.Errorf("invalid branch name: %s", branch)
+	}
+	path := worktreePath(mainPath, branch)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+
+	args := []string{"worktree", "add", path, branch}
+	if _, err := git(ctx, dir, "show-ref", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		args = []string{"worktree", "add", "-b", branch, path}
+	}
+	if _, err := git(ctx, dir, args...); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// removeWorktree removes the worktree directory. Uncommitted changes are
+// only thrown away if force is set; the user confirmed that in the view.
+func removeWorktree(ctx context.Context, dir string, wt worktree, force bool) error {
+	args := []string{"worktree", "remove", wt.Path}
+	if force {
+		args = []string{"worktree", "remove", "--force", wt.Path}
+	}
+	_, err := git(ctx, dir, args...)
+	return err
+}
+
+// run shows the worktree list on screen and returns the path of the picked
+// worktree, or "" if none was picked.
+func run(params *Params, stdout, screen io.Writer) (string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := filepath.Abs(params.Dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	current, err := currentWorktree(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	worktrees, err := listWorktrees(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	v := &view{current: current}
+	v.setWorktrees(worktrees)
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || (screen == stdout && !term.IsTerminal(int(os.Stdout.Fd()))) {
+		_, err := io.WriteString(stdout, v.render(0, false))
+		return "", err
+	}
+
+	return runInteractive(ctx, v, dir, screen)
+}
+
+func runInteractive(ctx context.Context, v *view, dir string, screen io.Writer) (string, error) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+
+	// Alternate screen, hidden cursor
+	fmt.Fprint(screen, "\033[?1049h\033[?25l")
+	defer fmt.Fprint(screen, "\033[?25h\033[?1049l")
+
+	keyCh := make(chan []common.Key)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			if n > 0 {
+				keyCh <- common.DecodeKeys(buf[:n])
+			}
+		}
+	}()
+
+	screenFd := int(os.Stdout.Fd())
+	if screen == os.Stderr {
+		screenFd = int(os.Stderr.Fd())
+	}
+	draw := func() {
+		_, height, err := term.GetSize(screenFd)
+		if err != nil {
+			height = 24
+		}
+		out := strings.ReplaceAll(v.render(height, true), "\n", "\033[K\r\n")
+		fmt.Fprint(screen, "\033[?7l\033[H"+out+"\033[J\033[?7h")
+	}
+
+	reload := func(selectPath string) {
+		worktrees, err := listWorktrees(ctx, dir)
+		if err != nil {
+			v.status = "Failed to list worktrees: " + err.Error()
+			return
+		}
+		v.setWorktrees(worktrees)
+		v.selectPath(selectPath)
+	}
+
+	for {
+		draw()
+
+		select {
+		case <-sigCh:
+			return "", nil
+		case keys := <-keyCh:
+			for _, k := range keys {
+				act := v.handleKey(k)
+				wt, _ := v.selectedWorktree()
+				switch act {
+				case actionQuit:
+					return "", nil
+				case actionPick:
+					return wt.Path, nil
+				case actionCreate:
+					v.status = "Creating worktree for " + v.input + "..."
+					draw()
+					if path, err := addWorktree(ctx, dir, v.mainPath(), v.input); err != nil {
+						v.status = "Failed to create worktree: " + err.Error()
+					} else {
+						v.status = "Created " + path
+						reload(path)
+					}
+					v.input = ""
+				case actionRemove:
+					v.status = "Removing " + wt.Path + "..."
+					draw()
+					if err := removeWorktree(ctx, dir, wt, wt.Dirty); err != nil {
+						v.status = "Failed to remove worktree: " + err.Error()
+					} else {
+						v.status = "Removed " + wt.Path
+						reload("")
+					}
+				case actionReload:
+					v.status = ""
+					reload(wt.Path)
+				}
+			}
+		}
+	}
+}
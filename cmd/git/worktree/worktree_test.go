@@ -0,0 +1,214 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+const samplePorcelain = `worktree /src/api
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /src/api-feature-login
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feature/login
+locked
+
+worktree /src/api-detached
+HEAD 3333333333333333333333333333333333333333
+detached
+
+worktree /src/api-gone
+HEAD 4444444444444444444444444444444444444444
+branch refs/heads/old
+prunable gitdir file points to non-existent location
+`
+
+func TestParseWorktrees(t *testing.T) {
+	worktrees := parseWorktrees(samplePorcelain)
+	if len(worktrees) != 4 {
+		t.Fatalf("Expected 4 worktrees, got %d", len(worktrees))
+	}
+
+	if !worktrees[0].IsMain || worktrees[0].name() != "main" || worktrees[0].Path != "/src/api" {
+		t.Errorf("Unexpected main worktree: %+v", worktrees[0])
+	}
+	if worktrees[1].IsMain || worktrees[1].name() != "feature/login" || !worktrees[1].Locked {
+		t.Errorf("Unexpected locked worktree: %+v", worktrees[1])
+	}
+	if worktrees[2].name() != "(detached 3333333)" {
+		t.Errorf("Expected detached worktree, got %q", worktrees[2].name())
+	}
+	if !worktrees[3].Prunable {
+		t.Errorf("Expected prunable worktree, got %+v", worktrees[3])
+	}
+}
+
+func sampleView(t *testing.T) *view {
+	t.Helper()
+	worktrees := parseWorktrees(samplePorcelain)
+	worktrees[0].Upstream, worktrees[0].Behind = true, 2
+	worktrees[1].Upstream, worktrees[1].Ahead, worktrees[1].Dirty = true, 3, true
+	v := &view{current: "/src/api-detached"}
+	v.setWorktrees(worktrees)
+	return v
+}
+
+func TestView_Render(t *testing.T) {
+	v := sampleView(t)
+
+	expected := `Worktrees of /src/api
+
+    BRANCH               AHEAD/BEHIND   STATUS          PATH
+    main                 ↑0 ↓2          clean           /src/api
+    feature/login        ↑3 ↓0          dirty, locked   /src/api-feature-login
+*   (detached 3333333)   -              clean           /src/api-detached
+    old                  -              missing         /src/api-gone
+`
+	if got := v.render(0, false); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestView_Keys(t *testing.T) {
+	v := sampleView(t)
+
+	// Starts on the worktree we are in
+	if wt, _ := v.selectedWorktree(); wt.Path != "/src/api-detached" {
+		t.Fatalf("Expected current worktree selected, got %s", wt.Path)
+	}
+	if act := v.handleKey(common.KeyEnter); act != actionPick {
+		t.Errorf("Expected enter to pick, got %v", act)
+	}
+
+	v.handleKey("d")
+	if v.mode != modeNormal || v.status != "Can't remove the worktree you are in" {
+		t.Errorf("Expected current worktree to be protected, got %q", v.status)
+	}
+	v.handleKey(common.KeyUp)
+	v.handleKey(common.KeyUp)
+	v.handleKey("d")
+	if v.status != "The main worktree can't be removed" {
+		t.Errorf("Expected main worktree to be protected, got %q", v.status)
+	}
+
+	// Removal needs confirmation, and warns about uncommitted changes
+	v.selected = 3
+	v.handleKey("d")
+	if act := v.handleKey("n"); act != actionNone || v.status != "Removal cancelled" {
+		t.Errorf("Expected removal to be cancelled, got %v %q", act, v.status)
+	}
+	v.handleKey("d")
+	if act := v.handleKey("y"); act != actionRemove {
+		t.Errorf("Expected removal, got %v", act)
+	}
+	if act := v.handleKey(common.KeyEnter); act != actionNone {
+		t.Errorf("Expected missing worktree not to be pickable, got %v", act)
+	}
+
+	// Keys go into the branch name while typing it, even q
+	v.handleKey("n")
+	for _, k := range []common.Key{"f", "i", "x", "/", "q", "x", common.KeyBackspace} {
+		if act := v.handleKey(k); act != actionNone {
+			t.Fatalf("Expected no action while typing, got %v", act)
+		}
+	}
+	if got := v.render(0, true); !strings.Contains(got, "New worktree branch: fix/q█") {
+		t.Errorf("Expected branch prompt, got:\n%s", got)
+	}
+	if act := v.handleKey(common.KeyEnter); act != actionCreate || v.input != "fix/q" {
+		t.Errorf("Expected create %q, got %v %q", "fix/q", act, v.input)
+	}
+
+	if act := v.handleKey("q"); act != actionQuit {
+		t.Errorf("Expected quit, got %v", act)
+	}
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestWorktrees_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping")
+	}
+	ctx := context.Background()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A repo cloned from an upstream, one commit behind it
+	upstream := filepath.Join(root, "upstream")
+	gitCmd(t, root, "init", "-q", "-b", "main", upstream)
+	gitCmd(t, upstream, "commit", "-q", "--allow-empty", "-m", "first")
+	repo := filepath.Join(root, "repo")
+	gitCmd(t, root, "clone", "-q", upstream, repo)
+	gitCmd(t, upstream, "commit", "-q", "--allow-empty", "-m", "second")
+	gitCmd(t, repo, "fetch", "-q")
+
+	path, err := addWorktree(ctx, repo, repo, "feature/x")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := filepath.Join(root, "repo-feature-x"); path != expected {
+		t.Errorf("Expected %q, got %q", expected, path)
+	}
+	if _, err := addWorktree(ctx, repo, repo, "feature/x"); err == nil {
+		t.Errorf("Expected error creating the same worktree twice")
+	}
+	if _, err := addWorktree(ctx, repo, repo, "bad..name"); err == nil || !strings.Contains(err.Error(), "invalid branch name") {
+		t.Errorf("Expected invalid branch name error, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "wip.txt"), []byte("wip"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Works from inside the linked worktree too
+	current, err := currentWorktree(ctx, path)
+	if err != nil || current != path {
+		t.Errorf("Expected current worktree %q, got %q (%v)", path, current, err)
+	}
+	worktrees, err := listWorktrees(ctx, path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(worktrees) != 2 {
+		t.Fatalf("Expected 2 worktrees, got %+v", worktrees)
+	}
+	if main := worktrees[0]; main.Path != repo || !main.Upstream || main.Behind != 1 || main.Ahead != 0 || main.Dirty {
+		t.Errorf("Expected clean main worktree 1 behind, got %+v", main)
+	}
+	if wt := worktrees[1]; wt.Branch != "feature/x" || wt.Upstream || !wt.Dirty {
+		t.Errorf("Expected dirty worktree without upstream, got %+v", wt)
+	}
+
+	// Uncommitted changes block removal unless forced
+	if err := removeWorktree(ctx, repo, worktrees[1], false); err == nil {
+		t.Errorf("Expected error removing a dirty worktree")
+	}
+	if err := removeWorktree(ctx, repo, worktrees[1], true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", path)
+	}
+	// The branch is kept
+	gitCmd(t, repo, "rev-parse", "--verify", "feature/x")
+}
@@ -2,6 +2,7 @@ package grep
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -36,6 +37,8 @@ type Params struct {
 	InvertMatch bool        `short:"v" help:"Select non-matching lines." default:"false"`
 	WordRegexp  bool        `short:"w" help:"Match only whole words." default:"false"`
 	LineRegexp  bool        `short:"x" help:"Match only whole lines." default:"false"`
+	NullData    bool        `short:"z" help:"Treat input and output records as NUL-terminated instead of lines." default:"false"`
+	Multiline   bool        `help:"Let . match newlines, so patterns can span the lines of a -z record." default:"false"`
 
 	// Output control
 	LineNumber        bool `short:"n" help:"Print line number with output lines." default:"false"`
@@ -222,14 +225,12 @@ func CompilePattern(params *Params) (*regexp.Regexp, error) {
 		// Use as-is
 	}
 
-	// Word boundary matching
-	if params.WordRegexp {
-		pattern = `\b` + pattern + `\b`
-	}
-
-	// Line matching
+	// Line matching takes precedence over word matching, like GNU grep.
+	// The pattern is grouped so alternations stay inside the anchors.
 	if params.LineRegexp {
-		pattern = `^` + pattern + `$`
+		pattern = `^(?:` + pattern + `)$`
+	} else if params.WordRegexp {
+		pattern = `\b(?:` + pattern + `)\b`
 	}
 
 	// Case insensitive
@@ -237,6 +238,10 @@ func CompilePattern(params *Params) (*regexp.Regexp, error) {
 		pattern = `(?i)` + pattern
 	}
 
+	if params.Multiline {
+		pattern = `(?s)` + pattern
+	}
+
 	return regexp.Compile(pattern)
 }
 
@@ -266,12 +271,15 @@ func GrepFile(filename string, pattern *regexp.Regexp, params *Params, showFilen
 	}(file)
 
 	// TODO: Check if file is binary and handle accordingly (skip or process)
-	if isBinary, err := IsFileBinary(file); err == nil && isBinary {
-		// Skip binary files, log if needed
-		if !params.IgnoreBinary {
-			_, _ = fmt.Fprintf(os.Stderr, "grep: %s: binary file skipped\n", filename)
+	// With -z, NUL bytes are record separators rather than a sign of binary content
+	if !params.NullData {
+		if isBinary, err := IsFileBinary(file); err == nil && isBinary {
+			// Skip binary files, log if needed
+			if !params.IgnoreBinary {
+				_, _ = fmt.Fprintf(os.Stderr, "grep: %s: binary file skipped\n", filename)
+			}
+			return false, nil
 		}
-		return false, nil
 	}
 
 	res, err := GrepReader(file, filename, pattern, params, showFilename)
@@ -306,6 +314,9 @@ func IsFileBinary(file *os.File) (bool, error) {
 func GrepReader(reader io.Reader, filename string, pattern *regexp.Regexp, params *Params, showFilename bool) (bool, error) {
 	scanner := bufio.NewScanner(reader)
 	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // 10MB max line size
+	if params.NullData {
+		scanner.Split(ScanNullRecords)
+	}
 	lineNum := 0
 	matchCount := 0
 	found := false
@@ -444,7 +455,28 @@ func printLine(filename string, lineNum int, line string, showFilename, showLine
 		}
 	}
 
-	fmt.Println(output.String())
+	if params.NullData {
+		output.WriteByte(0)
+	} else {
+		output.WriteByte('\n')
+	}
+	fmt.Print(output.String())
+}
+
+// ScanNullRecords is a bufio.SplitFunc like bufio.ScanLines, but splitting
+// on NUL bytes and keeping any newlines within a record.
+func ScanNullRecords(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		// Final record without a terminating NUL
+		return len(data), data, nil
+	}
+	return 0, nil, nil
 }
 
 func HighlightMatches(line string, pattern *regexp.Regexp) string {
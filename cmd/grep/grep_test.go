@@ -1,6 +1,7 @@
 package grep
 
 import (
+	"bufio"
 	"bytes"
 	"os"
 	"path/filepath"
@@ -122,6 +123,82 @@ func TestCompilePattern_LineRegexp(t *testing.T) {
 	}
 }
 
+func TestCompilePattern_WordAndLineRegexpGroupAlternation(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   Params
+		input    string
+		expected bool
+	}{
+		{"word cat", Params{Pattern: "cat", WordRegexp: true}, "cat", true},
+		{"word cat in sentence", Params{Pattern: "cat", WordRegexp: true}, "the cat sat", true},
+		{"word cat not category", Params{Pattern: "cat", WordRegexp: true}, "category", false},
+		{"word alternation", Params{Pattern: "cat|dog", WordRegexp: true}, "dogma", false},
+		{"word alternation match", Params{Pattern: "cat|dog", WordRegexp: true}, "hot dog", true},
+		{"word ignore case", Params{Pattern: "cat", WordRegexp: true, IgnoreCase: true}, "a CAT here", true},
+		{"word fixed", Params{Pattern: "a.b", PatternType: PatternTypeFixed, WordRegexp: true}, "x a.b y", true},
+		{"line alternation", Params{Pattern: "cat|dog", LineRegexp: true}, "cat food", false},
+		{"line alternation match", Params{Pattern: "cat|dog", LineRegexp: true}, "dog", true},
+		{"line ignore case", Params{Pattern: "cat", LineRegexp: true, IgnoreCase: true}, "Cat", true},
+		{"line overrides word", Params{Pattern: "cat.*", LineRegexp: true, WordRegexp: true}, "category", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.params.PatternType == "" {
+				tt.params.PatternType = PatternTypeExtended
+			}
+			pattern, err := CompilePattern(&tt.params)
+			if err != nil {
+				t.Fatalf("CompilePattern failed: %v", err)
+			}
+			if got := pattern.MatchString(tt.input); got != tt.expected {
+				t.Errorf("pattern %q on %q = %v, expected %v", pattern, tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGrepReader_WordRegexpOnlyMatching(t *testing.T) {
+	input := "category\nthe cat sat\n"
+	params := &Params{
+		Pattern:      "CAT",
+		PatternType:  PatternTypeExtended,
+		WordRegexp:   true,
+		IgnoreCase:   true,
+		OnlyMatching: true,
+	}
+
+	pattern, err := CompilePattern(params)
+	if err != nil {
+		t.Fatalf("CompilePattern failed: %v", err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	found, err := GrepReader(strings.NewReader(input), "test.txt", pattern, params, false)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("GrepReader failed: %v", err)
+	}
+	if !found {
+		t.Errorf("Expected to find match")
+	}
+	expected := colorRed + "cat" + colorReset + "\n"
+	if output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
+
 func TestConvertBasicToExtended(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -742,3 +819,96 @@ func TestGrepReader_FilesWithoutMatch(t *testing.T) {
 		t.Errorf("Expected output to be 'test.txt', got %q", output)
 	}
 }
+
+func TestScanNullRecords(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("first\nrecord\x00second\x00\x00last"))
+	scanner.Split(ScanNullRecords)
+	var records []string
+	for scanner.Scan() {
+		records = append(records, scanner.Text())
+	}
+	expected := []string{"first\nrecord", "second", "", "last"}
+	if strings.Join(records, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected records %q, got %q", expected, records)
+	}
+}
+
+func TestGrepReader_NullData(t *testing.T) {
+	input := "func main() {\n\tpanic(err)\n}\x00func other() {\n\treturn\n}\x00"
+	params := &Params{
+		Pattern:     `main\(\) \{\n\tpanic`,
+		PatternType: PatternTypeExtended,
+		NullData:    true,
+	}
+
+	pattern, err := CompilePattern(params)
+	if err != nil {
+		t.Fatalf("CompilePattern failed: %v", err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	found, err := GrepReader(strings.NewReader(input), "test.txt", pattern, params, false)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("GrepReader failed: %v", err)
+	}
+	if !found {
+		t.Errorf("Expected the pattern spanning a newline to match")
+	}
+	// The whole record is printed, NUL-terminated, and the other one isn't
+	if !strings.HasSuffix(output, "\n}\x00") || strings.Count(output, "\x00") != 1 || strings.Contains(output, "other") {
+		t.Errorf("Expected only the first record, NUL-terminated, got %q", output)
+	}
+}
+
+func TestCompilePattern_Multiline(t *testing.T) {
+	record := "BEGIN\nsecret\nEND"
+
+	params := &Params{Pattern: "BEGIN.*END", PatternType: PatternTypeExtended, NullData: true}
+	pattern, err := CompilePattern(params)
+	if err != nil {
+		t.Fatalf("CompilePattern failed: %v", err)
+	}
+	if pattern.MatchString(record) {
+		t.Errorf("Expected . not to match newlines without --multiline")
+	}
+
+	params.Multiline = true
+	pattern, err = CompilePattern(params)
+	if err != nil {
+		t.Fatalf("CompilePattern failed: %v", err)
+	}
+	if !pattern.MatchString(record) {
+		t.Errorf("Expected . to match newlines with --multiline")
+	}
+}
+
+func TestGrepFile_NullDataNotBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records")
+	if err := os.WriteFile(path, []byte("a\x00needle\nhere\x00c\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params := &Params{Pattern: "needle", PatternType: PatternTypeExtended, NullData: true, Quiet: true}
+	pattern, err := CompilePattern(params)
+	if err != nil {
+		t.Fatalf("CompilePattern failed: %v", err)
+	}
+	found, err := GrepFile(path, pattern, params, false)
+	if err != nil {
+		t.Fatalf("GrepFile failed: %v", err)
+	}
+	if !found {
+		t.Errorf("Expected NUL-separated file to be searched with -z, not skipped as binary")
+	}
+}
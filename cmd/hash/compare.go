@@ -0,0 +1,121 @@
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type CompareParams struct {
+	File  string `pos:"true" help:"File to check, or '-' for stdin."`
+	Other string `pos:"true" help:"File to compare with, or the expected hex digest."`
+	Algo  string `short:"a" optional:"true" help:"Hash algorithm (md5, sha1, sha256, sha512). Defaults to sha256 for two files, and to the algorithm matching the digest's length." alts:"md5,sha1,sha256,sha512"`
+	Quiet bool   `short:"q" help:"Print nothing, only set the exit status." default:"false"`
+}
+
+// digestAlgos maps hex digest lengths to the algorithm producing them
+var digestAlgos = map[int]string{
+	32:  "md5",
+	40:  "sha1",
+	64:  "sha256",
+	128: "sha512",
+}
+
+func compareCmd() *cobra.Command {
+	return boa.CmdT[CompareParams]{
+		Use:   "compare <file> <file|digest>",
+		Short: "Check that a file matches another file or an expected digest",
+		Long: `Hash a file and compare it with a second file, or verify it against an
+expected hex digest. When the second argument is not an existing file it is
+taken as a digest, and its length selects the algorithm unless -a is given.
+
+Exits 0 when they match, 1 when they don't, and 2 if a file can't be read.
+
+Examples:
+  tofu hash compare original.iso copy.iso
+  tofu hash compare download.zip 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824
+  curl -sL https://example.com/tool.tar.gz | tofu hash compare -q - "$EXPECTED"`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *CompareParams, cmd *cobra.Command, args []string) {
+			match, err := runCompare(params, os.Stdout, os.Stdin)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "hash: %v\n", err)
+				os.Exit(2)
+			}
+			if !match {
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+// runCompare reports whether params.File matches params.Other, printing
+// the result unless quiet.
+func runCompare(params *CompareParams, stdout io.Writer, stdin io.Reader) (bool, error) {
+	if params.Quiet {
+		stdout = io.Discard
+	}
+
+	if _, err := os.Stat(params.Other); err == nil || params.Other == "-" {
+		return compareFiles(params, stdout, stdin)
+	}
+
+	expected, err := hex.DecodeString(strings.ToLower(strings.TrimSpace(params.Other)))
+	if err != nil || len(expected) == 0 {
+		return false, fmt.Errorf("%s is neither a file nor a hex digest", params.Other)
+	}
+	algo := params.Algo
+	if algo == "" {
+		if algo = digestAlgos[len(expected)*2]; algo == "" {
+			return false, fmt.Errorf("no algorithm has %d hex digit digests, like %s", len(expected)*2, params.Other)
+		}
+	}
+
+	sum, err := digest(params.File, algo, stdin)
+	if err != nil {
+		return false, err
+	}
+	if len(sum) != len(expected) {
+		return false, fmt.Errorf("expected digest has %d hex digits, %s digests have %d", len(expected)*2, algo, len(sum)*2)
+	}
+	if !bytes.Equal(sum, expected) {
+		fmt.Fprintf(stdout, "FAIL: %s has %s %x, expected %x\n", params.File, algo, sum, expected)
+		return false, nil
+	}
+	fmt.Fprintf(stdout, "OK: %s matches %s %x\n", params.File, algo, sum)
+	return true, nil
+}
+
+func compareFiles(params *CompareParams, stdout io.Writer, stdin io.Reader) (bool, error) {
+	if params.File == "-" && params.Other == "-" {
+		return false, fmt.Errorf("only one of the files can be stdin")
+	}
+	algo := params.Algo
+	if algo == "" {
+		algo = "sha256"
+	}
+
+	sum, err := digest(params.File, algo, stdin)
+	if err != nil {
+		return false, err
+	}
+	otherSum, err := digest(params.Other, algo, stdin)
+	if err != nil {
+		return false, err
+	}
+
+	if !bytes.Equal(sum, otherSum) {
+		fmt.Fprintf(stdout, "FAIL: %s and %s differ\n", params.File, params.Other)
+		fmt.Fprintf(stdout, "  %x  %s\n  %x  %s\n", sum, params.File, otherSum, params.Other)
+		return false, nil
+	}
+	fmt.Fprintf(stdout, "OK: %s and %s are identical (%s %x)\n", params.File, params.Other, algo, sum)
+	return true, nil
+}
@@ -0,0 +1,115 @@
+package hash
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const helloSha256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCompareFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a": "hello", "b": "hello", "c": "world"})
+
+	tests := []struct {
+		other string
+		match bool
+		want  string
+	}{
+		{"b", true, "OK: " + filepath.Join(dir, "a") + " and " + filepath.Join(dir, "b") + " are identical (sha256 " + helloSha256 + ")\n"},
+		{"c", false, "FAIL: " + filepath.Join(dir, "a") + " and " + filepath.Join(dir, "c") + " differ\n"},
+	}
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		params := &CompareParams{File: filepath.Join(dir, "a"), Other: filepath.Join(dir, tt.other)}
+		match, err := runCompare(params, &stdout, strings.NewReader(""))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if match != tt.match {
+			t.Errorf("compare a %s: expected match %v, got %v", tt.other, tt.match, match)
+		}
+		if !strings.HasPrefix(stdout.String(), tt.want) {
+			t.Errorf("Expected %q, got %q", tt.want, stdout.String())
+		}
+	}
+
+	// One side can be stdin
+	var stdout bytes.Buffer
+	match, err := runCompare(&CompareParams{File: "-", Other: filepath.Join(dir, "b"), Algo: "md5"}, &stdout, strings.NewReader("hello"))
+	if err != nil || !match {
+		t.Errorf("Expected stdin to match, got %v, %v: %s", match, err, stdout.String())
+	}
+	if _, err := runCompare(&CompareParams{File: "-", Other: "-"}, &stdout, strings.NewReader("")); err == nil {
+		t.Error("Expected error comparing stdin with itself")
+	}
+}
+
+func TestCompareDigest(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a": "hello"})
+	file := filepath.Join(dir, "a")
+
+	tests := []struct {
+		name   string
+		digest string
+		algo   string
+		match  bool
+		want   string
+	}{
+		{"sha256", helloSha256, "", true, "OK: " + file + " matches sha256 " + helloSha256 + "\n"},
+		{"uppercase", strings.ToUpper(helloSha256), "", true, "OK: "},
+		{"md5 by length", "5d41402abc4b2a76b9719d911017c592", "", true, "OK: " + file + " matches md5 "},
+		{"mismatch", strings.Repeat("0", 64), "", false, "FAIL: " + file + " has sha256 " + helloSha256 + ", expected " + strings.Repeat("0", 64) + "\n"},
+		{"explicit algo", "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", "sha1", true, "OK: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			match, err := runCompare(&CompareParams{File: file, Other: tt.digest, Algo: tt.algo}, &stdout, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if match != tt.match {
+				t.Errorf("Expected match %v, got %v", tt.match, match)
+			}
+			if !strings.HasPrefix(stdout.String(), tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a": "hello"})
+	file := filepath.Join(dir, "a")
+
+	for _, params := range []*CompareParams{
+		{File: file, Other: "not-a-file-or-digest"},
+		{File: file, Other: "abcd"},                               // no algorithm with 4 digits
+		{File: file, Other: helloSha256, Algo: "md5"},             // wrong length for algo
+		{File: filepath.Join(dir, "missing"), Other: helloSha256}, // unreadable file
+	} {
+		if _, err := runCompare(params, &bytes.Buffer{}, nil); err == nil {
+			t.Errorf("Expected error for %+v", params)
+		}
+	}
+
+	// --quiet prints nothing
+	var stdout bytes.Buffer
+	if match, _ := runCompare(&CompareParams{File: file, Other: strings.Repeat("0", 64), Quiet: true}, &stdout, nil); match || stdout.Len() != 0 {
+		t.Errorf("Expected silent mismatch, got %v, %q", match, stdout.String())
+	}
+}
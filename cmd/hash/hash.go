@@ -25,8 +25,13 @@ func Cmd() *cobra.Command {
 		Use:   "hash [flags] [files...]",
 		Short: "Calculate file hashes",
 		Long: `Calculate cryptographic hashes for files or standard input.
-Supported algorithms: md5, sha1, sha256, sha512.`,
+Supported algorithms: md5, sha1, sha256, sha512.
+
+Use 'tofu hash compare' to check a file against another file or an expected digest.`,
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds: []*cobra.Command{
+			compareCmd(),
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if err := runHash(params, os.Stdout, os.Stdin); err != nil {
 				fmt.Fprintf(os.Stderr, "hash: %v\n", err)
@@ -53,33 +58,36 @@ func runHash(params *Params, stdout io.Writer, stdin io.Reader) error {
 }
 
 func processFile(input, algo string, stdout io.Writer, stdin io.Reader) error {
-	var r io.Reader
-	var name string
+	sum, err := digest(input, algo, stdin)
+	if err != nil {
+		return err
+	}
 
-	if input == "-" {
-		r = stdin
-		name = "-"
-	} else {
+	fmt.Fprintf(stdout, "%x  %s\n", sum, input)
+	return nil
+}
+
+// digest streams a file, or stdin for "-", through the hasher for algo.
+func digest(input, algo string, stdin io.Reader) ([]byte, error) {
+	r := stdin
+	if input != "-" {
 		f, err := os.Open(input)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		defer f.Close()
 		r = f
-		name = input
 	}
 
 	h, err := newHasher(algo)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if _, err := io.Copy(h, r); err != nil {
-		return fmt.Errorf("%s: %v", name, err)
+		return nil, fmt.Errorf("%s: %v", input, err)
 	}
-
-	fmt.Fprintf(stdout, "%x  %s\n", h.Sum(nil), name)
-	return nil
+	return h.Sum(nil), nil
 }
 
 func newHasher(algo string) (hash.Hash, error) {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
@@ -13,7 +14,8 @@ import (
 
 type Params struct {
 	Files   []string `pos:"true" optional:"true" help:"Files to head. If none specified, read from standard input."`
-	Lines   int      `short:"n" help:"Output the first N lines, instead of the first 10" default:"10"`
+	Lines   int      `short:"n" help:"Output the first N lines, instead of the first 10; with a leading '-', all but the last N lines" default:"10"`
+	Bytes   string   `short:"c" optional:"true" help:"Output the first N bytes (suffixes K, M, G allowed); with a leading '-', all but the last N bytes"`
 	Quiet   bool     `short:"q" help:"Never output headers giving file names"`
 	Verbose bool     `short:"v" help:"Always output headers giving file names"`
 }
@@ -28,8 +30,10 @@ func Cmd() *cobra.Command {
 				params.Files = []string{"-"}
 			}
 
-			if params.Lines < 0 {
-				params.Lines = 0
+			spec, err := parseHeadSpec(params)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "head: %v\n", err)
+				os.Exit(1)
 			}
 
 			// Header logic:
@@ -37,12 +41,44 @@ func Cmd() *cobra.Command {
 			// If Verbose, always print header.
 			printHeaders := (len(params.Files) > 1 && !params.Quiet) || params.Verbose
 
-			runHead(params, os.Stdout, os.Stderr, printHeaders)
+			runHead(params, spec, os.Stdout, os.Stderr, printHeaders)
 		},
 	}.ToCobra()
 }
 
-func runHead(params *Params, stdout, stderr io.Writer, printHeaders bool) {
+// headSpec describes which part of each input to output.
+type headSpec struct {
+	count      int64
+	bytes      bool // count is in bytes rather than lines
+	allButLast bool // -N: output everything except the last N lines/bytes
+}
+
+// parseHeadSpec parses --bytes (if given) or --lines. A negative count
+// selects everything except the last N.
+func parseHeadSpec(params *Params) (headSpec, error) {
+	if params.Bytes == "" {
+		spec := headSpec{count: int64(params.Lines)}
+		if spec.count < 0 {
+			spec.count, spec.allButLast = -spec.count, true
+		}
+		return spec, nil
+	}
+
+	spec := headSpec{bytes: true}
+	value := strings.TrimSpace(params.Bytes)
+	if rest, ok := strings.CutPrefix(value, "-"); ok {
+		spec.allButLast = true
+		value = rest
+	}
+	count, err := common.ParseSize(value)
+	if err != nil {
+		return headSpec{}, fmt.Errorf("invalid number of bytes: %q", params.Bytes)
+	}
+	spec.count = count
+	return spec, nil
+}
+
+func runHead(params *Params, spec headSpec, stdout, stderr io.Writer, printHeaders bool) {
 	for i, file := range params.Files {
 		if printHeaders {
 			if i > 0 {
@@ -52,19 +88,99 @@ func runHead(params *Params, stdout, stderr io.Writer, printHeaders bool) {
 		}
 
 		if file == "-" {
-			headReader(os.Stdin, stdout, stderr, params.Lines)
+			headInput(os.Stdin, stdout, stderr, spec)
 		} else {
 			f, err := os.Open(file)
 			if err != nil {
 				fmt.Fprintf(stderr, "head: cannot open '%s' for reading: %v\n", file, err)
 				continue
 			}
-			headReader(f, stdout, stderr, params.Lines)
+			headInput(f, stdout, stderr, spec)
 			f.Close()
 		}
 	}
 }
 
+// headInput writes the part of r selected by spec to stdout.
+func headInput(r io.Reader, stdout, stderr io.Writer, spec headSpec) {
+	var err error
+	switch {
+	case spec.bytes && spec.allButLast:
+		err = headAllButLastBytes(r, stdout, spec.count)
+	case spec.bytes:
+		_, err = io.CopyN(stdout, r, spec.count)
+		if err == io.EOF {
+			err = nil
+		}
+	case spec.allButLast:
+		err = headAllButLastLines(r, stdout, spec.count)
+	default:
+		headReader(r, stdout, stderr, int(spec.count))
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "head: error reading: %v\n", err)
+	}
+}
+
+// headAllButLastBytes writes all of r except its last n bytes. The size of
+// regular files is known up front; other inputs hold back a sliding buffer.
+func headAllButLastBytes(r io.Reader, stdout io.Writer, n int64) error {
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			_, err := io.CopyN(stdout, f, max(info.Size()-n, 0))
+			if err == io.EOF {
+				err = nil
+			}
+			return err
+		}
+	}
+
+	var held []byte
+	chunk := make([]byte, 32*1024)
+	for {
+		k, err := r.Read(chunk)
+		held = append(held, chunk[:k]...)
+		if excess := int64(len(held)) - n; excess > 0 {
+			if _, werr := stdout.Write(held[:excess]); werr != nil {
+				return werr
+			}
+			held = append(held[:0], held[excess:]...)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// headAllButLastLines writes all of r except its last n lines, holding back
+// n lines at a time. Line endings are preserved as read.
+func headAllButLastLines(r io.Reader, stdout io.Writer, n int64) error {
+	br := bufio.NewReader(r)
+	var held []string
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			held = append(held, line)
+			if int64(len(held)) > n {
+				if _, werr := io.WriteString(stdout, held[0]); werr != nil {
+					return werr
+				}
+				held = held[1:]
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func headReader(r io.Reader, stdout, stderr io.Writer, n int) {
 	if n == 0 {
 		return
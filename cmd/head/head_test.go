@@ -44,6 +44,15 @@ func TestHeadReader_ZeroLines(t *testing.T) {
 	}
 }
 
+func mustParseSpec(t *testing.T, params *Params) headSpec {
+	t.Helper()
+	spec, err := parseHeadSpec(params)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return spec
+}
+
 func TestRunHead_SingleFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	file := filepath.Join(tmpDir, "test.txt")
@@ -58,7 +67,7 @@ func TestRunHead_SingleFile(t *testing.T) {
 	}
 
 	var stdout, stderr bytes.Buffer
-	runHead(params, &stdout, &stderr, false)
+	runHead(params, mustParseSpec(t, params), &stdout, &stderr, false)
 
 	expected := "A\nB\n"
 	if stdout.String() != expected {
@@ -84,7 +93,7 @@ func TestRunHead_MultipleFiles(t *testing.T) {
 	}
 
 	var stdout, stderr bytes.Buffer
-	runHead(params, &stdout, &stderr, true)
+	runHead(params, mustParseSpec(t, params), &stdout, &stderr, true)
 
 	expectedSubstr1 := "==> " + file1 + " <==\nA\nB\n"
 	expectedSubstr2 := "\n==> " + file2 + " <==\nC\nD\n"
@@ -123,10 +132,98 @@ func TestRunHead_Quiet(t *testing.T) {
 		t.Fatalf("Logic error in test setup: Quiet should force printHeaders false")
 	}
 
-	runHead(params, &stdout, &stderr, printHeaders)
+	runHead(params, mustParseSpec(t, params), &stdout, &stderr, printHeaders)
 
 	expected := "A\nB\n"
 	if stdout.String() != expected {
 		t.Errorf("Expected %q, got %q", expected, stdout.String())
 	}
 }
+
+func TestParseHeadSpec(t *testing.T) {
+	tests := []struct {
+		lines int
+		bytes string
+		want  headSpec
+	}{
+		{10, "", headSpec{count: 10}},
+		{-5, "", headSpec{count: 5, allButLast: true}},
+		{10, "1K", headSpec{count: 1024, bytes: true}},
+		{10, "-5M", headSpec{count: 5 * 1024 * 1024, bytes: true, allButLast: true}},
+	}
+	for _, tt := range tests {
+		got, err := parseHeadSpec(&Params{Lines: tt.lines, Bytes: tt.bytes})
+		if err != nil {
+			t.Errorf("parseHeadSpec(%d, %q): unexpected error: %v", tt.lines, tt.bytes, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseHeadSpec(%d, %q) = %+v, want %+v", tt.lines, tt.bytes, got, tt.want)
+		}
+	}
+
+	if _, err := parseHeadSpec(&Params{Bytes: "lots"}); err == nil {
+		t.Error("Expected error for invalid byte count")
+	}
+}
+
+func TestHeadInput_AllButLastLines(t *testing.T) {
+	tests := []struct {
+		input    string
+		count    int64
+		expected string
+	}{
+		{"A\nB\nC\nD\n", 2, "A\nB\n"},
+		{"A\nB\nC\nD", 1, "A\nB\nC\n"},
+		{"A\nB\n", 5, ""},
+		{"A\nB\n", 0, "A\nB\n"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		headInput(strings.NewReader(tt.input), &stdout, &stderr, headSpec{count: tt.count, allButLast: true})
+		if stdout.String() != tt.expected {
+			t.Errorf("-%d of %q: Expected %q, got %q", tt.count, tt.input, tt.expected, stdout.String())
+		}
+	}
+}
+
+func TestHeadInput_Bytes(t *testing.T) {
+	input := "0123456789"
+	tests := []struct {
+		spec     headSpec
+		expected string
+	}{
+		{headSpec{count: 3, bytes: true}, "012"},
+		{headSpec{count: 100, bytes: true}, input},
+		{headSpec{count: 3, bytes: true, allButLast: true}, "0123456"},
+		{headSpec{count: 100, bytes: true, allButLast: true}, ""},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		headInput(strings.NewReader(input), &stdout, &stderr, tt.spec)
+		if stdout.String() != tt.expected {
+			t.Errorf("%+v: Expected %q, got %q", tt.spec, tt.expected, stdout.String())
+		}
+	}
+}
+
+func TestRunHead_BytesMultipleFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	file1 := filepath.Join(tmpDir, "1.txt")
+	file2 := filepath.Join(tmpDir, "2.txt")
+	if err := os.WriteFile(file1, []byte("hello world\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file2, []byte("goodbye\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params := &Params{Files: []string{file1, file2}, Lines: 10, Bytes: "-7"}
+	var stdout, stderr bytes.Buffer
+	runHead(params, mustParseSpec(t, params), &stdout, &stderr, true)
+
+	expected := "==> " + file1 + " <==\nhello\n==> " + file2 + " <==\ng"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
@@ -0,0 +1,297 @@
+package http
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	nethttp "net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// cookieEntry is one line of a Netscape format cookie file, the format used
+// by curl, so jars can be shared with it. Session files store it as JSON.
+type cookieEntry struct {
+	Domain            string    `json:"domain"` // without leading dot
+	IncludeSubdomains bool      `json:"include_subdomains,omitempty"`
+	Path              string    `json:"path"`
+	Secure            bool      `json:"secure,omitempty"`
+	HttpOnly          bool      `json:"http_only,omitempty"`
+	Expires           time.Time `json:"expires,omitzero"` // zero for session cookies
+	Name              string    `json:"name"`
+	Value             string    `json:"value"`
+}
+
+func (e cookieEntry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !e.Expires.After(now)
+}
+
+// fileJar is a cookie jar backed by a file, or by a --session when path is
+// empty. net/http/cookiejar can't list its cookies, so fileJar keeps its own
+// copy of everything it was given in order to save it again. The cookiejar
+// still decides which cookies are sent, by domain, path, expiry and Secure.
+type fileJar struct {
+	path    string
+	jar     *cookiejar.Jar
+	mu      sync.Mutex
+	entries []cookieEntry
+}
+
+func newJar(path string) (*fileJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &fileJar{path: path, jar: jar}, nil
+}
+
+// add seeds the jar with a stored cookie, skipping expired ones.
+func (j *fileJar) add(e cookieEntry, now time.Time) {
+	if e.expired(now) {
+		return
+	}
+	j.jar.SetCookies(e.url(), []*nethttp.Cookie{e.cookie()})
+	j.entries = append(j.entries, e)
+}
+
+// unexpired returns the cookies to store, session cookies included, so the
+// session survives to the next invocation.
+func (j *fileJar) unexpired(now time.Time) []cookieEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var result []cookieEntry
+	for _, e := range j.entries {
+		if !e.expired(now) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// loadCookieJar reads the cookie file at path. A missing file gives an
+// empty jar, so the first request of a session can create it.
+func loadCookieJar(path string) (*fileJar, error) {
+	j, err := newJar(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading cookie jar: %w", err)
+	}
+	defer f.Close()
+
+	now := time.Now()
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		e, ok, err := parseCookieLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("reading cookie jar: %s:%d: %w", path, lineNo, err)
+		}
+		if ok {
+			j.add(e, now)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading cookie jar: %w", err)
+	}
+	return j, nil
+}
+
+// parseCookieLine parses a line of a cookie file. ok is false for blank
+// lines and comments.
+func parseCookieLine(line string) (cookieEntry, bool, error) {
+	var e cookieEntry
+	line = strings.TrimRight(line, "\r")
+	if rest, found := strings.CutPrefix(line, "#HttpOnly_"); found {
+		line, e.HttpOnly = rest, true
+	}
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return e, false, nil
+	}
+
+	fields := strings.Split(line, "\t")
+	if len(fields) != 7 {
+		return e, false, fmt.Errorf("expected 7 tab separated fields, got %d", len(fields))
+	}
+	expires, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return e, false, fmt.Errorf("invalid expiry time: %s", fields[4])
+	}
+	e.Domain = strings.TrimPrefix(fields[0], ".")
+	e.IncludeSubdomains = fields[1] == "TRUE"
+	e.Path = fields[2]
+	e.Secure = fields[3] == "TRUE"
+	if expires != 0 {
+		e.Expires = time.Unix(expires, 0)
+	}
+	e.Name, e.Value = fields[5], fields[6]
+	return e, true, nil
+}
+
+func (e cookieEntry) String() string {
+	domain := e.Domain
+	if e.IncludeSubdomains {
+		domain = "." + domain
+	}
+	if e.HttpOnly {
+		domain = "#HttpOnly_" + domain
+	}
+	var expires int64
+	if !e.Expires.IsZero() {
+		expires = e.Expires.Unix()
+	}
+	return strings.Join([]string{domain, strings.ToUpper(strconv.FormatBool(e.IncludeSubdomains)), e.Path,
+		strings.ToUpper(strconv.FormatBool(e.Secure)), strconv.FormatInt(expires, 10), e.Name, e.Value}, "\t")
+}
+
+// url is an address the cookie would have been set from, for seeding the jar.
+func (e cookieEntry) url() *url.URL {
+	scheme := "http"
+	if e.Secure {
+		scheme = "https"
+	}
+	host := e.Domain
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+	return &url.URL{Scheme: scheme, Host: host, Path: e.Path}
+}
+
+func (e cookieEntry) cookie() *nethttp.Cookie {
+	c := &nethttp.Cookie{
+		Name:     e.Name,
+		Value:    e.Value,
+		Path:     e.Path,
+		Expires:  e.Expires,
+		Secure:   e.Secure,
+		HttpOnly: e.HttpOnly,
+	}
+	if e.IncludeSubdomains {
+		c.Domain = e.Domain
+	}
+	return c
+}
+
+func (j *fileJar) Cookies(u *url.URL) []*nethttp.Cookie {
+	return j.jar.Cookies(u)
+}
+
+func (j *fileJar) SetCookies(u *url.URL, cookies []*nethttp.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, c := range cookies {
+		domain, includeSubdomains, ok := cookieDomain(u.Hostname(), c.Domain)
+		if !ok {
+			continue // rejected by the jar as well
+		}
+		e := cookieEntry{
+			Domain:            domain,
+			IncludeSubdomains: includeSubdomains,
+			Path:              c.Path,
+			Secure:            c.Secure,
+			HttpOnly:          c.HttpOnly,
+			Expires:           c.Expires,
+			Name:              c.Name,
+			Value:             c.Value,
+		}
+		if !strings.HasPrefix(e.Path, "/") {
+			e.Path = defaultCookiePath(u.Path)
+		}
+		switch {
+		case c.MaxAge < 0:
+			e.Expires = now // deleted
+		case c.MaxAge > 0:
+			e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+
+		// A cookie replaces the one with the same name, domain and path
+		j.entries = slices.DeleteFunc(j.entries, func(old cookieEntry) bool {
+			return old.Name == e.Name && old.Domain == e.Domain && old.Path == e.Path
+		})
+		if !e.expired(now) {
+			j.entries = append(j.entries, e)
+		}
+	}
+}
+
+// cookieDomain returns the domain a cookie with the given Domain attribute
+// is stored under when set from host, and whether it applies to subdomains.
+// ok is false when the cookie must be rejected, with the same checks the
+// cookiejar makes (RFC 6265 section 5.3 step 6), so a response from one site
+// can't plant cookies for another in the file.
+func cookieDomain(host, domain string) (result string, includeSubdomains bool, ok bool) {
+	host = strings.ToLower(host)
+	if domain == "" {
+		return host, false, true
+	}
+	if net.ParseIP(host) != nil {
+		return host, false, host == domain
+	}
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if domain == "" || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") ||
+		strings.ContainsFunc(domain, func(r rune) bool { return r >= utf8.RuneSelf }) {
+		return "", false, false
+	}
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		return "", false, false
+	}
+	return domain, true, true
+}
+
+// defaultCookiePath is the path of cookies set without one, the directory
+// of the request path (RFC 6265 section 5.1.4).
+func defaultCookiePath(requestPath string) string {
+	i := strings.LastIndex(requestPath, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return requestPath[:i]
+}
+
+// save writes all unexpired cookies back to the file.
+func (j *fileJar) save() error {
+	var sb strings.Builder
+	sb.WriteString("# Netscape HTTP Cookie File\n# Written by tofu http, edit at your own risk.\n\n")
+	for _, e := range j.unexpired(time.Now()) {
+		sb.WriteString(e.String() + "\n")
+	}
+	if err := os.WriteFile(j.path, []byte(sb.String()), 0600); err != nil {
+		return fmt.Errorf("saving cookie jar: %w", err)
+	}
+	return nil
+}
+
+// parseCookies parses -b values, name=value pairs that may also be given
+// several at once separated by ';' like in a Cookie header.
+func parseCookies(values []string) ([]*nethttp.Cookie, error) {
+	var cookies []*nethttp.Cookie
+	for _, v := range values {
+		for pair := range strings.SplitSeq(v, ";") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("invalid cookie %q, expected name=value", pair)
+			}
+			cookies = append(cookies, &nethttp.Cookie{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+		}
+	}
+	return cookies, nil
+}
@@ -0,0 +1,187 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// historyEntry is one line of a --log file: a request, and the response to
+// it or the error that prevented one.
+type historyEntry struct {
+	Time     time.Time        `json:"time"`
+	Request  historyRequest   `json:"request"`
+	Response *historyResponse `json:"response,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+type historyRequest struct {
+	Method  string         `json:"method"`
+	URL     string         `json:"url"`
+	Headers nethttp.Header `json:"headers,omitempty"`
+	Body    string         `json:"body,omitempty"`
+	// Form holds the -F arguments of a multipart request, which is logged
+	// as given rather than as the body, since files are read when sending.
+	Form []string `json:"form,omitempty"`
+}
+
+type historyResponse struct {
+	Status  int            `json:"status"`
+	Headers nethttp.Header `json:"headers,omitempty"`
+	Body    string         `json:"body,omitempty"`
+	// BodyBase64 is set when the body isn't valid UTF-8 and Body holds it
+	// base64 encoded.
+	BodyBase64    bool `json:"body_base64,omitempty"`
+	BodyTruncated bool `json:"body_truncated,omitempty"`
+}
+
+// maxLoggedBody limits how much of a response body goes into the log.
+const maxLoggedBody = 1 << 20
+
+const redacted = "REDACTED"
+
+// secretHeaders are redacted in the log unless --log-secrets is set.
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+func redactHeaders(h nethttp.Header) nethttp.Header {
+	h = h.Clone()
+	for _, name := range secretHeaders {
+		if _, ok := h[name]; ok {
+			h[name] = []string{redacted}
+		}
+	}
+	return h
+}
+
+func newHistoryRequest(req *nethttp.Request, body string, form []string, secrets bool) historyRequest {
+	headers := req.Header
+	if !secrets {
+		headers = redactHeaders(headers)
+	}
+	return historyRequest{Method: req.Method, URL: req.URL.String(), Headers: headers, Body: body, Form: form}
+}
+
+func newHistoryResponse(resp *nethttp.Response, body *bodyCapture) *historyResponse {
+	r := &historyResponse{Status: resp.StatusCode, Headers: resp.Header, BodyTruncated: body.truncated}
+	if data := body.buf.Bytes(); utf8.Valid(data) {
+		r.Body = string(data)
+	} else {
+		r.Body, r.BodyBase64 = base64.StdEncoding.EncodeToString(data), true
+	}
+	return r
+}
+
+// appendHistory adds entry to the log file, creating it with mode 0600 as
+// it may hold credentials.
+func appendHistory(path string, entry historyEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("writing log: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("writing log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing log: %w", err)
+	}
+	return nil
+}
+
+// loadHistoryEntry reads entry n, counting from 1, of a log file. n = 0
+// selects the last one.
+func loadHistoryEntry(path string, n int) (historyEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return historyEntry{}, fmt.Errorf("reading replay file: %w", err)
+	}
+	defer f.Close()
+
+	var entry historyEntry
+	count := 0
+	dec := json.NewDecoder(f)
+	for {
+		var e historyEntry
+		if err := dec.Decode(&e); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return historyEntry{}, fmt.Errorf("reading replay file: entry %d: %w", count+1, err)
+		}
+		count++
+		entry = e
+		if count == n {
+			return entry, nil
+		}
+	}
+	if count == 0 {
+		return historyEntry{}, fmt.Errorf("replay file %s has no entries", path)
+	}
+	if n > 0 {
+		return historyEntry{}, fmt.Errorf("replay file %s has only %d entries", path, count)
+	}
+	return entry, nil
+}
+
+// applyReplay fills in params from a logged request. The URL, an explicit
+// method and -d, -f or -F given on the command line take precedence, and -H
+// headers replace logged ones of the same name. Redacted headers are dropped.
+func applyReplay(params *Params, entry historyEntry, stderr io.Writer) {
+	req := entry.Request
+	if params.URL == "" {
+		params.URL = req.URL
+	}
+	if params.Method == "" {
+		params.Method = req.Method
+	}
+	if params.Data == "" && len(params.Form) == 0 {
+		params.Data = req.Body
+		params.Form = req.Form
+	}
+
+	override := map[string]bool{}
+	for _, h := range params.Headers {
+		if name, _, ok := strings.Cut(h, ":"); ok {
+			override[nethttp.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+	var headers []string
+	for name, values := range req.Headers {
+		if override[nethttp.CanonicalHeaderKey(name)] {
+			continue
+		}
+		for _, v := range values {
+			if v == redacted {
+				fmt.Fprintf(stderr, "Warning: %s header was redacted in the log, pass it with -H\n", name)
+				continue
+			}
+			headers = append(headers, name+": "+v)
+		}
+	}
+	params.Headers = append(headers, params.Headers...)
+}
+
+// bodyCapture keeps the first maxLoggedBody bytes written to it.
+type bodyCapture struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	room := maxLoggedBody - c.buf.Len()
+	if len(p) > room {
+		c.buf.Write(p[:room])
+		c.truncated = true
+	} else {
+		c.buf.Write(p)
+	}
+	return len(p), nil
+}
@@ -4,8 +4,14 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"math/rand/v2"
+	"mime"
+	"mime/multipart"
 	nethttp "net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,14 +21,26 @@ import (
 )
 
 type Params struct {
-	URL             string   `pos:"true" help:"The URL to request."`
+	URL             string   `pos:"true" optional:"true" help:"The URL to request. Optional with --replay."`
 	Method          string   `short:"X" optional:"true" help:"HTTP method to use (GET, POST, PUT, DELETE, etc.). Default is GET." default:"GET" alts:"GET,POST,PUT,DELETE,PATCH,HEAD,OPTIONS" strict:"false"`
 	Headers         []string `short:"H" optional:"true" help:"Pass custom header(s) to server."`
-	Data            string   `short:"d" optional:"true" help:"HTTP POST data."`
+	Data            string   `short:"d" optional:"true" help:"HTTP POST data, or @file to send the contents of a file."`
+	URLForm         []string `short:"f" name:"url-form" optional:"true" help:"Send an application/x-www-form-urlencoded field, as name=value. Can be repeated."`
+	Form            []string `short:"F" optional:"true" help:"Send a multipart/form-data field, as name=value or name=@file to upload a file. Can be repeated."`
 	OutputFile      string   `short:"o" optional:"true" help:"Write to file instead of stdout."`
 	FollowRedirects bool     `short:"L" optional:"true" help:"Follow redirects."`
 	Verbose         bool     `short:"v" optional:"true" help:"Make the operation more talkative."`
 	Insecure        bool     `short:"k" optional:"true" help:"Allow insecure server connections when using SSL."`
+	Cookies         []string `short:"b" name:"cookie" optional:"true" help:"Send cookie(s), as name=value."`
+	CookieJar       string   `optional:"true" help:"Read cookies from this file before the request and save the cookies the server sets to it after, to keep a session across invocations. Uses the Netscape format, like curl."`
+	Session         string   `optional:"true" help:"Keep cookies and -H headers in a named session under the tofu config dir, and send them with later requests using the same session. See 'tofu http session'."`
+	Log             string   `optional:"true" help:"Append the request and response to this file as a line of JSON, to re-send later with --replay. Authorization and Cookie headers are redacted."`
+	LogSecrets      bool     `optional:"true" help:"Keep Authorization and Cookie headers in the --log file instead of redacting them."`
+	Replay          string   `optional:"true" help:"Re-send a request from a --log file. A URL, -X, -H, -d, -f and -F given on the command line override the logged values."`
+	ReplayEntry     int      `optional:"true" help:"Which request in the --replay file to send, counting from 1. Defaults to the last one." default:"0"`
+	Retry           int      `optional:"true" help:"Retry this many times on connection errors and --retry-on statuses, with exponential backoff." default:"0"`
+	RetryDelay      float64  `optional:"true" help:"Seconds to wait before the first retry, doubled for each one after. A Retry-After header from the server takes precedence." default:"1"`
+	RetryOn         []string `optional:"true" help:"Status codes to retry, like 503 or 5xx. Defaults to 429 and 5xx."`
 }
 
 func Cmd() *cobra.Command {
@@ -30,15 +48,20 @@ func Cmd() *cobra.Command {
 		Use:         "http",
 		Short:       "Make HTTP requests (like curl)",
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds:     []*cobra.Command{sessionCmd()},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			if params.URL == "" {
+			if params.URL == "" && params.Replay == "" {
 				_ = cmd.Usage()
 				os.Exit(1)
 			}
 			// Auto-detect URL scheme if missing
-			if !strings.HasPrefix(params.URL, "http://") && !strings.HasPrefix(params.URL, "https://") {
+			if params.URL != "" && !strings.HasPrefix(params.URL, "http://") && !strings.HasPrefix(params.URL, "https://") {
 				params.URL = "http://" + params.URL
 			}
+			// A replayed request keeps its method unless -X is given
+			if params.Replay != "" && !cmd.Flags().Changed("method") {
+				params.Method = ""
+			}
 
 			if err := runHttp(params, os.Stdout, os.Stderr); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -49,19 +72,96 @@ func Cmd() *cobra.Command {
 }
 
 func runHttp(params *Params, stdout, stderr io.Writer) error {
-	var body io.Reader
-	if params.Data != "" {
-		body = strings.NewReader(params.Data)
-		// If method is default (GET) and we have data, switch to POST
-		if params.Method == "GET" || params.Method == "" {
-			params.Method = "POST"
+	if len(params.URLForm) > 0 && len(params.Form) > 0 {
+		return fmt.Errorf("-f and -F cannot be combined")
+	}
+	if params.Data != "" && (len(params.URLForm) > 0 || len(params.Form) > 0) {
+		return fmt.Errorf("-d cannot be combined with -f or -F")
+	}
+	if params.Session != "" && params.CookieJar != "" {
+		return fmt.Errorf("--session cannot be combined with --cookie-jar")
+	}
+	// The body of -d and -f is kept in params.Data, so that it's logged
+	// and replayed as sent
+	var contentType string
+	if path, ok := strings.CutPrefix(params.Data, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading -d file: %w", err)
 		}
+		params.Data, contentType = string(data), mime.TypeByExtension(filepath.Ext(path))
+	}
+	if len(params.URLForm) > 0 {
+		encoded, err := encodeURLForm(params.URLForm)
+		if err != nil {
+			return err
+		}
+		params.Data, contentType = encoded, "application/x-www-form-urlencoded"
+	}
+
+	if params.Replay != "" {
+		entry, err := loadHistoryEntry(params.Replay, params.ReplayEntry)
+		if err != nil {
+			return err
+		}
+		applyReplay(params, entry, stderr)
+	}
+
+	if params.Retry < 0 || params.RetryDelay < 0 {
+		return fmt.Errorf("--retry and --retry-delay must not be negative")
+	}
+	retryOn := defaultRetryOn
+	if len(params.RetryOn) > 0 {
+		retryOn = params.RetryOn
+	}
+	retryStatuses, err := parseStatusSet(retryOn)
+	if err != nil {
+		return err
+	}
+
+	var fields []formField
+	var boundary string
+	if len(params.Form) > 0 {
+		if fields, err = parseFormFields(params.Form); err != nil {
+			return err
+		}
+		// The same boundary is used for retries, so the Content-Type holds
+		boundary = multipart.NewWriter(io.Discard).Boundary()
+		contentType = "multipart/form-data; boundary=" + boundary
+	}
+	// newBody makes the request body and its length, again for each retry
+	// and redirect
+	newBody := func() (io.ReadCloser, int64, error) {
+		if params.Data != "" {
+			return io.NopCloser(strings.NewReader(params.Data)), int64(len(params.Data)), nil
+		}
+		if fields != nil {
+			return multipartBody(fields, boundary)
+		}
+		return nil, 0, nil
+	}
+
+	body, length, err := newBody()
+	if err != nil {
+		return err
+	}
+	// If method is default (GET) and we have data, switch to POST
+	if body != nil && (params.Method == "GET" || params.Method == "") {
+		params.Method = "POST"
 	}
 
 	req, err := nethttp.NewRequest(params.Method, params.URL, body)
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
 	}
+	req.ContentLength = length
+	if body != nil {
+		// Lets 307 and 308 redirects send the body again
+		req.GetBody = func() (io.ReadCloser, error) {
+			b, _, err := newBody()
+			return b, err
+		}
+	}
 
 	// Set headers
 	for _, h := range params.Headers {
@@ -70,6 +170,24 @@ func runHttp(params *Params, stdout, stderr io.Writer) error {
 			req.Header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
 		}
 	}
+	var sess *session
+	if params.Session != "" {
+		if sess, err = loadSession(params.Session); err != nil {
+			return err
+		}
+		applySession(sess, req, params.Headers)
+	}
+	if err := applyBodyHeaders(req, contentType, boundary); err != nil {
+		return err
+	}
+
+	cookies, err := parseCookies(params.Cookies)
+	if err != nil {
+		return err
+	}
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
 
 	// Default User-Agent if not set
 	if req.Header.Get("User-Agent") == "" {
@@ -87,6 +205,24 @@ func runHttp(params *Params, stdout, stderr io.Writer) error {
 		},
 	}
 
+	var jar *fileJar
+	if params.CookieJar != "" {
+		if jar, err = loadCookieJar(params.CookieJar); err != nil {
+			return err
+		}
+		client.Jar = jar
+	}
+	if sess != nil {
+		if jar, err = newJar(""); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, c := range sess.Cookies {
+			jar.add(c, now)
+		}
+		client.Jar = jar
+	}
+
 	if params.Insecure {
 		tr := &nethttp.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
@@ -104,12 +240,70 @@ func runHttp(params *Params, stdout, stderr io.Writer) error {
 		fmt.Fprintln(stderr, ">")
 	}
 
+	// With --log, an entry is written however the request ends
+	var entry *historyEntry
+	var capture bodyCapture
+	if params.Log != "" {
+		entry = &historyEntry{Time: time.Now(), Request: newHistoryRequest(req, params.Data, params.Form, params.LogSecrets)}
+	}
+	logged := func(resp *nethttp.Response, err error) error {
+		if entry == nil {
+			return err
+		}
+		if resp != nil {
+			entry.Response = newHistoryResponse(resp, &capture)
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		if logErr := appendHistory(params.Log, *entry); logErr != nil && err == nil {
+			return logErr
+		}
+		return err
+	}
+
 	resp, err := client.Do(req)
+	for attempt := 1; attempt <= params.Retry; attempt++ {
+		var reason, retryAfter string
+		if err != nil {
+			reason = err.Error()
+		} else if retryStatuses.matches(resp.StatusCode) {
+			reason, retryAfter = resp.Status, resp.Header.Get("Retry-After")
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			break
+		}
+
+		baseDelay := time.Duration(params.RetryDelay * float64(time.Second))
+		delay := retryDelay(baseDelay, attempt, retryAfter, time.Now(), rand.Float64())
+		fmt.Fprintf(stderr, "Attempt %d/%d failed: %s, retrying in %v\n", attempt, params.Retry+1, reason, delay.Round(time.Millisecond))
+		time.Sleep(delay)
+
+		retryBody, _, bodyErr := newBody()
+		if bodyErr != nil {
+			return logged(nil, bodyErr)
+		}
+		req = req.Clone(req.Context())
+		req.Body = retryBody
+		resp, err = client.Do(req)
+	}
 	if err != nil {
-		return fmt.Errorf("performing request: %w", err)
+		return logged(nil, fmt.Errorf("performing request: %w", err))
 	}
 	defer resp.Body.Close()
 
+	if sess != nil {
+		sess.Cookies = jar.unexpired(time.Now())
+		if err := saveSession(params.Session, sess); err != nil {
+			return logged(resp, err)
+		}
+	} else if jar != nil {
+		if err := jar.save(); err != nil {
+			return logged(resp, err)
+		}
+	}
+
 	if params.Verbose {
 		// Print TLS details if available
 		if resp.TLS != nil {
@@ -135,16 +329,64 @@ func runHttp(params *Params, stdout, stderr io.Writer) error {
 	if params.OutputFile != "" {
 		f, err := os.Create(params.OutputFile)
 		if err != nil {
-			return fmt.Errorf("creating output file: %w", err)
+			return logged(resp, fmt.Errorf("creating output file: %w", err))
 		}
 		defer f.Close()
 		out = f
 	}
 
-	_, err = io.Copy(out, resp.Body)
+	var respBody io.Reader = resp.Body
+	if entry != nil {
+		respBody = io.TeeReader(resp.Body, &capture)
+	}
+	_, err = io.Copy(out, respBody)
 	if err != nil {
-		return fmt.Errorf("reading response body: %w", err)
+		return logged(resp, fmt.Errorf("reading response body: %w", err))
+	}
+
+	return logged(resp, nil)
+}
+
+// applyBodyHeaders sets the Content-Type of the body unless -H gave one. A
+// multipart Content-Type from -H gets the boundary of the body, which it
+// must match. A Content-Length from -H replaces the computed one.
+func applyBodyHeaders(req *nethttp.Request, contentType, boundary string) error {
+	userType := req.Header.Get("Content-Type")
+	switch {
+	case userType == "":
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+	case boundary != "":
+		mediaType, mediaParams, err := mime.ParseMediaType(userType)
+		if err != nil {
+			return fmt.Errorf("invalid Content-Type header %q: %w", userType, err)
+		}
+		mediaParams["boundary"] = boundary
+		req.Header.Set("Content-Type", mime.FormatMediaType(mediaType, mediaParams))
 	}
 
+	if v := req.Header.Get("Content-Length"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid Content-Length header %q", v)
+		}
+		// The client only sends the length it's given in the request
+		req.ContentLength = n
+		req.Header.Del("Content-Length")
+	}
 	return nil
 }
+
+// encodeURLForm encodes -f fields in the order given.
+func encodeURLForm(args []string) (string, error) {
+	var pairs []string
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return "", fmt.Errorf("invalid form field %q: expected name=value", arg)
+		}
+		pairs = append(pairs, url.QueryEscape(name)+"="+url.QueryEscape(value))
+	}
+	return strings.Join(pairs, "&"), nil
+}
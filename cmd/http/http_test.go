@@ -2,10 +2,20 @@ package http
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	nethttp "net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRunHttp(t *testing.T) {
@@ -69,3 +79,493 @@ func TestRunHttp(t *testing.T) {
 		})
 	}
 }
+
+func TestRunHttp_CookieJar(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/login":
+			nethttp.SetCookie(w, &nethttp.Cookie{Name: "session", Value: "abc123", Path: "/", HttpOnly: true})
+			nethttp.SetCookie(w, &nethttp.Cookie{Name: "theme", Value: "dark", Path: "/", MaxAge: 3600})
+			w.Write([]byte("logged in"))
+		case "/logout":
+			nethttp.SetCookie(w, &nethttp.Cookie{Name: "session", Path: "/", MaxAge: -1})
+			w.Write([]byte("logged out"))
+		default:
+			var names []string
+			for _, c := range r.Cookies() {
+				names = append(names, c.Name+"="+c.Value)
+			}
+			sort.Strings(names)
+			w.Write([]byte(strings.Join(names, "; ")))
+		}
+	}))
+	defer server.Close()
+
+	jarFile := filepath.Join(t.TempDir(), "cookies.txt")
+	request := func(path string, cookies ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		params := &Params{URL: server.URL + path, CookieJar: jarFile, Cookies: cookies}
+		if err := runHttp(params, &stdout, &stderr); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return stdout.String()
+	}
+
+	// No jar file yet
+	if got := request("/me"); got != "" {
+		t.Errorf("Expected no cookies, got %q", got)
+	}
+
+	request("/login")
+	data, err := os.ReadFile(jarFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	host = host[:strings.LastIndex(host, ":")]
+	if !strings.Contains(string(data), "#HttpOnly_"+host+"\tFALSE\t/\tFALSE\t0\tsession\tabc123\n") {
+		t.Errorf("Expected session cookie in jar, got:\n%s", data)
+	}
+
+	// The next invocation sends the saved cookies, along with -b ones
+	if got := request("/me", "lang=en; tz=UTC"); got != "lang=en; session=abc123; theme=dark; tz=UTC" {
+		t.Errorf("Expected cookies from jar and -b, got %q", got)
+	}
+
+	// Deleted cookies are removed from the jar, -b ones are never saved
+	request("/logout")
+	if got := request("/me"); got != "theme=dark" {
+		t.Errorf("Expected session cookie to be deleted, got %q", got)
+	}
+}
+
+func TestParseCookies(t *testing.T) {
+	cookies, err := parseCookies([]string{"a=1", "b=2; c=x=y"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var got []string
+	for _, c := range cookies {
+		got = append(got, c.Name+"="+c.Value)
+	}
+	if strings.Join(got, ",") != "a=1,b=2,c=x=y" {
+		t.Errorf("Expected %q, got %q", "a=1,b=2,c=x=y", got)
+	}
+
+	if _, err := parseCookies([]string{"novalue"}); err == nil {
+		t.Errorf("Expected error for cookie without value")
+	}
+}
+
+func TestFileJarDomain(t *testing.T) {
+	jar, err := newJar(filepath.Join(t.TempDir(), "cookies.txt"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	u, _ := url.Parse("http://www.evil.com/login")
+	jar.SetCookies(u, []*nethttp.Cookie{
+		{Name: "planted", Value: "1", Domain: "bank.com", Path: "/"},
+		{Name: "suffix", Value: "2", Domain: "il.com", Path: "/"},
+		{Name: "parent", Value: "3", Domain: ".evil.com", Path: "/"},
+		{Name: "host", Value: "4", Path: "/"},
+	})
+
+	var got []string
+	for _, e := range jar.unexpired(time.Now()) {
+		got = append(got, fmt.Sprintf("%s=%s@%s/%v", e.Name, e.Value, e.Domain, e.IncludeSubdomains))
+	}
+	if want := "parent=3@evil.com/true,host=4@www.evil.com/false"; strings.Join(got, ",") != want {
+		t.Errorf("Expected only cookies the jar accepts to be saved, want %q, got %q", want, got)
+	}
+	bank, _ := url.Parse("http://bank.com/")
+	if cookies := jar.Cookies(bank); len(cookies) != 0 {
+		t.Errorf("Expected no cookies for bank.com, got %v", cookies)
+	}
+}
+
+func TestRunHttp_LogAndReplay(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Served", "yes")
+		fmt.Fprintf(w, "%s %s body=%s auth=%s test=%s", r.Method, r.URL.Path, body, r.Header.Get("Authorization"), r.Header.Get("X-Test"))
+	}))
+	defer server.Close()
+
+	logFile := filepath.Join(t.TempDir(), "history.jsonl")
+	request := func(params *Params) (string, string) {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if err := runHttp(params, &stdout, &stderr); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return stdout.String(), stderr.String()
+	}
+
+	request(&Params{URL: server.URL + "/users", Method: "PUT", Data: `{"name":"a"}`, Log: logFile,
+		Headers: []string{"Authorization: Bearer secret", "X-Test: one"}, Cookies: []string{"sid=abc123"}})
+	request(&Params{URL: server.URL + "/other", Log: logFile, LogSecrets: true,
+		Headers: []string{"Authorization: Bearer kept"}})
+
+	info, err := os.Stat(logFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("Expected log file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	entry, err := loadHistoryEntry(logFile, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if entry.Request.Method != "PUT" || entry.Request.URL != server.URL+"/users" || entry.Request.Body != `{"name":"a"}` {
+		t.Errorf("Unexpected logged request: %+v", entry.Request)
+	}
+	if got := entry.Request.Headers.Get("Authorization"); got != "REDACTED" {
+		t.Errorf("Expected Authorization to be redacted, got %q", got)
+	}
+	if got := entry.Request.Headers.Get("Cookie"); got != "REDACTED" {
+		t.Errorf("Expected Cookie to be redacted, got %q", got)
+	}
+	if got := entry.Request.Headers.Get("X-Test"); got != "one" {
+		t.Errorf("Expected X-Test header to be logged, got %q", got)
+	}
+	resp := entry.Response
+	if resp == nil || resp.Status != 200 || resp.Headers.Get("X-Served") != "yes" ||
+		resp.Body != `PUT /users body={"name":"a"} auth=Bearer secret test=one` {
+		t.Errorf("Unexpected logged response: %+v", resp)
+	}
+
+	last, err := loadHistoryEntry(logFile, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := last.Request.Headers.Get("Authorization"); got != "Bearer kept" {
+		t.Errorf("Expected Authorization to be kept with --log-secrets, got %q", got)
+	}
+
+	// Replaying the last entry by default
+	if got, _ := request(&Params{Replay: logFile}); got != "GET /other body= auth=Bearer kept test=" {
+		t.Errorf("Unexpected replay of last entry: %q", got)
+	}
+
+	// Redacted headers are dropped with a warning, unless given again
+	got, stderr := request(&Params{Replay: logFile, ReplayEntry: 1})
+	if got != `PUT /users body={"name":"a"} auth= test=one` || !strings.Contains(stderr, "Authorization header was redacted") {
+		t.Errorf("Unexpected replay of redacted entry: %q, stderr %q", got, stderr)
+	}
+	got, _ = request(&Params{Replay: logFile, ReplayEntry: 1, Headers: []string{"Authorization: Bearer again", "x-test: two"}})
+	if got != `PUT /users body={"name":"a"} auth=Bearer again test=two` {
+		t.Errorf("Expected -H to override logged headers, got %q", got)
+	}
+
+	// The command line overrides the logged URL, method and body
+	got, _ = request(&Params{Replay: logFile, ReplayEntry: 1, URL: server.URL + "/staging", Method: "POST", Data: "new"})
+	if got != "POST /staging body=new auth= test=one" {
+		t.Errorf("Expected command line to override the logged request, got %q", got)
+	}
+
+	var stdout, stderr2 bytes.Buffer
+	if err := runHttp(&Params{Replay: logFile, ReplayEntry: 9}, &stdout, &stderr2); err == nil || !strings.Contains(err.Error(), "has only 2 entries") {
+		t.Errorf("Expected error for a missing entry, got %v", err)
+	}
+}
+
+func TestRunHttp_LogFailedRequest(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "history.jsonl")
+	var stdout, stderr bytes.Buffer
+	err := runHttp(&Params{URL: "http://127.0.0.1:1/unreachable", Log: logFile}, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("Expected request to fail")
+	}
+
+	entry, err := loadHistoryEntry(logFile, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if entry.Response != nil || !strings.Contains(entry.Error, "performing request") {
+		t.Errorf("Expected the error to be logged without a response, got %+v", entry)
+	}
+}
+
+func TestRunHttp_Form(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			nethttp.Error(w, err.Error(), nethttp.StatusBadRequest)
+			return
+		}
+		// The streamed body's length is computed up front
+		fmt.Fprintf(w, "%s length-ok=%v title=%v\n", r.Method, r.ContentLength == int64(len(body)), r.MultipartForm.Value["title"])
+		for _, name := range []string{"doc", "img", "sniffed"} {
+			for _, fh := range r.MultipartForm.File[name] {
+				f, _ := fh.Open()
+				data, _ := io.ReadAll(f)
+				f.Close()
+				fmt.Fprintf(w, "%s: %s %s %s\n", name, fh.Filename, fh.Header.Get("Content-Type"), data)
+			}
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	docPath := filepath.Join(dir, "report.txt")
+	imgPath := filepath.Join(dir, "pic.bin")
+	if err := os.WriteFile(docPath, []byte("report contents"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(imgPath, []byte{0, 1, 2}, 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Without a known extension, the type is detected from the contents
+	gifPath := filepath.Join(dir, "logo")
+	if err := os.WriteFile(gifPath, []byte("GIF89a"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	params := &Params{
+		URL:    server.URL,
+		Method: "GET",
+		Form: []string{"title=hello world", "title=second", "doc=@" + docPath,
+			"img=@" + imgPath + ";type=image/png;filename=upload.png", "sniffed=@" + gifPath},
+	}
+	if err := runHttp(params, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "POST length-ok=true title=[hello world second]\n" +
+		"doc: report.txt text/plain; charset=utf-8 report contents\n" +
+		"img: upload.png image/png \x00\x01\x02\n" +
+		"sniffed: logo image/gif GIF89a\n"
+	if got := stdout.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestRunHttp_FormRedirect(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path == "/old" {
+			nethttp.Redirect(w, r, "/new", nethttp.StatusTemporaryRedirect)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			nethttp.Error(w, err.Error(), nethttp.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "%s %s a=%v", r.Method, r.URL.Path, r.MultipartForm.Value["a"])
+	}))
+	defer server.Close()
+
+	// A 307 repeats the request, body included
+	var stdout, stderr bytes.Buffer
+	params := &Params{URL: server.URL + "/old", Form: []string{"a=b"}, FollowRedirects: true}
+	if err := runHttp(params, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, want := stdout.String(), "POST /new a=[b]"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestRunHttp_FormErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	tests := []struct {
+		name   string
+		params Params
+		errMsg string
+	}{
+		{"with data", Params{URL: "http://localhost:1", Data: "x", Form: []string{"a=b"}}, "cannot be combined"},
+		{"data with url form", Params{URL: "http://localhost:1", Data: "x", URLForm: []string{"a=b"}}, "-d cannot be combined with -f or -F"},
+		{"both forms", Params{URL: "http://localhost:1", URLForm: []string{"a=b"}, Form: []string{"a=b"}}, "-f and -F cannot be combined"},
+		{"url form no value", Params{URL: "http://localhost:1", URLForm: []string{"a"}}, "expected name=value"},
+		{"missing data file", Params{URL: "http://localhost:1", Data: "@" + missing}, "reading -d file"},
+		{"bad content length", Params{URL: "http://localhost:1", Data: "x", Headers: []string{"Content-Length: lots"}}, "invalid Content-Length"},
+		{"no value", Params{URL: "http://localhost:1", Form: []string{"field"}}, "expected name=value"},
+		{"missing file", Params{URL: "http://localhost:1", Form: []string{"doc=@" + missing}}, "form field doc"},
+		{"directory", Params{URL: "http://localhost:1", Form: []string{"doc=@" + t.TempDir()}}, "is a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := runHttp(&tt.params, &stdout, &stderr)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestRunHttp_FormContentTypeOverride(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		mediaType, mediaParams, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			nethttp.Error(w, err.Error(), nethttp.StatusBadRequest)
+			return
+		}
+		mr := multipart.NewReader(r.Body, mediaParams["boundary"])
+		fmt.Fprintf(w, "%s:", mediaType)
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(part)
+			fmt.Fprintf(w, " %s=%s", part.FormName(), data)
+		}
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	params := &Params{URL: server.URL, Headers: []string{"Content-Type: multipart/related; boundary=stale"}, Form: []string{"a=1", "b=2"}}
+	if err := runHttp(params, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "multipart/related: a=1 b=2"; stdout.String() != want {
+		t.Errorf("Expected %q, got %q", want, stdout.String())
+	}
+}
+
+func TestRunHttp_Body(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s type=%q length=%d body=%s", r.Method, r.Header.Get("Content-Type"), r.ContentLength, body)
+	}))
+	defer server.Close()
+
+	jsonPath := filepath.Join(t.TempDir(), "payload.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"a": 1}`+"\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		params Params
+		want   string
+	}{
+		{
+			name:   "data from file",
+			params: Params{URL: server.URL, Data: "@" + jsonPath},
+			want:   "POST type=\"application/json\" length=9 body={\"a\": 1}\n",
+		},
+		{
+			name:   "url form",
+			params: Params{URL: server.URL, URLForm: []string{"q=tofu & more", "page=2", "q=again"}},
+			want:   "POST type=\"application/x-www-form-urlencoded\" length=30 body=q=tofu+%26+more&page=2&q=again",
+		},
+		{
+			name:   "headers override",
+			params: Params{URL: server.URL, Method: "PUT", Data: "@" + jsonPath, Headers: []string{"Content-Type: text/plain", "Content-Length: 9"}},
+			want:   "PUT type=\"text/plain\" length=9 body={\"a\": 1}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := runHttp(&tt.params, &stdout, &stderr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunHttp_Retry(t *testing.T) {
+	var attempts int
+	var bodies []string
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch {
+		case r.URL.Path == "/missing":
+			nethttp.Error(w, "not found", nethttp.StatusNotFound)
+		case r.URL.Path == "/down" || attempts < 3:
+			w.Header().Set("Retry-After", "0")
+			nethttp.Error(w, "unavailable", nethttp.StatusServiceUnavailable)
+		default:
+			fmt.Fprintf(w, "ok after %d", attempts)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		params       Params
+		wantStdout   string
+		wantAttempts int
+	}{
+		{"recovers", Params{URL: server.URL, Data: "payload", Retry: 3}, "ok after 3", 3},
+		{"gives up", Params{URL: server.URL + "/down", Retry: 2}, "unavailable\n", 3},
+		{"not retryable", Params{URL: server.URL + "/missing", Retry: 2}, "not found\n", 1},
+		{"retry on", Params{URL: server.URL + "/missing", Retry: 1, RetryOn: []string{"404"}}, "not found\n", 2},
+		{"no retry", Params{URL: server.URL, Method: "GET"}, "unavailable\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts, bodies = 0, nil
+			var stdout, stderr bytes.Buffer
+			if err := runHttp(&tt.params, &stdout, &stderr); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := stdout.String(); got != tt.wantStdout {
+				t.Errorf("Expected %q, got %q", tt.wantStdout, got)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+			if n := strings.Count(stderr.String(), "retrying in"); n != tt.wantAttempts-1 {
+				t.Errorf("Expected %d retry messages, got:\n%s", tt.wantAttempts-1, stderr.String())
+			}
+			for i, body := range bodies {
+				if body != tt.params.Data {
+					t.Errorf("Expected attempt %d to send %q, got %q", i+1, tt.params.Data, body)
+				}
+			}
+		})
+	}
+}
+
+func TestRunHttp_RetryForm(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			nethttp.Error(w, err.Error(), nethttp.StatusBadRequest)
+			return
+		}
+		got = append(got, r.MultipartForm.Value["a"]...)
+		if len(got) == 1 {
+			w.WriteHeader(nethttp.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	params := &Params{URL: server.URL, Form: []string{"a=b"}, Retry: 1, RetryDelay: 0.001}
+	if err := runHttp(params, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "b" || got[1] != "b" {
+		t.Errorf("Expected the form to be sent twice, got %v", got)
+	}
+}
+
+func TestRunHttp_RetryConnectionError(t *testing.T) {
+	server := httptest.NewServer(nethttp.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	var stdout, stderr bytes.Buffer
+	err := runHttp(&Params{URL: url, Retry: 2, RetryDelay: 0.001}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "performing request") {
+		t.Errorf("Expected a request error, got %v", err)
+	}
+	if n := strings.Count(stderr.String(), "Attempt "); n != 2 {
+		t.Errorf("Expected 2 retry messages, got:\n%s", stderr.String())
+	}
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	nethttp "net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// formField is a -F argument: name=value, or name=@path to upload a file.
+// Like curl, a file can be given ;type= and ;filename= options after the
+// path, e.g. -F "doc=@report.txt;type=text/plain;filename=r.txt".
+type formField struct {
+	name        string
+	value       string
+	path        string
+	filename    string
+	contentType string
+}
+
+func parseFormFields(args []string) ([]formField, error) {
+	var fields []formField
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid form field %q: expected name=value or name=@file", arg)
+		}
+		path, isFile := strings.CutPrefix(value, "@")
+		if !isFile {
+			fields = append(fields, formField{name: name, value: value})
+			continue
+		}
+
+		field := formField{name: name}
+		parts := strings.Split(path, ";")
+		path = parts[0]
+		for _, opt := range parts[1:] {
+			if v, ok := strings.CutPrefix(opt, "type="); ok {
+				field.contentType = v
+			} else if v, ok := strings.CutPrefix(opt, "filename="); ok {
+				field.filename = v
+			} else {
+				// Not an option, so part of the file name
+				path += ";" + opt
+			}
+		}
+		if path == "" {
+			return nil, fmt.Errorf("invalid form field %q: missing file name after @", arg)
+		}
+		// Fail before sending anything if a file can't be read
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("form field %s: %w", name, err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("form field %s: %s is a directory", name, path)
+		}
+		field.path = path
+		if field.filename == "" {
+			field.filename = filepath.Base(path)
+		}
+		if field.contentType == "" {
+			field.contentType = mime.TypeByExtension(filepath.Ext(path))
+		}
+		if field.contentType == "" {
+			if field.contentType, err = sniffContentType(path); err != nil {
+				return nil, fmt.Errorf("form field %s: %w", name, err)
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// sniffContentType detects the type of a file without a known extension
+// from its first bytes, like a browser does.
+func sniffContentType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	if n == 0 {
+		return "application/octet-stream", nil
+	}
+	return nethttp.DetectContentType(head[:n]), nil
+}
+
+// multipartBody returns a multipart/form-data body of fields with the given
+// boundary, and its length. The body is written by a goroutine as it is
+// read, so files are streamed rather than held in memory, and the length is
+// computed up front from the file sizes. Closing the body before it is fully
+// read stops the goroutine and closes the file being sent.
+func multipartBody(fields []formField, boundary string) (io.ReadCloser, int64, error) {
+	var counter countingWriter
+	cw := multipart.NewWriter(&counter)
+	if err := cw.SetBoundary(boundary); err != nil {
+		return nil, 0, err
+	}
+	if err := writeFormFields(cw, fields, false); err != nil {
+		return nil, 0, err
+	}
+	if err := cw.Close(); err != nil {
+		return nil, 0, err
+	}
+	length := counter.n
+	for _, f := range fields {
+		if f.path == "" {
+			continue
+		}
+		info, err := os.Stat(f.path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("form field %s: %w", f.name, err)
+		}
+		length += info.Size()
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return nil, 0, err
+	}
+	go func() {
+		err := writeFormFields(mw, fields, true)
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, length, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// writeFormFields writes the parts of fields, leaving out the contents of
+// files unless withFiles is set.
+func writeFormFields(mw *multipart.Writer, fields []formField, withFiles bool) error {
+	for _, f := range fields {
+		if f.path == "" {
+			if err := mw.WriteField(f.name, f.value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(f.name), quoteEscaper.Replace(f.filename)))
+		h.Set("Content-Type", f.contentType)
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if !withFiles {
+			continue
+		}
+		file, err := os.Open(f.path)
+		if err != nil {
+			return fmt.Errorf("form field %s: %w", f.name, err)
+		}
+		_, err = io.Copy(part, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("form field %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"fmt"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRetryOn are the statuses retried when --retry-on isn't given.
+var defaultRetryOn = []string{"429", "5xx"}
+
+// maxBackoff caps the exponential backoff. A server's Retry-After is
+// respected even when longer.
+const maxBackoff = time.Minute
+
+// statusSet matches status codes given as exact codes like 503, or as
+// classes like 5xx.
+type statusSet struct {
+	codes   map[int]bool
+	classes map[int]bool
+}
+
+func parseStatusSet(specs []string) (statusSet, error) {
+	set := statusSet{codes: map[int]bool{}, classes: map[int]bool{}}
+	for _, spec := range specs {
+		for s := range strings.SplitSeq(spec, ",") {
+			s = strings.ToLower(strings.TrimSpace(s))
+			if class, ok := strings.CutSuffix(s, "xx"); ok && len(class) == 1 && class[0] >= '1' && class[0] <= '5' {
+				set.classes[int(class[0]-'0')] = true
+				continue
+			}
+			code, err := strconv.Atoi(s)
+			if err != nil || code < 100 || code > 599 {
+				return statusSet{}, fmt.Errorf("invalid --retry-on status %q: expected a code like 503 or a class like 5xx", s)
+			}
+			set.codes[code] = true
+		}
+	}
+	return set, nil
+}
+
+func (s statusSet) matches(code int) bool {
+	return s.codes[code] || s.classes[code/100]
+}
+
+// retryDelay is how long to wait before retry number attempt, counting
+// from 1. It is base doubled for each earlier retry, capped at maxBackoff,
+// and spread by jitter, a number in [0, 1), to between half and one and a
+// half times that. A Retry-After header, in seconds or as a date, is used
+// instead when present.
+func retryDelay(base time.Duration, attempt int, retryAfter string, now time.Time, jitter float64) time.Duration {
+	if d, ok := parseRetryAfter(retryAfter, now); ok {
+		return d
+	}
+	d := base
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxBackoff)
+	return d/2 + time.Duration(jitter*float64(d))
+}
+
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := nethttp.ParseTime(value); err == nil {
+		return max(0, t.Sub(now)), true
+	}
+	return 0, false
+}
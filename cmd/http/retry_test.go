@@ -0,0 +1,53 @@
+package http
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseStatusSet(t *testing.T) {
+	set, err := parseStatusSet([]string{"429", "5xx,408"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for code, want := range map[int]bool{429: true, 408: true, 500: true, 503: true, 599: true, 404: false, 200: false, 430: false} {
+		if got := set.matches(code); got != want {
+			t.Errorf("Expected matches(%d) = %v, got %v", code, want, got)
+		}
+	}
+
+	for _, bad := range []string{"abc", "6xx", "99", "600", "x5xx"} {
+		if _, err := parseStatusSet([]string{bad}); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		jitter     float64
+		want       time.Duration
+	}{
+		{"first, no jitter", 1, "", 0.5, time.Second},
+		{"second, no jitter", 2, "", 0.5, 2 * time.Second},
+		{"third, low jitter", 3, "", 0, 2 * time.Second},
+		{"third, high jitter", 3, "", 0.99, 5960 * time.Millisecond},
+		{"capped", 20, "", 0.5, time.Minute},
+		{"retry-after seconds", 1, "7", 0.5, 7 * time.Second},
+		{"retry-after longer than cap", 20, "120", 0.5, 2 * time.Minute},
+		{"retry-after date", 1, "Fri, 16 Oct 2026 12:00:30 GMT", 0.5, 30 * time.Second},
+		{"retry-after date passed", 1, "Fri, 16 Oct 2026 11:00:00 GMT", 0.5, 0},
+		{"retry-after invalid", 1, "soon", 0.5, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(time.Second, tt.attempt, tt.retryAfter, now, tt.jitter); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+var sessionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// session is a --session file: the cookies the server set, and the headers
+// given with -H, sent with every request of the session.
+type session struct {
+	Headers nethttp.Header `json:"headers,omitempty"`
+	Cookies []cookieEntry  `json:"cookies,omitempty"`
+}
+
+// unsessionedHeaders are -H headers that only make sense for one request,
+// so they aren't stored in a session. Cookies are kept in the jar instead.
+var unsessionedHeaders = []string{"Content-Type", "Content-Length", "Content-Encoding", "Cookie"}
+
+func sessionsDir() string {
+	return filepath.Join(common.ConfigDir(), "http", "sessions")
+}
+
+func sessionPath(name string) (string, error) {
+	if !sessionNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid session name %q, use letters, digits, '.', '_' and '-'", name)
+	}
+	return filepath.Join(sessionsDir(), name+".json"), nil
+}
+
+// loadSession reads a session. A missing file gives an empty session, so the
+// first request creates it.
+func loadSession(name string) (*session, error) {
+	path, err := sessionPath(name)
+	if err != nil {
+		return nil, err
+	}
+	s := &session{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("reading session %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// saveSession writes the session atomically. The file is created with mode
+// 0600 in a 0700 directory, as sessions hold credentials.
+func saveSession(name string, s *session) error {
+	path, err := sessionPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	if err := common.WriteJSONFile(path, s); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	return nil
+}
+
+// applySession adds the session's headers to req, except those given with
+// -H, and then stores the -H headers in the session for later requests.
+func applySession(s *session, req *nethttp.Request, given []string) {
+	override := map[string]bool{}
+	for _, h := range given {
+		if name, _, ok := strings.Cut(h, ":"); ok {
+			override[nethttp.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+	for name, values := range s.Headers {
+		if override[name] {
+			continue
+		}
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+
+	if s.Headers == nil {
+		s.Headers = nethttp.Header{}
+	}
+	for name := range override {
+		if slices.Contains(unsessionedHeaders, name) || strings.HasPrefix(name, "If-") {
+			continue
+		}
+		s.Headers[name] = req.Header.Values(name)
+	}
+}
+
+type SessionShowParams struct {
+	Name    string `pos:"true" help:"Name of the session."`
+	Secrets bool   `optional:"true" help:"Show Authorization headers and cookie values instead of redacting them."`
+}
+
+type SessionDeleteParams struct {
+	Name string `pos:"true" help:"Name of the session."`
+}
+
+type SessionListParams struct{}
+
+func sessionCmd() *cobra.Command {
+	return boa.CmdT[boa.NoParams]{
+		Use:         "session",
+		Short:       "Manage --session files",
+		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds: []*cobra.Command{
+			sessionListCmd(),
+			sessionShowCmd(),
+			sessionDeleteCmd(),
+		},
+	}.ToCobra()
+}
+
+func sessionListCmd() *cobra.Command {
+	return boa.CmdT[SessionListParams]{
+		Use:         "list",
+		Short:       "List stored sessions",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *SessionListParams, cmd *cobra.Command, args []string) {
+			if err := runSessionList(os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func sessionShowCmd() *cobra.Command {
+	return boa.CmdT[SessionShowParams]{
+		Use:         "show <name>",
+		Short:       "Show the headers and cookies of a session",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *SessionShowParams, cmd *cobra.Command, args []string) {
+			if err := runSessionShow(params, time.Now(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func sessionDeleteCmd() *cobra.Command {
+	return boa.CmdT[SessionDeleteParams]{
+		Use:         "delete <name>",
+		Short:       "Delete a session",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *SessionDeleteParams, cmd *cobra.Command, args []string) {
+			if err := runSessionDelete(params, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func runSessionList(stdout io.Writer) error {
+	entries, err := os.ReadDir(sessionsDir())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	count := 0
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() || !sessionNamePattern.MatchString(name) {
+			continue
+		}
+		s, err := loadSession(name)
+		if err != nil {
+			return err
+		}
+		var modified string
+		if info, err := e.Info(); err == nil {
+			modified = info.ModTime().Format("2006-01-02 15:04")
+		}
+		if count == 0 {
+			fmt.Fprintln(w, "NAME\tHEADERS\tCOOKIES\tMODIFIED")
+		}
+		count++
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", name, len(s.Headers), len(s.Cookies), modified)
+	}
+	if count == 0 {
+		fmt.Fprintln(stdout, "No sessions")
+		return nil
+	}
+	return w.Flush()
+}
+
+func runSessionShow(params *SessionShowParams, now time.Time, stdout io.Writer) error {
+	path, err := sessionPath(params.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("no session named %q", params.Name)
+	}
+	s, err := loadSession(params.Name)
+	if err != nil {
+		return err
+	}
+
+	headers := s.Headers
+	if !params.Secrets {
+		headers = redactHeaders(headers)
+	}
+	fmt.Fprintf(stdout, "Session %s (%s)\n", params.Name, path)
+	fmt.Fprintln(stdout, "\nHeaders:")
+	if len(headers) == 0 {
+		fmt.Fprintln(stdout, "  (none)")
+	}
+	names := slices.Sorted(func(yield func(string) bool) {
+		for name := range headers {
+			if !yield(name) {
+				return
+			}
+		}
+	})
+	for _, name := range names {
+		for _, v := range headers[name] {
+			fmt.Fprintf(stdout, "  %s: %s\n", name, v)
+		}
+	}
+
+	fmt.Fprintln(stdout, "\nCookies:")
+	if len(s.Cookies) == 0 {
+		fmt.Fprintln(stdout, "  (none)")
+		return nil
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  DOMAIN\tPATH\tNAME\tVALUE\tEXPIRES\tFLAGS")
+	for _, c := range s.Cookies {
+		domain := c.Domain
+		if c.IncludeSubdomains {
+			domain = "." + domain
+		}
+		value := c.Value
+		if !params.Secrets {
+			value = redacted
+		}
+		expires := "session"
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Local().Format("2006-01-02 15:04")
+			if c.expired(now) {
+				expires += " (expired)"
+			}
+		}
+		var flags []string
+		if c.Secure {
+			flags = append(flags, "secure")
+		}
+		if c.HttpOnly {
+			flags = append(flags, "httponly")
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", domain, c.Path, c.Name, value, expires, strings.Join(flags, ","))
+	}
+	return w.Flush()
+}
+
+func runSessionDelete(params *SessionDeleteParams, stdout io.Writer) error {
+	path, err := sessionPath(params.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no session named %q", params.Name)
+	} else if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Deleted session %s\n", params.Name)
+	return nil
+}
@@ -0,0 +1,252 @@
+package http
+
+import (
+	"bytes"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sessionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path == "/login" {
+			nethttp.SetCookie(w, &nethttp.Cookie{Name: "sid", Value: "abc123", Path: "/api", HttpOnly: true})
+			nethttp.SetCookie(w, &nethttp.Cookie{Name: "theme", Value: "dark", Path: "/", MaxAge: 3600})
+			return
+		}
+		var names []string
+		for _, c := range r.Cookies() {
+			names = append(names, c.Name+"="+c.Value)
+		}
+		sort.Strings(names)
+		w.Write([]byte(strings.Join(names, "; ") + " auth=" + r.Header.Get("Authorization") + " type=" + r.Header.Get("Content-Type")))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func sessionRequest(t *testing.T, params *Params) string {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if err := runHttp(params, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return stdout.String()
+}
+
+func TestRunHttp_Session(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	server := sessionServer(t)
+
+	sessionRequest(t, &Params{
+		URL:     server.URL + "/login",
+		Session: "api",
+		Headers: []string{"Authorization: Bearer t0ken", "Content-Type: text/plain"},
+	})
+
+	// Cookies are sent by path, and only the -H headers that describe the
+	// client are kept
+	if got, want := sessionRequest(t, &Params{URL: server.URL + "/api/me", Session: "api"}), "sid=abc123; theme=dark auth=Bearer t0ken type="; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got, want := sessionRequest(t, &Params{URL: server.URL + "/other", Session: "api"}), "theme=dark auth=Bearer t0ken type="; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// -H overrides a session header for this request and in the session
+	sessionRequest(t, &Params{URL: server.URL + "/other", Session: "api", Headers: []string{"authorization: Bearer n3w"}})
+	if got, want := sessionRequest(t, &Params{URL: server.URL + "/other", Session: "api"}), "theme=dark auth=Bearer n3w type="; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// Other sessions are separate
+	if got, want := sessionRequest(t, &Params{URL: server.URL + "/api/me", Session: "other"}), " auth= type="; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	path, _ := sessionPath("api")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestRunHttp_SessionStoredCookies(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	server := sessionServer(t)
+	host := strings.TrimPrefix(server.URL, "http://")
+	host = host[:strings.LastIndex(host, ":")]
+
+	now := time.Now()
+	seeded := &session{Cookies: []cookieEntry{
+		{Domain: host, Path: "/", Name: "plain", Value: "1", Expires: now.Add(time.Hour)},
+		{Domain: host, Path: "/", Name: "secure", Value: "2", Secure: true},
+		{Domain: host, Path: "/", Name: "stale", Value: "3", Expires: now.Add(-time.Hour)},
+		{Domain: "example.com", Path: "/", Name: "elsewhere", Value: "4"},
+	}}
+	if err := saveSession("seeded", seeded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Expired cookies and those of other domains aren't sent. Loopback
+	// hosts count as secure, like in browsers.
+	if got, want := sessionRequest(t, &Params{URL: server.URL + "/", Session: "seeded"}), "plain=1; secure=2 auth= type="; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	stored, err := loadSession("seeded")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, c := range stored.Cookies {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, ","); got != "plain,secure,elsewhere" {
+		t.Errorf("Expected the expired cookie to be dropped, got %q", got)
+	}
+}
+
+func TestRunHttp_SessionForeignCookie(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		nethttp.SetCookie(w, &nethttp.Cookie{Name: "planted", Value: "1", Domain: "bank.com", Path: "/"})
+		nethttp.SetCookie(w, &nethttp.Cookie{Name: "own", Value: "2", Path: "/"})
+	}))
+	defer server.Close()
+
+	sessionRequest(t, &Params{URL: server.URL + "/", Session: "foreign"})
+
+	// The cookie for another domain is rejected, not stored for later runs
+	stored, err := loadSession("foreign")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, c := range stored.Cookies {
+		names = append(names, c.Name+"@"+c.Domain)
+	}
+	if got, want := strings.Join(names, ","), "own@127.0.0.1"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestSessionJarSecure(t *testing.T) {
+	jar, err := newJar("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	jar.add(cookieEntry{Domain: "example.com", Path: "/", Name: "sid", Value: "1", Secure: true}, time.Now())
+	for rawURL, want := range map[string]int{"http://example.com/": 0, "https://example.com/": 1} {
+		u, _ := url.Parse(rawURL)
+		if got := len(jar.Cookies(u)); got != want {
+			t.Errorf("%s: expected %d cookies, got %d", rawURL, want, got)
+		}
+	}
+}
+
+func TestRunHttp_SessionErrors(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	var stdout, stderr bytes.Buffer
+	err := runHttp(&Params{URL: "http://127.0.0.1:1", Session: "a", CookieJar: "cookies.txt"}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("Expected a conflict error, got %v", err)
+	}
+	for _, name := range []string{"../escape", ".hidden", "a/b", ""} {
+		if _, err := loadSession(name); err == nil {
+			t.Errorf("Expected error for session name %q", name)
+		}
+	}
+
+	path, _ := sessionPath("broken")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSession("broken"); err == nil {
+		t.Error("Expected error for a malformed session file")
+	}
+}
+
+func TestSessionCommands(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	var out bytes.Buffer
+	if err := runSessionList(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "No sessions\n"; out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+
+	expires := time.Date(2030, 1, 2, 3, 4, 0, 0, time.Local)
+	s := &session{
+		Headers: nethttp.Header{"Authorization": {"Bearer t0ken"}, "X-Team": {"blue"}},
+		Cookies: []cookieEntry{
+			{Domain: "example.com", IncludeSubdomains: true, Path: "/", Name: "sid", Value: "abc123", Secure: true, HttpOnly: true, Expires: expires},
+			{Domain: "example.com", Path: "/app", Name: "theme", Value: "dark"},
+		},
+	}
+	if err := saveSession("work", s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	out.Reset()
+	if err := runSessionList(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lines := strings.Split(out.String(), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[1], "work  2        2        ") {
+		t.Errorf("Unexpected list:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runSessionShow(&SessionShowParams{Name: "work"}, expires.AddDate(0, 0, 1), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"  Authorization: REDACTED\n  X-Team: blue\n",
+		"  .example.com  /     sid    REDACTED  2030-01-02 03:04 (expired)  secure,httponly\n",
+		"  example.com   /app  theme  REDACTED  session",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "t0ken") || strings.Contains(out.String(), "abc123") {
+		t.Errorf("Expected secrets to be redacted:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runSessionShow(&SessionShowParams{Name: "work", Secrets: true}, expires, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Bearer t0ken") || !strings.Contains(out.String(), "abc123") {
+		t.Errorf("Expected secrets with --secrets:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runSessionDelete(&SessionDeleteParams{Name: "work"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "Deleted session work\n"; out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+	if err := runSessionDelete(&SessionDeleteParams{Name: "work"}, &out); err == nil {
+		t.Error("Expected error deleting a missing session")
+	}
+	if err := runSessionShow(&SessionShowParams{Name: "work"}, expires, &out); err == nil {
+		t.Error("Expected error showing a missing session")
+	}
+}
@@ -0,0 +1,192 @@
+package ip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type CalcParams struct {
+	CIDR     string `pos:"true" help:"Address with prefix length (10.0.0.0/24, 2001:db8::/48) or netmask (10.0.0.0/255.255.255.0). A bare address is a single host."`
+	Contains string `short:"c" optional:"true" help:"Check whether this address is inside the network. Exits non-zero if it is not."`
+	Json     bool   `short:"j" help:"Output in JSON format."`
+}
+
+type CalcOutput struct {
+	Address      string   `json:"address"`
+	Network      string   `json:"network"`
+	PrefixLength int      `json:"prefix_length"`
+	Netmask      string   `json:"netmask"`
+	Hostmask     string   `json:"hostmask"`
+	Broadcast    string   `json:"broadcast,omitempty"`
+	FirstHost    string   `json:"first_host"`
+	LastHost     string   `json:"last_host"`
+	Hosts        *big.Int `json:"hosts"`
+	Contains     *bool    `json:"contains,omitempty"`
+}
+
+func calcCmd() *cobra.Command {
+	return boa.CmdT[CalcParams]{
+		Use:         "calc",
+		Short:       "Subnet calculator for IPv4 and IPv6 networks",
+		Long:        "Show the network address, netmask, broadcast address, usable host range and host count of a network given in CIDR notation, like ipcalc. With --contains, also check whether an address is inside it.",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *CalcParams, cmd *cobra.Command, args []string) {
+			os.Exit(runCalc(params, os.Stdout, os.Stderr))
+		},
+	}.ToCobra()
+}
+
+func runCalc(params *CalcParams, stdout, stderr io.Writer) int {
+	addr, prefix, err := parseNetwork(params.CIDR)
+	if err != nil {
+		fmt.Fprintf(stderr, "ip calc: %v\n", err)
+		return 1
+	}
+	output := calcSubnet(addr, prefix)
+
+	exitCode := 0
+	if params.Contains != "" {
+		other, err := netip.ParseAddr(params.Contains)
+		if err != nil {
+			fmt.Fprintf(stderr, "ip calc: not an IP address: %s\n", params.Contains)
+			return 1
+		}
+		other = other.Unmap()
+		if other.Is4() != addr.Is4() {
+			fmt.Fprintf(stderr, "ip calc: %s and %s are different address families\n", other, prefix)
+			return 1
+		}
+		contains := prefix.Contains(other)
+		output.Contains = &contains
+		if !contains {
+			exitCode = 1
+		}
+	}
+
+	if params.Json {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(output)
+		return exitCode
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Address:\t%s\n", output.Address)
+	fmt.Fprintf(w, "Network:\t%s\n", output.Network)
+	fmt.Fprintf(w, "Netmask:\t%s (/%d)\n", output.Netmask, output.PrefixLength)
+	fmt.Fprintf(w, "Hostmask:\t%s\n", output.Hostmask)
+	if output.Broadcast != "" {
+		fmt.Fprintf(w, "Broadcast:\t%s\n", output.Broadcast)
+	}
+	fmt.Fprintf(w, "First host:\t%s\n", output.FirstHost)
+	fmt.Fprintf(w, "Last host:\t%s\n", output.LastHost)
+	fmt.Fprintf(w, "Hosts:\t%s\n", output.Hosts)
+	if output.Contains != nil {
+		answer := "no"
+		if *output.Contains {
+			answer = "yes"
+		}
+		fmt.Fprintf(w, "Contains %s:\t%s\n", params.Contains, answer)
+	}
+	w.Flush()
+	return exitCode
+}
+
+// parseNetwork parses "addr/bits", "addr/netmask" (IPv4 only) or a bare
+// address, returning the address and the network it belongs to.
+func parseNetwork(s string) (netip.Addr, netip.Prefix, error) {
+	addrPart, maskPart, hasMask := strings.Cut(s, "/")
+	addr, err := netip.ParseAddr(addrPart)
+	if err != nil {
+		return netip.Addr{}, netip.Prefix{}, fmt.Errorf("not an IP address: %s", addrPart)
+	}
+	addr = addr.Unmap().WithZone("")
+
+	bits := addr.BitLen()
+	if hasMask {
+		if strings.Contains(maskPart, ".") {
+			bits, err = netmaskBits(maskPart)
+		} else {
+			var p netip.Prefix
+			p, err = netip.ParsePrefix(addr.String() + "/" + maskPart)
+			bits = p.Bits()
+		}
+		if err != nil {
+			return netip.Addr{}, netip.Prefix{}, fmt.Errorf("invalid prefix length or netmask: %s", maskPart)
+		}
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return netip.Addr{}, netip.Prefix{}, err
+	}
+	return addr, prefix, nil
+}
+
+// netmaskBits converts a dotted IPv4 netmask such as 255.255.240.0 to a
+// prefix length. Non-contiguous masks are rejected.
+func netmaskBits(mask string) (int, error) {
+	ip := net.ParseIP(mask).To4()
+	if ip == nil {
+		return 0, errors.New("invalid netmask")
+	}
+	ones, bits := net.IPMask(ip).Size()
+	if bits == 0 {
+		return 0, errors.New("non-contiguous netmask")
+	}
+	return ones, nil
+}
+
+// calcSubnet computes the properties of the network. IPv4 /31 networks have
+// two usable hosts and no broadcast address (RFC 3021), /32 is a single host.
+// IPv6 has no broadcast, so every address in the network counts as a host.
+func calcSubnet(addr netip.Addr, prefix netip.Prefix) CalcOutput {
+	network := prefix.Masked().Addr()
+	size := addr.BitLen()
+	hostBits := size - prefix.Bits()
+
+	netmask := make([]byte, size/8)
+	for i := range prefix.Bits() {
+		netmask[i/8] |= 0x80 >> (i % 8)
+	}
+	hostmask := make([]byte, len(netmask))
+	last := network.AsSlice()
+	for i := range netmask {
+		hostmask[i] = ^netmask[i]
+		last[i] |= hostmask[i]
+	}
+	lastAddr, _ := netip.AddrFromSlice(last)
+	netmaskAddr, _ := netip.AddrFromSlice(netmask)
+	hostmaskAddr, _ := netip.AddrFromSlice(hostmask)
+
+	output := CalcOutput{
+		Address:      addr.String(),
+		Network:      prefix.Masked().String(),
+		PrefixLength: prefix.Bits(),
+		Netmask:      netmaskAddr.String(),
+		Hostmask:     hostmaskAddr.String(),
+		FirstHost:    network.String(),
+		LastHost:     lastAddr.String(),
+		Hosts:        new(big.Int).Lsh(big.NewInt(1), uint(hostBits)),
+	}
+	if addr.Is4() && hostBits >= 2 {
+		// The network and broadcast addresses can't be assigned to hosts
+		output.Broadcast = lastAddr.String()
+		output.FirstHost = network.Next().String()
+		output.LastHost = lastAddr.Prev().String()
+		output.Hosts.Sub(output.Hosts, big.NewInt(2))
+	}
+	return output
+}
@@ -0,0 +1,187 @@
+package ip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+// geoURL is the ipinfo.io compatible API used for geolocation. Its free tier
+// needs no API key and includes the ASN in the org field.
+var geoURL = "https://ipinfo.io"
+
+// geoCacheTTL keeps repeated lookups of the same address off the network
+// while still picking up changes within minutes.
+const geoCacheTTL = 10 * time.Minute
+
+type GeoInfo struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Region   string `json:"region,omitempty"`
+	City     string `json:"city,omitempty"`
+	ASN      string `json:"asn,omitempty"`
+	Org      string `json:"org,omitempty"`
+	Location string `json:"location,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// ipinfoResponse is the subset of the ipinfo.io response we use. Private and
+// reserved addresses only get ip and bogon.
+type ipinfoResponse struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname"`
+	City     string `json:"city"`
+	Region   string `json:"region"`
+	Country  string `json:"country"`
+	Loc      string `json:"loc"`
+	Org      string `json:"org"`
+	Timezone string `json:"timezone"`
+	Bogon    bool   `json:"bogon"`
+}
+
+// runGeo prints geolocation and ASN info for params.Address, or for the
+// caller's own public address if none is given.
+func runGeo(params *Params, stdout, stderr io.Writer) int {
+	addr := params.Address
+	if addr == "" {
+		v4, v6, v4Err, v6Err := lookupPublicIPs(params)
+		switch {
+		case v4 != "":
+			addr = v4
+		case v6 != "":
+			addr = v6
+		default:
+			lastErr := v6Err
+			if lastErr == nil {
+				lastErr = v4Err
+			}
+			fmt.Fprintf(stderr, "ip: failed to discover public IP: %v\n", describeNetErr(lastErr))
+			return 1
+		}
+	} else if ip := net.ParseIP(addr); ip == nil {
+		fmt.Fprintf(stderr, "ip: not an IP address: %s\n", addr)
+		return 1
+	} else {
+		addr = ip.String()
+	}
+
+	client := &http.Client{Timeout: time.Duration(params.Timeout * float64(time.Second))}
+	cache := &common.FileCache{Dir: filepath.Join(common.CacheDir(), "ip"), TTL: geoCacheTTL}
+	info, err := lookupGeo(client, cache, addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "ip: geo lookup of %s failed: %v\n", addr, describeNetErr(err))
+		return 1
+	}
+
+	if params.Json {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(info)
+		return 0
+	}
+	printGeo(stdout, info)
+	return 0
+}
+
+// lookupGeo returns geolocation info for addr, from the cache if fresh.
+func lookupGeo(client *http.Client, cache *common.FileCache, addr string) (*GeoInfo, error) {
+	if data, ok := cache.Get(addr); ok {
+		var info GeoInfo
+		if err := json.Unmarshal(data, &info); err == nil {
+			return &info, nil
+		}
+	}
+
+	resp, err := client.Get(geoURL + "/" + url.PathEscape(addr) + "/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%s: rate limit exceeded, try again later", resp.Request.URL.Host)
+	default:
+		return nil, fmt.Errorf("%s: unexpected status %s", resp.Request.URL.Host, resp.Status)
+	}
+
+	var r ipinfoResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if r.Bogon {
+		return nil, fmt.Errorf("%s is a private or reserved address", addr)
+	}
+
+	info := &GeoInfo{
+		IP:       r.IP,
+		Hostname: r.Hostname,
+		Country:  r.Country,
+		Region:   r.Region,
+		City:     r.City,
+		Location: r.Loc,
+		Timezone: r.Timezone,
+	}
+	info.ASN, info.Org = splitASN(r.Org)
+	if info.IP == "" {
+		info.IP = addr
+	}
+	// A failing cache only costs another request next time
+	if data, err := json.Marshal(info); err == nil {
+		_ = cache.Put(addr, data)
+	}
+	return info, nil
+}
+
+// splitASN splits an org field like "AS15169 Google LLC" into the AS number
+// and the organization name.
+func splitASN(org string) (asn, name string) {
+	first, rest, _ := strings.Cut(org, " ")
+	if len(first) > 2 && strings.HasPrefix(first, "AS") && strings.Trim(first[2:], "0123456789") == "" {
+		return first, rest
+	}
+	return "", org
+}
+
+// describeNetErr adds a hint to errors caused by not reaching the network
+// at all, which are otherwise easy to mistake for a problem with the API.
+func describeNetErr(err error) error {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	if errors.As(err, &dnsErr) || errors.As(err, &opErr) {
+		return fmt.Errorf("network unreachable, are you offline? (%w)", err)
+	}
+	return err
+}
+
+func printGeo(stdout io.Writer, info *GeoInfo) {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, field := range []struct{ name, value string }{
+		{"IP", info.IP},
+		{"Hostname", info.Hostname},
+		{"Country", info.Country},
+		{"Region", info.Region},
+		{"City", info.City},
+		{"ASN", info.ASN},
+		{"Org", info.Org},
+		{"Location", info.Location},
+		{"Timezone", info.Timezone},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", field.name, field.value)
+		}
+	}
+	w.Flush()
+}
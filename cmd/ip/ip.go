@@ -1,13 +1,16 @@
 package ip
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
@@ -16,32 +19,69 @@ import (
 )
 
 type Params struct {
-	LocalOnly bool `short:"l" help:"Only show local interfaces, do not attempt to discover public IP."`
-	Json      bool `short:"j" help:"Output in JSON format."`
+	Address    string  `pos:"true" optional:"true" help:"IP address to show geolocation and ASN info for. Implies --geo." default:""`
+	Geo        bool    `short:"g" help:"Show geolocation and ASN info (country, city, ASN, org) for the given address, or for your own public IP."`
+	LocalOnly  bool    `short:"l" help:"Only show local interfaces, do not attempt to discover public IP."`
+	Public     bool    `short:"p" help:"Only show the public IPv4/IPv6 addresses, one per line. Exits non-zero if none could be discovered."`
+	Json       bool    `short:"j" help:"Output in JSON format."`
+	IPv4       bool    `short:"4" name:"ipv4" help:"Only show IPv4 addresses."`
+	IPv6       bool    `short:"6" name:"ipv6" help:"Only show IPv6 addresses."`
+	Interface  string  `short:"i" optional:"true" help:"Only show addresses of the named interface."`
+	NoLoopback bool    `help:"Hide loopback interfaces and addresses."`
+	Timeout    float64 `short:"t" help:"Timeout for each public IP discovery or geo lookup request, in seconds." default:"3"`
+}
+
+// publicIPServices are asked in order until one returns a valid address.
+// All of them answer over both IPv4 and IPv6, reporting the caller's address.
+var publicIPServices = []string{
+	"https://api64.ipify.org",
+	"https://icanhazip.com",
+	"https://ifconfig.me/ip",
 }
 
 type IPOutput struct {
-	Interfaces    map[string][]string `json:"interfaces"`
-	PublicIP      string              `json:"public_ip,omitempty"`
-	PublicIPError string              `json:"public_ip_error,omitempty"`
-	DNSServers    []string            `json:"dns_servers,omitempty"`
-	DNSError      string              `json:"dns_error,omitempty"`
-	Gateways      []string            `json:"gateways,omitempty"`
-	GatewaysError string              `json:"gateways_error,omitempty"`
+	Interfaces      map[string][]string `json:"interfaces"`
+	PublicIP        string              `json:"public_ip,omitempty"`
+	PublicIPError   string              `json:"public_ip_error,omitempty"`
+	PublicIPv6      string              `json:"public_ipv6,omitempty"`
+	PublicIPv6Error string              `json:"public_ipv6_error,omitempty"`
+	DNSServers      []string            `json:"dns_servers,omitempty"`
+	DNSError        string              `json:"dns_error,omitempty"`
+	Gateways        []string            `json:"gateways,omitempty"`
+	GatewaysError   string              `json:"gateways_error,omitempty"`
 }
 
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
 		Use:         "ip",
 		Short:       "Show local and public IP addresses",
+		Long:        "Show local interfaces, public IP, DNS servers and default gateway. With --geo or an address argument, show where an IP is located and which network (ASN) it belongs to.",
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds: []*cobra.Command{
+			calcCmd(),
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			runIp(params, os.Stdout)
+			os.Exit(runIp(params, os.Stdout, os.Stderr))
 		},
 	}.ToCobra()
 }
 
-func runIp(params *Params, stdout io.Writer) {
+func runIp(params *Params, stdout, stderr io.Writer) int {
+	if params.LocalOnly && params.Public {
+		fmt.Fprintln(stderr, "ip: --local-only and --public are mutually exclusive")
+		return 1
+	}
+	if params.Geo || params.Address != "" {
+		if params.LocalOnly || params.Public {
+			fmt.Fprintln(stderr, "ip: --geo cannot be combined with --local-only or --public")
+			return 1
+		}
+		return runGeo(params, stdout, stderr)
+	}
+	if params.Public {
+		return runPublic(params, stdout, stderr)
+	}
+
 	output := IPOutput{
 		Interfaces: make(map[string][]string),
 	}
@@ -55,7 +95,12 @@ func runIp(params *Params, stdout io.Writer) {
 		} else {
 			fmt.Fprintf(stdout, "Error getting interfaces: %v\n", err)
 		}
-		return
+		return 1
+	}
+
+	if params.Interface != "" && !hasInterface(ifaces, params.Interface) {
+		fmt.Fprintf(stderr, "ip: no such interface: %s\n", params.Interface)
+		return 1
 	}
 
 	for _, i := range ifaces {
@@ -63,21 +108,20 @@ func runIp(params *Params, stdout io.Writer) {
 		if err != nil {
 			continue
 		}
-		if len(addrs) > 0 {
-			var addrStrings []string
-			for _, addr := range addrs {
-				addrStrings = append(addrStrings, addr.String())
-			}
+		addrStrings := filterAddrs(i, addrs, params)
+		if len(addrStrings) > 0 {
 			output.Interfaces[i.Name] = addrStrings
 		}
 	}
 
 	if !params.LocalOnly {
-		ip, err := GetPublicIP()
-		if err != nil {
-			output.PublicIPError = err.Error()
-		} else {
-			output.PublicIP = strings.TrimSpace(ip)
+		v4, v6, v4Err, v6Err := lookupPublicIPs(params)
+		output.PublicIP, output.PublicIPv6 = v4, v6
+		if v4Err != nil {
+			output.PublicIPError = v4Err.Error()
+		}
+		if v6Err != nil {
+			output.PublicIPv6Error = v6Err.Error()
 		}
 	}
 
@@ -102,6 +146,116 @@ func runIp(params *Params, stdout io.Writer) {
 	} else {
 		outputPlain(stdout, params, output)
 	}
+	return 0
+}
+
+// runPublic prints only the public addresses. It fails if no address could be
+// discovered for any of the requested families.
+func runPublic(params *Params, stdout, stderr io.Writer) int {
+	v4, v6, v4Err, v6Err := lookupPublicIPs(params)
+	if v4 == "" && v6 == "" {
+		lastErr := v6Err
+		if lastErr == nil {
+			lastErr = v4Err
+		}
+		fmt.Fprintf(stderr, "ip: failed to discover public IP: %v\n", lastErr)
+		return 1
+	}
+
+	if params.Json {
+		output := struct {
+			PublicIP        string `json:"public_ip,omitempty"`
+			PublicIPError   string `json:"public_ip_error,omitempty"`
+			PublicIPv6      string `json:"public_ipv6,omitempty"`
+			PublicIPv6Error string `json:"public_ipv6_error,omitempty"`
+		}{PublicIP: v4, PublicIPv6: v6}
+		if v4Err != nil {
+			output.PublicIPError = v4Err.Error()
+		}
+		if v6Err != nil {
+			output.PublicIPv6Error = v6Err.Error()
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(output)
+		return 0
+	}
+
+	for _, ip := range []string{v4, v6} {
+		if ip != "" {
+			fmt.Fprintln(stdout, ip)
+		}
+	}
+	return 0
+}
+
+func hasInterface(ifaces []net.Interface, name string) bool {
+	for _, i := range ifaces {
+		if i.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// filterAddrs returns the addresses of iface that pass the interface, family
+// and loopback filters.
+func filterAddrs(iface net.Interface, addrs []net.Addr, params *Params) []string {
+	if params.Interface != "" && iface.Name != params.Interface {
+		return nil
+	}
+	if params.NoLoopback && iface.Flags&net.FlagLoopback != 0 {
+		return nil
+	}
+
+	var result []string
+	for _, addr := range addrs {
+		var ip net.IP
+		switch a := addr.(type) {
+		case *net.IPNet:
+			ip = a.IP
+		case *net.IPAddr:
+			ip = a.IP
+		}
+		if ip != nil {
+			if !wantFamily(params, ip.To4() != nil) {
+				continue
+			}
+			if params.NoLoopback && ip.IsLoopback() {
+				continue
+			}
+		}
+		result = append(result, addr.String())
+	}
+	return result
+}
+
+// wantFamily reports whether addresses of the given family should be shown.
+// Passing neither or both of -4/-6 shows both.
+func wantFamily(params *Params, isIPv4 bool) bool {
+	if params.IPv4 == params.IPv6 {
+		return true
+	}
+	return params.IPv4 == isIPv4
+}
+
+// lookupPublicIPs discovers the public IPv4 and IPv6 addresses concurrently,
+// for the families selected by -4/-6.
+func lookupPublicIPs(params *Params) (v4, v6 string, v4Err, v6Err error) {
+	timeout := time.Duration(params.Timeout * float64(time.Second))
+	var wg sync.WaitGroup
+	if wantFamily(params, true) {
+		wg.Go(func() {
+			v4, v4Err = lookupPublicIP("tcp4", publicIPServices, timeout)
+		})
+	}
+	if wantFamily(params, false) {
+		wg.Go(func() {
+			v6, v6Err = lookupPublicIP("tcp6", publicIPServices, timeout)
+		})
+	}
+	wg.Wait()
+	return v4, v6, v4Err, v6Err
 }
 
 func outputJSON(stdout io.Writer, output IPOutput) {
@@ -121,10 +275,18 @@ func outputPlain(stdout io.Writer, params *Params, output IPOutput) {
 
 	if !params.LocalOnly {
 		fmt.Fprintln(stdout, "\nPublic IP:")
-		if output.PublicIPError != "" {
-			fmt.Fprintf(stdout, "  Error discovering public IP: %s\n", output.PublicIPError)
-		} else {
-			fmt.Fprintf(stdout, "  %s\n", output.PublicIP)
+		for _, ip := range []string{output.PublicIP, output.PublicIPv6} {
+			if ip != "" {
+				fmt.Fprintf(stdout, "  %s\n", ip)
+			}
+		}
+		// A missing IPv6 address is common, so only report errors if nothing was found
+		if output.PublicIP == "" && output.PublicIPv6 == "" {
+			for _, e := range []string{output.PublicIPError, output.PublicIPv6Error} {
+				if e != "" {
+					fmt.Fprintf(stdout, "  Error discovering public IP: %s\n", e)
+				}
+			}
 		}
 	}
 
@@ -151,29 +313,61 @@ func outputPlain(stdout io.Writer, params *Params, output IPOutput) {
 	}
 }
 
+// GetPublicIP returns the public IPv4 address, as reported by the first
+// reachable discovery service.
 func GetPublicIP() (string, error) {
-	// Try a few services in order, return first success
-	services := []string{
-		"https://api.ipify.org",
-		"https://ifconfig.me/ip",
-		"https://icanhazip.com",
-	}
+	return lookupPublicIP("tcp4", publicIPServices, 3*time.Second)
+}
 
-	client := http.Client{
-		Timeout: 3 * time.Second,
+// lookupPublicIP asks services in order for the address seen when connecting
+// over network ("tcp4" or "tcp6"). If all of them fail, the last error is
+// returned.
+func lookupPublicIP(network string, services []string, timeout time.Duration) (string, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
 	}
+	defer client.CloseIdleConnections()
 
+	lastErr := errors.New("no public IP discovery services configured")
 	for _, service := range services {
-		resp, err := client.Get(service)
+		ip, err := queryPublicIPService(client, service, network == "tcp4")
 		if err == nil {
-			defer resp.Body.Close()
-			if resp.StatusCode == 200 {
-				body, err := io.ReadAll(resp.Body)
-				if err == nil {
-					return string(body), nil
-				}
-			}
+			return ip, nil
 		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+func queryPublicIPService(client *http.Client, service string, wantIPv4 bool) (string, error) {
+	resp, err := client.Get(service)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: unexpected status %s", service, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", service, err)
+	}
+
+	text := strings.TrimSpace(string(body))
+	ip := net.ParseIP(text)
+	if ip == nil {
+		return "", fmt.Errorf("%s: invalid address in response: %q", service, text)
+	}
+	if (ip.To4() != nil) != wantIPv4 {
+		return "", fmt.Errorf("%s: returned address of the wrong family: %s", service, ip)
 	}
-	return "", fmt.Errorf("failed to reach any public IP discovery service")
+	return ip.String(), nil
 }
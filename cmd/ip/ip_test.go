@@ -2,17 +2,22 @@ package ip
 
 import (
 	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestIpCmd_LocalOnly(t *testing.T) {
 	params := &Params{
 		LocalOnly: true,
 	}
-	var buf bytes.Buffer
+	var buf, stderr bytes.Buffer
 
-	runIp(params, &buf)
+	runIp(params, &buf, &stderr)
 
 	output := buf.String()
 
@@ -35,9 +40,9 @@ func TestIpCmd_Public(t *testing.T) {
 	params := &Params{
 		LocalOnly: false,
 	}
-	var buf bytes.Buffer
+	var buf, stderr bytes.Buffer
 
-	runIp(params, &buf)
+	runIp(params, &buf, &stderr)
 
 	output := buf.String()
 
@@ -50,3 +55,389 @@ func TestIpCmd_Public(t *testing.T) {
 		t.Logf("Warning: Output did not contain 'Public IP:'. This might be due to network issues.\nOutput:\n%s", output)
 	}
 }
+
+func publicIPServer(t *testing.T, status int, body string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestLookupPublicIP_Fallback(t *testing.T) {
+	services := []string{
+		publicIPServer(t, http.StatusServiceUnavailable, "down"),
+		publicIPServer(t, http.StatusOK, "not an ip"),
+		publicIPServer(t, http.StatusOK, "203.0.113.42\n"),
+	}
+
+	ip, err := lookupPublicIP("tcp4", services, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ip != "203.0.113.42" {
+		t.Errorf("Expected %q, got %q", "203.0.113.42", ip)
+	}
+}
+
+func TestLookupPublicIP_AllFail(t *testing.T) {
+	services := []string{
+		publicIPServer(t, http.StatusOK, "2001:db8::1"),
+		publicIPServer(t, http.StatusInternalServerError, "boom"),
+	}
+
+	// The IPv6 answer is rejected for tcp4, then the last service fails
+	_, err := lookupPublicIP("tcp4", services, 2*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "unexpected status") {
+		t.Errorf("Expected last error to be reported, got: %v", err)
+	}
+
+	_, err = lookupPublicIP("tcp4", services[:1], 2*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "wrong family") {
+		t.Errorf("Expected wrong family error, got: %v", err)
+	}
+}
+
+func TestRunIp_PublicFailureExitCode(t *testing.T) {
+	original := publicIPServices
+	publicIPServices = []string{publicIPServer(t, http.StatusBadGateway, "")}
+	defer func() { publicIPServices = original }()
+
+	var stdout, stderr bytes.Buffer
+	exitCode := runIp(&Params{Public: true, IPv4: true, Timeout: 2}, &stdout, &stderr)
+
+	if exitCode == 0 {
+		t.Errorf("Expected non-zero exit code")
+	}
+	if !strings.Contains(stderr.String(), "502 Bad Gateway") {
+		t.Errorf("Expected last error in stderr, got %q", stderr.String())
+	}
+}
+
+func TestRunIp_PublicOnly(t *testing.T) {
+	original := publicIPServices
+	publicIPServices = []string{publicIPServer(t, http.StatusOK, "198.51.100.7\n")}
+	defer func() { publicIPServices = original }()
+
+	var stdout, stderr bytes.Buffer
+	exitCode := runIp(&Params{Public: true, IPv4: true, Timeout: 2}, &stdout, &stderr)
+
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "198.51.100.7\n" {
+		t.Errorf("Expected %q, got %q", "198.51.100.7\n", stdout.String())
+	}
+
+	stdout.Reset()
+	runIp(&Params{Public: true, IPv4: true, Json: true, Timeout: 2}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), `"public_ip": "198.51.100.7"`) {
+		t.Errorf("Expected JSON public_ip, got %q", stdout.String())
+	}
+}
+
+func TestFilterAddrs(t *testing.T) {
+	loopback := net.Interface{Name: "lo", Flags: net.FlagLoopback | net.FlagUp}
+	eth := net.Interface{Name: "eth0", Flags: net.FlagUp}
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("192.168.1.10"), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+	}
+
+	tests := []struct {
+		name     string
+		iface    net.Interface
+		params   Params
+		expected []string
+	}{
+		{"no filters", eth, Params{}, []string{"192.168.1.10/24", "fe80::1/64"}},
+		{"ipv4 only", eth, Params{IPv4: true}, []string{"192.168.1.10/24"}},
+		{"ipv6 only", eth, Params{IPv6: true}, []string{"fe80::1/64"}},
+		{"both families", eth, Params{IPv4: true, IPv6: true}, []string{"192.168.1.10/24", "fe80::1/64"}},
+		{"other interface", eth, Params{Interface: "wlan0"}, nil},
+		{"matching interface", eth, Params{Interface: "eth0", IPv4: true}, []string{"192.168.1.10/24"}},
+		{"no loopback", loopback, Params{NoLoopback: true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterAddrs(tt.iface, addrs, &tt.params)
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunIp_UnknownInterface(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := runIp(&Params{LocalOnly: true, Interface: "definitely-not-an-interface0"}, &stdout, &stderr)
+
+	if exitCode == 0 {
+		t.Errorf("Expected non-zero exit code")
+	}
+	if !strings.Contains(stderr.String(), "no such interface") {
+		t.Errorf("Expected interface error, got %q", stderr.String())
+	}
+}
+
+// fakeGeoAPI serves ipinfo.io style answers and counts requests.
+func fakeGeoAPI(t *testing.T, requests *int) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		switch r.URL.Path {
+		case "/8.8.8.8/json", "/198.51.100.7/json":
+			fmt.Fprintf(w, `{"ip": %q, "hostname": "dns.google", "city": "Mountain View", "region": "California",
+				"country": "US", "loc": "37.4056,-122.0775", "org": "AS15169 Google LLC", "timezone": "America/Los_Angeles"}`,
+				strings.Split(r.URL.Path, "/")[1])
+		case "/10.0.0.1/json":
+			fmt.Fprint(w, `{"ip": "10.0.0.1", "bogon": true}`)
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	original := geoURL
+	geoURL = server.URL
+	t.Cleanup(func() { geoURL = original })
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+}
+
+func TestRunIp_Geo(t *testing.T) {
+	requests := 0
+	fakeGeoAPI(t, &requests)
+
+	var stdout, stderr bytes.Buffer
+	exitCode := runIp(&Params{Address: "8.8.8.8", Timeout: 2}, &stdout, &stderr)
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+
+	expected := `IP:        8.8.8.8
+Hostname:  dns.google
+Country:   US
+Region:    California
+City:      Mountain View
+ASN:       AS15169
+Org:       Google LLC
+Location:  37.4056,-122.0775
+Timezone:  America/Los_Angeles
+`
+	if stdout.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, stdout.String())
+	}
+
+	// The second lookup is served from the cache
+	stdout.Reset()
+	runIp(&Params{Address: "8.8.8.8", Json: true, Timeout: 2}, &stdout, &stderr)
+	if requests != 1 {
+		t.Errorf("Expected 1 request, got %d", requests)
+	}
+	if !strings.Contains(stdout.String(), `"asn": "AS15169"`) {
+		t.Errorf("Expected JSON asn, got %q", stdout.String())
+	}
+}
+
+func TestRunIp_GeoOwnAddress(t *testing.T) {
+	requests := 0
+	fakeGeoAPI(t, &requests)
+	original := publicIPServices
+	publicIPServices = []string{publicIPServer(t, http.StatusOK, "198.51.100.7\n")}
+	defer func() { publicIPServices = original }()
+
+	var stdout, stderr bytes.Buffer
+	exitCode := runIp(&Params{Geo: true, IPv4: true, Timeout: 2}, &stdout, &stderr)
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "IP:        198.51.100.7\n") {
+		t.Errorf("Expected own public IP to be looked up, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunIp_GeoErrors(t *testing.T) {
+	requests := 0
+	fakeGeoAPI(t, &requests)
+
+	tests := []struct {
+		name   string
+		params Params
+		want   string
+	}{
+		{"invalid address", Params{Address: "example.com"}, "not an IP address"},
+		{"private address", Params{Address: "10.0.0.1"}, "private or reserved"},
+		{"rate limited", Params{Address: "192.0.2.1"}, "rate limit exceeded"},
+		{"with --public", Params{Geo: true, Public: true}, "cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Timeout = 2
+			var stdout, stderr bytes.Buffer
+			if exitCode := runIp(&tt.params, &stdout, &stderr); exitCode == 0 {
+				t.Errorf("Expected non-zero exit code")
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("Expected stderr to contain %q, got %q", tt.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunIp_GeoOffline(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	// Nothing listens on a closed server's address
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	original := geoURL
+	geoURL = server.URL
+	defer func() { geoURL = original }()
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := runIp(&Params{Address: "8.8.8.8", Timeout: 2}, &stdout, &stderr); exitCode == 0 {
+		t.Errorf("Expected non-zero exit code")
+	}
+	if !strings.Contains(stderr.String(), "are you offline?") {
+		t.Errorf("Expected offline hint, got %q", stderr.String())
+	}
+}
+
+func TestSplitASN(t *testing.T) {
+	tests := []struct {
+		org, asn, name string
+	}{
+		{"AS15169 Google LLC", "AS15169", "Google LLC"},
+		{"AS13335", "AS13335", ""},
+		{"ASUS Computer", "", "ASUS Computer"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		asn, name := splitASN(tt.org)
+		if asn != tt.asn || name != tt.name {
+			t.Errorf("splitASN(%q) = %q, %q; expected %q, %q", tt.org, asn, name, tt.asn, tt.name)
+		}
+	}
+}
+
+func TestRunCalc(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		expected string
+	}{
+		{"192.168.1.77/24", `Address:     192.168.1.77
+Network:     192.168.1.0/24
+Netmask:     255.255.255.0 (/24)
+Hostmask:    0.0.0.255
+Broadcast:   192.168.1.255
+First host:  192.168.1.1
+Last host:   192.168.1.254
+Hosts:       254
+`},
+		// Point-to-point links: both addresses are usable, no broadcast (RFC 3021)
+		{"10.0.0.1/31", `Address:     10.0.0.1
+Network:     10.0.0.0/31
+Netmask:     255.255.255.254 (/31)
+Hostmask:    0.0.0.1
+First host:  10.0.0.0
+Last host:   10.0.0.1
+Hosts:       2
+`},
+		{"10.0.0.7/32", `Address:     10.0.0.7
+Network:     10.0.0.7/32
+Netmask:     255.255.255.255 (/32)
+Hostmask:    0.0.0.0
+First host:  10.0.0.7
+Last host:   10.0.0.7
+Hosts:       1
+`},
+		{"172.16.5.4/255.255.240.0", `Address:     172.16.5.4
+Network:     172.16.0.0/20
+Netmask:     255.255.240.0 (/20)
+Hostmask:    0.0.15.255
+Broadcast:   172.16.15.255
+First host:  172.16.0.1
+Last host:   172.16.15.254
+Hosts:       4094
+`},
+		{"2001:db8:abcd:12::1/64", `Address:     2001:db8:abcd:12::1
+Network:     2001:db8:abcd:12::/64
+Netmask:     ffff:ffff:ffff:ffff:: (/64)
+Hostmask:    ::ffff:ffff:ffff:ffff
+First host:  2001:db8:abcd:12::
+Last host:   2001:db8:abcd:12:ffff:ffff:ffff:ffff
+Hosts:       18446744073709551616
+`},
+		{"2001:db8::5", `Address:     2001:db8::5
+Network:     2001:db8::5/128
+Netmask:     ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff (/128)
+Hostmask:    ::
+First host:  2001:db8::5
+Last host:   2001:db8::5
+Hosts:       1
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if exitCode := runCalc(&CalcParams{CIDR: tt.cidr}, &stdout, &stderr); exitCode != 0 {
+				t.Fatalf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.expected, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunCalc_Contains(t *testing.T) {
+	tests := []struct {
+		cidr, ip string
+		want     bool
+	}{
+		{"10.0.0.0/8", "10.255.0.1", true},
+		{"10.0.0.0/8", "11.0.0.1", false},
+		{"10.0.0.0/31", "10.0.0.1", true},
+		{"10.0.0.0/31", "10.0.0.2", false},
+		{"10.0.0.7/32", "10.0.0.7", true},
+		{"10.0.0.7/32", "10.0.0.8", false},
+		{"2001:db8::/32", "2001:db8:ffff::1", true},
+		{"2001:db8::/32", "2001:db9::1", false},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		exitCode := runCalc(&CalcParams{CIDR: tt.cidr, Contains: tt.ip, Json: true}, &stdout, &stderr)
+		if (exitCode == 0) != tt.want {
+			t.Errorf("%s contains %s: expected %v, got exit code %d", tt.cidr, tt.ip, tt.want, exitCode)
+		}
+		if want := fmt.Sprintf(`"contains": %v`, tt.want); !strings.Contains(stdout.String(), want) {
+			t.Errorf("%s contains %s: expected JSON to contain %q, got %q", tt.cidr, tt.ip, want, stdout.String())
+		}
+	}
+}
+
+func TestRunCalc_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		params CalcParams
+		want   string
+	}{
+		{"not an address", CalcParams{CIDR: "example.com/24"}, "not an IP address"},
+		{"prefix too long", CalcParams{CIDR: "10.0.0.0/33"}, "invalid prefix length"},
+		{"non-contiguous netmask", CalcParams{CIDR: "10.0.0.0/255.0.255.0"}, "invalid prefix length or netmask"},
+		{"mixed families", CalcParams{CIDR: "10.0.0.0/8", Contains: "::1"}, "different address families"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if exitCode := runCalc(&tt.params, &stdout, &stderr); exitCode == 0 {
+				t.Errorf("Expected non-zero exit code")
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("Expected stderr to contain %q, got %q", tt.want, stderr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// assertion is a --assert expression comparing a claim with a value, like
+// role==admin, scope~=write or exp>now+1h.
+type assertion struct {
+	expr  string
+	claim string
+	op    string
+	value string
+}
+
+// assertionOps are tried longest first at each position, so >= isn't read
+// as > followed by a value starting with =.
+var assertionOps = []string{"==", "!=", "~=", ">=", "<=", ">", "<"}
+
+func parseAssertion(expr string) (assertion, error) {
+	for i := range len(expr) {
+		for _, op := range assertionOps {
+			if !strings.HasPrefix(expr[i:], op) {
+				continue
+			}
+			a := assertion{
+				expr:  expr,
+				claim: strings.TrimSpace(expr[:i]),
+				op:    op,
+				value: strings.TrimSpace(expr[i+len(op):]),
+			}
+			if a.claim == "" {
+				return a, fmt.Errorf("invalid assertion %q: missing claim name", expr)
+			}
+			return a, nil
+		}
+	}
+	return assertion{}, fmt.Errorf("invalid assertion %q: expected <claim><op><value> with op one of %s", expr, strings.Join(assertionOps, " "))
+}
+
+// check returns nil if the assertion holds for claims, or an error saying
+// what the claim actually is.
+//
+// == and != compare the claim as text, ~= checks that it contains the value.
+// For array claims, such as aud, it is enough that one element matches.
+// Ordering operators need a numeric claim and a number, now, now±duration
+// or an RFC3339 time as value; times are compared as Unix seconds.
+func (a assertion) check(claims map[string]interface{}, now time.Time) error {
+	actual, ok := lookupClaim(claims, a.claim)
+	if !ok {
+		return fmt.Errorf("%s is not set", a.claim)
+	}
+
+	switch a.op {
+	case "==", "!=", "~=":
+		values := []interface{}{actual}
+		if list, ok := actual.([]interface{}); ok {
+			values = list
+		}
+		match := false
+		for _, v := range values {
+			s := claimString(v)
+			if a.op == "~=" && strings.Contains(s, a.value) || a.op != "~=" && s == a.value {
+				match = true
+				break
+			}
+		}
+		if match == (a.op != "!=") {
+			return nil
+		}
+		return fmt.Errorf("%s is %s", a.claim, describeClaim(actual))
+	}
+
+	n, ok := claimNumber(actual)
+	if !ok {
+		return fmt.Errorf("%s is %s, not a number", a.claim, describeClaim(actual))
+	}
+	want, isTime, err := parseAssertionValue(a.value, now)
+	if err != nil {
+		return fmt.Errorf("invalid value in %q: %w", a.expr, err)
+	}
+
+	var holds bool
+	switch a.op {
+	case ">":
+		holds = n > want
+	case ">=":
+		holds = n >= want
+	case "<":
+		holds = n < want
+	case "<=":
+		holds = n <= want
+	}
+	if holds {
+		return nil
+	}
+	if isTime {
+		return fmt.Errorf("%s is %s", a.claim, time.Unix(int64(n), 0).UTC().Format(time.RFC3339))
+	}
+	return fmt.Errorf("%s is %s", a.claim, describeClaim(actual))
+}
+
+// lookupClaim finds a claim by name, or by a dotted path into nested
+// objects such as realm_access.roles. Names that contain dots themselves,
+// like namespaced URL claims, are matched as a whole first.
+func lookupClaim(claims map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := claims[name]; ok {
+		return v, true
+	}
+	head, rest, found := strings.Cut(name, ".")
+	if !found {
+		return nil, false
+	}
+	nested, ok := claims[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupClaim(nested, rest)
+}
+
+// parseAssertionValue parses the right hand side of an ordering assertion.
+// isTime is set for now, now±duration and RFC3339 values.
+func parseAssertionValue(s string, now time.Time) (value float64, isTime bool, err error) {
+	if rest, ok := strings.CutPrefix(s, "now"); ok {
+		t := now
+		if rest != "" {
+			sign := rest[0]
+			d, err := parseDuration(rest[1:])
+			if err != nil || (sign != '+' && sign != '-') {
+				return 0, false, fmt.Errorf("expected now+<duration> or now-<duration>, got %q", s)
+			}
+			if sign == '-' {
+				d = -d
+			}
+			t = now.Add(d)
+		}
+		return float64(t.Unix()), true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return float64(t.Unix()), true, nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n, false, nil
+	}
+	return 0, false, fmt.Errorf("expected a number, now, now±duration or RFC3339 time, got %q", s)
+}
+
+func claimNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// claimString is the text == and ~= compare against: strings as is,
+// numbers without exponent or trailing zeros, anything else as JSON.
+func claimString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	}
+	return describeClaim(v)
+}
+
+func describeClaim(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,112 @@
+package jwt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAssertion(t *testing.T) {
+	tests := []struct {
+		expr    string
+		claim   string
+		op      string
+		value   string
+		wantErr bool
+	}{
+		{"role==admin", "role", "==", "admin", false},
+		{"scope ~= write", "scope", "~=", "write", false},
+		{"exp>=now+1h", "exp", ">=", "now+1h", false},
+		{"exp<now", "exp", "<", "now", false},
+		{"tenant!=", "tenant", "!=", "", false},
+		{"https://example.com/roles~=admin", "https://example.com/roles", "~=", "admin", false},
+		{"role=admin", "", "", "", true},
+		{"==admin", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			a, err := parseAssertion(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q", tt.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if a.claim != tt.claim || a.op != tt.op || a.value != tt.value {
+				t.Errorf("parseAssertion(%q) = %q %q %q, want %q %q %q", tt.expr, a.claim, a.op, a.value, tt.claim, tt.op, tt.value)
+			}
+		})
+	}
+}
+
+func TestAssertionCheck(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	claims := map[string]interface{}{
+		"role":   "admin",
+		"scope":  "read write",
+		"aud":    []interface{}{"api", "web"},
+		"level":  float64(3),
+		"active": true,
+		"exp":    float64(now.Add(2 * time.Hour).Unix()),
+		"realm_access": map[string]interface{}{
+			"roles": []interface{}{"offline_access", "editor"},
+		},
+	}
+
+	tests := []struct {
+		expr   string
+		errMsg string // empty if the assertion should hold
+	}{
+		// Equality
+		{"role==admin", ""},
+		{"role==user", `role is "admin"`},
+		{"role!=user", ""},
+		{"role!=admin", `role is "admin"`},
+		{"level==3", ""},
+		{"active==true", ""},
+		{"aud==web", ""},
+		{"aud==mobile", `aud is ["api","web"]`},
+		{"realm_access.roles==editor", ""},
+		{"missing==x", "missing is not set"},
+
+		// Substring
+		{"scope~=write", ""},
+		{"scope~=delete", `scope is "read write"`},
+		{"aud~=we", ""},
+
+		// Numbers and times
+		{"level>2", ""},
+		{"level<=2", "level is 3"},
+		{"exp>now+1h", ""},
+		{"exp>now+3h", "exp is 2026-10-16T12:00:00Z"},
+		{"exp<now+3h", ""},
+		{"exp>=now-1d", ""},
+		{"exp<2026-10-16T11:00:00Z", "exp is 2026-10-16T12:00:00Z"},
+		{"role>1", `role is "admin", not a number`},
+		{"exp>tomorrow", "invalid value"},
+		{"exp>now*2", "invalid value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			a, err := parseAssertion(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			err = a.check(claims, now)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("expected %q to hold, got %v", tt.expr, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected %q to fail with %q, got %v", tt.expr, tt.errMsg, err)
+			}
+		})
+	}
+}
@@ -39,11 +39,12 @@ type CreateParams struct {
 }
 
 type ValidateParams struct {
-	Token    string `pos:"true" optional:"true" help:"JWT token to validate."`
-	Secret   string `short:"s" help:"Secret key for HMAC algorithms or path to public key file for RSA/ECDSA." optional:"true"`
-	Issuer   string `help:"Expected issuer (iss) claim." optional:"true"`
-	Audience string `help:"Expected audience (aud) claim." optional:"true"`
-	Subject  string `help:"Expected subject (sub) claim." optional:"true"`
+	Token    string   `pos:"true" optional:"true" help:"JWT token to validate."`
+	Secret   string   `short:"s" help:"Secret key for HMAC algorithms or path to public key file for RSA/ECDSA." optional:"true"`
+	Issuer   string   `help:"Expected issuer (iss) claim." optional:"true"`
+	Audience string   `help:"Expected audience (aud) claim." optional:"true"`
+	Subject  string   `help:"Expected subject (sub) claim." optional:"true"`
+	Assert   []string `help:"Require a claim expression to hold, e.g. 'role==admin', 'scope~=write' or 'exp>now+1h'. Can be repeated." optional:"true"`
 }
 
 func Cmd() *cobra.Command {
@@ -169,6 +170,12 @@ Checks:
   - Issuer claim (iss) if --issuer specified
   - Audience claim (aud) if --audience specified
   - Subject claim (sub) if --subject specified
+  - Claim expressions if --assert specified
+
+Assertions compare a claim with a value: == and != for equality, ~= for
+substring, and >, >=, <, <= for numbers and times. Time values can be now,
+now+<duration>, now-<duration> or RFC3339. For array claims, == and ~=
+hold if any element matches. Nested claims are addressed with dots.
 
 Examples:
   # Validate signature and expiration
@@ -177,6 +184,9 @@ Examples:
   # Validate with expected issuer
   tofu jwt validate -s "my-secret" --issuer "myapp" eyJhbGci...
 
+  # Require an admin token valid for at least another hour
+  tofu jwt validate -s "my-secret" --assert 'role==admin' --assert 'exp>now+1h' eyJhbGci...
+
   # Validate from stdin
   echo "eyJhbGci..." | tofu jwt validate -s "my-secret"`,
 		ParamEnrich: common.DefaultParamEnricher(),
@@ -387,6 +397,15 @@ func runJwtCreate(params *CreateParams, stdout io.Writer) error {
 }
 
 func runJwtValidate(params *ValidateParams, tokenString string, stdout io.Writer) error {
+	assertions := make([]assertion, 0, len(params.Assert))
+	for _, expr := range params.Assert {
+		a, err := parseAssertion(expr)
+		if err != nil {
+			return err
+		}
+		assertions = append(assertions, a)
+	}
+
 	// Build parser options
 	var parserOpts []jwt.ParserOption
 
@@ -504,7 +523,21 @@ func runJwtValidate(params *ValidateParams, tokenString string, stdout io.Writer
 		fmt.Fprintf(stdout, "✓ JWT ID: %s\n", jti)
 	}
 
+	// Claim assertions
+	var failed []string
+	for _, a := range assertions {
+		if err := a.check(claims, now); err != nil {
+			fmt.Fprintf(stdout, "✗ Assert: %s (%v)\n", a.expr, err)
+			failed = append(failed, fmt.Sprintf("%s (%v)", a.expr, err))
+		} else {
+			fmt.Fprintf(stdout, "✓ Assert: %s\n", a.expr)
+		}
+	}
+
 	fmt.Fprintln(stdout)
+	if len(failed) > 0 {
+		return fmt.Errorf("assertion failed: %s", strings.Join(failed, "; "))
+	}
 	fmt.Fprintln(stdout, "Token is valid ✓")
 
 	return nil
@@ -299,6 +299,47 @@ func TestJwtValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "assertions hold",
+			token: createValidToken(jwt.MapClaims{
+				"sub":   "user123",
+				"role":  "admin",
+				"scope": "read write",
+				"exp":   now.Add(2 * time.Hour).Unix(),
+			}),
+			params: &ValidateParams{
+				Secret: secret,
+				Assert: []string{"role==admin", "scope~=write", "exp>now+1h"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "assertion fails",
+			token: createValidToken(jwt.MapClaims{
+				"sub":  "user123",
+				"role": "user",
+				"exp":  now.Add(2 * time.Hour).Unix(),
+			}),
+			params: &ValidateParams{
+				Secret: secret,
+				Assert: []string{"exp>now+1h", "role==admin"},
+			},
+			wantErr: true,
+			errMsg:  `assertion failed: role==admin (role is "user")`,
+		},
+		{
+			name: "invalid assertion",
+			token: createValidToken(jwt.MapClaims{
+				"sub": "user123",
+				"exp": now.Add(time.Hour).Unix(),
+			}),
+			params: &ValidateParams{
+				Secret: secret,
+				Assert: []string{"role=admin"},
+			},
+			wantErr: true,
+			errMsg:  "invalid assertion",
+		},
 		{
 			name: "validation without secret (warning only)",
 			token: createValidToken(jwt.MapClaims{
@@ -2,8 +2,10 @@ package k8s
 
 import (
 	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/k8s/logs"
 	"github.com/gigurra/tofu/cmd/k8s/portforward"
 	"github.com/gigurra/tofu/cmd/k8s/tail"
+	"github.com/gigurra/tofu/cmd/k8s/top"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +16,8 @@ func Cmd() *cobra.Command {
 		SubCmds: []*cobra.Command{
 			tail.Cmd(),
 			portforward.Cmd(),
+			top.Cmd(),
+			logs.Cmd(),
 		},
 	}.ToCobra()
 }
@@ -0,0 +1,329 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/GiGurra/cmder"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+type Params struct {
+	Query         string   `pos:"true" help:"Pod name regex, or a label selector if it contains '=' or '!' (e.g. app=web)"`
+	Namespace     []string `short:"n" optional:"true" help:"Kubernetes namespace (can be repeated, default: current context)"`
+	AllNamespaces bool     `short:"A" help:"Tail pods in all namespaces" default:"false"`
+	Container     string   `short:"c" optional:"true" help:"Only tail containers whose name matches this regex"`
+	Since         string   `optional:"true" help:"Only return logs newer than relative duration (e.g., 5m, 1h)"`
+	Tail          int      `help:"Number of lines to initially read per container, -1 for all" default:"10"`
+	Output        string   `short:"o" help:"Output format" default:"text" alts:"text,json"`
+	Timestamps    bool     `short:"t" help:"Show timestamps in text output" default:"false"`
+	Color         string   `help:"Color output (auto, always, never)" default:"auto" alts:"auto,always,never"`
+	Interval      int      `help:"Pod discovery poll interval in milliseconds" default:"2000"`
+}
+
+func Cmd() *cobra.Command {
+	return boa.CmdT[Params]{
+		Use:         "logs",
+		Short:       "Tail logs from all pods matching a selector",
+		Long:        "Follow the logs of all containers in pods matching a name regex or label selector, merged into one stream with a colored pod/container prefix per line. New pods are picked up as they start, deleted pods are dropped, and streams that break are reconnected without repeating lines.",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
+			if err := run(params, os.Stdout, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func run(params *Params, stdout, stderr io.Writer) error {
+	t, err := newTailer(params, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	if err := checkKubectl(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return t.run(ctx)
+}
+
+// streamOptions select where a log stream starts.
+type streamOptions struct {
+	tail      int
+	since     string
+	sinceTime time.Time // set when resuming a dropped stream
+}
+
+// tailer keeps one log stream running per matching container. Pod discovery
+// and log streaming are pluggable so the merging can be tested without a
+// cluster.
+type tailer struct {
+	list        func(ctx context.Context) ([]podInfo, error)
+	stream      func(ctx context.Context, t target, opts streamOptions, stdout io.Writer) error
+	podRe       *regexp.Regexp
+	containerRe *regexp.Regexp
+	opts        streamOptions
+	printer     *printer
+	interval    time.Duration
+	backoff     time.Duration
+
+	active map[target]context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newTailer(params *Params, stdout, stderr io.Writer) (*tailer, error) {
+	if params.Interval < 100 {
+		return nil, fmt.Errorf("--interval must be at least 100 milliseconds")
+	}
+	if params.Output != "text" && params.Output != "json" {
+		return nil, fmt.Errorf("invalid --output %q, must be text or json", params.Output)
+	}
+
+	var selector string
+	var podRe, containerRe *regexp.Regexp
+	if isLabelSelector(params.Query) {
+		selector = params.Query
+	} else {
+		re, err := regexp.Compile(params.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pod name regex: %w", err)
+		}
+		podRe = re
+	}
+	if params.Container != "" {
+		re, err := regexp.Compile(params.Container)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --container regex: %w", err)
+		}
+		containerRe = re
+	}
+
+	namespaces := params.Namespace
+	if params.AllNamespaces {
+		namespaces = nil
+	}
+	return &tailer{
+		list: func(ctx context.Context) ([]podInfo, error) {
+			return listPods(ctx, namespaces, params.AllNamespaces, selector)
+		},
+		stream:      kubectlLogs,
+		podRe:       podRe,
+		containerRe: containerRe,
+		opts:        streamOptions{tail: params.Tail, since: params.Since},
+		printer: &printer{
+			stdout:        stdout,
+			stderr:        stderr,
+			json:          params.Output == "json",
+			color:         useColor(params.Color, stdout),
+			showNamespace: params.AllNamespaces || len(params.Namespace) > 1,
+			timestamps:    params.Timestamps,
+		},
+		interval: time.Duration(params.Interval) * time.Millisecond,
+		backoff:  time.Second,
+		active:   make(map[target]context.CancelFunc),
+	}, nil
+}
+
+func useColor(mode string, stdout io.Writer) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	default:
+		if os.Getenv("NO_COLOR") != "" {
+			return false
+		}
+		f, ok := stdout.(*os.File)
+		return ok && term.IsTerminal(int(f.Fd()))
+	}
+}
+
+// run discovers pods every interval until ctx is cancelled, then waits for
+// all streams to stop.
+func (t *tailer) run(ctx context.Context) error {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		t.sync(ctx)
+		select {
+		case <-ctx.Done():
+			for _, cancel := range t.active {
+				cancel()
+			}
+			t.wg.Wait()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync starts streams for new containers and stops those that are gone.
+func (t *tailer) sync(ctx context.Context) {
+	pods, err := t.list(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			t.printer.status("Discovery error: %v", err)
+		}
+		return
+	}
+
+	wanted := make(map[target]bool)
+	for _, tg := range selectTargets(pods, t.podRe, t.containerRe) {
+		wanted[tg] = true
+		if _, ok := t.active[tg]; ok {
+			continue
+		}
+		streamCtx, cancel := context.WithCancel(ctx)
+		t.active[tg] = cancel
+		t.printer.status("+ %s", tg)
+		t.wg.Go(func() { t.follow(streamCtx, tg) })
+	}
+
+	for tg, cancel := range t.active {
+		if !wanted[tg] {
+			cancel()
+			delete(t.active, tg)
+			t.printer.status("- %s", tg)
+		}
+	}
+}
+
+// follow streams the logs of one container until ctx is cancelled,
+// reconnecting when the stream ends.
+func (t *tailer) follow(ctx context.Context, tg target) {
+	w := &lineWriter{target: tg, printer: t.printer}
+	opts := t.opts
+	for {
+		err := t.stream(ctx, tg, opts, w)
+		w.flush()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			t.printer.status("Stream %s dropped, reconnecting: %v", tg, err)
+		}
+		if !w.last.IsZero() {
+			opts.sinceTime = w.last
+			w.skipUntil = w.last
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(t.backoff):
+		}
+	}
+}
+
+// kubectlLogs follows one container's logs with kubectl. Timestamps are
+// always requested, to be able to resume a dropped stream.
+func kubectlLogs(ctx context.Context, tg target, opts streamOptions, stdout io.Writer) error {
+	args := []string{"kubectl", "logs", "-f", "--timestamps", tg.Pod, "-c", tg.Container}
+	if tg.Namespace != "" {
+		args = append(args, "-n", tg.Namespace)
+	}
+	if !opts.sinceTime.IsZero() {
+		// The API only has second precision here, lineWriter drops the overlap
+		args = append(args, "--since-time="+opts.sinceTime.UTC().Format(time.RFC3339))
+	} else {
+		args = append(args, "--tail="+strconv.Itoa(opts.tail))
+		if opts.since != "" {
+			args = append(args, "--since="+opts.since)
+		}
+	}
+
+	var stderr bytes.Buffer
+	result := cmder.New(args...).
+		WithStdOut(stdout).
+		WithStdErr(&stderr).
+		Run(ctx)
+	if result.Err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", result.Err, msg)
+		}
+		return result.Err
+	}
+	return nil
+}
+
+// listPods lists pods with kubectl, once per namespace. No namespaces means
+// the current context's namespace, or all namespaces with allNamespaces.
+func listPods(ctx context.Context, namespaces []string, allNamespaces bool, selector string) ([]podInfo, error) {
+	var nsArgs [][]string
+	switch {
+	case allNamespaces:
+		nsArgs = [][]string{{"-A"}}
+	case len(namespaces) == 0:
+		nsArgs = [][]string{nil}
+	default:
+		for _, ns := range namespaces {
+			nsArgs = append(nsArgs, []string{"-n", ns})
+		}
+	}
+
+	var pods []podInfo
+	for _, ns := range nsArgs {
+		args := append([]string{"kubectl", "get", "pods", "-o", "json"}, ns...)
+		if selector != "" {
+			args = append(args, "-l", selector)
+		}
+		result := cmder.New(args...).
+			WithAttemptTimeout(10 * time.Second).
+			Run(ctx)
+		if result.Err != nil {
+			if result.Combined != "" {
+				return nil, fmt.Errorf("kubectl get pods failed: %w\n%s", result.Err, result.Combined)
+			}
+			return nil, fmt.Errorf("kubectl get pods failed: %w", result.Err)
+		}
+		found, err := parsePods([]byte(result.StdOut))
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, found...)
+	}
+	return pods, nil
+}
+
+func checkKubectl() error {
+	result := cmder.New("kubectl", "version", "--client").
+		WithAttemptTimeout(5 * time.Second).
+		Run(context.Background())
+	if result.Err != nil {
+		if result.Combined != "" {
+			return fmt.Errorf("kubectl not found or not working: %w\n%s", result.Err, result.Combined)
+		}
+		return fmt.Errorf("kubectl not found or not working: %w", result.Err)
+	}
+	return nil
+}
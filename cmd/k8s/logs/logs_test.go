@@ -0,0 +1,299 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParsePods(t *testing.T) {
+	data := `{
+  "items": [
+    {
+      "metadata": {"name": "web-1", "namespace": "prod"},
+      "status": {"containerStatuses": [
+        {"name": "app", "state": {"running": {"startedAt": "2026-10-16T10:00:00Z"}}},
+        {"name": "sidecar", "state": {"waiting": {"reason": "CrashLoopBackOff"}}}
+      ]}
+    },
+    {
+      "metadata": {"name": "web-2", "namespace": "prod"},
+      "status": {"phase": "Pending"}
+    }
+  ]
+}`
+	pods, err := parsePods([]byte(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pods) != 2 {
+		t.Fatalf("Expected 2 pods, got %d", len(pods))
+	}
+	if pods[0].Name != "web-1" || pods[0].Namespace != "prod" || len(pods[0].Containers) != 1 || pods[0].Containers[0] != "app" {
+		t.Errorf("Expected web-1 with only the running container, got %+v", pods[0])
+	}
+	if len(pods[1].Containers) != 0 {
+		t.Errorf("Expected pending pod without containers, got %+v", pods[1])
+	}
+
+	if _, err := parsePods([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestIsLabelSelector(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"app=web", true},
+		{"app!=web,tier=backend", true},
+		{"!canary", true},
+		{"env in (prod, staging)", true},
+		{"web-.*", false},
+		{"^api", false},
+	}
+	for _, tt := range tests {
+		if got := isLabelSelector(tt.query); got != tt.want {
+			t.Errorf("isLabelSelector(%q) = %v, expected %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSelectTargets(t *testing.T) {
+	pods := []podInfo{
+		{Namespace: "prod", Name: "web-2", Containers: []string{"app", "istio-proxy"}},
+		{Namespace: "prod", Name: "api-1", Containers: []string{"app"}},
+		{Namespace: "prod", Name: "web-1", Containers: []string{"app"}},
+	}
+
+	var got []string
+	for _, tg := range selectTargets(pods, regexp.MustCompile("^web"), regexp.MustCompile("^app$")) {
+		got = append(got, tg.String())
+	}
+	expected := []string{"prod/web-1/app", "prod/web-2/app"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if n := len(selectTargets(pods, nil, nil)); n != 4 {
+		t.Errorf("Expected all 4 containers without filters, got %d", n)
+	}
+}
+
+func TestParseLogLine(t *testing.T) {
+	ts, msg := parseLogLine("2026-10-16T10:00:00.123456789Z hello world")
+	if ts.IsZero() || ts.Nanosecond() != 123456789 {
+		t.Errorf("Expected timestamp to be parsed, got %v", ts)
+	}
+	if msg != "hello world" {
+		t.Errorf("Expected %q, got %q", "hello world", msg)
+	}
+
+	ts, msg = parseLogLine("unable to retrieve container logs")
+	if !ts.IsZero() || msg != "unable to retrieve container logs" {
+		t.Errorf("Expected line without timestamp unchanged, got %v %q", ts, msg)
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	tg := target{Namespace: "prod", Pod: "web-1", Container: "app"}
+	ts := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		printer  *printer
+		expected string
+	}{
+		{"plain", &printer{}, "[web-1/app] hello\n"},
+		{"namespace", &printer{showNamespace: true}, "[prod/web-1/app] hello\n"},
+		{"timestamps", &printer{timestamps: true}, "[web-1/app] 2026-10-16T10:00:00Z hello\n"},
+		{"color", &printer{color: true}, "[" + colorFor("prod/web-1") + "web-1" + colorReset + "/" + colorFor("app") + "app" + colorReset + "] hello\n"},
+		{"json", &printer{json: true}, `{"timestamp":"2026-10-16T10:00:00Z","namespace":"prod","pod":"web-1","container":"app","message":"hello"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			tt.printer.stdout = &stdout
+			tt.printer.line(tg, ts, "hello")
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+			}
+		})
+	}
+}
+
+// lockedBuffer lets the test read output while streams are still writing.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// fakeCluster stands in for kubectl: it serves a pod list that the test can
+// change, and scripted log streams that drop once before staying open. tofu
+// has no client-go dependency, whose fake clientset would otherwise serve
+// here, so logs reaches the cluster only through kubectl behind the list and
+// stream hooks that this replaces.
+type fakeCluster struct {
+	mu        sync.Mutex
+	pods      []podInfo
+	calls     map[target]int
+	sinceTime map[target]time.Time
+	stopped   map[target]bool
+}
+
+func (c *fakeCluster) list(ctx context.Context) ([]podInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]podInfo(nil), c.pods...), nil
+}
+
+func (c *fakeCluster) stream(ctx context.Context, tg target, opts streamOptions, stdout io.Writer) error {
+	c.mu.Lock()
+	c.calls[tg]++
+	call := c.calls[tg]
+	c.sinceTime[tg] = opts.sinceTime
+	c.mu.Unlock()
+
+	if call == 1 {
+		// Lines may arrive split across writes
+		fmt.Fprintf(stdout, "2026-10-16T10:00:00.5Z first from %s\n2026-10-16T10:00:01Z sec", tg.Container)
+		fmt.Fprint(stdout, "ond\n")
+		return errors.New("connection reset")
+	}
+	// Resuming repeats lines of the same second, which must not be printed twice
+	fmt.Fprint(stdout, "2026-10-16T10:00:01Z second\n2026-10-16T10:00:02Z third\n")
+	<-ctx.Done()
+	c.mu.Lock()
+	c.stopped[tg] = true
+	c.mu.Unlock()
+	return ctx.Err()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTailer_MergesAndReconnects(t *testing.T) {
+	cluster := &fakeCluster{
+		pods: []podInfo{
+			{Namespace: "prod", Name: "web-1", Containers: []string{"app", "sidecar"}},
+			{Namespace: "prod", Name: "api-1", Containers: []string{"app"}},
+		},
+		calls:     make(map[target]int),
+		sinceTime: make(map[target]time.Time),
+		stopped:   make(map[target]bool),
+	}
+	var stdout, stderr lockedBuffer
+	tl := &tailer{
+		list:     cluster.list,
+		stream:   cluster.stream,
+		podRe:    regexp.MustCompile("^web"),
+		opts:     streamOptions{tail: 10},
+		printer:  &printer{stdout: &stdout, stderr: &stderr},
+		interval: 10 * time.Millisecond,
+		backoff:  time.Millisecond,
+		active:   make(map[target]context.CancelFunc),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- tl.run(ctx) }()
+
+	waitFor(t, "both streams to resume", func() bool {
+		return strings.Count(stdout.String(), "third") == 2
+	})
+
+	for _, c := range []string{"app", "sidecar"} {
+		prefix := "[web-1/" + c + "] "
+		for _, line := range []string{"first from " + c, "second", "third"} {
+			if n := strings.Count(stdout.String(), prefix+line+"\n"); n != 1 {
+				t.Errorf("Expected %q once, got %d times in:\n%s", prefix+line, n, stdout.String())
+			}
+		}
+	}
+	if strings.Contains(stdout.String(), "api-1") {
+		t.Errorf("Expected api-1 not to be tailed, got:\n%s", stdout.String())
+	}
+
+	sidecar := target{Namespace: "prod", Pod: "web-1", Container: "sidecar"}
+	cluster.mu.Lock()
+	resumedAt := cluster.sinceTime[sidecar]
+	cluster.mu.Unlock()
+	if want := time.Date(2026, 10, 16, 10, 0, 1, 0, time.UTC); !resumedAt.Equal(want) {
+		t.Errorf("Expected reconnect since %v, got %v", want, resumedAt)
+	}
+	if !strings.Contains(stderr.String(), "Stream prod/web-1/app dropped, reconnecting: connection reset") {
+		t.Errorf("Expected reconnect message, got:\n%s", stderr.String())
+	}
+
+	// A container that goes away stops being tailed
+	cluster.mu.Lock()
+	cluster.pods[0].Containers = []string{"app"}
+	cluster.mu.Unlock()
+	waitFor(t, "sidecar stream to stop", func() bool {
+		cluster.mu.Lock()
+		defer cluster.mu.Unlock()
+		return cluster.stopped[sidecar]
+	})
+	if !strings.Contains(stderr.String(), "- prod/web-1/sidecar") {
+		t.Errorf("Expected removal message, got:\n%s", stderr.String())
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for tailer to stop")
+	}
+}
+
+func TestNewTailer_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   string
+	}{
+		{"bad pod regex", Params{Query: "web-(", Output: "text", Interval: 2000}, "invalid pod name regex"},
+		{"bad container regex", Params{Query: "web", Container: "[", Output: "text", Interval: 2000}, "invalid --container regex"},
+		{"bad output", Params{Query: "web", Output: "yaml", Interval: 2000}, "invalid --output"},
+		{"short interval", Params{Query: "web", Output: "text", Interval: 10}, "--interval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTailer(&tt.params, io.Discard, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
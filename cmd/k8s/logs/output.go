@@ -0,0 +1,174 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// target is one container whose logs are followed.
+type target struct {
+	Namespace string
+	Pod       string
+	Container string
+}
+
+func (t target) String() string {
+	return t.Namespace + "/" + t.Pod + "/" + t.Container
+}
+
+var colors = []string{
+	"\033[31m", // red
+	"\033[32m", // green
+	"\033[33m", // yellow
+	"\033[34m", // blue
+	"\033[35m", // magenta
+	"\033[36m", // cyan
+	"\033[91m", // bright red
+	"\033[92m", // bright green
+	"\033[93m", // bright yellow
+	"\033[94m", // bright blue
+	"\033[95m", // bright magenta
+	"\033[96m", // bright cyan
+}
+
+const colorReset = "\033[0m"
+
+// colorFor picks a stable color for a name, so a pod keeps its color across
+// reconnects and runs.
+func colorFor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return colors[h.Sum32()%uint32(len(colors))]
+}
+
+// logEntry is the --output json format, one object per line.
+type logEntry struct {
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Namespace string     `json:"namespace"`
+	Pod       string     `json:"pod"`
+	Container string     `json:"container"`
+	Message   string     `json:"message"`
+}
+
+// printer merges the lines of all streams into one output. Each line is
+// written in a single call under the lock, so lines never interleave.
+type printer struct {
+	mu            sync.Mutex
+	stdout        io.Writer
+	stderr        io.Writer
+	json          bool
+	color         bool
+	showNamespace bool
+	timestamps    bool
+}
+
+func (p *printer) prefix(t target) string {
+	pod, container := t.Pod, t.Container
+	if p.color {
+		pod = colorFor(t.Namespace+"/"+t.Pod) + pod + colorReset
+		container = colorFor(t.Container) + container + colorReset
+	}
+	if p.showNamespace {
+		return "[" + t.Namespace + "/" + pod + "/" + container + "] "
+	}
+	return "[" + pod + "/" + container + "] "
+}
+
+func (p *printer) line(t target, ts time.Time, msg string) {
+	var out string
+	if p.json {
+		entry := logEntry{Namespace: t.Namespace, Pod: t.Pod, Container: t.Container, Message: msg}
+		if !ts.IsZero() {
+			entry.Timestamp = &ts
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		out = string(data) + "\n"
+	} else {
+		out = p.prefix(t)
+		if p.timestamps && !ts.IsZero() {
+			out += ts.Format(time.RFC3339) + " "
+		}
+		out += msg + "\n"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	io.WriteString(p.stdout, out)
+}
+
+// status reports stream and discovery events on stderr, keeping stdout
+// clean for piping.
+func (p *printer) status(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.stderr, format+"\n", args...)
+}
+
+// parseLogLine splits a line from kubectl logs --timestamps into its
+// timestamp and message. Lines without a timestamp are returned as is.
+func parseLogLine(line string) (time.Time, string) {
+	first, rest, found := strings.Cut(line, " ")
+	ts, err := time.Parse(time.RFC3339Nano, first)
+	if err != nil {
+		return time.Time{}, line
+	}
+	if !found {
+		return ts, ""
+	}
+	return ts, rest
+}
+
+// lineWriter receives the raw output of one stream and passes complete lines
+// to the printer. It remembers the newest timestamp, so a reconnected stream
+// can resume where the previous one stopped and skip lines already printed.
+type lineWriter struct {
+	target    target
+	printer   *printer
+	buf       bytes.Buffer
+	last      time.Time // newest timestamp printed
+	skipUntil time.Time // lines up to this time were printed by a previous stream
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := string(w.buf.Next(i + 1))
+		w.emit(strings.TrimRight(line, "\r\n"))
+	}
+	return len(p), nil
+}
+
+// flush prints a trailing line without a newline, left when a stream ends.
+func (w *lineWriter) flush() {
+	if w.buf.Len() > 0 {
+		line := w.buf.String()
+		w.buf.Reset()
+		w.emit(line)
+	}
+}
+
+func (w *lineWriter) emit(line string) {
+	ts, msg := parseLogLine(line)
+	if !ts.IsZero() {
+		if !ts.After(w.skipUntil) {
+			return
+		}
+		if ts.After(w.last) {
+			w.last = ts
+		}
+	}
+	w.printer.line(w.target, ts, msg)
+}
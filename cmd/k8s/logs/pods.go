@@ -0,0 +1,84 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// podInfo is a pod and the containers in it that currently have a log
+// stream to follow.
+type podInfo struct {
+	Namespace  string
+	Name       string
+	Containers []string
+}
+
+// podList is the subset of a kubectl get pods -o json response we use.
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Status struct {
+			ContainerStatuses []struct {
+				Name  string `json:"name"`
+				State struct {
+					Running *struct{} `json:"running"`
+				} `json:"state"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// parsePods returns the pods in a kubectl get pods -o json response with
+// their running containers. Pending pods and crashed containers are left out
+// until they run again, since kubectl logs -f can't follow them.
+func parsePods(data []byte) ([]podInfo, error) {
+	var list podList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pod list: %w", err)
+	}
+
+	var pods []podInfo
+	for _, item := range list.Items {
+		pod := podInfo{Namespace: item.Metadata.Namespace, Name: item.Metadata.Name}
+		for _, cs := range item.Status.ContainerStatuses {
+			if cs.State.Running != nil {
+				pod.Containers = append(pod.Containers, cs.Name)
+			}
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// isLabelSelector reports whether the query is a label selector rather than
+// a pod name regex.
+func isLabelSelector(query string) bool {
+	return strings.ContainsAny(query, "=!") ||
+		strings.Contains(query, " in (") ||
+		strings.Contains(query, " notin (")
+}
+
+// selectTargets returns the containers to follow, sorted. A nil regex
+// matches everything.
+func selectTargets(pods []podInfo, podRe, containerRe *regexp.Regexp) []target {
+	var targets []target
+	for _, p := range pods {
+		if podRe != nil && !podRe.MatchString(p.Name) {
+			continue
+		}
+		for _, c := range p.Containers {
+			if containerRe != nil && !containerRe.MatchString(c) {
+				continue
+			}
+			targets = append(targets, target{Namespace: p.Namespace, Pod: p.Name, Container: c})
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].String() < targets[j].String() })
+	return targets
+}
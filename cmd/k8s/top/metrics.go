@@ -0,0 +1,124 @@
+package top
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// podUsage is the resource usage of one pod, summed over its containers.
+type podUsage struct {
+	Namespace  string
+	Name       string
+	CPU        int64 // millicores
+	Memory     int64 // bytes
+	Containers []containerUsage
+}
+
+type containerUsage struct {
+	Name   string
+	CPU    int64 // millicores
+	Memory int64 // bytes
+}
+
+// podMetricsList is the subset of metrics.k8s.io/v1beta1 PodMetricsList we use.
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Containers []struct {
+			Name  string `json:"name"`
+			Usage struct {
+				CPU    string `json:"cpu"`
+				Memory string `json:"memory"`
+			} `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+func parsePodMetrics(data []byte) ([]podUsage, error) {
+	var list podMetricsList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pod metrics: %w", err)
+	}
+
+	pods := make([]podUsage, 0, len(list.Items))
+	for _, item := range list.Items {
+		pod := podUsage{Namespace: item.Metadata.Namespace, Name: item.Metadata.Name}
+		for _, c := range item.Containers {
+			cpu, err := parseCPU(c.Usage.CPU)
+			if err != nil {
+				return nil, err
+			}
+			mem, err := parseMemory(c.Usage.Memory)
+			if err != nil {
+				return nil, err
+			}
+			pod.Containers = append(pod.Containers, containerUsage{Name: c.Name, CPU: cpu, Memory: mem})
+			pod.CPU += cpu
+			pod.Memory += mem
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// parseCPU parses a Kubernetes CPU quantity ("250m", "1", "1500000n") into
+// millicores.
+func parseCPU(q string) (int64, error) {
+	if q == "" {
+		return 0, nil
+	}
+	divisors := map[byte]float64{'n': 1e6, 'u': 1e3, 'm': 1}
+	value := q
+	scale := 1000.0 // plain cores
+	if d, ok := divisors[q[len(q)-1]]; ok {
+		value = q[:len(q)-1]
+		scale = 1 / d
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid CPU quantity %q", q)
+	}
+	return int64(f*scale + 0.5), nil
+}
+
+// parseMemory parses a Kubernetes memory quantity ("34Mi", "1G", "1024")
+// into bytes.
+func parseMemory(q string) (int64, error) {
+	if q == "" {
+		return 0, nil
+	}
+	suffixes := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+		{"k", 1e3}, {"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+	}
+	value := q
+	multiplier := 1.0
+	for _, s := range suffixes {
+		if strings.HasSuffix(q, s.suffix) {
+			value = strings.TrimSuffix(q, s.suffix)
+			multiplier = s.multiplier
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory quantity %q", q)
+	}
+	return int64(f * multiplier), nil
+}
+
+func formatCPU(millicores int64) string {
+	return fmt.Sprintf("%dm", millicores)
+}
+
+func formatMemory(bytes int64) string {
+	return fmt.Sprintf("%dMi", (bytes+(1<<19))>>20)
+}
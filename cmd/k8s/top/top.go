@@ -0,0 +1,260 @@
+package top
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/GiGurra/cmder"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+type Params struct {
+	Namespace     string `short:"n" optional:"true" help:"Kubernetes namespace (default: current context)"`
+	AllNamespaces bool   `short:"A" help:"Show pods in all namespaces" default:"false"`
+	Interval      int    `help:"Refresh interval in milliseconds" default:"2000"`
+}
+
+func Cmd() *cobra.Command {
+	return boa.CmdT[Params]{
+		Use:         "top",
+		Short:       "Live CPU/memory usage of pods",
+		Long:        "Show a live-updating table of pod CPU and memory usage from the metrics API (requires metrics-server). Sort with s/r, filter with /, switch namespace with n/N and press enter to see per-container usage. Prints the table once when stdin is not a terminal.",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
+			if err := run(params, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func run(params *Params, stdout io.Writer) error {
+	if err := checkKubectl(); err != nil {
+		return err
+	}
+	if params.Interval < 100 {
+		return fmt.Errorf("--interval must be at least 100 milliseconds")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	v := &view{
+		namespaces: namespaceCycle(ctx, params),
+		sort:       sortState{column: sortByCPU, desc: true},
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		pods, err := fetchPodMetrics(ctx, v.namespace())
+		if err != nil {
+			return err
+		}
+		v.setPods(pods, nil, time.Now())
+		_, err = io.WriteString(stdout, v.render(0, false))
+		return err
+	}
+
+	return runInteractive(ctx, v, time.Duration(params.Interval)*time.Millisecond, stdout)
+}
+
+// runInteractive redraws the view every interval until q or ctrl+c. The
+// terminal is driven directly with the key decoding and table drawing of
+// cmd/common/tui.go, as bubbletea and lipgloss are not dependencies of tofu.
+func runInteractive(ctx context.Context, v *view, interval time.Duration, stdout io.Writer) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+
+	// Alternate screen, hidden cursor
+	fmt.Fprint(stdout, "\033[?1049h\033[?25l")
+	defer fmt.Fprint(stdout, "\033[?25h\033[?1049l")
+
+	keyCh := make(chan []common.Key)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			if n > 0 {
+				keyCh <- common.DecodeKeys(buf[:n])
+			}
+		}
+	}()
+
+	// Fetch in the background so keys stay responsive on slow clusters
+	type fetchResult struct {
+		namespace string
+		pods      []podUsage
+		err       error
+	}
+	refreshCh := make(chan string, 1)
+	resultCh := make(chan fetchResult, 1)
+	go func() {
+		for ns := range refreshCh {
+			pods, err := fetchPodMetrics(ctx, ns)
+			select {
+			case resultCh <- fetchResult{ns, pods, err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	defer close(refreshCh)
+	requestRefresh := func() {
+		select {
+		case refreshCh <- v.namespace():
+		default:
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	requestRefresh()
+	for {
+		_, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			height = 24
+		}
+		screen := strings.ReplaceAll(v.render(height, true), "\n", "\033[K\r\n")
+		fmt.Fprint(stdout, "\033[H"+screen+"\033[J")
+
+		select {
+		case <-sigCh:
+			return nil
+		case keys := <-keyCh:
+			for _, k := range keys {
+				switch v.handleKey(k) {
+				case actionQuit:
+					return nil
+				case actionNamespace:
+					requestRefresh()
+				}
+			}
+		case res := <-resultCh:
+			if res.namespace == v.namespace() {
+				v.setPods(res.pods, res.err, time.Now())
+			}
+		case <-ticker.C:
+			requestRefresh()
+		}
+	}
+}
+
+// namespaceCycle returns the namespaces n/N cycle through, rotated so the
+// starting namespace comes first. "" stands for all namespaces.
+func namespaceCycle(ctx context.Context, params *Params) []string {
+	start := ""
+	if !params.AllNamespaces {
+		start = params.Namespace
+		if start == "" {
+			start = currentNamespace(ctx)
+		}
+	}
+
+	namespaces := append([]string{""}, listNamespaces(ctx)...)
+	i := slices.Index(namespaces, start)
+	if i < 0 {
+		namespaces = append(namespaces, start)
+		i = len(namespaces) - 1
+	}
+	return append(namespaces[i:], namespaces[:i]...)
+}
+
+func currentNamespace(ctx context.Context) string {
+	res := cmder.New("kubectl", "config", "view", "--minify", "-o", "jsonpath={..namespace}").
+		WithAttemptTimeout(5 * time.Second).
+		Run(ctx)
+	if ns := strings.TrimSpace(res.StdOut); res.Err == nil && ns != "" {
+		return ns
+	}
+	return "default"
+}
+
+func listNamespaces(ctx context.Context) []string {
+	res := cmder.New("kubectl", "get", "namespaces", "-o", "name").
+		WithAttemptTimeout(5 * time.Second).
+		Run(ctx)
+	if res.Err != nil {
+		return nil
+	}
+	var namespaces []string
+	for _, line := range strings.Split(strings.TrimSpace(res.StdOut), "\n") {
+		if ns := strings.TrimPrefix(strings.TrimSpace(line), "namespace/"); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+var errMetricsUnavailable = errors.New("metrics API not available, is metrics-server installed and running? (kubectl top needs it too)")
+
+func fetchPodMetrics(ctx context.Context, namespace string) ([]podUsage, error) {
+	path := "/apis/metrics.k8s.io/v1beta1/pods"
+	if namespace != "" {
+		path = "/apis/metrics.k8s.io/v1beta1/namespaces/" + namespace + "/pods"
+	}
+
+	res := cmder.New("kubectl", "get", "--raw", path).
+		WithAttemptTimeout(10 * time.Second).
+		Run(ctx)
+	if res.Err != nil {
+		if isMetricsUnavailable(res.Combined) {
+			return nil, errMetricsUnavailable
+		}
+		msg := strings.TrimSpace(res.Combined)
+		if msg == "" {
+			msg = res.Err.Error()
+		}
+		return nil, fmt.Errorf("failed to get pod metrics: %s", msg)
+	}
+	return parsePodMetrics([]byte(res.StdOut))
+}
+
+// isMetricsUnavailable recognizes the API server's answers when the
+// metrics.k8s.io group is not registered or its backend is down. Other
+// errors, like a missing namespace, are NotFound too and reported as is.
+func isMetricsUnavailable(output string) bool {
+	for _, s := range []string{
+		"the server could not find the requested resource",
+		"the server is currently unable to handle the request",
+	} {
+		if strings.Contains(output, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkKubectl() error {
+	result := cmder.New("kubectl", "version", "--client").
+		WithAttemptTimeout(5 * time.Second).
+		Run(context.Background())
+	if result.Err != nil {
+		if result.Combined != "" {
+			return fmt.Errorf("kubectl not found or not working: %w\n%s", result.Err, result.Combined)
+		}
+		return fmt.Errorf("kubectl not found or not working: %w", result.Err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package top
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+const samplePodMetrics = `{
+  "kind": "PodMetricsList",
+  "apiVersion": "metrics.k8s.io/v1beta1",
+  "items": [
+    {
+      "metadata": {"name": "api-7d9f", "namespace": "prod"},
+      "containers": [
+        {"name": "api", "usage": {"cpu": "250000000n", "memory": "128Mi"}},
+        {"name": "sidecar", "usage": {"cpu": "5m", "memory": "16384Ki"}}
+      ]
+    },
+    {
+      "metadata": {"name": "web-1", "namespace": "prod"},
+      "containers": [{"name": "web", "usage": {"cpu": "1", "memory": "512Mi"}}]
+    },
+    {
+      "metadata": {"name": "cron-x", "namespace": "batch"},
+      "containers": [{"name": "job", "usage": {"cpu": "0", "memory": "8Mi"}}]
+    }
+  ]
+}`
+
+func samplePods(t *testing.T) []podUsage {
+	t.Helper()
+	pods, err := parsePodMetrics([]byte(samplePodMetrics))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return pods
+}
+
+func TestParseQuantities(t *testing.T) {
+	cpus := map[string]int64{"250m": 250, "1": 1000, "1.5": 1500, "1500000n": 2, "2500u": 3, "": 0}
+	for q, expected := range cpus {
+		got, err := parseCPU(q)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", q, err)
+		}
+		if got != expected {
+			t.Errorf("parseCPU(%q) = %d, expected %d", q, got, expected)
+		}
+	}
+
+	mems := map[string]int64{"1024": 1024, "1Ki": 1024, "34Mi": 34 << 20, "1Gi": 1 << 30, "1M": 1e6, "2k": 2000}
+	for q, expected := range mems {
+		got, err := parseMemory(q)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", q, err)
+		}
+		if got != expected {
+			t.Errorf("parseMemory(%q) = %d, expected %d", q, got, expected)
+		}
+	}
+
+	if _, err := parseCPU("lots"); err == nil {
+		t.Error("Expected error for invalid CPU quantity")
+	}
+	if _, err := parseMemory("12Xi"); err == nil {
+		t.Error("Expected error for invalid memory quantity")
+	}
+}
+
+func TestParsePodMetrics(t *testing.T) {
+	pods := samplePods(t)
+	if len(pods) != 3 {
+		t.Fatalf("Expected 3 pods, got %d", len(pods))
+	}
+	api := pods[0]
+	if api.CPU != 255 || api.Memory != 144<<20 || len(api.Containers) != 2 {
+		t.Errorf("Unexpected totals for api pod: %+v", api)
+	}
+	if api.Containers[1].Name != "sidecar" || api.Containers[1].Memory != 16<<20 {
+		t.Errorf("Unexpected sidecar usage: %+v", api.Containers[1])
+	}
+}
+
+func names(rows []podUsage) string {
+	var s []string
+	for _, r := range rows {
+		s = append(s, r.Name)
+	}
+	return strings.Join(s, ",")
+}
+
+func TestView_SortAndFilter(t *testing.T) {
+	v := &view{namespaces: []string{""}, sort: sortState{column: sortByCPU, desc: true}}
+	v.setPods(samplePods(t), nil, time.Now())
+
+	if got := names(v.visibleRows()); got != "web-1,api-7d9f,cron-x" {
+		t.Errorf("CPU desc: got %s", got)
+	}
+
+	v.handleKey("r")
+	if got := names(v.visibleRows()); got != "cron-x,api-7d9f,web-1" {
+		t.Errorf("CPU asc: got %s", got)
+	}
+
+	v.handleKey("s") // memory, descending
+	if got := names(v.visibleRows()); got != "web-1,api-7d9f,cron-x" {
+		t.Errorf("Memory desc: got %s", got)
+	}
+
+	v.handleKey("s") // name, ascending
+	if got := names(v.visibleRows()); got != "cron-x,api-7d9f,web-1" {
+		t.Errorf("Name asc (namespace first): got %s", got)
+	}
+
+	for _, k := range []common.Key{"/", "P", "R", "O", "D", "/", "w", common.KeyEnter} {
+		v.handleKey(k)
+	}
+	if v.filtering || v.filter != "PROD/w" {
+		t.Errorf("Expected confirmed filter %q, got %q (filtering=%v)", "PROD/w", v.filter, v.filtering)
+	}
+	if got := names(v.visibleRows()); got != "web-1" {
+		t.Errorf("Filtered: got %s", got)
+	}
+
+	v.handleKey(common.KeyEsc)
+	if v.filter != "" || len(v.visibleRows()) != 3 {
+		t.Errorf("Expected esc to clear filter, got %q", v.filter)
+	}
+}
+
+func TestView_SelectionAndDetail(t *testing.T) {
+	v := &view{namespaces: []string{"prod", ""}, sort: sortState{column: sortByCPU, desc: true}}
+	v.setPods(samplePods(t), nil, time.Now())
+
+	v.handleKey(common.KeyDown)
+	v.handleKey(common.KeyDown)
+	v.handleKey(common.KeyDown) // stays on the last row
+	if v.selected != 2 {
+		t.Errorf("Expected selection clamped to 2, got %d", v.selected)
+	}
+	v.handleKey(common.KeyUp)
+	v.handleKey(common.KeyEnter)
+	if v.detail != "prod/api-7d9f" {
+		t.Fatalf("Expected detail for prod/api-7d9f, got %q", v.detail)
+	}
+
+	out := v.render(0, true)
+	for _, want := range []string{"Pod: prod/api-7d9f", "CONTAINER   CPU    MEMORY\n", "api         250m   128Mi\n", "sidecar     5m     16Mi\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected detail view to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	if v.handleKey("q") != actionNone || v.detail != "" {
+		t.Errorf("Expected q to leave the detail view")
+	}
+	if v.handleKey("q") != actionQuit {
+		t.Errorf("Expected q to quit from the table")
+	}
+}
+
+func TestView_NamespaceSwitch(t *testing.T) {
+	v := &view{namespaces: []string{"prod", "", "batch"}}
+	v.setPods(samplePods(t), nil, time.Now())
+	v.selected = 1
+
+	if v.handleKey("n") != actionNamespace || v.namespace() != "" {
+		t.Errorf("Expected n to switch to all namespaces, got %q", v.namespace())
+	}
+	if v.pods != nil || v.selected != 0 {
+		t.Errorf("Expected table to reset on namespace switch")
+	}
+	v.handleKey("N")
+	v.handleKey("N")
+	if v.namespace() != "batch" {
+		t.Errorf("Expected N to wrap around to batch, got %q", v.namespace())
+	}
+}
+
+func TestView_Render(t *testing.T) {
+	v := &view{namespaces: []string{""}, sort: sortState{column: sortByCPU, desc: true}}
+	v.setPods(samplePods(t), nil, time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC))
+
+	out := v.render(0, false)
+	expected := `Namespace: (all)   Sort: CPU ↓   Updated: 15:04:05
+
+NAMESPACE   NAME       CPU     MEMORY   CONTAINERS
+prod        web-1      1000m   512Mi    1
+prod        api-7d9f   255m    144Mi    2
+batch       cron-x     0m      8Mi      1
+`
+	if out != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	// Only a window of rows around the selection fits on small screens
+	v.selected = 2
+	out = v.render(6, true)
+	if strings.Contains(out, "web-1") || !strings.Contains(out, "\033[7mbatch       cron-x") {
+		t.Errorf("Expected scrolled view with cron-x selected, got:\n%s", out)
+	}
+
+	v.setPods(nil, errMetricsUnavailable, time.Now())
+	if out := v.render(0, true); !strings.Contains(out, "metrics-server") {
+		t.Errorf("Expected metrics-server hint, got:\n%s", out)
+	}
+}
+
+func TestIsMetricsUnavailable(t *testing.T) {
+	if !isMetricsUnavailable("Error from server (NotFound): the server could not find the requested resource") {
+		t.Error("Expected missing metrics API to be detected")
+	}
+	if isMetricsUnavailable("error: You must be logged in to the server (Unauthorized)") {
+		t.Error("Expected auth errors to be reported as is")
+	}
+	if !isMetricsUnavailable("Error from server (ServiceUnavailable): the server is currently unable to handle the request") {
+		t.Error("Expected a metrics-server that is down to be detected")
+	}
+	if isMetricsUnavailable(`Error from server (NotFound): namespaces "nope" not found`) {
+		t.Error("Expected a missing namespace to be reported as is")
+	}
+}
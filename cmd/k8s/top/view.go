@@ -0,0 +1,296 @@
+package top
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+type sortColumn int
+
+const (
+	sortByName sortColumn = iota
+	sortByCPU
+	sortByMemory
+)
+
+var sortColumnNames = []string{"NAME", "CPU", "MEMORY"}
+
+// sortState is the current sort column and direction. Cycling to a new
+// column resets the direction to what is most useful for it: names
+// ascending, usage descending.
+type sortState struct {
+	column sortColumn
+	desc   bool
+}
+
+func (s *sortState) next() {
+	s.column = (s.column + 1) % sortColumn(len(sortColumnNames))
+	s.desc = s.column != sortByName
+}
+
+func (s sortState) String() string {
+	arrow := "↑"
+	if s.desc {
+		arrow = "↓"
+	}
+	return sortColumnNames[s.column] + " " + arrow
+}
+
+type action int
+
+const (
+	actionNone action = iota
+	actionQuit
+	actionNamespace // the namespace changed, fetch right away
+)
+
+// view holds the interactive state of the pod table.
+type view struct {
+	pods       []podUsage
+	err        error
+	updated    time.Time
+	namespaces []string // "" means all namespaces
+	nsIndex    int
+	sort       sortState
+	filter     string
+	filtering  bool   // typing a filter after '/'
+	selected   int    // index into visibleRows
+	detail     string // namespace/name of the pod whose containers are shown
+}
+
+func (v *view) namespace() string {
+	return v.namespaces[v.nsIndex]
+}
+
+func (v *view) allNamespaces() bool {
+	return v.namespace() == ""
+}
+
+// setPods replaces the table contents after a refresh.
+func (v *view) setPods(pods []podUsage, err error, now time.Time) {
+	v.pods, v.err, v.updated = pods, err, now
+	v.clampSelection()
+}
+
+// visibleRows returns the filtered and sorted pods.
+func (v *view) visibleRows() []podUsage {
+	filter := strings.ToLower(v.filter)
+	var rows []podUsage
+	for _, p := range v.pods {
+		if filter == "" || strings.Contains(strings.ToLower(p.Namespace+"/"+p.Name), filter) {
+			rows = append(rows, p)
+		}
+	}
+
+	less := func(a, b podUsage) bool {
+		switch v.sort.column {
+		case sortByCPU:
+			if a.CPU != b.CPU {
+				return a.CPU < b.CPU
+			}
+		case sortByMemory:
+			if a.Memory != b.Memory {
+				return a.Memory < b.Memory
+			}
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if v.sort.desc {
+			return less(rows[j], rows[i])
+		}
+		return less(rows[i], rows[j])
+	})
+	return rows
+}
+
+func (v *view) clampSelection() {
+	n := len(v.visibleRows())
+	if v.selected >= n {
+		v.selected = n - 1
+	}
+	if v.selected < 0 {
+		v.selected = 0
+	}
+}
+
+func (v *view) detailPod() (podUsage, bool) {
+	for _, p := range v.pods {
+		if p.Namespace+"/"+p.Name == v.detail {
+			return p, true
+		}
+	}
+	return podUsage{}, false
+}
+
+func (v *view) handleKey(k common.Key) action {
+	if k == common.KeyCtrlC {
+		return actionQuit
+	}
+
+	if v.filtering {
+		switch k {
+		case common.KeyEnter:
+			v.filtering = false
+		case common.KeyEsc:
+			v.filtering = false
+			v.filter = ""
+		case common.KeyBackspace:
+			if v.filter != "" {
+				v.filter = v.filter[:len(v.filter)-1]
+			}
+		case common.KeyUp, common.KeyDown:
+		default:
+			v.filter += string(k)
+		}
+		v.clampSelection()
+		return actionNone
+	}
+
+	if v.detail != "" {
+		switch k {
+		case common.KeyEsc, common.KeyBackspace, common.KeyEnter, "q":
+			v.detail = ""
+		}
+		return actionNone
+	}
+
+	switch k {
+	case "q":
+		return actionQuit
+	case common.KeyUp, "k":
+		if v.selected > 0 {
+			v.selected--
+		}
+	case common.KeyDown, "j":
+		v.selected++
+		v.clampSelection()
+	case common.KeyEnter:
+		if rows := v.visibleRows(); v.selected < len(rows) {
+			v.detail = rows[v.selected].Namespace + "/" + rows[v.selected].Name
+		}
+	case "/":
+		v.filtering = true
+	case common.KeyEsc:
+		v.filter = ""
+		v.clampSelection()
+	case "s":
+		v.sort.next()
+	case "r":
+		v.sort.desc = !v.sort.desc
+	case "n", "N":
+		if k == "n" {
+			v.nsIndex = (v.nsIndex + 1) % len(v.namespaces)
+		} else {
+			v.nsIndex = (v.nsIndex + len(v.namespaces) - 1) % len(v.namespaces)
+		}
+		v.pods, v.err = nil, nil
+		v.selected = 0
+		return actionNamespace
+	}
+	return actionNone
+}
+
+// render draws the full screen, limited to height lines. Lines are separated
+// by "\n"; the caller adapts them for raw mode terminals.
+func (v *view) render(height int, interactive bool) string {
+	var sb strings.Builder
+
+	nsLabel := v.namespace()
+	if v.allNamespaces() {
+		nsLabel = "(all)"
+	}
+	header := fmt.Sprintf("Namespace: %s   Sort: %s", nsLabel, v.sort)
+	if v.filter != "" || v.filtering {
+		header += "   Filter: " + v.filter
+		if v.filtering {
+			header += "█"
+		}
+	}
+	if !v.updated.IsZero() {
+		header += "   Updated: " + v.updated.Format("15:04:05")
+	}
+	sb.WriteString(header + "\n")
+	if interactive {
+		if v.detail != "" {
+			sb.WriteString("esc/enter: back  q: back  ctrl+c: quit\n")
+		} else {
+			sb.WriteString("↑/↓: select  enter: containers  /: filter  s: sort  r: reverse  n/N: namespace  q: quit\n")
+		}
+	}
+	sb.WriteString("\n")
+	used := strings.Count(sb.String(), "\n")
+
+	if v.err != nil {
+		sb.WriteString(v.err.Error() + "\n")
+		return sb.String()
+	}
+
+	if v.detail != "" {
+		pod, ok := v.detailPod()
+		if !ok {
+			sb.WriteString("Pod " + v.detail + " is gone\n")
+			return sb.String()
+		}
+		sb.WriteString("Pod: " + v.detail + "\n\n")
+		table := [][]string{{"CONTAINER", "CPU", "MEMORY"}}
+		for _, c := range pod.Containers {
+			table = append(table, []string{c.Name, formatCPU(c.CPU), formatMemory(c.Memory)})
+		}
+		common.WriteTable(&sb, table, -1, 0, len(table))
+		return sb.String()
+	}
+
+	rows := v.visibleRows()
+	table := [][]string{v.columns()}
+	for _, p := range rows {
+		table = append(table, v.row(p))
+	}
+	if len(rows) == 0 {
+		if v.pods == nil {
+			sb.WriteString("Loading...\n")
+		} else {
+			sb.WriteString("No pods found\n")
+		}
+		return sb.String()
+	}
+
+	// Scroll so the selected row stays visible below the column headers
+	space := len(table) - 1
+	if height > 0 {
+		space = max(1, height-used-1)
+	}
+	offset := 0
+	if v.selected >= space {
+		offset = v.selected - space + 1
+	}
+	selected := -1
+	if interactive {
+		selected = v.selected
+	}
+	common.WriteTable(&sb, table, selected, offset, space)
+	return sb.String()
+}
+
+func (v *view) columns() []string {
+	cols := []string{"NAME", "CPU", "MEMORY", "CONTAINERS"}
+	if v.allNamespaces() {
+		cols = append([]string{"NAMESPACE"}, cols...)
+	}
+	return cols
+}
+
+func (v *view) row(p podUsage) []string {
+	r := []string{p.Name, formatCPU(p.CPU), formatMemory(p.Memory), fmt.Sprint(len(p.Containers))}
+	if v.allNamespaces() {
+		r = append([]string{p.Namespace}, r...)
+	}
+	return r
+}
@@ -3,7 +3,8 @@ package pick
 import (
 	"bufio"
 	"fmt"
-	"math/rand"
+	"io"
+	"math/rand/v2"
 	"os"
 	"strings"
 
@@ -13,18 +14,29 @@ import (
 )
 
 type Params struct {
-	Items []string `pos:"true" optional:"true" help:"Items to pick from. If none provided, reads from stdin."`
-	Count int      `short:"n" help:"Number of items to pick." default:"1"`
+	Items    []string `pos:"true" optional:"true" help:"Items to pick from. If none provided, reads from stdin."`
+	From     string   `short:"f" optional:"true" help:"Read items from this file, one per line, or from stdin with '-'."`
+	Count    int      `short:"n" help:"Number of items to pick." default:"1"`
+	Unique   bool     `short:"u" help:"Don't pick an item more than once."`
+	Weighted bool     `short:"w" help:"Items have a relative weight after their last ':', like 'pizza:3'."`
+	Seed     int64    `optional:"true" help:"Seed the random picks, to repeat them."`
 }
 
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
-		Use:         "pick",
-		Short:       "Randomly pick from a list",
-		Long:        "Randomly select items from arguments or stdin. Great for settling debates or choosing lunch spots.",
+		Use:   "pick",
+		Short: "Randomly pick from a list",
+		Long: `Randomly select items from arguments, a file or stdin. Great for settling debates or choosing lunch spots.
+
+Items are picked independently, so with -n the same item can come up again.
+Use --unique to pick each item at most once, e.g. to shuffle a whole list.`,
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			if err := Run(params); err != nil {
+			rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+			if cmd.Flags().Changed("seed") {
+				rng = rand.New(rand.NewPCG(uint64(params.Seed), 0))
+			}
+			if err := Run(params, os.Stdin, os.Stdout, rng); err != nil {
 				fmt.Fprintf(os.Stderr, "pick: %v\n", err)
 				os.Exit(1)
 			}
@@ -32,41 +44,74 @@ func Cmd() *cobra.Command {
 	}.ToCobra()
 }
 
-func Run(params *Params) error {
+func Run(params *Params, stdin io.Reader, stdout io.Writer, rng *rand.Rand) error {
+	if params.Count < 0 {
+		return fmt.Errorf("--count must not be negative")
+	}
 	items := params.Items
 
-	// If no args, read from stdin
-	if len(items) == 0 {
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line != "" {
-				items = append(items, line)
+	if params.From != "" {
+		r := stdin
+		if params.From != "-" {
+			f, err := os.Open(params.From)
+			if err != nil {
+				return err
 			}
+			defer f.Close()
+			r = f
 		}
-		if err := scanner.Err(); err != nil {
+		lines, err := readLines(r)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", params.From, err)
+		}
+		items = append(items, lines...)
+	} else if len(items) == 0 {
+		// If no args, read from stdin
+		lines, err := readLines(stdin)
+		if err != nil {
 			return fmt.Errorf("reading stdin: %w", err)
 		}
+		items = lines
 	}
 
 	if len(items) == 0 {
 		return fmt.Errorf("no items to pick from")
 	}
 
-	if params.Count > len(items) {
-		return fmt.Errorf("cannot pick %d items from %d options", params.Count, len(items))
+	// Unweighted, a ':' is just part of the item
+	choices := make([]common.Choice, 0, len(items))
+	for _, item := range items {
+		c := common.Choice{Value: item, Weight: 1}
+		if params.Weighted {
+			var err error
+			if c, err = common.ParseChoice(item); err != nil {
+				return err
+			}
+		}
+		choices = append(choices, c)
 	}
 
-	// Shuffle and pick
-	shuffled := make([]string, len(items))
-	copy(shuffled, items)
-	rand.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
-	})
-
-	for i := 0; i < params.Count; i++ {
-		fmt.Println(shuffled[i])
+	picked, err := common.SampleChoices(rng, choices, params.Count, params.Unique)
+	if err != nil {
+		return err
+	}
+	for _, item := range picked {
+		fmt.Fprintln(stdout, item)
 	}
 
 	return nil
 }
+
+// readLines returns the non-empty lines of r, trimmed.
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
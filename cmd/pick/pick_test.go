@@ -0,0 +1,131 @@
+package pick
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func seeded(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, 0))
+}
+
+func run(t *testing.T, params *Params, stdin string) []string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Run(params, strings.NewReader(stdin), &out, seeded(1)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return strings.Fields(out.String())
+}
+
+func TestRun_Sources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.txt")
+	if err := os.WriteFile(path, []byte("a\n\n  b  \nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		params Params
+		stdin  string
+		want   []string
+	}{
+		{"args", Params{Items: []string{"x", "y"}}, "ignored", []string{"x", "y"}},
+		{"stdin lines", Params{}, "p\n\nq\n", []string{"p", "q"}},
+		{"file", Params{From: path}, "ignored", []string{"a", "b", "c"}},
+		{"file from stdin", Params{From: "-"}, "s\nt\n", []string{"s", "t"}},
+		{"args and file", Params{Items: []string{"z"}, From: path}, "", []string{"a", "b", "c", "z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Count = len(tt.want)
+			tt.params.Unique = true
+			got := run(t, &tt.params, tt.stdin)
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRun_SeedRepeats(t *testing.T) {
+	params := &Params{Items: strings.Fields("a b c d e f g h"), Count: 20}
+	first := run(t, params, "")
+	if again := run(t, params, ""); !slices.Equal(first, again) {
+		t.Errorf("Expected the same picks with the same seed, got %q and %q", first, again)
+	}
+	if len(first) != 20 {
+		t.Errorf("Expected 20 picks, got %d", len(first))
+	}
+	// 20 picks from 8 items must repeat some
+	if slices.Sort(first); len(slices.Compact(first)) == 20 {
+		t.Errorf("Expected repeats without --unique")
+	}
+}
+
+func TestRun_UniqueShufflesLargeList(t *testing.T) {
+	items := make([]string, 100000)
+	for i := range items {
+		items[i] = strings.Repeat("x", i%7) + string(rune('a'+i%26)) + strings.Repeat("y", i/26%5)
+	}
+	var lines strings.Builder
+	for i := range items {
+		lines.WriteString(items[i])
+		lines.WriteString("\n")
+	}
+	got := run(t, &Params{Count: len(items), Unique: true}, lines.String())
+	slices.Sort(got)
+	slices.Sort(items)
+	if !slices.Equal(got, items) {
+		t.Errorf("Expected a shuffle of all items")
+	}
+}
+
+func TestRun_Weighted(t *testing.T) {
+	got := run(t, &Params{Items: []string{"never:0", "always:1", "12:30"}, Count: 50, Weighted: true}, "")
+	counts := map[string]int{}
+	for _, v := range got {
+		counts[v]++
+	}
+	if counts["never"] != 0 || counts["always"] == 0 || counts["12"] == 0 || counts["always"] > counts["12"] {
+		t.Errorf("Expected 12 (weight 30) more often than always, and never not at all, got %v", counts)
+	}
+
+	got = run(t, &Params{Items: []string{"a:1", "b:100", "c:0"}, Count: 2, Unique: true, Weighted: true}, "")
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Expected a and b, got %q", got)
+	}
+
+	// Unweighted, the colon is part of the item
+	got = run(t, &Params{Items: []string{"12:30"}, Count: 1}, "")
+	if !slices.Equal(got, []string{"12:30"}) {
+		t.Errorf("Expected 12:30, got %q", got)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		params Params
+		want   string
+	}{
+		{Params{Count: 1}, "no items to pick from"},
+		{Params{Items: []string{"a", "b"}, Count: 3, Unique: true}, "cannot pick 3 unique values from 2 with a weight above 0"},
+		{Params{Items: []string{"a:1", "b:0"}, Count: 2, Unique: true, Weighted: true}, "cannot pick 2 unique values from 1 with a weight above 0"},
+		{Params{Items: []string{"a:0"}, Count: 1, Weighted: true}, "at least one value needs a weight above 0"},
+		{Params{Items: []string{"a:-1"}, Count: 1, Weighted: true}, `invalid weight in "a:-1", must be a non-negative number`},
+		{Params{Items: []string{"a"}, Count: -1}, "--count must not be negative"},
+	}
+	for _, tt := range tests {
+		err := Run(&tt.params, strings.NewReader(""), &bytes.Buffer{}, seeded(1))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Expected error %q, got %v", tt.want, err)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package ping
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"os"
 	"os/signal"
@@ -18,12 +20,43 @@ import (
 )
 
 type Params struct {
-	Host     string  `pos:"true" required:"true" help:"Host to ping."`
-	Count    int     `short:"c" optional:"true" help:"Stop after sending count ECHO_REQUEST packets." default:"0"`
-	Interval float64 `short:"i" optional:"true" help:"Wait interval seconds between sending each packet." default:"1"`
-	Timeout  float64 `short:"W" optional:"true" help:"Time to wait for a response, in seconds." default:"5"`
-	IPv4     bool    `short:"4" optional:"true" help:"Use IPv4 only."`
-	IPv6     bool    `short:"6" optional:"true" help:"Use IPv6 only."`
+	Host       string  `pos:"true" required:"true" help:"Host to ping."`
+	Count      int     `short:"c" optional:"true" help:"Stop after sending count ECHO_REQUEST packets." default:"0"`
+	Interval   float64 `short:"i" optional:"true" help:"Wait interval seconds between sending each packet." default:"1"`
+	Timeout    float64 `short:"W" optional:"true" help:"Time to wait for a response, in seconds." default:"5"`
+	IPv4       bool    `short:"4" optional:"true" help:"Use IPv4 only."`
+	IPv6       bool    `short:"6" optional:"true" help:"Use IPv6 only."`
+	Tcp        int     `optional:"true" help:"Measure round-trip time by opening a TCP connection to this port instead of sending ICMP. Does not require root." default:"0"`
+	Json       bool    `short:"j" optional:"true" help:"Print the summary statistics as a JSON object instead of text."`
+	JsonStream bool    `optional:"true" help:"Print each probe as a line of JSON, followed by the summary. Implies --json."`
+	Audible    bool    `short:"a" optional:"true" help:"Ring the terminal bell on each reply, e.g. to hear when a host comes up."`
+	Rapid      bool    `optional:"true" help:"Send each probe as soon as the previous one is answered or times out, instead of waiting --interval. Capped at 100 probes per second."`
+}
+
+// rapidMaxRate caps --rapid, so a fast host on the local network isn't
+// flooded.
+const rapidMaxRate = 100
+
+// ProbeOutput is one line of --json-stream output.
+type ProbeOutput struct {
+	Seq     int      `json:"seq"`
+	Address string   `json:"address"`
+	TimeMs  *float64 `json:"time_ms,omitempty"`
+	Timeout bool     `json:"timeout,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// SummaryOutput is the --json summary.
+type SummaryOutput struct {
+	Host        string   `json:"host"`
+	Address     string   `json:"address"`
+	Transmitted int      `json:"transmitted"`
+	Received    int      `json:"received"`
+	LossPercent float64  `json:"loss_percent"`
+	MinMs       *float64 `json:"min_ms,omitempty"`
+	AvgMs       *float64 `json:"avg_ms,omitempty"`
+	MaxMs       *float64 `json:"max_ms,omitempty"`
+	StddevMs    *float64 `json:"stddev_ms,omitempty"`
 }
 
 type pingStats struct {
@@ -32,13 +65,91 @@ type pingStats struct {
 	minRTT      time.Duration
 	maxRTT      time.Duration
 	totalRTT    time.Duration
+	sumSquares  float64 // of RTTs in ms, for the standard deviation
+}
+
+func (s *pingStats) record(rtt time.Duration) {
+	s.received++
+	s.totalRTT += rtt
+	if rtt < s.minRTT {
+		s.minRTT = rtt
+	}
+	if rtt > s.maxRTT {
+		s.maxRTT = rtt
+	}
+	ms := float64(rtt.Microseconds()) / 1000.0
+	s.sumSquares += ms * ms
+}
+
+// printer writes probe results as text, or as JSON lines with --json-stream.
+// With --json, the text lines are left out so stdout is only JSON.
+type printer struct {
+	stdout  io.Writer
+	stderr  io.Writer
+	json    bool
+	stream  bool
+	audible bool
+}
+
+func (p *printer) text(format string, args ...any) {
+	if !p.json {
+		fmt.Fprintf(p.stdout, format, args...)
+	}
+}
+
+func (p *printer) probe(out ProbeOutput) {
+	if p.stream {
+		_ = json.NewEncoder(p.stdout).Encode(out)
+	}
+}
+
+func (p *printer) reply(seq int, address string, rtt time.Duration) {
+	ms := float64(rtt.Microseconds()) / 1000.0
+	p.probe(ProbeOutput{Seq: seq, Address: address, TimeMs: &ms})
+	p.bell()
+}
+
+// bell rings the terminal bell with --audible. Only replies ring it, not
+// timeouts or errors. It goes to stderr when stdout is JSON.
+func (p *printer) bell() {
+	if !p.audible {
+		return
+	}
+	if p.json {
+		fmt.Fprint(p.stderr, "\a")
+	} else {
+		fmt.Fprint(p.stdout, "\a")
+	}
+}
+
+func (p *printer) failure(seq int, address string, err error) {
+	p.probe(ProbeOutput{Seq: seq, Address: address, Error: err.Error()})
+}
+
+// summary prints the final statistics. With --json-stream it is one more
+// JSON line, otherwise indented like the JSON output of other commands.
+func (p *printer) summary(host, address string, stats *pingStats) {
+	if !p.json {
+		printStats(host, stats, p.stdout)
+		return
+	}
+	encoder := json.NewEncoder(p.stdout)
+	if !p.stream {
+		encoder.SetIndent("", "  ")
+	}
+	_ = encoder.Encode(stats.summary(host, address))
+}
+
+func (p *printer) timeout(seq int, address, proto string) {
+	p.text("Request timeout for %s_seq %d\n", proto, seq)
+	p.probe(ProbeOutput{Seq: seq, Address: address, Timeout: true})
 }
 
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
 		Use:         "ping",
 		Short:       "Send ICMP ECHO_REQUEST to network hosts",
-		Long:        "ping uses the ICMP protocol's mandatory ECHO_REQUEST datagram to elicit an ICMP ECHO_RESPONSE from a host or gateway. Requires root/sudo on most systems.",
+		Long:        "ping uses the ICMP protocol's mandatory ECHO_REQUEST datagram to elicit an ICMP ECHO_RESPONSE from a host or gateway. Requires root/sudo on most systems. With --tcp, it instead times how long it takes to open a TCP connection to the given port, which works for hosts that drop ICMP, such as many cloud load balancers.",
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			exitCode := Run(params, os.Stdout, os.Stderr)
@@ -74,27 +185,45 @@ func Run(params *Params, stdout, stderr io.Writer) int {
 		return 1
 	}
 
-	isIPv6 := addr.To4() == nil
-
-	var network string
-	if isIPv6 {
-		network = "ip6:ipv6-icmp"
-	} else {
-		network = "ip4:icmp"
-	}
-
-	conn, err := icmp.ListenPacket(network, "")
-	if err != nil {
-		fmt.Fprintf(stderr, "ping: %v (try running with sudo)\n", err)
+	if params.Tcp < 0 || params.Tcp > 65535 {
+		fmt.Fprintf(stderr, "ping: invalid port: %d\n", params.Tcp)
 		return 1
 	}
-	defer conn.Close()
-
-	fmt.Fprintf(stdout, "PING %s (%s): 56 data bytes\n", params.Host, addr.String())
 
 	stats := &pingStats{
 		minRTT: time.Hour,
 	}
+	out := &printer{stdout: stdout, stderr: stderr, json: params.Json || params.JsonStream, stream: params.JsonStream, audible: params.Audible}
+
+	var probe func(seq int)
+	if params.Tcp > 0 {
+		target := net.JoinHostPort(addr.String(), fmt.Sprint(params.Tcp))
+		out.text("TCP PING %s (%s): port %d\n", params.Host, addr.String(), params.Tcp)
+		probe = func(seq int) {
+			sendTCPPing(target, seq, params, out, stats)
+		}
+	} else {
+		isIPv6 := addr.To4() == nil
+
+		var network string
+		if isIPv6 {
+			network = "ip6:ipv6-icmp"
+		} else {
+			network = "ip4:icmp"
+		}
+
+		conn, err := icmp.ListenPacket(network, "")
+		if err != nil {
+			fmt.Fprintf(stderr, "ping: %v (try running with sudo, or use --tcp)\n", err)
+			return 1
+		}
+		defer conn.Close()
+
+		out.text("PING %s (%s): 56 data bytes\n", params.Host, addr.String())
+		probe = func(seq int) {
+			sendPing(conn, addr, seq, isIPv6, params, out, stderr, stats)
+		}
+	}
 
 	// Handle interrupt
 	sigChan := make(chan os.Signal, 1)
@@ -106,39 +235,80 @@ func Run(params *Params, stdout, stderr io.Writer) int {
 		done <- true
 	}()
 
+	finish := func() int {
+		out.summary(params.Host, addr.String(), stats)
+		if stats.received == 0 {
+			return 1
+		}
+		return 0
+	}
+
 	seq := 0
+	if params.Rapid {
+		scheduler := newRapidScheduler(rapidMaxRate, time.Now, time.Sleep)
+		for {
+			select {
+			case <-done:
+				return finish()
+			default:
+			}
+			if params.Count > 0 && stats.transmitted >= params.Count {
+				return finish()
+			}
+			scheduler.wait()
+			probe(seq)
+			seq++
+			stats.transmitted++
+		}
+	}
+
 	ticker := time.NewTicker(time.Duration(params.Interval * float64(time.Second)))
 	defer ticker.Stop()
 
 	// Send first ping immediately
-	sendPing(conn, addr, seq, isIPv6, params, stdout, stderr, stats)
+	probe(seq)
 	seq++
 	stats.transmitted++
 
 	for {
 		select {
 		case <-done:
-			printStats(params.Host, stats, stdout)
-			if stats.received == 0 {
-				return 1
-			}
-			return 0
+			return finish()
 		case <-ticker.C:
 			if params.Count > 0 && stats.transmitted >= params.Count {
-				printStats(params.Host, stats, stdout)
-				if stats.received == 0 {
-					return 1
-				}
-				return 0
+				return finish()
 			}
-			sendPing(conn, addr, seq, isIPv6, params, stdout, stderr, stats)
+			probe(seq)
 			seq++
 			stats.transmitted++
 		}
 	}
 }
 
-func sendPing(conn *icmp.PacketConn, addr net.IP, seq int, isIPv6 bool, params *Params, stdout, stderr io.Writer, stats *pingStats) {
+// rapidScheduler paces --rapid probes. Probes go out back to back, but the
+// start of one is never less than minGap after the start of the previous.
+type rapidScheduler struct {
+	minGap time.Duration
+	now    func() time.Time
+	sleep  func(time.Duration)
+	last   time.Time
+}
+
+func newRapidScheduler(maxRate int, now func() time.Time, sleep func(time.Duration)) *rapidScheduler {
+	return &rapidScheduler{minGap: time.Second / time.Duration(maxRate), now: now, sleep: sleep}
+}
+
+// wait blocks until the next probe may be sent.
+func (s *rapidScheduler) wait() {
+	if !s.last.IsZero() {
+		if d := s.minGap - s.now().Sub(s.last); d > 0 {
+			s.sleep(d)
+		}
+	}
+	s.last = s.now()
+}
+
+func sendPing(conn *icmp.PacketConn, addr net.IP, seq int, isIPv6 bool, params *Params, out *printer, stderr io.Writer, stats *pingStats) {
 	var msgType icmp.Type
 	if isIPv6 {
 		msgType = ipv6.ICMPTypeEchoRequest
@@ -159,6 +329,7 @@ func sendPing(conn *icmp.PacketConn, addr net.IP, seq int, isIPv6 bool, params *
 	msgBytes, err := msg.Marshal(nil)
 	if err != nil {
 		fmt.Fprintf(stderr, "ping: %v\n", err)
+		out.failure(seq, addr.String(), err)
 		return
 	}
 
@@ -167,6 +338,7 @@ func sendPing(conn *icmp.PacketConn, addr net.IP, seq int, isIPv6 bool, params *
 	start := time.Now()
 	if _, err := conn.WriteTo(msgBytes, dst); err != nil {
 		fmt.Fprintf(stderr, "ping: %v\n", err)
+		out.failure(seq, addr.String(), err)
 		return
 	}
 
@@ -176,10 +348,11 @@ func sendPing(conn *icmp.PacketConn, addr net.IP, seq int, isIPv6 bool, params *
 	n, _, err := conn.ReadFrom(reply)
 	if err != nil {
 		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			fmt.Fprintf(stdout, "Request timeout for icmp_seq %d\n", seq)
+			out.timeout(seq, addr.String(), "icmp")
 			return
 		}
 		fmt.Fprintf(stderr, "ping: %v\n", err)
+		out.failure(seq, addr.String(), err)
 		return
 	}
 
@@ -195,37 +368,85 @@ func sendPing(conn *icmp.PacketConn, addr net.IP, seq int, isIPv6 bool, params *
 	parsedMsg, err := icmp.ParseMessage(proto, reply[:n])
 	if err != nil {
 		fmt.Fprintf(stderr, "ping: %v\n", err)
+		out.failure(seq, addr.String(), err)
 		return
 	}
 
 	switch parsedMsg.Type {
 	case ipv4.ICMPTypeEchoReply, ipv6.ICMPTypeEchoReply:
-		stats.received++
-		stats.totalRTT += rtt
-		if rtt < stats.minRTT {
-			stats.minRTT = rtt
-		}
-		if rtt > stats.maxRTT {
-			stats.maxRTT = rtt
-		}
-		fmt.Fprintf(stdout, "64 bytes from %s: icmp_seq=%d time=%.3f ms\n",
+		stats.record(rtt)
+		out.text("64 bytes from %s: icmp_seq=%d time=%.3f ms\n",
 			addr.String(), seq, float64(rtt.Microseconds())/1000.0)
+		out.reply(seq, addr.String(), rtt)
 	default:
-		fmt.Fprintf(stdout, "Unexpected ICMP message type: %v\n", parsedMsg.Type)
+		out.text("Unexpected ICMP message type: %v\n", parsedMsg.Type)
+		out.failure(seq, addr.String(), fmt.Errorf("unexpected ICMP message type: %v", parsedMsg.Type))
 	}
 }
 
+// sendTCPPing times opening a TCP connection to target, closing it again
+// right away. Refused connections and timeouts count as lost packets.
+func sendTCPPing(target string, seq int, params *Params, out *printer, stats *pingStats) {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", target, time.Duration(params.Timeout*float64(time.Second)))
+	rtt := time.Since(start)
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			out.timeout(seq, target, "tcp")
+			return
+		}
+		out.text("Connection to %s failed for tcp_seq %d: %v\n", target, seq, unwrapOpError(err))
+		out.failure(seq, target, unwrapOpError(err))
+		return
+	}
+	conn.Close()
+
+	stats.record(rtt)
+	out.text("Connected to %s: tcp_seq=%d time=%.3f ms\n",
+		target, seq, float64(rtt.Microseconds())/1000.0)
+	out.reply(seq, target, rtt)
+}
+
+// unwrapOpError drops the "dial tcp 1.2.3.4:80:" prefix, which repeats the
+// target already shown.
+func unwrapOpError(err error) error {
+	if opErr, ok := err.(*net.OpError); ok && opErr.Err != nil {
+		return opErr.Err
+	}
+	return err
+}
+
+func (s *pingStats) summary(host, address string) SummaryOutput {
+	out := SummaryOutput{
+		Host:        host,
+		Address:     address,
+		Transmitted: s.transmitted,
+		Received:    s.received,
+		LossPercent: float64(s.transmitted-s.received) / float64(s.transmitted) * 100,
+	}
+	if s.received > 0 {
+		ms := func(d time.Duration) *float64 {
+			v := float64(d.Microseconds()) / 1000.0
+			return &v
+		}
+		out.MinMs = ms(s.minRTT)
+		out.AvgMs = ms(s.totalRTT / time.Duration(s.received))
+		out.MaxMs = ms(s.maxRTT)
+		stddev := math.Sqrt(max(0, s.sumSquares/float64(s.received)-*out.AvgMs**out.AvgMs))
+		stddev = math.Round(stddev*1000) / 1000
+		out.StddevMs = &stddev
+	}
+	return out
+}
+
 func printStats(host string, stats *pingStats, stdout io.Writer) {
+	summary := stats.summary(host, "")
 	fmt.Fprintf(stdout, "\n--- %s ping statistics ---\n", host)
-	loss := float64(stats.transmitted-stats.received) / float64(stats.transmitted) * 100
 	fmt.Fprintf(stdout, "%d packets transmitted, %d packets received, %.1f%% packet loss\n",
-		stats.transmitted, stats.received, loss)
-
-	if stats.received > 0 {
-		avg := stats.totalRTT / time.Duration(stats.received)
-		fmt.Fprintf(stdout, "round-trip min/avg/max = %.3f/%.3f/%.3f ms\n",
-			float64(stats.minRTT.Microseconds())/1000.0,
-			float64(avg.Microseconds())/1000.0,
-			float64(stats.maxRTT.Microseconds())/1000.0)
+		summary.Transmitted, summary.Received, summary.LossPercent)
+
+	if summary.Received > 0 {
+		fmt.Fprintf(stdout, "round-trip min/avg/max/stddev = %.3f/%.3f/%.3f/%.3f ms\n",
+			*summary.MinMs, *summary.AvgMs, *summary.MaxMs, *summary.StddevMs)
 	}
 }
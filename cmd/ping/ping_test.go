@@ -0,0 +1,261 @@
+package ping
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen accepts and closes TCP connections until the test ends.
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return ln
+}
+
+func TestRun_Tcp(t *testing.T) {
+	ln := listen(t)
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	var stdout, stderr bytes.Buffer
+	params := &Params{Host: "127.0.0.1", Count: 3, Interval: 0.01, Timeout: 1, Tcp: port}
+	if code := Run(params, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d, stderr: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	if !strings.HasPrefix(out, "TCP PING 127.0.0.1 (127.0.0.1): port ") {
+		t.Errorf("Unexpected header, got:\n%s", out)
+	}
+	if n := strings.Count(out, "Connected to "+ln.Addr().String()+": tcp_seq="); n != 3 {
+		t.Errorf("Expected 3 connections, got %d:\n%s", n, out)
+	}
+	if !strings.Contains(out, "3 packets transmitted, 3 packets received, 0.0% packet loss") {
+		t.Errorf("Expected no loss, got:\n%s", out)
+	}
+	if !strings.Contains(out, "round-trip min/avg/max/stddev = ") {
+		t.Errorf("Expected round-trip statistics, got:\n%s", out)
+	}
+}
+
+func TestRun_TcpRefused(t *testing.T) {
+	// Grab a free port, then close it so nothing is listening
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	var stdout, stderr bytes.Buffer
+	params := &Params{Host: "127.0.0.1", Count: 1, Interval: 0.01, Timeout: 1, Tcp: port}
+	if code := Run(params, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+
+	out := stdout.String()
+	if !strings.Contains(out, "failed for tcp_seq 0: ") {
+		t.Errorf("Expected failed connection, got:\n%s", out)
+	}
+	if !strings.Contains(out, "1 packets transmitted, 0 packets received, 100.0% packet loss") {
+		t.Errorf("Expected full loss, got:\n%s", out)
+	}
+	if strings.Contains(out, "round-trip") {
+		t.Errorf("Expected no round-trip statistics, got:\n%s", out)
+	}
+}
+
+func TestRun_Json(t *testing.T) {
+	ln := listen(t)
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	var stdout, stderr bytes.Buffer
+	params := &Params{Host: "127.0.0.1", Count: 2, Interval: 0.01, Timeout: 1, Tcp: port, Json: true}
+	if code := Run(params, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d, stderr: %s", code, stderr.String())
+	}
+
+	// Only the summary, as one JSON document
+	var summary SummaryOutput
+	if err := json.Unmarshal(stdout.Bytes(), &summary); err != nil {
+		t.Fatalf("Expected JSON output, got %v:\n%s", err, stdout.String())
+	}
+	if summary.Host != "127.0.0.1" || summary.Address != "127.0.0.1" || summary.Transmitted != 2 || summary.Received != 2 || summary.LossPercent != 0 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if summary.MinMs == nil || summary.AvgMs == nil || summary.MaxMs == nil || summary.StddevMs == nil {
+		t.Errorf("Expected round-trip statistics, got %s", stdout.String())
+	}
+}
+
+func TestRun_JsonStream(t *testing.T) {
+	ln := listen(t)
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	var stdout, stderr bytes.Buffer
+	params := &Params{Host: "127.0.0.1", Count: 2, Interval: 0.01, Timeout: 1, Tcp: port, JsonStream: true}
+	if code := Run(params, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d, stderr: %s", code, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 2 probes and a summary, got:\n%s", stdout.String())
+	}
+	for i, line := range lines[:2] {
+		var probe ProbeOutput
+		if err := json.Unmarshal([]byte(line), &probe); err != nil {
+			t.Fatalf("Expected JSON line, got %v: %s", err, line)
+		}
+		if probe.Seq != i || probe.Address != ln.Addr().String() || probe.TimeMs == nil || probe.Timeout || probe.Error != "" {
+			t.Errorf("Unexpected probe: %s", line)
+		}
+	}
+	var summary SummaryOutput
+	if err := json.Unmarshal([]byte(lines[2]), &summary); err != nil || summary.Received != 2 {
+		t.Errorf("Expected summary line, got %s", lines[2])
+	}
+}
+
+func TestRun_JsonStreamRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	var stdout, stderr bytes.Buffer
+	params := &Params{Host: "127.0.0.1", Count: 1, Interval: 0.01, Timeout: 1, Tcp: port, JsonStream: true}
+	if code := Run(params, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+
+	expectedSummary := `{"host":"127.0.0.1","address":"127.0.0.1","transmitted":1,"received":0,"loss_percent":100}`
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"error":"connect: connection refused"`) || lines[1] != expectedSummary {
+		t.Errorf("Expected failed probe and summary, got:\n%s", stdout.String())
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	stats := &pingStats{minRTT: time.Hour}
+	for _, ms := range []int{10, 20, 30} {
+		stats.transmitted++
+		stats.record(time.Duration(ms) * time.Millisecond)
+	}
+	stats.transmitted++
+
+	var stdout bytes.Buffer
+	printStats("example.com", stats, &stdout)
+
+	expected := `
+--- example.com ping statistics ---
+4 packets transmitted, 3 packets received, 25.0% packet loss
+round-trip min/avg/max/stddev = 10.000/20.000/30.000/8.165 ms
+`
+	if got := stdout.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestPrinterBell(t *testing.T) {
+	tests := []struct {
+		name       string
+		printer    printer
+		wantStdout int
+		wantStderr int
+	}{
+		{"audible", printer{audible: true}, 1, 0},
+		{"audible json", printer{audible: true, json: true}, 0, 1},
+		{"silent", printer{}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			p := tt.printer
+			p.stdout, p.stderr = &stdout, &stderr
+
+			// Only the reply may ring the bell
+			p.timeout(0, "127.0.0.1", "tcp")
+			p.failure(1, "127.0.0.1", net.ErrClosed)
+			p.reply(2, "127.0.0.1", time.Millisecond)
+
+			if n := strings.Count(stdout.String(), "\a"); n != tt.wantStdout {
+				t.Errorf("Expected %d bells on stdout, got %d", tt.wantStdout, n)
+			}
+			if n := strings.Count(stderr.String(), "\a"); n != tt.wantStderr {
+				t.Errorf("Expected %d bells on stderr, got %d", tt.wantStderr, n)
+			}
+		})
+	}
+}
+
+func TestRapidScheduler(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	s := newRapidScheduler(100, func() time.Time { return now }, func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	})
+
+	// Each probe takes this long before the next wait
+	for _, probeTime := range []time.Duration{3 * time.Millisecond, 0, 25 * time.Millisecond, 10 * time.Millisecond} {
+		s.wait()
+		now = now.Add(probeTime)
+	}
+	s.wait()
+
+	expected := []time.Duration{7 * time.Millisecond, 10 * time.Millisecond}
+	if len(sleeps) != len(expected) {
+		t.Fatalf("Expected sleeps %v, got %v", expected, sleeps)
+	}
+	for i := range expected {
+		if sleeps[i] != expected[i] {
+			t.Errorf("Expected sleeps %v, got %v", expected, sleeps)
+			break
+		}
+	}
+}
+
+func TestRun_TcpRapidAudible(t *testing.T) {
+	ln := listen(t)
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	var stdout, stderr bytes.Buffer
+	// The interval would make this take 50 seconds without --rapid
+	params := &Params{Host: "127.0.0.1", Count: 5, Interval: 10, Timeout: 1, Tcp: port, Rapid: true, Audible: true}
+	start := time.Now()
+	if code := Run(params, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d, stderr: %s", code, stderr.String())
+	}
+	elapsed := time.Since(start)
+
+	// 5 probes at most 100 per second need at least 40ms
+	if elapsed < 40*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("Expected rapid probes capped at 100/s, took %v", elapsed)
+	}
+	out := stdout.String()
+	if n := strings.Count(out, "\a"); n != 5 {
+		t.Errorf("Expected 5 bells, got %d:\n%q", n, out)
+	}
+	if !strings.Contains(out, "5 packets transmitted, 5 packets received, 0.0% packet loss") {
+		t.Errorf("Expected no loss, got:\n%s", out)
+	}
+}
@@ -2,6 +2,7 @@ package pomodoro
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,105 +14,278 @@ import (
 )
 
 type Params struct {
-	Work       int  `short:"w" help:"Work duration in minutes." default:"25"`
-	Break      int  `short:"b" help:"Break duration in minutes." default:"5"`
-	LongBreak  int  `short:"l" help:"Long break duration in minutes." default:"15"`
-	Sessions   int  `short:"n" help:"Number of sessions before long break." default:"4"`
-	Continuous bool `short:"c" help:"Run continuously (multiple pomodoros)." default:"false"`
+	Work       int    `short:"w" help:"Work duration in minutes." default:"25"`
+	Break      int    `short:"b" help:"Break duration in minutes." default:"5"`
+	LongBreak  int    `short:"l" help:"Long break duration in minutes." default:"15"`
+	Sessions   int    `short:"n" help:"Number of sessions before long break." default:"4"`
+	Continuous bool   `short:"c" help:"Run continuously (multiple pomodoros)." default:"false"`
+	Task       string `short:"t" optional:"true" help:"What you are working on, recorded with each completed pomodoro."`
+	Bell       bool   `help:"Ring the terminal bell when a period ends." default:"true"`
+	Notify     bool   `help:"Show a desktop notification when a period ends." default:"true"`
 }
 
+type StatusParams struct{}
+
+type ResumeParams struct{}
+
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
-		Use:         "pomodoro",
-		Short:       "Pomodoro timer for productivity",
-		Long:        "A simple pomodoro timer. Default: 25min work, 5min break, 15min long break after 4 sessions.",
+		Use:   "pomodoro",
+		Short: "Pomodoro timer for productivity",
+		Long: `A simple pomodoro timer. Default: 25min work, 5min break, 15min long break after 4 sessions.
+
+The timer is saved in the tofu config directory, so 'pomodoro status' works from
+another terminal, and a timer stopped with Ctrl+C or by closing the terminal can
+be continued with 'pomodoro resume'. Completed pomodoros are recorded for
+'pomodoro stats'.`,
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds: []*cobra.Command{
+			statusCmd(),
+			resumeCmd(),
+			statsCmd(),
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			Run(params)
+			if err := Run(params); err != nil {
+				fmt.Fprintf(os.Stderr, "pomodoro: %v\n", err)
+				os.Exit(1)
+			}
 		},
 	}.ToCobra()
 }
 
-func Run(params *Params) {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+func statusCmd() *cobra.Command {
+	return boa.CmdT[StatusParams]{
+		Use:         "status",
+		Short:       "Show the current pomodoro, also from another terminal",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *StatusParams, cmd *cobra.Command, args []string) {
+			if err := runStatus(defaultStore(), time.Now(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "pomodoro: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func resumeCmd() *cobra.Command {
+	return boa.CmdT[ResumeParams]{
+		Use:         "resume",
+		Short:       "Continue a paused or interrupted pomodoro",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *ResumeParams, cmd *cobra.Command, args []string) {
+			if err := runResume(defaultStore()); err != nil {
+				fmt.Fprintf(os.Stderr, "pomodoro: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
 
-	session := 1
-	totalSessions := params.Sessions
+func Run(params *Params) error {
+	if params.Work <= 0 || params.Break < 0 || params.LongBreak < 0 {
+		return fmt.Errorf("durations must be positive")
+	}
+	st := defaultStore()
+	existing, err := st.loadState()
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.running(time.Now()) {
+		return fmt.Errorf("a pomodoro is already running in another terminal, see 'tofu pomodoro status'")
+	}
+	return runTimer(st, newTimerState(*params))
+}
+
+func runResume(st *store) error {
+	state, err := st.loadState()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if state == nil {
+		return fmt.Errorf("no pomodoro to resume")
+	}
+	if state.running(now) {
+		return fmt.Errorf("the pomodoro is still running in another terminal")
+	}
+	if !state.paused() {
+		// Interrupted without pausing, e.g. by closing the terminal
+		if left := state.EndsAt.Sub(now); left > 0 {
+			state.EndsAt, state.Remaining = time.Time{}, left
+		} else {
+			// The period ended while no timer was running, so start the next
+			completePeriod(st, state, state.EndsAt)
+			if !state.next() {
+				fmt.Println("🎉 The pomodoro finished while no timer was running. Great work!")
+				return st.clearState()
+			}
+		}
+	}
+	return runTimer(st, state)
+}
+
+// runTimer counts down the periods of state, saving it as it goes. Ctrl+C
+// pauses the timer, leaving the state to resume from.
+func runTimer(st *store, state *timerState) error {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
 
 	// Clear screen and hide cursor
 	fmt.Print("\033[2J\033[H") // Clear screen and move cursor to top-left
 	fmt.Print("\033[?25l")     // Hide cursor
 	defer fmt.Print("\033[?25h\n")
 
+	save := func() {
+		state.Heartbeat = time.Now()
+		if err := st.saveState(state); err != nil {
+			fmt.Fprintf(os.Stderr, "\npomodoro: failed to save state: %v\n", err)
+		}
+	}
+
 	for {
-		// Work session
-		fmt.Printf("\n🍅 Pomodoro #%d - Work time! (%d minutes)\n", session, params.Work)
-		if !countdown(params.Work*60, "🍅 Working", sigChan) {
-			return
+		total := state.duration()
+		left := total
+		if state.Remaining > 0 {
+			left = state.Remaining
 		}
-		playBell()
-		fmt.Printf("\n✅ Work session complete!\n")
-
-		// Break
-		var breakDuration int
-		var breakType string
-		if session%totalSessions == 0 {
-			breakDuration = params.LongBreak
-			breakType = "☕ Long break"
+		state.EndsAt, state.Remaining = time.Now().Add(left), 0
+		save()
+
+		if state.Phase == phaseWork {
+			fmt.Printf("\n%s - Work time! (%d minutes)\n", state.label(), state.Settings.Work)
 		} else {
-			breakDuration = params.Break
-			breakType = "☕ Short break"
+			fmt.Printf("\n%s time! (%d minutes)\n", state.label(), int(total/time.Minute))
 		}
-
-		fmt.Printf("\n%s time! (%d minutes)\n", breakType, breakDuration)
-		if !countdown(breakDuration*60, breakType, sigChan) {
-			return
+		if !countdown(total, state.EndsAt, state.label(), sigChan, save) {
+			state.Remaining = max(time.Until(state.EndsAt), time.Second)
+			state.EndsAt = time.Time{}
+			if err := st.saveState(state); err != nil {
+				return fmt.Errorf("failed to save state: %w", err)
+			}
+			fmt.Printf("\n⏸  Paused with %s left. Resume with: tofu pomodoro resume\n", formatClock(state.Remaining))
+			return nil
 		}
-		playBell()
-		fmt.Printf("\n✅ Break complete!\n")
 
-		session++
+		ended := *state
+		completePeriod(st, state, time.Now())
+		more := state.next()
+		alert(&ended, state, more)
+		if ended.Phase == phaseWork {
+			fmt.Printf("\n✅ Work session complete!\n")
+		} else {
+			fmt.Printf("\n✅ Break complete!\n")
+		}
 
-		if !params.Continuous {
+		if !more {
 			fmt.Printf("\n🎉 Pomodoro session finished! Great work!\n")
-			return
+			return st.clearState()
 		}
 	}
 }
 
-func countdown(seconds int, label string, sigChan chan os.Signal) bool {
+// completePeriod records a completed work period in the log.
+func completePeriod(st *store, state *timerState, at time.Time) {
+	if state.Phase != phaseWork {
+		return
+	}
+	entry := logEntry{CompletedAt: at, Minutes: state.Settings.Work, Task: state.Settings.Task}
+	if err := st.appendLog(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "\npomodoro: failed to record pomodoro: %v\n", err)
+	}
+}
+
+// alert announces the end of a period with the bell and a desktop
+// notification, as enabled in the settings.
+func alert(ended, next *timerState, more bool) {
+	if ended.Settings.Bell {
+		playBell()
+	}
+	if !ended.Settings.Notify {
+		return
+	}
+	title, message := "Break over", "Time to focus!"
+	switch {
+	case ended.Phase == phaseWork:
+		title = "Pomodoro complete"
+		message = fmt.Sprintf("%s! Take %d minutes off.", next.label(), int(next.duration()/time.Minute))
+	case !more:
+		message = "Pomodoro session finished. Great work!"
+	}
+	// Best effort, there may be no desktop to notify
+	_ = common.Notify(title, message)
+}
+
+func runStatus(st *store, now time.Time, out io.Writer) error {
+	state, err := st.loadState()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		fmt.Fprintln(out, "No pomodoro running")
+		return nil
+	}
+
+	left := state.remaining(now)
+	switch {
+	case state.running(now):
+		fmt.Fprintf(out, "%s: %s left, ends at %s\n", state.label(), formatClock(left), state.EndsAt.Local().Format("15:04"))
+	case state.paused():
+		fmt.Fprintf(out, "%s: paused with %s left. Resume with: tofu pomodoro resume\n", state.label(), formatClock(left))
+	case left > 0:
+		fmt.Fprintf(out, "%s: interrupted with %s left. Resume with: tofu pomodoro resume\n", state.label(), formatClock(left))
+	default:
+		fmt.Fprintf(out, "%s: ended at %s while no timer was running. Start the next period with: tofu pomodoro resume\n",
+			state.label(), state.EndsAt.Local().Format("15:04"))
+	}
+	return nil
+}
+
+func countdown(total time.Duration, endsAt time.Time, label string, sigChan chan os.Signal, heartbeat func()) bool {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
-	remaining := seconds
+	lastBeat := time.Now()
+	for {
+		remaining := max(time.Until(endsAt), 0)
+
+		// Progress bar
+		progress := 1.0
+		if total > 0 {
+			progress = float64(total-remaining) / float64(total)
+		}
+		barWidth := 30
+		filled := int(progress * float64(barWidth))
+		bar := ""
+		for i := 0; i < barWidth; i++ {
+			if i < filled {
+				bar += "█"
+			} else {
+				bar += "░"
+			}
+		}
+
+		fmt.Printf("\r\033[K%s [%s] %s ", label, bar, formatClock(remaining))
+		if remaining == 0 {
+			return true
+		}
 
-	for remaining >= 0 {
 		select {
 		case <-sigChan:
 			return false
 		case <-ticker.C:
-			mins := remaining / 60
-			secs := remaining % 60
-
-			// Progress bar
-			progress := float64(seconds-remaining) / float64(seconds)
-			barWidth := 30
-			filled := int(progress * float64(barWidth))
-			bar := ""
-			for i := 0; i < barWidth; i++ {
-				if i < filled {
-					bar += "█"
-				} else {
-					bar += "░"
-				}
+			if time.Since(lastBeat) >= heartbeatInterval {
+				heartbeat()
+				lastBeat = time.Now()
 			}
-
-			fmt.Printf("\r\033[K%s [%s] %02d:%02d ", label, bar, mins, secs)
-			remaining--
 		}
 	}
-	return true
+}
+
+// formatClock formats a duration as mm:ss, rounded up to whole seconds.
+func formatClock(d time.Duration) string {
+	secs := int((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
 }
 
 func playBell() {
@@ -0,0 +1,213 @@
+package pomodoro
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSettings() Params {
+	return Params{Work: 25, Break: 5, LongBreak: 15, Sessions: 2}
+}
+
+func TestNext(t *testing.T) {
+	s := newTimerState(testSettings())
+	if !s.next() || s.Phase != phaseBreak {
+		t.Fatalf("Expected a short break after session 1, got %q", s.Phase)
+	}
+	if s.next() {
+		t.Errorf("Expected a non-continuous timer to end after its break")
+	}
+
+	settings := testSettings()
+	settings.Continuous = true
+	s = newTimerState(settings)
+	var phases []string
+	for range 6 {
+		if !s.next() {
+			t.Fatalf("Expected a continuous timer to keep going")
+		}
+		phases = append(phases, string(s.Phase))
+	}
+	want := "break work long-break work break work"
+	if got := strings.Join(phases, " "); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if s.Session != 4 {
+		t.Errorf("Expected session 4, got %d", s.Session)
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	st := &store{dir: t.TempDir()}
+	if state, err := st.loadState(); err != nil || state != nil {
+		t.Fatalf("Expected no state, got %v, %v", state, err)
+	}
+
+	settings := testSettings()
+	settings.Task = "docs"
+	state := newTimerState(settings)
+	state.Remaining = 3 * time.Minute
+	if err := st.saveState(state); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaded, err := st.loadState()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded.Settings.Task != "docs" || loaded.Remaining != 3*time.Minute || !loaded.paused() {
+		t.Errorf("Unexpected state after round trip: %+v", loaded)
+	}
+
+	if err := st.clearState(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state, _ := st.loadState(); state != nil {
+		t.Errorf("Expected state to be cleared, got %+v", state)
+	}
+
+	at := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for _, task := range []string{"a", "b"} {
+		if err := st.appendLog(logEntry{CompletedAt: at, Minutes: 25, Task: task}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	entries, err := st.loadLog()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Task != "b" || !entries[0].CompletedAt.Equal(at) {
+		t.Errorf("Unexpected log: %+v", entries)
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	running := newTimerState(testSettings())
+	running.EndsAt, running.Heartbeat = now.Add(90*time.Second), now.Add(-5*time.Second)
+	paused := newTimerState(testSettings())
+	paused.Remaining = 2 * time.Minute
+	interrupted := newTimerState(testSettings())
+	interrupted.EndsAt, interrupted.Heartbeat = now.Add(time.Minute), now.Add(-time.Hour)
+	ended := newTimerState(testSettings())
+	ended.Phase = phaseBreak
+	ended.EndsAt, ended.Heartbeat = now.Add(-time.Minute), now.Add(-time.Hour)
+
+	tests := []struct {
+		name  string
+		state *timerState
+		want  string
+	}{
+		{"none", nil, "No pomodoro running"},
+		{"running", running, "🍅 Pomodoro #1: 01:30 left, ends at 10:01"},
+		{"paused", paused, "🍅 Pomodoro #1: paused with 02:00 left"},
+		{"interrupted", interrupted, "🍅 Pomodoro #1: interrupted with 01:00 left"},
+		{"ended", ended, "☕ Short break: ended at 09:59 while no timer was running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &store{dir: t.TempDir()}
+			if tt.state != nil {
+				if err := st.saveState(tt.state); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			var out bytes.Buffer
+			if err := runStatus(st, now, &out); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(out.String(), tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestResumeRecordsEndedWorkPeriod(t *testing.T) {
+	st := &store{dir: t.TempDir()}
+	settings := testSettings()
+	settings.Break = 0
+	settings.Task = "review"
+	state := newTimerState(settings)
+	state.EndsAt, state.Heartbeat = time.Now().Add(-time.Minute), time.Now().Add(-time.Hour)
+	if err := st.saveState(state); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := runResume(st); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	entries, err := st.loadLog()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Task != "review" || !entries[0].CompletedAt.Equal(state.EndsAt) {
+		t.Errorf("Expected the ended pomodoro to be recorded, got %+v", entries)
+	}
+	if state, _ := st.loadState(); state != nil {
+		t.Errorf("Expected the finished timer to be cleared, got %+v", state)
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	// Wednesday
+	now := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC)
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	entries := []logEntry{
+		{CompletedAt: at(3, 4, 9), Minutes: 25, Task: "a"},
+		{CompletedAt: at(3, 4, 10), Minutes: 25, Task: "b"},
+		{CompletedAt: at(3, 2, 9), Minutes: 50, Task: "a"},  // Monday this week
+		{CompletedAt: at(3, 1, 9), Minutes: 25},             // Sunday last week
+		{CompletedAt: at(2, 23, 9), Minutes: 25, Task: "a"}, // Monday last week
+		{CompletedAt: at(2, 22, 9), Minutes: 25, Task: "b"}, // two weeks ago
+	}
+
+	s := computeStats(entries, now, 3, 2)
+	if s.total.count != 6 || s.total.minutes != 175 {
+		t.Errorf("Expected total 6 / 175m, got %+v", s.total)
+	}
+	if !s.first.Equal(at(2, 22, 9)) {
+		t.Errorf("Expected first %v, got %v", at(2, 22, 9), s.first)
+	}
+	wantDays := []tally{{2, 50}, {0, 0}, {1, 50}}
+	for i, want := range wantDays {
+		if s.days[i] != want {
+			t.Errorf("Day %d: expected %+v, got %+v", i, want, s.days[i])
+		}
+	}
+	wantWeeks := []tally{{3, 100}, {2, 50}}
+	for i, want := range wantWeeks {
+		if s.weeks[i] != want {
+			t.Errorf("Week %d: expected %+v, got %+v", i, want, s.weeks[i])
+		}
+	}
+	var tasks []string
+	for _, task := range s.tasks {
+		tasks = append(tasks, task.task)
+	}
+	if got := strings.Join(tasks, ","); got != "a,b," {
+		t.Errorf("Expected tasks ordered by focus time %q, got %q", "a,b,", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	clocks := map[time.Duration]string{
+		0:                       "00:00",
+		1500 * time.Millisecond: "00:02",
+		25 * time.Minute:        "25:00",
+	}
+	for d, want := range clocks {
+		if got := formatClock(d); got != want {
+			t.Errorf("formatClock(%v): expected %q, got %q", d, want, got)
+		}
+	}
+	minutes := map[int]string{0: "0m", 45: "45m", 100: "1h 40m", 125: "2h 05m"}
+	for m, want := range minutes {
+		if got := formatMinutes(m); got != want {
+			t.Errorf("formatMinutes(%d): expected %q, got %q", m, want, got)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package pomodoro
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+type phase string
+
+const (
+	phaseWork      phase = "work"
+	phaseBreak     phase = "break"
+	phaseLongBreak phase = "long-break"
+)
+
+// heartbeatInterval is how often a running timer updates its state file.
+// A timer whose heartbeat is older than staleAfter was interrupted without
+// pausing, e.g. by closing the terminal.
+const (
+	heartbeatInterval = 10 * time.Second
+	staleAfter        = 3 * heartbeatInterval
+)
+
+// timerState is the current timer, persisted so that other terminals can
+// show its status and an interrupted timer can be resumed.
+type timerState struct {
+	Settings Params `json:"settings"`
+	Phase    phase  `json:"phase"`
+	Session  int    `json:"session"`
+	// EndsAt is set while the timer runs, Remaining while it is paused
+	EndsAt    time.Time     `json:"ends_at,omitzero"`
+	Remaining time.Duration `json:"remaining,omitempty"`
+	Heartbeat time.Time     `json:"heartbeat,omitzero"`
+}
+
+func newTimerState(settings Params) *timerState {
+	return &timerState{Settings: settings, Phase: phaseWork, Session: 1}
+}
+
+func (s *timerState) paused() bool {
+	return s.EndsAt.IsZero()
+}
+
+// running reports whether a timer process is still counting down.
+func (s *timerState) running(now time.Time) bool {
+	return !s.paused() && now.Sub(s.Heartbeat) < staleAfter
+}
+
+func (s *timerState) duration() time.Duration {
+	minutes := s.Settings.Work
+	switch s.Phase {
+	case phaseBreak:
+		minutes = s.Settings.Break
+	case phaseLongBreak:
+		minutes = s.Settings.LongBreak
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// remaining returns the time left of the current period, which is negative if
+// it ended while no timer was running.
+func (s *timerState) remaining(now time.Time) time.Duration {
+	if s.paused() {
+		return s.Remaining
+	}
+	return s.EndsAt.Sub(now)
+}
+
+// next moves to the period after the current one. It returns false when the
+// timer is done, after the break of a non-continuous pomodoro.
+func (s *timerState) next() bool {
+	s.EndsAt, s.Remaining = time.Time{}, 0
+	if s.Phase == phaseWork {
+		s.Phase = phaseBreak
+		if s.Settings.Sessions > 0 && s.Session%s.Settings.Sessions == 0 {
+			s.Phase = phaseLongBreak
+		}
+		return true
+	}
+	if !s.Settings.Continuous {
+		return false
+	}
+	s.Phase = phaseWork
+	s.Session++
+	return true
+}
+
+func (s *timerState) label() string {
+	switch s.Phase {
+	case phaseBreak:
+		return "☕ Short break"
+	case phaseLongBreak:
+		return "☕ Long break"
+	}
+	label := fmt.Sprintf("🍅 Pomodoro #%d", s.Session)
+	if s.Settings.Task != "" {
+		label += " (" + s.Settings.Task + ")"
+	}
+	return label
+}
+
+// logEntry is a completed work period.
+type logEntry struct {
+	CompletedAt time.Time `json:"completed_at"`
+	Minutes     int       `json:"minutes"`
+	Task        string    `json:"task,omitempty"`
+}
+
+// store persists the timer state and the log of completed pomodoros.
+type store struct {
+	dir string
+}
+
+func defaultStore() *store {
+	return &store{dir: filepath.Join(common.ConfigDir(), "pomodoro")}
+}
+
+func (s *store) statePath() string { return filepath.Join(s.dir, "state.json") }
+func (s *store) logPath() string   { return filepath.Join(s.dir, "log.jsonl") }
+
+// loadState returns the saved timer, or nil if there is none.
+func (s *store) loadState() (*timerState, error) {
+	var state timerState
+	if found, err := common.ReadJSONFile(s.statePath(), &state); !found {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// saveState replaces the state file atomically, so that status in another
+// terminal never reads it half written.
+func (s *store) saveState(state *timerState) error {
+	return common.WriteJSONFile(s.statePath(), state)
+}
+
+func (s *store) clearState() error {
+	err := os.Remove(s.statePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *store) appendLog(entry logEntry) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.logPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadLog returns the completed pomodoros, skipping lines that can't be
+// parsed, such as one cut short by a crash.
+func (s *store) loadLog() ([]logEntry, error) {
+	f, err := os.Open(s.logPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []logEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry logEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,174 @@
+package pomodoro
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type StatsParams struct {
+	Days  int `short:"d" help:"Number of days to show counts for." default:"7"`
+	Weeks int `short:"w" help:"Number of weeks to show counts for." default:"4"`
+}
+
+func statsCmd() *cobra.Command {
+	return boa.CmdT[StatsParams]{
+		Use:         "stats",
+		Short:       "Show completed pomodoros per day and week, and total focus time",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *StatsParams, cmd *cobra.Command, args []string) {
+			if err := runStats(params, defaultStore(), time.Now(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "pomodoro: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+// tally is the number of pomodoros and focus time in some period.
+type tally struct {
+	count   int
+	minutes int
+}
+
+func (t *tally) add(e logEntry) {
+	t.count++
+	t.minutes += e.Minutes
+}
+
+type taskTally struct {
+	task string
+	tally
+}
+
+type stats struct {
+	total tally
+	first time.Time
+	days  []tally // today first
+	weeks []tally // this week first, weeks start on Monday
+	tasks []taskTally
+}
+
+// computeStats tallies the log in now's location.
+func computeStats(entries []logEntry, now time.Time, days, weeks int) stats {
+	s := stats{days: make([]tally, days), weeks: make([]tally, weeks)}
+	today := startOfDay(now)
+	thisWeek := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+
+	byTask := map[string]*tally{}
+	for _, e := range entries {
+		at := e.CompletedAt.In(now.Location())
+		s.total.add(e)
+		if s.first.IsZero() || at.Before(s.first) {
+			s.first = at
+		}
+		if i := daysBetween(startOfDay(at), today); i >= 0 && i < days {
+			s.days[i].add(e)
+		}
+		// Days before this week's Monday, 1-7 being last week
+		if n := daysBetween(startOfDay(at), thisWeek); n > -7 {
+			if w := (max(n, 0) + 6) / 7; w < weeks {
+				s.weeks[w].add(e)
+			}
+		}
+		task := e.Task
+		if byTask[task] == nil {
+			byTask[task] = &tally{}
+		}
+		byTask[task].add(e)
+	}
+
+	for task, t := range byTask {
+		s.tasks = append(s.tasks, taskTally{task: task, tally: *t})
+	}
+	slices.SortFunc(s.tasks, func(a, b taskTally) int {
+		if a.minutes != b.minutes {
+			return b.minutes - a.minutes
+		}
+		return strings.Compare(a.task, b.task)
+	})
+	return s
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween counts calendar days from a to b, which must both be at the
+// start of a day. It is exact across DST changes, unlike dividing durations.
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua) / (24 * time.Hour))
+}
+
+func runStats(params *StatsParams, st *store, now time.Time, out io.Writer) error {
+	if params.Days < 0 || params.Weeks < 0 {
+		return fmt.Errorf("--days and --weeks can't be negative")
+	}
+	entries, err := st.loadLog()
+	if err != nil {
+		return fmt.Errorf("failed to read log: %w", err)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No completed pomodoros yet")
+		return nil
+	}
+
+	s := computeStats(entries, now, params.Days, params.Weeks)
+	fmt.Fprintf(out, "Total: %d pomodoros, %s focus time since %s\n",
+		s.total.count, formatMinutes(s.total.minutes), s.first.Format("2006-01-02"))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	row := func(label string, t tally) {
+		fmt.Fprintf(w, "  %s\t%3d 🍅\t%s\n", label, t.count, formatMinutes(t.minutes))
+	}
+	if params.Days > 0 {
+		fmt.Fprintf(w, "\nLast %d days:\n", params.Days)
+		day := startOfDay(now)
+		for i, t := range s.days {
+			row(day.AddDate(0, 0, -i).Format("Mon 2006-01-02"), t)
+		}
+	}
+	if params.Weeks > 0 {
+		fmt.Fprintf(w, "\nLast %d weeks:\n", params.Weeks)
+		today := startOfDay(now)
+		monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		for i, t := range s.weeks {
+			row("Week of "+monday.AddDate(0, 0, -7*i).Format("2006-01-02"), t)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(s.tasks) > 1 || (len(s.tasks) == 1 && s.tasks[0].task != "") {
+		fmt.Fprintln(out, "\nBy task:")
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, t := range s.tasks {
+			task := t.task
+			if task == "" {
+				task = "(no task)"
+			}
+			fmt.Fprintf(tw, "  %s\t%3d 🍅\t%s\n", task, t.count, formatMinutes(t.minutes))
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+// formatMinutes formats a duration like "1h 40m".
+func formatMinutes(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
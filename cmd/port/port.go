@@ -1,6 +1,7 @@
 package port
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -20,10 +21,21 @@ type Params struct {
 	Kill    bool `short:"k" help:"Kill the process listening on the specified port." default:"false"`
 	UDP     bool `short:"u" help:"Include UDP ports (TCP is default)." default:"false"`
 	All     bool `short:"a" help:"Show all ports (not just listening)." default:"false"`
+
+	Scan     string `optional:"true" help:"Scan these TCP ports for open ones instead, e.g. 8000-8100 or 22,80,443."`
+	Host     string `help:"Host to scan with --scan." default:"localhost"`
+	Workers  int    `short:"w" help:"Ports to scan concurrently with --scan." default:"100"`
+	Timeout  int64  `short:"t" help:"Connect timeout per port with --scan (ms)." default:"500"`
+	Deadline int64  `help:"Stop a --scan after this long, 0 for no limit (ms)." default:"60000"`
+	Verbose  bool   `short:"v" help:"With --scan, show closed and filtered ports too." default:"false"`
 }
 
 // Run now takes io.Writer for stdout and stderr for testability
 func Run(params *Params, stdout, stderr io.Writer) error {
+	if params.Scan != "" {
+		return runScan(context.Background(), params, stdout)
+	}
+
 	if params.Kill && params.PortNum == 0 {
 		return fmt.Errorf("--kill requires a specific port number")
 	}
@@ -148,7 +160,7 @@ func getProto(t uint32) string {
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
 		Use:         "port",
-		Short:       "List or kill processes by port",
+		Short:       "List or kill processes by port, or scan for open ports",
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if err := Run(params, os.Stdout, os.Stderr); err != nil {
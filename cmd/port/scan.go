@@ -0,0 +1,203 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// Port states reported by a scan
+const (
+	stateOpen     = "open"
+	stateClosed   = "closed"
+	stateFiltered = "filtered" // no answer within the timeout
+	stateError    = "error"
+	stateSkipped  = "skipped" // not scanned before the deadline
+)
+
+type scanResult struct {
+	Port  int
+	State string
+	Err   error
+}
+
+// parsePortSpec parses a comma separated list of ports and ranges, like
+// "22,80,8000-8100", into sorted unique port numbers.
+func parsePortSpec(spec string) ([]int, error) {
+	seen := map[int]bool{}
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := parsePortNumber(lo)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parsePortNumber(hi); err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid port range %s: end is before start", part)
+			}
+		}
+		for p := first; p <= last; p++ {
+			seen[p] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("no ports in %q", spec)
+	}
+
+	ports := make([]int, 0, len(seen))
+	for p := range seen {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+func parsePortNumber(s string) (int, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || p < 1 || p > 65535 {
+		return 0, fmt.Errorf("invalid port %q, must be 1-65535", s)
+	}
+	return p, nil
+}
+
+// resolveScanHost looks the host up once, preferring IPv4 since that is
+// what most services listen on, so a scan doesn't resolve it per port.
+func resolveScanHost(ctx context.Context, host string) (string, error) {
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+			return addr, nil
+		}
+	}
+	return addrs[0], nil
+}
+
+// scanPorts tries a TCP connection to each port with up to workers at a
+// time. Ports not tried before ctx is done are reported as skipped.
+func scanPorts(ctx context.Context, ip string, ports []int, workers int, timeout time.Duration) []scanResult {
+	results := make([]scanResult, len(ports))
+	for i, p := range ports {
+		results[i] = scanResult{Port: p, State: stateSkipped}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range max(1, min(workers, len(ports))) {
+		wg.Go(func() {
+			dialer := net.Dialer{Timeout: timeout}
+			for i := range jobs {
+				conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(ports[i])))
+				if err == nil {
+					conn.Close()
+				}
+				if ctx.Err() != nil {
+					// Interrupted by the deadline, the port may not have had its full timeout
+					continue
+				}
+				results[i].State, results[i].Err = classifyDial(err)
+			}
+		})
+	}
+
+feed:
+	for i := range ports {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func classifyDial(err error) (string, error) {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return stateOpen, nil
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return stateClosed, nil
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return stateFiltered, nil
+	default:
+		return stateError, err
+	}
+}
+
+// runScan scans params.Scan on params.Host and prints the open ports, or
+// all ports when verbose.
+func runScan(ctx context.Context, params *Params, stdout io.Writer) error {
+	if params.Kill || params.UDP || params.All || params.PortNum != 0 {
+		return fmt.Errorf("--scan cannot be combined with a port argument, --kill, --udp or --all")
+	}
+	if params.Workers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+	ports, err := parsePortSpec(params.Scan)
+	if err != nil {
+		return err
+	}
+
+	if params.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(params.Deadline)*time.Millisecond)
+		defer cancel()
+	}
+	ip, err := resolveScanHost(ctx, params.Host)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	results := scanPorts(ctx, ip, ports, params.Workers, time.Duration(params.Timeout)*time.Millisecond)
+	elapsed := time.Since(start)
+
+	open, skipped := 0, 0
+	w := tabwriter.NewWriter(stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "PORT\tSTATE")
+	for _, r := range results {
+		switch r.State {
+		case stateOpen:
+			open++
+		case stateSkipped:
+			skipped++
+		}
+		if r.State != stateOpen && !params.Verbose {
+			continue
+		}
+		if r.Err != nil {
+			fmt.Fprintf(w, "%d\t%s (%v)\n", r.Port, r.State, r.Err)
+		} else {
+			fmt.Fprintf(w, "%d\t%s\n", r.Port, r.State)
+		}
+	}
+	w.Flush()
+
+	fmt.Fprintf(stdout, "\nScanned %d ports on %s in %v: %d open\n", len(ports)-skipped, params.Host, elapsed.Round(time.Millisecond), open)
+	if skipped > 0 {
+		return fmt.Errorf("deadline of %dms reached, %d of %d ports not scanned", params.Deadline, skipped, len(ports))
+	}
+	return nil
+}
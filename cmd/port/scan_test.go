@@ -0,0 +1,133 @@
+package port
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePortSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []int
+	}{
+		{"80", []int{80}},
+		{"443,22,80", []int{22, 80, 443}},
+		{"8000-8003", []int{8000, 8001, 8002, 8003}},
+		{"22, 8000-8002,8001,", []int{22, 8000, 8001, 8002}},
+		{"65535", []int{65535}},
+	}
+	for _, tt := range tests {
+		got, err := parsePortSpec(tt.spec)
+		if err != nil {
+			t.Errorf("parsePortSpec(%q): unexpected error: %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePortSpec(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", ",", "0", "65536", "http", "100-90", "1-", "-5"} {
+		if _, err := parsePortSpec(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+// openAndClosedPorts returns a port with a listener and one without
+func openAndClosedPorts(t *testing.T) (int, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	return ln.Addr().(*net.TCPAddr).Port, closedPort
+}
+
+func TestScanPorts(t *testing.T) {
+	open, closed := openAndClosedPorts(t)
+
+	results := scanPorts(context.Background(), "127.0.0.1", []int{open, closed}, 2, time.Second)
+	if results[0].State != stateOpen {
+		t.Errorf("Expected port %d open, got %s (%v)", open, results[0].State, results[0].Err)
+	}
+	if results[1].State != stateClosed {
+		t.Errorf("Expected port %d closed, got %s (%v)", closed, results[1].State, results[1].Err)
+	}
+
+	// Nothing is scanned once the deadline has passed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range scanPorts(ctx, "127.0.0.1", []int{open, closed}, 1, time.Second) {
+		if r.State != stateSkipped {
+			t.Errorf("Expected port %d skipped, got %s", r.Port, r.State)
+		}
+	}
+}
+
+func TestRunScan(t *testing.T) {
+	open, closed := openAndClosedPorts(t)
+	spec := fmt.Sprintf("%d,%d", open, closed)
+
+	var stdout bytes.Buffer
+	params := &Params{Scan: spec, Host: "127.0.0.1", Workers: 10, Timeout: 1000, Deadline: 10000}
+	if err := Run(params, &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out := stdout.String()
+	if !strings.Contains(out, fmt.Sprintf("%d   open", open)) {
+		t.Errorf("Expected port %d open in output, got:\n%s", open, out)
+	}
+	if strings.Contains(out, fmt.Sprint(closed)) {
+		t.Errorf("Expected closed port %d hidden without -v, got:\n%s", closed, out)
+	}
+	if !strings.Contains(out, "Scanned 2 ports on 127.0.0.1 in ") || !strings.HasSuffix(out, ": 1 open\n") {
+		t.Errorf("Unexpected summary in:\n%s", out)
+	}
+
+	stdout.Reset()
+	params.Verbose = true
+	if err := Run(params, &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), fmt.Sprintf("%d   closed", closed)) {
+		t.Errorf("Expected port %d closed with -v, got:\n%s", closed, stdout.String())
+	}
+}
+
+func TestRunScanInvalid(t *testing.T) {
+	for _, params := range []*Params{
+		{Scan: "80", Host: "127.0.0.1", Workers: 10, Kill: true},
+		{Scan: "80", Host: "127.0.0.1", Workers: 10, PortNum: 80},
+		{Scan: "80", Host: "127.0.0.1", Workers: 0},
+		{Scan: "80-70", Host: "127.0.0.1", Workers: 10},
+	} {
+		if err := Run(params, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+			t.Errorf("Expected error for %+v", params)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// headerRewrites are the --set-header and --remove-header changes for one
+// direction of traffic.
+type headerRewrites struct {
+	set    [][2]string
+	remove []string
+}
+
+func parseHeaderRewrites(set, remove []string) (headerRewrites, error) {
+	var rw headerRewrites
+	for _, s := range set {
+		name, value, ok := strings.Cut(s, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return rw, fmt.Errorf("invalid header %q, expected name=value", s)
+		}
+		rw.set = append(rw.set, [2]string{http.CanonicalHeaderKey(name), value})
+	}
+	for _, name := range remove {
+		rw.remove = append(rw.remove, http.CanonicalHeaderKey(strings.TrimSpace(name)))
+	}
+	return rw, nil
+}
+
+// apply removes headers first, so a header can be both removed and set to
+// replace all its values with one.
+func (rw headerRewrites) apply(h http.Header) {
+	for _, name := range rw.remove {
+		h.Del(name)
+	}
+	for _, kv := range rw.set {
+		h.Set(kv[0], kv[1])
+	}
+}
+
+// httpMode reports whether any option needs the proxy to understand HTTP
+// rather than forward bytes.
+func (p *Params) httpMode() bool {
+	return p.Log || len(p.SetHeader) > 0 || len(p.RemoveHeader) > 0 ||
+		len(p.SetResponseHeader) > 0 || len(p.RemoveResponseHeader) > 0
+}
+
+// targetURL turns the target into a URL. A plain host:port target is
+// reached over plain HTTP.
+func targetURL(target string) (*url.URL, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid target %q, expected host:port or an http(s) URL", target)
+	}
+	return u, nil
+}
+
+// newHTTPProxy returns a reverse proxy to params.Target that rewrites
+// headers and, with --log, writes a line per request to out.
+func newHTTPProxy(params *Params, out io.Writer) (http.Handler, error) {
+	target, err := targetURL(params.Target)
+	if err != nil {
+		return nil, err
+	}
+	requestRewrites, err := parseHeaderRewrites(params.SetHeader, params.RemoveHeader)
+	if err != nil {
+		return nil, err
+	}
+	responseRewrites, err := parseHeaderRewrites(params.SetResponseHeader, params.RemoveResponseHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Connect timeout and retries work as for TCP, against the target's address
+	dialParams := *params
+	dialParams.Target = target.Host
+	if target.Port() == "" {
+		port := "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+		dialParams.Target = net.JoinHostPort(target.Hostname(), port)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		id, _ := ctx.Value(requestIDKey{}).(int64)
+		return dialWithRetry(&dialParams, id)
+	}
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			requestRewrites.apply(pr.Out.Header)
+			if host := pr.Out.Header.Get("Host"); host != "" {
+				pr.Out.Host = host
+				pr.Out.Header.Del("Host")
+			}
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			responseRewrites.apply(resp.Header)
+			return nil
+		},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if sw, ok := w.(*statusWriter); ok {
+				sw.err = err
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	var requestCount atomic.Int64
+	var sem chan struct{}
+	if params.MaxConns > 0 {
+		sem = make(chan struct{}, params.MaxConns)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestCount.Add(1)
+		start := time.Now()
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		if sem != nil {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			default:
+				http.Error(sw, "too many concurrent requests", http.StatusServiceUnavailable)
+				sw.err = fmt.Errorf("max connections: %d", params.MaxConns)
+			}
+		}
+		if sw.err == nil {
+			rp.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		}
+
+		if params.Log {
+			line := fmt.Sprintf("[%d] %s %s -> %d %s (%s)", id, r.Method, r.RequestURI, sw.status,
+				http.StatusText(sw.status), time.Since(start).Round(time.Millisecond))
+			if sw.err != nil {
+				line += ": " + sw.err.Error()
+			}
+			fmt.Fprintln(out, line)
+		}
+	}), nil
+}
+
+// runHTTP serves the HTTP proxy on ln.
+func runHTTP(params *Params, ln net.Listener, out io.Writer) error {
+	handler, err := newHTTPProxy(params, out)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:     handler,
+		IdleTimeout: time.Duration(params.IdleTimeout) * time.Millisecond,
+	}
+	if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", err)
+	}
+	return nil
+}
+
+type requestIDKey struct{}
+
+// statusWriter captures the status of a proxied response, and the error
+// when the target couldn't be reached.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	err    error
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	sw.status = code
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// Hijack hands over the client connection for Upgrade requests, like
+// websockets, which the reverse proxy then joins to the target's
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(sw.ResponseWriter).Hijack()
+	if err == nil {
+		sw.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
+// Flush keeps streamed responses, like server-sent events, flowing
+func (sw *statusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,256 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the proxy's handler goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestParseHeaderRewrites(t *testing.T) {
+	rw, err := parseHeaderRewrites([]string{"x-api-key=abc=def", "X-Empty="}, []string{"cookie"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	h := http.Header{"Cookie": {"a=b"}, "X-Api-Key": {"old", "older"}}
+	rw.apply(h)
+	if got := h.Values("X-Api-Key"); len(got) != 1 || got[0] != "abc=def" {
+		t.Errorf("Expected X-Api-Key [abc=def], got %q", got)
+	}
+	if _, ok := h["X-Empty"]; !ok {
+		t.Error("Expected X-Empty to be set")
+	}
+	if h.Get("Cookie") != "" {
+		t.Errorf("Expected Cookie removed, got %q", h.Get("Cookie"))
+	}
+
+	for _, bad := range []string{"no-equals", "=value", " =value"} {
+		if _, err := parseHeaderRewrites([]string{bad}, nil); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestHttpMode(t *testing.T) {
+	if (&Params{}).httpMode() {
+		t.Error("Expected TCP mode without HTTP flags")
+	}
+	for _, p := range []Params{
+		{Log: true},
+		{SetHeader: []string{"a=b"}},
+		{RemoveHeader: []string{"a"}},
+		{SetResponseHeader: []string{"a=b"}},
+		{RemoveResponseHeader: []string{"a"}},
+	} {
+		if !p.httpMode() {
+			t.Errorf("Expected HTTP mode for %+v", p)
+		}
+	}
+}
+
+func TestTargetURL(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"localhost:3000", "http://localhost:3000"},
+		{"http://example.com/api", "http://example.com/api"},
+		{"https://example.com", "https://example.com"},
+	}
+	for _, tt := range tests {
+		u, err := targetURL(tt.target)
+		if err != nil {
+			t.Errorf("targetURL(%q): unexpected error: %v", tt.target, err)
+			continue
+		}
+		if u.String() != tt.want {
+			t.Errorf("targetURL(%q) = %q, want %q", tt.target, u, tt.want)
+		}
+	}
+	for _, bad := range []string{"ftp://example.com", "http://"} {
+		if _, err := targetURL(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestHTTPProxy_RewritesAndLogs(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "backend")
+		w.Header().Set("X-Internal", "secret")
+		fmt.Fprintf(w, "path=%s auth=%q cookie=%q host=%s", r.URL.RequestURI(), r.Header.Get("Authorization"), r.Header.Get("Cookie"), r.Host)
+	}))
+	defer backend.Close()
+
+	var out syncBuffer
+	handler, err := newHTTPProxy(&Params{
+		Target:               backend.Listener.Addr().String(),
+		ConnectTimeout:       1000,
+		Log:                  true,
+		SetHeader:            []string{"Authorization=Bearer token", "Host=example.test"},
+		RemoveHeader:         []string{"Cookie"},
+		SetResponseHeader:    []string{"Server=tofu"},
+		RemoveResponseHeader: []string{"X-Internal"},
+	}, &out)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	front := httptest.NewServer(handler)
+	defer front.Close()
+
+	req, _ := http.NewRequest("GET", front.URL+"/items?id=1", nil)
+	req.Header.Set("Cookie", "session=abc")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body := new(bytes.Buffer)
+	body.ReadFrom(resp.Body)
+	resp.Body.Close()
+
+	want := `path=/items?id=1 auth="Bearer token" cookie="" host=example.test`
+	if body.String() != want {
+		t.Errorf("Expected %q, got %q", want, body.String())
+	}
+	if got := resp.Header.Get("Server"); got != "tofu" {
+		t.Errorf("Expected Server tofu, got %q", got)
+	}
+	if got := resp.Header.Get("X-Internal"); got != "" {
+		t.Errorf("Expected X-Internal removed, got %q", got)
+	}
+	if log := out.String(); !strings.HasPrefix(log, "[1] GET /items?id=1 -> 200 OK (") {
+		t.Errorf("Unexpected log line: %q", log)
+	}
+}
+
+func TestHTTPProxy_BadGateway(t *testing.T) {
+	var out syncBuffer
+	handler, err := newHTTPProxy(&Params{
+		Target:         fmt.Sprintf("127.0.0.1:%d", freePort(t)),
+		ConnectTimeout: 500,
+		Log:            true,
+	}, &out)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/submit", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("Expected 502, got %d", rec.Code)
+	}
+	log := out.String()
+	if !strings.HasPrefix(log, "[1] POST /submit -> 502 Bad Gateway (") || !strings.Contains(log, "refused") {
+		t.Errorf("Unexpected log line: %q", log)
+	}
+}
+
+func TestHTTPProxy_Upgrade(t *testing.T) {
+	// An echo protocol, standing in for websockets
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "echo" {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		buf.Flush()
+		line, _ := buf.ReadString('\n')
+		conn.Write([]byte(line))
+	}))
+	defer backend.Close()
+
+	var out syncBuffer
+	handler, err := newHTTPProxy(&Params{Target: backend.URL, ConnectTimeout: 1000, Log: true}, &out)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	front := httptest.NewServer(handler)
+	defer front.Close()
+
+	conn, err := net.Dial("tcp", front.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprintf(conn, "GET /ws HTTP/1.1\r\nHost: %s\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n", front.Listener.Addr())
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+	fmt.Fprint(conn, "ping\n")
+	if line, err := reader.ReadString('\n'); err != nil || line != "ping\n" {
+		t.Errorf("Expected echoed %q, got %q (%v)", "ping\n", line, err)
+	}
+
+	// The line is logged once the tunnel is closed, and Close doesn't wait
+	// for hijacked connections
+	conn.Close()
+	for deadline := time.Now().Add(5 * time.Second); out.String() == "" && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if log := out.String(); !strings.HasPrefix(log, "[1] GET /ws -> 101 Switching Protocols (") {
+		t.Errorf("Unexpected log line: %q", log)
+	}
+}
+
+func TestHTTPProxy_EndToEnd(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("X-Tag"))
+	}))
+	defer backend.Close()
+
+	params := &Params{
+		Listen:         fmt.Sprintf("127.0.0.1:%d", freePort(t)),
+		Target:         backend.URL,
+		ConnectTimeout: 1000,
+		SetHeader:      []string{"X-Tag=proxied"},
+	}
+	startProxy(t, params)
+
+	resp, err := http.Get("http://" + params.Listen + "/")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body := new(bytes.Buffer)
+	body.ReadFrom(resp.Body)
+	if body.String() != "proxied" {
+		t.Errorf("Expected %q, got %q", "proxied", body.String())
+	}
+}
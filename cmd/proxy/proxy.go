@@ -23,6 +23,12 @@ type Params struct {
 	RetryInterval  int64 `help:"Retry interval in ms" default:"1000"`
 	MaxConns       int   `short:"m" help:"Max concurrent connections (0=unlimited)" default:"0"`
 	Verbose        bool  `short:"v" help:"Verbose logging" default:"false"`
+
+	Log                  bool     `help:"Log each proxied HTTP request line and response status (HTTP mode)" default:"false"`
+	SetHeader            []string `optional:"true" help:"Set a request header sent upstream, as name=value (HTTP mode, repeatable)"`
+	RemoveHeader         []string `optional:"true" help:"Remove a request header before sending upstream (HTTP mode, repeatable)"`
+	SetResponseHeader    []string `optional:"true" help:"Set a response header sent back to the client, as name=value (HTTP mode, repeatable)"`
+	RemoveResponseHeader []string `optional:"true" help:"Remove a response header before sending it back to the client (HTTP mode, repeatable)"`
 }
 
 func Cmd() *cobra.Command {
@@ -33,9 +39,13 @@ func Cmd() *cobra.Command {
 
 Useful for exposing WSL services on Windows LAN interfaces, or any TCP forwarding.
 
+--log and the header rewriting flags switch to HTTP mode, where requests are
+parsed and forwarded as a reverse proxy instead of as raw bytes.
+
 Example:
   tofu proxy 0.0.0.0:8443 localhost:8443
-  tofu proxy -t 10000 -i 60000 -r 3 0.0.0.0:8443 localhost:8443`,
+  tofu proxy -t 10000 -i 60000 -r 3 0.0.0.0:8443 localhost:8443
+  tofu proxy --log --set-header "Authorization=Bearer token" :8080 localhost:3000`,
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if err := run(params); err != nil {
@@ -59,6 +69,10 @@ func run(params *Params) error {
 			params.ConnectTimeout, params.IdleTimeout, params.Retries, params.RetryInterval, params.MaxConns)
 	}
 
+	if params.httpMode() {
+		return runHTTP(params, ln, os.Stdout)
+	}
+
 	var connCount atomic.Int64
 	var activeConns atomic.Int64
 
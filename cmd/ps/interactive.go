@@ -0,0 +1,456 @@
+package ps
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/term"
+)
+
+// defaultRefresh is how often -i resamples processes without --watch.
+const defaultRefresh = 2 * time.Second
+
+type sortColumn int
+
+const (
+	sortByPID sortColumn = iota
+	sortByUser
+	sortByCPU
+	sortByMem
+	sortByCommand
+)
+
+var sortColumnNames = []string{"PID", "USER", "%CPU", "%MEM", "COMMAND"}
+
+// sortState is the current sort column and direction. Cycling to a new
+// column resets the direction to what is most useful for it: usage
+// descending, the rest ascending.
+type sortState struct {
+	column sortColumn
+	desc   bool
+}
+
+func (s *sortState) next() {
+	s.column = (s.column + 1) % sortColumn(len(sortColumnNames))
+	s.desc = s.column == sortByCPU || s.column == sortByMem
+}
+
+func (s sortState) String() string {
+	arrow := "↑"
+	if s.desc {
+		arrow = "↓"
+	}
+	return sortColumnNames[s.column] + " " + arrow
+}
+
+// signals are the signals k offers, in the order shown.
+var signals = []struct {
+	name string
+	help string
+}{
+	{"TERM", "ask the process to exit"},
+	{"KILL", "force the process to exit, without cleaning up"},
+	{"HUP", "hang up, many daemons reload their configuration"},
+}
+
+// sendSignal sends one of signals to a process.
+var sendSignal = defaultSendSignal
+
+func defaultSendSignal(pid int32, name string) error {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "TERM":
+		return p.Terminate()
+	case "KILL":
+		return p.Kill()
+	default:
+		return p.SendSignal(syscall.SIGHUP)
+	}
+}
+
+type action int
+
+const (
+	actionNone action = iota
+	actionQuit
+	actionSignal // send signals[v.signal] to v.target
+)
+
+// view holds the interactive state of the process table.
+type view struct {
+	procs     []procInfo
+	err       error
+	updated   time.Time
+	sort      sortState
+	filter    string
+	filtering bool     // typing a filter after '/'
+	selected  int      // index into visibleRows
+	picking   bool     // choosing a signal after k
+	confirm   bool     // asking whether to send the chosen signal
+	signal    int      // index into signals
+	target    procInfo // the process to send the signal to
+	status    string   // outcome of the last signal
+}
+
+func newView(sortBy string) *view {
+	v := &view{}
+	switch sortBy {
+	case "cpu":
+		v.sort = sortState{column: sortByCPU, desc: true}
+	case "mem":
+		v.sort = sortState{column: sortByMem, desc: true}
+	}
+	return v
+}
+
+// setProcs replaces the table contents after a refresh.
+func (v *view) setProcs(procs []procInfo, err error, now time.Time) {
+	v.procs, v.err, v.updated = procs, err, now
+	v.clampSelection()
+}
+
+// visibleRows returns the processes matching the search, sorted.
+func (v *view) visibleRows() []procInfo {
+	filter := strings.ToLower(v.filter)
+	var rows []procInfo
+	for _, p := range v.procs {
+		if filter == "" || strings.Contains(strings.ToLower(fmt.Sprintf("%d %s %s", p.Pid, p.User, p.Command)), filter) {
+			rows = append(rows, p)
+		}
+	}
+
+	slices.SortStableFunc(rows, func(a, b procInfo) int {
+		var c int
+		switch v.sort.column {
+		case sortByUser:
+			c = cmp.Compare(a.User, b.User)
+		case sortByCPU:
+			c = cmp.Compare(a.CPUPercent, b.CPUPercent)
+		case sortByMem:
+			c = cmp.Compare(a.MemPercent, b.MemPercent)
+		case sortByCommand:
+			c = cmp.Compare(a.Command, b.Command)
+		}
+		c = cmp.Or(c, cmp.Compare(a.Pid, b.Pid))
+		if v.sort.desc {
+			return -c
+		}
+		return c
+	})
+	return rows
+}
+
+func (v *view) clampSelection() {
+	n := len(v.visibleRows())
+	if v.selected >= n {
+		v.selected = n - 1
+	}
+	if v.selected < 0 {
+		v.selected = 0
+	}
+}
+
+func (v *view) handleKey(k common.Key) action {
+	if k == common.KeyCtrlC {
+		return actionQuit
+	}
+
+	if v.confirm {
+		v.confirm, v.picking = false, false
+		if k == "y" || k == "Y" {
+			return actionSignal
+		}
+		v.status = "Cancelled, no signal sent"
+		return actionNone
+	}
+
+	if v.picking {
+		switch k {
+		case common.KeyUp:
+			if v.signal > 0 {
+				v.signal--
+			}
+		case common.KeyDown:
+			if v.signal < len(signals)-1 {
+				v.signal++
+			}
+		case common.KeyEnter:
+			v.confirm = true
+		case common.KeyEsc, "q":
+			v.picking = false
+		}
+		return actionNone
+	}
+
+	if v.filtering {
+		switch k {
+		case common.KeyEnter:
+			v.filtering = false
+		case common.KeyEsc:
+			v.filtering = false
+			v.filter = ""
+		case common.KeyBackspace:
+			if v.filter != "" {
+				v.filter = v.filter[:len(v.filter)-1]
+			}
+		case common.KeyUp, common.KeyDown:
+		default:
+			v.filter += string(k)
+		}
+		v.clampSelection()
+		return actionNone
+	}
+
+	v.status = ""
+	switch k {
+	case "q":
+		return actionQuit
+	case common.KeyUp:
+		if v.selected > 0 {
+			v.selected--
+		}
+	case common.KeyDown:
+		v.selected++
+		v.clampSelection()
+	case "/":
+		v.filtering = true
+	case common.KeyEsc:
+		v.filter = ""
+		v.clampSelection()
+	case "s":
+		v.sort.next()
+	case "r":
+		v.sort.desc = !v.sort.desc
+	case "k":
+		if rows := v.visibleRows(); v.selected < len(rows) {
+			v.target = rows[v.selected]
+			v.picking, v.signal = true, 0
+		}
+	}
+	return actionNone
+}
+
+// sendSelectedSignal sends the confirmed signal and reports how it went in
+// the status line.
+func (v *view) sendSelectedSignal() {
+	name := signals[v.signal].name
+	err := sendSignal(v.target.Pid, name)
+	switch {
+	case err == nil:
+		v.status = fmt.Sprintf("Sent SIG%s to %d (%s)", name, v.target.Pid, v.target.Name)
+	case errors.Is(err, os.ErrPermission):
+		owner := v.target.User
+		if owner == "" {
+			owner = "another user"
+		}
+		v.status = fmt.Sprintf("Permission denied: %d (%s) is owned by %s, run as that user or with sudo to signal it", v.target.Pid, v.target.Name, owner)
+	case errors.Is(err, process.ErrorProcessNotRunning) || errors.Is(err, syscall.ESRCH):
+		v.status = fmt.Sprintf("Process %d (%s) has already exited", v.target.Pid, v.target.Name)
+	default:
+		v.status = fmt.Sprintf("Failed to send SIG%s to %d (%s): %v", name, v.target.Pid, v.target.Name, err)
+	}
+}
+
+// render draws the full screen, limited to height lines and width columns.
+// Lines are separated by "\n"; the caller adapts them for raw mode terminals.
+func (v *view) render(height, width int) string {
+	var sb strings.Builder
+
+	header := fmt.Sprintf("Processes: %d   Sort: %s", len(v.procs), v.sort)
+	if v.filter != "" || v.filtering {
+		header += "   Search: " + v.filter
+		if v.filtering {
+			header += "█"
+		}
+	}
+	if !v.updated.IsZero() {
+		header += "   Updated: " + v.updated.Format("15:04:05")
+	}
+	sb.WriteString(header + "\n")
+
+	switch {
+	case v.confirm:
+		fmt.Fprintf(&sb, "Send SIG%s to %d (%s)? y: send  any other key: cancel\n\n", signals[v.signal].name, v.target.Pid, v.target.Name)
+		return sb.String()
+	case v.picking:
+		sb.WriteString("↑/↓: select  enter: send  esc: cancel\n\n")
+		fmt.Fprintf(&sb, "Send a signal to %d (%s)\n\n", v.target.Pid, v.target.Command)
+		for i, s := range signals {
+			line := fmt.Sprintf("  %-5s %s", s.name, s.help)
+			if i == v.signal {
+				line = "\033[7m" + line + "\033[0m"
+			}
+			sb.WriteString(line + "\n")
+		}
+		return sb.String()
+	}
+
+	sb.WriteString("↑/↓: select  /: search  s: sort  r: reverse  k: send signal  q: quit\n")
+	if v.status != "" {
+		sb.WriteString(v.status + "\n")
+	}
+	sb.WriteString("\n")
+	used := strings.Count(sb.String(), "\n")
+
+	if v.err != nil {
+		sb.WriteString(v.err.Error() + "\n")
+		return sb.String()
+	}
+
+	rows := v.visibleRows()
+	if len(rows) == 0 {
+		if v.procs == nil {
+			sb.WriteString("Loading...\n")
+		} else {
+			sb.WriteString("No processes found\n")
+		}
+		return sb.String()
+	}
+
+	table := [][]string{{"PID", "PPID", "USER", "%CPU", "%MEM", "COMMAND"}}
+	for _, p := range rows {
+		table = append(table, []string{
+			fmt.Sprint(p.Pid), fmt.Sprint(p.Ppid), p.User,
+			fmt.Sprintf("%.1f", p.CPUPercent), fmt.Sprintf("%.1f", p.MemPercent), p.Command,
+		})
+	}
+	truncateLastColumn(table, width)
+
+	// Scroll so the selected row stays visible below the column headers
+	space := max(1, height-used-1)
+	offset := 0
+	if v.selected >= space {
+		offset = v.selected - space + 1
+	}
+	common.WriteTable(&sb, table, v.selected, offset, space)
+	return sb.String()
+}
+
+// truncateLastColumn shortens the last column so that rows written with
+// common.WriteTable fit in width, as long commands would otherwise wrap.
+func truncateLastColumn(table [][]string, width int) {
+	last := len(table[0]) - 1
+	used := 0
+	for col := range last {
+		w := 0
+		for _, row := range table {
+			w = max(w, len([]rune(row[col])))
+		}
+		used += w + 3
+	}
+	room := max(10, width-used)
+	for _, row := range table {
+		if r := []rune(row[last]); len(r) > room {
+			row[last] = string(r[:room-1]) + "…"
+		}
+	}
+}
+
+func runInteractive(ctx context.Context, params *Params, stdout io.Writer) error {
+	interval := defaultRefresh
+	if params.Watch > 0 {
+		interval = time.Duration(params.Watch * float64(time.Second))
+	}
+	v := newView(params.Sort)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set raw mode: %w", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+
+	// Alternate screen, hidden cursor
+	fmt.Fprint(stdout, "\033[?1049h\033[?25l")
+	defer fmt.Fprint(stdout, "\033[?25h\033[?1049l")
+
+	keyCh := make(chan []common.Key)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			if n > 0 {
+				keyCh <- common.DecodeKeys(buf[:n])
+			}
+		}
+	}()
+
+	// List in the background so keys stay responsive with many processes
+	type listResult struct {
+		procs []procInfo
+		err   error
+	}
+	refreshCh := make(chan struct{}, 1)
+	resultCh := make(chan listResult, 1)
+	go func() {
+		for range refreshCh {
+			procs, err := listProcs(params)
+			select {
+			case resultCh <- listResult{procs, err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	defer close(refreshCh)
+	requestRefresh := func() {
+		select {
+		case refreshCh <- struct{}{}:
+		default:
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	requestRefresh()
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		screen := strings.ReplaceAll(v.render(height, width), "\n", "\033[K\r\n")
+		fmt.Fprint(stdout, "\033[H"+screen+"\033[J")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sigCh:
+			return nil
+		case keys := <-keyCh:
+			for _, k := range keys {
+				switch v.handleKey(k) {
+				case actionQuit:
+					return nil
+				case actionSignal:
+					v.sendSelectedSignal()
+					requestRefresh()
+				}
+			}
+		case res := <-resultCh:
+			v.setProcs(res.procs, res.err, time.Now())
+		case <-ticker.C:
+			requestRefresh()
+		}
+	}
+}
@@ -1,27 +1,53 @@
 package ps
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"os/user"
-	"sort"
+	"slices"
 	"strings"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
 	"github.com/shirou/gopsutil/v4/process"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 type Params struct {
-	Full       bool     `short:"f" help:"Display full format listing."`
-	Users      []string `short:"u" optional:"true" help:"Filter by username(s)."`
-	Pids       []int32  `short:"p" optional:"true" help:"Filter by PID(s)."`
-	Name       string   `short:"n" optional:"true" help:"Filter by command name (substring)."`
-	Current    bool     `short:"c" help:"Show only processes owned by the current user."`
-	Invert     bool     `short:"v" help:"Invert filtering (matches non-matching processes)."`
-	NoTruncate bool     `short:"N" help:"Do not truncate command line output."`
+	Full        bool     `short:"f" help:"Display full format listing."`
+	Users       []string `short:"u" optional:"true" help:"Filter by username(s)."`
+	Pids        []int32  `short:"p" optional:"true" help:"Filter by PID(s)."`
+	Name        string   `short:"n" optional:"true" help:"Filter by command name (substring)."`
+	Current     bool     `short:"c" help:"Show only processes owned by the current user."`
+	Invert      bool     `short:"v" help:"Invert filtering (matches non-matching processes)."`
+	NoTruncate  bool     `short:"N" help:"Do not truncate command line output."`
+	Filter      string   `optional:"true" help:"Filter by command line (substring), including arguments."`
+	Sort        string   `help:"Sort by pid, or by cpu or mem with the highest first." default:"pid" alts:"cpu,mem,pid"`
+	Json        bool     `short:"j" help:"Output in JSON format, with every field of the full listing."`
+	Watch       float64  `short:"w" optional:"true" help:"Resample every N seconds until stopped, as newline-delimited JSON with --json when not on a terminal."`
+	Tree        bool     `short:"t" help:"Show processes as a tree, children under their parent. With filters, only matches and their ancestors are shown."`
+	Interactive bool     `short:"i" help:"Browse processes interactively, and send them signals."`
+}
+
+// procInfo is a listed process. The JSON output is a list of these.
+type procInfo struct {
+	Pid        int32   `json:"pid"`
+	Ppid       int32   `json:"ppid"`
+	User       string  `json:"user"`
+	Status     string  `json:"status"`
+	CPUPercent float64 `json:"cpu_percent"`
+	MemPercent float32 `json:"mem_percent"`
+	Name       string  `json:"name"`
+	Command    string  `json:"command"`
 }
 
 func Cmd() *cobra.Command {
@@ -32,10 +58,17 @@ func Cmd() *cobra.Command {
 By default, it lists all processes with a minimal set of columns.
 Use -f for a full format listing.
 Filters can be combined (AND logic). Use -v to invert the filter.
-Use -N to prevent truncation of command line output.`,
+Use -N to prevent truncation of command line output.
+Use -t to show the processes as a tree, and -i to browse them interactively.
+
+With -w SECONDS, processes are resampled at that interval until stopped with
+Ctrl+C. On a terminal the screen is cleared between samples; when piped, every
+sample is kept, with --json as one JSON list per line.`,
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			if err := runPs(params); err != nil {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err := runPs(ctx, params, os.Stdout, term.IsTerminal(int(os.Stdout.Fd()))); err != nil {
 				fmt.Fprintf(os.Stderr, "ps: %v\n", err)
 				os.Exit(1)
 			}
@@ -43,24 +76,54 @@ Use -N to prevent truncation of command line output.`,
 	}.ToCobra()
 }
 
-func runPs(params *Params) error {
-	procs, err := process.Processes()
+// runPs lists processes once, or every --watch seconds until ctx is done.
+// clear is set when stdout is a terminal.
+func runPs(ctx context.Context, params *Params, stdout io.Writer, clear bool) error {
+	if params.Watch < 0 {
+		return fmt.Errorf("--watch must be positive")
+	}
+	if params.Tree && params.Json {
+		return fmt.Errorf("--tree cannot be combined with --json, which has the ppid of every process")
+	}
+	if params.Interactive {
+		if params.Tree || params.Json {
+			return fmt.Errorf("-i cannot be combined with --tree or --json")
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("-i needs a terminal")
+		}
+		return runInteractive(ctx, params, stdout)
+	}
+	if params.Watch == 0 {
+		return printPs(stdout, params, true)
+	}
+	between := "\n"
+	if params.Json {
+		between = ""
+	}
+	interval := time.Duration(params.Watch * float64(time.Second))
+	return common.Resample(ctx, interval, stdout, clear, between, func(w io.Writer) error {
+		// Piped samples are one JSON list per line
+		return printPs(w, params, clear)
+	})
+}
+
+// printPs prints one listing of processes. JSON is indented when pretty.
+func printPs(out io.Writer, params *Params, pretty bool) error {
+	procs, err := listProcs(params)
 	if err != nil {
-		return fmt.Errorf("failed to list processes: %w", err)
+		return err
 	}
 
-	currentUser, err := user.Current()
-	currentUsername := ""
-	if err == nil {
-		currentUsername = currentUser.Username
-		// On Windows, username might be qualified with domain (DOMAIN\User)
-		// gopsutil might return just User or DOMAIN\User.
-		// For robustness, we might need flexible matching, but exact match is a good start.
-	} else if params.Current {
-		return fmt.Errorf("failed to determine current user: %w", err)
+	if params.Json {
+		enc := json.NewEncoder(out)
+		if pretty {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(procs)
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
 	defer w.Flush()
 
 	// Header
@@ -70,57 +133,107 @@ func runPs(params *Params) error {
 		fmt.Fprintln(w, "PID\tCOMMAND")
 	}
 
-	// Sort by PID
-	sort.Slice(procs, func(i, j int) bool {
-		return procs[i].Pid < procs[j].Pid
-	})
-
-	for _, p := range procs {
-		if !shouldInclude(p, params, currentUsername) {
-			continue
-		}
-
-		pid := p.Pid
-		name, _ := p.Name()
-		if name == "" {
-			name = "[unknown]"
-		}
+	prefixes := make([]string, len(procs))
+	if params.Tree {
+		procs, prefixes = treeOrder(procs)
+	}
 
+	for i, p := range procs {
 		if params.Full {
-			ppid, _ := p.Ppid()
-			username, _ := p.Username()
+			username := p.User
 			if username == "" {
 				username = "-"
 			}
-
-			status, _ := p.Status()
-			cpu, _ := p.CPUPercent()
-			mem, _ := p.MemoryPercent()
-			cmdline, _ := p.Cmdline()
-			if cmdline == "" {
-				cmdline = name
-			} else if !params.NoTruncate && len(cmdline) > 50 {
+			cmdline := p.Command
+			if !params.NoTruncate && len(cmdline) > 50 {
 				cmdline = cmdline[:47] + "..."
 			}
-
-			statusStr := ""
-			if len(status) > 0 {
-				statusStr = status[0]
-			}
-
 			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%.1f\t%.1f\t%s\n",
-				pid, ppid, username, statusStr, cpu, mem, cmdline)
+				p.Pid, p.Ppid, username, p.Status, p.CPUPercent, p.MemPercent, prefixes[i]+cmdline)
 		} else {
-			fmt.Fprintf(w, "%d\t%s\n", pid, name)
+			fmt.Fprintf(w, "%d\t%s\n", p.Pid, prefixes[i]+p.Name)
 		}
 	}
 
 	return nil
 }
 
+// listProcs returns the processes that pass the filters, sorted by
+// params.Sort. With --tree, their ancestors are included too, so the tree
+// stays connected. Only the name is read unless more is shown or sorted on.
+func listProcs(params *Params) ([]procInfo, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	currentUser, err := user.Current()
+	currentUsername := ""
+	if err == nil {
+		currentUsername = currentUser.Username
+		// On Windows, username might be qualified with domain (DOMAIN\User)
+		// gopsutil might return just User or DOMAIN\User.
+		// For robustness, we might need flexible matching, but exact match is a good start.
+	} else if params.Current {
+		return nil, fmt.Errorf("failed to determine current user: %w", err)
+	}
+
+	detailed := params.Full || params.Json || params.Sort != "pid" || params.Tree || params.Interactive
+	infos := []procInfo{}
+	matched := map[int32]bool{}
+	for _, p := range procs {
+		if shouldInclude(p, params, currentUsername) {
+			matched[p.Pid] = true
+		} else if !params.Tree {
+			continue
+		}
+
+		info := procInfo{Pid: p.Pid}
+		info.Name, _ = p.Name()
+		if info.Name == "" {
+			info.Name = "[unknown]"
+		}
+
+		if detailed {
+			info.Ppid, _ = p.Ppid()
+			info.User, _ = p.Username()
+			if status, _ := p.Status(); len(status) > 0 {
+				info.Status = status[0]
+			}
+			info.CPUPercent, _ = p.CPUPercent()
+			info.MemPercent, _ = p.MemoryPercent()
+			info.Command, _ = p.Cmdline()
+			if info.Command == "" {
+				info.Command = info.Name
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	if params.Tree {
+		infos = withAncestors(infos, matched)
+	}
+	sortProcs(infos, params.Sort)
+	return infos, nil
+}
+
+// sortProcs sorts by PID, or by CPU or memory use with the highest first.
+func sortProcs(procs []procInfo, by string) {
+	slices.SortFunc(procs, func(a, b procInfo) int {
+		var c int
+		switch by {
+		case "cpu":
+			c = cmp.Compare(b.CPUPercent, a.CPUPercent)
+		case "mem":
+			c = cmp.Compare(b.MemPercent, a.MemPercent)
+		}
+		return cmp.Or(c, cmp.Compare(a.Pid, b.Pid))
+	})
+}
+
 func shouldInclude(p *process.Process, params *Params, currentUsername string) bool {
 	// If no filters are active, include everything
-	if len(params.Users) == 0 && len(params.Pids) == 0 && params.Name == "" && !params.Current {
+	if len(params.Users) == 0 && len(params.Pids) == 0 && params.Name == "" && params.Filter == "" && !params.Current {
 		return true
 	}
 
@@ -148,6 +261,17 @@ func shouldInclude(p *process.Process, params *Params, currentUsername string) b
 		}
 	}
 
+	// Command Line Filter (if still matched)
+	if matched && params.Filter != "" {
+		cmdline, _ := p.Cmdline()
+		if cmdline == "" {
+			cmdline, _ = p.Name()
+		}
+		if !strings.Contains(cmdline, params.Filter) {
+			matched = false
+		}
+	}
+
 	// User Filter (if still matched)
 	if matched && len(params.Users) > 0 {
 		userMatch := false
@@ -2,12 +2,18 @@ package ps
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
 )
 
 func TestPsCommand(t *testing.T) {
@@ -67,7 +73,7 @@ func TestPsCommand(t *testing.T) {
 				io.Copy(&stdoutBuf, r)
 			})
 
-			err := runPs(tc.params)
+			err := runPs(context.Background(), tc.params, os.Stdout, false)
 
 			w.Close()
 			wg.Wait()
@@ -119,7 +125,7 @@ func TestPsFilterLogic(t *testing.T) {
 		params := &Params{
 			Pids: []int32{myPid},
 		}
-		err := runPs(params)
+		err := runPs(context.Background(), params, os.Stdout, false)
 		w.Close()
 		os.Stdout = oldStdout
 		io.Copy(&stdoutBuf, r)
@@ -134,3 +140,268 @@ func TestPsFilterLogic(t *testing.T) {
 		}
 	})
 }
+
+func TestPsJSONAndFilter(t *testing.T) {
+	// The test binary's own command line contains its name
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	params := &Params{Json: true, Filter: self, Pids: []int32{int32(os.Getpid())}, Sort: "pid"}
+	if err := runPs(context.Background(), params, &out, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var procs []procInfo
+	if err := json.Unmarshal(out.Bytes(), &procs); err != nil {
+		t.Fatalf("Expected a JSON list, got %q: %v", out.String(), err)
+	}
+	if len(procs) != 1 || procs[0].Pid != int32(os.Getpid()) || procs[0].Ppid != int32(os.Getppid()) {
+		t.Fatalf("Expected only this process, got %+v", procs)
+	}
+	if !strings.Contains(procs[0].Command, self) {
+		t.Errorf("Expected the command to contain %q, got %q", self, procs[0].Command)
+	}
+
+	out.Reset()
+	params.Filter = "this_command_line_should_not_exist_xyz123"
+	if err := runPs(context.Background(), params, &out, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("Expected no processes, got %q", out.String())
+	}
+}
+
+func TestPsWatchJSON(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	params := &Params{Json: true, Watch: 0.1, Pids: []int32{int32(os.Getpid())}, Sort: "pid"}
+	if err := runPs(ctx, params, &out, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("Expected several samples, got %q", out.String())
+	}
+	for _, line := range lines {
+		var procs []procInfo
+		if err := json.Unmarshal([]byte(line), &procs); err != nil || len(procs) != 1 {
+			t.Errorf("Expected a JSON list with this process per line, got %q", line)
+		}
+	}
+}
+
+func TestSortProcs(t *testing.T) {
+	procs := []procInfo{
+		{Pid: 3, CPUPercent: 1, MemPercent: 9},
+		{Pid: 1, CPUPercent: 5, MemPercent: 1},
+		{Pid: 2, CPUPercent: 5, MemPercent: 4},
+	}
+	pids := func() []int32 {
+		var p []int32
+		for _, proc := range procs {
+			p = append(p, proc.Pid)
+		}
+		return p
+	}
+	for _, tt := range []struct {
+		by   string
+		want string
+	}{
+		{"pid", "[1 2 3]"},
+		{"cpu", "[1 2 3]"},
+		{"mem", "[3 2 1]"},
+	} {
+		sortProcs(procs, tt.by)
+		if got := fmt.Sprint(pids()); got != tt.want {
+			t.Errorf("Sorting by %s: expected %s, got %s", tt.by, tt.want, got)
+		}
+	}
+}
+
+var sampleProcs = []procInfo{
+	{Pid: 1, Ppid: 0, User: "root", Name: "init", Command: "/sbin/init", CPUPercent: 0.1, MemPercent: 0.2},
+	{Pid: 10, Ppid: 1, User: "root", Name: "sshd", Command: "/usr/sbin/sshd -D", CPUPercent: 0, MemPercent: 0.1},
+	{Pid: 11, Ppid: 10, User: "me", Name: "bash", Command: "-bash", CPUPercent: 0.5, MemPercent: 0.3},
+	{Pid: 12, Ppid: 11, User: "me", Name: "node", Command: "node server.js", CPUPercent: 12.5, MemPercent: 4.2},
+	{Pid: 20, Ppid: 1, User: "www", Name: "nginx", Command: "nginx: master", CPUPercent: 1, MemPercent: 1.5},
+	{Pid: 21, Ppid: 20, User: "www", Name: "nginx", Command: "nginx: worker", CPUPercent: 2, MemPercent: 1.1},
+}
+
+func TestTreeOrder(t *testing.T) {
+	procs, prefixes := treeOrder(sampleProcs)
+	var lines []string
+	for i, p := range procs {
+		lines = append(lines, prefixes[i]+p.Name)
+	}
+	want := strings.Join([]string{
+		"init",
+		"├─ sshd",
+		"│  └─ bash",
+		"│     └─ node",
+		"└─ nginx",
+		"   └─ nginx",
+	}, "\n")
+	if got := strings.Join(lines, "\n"); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+
+	// A ppid cycle without a root is still listed once
+	procs, _ = treeOrder([]procInfo{{Pid: 5, Ppid: 6}, {Pid: 6, Ppid: 5}})
+	if len(procs) != 2 {
+		t.Errorf("Expected both processes of a cycle, got %+v", procs)
+	}
+}
+
+func TestWithAncestors(t *testing.T) {
+	kept := withAncestors(sampleProcs, map[int32]bool{12: true, 21: true})
+	var pids []int32
+	for _, p := range kept {
+		pids = append(pids, p.Pid)
+	}
+	if got := fmt.Sprint(pids); got != "[1 10 11 12 20 21]" {
+		t.Errorf("Expected node and the nginx worker with their ancestors, got %s", got)
+	}
+
+	kept = withAncestors(sampleProcs, map[int32]bool{11: true})
+	if len(kept) != 3 || kept[2].Pid != 11 {
+		t.Errorf("Expected bash, sshd and init, got %+v", kept)
+	}
+}
+
+func TestPsTree(t *testing.T) {
+	var out bytes.Buffer
+	params := &Params{Tree: true, Pids: []int32{int32(os.Getpid())}, Sort: "pid"}
+	if err := runPs(context.Background(), params, &out, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// This process is shown below its parent
+	if !strings.Contains(out.String(), "└─ ") {
+		t.Errorf("Expected this process under its ancestors, got:\n%s", out.String())
+	}
+
+	for _, tt := range []struct {
+		params Params
+		want   string
+	}{
+		{Params{Tree: true, Json: true}, "--tree cannot be combined with --json, which has the ppid of every process"},
+		{Params{Interactive: true, Tree: true}, "-i cannot be combined with --tree or --json"},
+		{Params{Interactive: true}, "-i needs a terminal"},
+	} {
+		if err := runPs(context.Background(), &tt.params, &out, false); err == nil || err.Error() != tt.want {
+			t.Errorf("Expected error %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestView(t *testing.T) {
+	v := newView("cpu")
+	if got := v.render(24, 100); !strings.Contains(got, "Loading...") {
+		t.Errorf("Expected Loading... before the first sample, got:\n%s", got)
+	}
+	v.setProcs(sampleProcs, nil, time.Now())
+
+	rows := v.visibleRows()
+	if rows[0].Name != "node" || v.sort.String() != "%CPU ↓" {
+		t.Errorf("Expected node first by CPU, got %s sorted by %s", rows[0].Name, v.sort)
+	}
+
+	v.handleKey("s")
+	if v.sort.String() != "%MEM ↓" {
+		t.Errorf("Expected %%MEM ↓, got %s", v.sort)
+	}
+	v.handleKey("s")
+	v.handleKey("r")
+	if v.sort.String() != "COMMAND ↓" || v.visibleRows()[0].Command != "node server.js" {
+		t.Errorf("Expected node server.js first by COMMAND ↓, got %s by %s", v.visibleRows()[0].Command, v.sort)
+	}
+
+	// Search matches the pid, user and command line
+	for _, k := range []common.Key{"/", "w", "w", "w", common.KeyEnter} {
+		v.handleKey(k)
+	}
+	if len(v.visibleRows()) != 2 {
+		t.Errorf("Expected the 2 www processes, got %+v", v.visibleRows())
+	}
+	screen := v.render(24, 100)
+	if !strings.Contains(screen, "Search: www") || strings.Contains(screen, "node server.js") {
+		t.Errorf("Expected only matches on screen, got:\n%s", screen)
+	}
+	v.handleKey(common.KeyEsc)
+	if len(v.visibleRows()) != len(sampleProcs) {
+		t.Errorf("Expected esc to clear the search")
+	}
+
+	if v.handleKey("q") != actionQuit || v.handleKey(common.KeyCtrlC) != actionQuit {
+		t.Errorf("Expected q and ctrl+c to quit")
+	}
+}
+
+func TestViewSignal(t *testing.T) {
+	var sent []string
+	sendSignal = func(pid int32, name string) error {
+		sent = append(sent, fmt.Sprintf("%s %d", name, pid))
+		if pid == 1 {
+			return fmt.Errorf("kill: %w", syscall.EPERM)
+		}
+		return nil
+	}
+	t.Cleanup(func() { sendSignal = defaultSendSignal })
+
+	v := newView("pid")
+	v.setProcs(sampleProcs, nil, time.Now())
+	v.handleKey(common.KeyDown)
+	v.handleKey(common.KeyDown)
+	v.handleKey(common.KeyDown)
+
+	// k, pick KILL, then cancel at the confirmation
+	for _, k := range []common.Key{"k", common.KeyDown, common.KeyEnter} {
+		if a := v.handleKey(k); a != actionNone {
+			t.Fatalf("Expected no action yet for %q, got %v", k, a)
+		}
+	}
+	if screen := v.render(24, 100); !strings.Contains(screen, "Send SIGKILL to 12 (node)? y: send") {
+		t.Errorf("Expected a confirmation, got:\n%s", screen)
+	}
+	v.handleKey("n")
+	if len(sent) != 0 || v.status != "Cancelled, no signal sent" {
+		t.Errorf("Expected nothing sent, got %q and status %q", sent, v.status)
+	}
+
+	for _, k := range []common.Key{"k", common.KeyDown, common.KeyEnter} {
+		v.handleKey(k)
+	}
+	if v.handleKey("y") != actionSignal {
+		t.Fatalf("Expected y to send the signal")
+	}
+	v.sendSelectedSignal()
+	if fmt.Sprint(sent) != "[KILL 12]" || v.status != "Sent SIGKILL to 12 (node)" {
+		t.Errorf("Expected SIGKILL sent to 12, got %q and status %q", sent, v.status)
+	}
+
+	// Permission denied is explained
+	v.handleKey(common.KeyUp)
+	v.handleKey(common.KeyUp)
+	v.handleKey(common.KeyUp)
+	for _, k := range []common.Key{"k", common.KeyEnter, "y"} {
+		v.handleKey(k)
+	}
+	v.sendSelectedSignal()
+	want := "Permission denied: 1 (init) is owned by root, run as that user or with sudo to signal it"
+	if v.status != want {
+		t.Errorf("Expected %q, got %q", want, v.status)
+	}
+	if screen := v.render(24, 100); !strings.Contains(screen, want) {
+		t.Errorf("Expected the error on screen, got:\n%s", screen)
+	}
+}
+
+func TestTruncateLastColumn(t *testing.T) {
+	table := [][]string{{"PID", "COMMAND"}, {"1", strings.Repeat("x", 50)}}
+	truncateLastColumn(table, 30)
+	if got := table[1][1]; got != strings.Repeat("x", 23)+"…" {
+		t.Errorf("Expected the command cut to 24 characters, got %q", got)
+	}
+}
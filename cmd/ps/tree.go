@@ -0,0 +1,78 @@
+package ps
+
+// withAncestors returns the matched processes and the processes they descend
+// from, in their original order.
+func withAncestors(procs []procInfo, matched map[int32]bool) []procInfo {
+	byPid := make(map[int32]procInfo, len(procs))
+	for _, p := range procs {
+		byPid[p.Pid] = p
+	}
+	keep := map[int32]bool{}
+	for pid := range matched {
+		// Stop at processes already kept, which also ends ppid cycles
+		for !keep[pid] {
+			p, ok := byPid[pid]
+			if !ok {
+				break
+			}
+			keep[pid] = true
+			pid = p.Ppid
+		}
+	}
+
+	var kept []procInfo
+	for _, p := range procs {
+		if keep[p.Pid] {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// treeOrder orders processes depth first, each followed by its children, and
+// returns the box-drawing prefix to show before each one. Processes whose
+// parent isn't listed are roots. Siblings keep their order in procs.
+func treeOrder(procs []procInfo) ([]procInfo, []string) {
+	listed := make(map[int32]bool, len(procs))
+	for _, p := range procs {
+		listed[p.Pid] = true
+	}
+	children := map[int32][]int{}
+	var roots []int
+	for i, p := range procs {
+		if p.Ppid != p.Pid && listed[p.Ppid] {
+			children[p.Ppid] = append(children[p.Ppid], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	ordered := make([]procInfo, 0, len(procs))
+	prefixes := make([]string, 0, len(procs))
+	visited := make([]bool, len(procs))
+	var walk func(i int, prefix, indent string)
+	walk = func(i int, prefix, indent string) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		ordered = append(ordered, procs[i])
+		prefixes = append(prefixes, prefix)
+		kids := children[procs[i].Pid]
+		for n, child := range kids {
+			if n == len(kids)-1 {
+				walk(child, indent+"└─ ", indent+"   ")
+			} else {
+				walk(child, indent+"├─ ", indent+"│  ")
+			}
+		}
+	}
+	for _, i := range roots {
+		walk(i, "", "")
+	}
+	// Processes in a ppid cycle have no root, show them at the top level
+	for i := range procs {
+		walk(i, "", "")
+	}
+	return ordered, prefixes
+}
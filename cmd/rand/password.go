@@ -0,0 +1,225 @@
+package rand
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type PasswordParams struct {
+	Length        int  `short:"l" help:"Password length, in characters." default:"20"`
+	Count         int  `short:"n" help:"Number of passwords to generate." default:"1"`
+	Upper         bool `short:"U" help:"Include uppercase letters."`
+	Lower         bool `short:"L" help:"Include lowercase letters."`
+	Digits        bool `short:"D" help:"Include digits."`
+	Symbols       bool `short:"S" help:"Include symbols."`
+	NoAmbiguous   bool `short:"a" help:"Leave out characters that are easily mistaken for each other, like 0/O and 1/l/I."`
+	Pronounceable bool `short:"p" help:"Alternate consonants and vowels, for passwords that are easier to remember and type."`
+}
+
+// Character classes of generated passwords
+const (
+	upperChars     = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lowerChars     = "abcdefghijklmnopqrstuvwxyz"
+	digitChars     = "0123456789"
+	symbolChars    = "!#$%&*+-=?@^_~.,:;()[]{}<>/"
+	ambiguousChars = "0O1lI|"
+	consonants     = "bcdfghjklmnprstvwxz"
+	vowels         = "aeiouy"
+)
+
+func passwordCmd() *cobra.Command {
+	return boa.CmdT[PasswordParams]{
+		Use:   "password",
+		Short: "Generate secure passwords",
+		Long: `Generate passwords with a cryptographically secure random source.
+
+By default passwords use uppercase and lowercase letters, digits and symbols.
+Giving any of --upper, --lower, --digits or --symbols uses only those classes.
+Each password has at least one character of every class used.
+
+The estimated entropy is printed to stderr, so the passwords can be piped.`,
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *PasswordParams, cmd *cobra.Command, args []string) {
+			if err := runPassword(params, os.Stdout, os.Stderr); err != nil {
+				fmt.Fprintf(os.Stderr, "rand: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func runPassword(params *PasswordParams, stdout, stderr io.Writer) error {
+	if params.Count < 0 {
+		return fmt.Errorf("--count must not be negative")
+	}
+	if params.Length < 1 {
+		return fmt.Errorf("--length must be at least 1")
+	}
+	gen, err := newPasswordGenerator(params)
+	if err != nil {
+		return err
+	}
+	for range params.Count {
+		pwd, err := gen.generate()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, pwd)
+	}
+	fmt.Fprintf(stderr, "Entropy: ~%.0f bits per password\n", gen.entropy())
+	return nil
+}
+
+// passwordGenerator generates passwords of a fixed length. Passwords are
+// either drawn from classes, each used at least once, or are pronounceable
+// from a pattern of per-position character sets.
+type passwordGenerator struct {
+	length  int
+	classes []string
+	pattern []string
+}
+
+func newPasswordGenerator(params *PasswordParams) (*passwordGenerator, error) {
+	strip := func(chars string) string {
+		if !params.NoAmbiguous {
+			return chars
+		}
+		return strings.Map(func(r rune) rune {
+			if strings.ContainsRune(ambiguousChars, r) {
+				return -1
+			}
+			return r
+		}, chars)
+	}
+
+	if params.Pronounceable {
+		// Lowercase syllables, capitalized and ended with a digit and a
+		// symbol when asked for those
+		var suffix []string
+		if params.Digits {
+			suffix = append(suffix, strip(digitChars))
+		}
+		if params.Symbols {
+			suffix = append(suffix, strip(symbolChars))
+		}
+		letters := params.Length - len(suffix)
+		if letters < 2 {
+			return nil, fmt.Errorf("--length must be at least %d for a pronounceable password", len(suffix)+2)
+		}
+		cons, vows := strip(consonants), strip(vowels)
+		pattern := make([]string, 0, params.Length)
+		for i := range letters {
+			set := cons
+			if i%2 == 1 {
+				set = vows
+			}
+			if i == 0 && params.Upper {
+				set = strings.ToUpper(set)
+			}
+			pattern = append(pattern, set)
+		}
+		return &passwordGenerator{length: params.Length, pattern: append(pattern, suffix...)}, nil
+	}
+
+	all := !params.Upper && !params.Lower && !params.Digits && !params.Symbols
+	var classes []string
+	for _, class := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{params.Upper, upperChars},
+		{params.Lower, lowerChars},
+		{params.Digits, digitChars},
+		{params.Symbols, symbolChars},
+	} {
+		if all || class.enabled {
+			classes = append(classes, strip(class.chars))
+		}
+	}
+	if params.Length < len(classes) {
+		return nil, fmt.Errorf("--length must be at least %d to include every character class", len(classes))
+	}
+	return &passwordGenerator{length: params.Length, classes: classes}, nil
+}
+
+func (g *passwordGenerator) generate() (string, error) {
+	if g.pattern != nil {
+		var sb strings.Builder
+		for _, set := range g.pattern {
+			s, err := randomString(1, set)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(s)
+		}
+		return sb.String(), nil
+	}
+
+	// Rejecting passwords that miss a class keeps every valid password
+	// equally likely, which the entropy estimate relies on
+	charset := strings.Join(g.classes, "")
+	for {
+		pwd, err := randomString(g.length, charset)
+		if err != nil {
+			return "", err
+		}
+		if g.hasAllClasses(pwd) {
+			return pwd, nil
+		}
+	}
+}
+
+func (g *passwordGenerator) hasAllClasses(pwd string) bool {
+	for _, class := range g.classes {
+		if !strings.ContainsAny(pwd, class) {
+			return false
+		}
+	}
+	return true
+}
+
+// entropy returns log2 of the number of passwords that can be generated. With
+// classes, these are counted by inclusion-exclusion over the missing classes.
+func (g *passwordGenerator) entropy() float64 {
+	if g.pattern != nil {
+		bits := 0.0
+		for _, set := range g.pattern {
+			bits += math.Log2(float64(len(set)))
+		}
+		return bits
+	}
+
+	total := new(big.Int)
+	for mask := 0; mask < 1<<len(g.classes); mask++ {
+		size, missing := 0, 0
+		for i, class := range g.classes {
+			if mask&(1<<i) != 0 {
+				missing++
+			} else {
+				size += len(class)
+			}
+		}
+		term := new(big.Int).Exp(big.NewInt(int64(size)), big.NewInt(int64(g.length)), nil)
+		if missing%2 == 1 {
+			total.Sub(total, term)
+		} else {
+			total.Add(total, term)
+		}
+	}
+	return bigLog2(total)
+}
+
+// bigLog2 returns log2 of a positive n, which may not fit a float64.
+func bigLog2(n *big.Int) float64 {
+	shift := max(n.BitLen()-64, 0)
+	f, _ := new(big.Float).SetInt(new(big.Int).Rsh(n, uint(shift))).Float64()
+	return math.Log2(f) + float64(shift)
+}
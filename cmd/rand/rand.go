@@ -5,7 +5,9 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"math/big"
+	mathrand "math/rand/v2"
 	"os"
 
 	"github.com/GiGurra/boa/pkg/boa"
@@ -15,23 +17,32 @@ import (
 )
 
 type Params struct {
-	Type       string `short:"t" help:"Type of random data (str, int, hex, base64, password, phrase)." default:"str" alts:"str,int,hex,base64,password,phrase"`
-	Length     int    `short:"l" help:"Length (chars for str/password/hex/base64, words for phrase)." default:"16"`
-	Min        int64  `help:"Minimum value for integer generation." default:"0"`
-	Max        int64  `help:"Maximum value for integer generation." default:"100"`
-	Charset    string `short:"c" help:"Custom character set for string generation." default:""`
-	Count      int    `short:"n" help:"Number of items to generate." default:"1"`
-	Separator  string `help:"Separator for phrases." default:" "`
-	Capitalize string `help:"Capitalization for phrases (none, first, all, random, one)." default:"none" alts:"none,first,all,random,one"`
+	Choices    []string `pos:"true" optional:"true" help:"Pick from these values instead, each optionally with a relative weight as value:weight."`
+	Type       string   `short:"t" help:"Type of random data (str, int, hex, base64, password, phrase)." default:"str" alts:"str,int,hex,base64,password,phrase"`
+	Length     int      `short:"l" help:"Length (chars for str/password/hex/base64, words for phrase)." default:"16"`
+	Min        int64    `help:"Minimum value for integer generation." default:"0"`
+	Max        int64    `help:"Maximum value for integer generation." default:"100"`
+	Charset    string   `short:"c" help:"Custom character set for string generation." default:""`
+	Count      int      `short:"n" help:"Number of items to generate." default:"1"`
+	Unique     bool     `short:"u" help:"Don't repeat values, for integers and picked values."`
+	Separator  string   `help:"Separator for phrases." default:" "`
+	Capitalize string   `help:"Capitalization for phrases (none, first, all, random, one)." default:"none" alts:"none,first,all,random,one"`
 }
 
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
-		Use:         "rand",
-		Short:       "Generate random data",
+		Use:   "rand [value[:weight]...]",
+		Short: "Generate random data",
+		Long: `Generate random data, or pick from the given values.
+
+Values can have a relative weight, like 'control:90 variant:10', to pick some
+more often than others. Values without a weight have weight 1.`,
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds: []*cobra.Command{
+			passwordCmd(),
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			if err := runRand(params); err != nil {
+			if err := runRand(params, os.Stdout); err != nil {
 				fmt.Fprintf(os.Stderr, "rand: %v\n", err)
 				os.Exit(1)
 			}
@@ -39,13 +50,42 @@ func Cmd() *cobra.Command {
 	}.ToCobra()
 }
 
-func runRand(params *Params) error {
+func runRand(params *Params, stdout io.Writer) error {
+	if params.Count < 0 {
+		return fmt.Errorf("--count must not be negative")
+	}
+	if len(params.Choices) > 0 || params.Unique {
+		var values []string
+		var err error
+		switch {
+		case len(params.Choices) > 0:
+			choices := make([]common.Choice, len(params.Choices))
+			for i, arg := range params.Choices {
+				if choices[i], err = common.ParseChoice(arg); err != nil {
+					return err
+				}
+			}
+			values, err = common.SampleChoices(mathrand.New(common.CryptoSource{}), choices, params.Count, params.Unique)
+		case params.Type == "int":
+			values, err = uniqueInts(params.Min, params.Max, params.Count)
+		default:
+			err = fmt.Errorf("--unique only works with -t int or values to pick from")
+		}
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			fmt.Fprintln(stdout, v)
+		}
+		return nil
+	}
+
 	for i := 0; i < params.Count; i++ {
 		val, err := generateRandom(params)
 		if err != nil {
 			return err
 		}
-		fmt.Println(val)
+		fmt.Fprintln(stdout, val)
 	}
 	return nil
 }
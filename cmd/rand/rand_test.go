@@ -1,8 +1,14 @@
 package rand
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -114,7 +120,202 @@ func TestRandCmd(t *testing.T) {
 		Length: 5,
 		Count:  2,
 	}
-	if err := runRand(params); err != nil {
+	var stdout bytes.Buffer
+	if err := runRand(params, &stdout); err != nil {
 		t.Errorf("runRand failed: %v", err)
 	}
+	if lines := strings.Fields(stdout.String()); len(lines) != 2 || len(lines[0]) != 5 {
+		t.Errorf("Expected 2 strings of 5 chars, got %q", stdout.String())
+	}
+}
+
+func TestRunRand_UniqueInts(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := runRand(&Params{Type: "int", Min: 1, Max: 49, Count: 6, Unique: true}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	seen := map[int]bool{}
+	for _, line := range strings.Fields(stdout.String()) {
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 1 || n > 49 || seen[n] {
+			t.Errorf("Expected 6 unique numbers from 1 to 49, got %q", stdout.String())
+			break
+		}
+		seen[n] = true
+	}
+	if len(seen) != 6 {
+		t.Errorf("Expected 6 numbers, got %q", stdout.String())
+	}
+
+	// The whole range is a permutation
+	stdout.Reset()
+	if err := runRand(&Params{Type: "int", Min: -2, Max: 2, Count: 5, Unique: true}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := strings.Fields(stdout.String())
+	sort.Slice(got, func(i, j int) bool { a, _ := strconv.Atoi(got[i]); b, _ := strconv.Atoi(got[j]); return a < b })
+	if strings.Join(got, ",") != "-2,-1,0,1,2" {
+		t.Errorf("Expected a permutation of -2..2, got %q", stdout.String())
+	}
+
+	// Huge ranges don't need memory
+	if values, err := uniqueInts(math.MinInt64, math.MaxInt64, 3); err != nil || len(values) != 3 {
+		t.Errorf("Expected 3 values from the full range, got %q, %v", values, err)
+	}
+
+	err := runRand(&Params{Type: "int", Min: 1, Max: 5, Count: 6, Unique: true}, &stdout)
+	if err == nil || err.Error() != "cannot pick 6 unique integers from 1 to 5, the range has only 5" {
+		t.Errorf("Expected a range error, got %v", err)
+	}
+	if err := runRand(&Params{Type: "hex", Count: 2, Unique: true}, &stdout); err == nil {
+		t.Error("Expected error for --unique with hex")
+	}
+}
+
+func TestRunRand_Weighted(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := runRand(&Params{Choices: []string{"a:3", "b:1", "never:0"}, Count: 4000}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	counts := map[string]int{}
+	for _, line := range strings.Fields(stdout.String()) {
+		counts[line]++
+	}
+	// a is expected 3000 times, with a standard deviation of about 27
+	if counts["never"] != 0 || counts["a"]+counts["b"] != 4000 || counts["a"] < 2800 || counts["a"] > 3200 {
+		t.Errorf("Unexpected distribution: %v", counts)
+	}
+
+	stdout.Reset()
+	if err := runRand(&Params{Choices: []string{"a:100", "b:1", "c:1", "d:0"}, Count: 3, Unique: true}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := strings.Fields(stdout.String())
+	slices.Sort(got)
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("Expected each value with a weight once, got %q", stdout.String())
+	}
+
+	err := runRand(&Params{Choices: []string{"a", "b:2", "c:0"}, Count: 3, Unique: true}, &stdout)
+	if err == nil || err.Error() != "cannot pick 3 unique values from 2 with a weight above 0" {
+		t.Errorf("Expected a count error, got %v", err)
+	}
+}
+
+func TestRunPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  PasswordParams
+		classes []string
+		bits    string
+	}{
+		{"default classes", PasswordParams{Length: 20}, []string{upperChars, lowerChars, digitChars, symbolChars}, "~129 bits"},
+		{"digits only", PasswordParams{Length: 6, Digits: true}, []string{digitChars}, "~20 bits"},
+		{"upper and lower", PasswordParams{Length: 12, Upper: true, Lower: true}, []string{upperChars, lowerChars}, "~68 bits"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Count = 50
+			var stdout, stderr bytes.Buffer
+			if err := runPassword(&tt.params, &stdout, &stderr); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			if len(lines) != 50 {
+				t.Fatalf("Expected 50 passwords, got %d", len(lines))
+			}
+			charset := strings.Join(tt.classes, "")
+			for _, pwd := range lines {
+				if len(pwd) != tt.params.Length {
+					t.Errorf("Expected length %d, got %q", tt.params.Length, pwd)
+				}
+				for _, class := range tt.classes {
+					if !strings.ContainsAny(pwd, class) {
+						t.Errorf("Expected a character from %q in %q", class, pwd)
+					}
+				}
+				for _, r := range pwd {
+					if !strings.ContainsRune(charset, r) {
+						t.Errorf("Unexpected character %q in %q", r, pwd)
+					}
+				}
+			}
+			if !strings.Contains(stderr.String(), tt.bits) {
+				t.Errorf("Expected entropy %q, got %q", tt.bits, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunPassword_NoAmbiguous(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	params := &PasswordParams{Length: 64, Count: 20, NoAmbiguous: true}
+	if err := runPassword(params, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.ContainsAny(stdout.String(), ambiguousChars) {
+		t.Errorf("Expected no ambiguous characters, got %q", stdout.String())
+	}
+}
+
+func TestRunPassword_Pronounceable(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	params := &PasswordParams{Length: 10, Count: 20, Pronounceable: true, Upper: true, Digits: true}
+	if err := runPassword(params, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, pwd := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		if len(pwd) != 10 {
+			t.Fatalf("Expected length 10, got %q", pwd)
+		}
+		if !strings.ContainsRune(strings.ToUpper(consonants), rune(pwd[0])) {
+			t.Errorf("Expected a capital consonant first, got %q", pwd)
+		}
+		for i := 1; i < 9; i++ {
+			set := consonants
+			if i%2 == 1 {
+				set = vowels
+			}
+			if !strings.ContainsRune(set, rune(pwd[i])) {
+				t.Errorf("Expected a character from %q at %d, got %q", set, i, pwd)
+			}
+		}
+		if !strings.ContainsRune(digitChars, rune(pwd[9])) {
+			t.Errorf("Expected a digit last, got %q", pwd)
+		}
+	}
+	// 5 consonants, 4 vowels and a digit
+	want := 5*math.Log2(19) + 4*math.Log2(6) + math.Log2(10)
+	if got := fmt.Sprintf("~%.0f bits", want); !strings.Contains(stderr.String(), got) {
+		t.Errorf("Expected entropy %q, got %q", got, stderr.String())
+	}
+}
+
+func TestRunPassword_Errors(t *testing.T) {
+	tests := []struct {
+		params PasswordParams
+		want   string
+	}{
+		{PasswordParams{Length: 3, Count: 1}, "--length must be at least 4 to include every character class"},
+		{PasswordParams{Length: 0, Count: 1}, "--length must be at least 1"},
+		{PasswordParams{Length: 3, Count: 1, Pronounceable: true, Digits: true, Symbols: true}, "--length must be at least 4 for a pronounceable password"},
+	}
+	for _, tt := range tests {
+		err := runPassword(&tt.params, io.Discard, io.Discard)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Expected error %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	// 2 classes of 1 character, length 3: 2^3 strings minus aaa and bbb
+	g := &passwordGenerator{length: 3, classes: []string{"a", "b"}}
+	if got, want := g.entropy(), math.Log2(6); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	g = &passwordGenerator{length: 100, classes: []string{digitChars}}
+	if got, want := g.entropy(), 100*math.Log2(10); math.Abs(got-want) > 1e-6 {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
 }
@@ -0,0 +1,43 @@
+package rand
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// uniqueInts picks count distinct integers from [min, max] in random order,
+// with a Fisher-Yates shuffle that only stores the positions it swapped, so
+// that huge ranges don't need memory.
+func uniqueInts(min, max int64, count int) ([]string, error) {
+	if min > max {
+		return nil, fmt.Errorf("min cannot be greater than max")
+	}
+	size := new(big.Int).Sub(big.NewInt(max), big.NewInt(min))
+	size.Add(size, big.NewInt(1))
+	if size.Cmp(big.NewInt(int64(count))) < 0 {
+		return nil, fmt.Errorf("cannot pick %d unique integers from %d to %d, the range has only %s", count, min, max, size)
+	}
+
+	swapped := map[string]*big.Int{}
+	at := func(i *big.Int) *big.Int {
+		if v, ok := swapped[i.String()]; ok {
+			return v
+		}
+		return i
+	}
+	result := make([]string, 0, count)
+	for i := range count {
+		pos := big.NewInt(int64(i))
+		remaining := new(big.Int).Sub(size, pos)
+		j, err := rand.Int(rand.Reader, remaining)
+		if err != nil {
+			return nil, err
+		}
+		j.Add(j, pos)
+		picked := at(j)
+		swapped[j.String()] = at(pos)
+		result = append(result, new(big.Int).Add(picked, big.NewInt(min)).String())
+	}
+	return result, nil
+}
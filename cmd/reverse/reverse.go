@@ -2,9 +2,15 @@ package reverse
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
+	"unicode"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
@@ -12,13 +18,23 @@ import (
 )
 
 type Params struct {
-	Files []string `pos:"true" optional:"true" help:"Files to reverse. If none specified, read from standard input."`
+	Files      []string `pos:"true" optional:"true" help:"Files to reverse. If none specified, read from standard input."`
+	Lines      bool     `short:"l" help:"Reverse the order of lines, like tac (default)."`
+	Words      bool     `short:"w" help:"Reverse the order of words within each line."`
+	Chars      bool     `short:"c" help:"Reverse the characters within each line, like rev."`
+	Paragraphs bool     `short:"p" help:"Reverse the order of blank-line separated paragraphs, keeping the lines within each in order."`
+	Hex        bool     `help:"Treat each input line as hex bytes and reverse the byte order (endianness swap)."`
 }
 
+// reverseChunkSize is how much of a file is read at a time when reading
+// lines backwards from its end.
+const reverseChunkSize = 64 * 1024
+
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
 		Use:         "reverse",
 		Short:       "Output lines in reverse order",
+		Long:        "Reverse input. By default the order of lines is reversed (like tac); --words reverses the words within each line, --chars reverses the characters within each line (like rev), --paragraphs reverses the order of blank-line separated paragraphs, and --hex reverses byte order.",
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if len(params.Files) == 0 {
@@ -31,6 +47,17 @@ func Cmd() *cobra.Command {
 }
 
 func Run(params *Params, stdin io.Reader, stdout, stderr io.Writer) int {
+	modes := 0
+	for _, set := range []bool{params.Lines, params.Words, params.Chars, params.Paragraphs, params.Hex} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		fmt.Fprintln(stderr, "reverse: --lines, --words, --chars, --paragraphs and --hex are mutually exclusive")
+		return 1
+	}
+
 	for _, file := range params.Files {
 		var reader io.Reader
 		if file == "-" {
@@ -45,7 +72,26 @@ func Run(params *Params, stdin io.Reader, stdout, stderr io.Writer) int {
 			reader = f
 		}
 
-		if err := reverseLines(reader, stdout); err != nil {
+		if params.Hex {
+			if err := reverseHex(reader, stdout); err != nil {
+				fmt.Fprintf(stderr, "reverse: %v\n", err)
+				return 1
+			}
+			continue
+		}
+
+		var err error
+		switch {
+		case params.Words:
+			err = mapLines(reader, stdout, reverseWords)
+		case params.Chars:
+			err = mapLines(reader, stdout, reverseChars)
+		case params.Paragraphs:
+			err = reverseParagraphs(reader, stdout)
+		default:
+			err = reverseLines(reader, stdout)
+		}
+		if err != nil {
 			fmt.Fprintf(stderr, "reverse: error reading: %v\n", err)
 			return 1
 		}
@@ -53,7 +99,15 @@ func Run(params *Params, stdin io.Reader, stdout, stderr io.Writer) int {
 	return 0
 }
 
+// reverseLines writes the lines of r in reverse order. Regular files are read
+// backwards from the end in chunks; other input has to be buffered in full.
 func reverseLines(r io.Reader, w io.Writer) error {
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			return reverseLinesFromEnd(f, info.Size(), w)
+		}
+	}
+
 	scanner := bufio.NewScanner(r)
 	var lines []string
 
@@ -71,3 +125,178 @@ func reverseLines(r io.Reader, w io.Writer) error {
 
 	return nil
 }
+
+// reverseLinesFromEnd writes the lines of the first size bytes of r in reverse
+// order, reading chunks backwards so memory use is bounded by the longest line.
+// Line endings are normalized the same way bufio.ScanLines does.
+func reverseLinesFromEnd(r io.ReaderAt, size int64, w io.Writer) error {
+	if size == 0 {
+		return nil
+	}
+
+	bw := bufio.NewWriter(w)
+	emit := func(line []byte) {
+		bw.Write(bytes.TrimSuffix(line, []byte("\r")))
+		bw.WriteByte('\n')
+	}
+
+	pos := size
+	var carry []byte // start of the line that continues into the following chunk
+	for pos > 0 {
+		n := min(int64(reverseChunkSize), pos)
+		pos -= n
+		buf := make([]byte, n, n+int64(len(carry)))
+		if _, err := r.ReadAt(buf, pos); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		buf = append(buf, carry...)
+
+		// A final newline terminates the last line rather than starting an empty one
+		if pos+n == size {
+			buf = bytes.TrimSuffix(buf, []byte("\n"))
+		}
+
+		for {
+			i := bytes.LastIndexByte(buf, '\n')
+			if i < 0 {
+				break
+			}
+			emit(buf[i+1:])
+			buf = buf[:i]
+		}
+		carry = buf
+	}
+	emit(carry)
+
+	return bw.Flush()
+}
+
+// reverseParagraphs writes the paragraphs of r in reverse order, keeping
+// the order of the lines within each. Paragraphs are separated by blank or
+// whitespace-only lines; in the output they are separated by exactly one
+// empty line, and leading and trailing blank lines are dropped.
+func reverseParagraphs(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	var paragraphs [][]string
+	var current []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if current != nil {
+				paragraphs = append(paragraphs, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if current != nil {
+		paragraphs = append(paragraphs, current)
+	}
+
+	bw := bufio.NewWriter(w)
+	for i := len(paragraphs) - 1; i >= 0; i-- {
+		for _, line := range paragraphs[i] {
+			bw.WriteString(line)
+			bw.WriteByte('\n')
+		}
+		if i > 0 {
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// mapLines streams r line by line, writing transform(line) for each.
+func mapLines(r io.Reader, w io.Writer, transform func(string) string) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	bw := bufio.NewWriter(w)
+	for scanner.Scan() {
+		bw.WriteString(transform(scanner.Text()))
+		bw.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// reverseWords reverses the order of the words in line. Leading and trailing
+// whitespace stay in place, and the whitespace between words is reversed
+// along with them, so "a  b c" becomes "c b  a".
+func reverseWords(line string) string {
+	trimmed := strings.TrimFunc(line, unicode.IsSpace)
+	if trimmed == "" {
+		return line
+	}
+	start := strings.Index(line, trimmed)
+	leading, trailing := line[:start], line[start+len(trimmed):]
+
+	// Split into alternating word and whitespace tokens
+	var tokens []string
+	inSpace := false
+	tokenStart := 0
+	for i, r := range trimmed {
+		if space := unicode.IsSpace(r); space != inSpace {
+			tokens = append(tokens, trimmed[tokenStart:i])
+			tokenStart = i
+			inSpace = space
+		}
+	}
+	tokens = append(tokens, trimmed[tokenStart:])
+	slices.Reverse(tokens)
+
+	return leading + strings.Join(tokens, "") + trailing
+}
+
+// reverseChars reverses the characters (runes) of line.
+func reverseChars(line string) string {
+	runes := []rune(line)
+	slices.Reverse(runes)
+	return string(runes)
+}
+
+// reverseHex parses each non-empty line as a hex byte string, reverses the
+// byte order and writes it back out as hex. Whitespace inside a line and an
+// optional 0x prefix are ignored, so "0x12345678" and "12 34 56 78" both
+// become "78563412".
+func reverseHex(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, scanner.Text())
+		line = strings.TrimPrefix(strings.TrimPrefix(line, "0x"), "0X")
+		if line == "" {
+			continue
+		}
+
+		if len(line)%2 != 0 {
+			return fmt.Errorf("invalid hex input on line %d: odd number of hex digits (%d)", lineNum, len(line))
+		}
+
+		data, err := hex.DecodeString(line)
+		if err != nil {
+			return fmt.Errorf("invalid hex input on line %d: %v", lineNum, err)
+		}
+
+		for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+			data[i], data[j] = data[j], data[i]
+		}
+
+		fmt.Fprintln(w, hex.EncodeToString(data))
+	}
+
+	return scanner.Err()
+}
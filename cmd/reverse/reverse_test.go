@@ -154,3 +154,228 @@ func TestRun_NonexistentFile(t *testing.T) {
 		t.Errorf("Expected error message about opening file, got: %s", stderr.String())
 	}
 }
+
+func TestReverseHex_FourBytes(t *testing.T) {
+	input := "12345678\n"
+	expected := "78563412\n"
+
+	var stdout bytes.Buffer
+	err := reverseHex(strings.NewReader(input), &stdout)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestReverseHex_EightBytes(t *testing.T) {
+	input := "0x0102030405060708\nde ad be ef ca fe ba be\n"
+	expected := "0807060504030201\nbebafecaefbeadde\n"
+
+	var stdout bytes.Buffer
+	err := reverseHex(strings.NewReader(input), &stdout)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestReverseHex_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"odd length", "12345\n", "odd number of hex digits"},
+		{"non-hex", "zz00\n", "invalid hex input on line 1"},
+		{"second line", "abcd\n12g4\n", "invalid hex input on line 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := reverseHex(strings.NewReader(tt.input), &stdout)
+			if err == nil {
+				t.Fatalf("Expected error, got output %q", stdout.String())
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRun_Hex(t *testing.T) {
+	params := &Params{
+		Files: []string{"-"},
+		Hex:   true,
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Run(params, strings.NewReader("aabbccdd\n"), &stdout, &stderr)
+
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+	}
+	if stdout.String() != "ddccbbaa\n" {
+		t.Errorf("Expected %q, got %q", "ddccbbaa\n", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	exitCode = Run(params, strings.NewReader("abc\n"), &stdout, &stderr)
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "odd number of hex digits") {
+		t.Errorf("Expected odd-length error, got: %s", stderr.String())
+	}
+}
+
+func TestReverseLinesFromEnd(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"trailing newline", "line1\nline2\nline3\n", "line3\nline2\nline1\n"},
+		{"no trailing newline", "line1\nline2\nline3", "line3\nline2\nline1\n"},
+		{"crlf", "a\r\nb\r\n", "b\na\n"},
+		{"empty lines", "a\n\nb\n", "b\n\na\n"},
+		{"only newline", "\n", "\n"},
+		{"empty", "", ""},
+		{"spans chunks", strings.Repeat("x", reverseChunkSize+10) + "\nshort\n", "short\n" + strings.Repeat("x", reverseChunkSize+10) + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := reverseLinesFromEnd(strings.NewReader(tt.input), int64(len(tt.input)), &stdout)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+			}
+		})
+	}
+}
+
+func TestReverseWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"one two three", "three two one"},
+		{"a  b c", "c b  a"},
+		{"  indented words\t", "  words indented\t"},
+		{"single", "single"},
+		{"", ""},
+		{"   ", "   "},
+	}
+
+	for _, tt := range tests {
+		if got := reverseWords(tt.input); got != tt.expected {
+			t.Errorf("reverseWords(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestReverseChars(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"hello", "olleh"},
+		{"ñandú ☃", "☃ údnañ"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := reverseChars(tt.input); got != tt.expected {
+			t.Errorf("reverseChars(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestReverseParagraphs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			"three paragraphs",
+			"## 1.0\n- first\n- second\n\n## 1.1\n- third\n\n## 1.2\n- fourth\n- fifth\n",
+			"## 1.2\n- fourth\n- fifth\n\n## 1.1\n- third\n\n## 1.0\n- first\n- second\n",
+		},
+		{
+			"extra and whitespace-only blank lines",
+			"\n\na\nb\n\n \t\n\nc\n\n",
+			"c\n\na\nb\n",
+		},
+		{"single paragraph", "a\nb\nc", "a\nb\nc\n"},
+		{"empty", "", ""},
+		{"only blank lines", "\n\n\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := reverseParagraphs(strings.NewReader(tt.input), &out); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestRun_Modes(t *testing.T) {
+	input := "hello world\nfoo bar baz\n"
+	tests := []struct {
+		name     string
+		params   Params
+		expected string
+	}{
+		{"default reverses lines", Params{}, "foo bar baz\nhello world\n"},
+		{"lines", Params{Lines: true}, "foo bar baz\nhello world\n"},
+		{"words", Params{Words: true}, "world hello\nbaz bar foo\n"},
+		{"chars", Params{Chars: true}, "dlrow olleh\nzab rab oof\n"},
+		{"paragraphs", Params{Paragraphs: true}, "hello world\nfoo bar baz\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Files = []string{"-"}
+			var stdout, stderr bytes.Buffer
+			exitCode := Run(&tt.params, strings.NewReader(input), &stdout, &stderr)
+			if exitCode != 0 {
+				t.Errorf("Expected exit code 0, got %d. Stderr: %s", exitCode, stderr.String())
+			}
+			if stdout.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_ConflictingModes(t *testing.T) {
+	params := &Params{Files: []string{"-"}, Words: true, Chars: true}
+
+	var stdout, stderr bytes.Buffer
+	exitCode := Run(params, strings.NewReader("a b\n"), &stdout, &stderr)
+
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "mutually exclusive") {
+		t.Errorf("Expected mutually exclusive error, got: %s", stderr.String())
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
@@ -21,13 +24,46 @@ const (
 )
 
 type Params struct {
-	From       string      `pos:"true" help:"Pattern to search for."`
-	To         string      `pos:"true" help:"Replacement string."`
-	Files      []string    `pos:"true" optional:"true" help:"Files to process. If none specified or -, read from standard input." default:"-"`
-	SearchType PatternType `short:"t" help:"Type of pattern to search for (literal, regex)." default:"regex" alts:"literal,regex"`
-	InPlace    bool        `short:"i" help:"Edit files in place." default:"false"`
-	IgnoreCase bool        `short:"I" help:"Perform a case-insensitive search." default:"false"`
-	Global     bool        `short:"g" help:"Replace all occurrences on each line (not just first)." default:"false"`
+	From           string      `pos:"true" optional:"true" help:"Pattern to search for (omitted when -e or -f is used)."`
+	To             string      `pos:"true" optional:"true" help:"Replacement string (omitted when -e or -f is used)."`
+	Files          []string    `pos:"true" optional:"true" help:"Files to process. If none specified or -, read from standard input." default:"-"`
+	SearchType     PatternType `short:"t" help:"Type of pattern to search for (literal, regex)." default:"regex" alts:"literal,regex"`
+	InPlace        string      `short:"i" optional:"true" help:"Edit files in place. Attach a suffix to keep a backup of each original (-i.bak or --in-place=.bak)."`
+	IgnoreCase     bool        `short:"I" help:"Perform a case-insensitive search." default:"false"`
+	Global         bool        `short:"g" help:"Replace all occurrences on each line (not just first)." default:"false"`
+	FollowSymlinks bool        `help:"When editing in place, edit the target of symlinks. With --follow-symlinks=false the link itself is replaced by a regular file." default:"true"`
+	Expressions    []string    `short:"e" name:"expression" optional:"true" help:"Expression s/pattern/replacement/[gi] to apply. Can be repeated; expressions are applied in order."`
+	ScriptFile     string      `short:"f" optional:"true" help:"Read expressions from a file, one per line (applied after any -e expressions)."`
+}
+
+// usesExpressions reports whether edits come from -e/-f rather than the
+// positional <from> <to> pair.
+func (p *Params) usesExpressions() bool {
+	return len(p.Expressions) > 0 || p.ScriptFile != ""
+}
+
+// expression is a single substitution to apply to each line.
+type expression struct {
+	pattern     *regexp.Regexp
+	replacement string
+	global      bool
+}
+
+// inPlaceNoBackup is the value --in-place takes when given without a suffix.
+// It doubles as compatibility with the old boolean form (--in-place=true).
+const inPlaceNoBackup = "true"
+
+// inPlaceMode reports whether files are edited in place, and the suffix for
+// backup copies of the originals (empty for no backup).
+func (p *Params) inPlaceMode() (bool, string) {
+	switch p.InPlace {
+	case "", "false":
+		return false, ""
+	case inPlaceNoBackup:
+		return true, ""
+	default:
+		return true, p.InPlace
+	}
 }
 
 func Cmd() *cobra.Command {
@@ -35,13 +71,29 @@ func Cmd() *cobra.Command {
 		Use:         "sed2",
 		Short:       "sed-like-but-different stream editor for filtering and transforming text",
 		ParamEnrich: common.DefaultParamEnricher(),
+		PostCreateFunc: func(params *Params, cmd *cobra.Command) error {
+			// Allow -i without a value, and -i.bak with an attached backup suffix
+			if f := cmd.Flags().Lookup("in-place"); f != nil {
+				f.NoOptDefVal = inPlaceNoBackup
+			}
+			return nil
+		},
 		PreExecuteFunc: func(params *Params, cmd *cobra.Command, args []string) error {
-			if params.From == "" {
-				return fmt.Errorf("search pattern cannot be empty")
+			if params.usesExpressions() {
+				// There is no positional pattern, so every argument is a file
+				params.From, params.To = "", ""
+				params.Files = args
+			} else {
+				if params.From == "" {
+					return fmt.Errorf("search pattern cannot be empty")
+				}
+				if len(args) < 2 {
+					return fmt.Errorf("expected <from> <to> [files...], or expressions via -e/-f")
+				}
 			}
 
 			// InPlace only makes sense with actual files
-			if params.InPlace && (len(params.Files) == 0 || params.Files[0] == "-") {
+			if inPlace, _ := params.inPlaceMode(); inPlace && (len(params.Files) == 0 || params.Files[0] == "-") {
 				return fmt.Errorf("-i (in-place) flag requires file arguments")
 			}
 
@@ -57,28 +109,9 @@ func Cmd() *cobra.Command {
 }
 
 func Run(params *Params) int {
-	// Build the pattern
-	var pattern *regexp.Regexp
-	var err error
-
-	if params.SearchType == PatternTypeLiteral {
-		// For literal search, escape regex special characters
-		escapedPattern := regexp.QuoteMeta(params.From)
-		if params.IgnoreCase {
-			escapedPattern = "(?i)" + escapedPattern
-		}
-		pattern, err = regexp.Compile(escapedPattern)
-	} else {
-		// Regex search
-		patternStr := params.From
-		if params.IgnoreCase {
-			patternStr = "(?i)" + patternStr
-		}
-		pattern, err = regexp.Compile(patternStr)
-	}
-
+	exprs, err := buildExpressions(params)
 	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "sed2: invalid pattern: %v\n", err)
+		_, _ = fmt.Fprintf(os.Stderr, "sed2: %v\n", err)
 		return 2
 	}
 
@@ -92,9 +125,9 @@ func Run(params *Params) int {
 	for _, file := range params.Files {
 		var err error
 		if file == "-" {
-			err = ProcessReader(os.Stdin, os.Stdout, pattern, params)
+			err = processExpressions(os.Stdin, os.Stdout, exprs)
 		} else {
-			err = ProcessFile(file, pattern, params)
+			err = processFile(file, exprs, params)
 		}
 
 		if err != nil {
@@ -109,67 +142,306 @@ func Run(params *Params) int {
 	return 0
 }
 
-func ProcessFile(filename string, pattern *regexp.Regexp, params *Params) error {
-	if params.InPlace {
-		// Read entire file into memory
-		file, err := os.Open(filename)
+// compilePattern compiles a search pattern according to the search type.
+func compilePattern(pattern string, searchType PatternType, ignoreCase bool) (*regexp.Regexp, error) {
+	if searchType == PatternTypeLiteral {
+		// For literal search, escape regex special characters
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	return re, nil
+}
+
+// buildExpressions collects the substitutions to apply: the -e expressions
+// followed by those from the -f script file, or else the positional pair.
+func buildExpressions(params *Params) ([]expression, error) {
+	if !params.usesExpressions() {
+		if params.From == "" {
+			return nil, fmt.Errorf("search pattern cannot be empty")
+		}
+		pattern, err := compilePattern(params.From, params.SearchType, params.IgnoreCase)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		return []expression{{pattern: pattern, replacement: params.To, global: params.Global}}, nil
+	}
 
-		var output strings.Builder
-		err = ProcessReader(file, &output, pattern, params)
-		closeErr := file.Close()
+	sources := append([]string{}, params.Expressions...)
+	if params.ScriptFile != "" {
+		lines, err := readScriptFile(params.ScriptFile)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, lines...)
+	}
 
+	var exprs []expression
+	for _, src := range sources {
+		expr, err := parseExpression(src, params)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if closeErr != nil {
-			return fmt.Errorf("error closing file: %v", closeErr)
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+// readScriptFile returns the expressions in a script file, skipping blank
+// lines and lines starting with #.
+func readScriptFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read script file: %v", err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read script file: %v", err)
+	}
+	return lines, nil
+}
 
-		// Write back to file
-		err = os.WriteFile(filename, []byte(output.String()), 0644)
-		if err != nil {
-			return fmt.Errorf("error writing file: %v", err)
+// parseExpression parses s/pattern/replacement/flags. Any character not a
+// letter, digit or backslash can be used as delimiter, and an escaped
+// delimiter stands for itself. Supported flags are g (global) and i/I
+// (ignore case); --global and --ignore-case apply to every expression.
+// Replacements use the same $1 syntax as the positional form.
+func parseExpression(src string, params *Params) (expression, error) {
+	invalid := fmt.Errorf("invalid expression %q: expected s/pattern/replacement/[flags]", src)
+	if len(src) < 2 || src[0] != 's' {
+		return expression{}, invalid
+	}
+	delim, size := utf8.DecodeRuneInString(src[1:])
+	if delim == '\\' || delim == '\n' || unicode.IsLetter(delim) || unicode.IsDigit(delim) {
+		return expression{}, invalid
+	}
+
+	var parts []string
+	var current strings.Builder
+	rest := src[1+size:]
+	for i := 0; i < len(rest); {
+		r, n := utf8.DecodeRuneInString(rest[i:])
+		if r == '\\' && i+n < len(rest) {
+			next, m := utf8.DecodeRuneInString(rest[i+n:])
+			if next != delim {
+				current.WriteRune(r)
+			}
+			current.WriteRune(next)
+			i += n + m
+			continue
+		}
+		if r == delim && len(parts) < 2 {
+			parts = append(parts, current.String())
+			current.Reset()
+		} else {
+			current.WriteRune(r)
 		}
+		i += n
+	}
+	if len(parts) != 2 {
+		return expression{}, invalid
+	}
 
-		return nil
-	} else {
-		// Just read and output
-		file, err := os.Open(filename)
+	global, ignoreCase := params.Global, params.IgnoreCase
+	for _, flag := range current.String() {
+		switch flag {
+		case 'g':
+			global = true
+		case 'i', 'I':
+			ignoreCase = true
+		default:
+			return expression{}, fmt.Errorf("invalid expression %q: unknown flag %q", src, flag)
+		}
+	}
+
+	if parts[0] == "" {
+		return expression{}, fmt.Errorf("invalid expression %q: search pattern cannot be empty", src)
+	}
+	pattern, err := compilePattern(parts[0], params.SearchType, ignoreCase)
+	if err != nil {
+		return expression{}, fmt.Errorf("expression %q: %v", src, err)
+	}
+
+	return expression{pattern: pattern, replacement: parts[1], global: global}, nil
+}
+
+func ProcessFile(filename string, pattern *regexp.Regexp, params *Params) error {
+	return processFile(filename, []expression{{pattern: pattern, replacement: params.To, global: params.Global}}, params)
+}
+
+func processFile(filename string, exprs []expression, params *Params) error {
+	if inPlace, backupSuffix := params.inPlaceMode(); inPlace {
+		return editInPlace(filename, exprs, params, backupSuffix)
+	}
+
+	// Just read and output
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		err := file.Close()
 		if err != nil {
-			return err
+			_, _ = fmt.Fprintf(os.Stderr, "sed2: error closing file %s: %v\n", filename, err)
 		}
-		defer func(file *os.File) {
-			err := file.Close()
+	}(file)
+
+	return processExpressions(file, os.Stdout, exprs)
+}
+
+// editInPlace writes the transformed content to a temp file next to the
+// original and renames it over the original, so the file is never left
+// half-written. Mode and (where permitted) ownership are preserved. With a
+// backup suffix, the original is kept as filename+suffix.
+//
+// Symlinks are followed and their target is edited, unless FollowSymlinks is
+// off, in which case (like GNU sed) the link is replaced by a regular file
+// holding the edited content.
+func editInPlace(filename string, exprs []expression, params *Params, backupSuffix string) error {
+	path := filename
+	info, err := os.Lstat(filename)
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		if params.FollowSymlinks {
+			path, err = filepath.EvalSymlinks(filename)
 			if err != nil {
-				_, _ = fmt.Fprintf(os.Stderr, "sed2: error closing file %s: %v\n", filename, err)
+				return err
 			}
-		}(file)
+		}
+		info, err = os.Stat(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file")
+	}
 
-		return ProcessReader(file, os.Stdout, pattern, params)
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".sed2-*")
+	if err != nil {
+		return fmt.Errorf("error creating temp file: %v", err)
+	}
+	tmpName := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tmp.Close()
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	out := bufio.NewWriter(tmp)
+	if err := processExpressions(in, out, exprs); err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
 	}
+
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		return fmt.Errorf("error setting file mode: %v", err)
+	}
+	preserveOwner(tmp, info)
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	if backupSuffix != "" {
+		if err := backupFile(path, path+backupSuffix); err != nil {
+			return fmt.Errorf("error creating backup: %v", err)
+		}
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("error replacing file: %v", err)
+	}
+	committed = true
+
+	return nil
+}
+
+// backupFile makes backup a copy of path. A hard link is used when possible
+// so the backup is exactly the original file; otherwise the content is copied.
+func backupFile(path, backup string) error {
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(path, backup); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(backup, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
 }
 
 func ProcessReader(reader io.Reader, writer io.Writer, pattern *regexp.Regexp, params *Params) error {
+	return processExpressions(reader, writer, []expression{{pattern: pattern, replacement: params.To, global: params.Global}})
+}
+
+// processExpressions applies each expression in order to every line.
+func processExpressions(reader io.Reader, writer io.Writer, exprs []expression) error {
 	scanner := bufio.NewScanner(reader)
 	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // 10MB max line size
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		// Perform replacement
-		var newLine string
-		if params.Global {
-			// Replace all occurrences
-			newLine = pattern.ReplaceAllString(line, params.To)
-		} else {
-			// Replace only first occurrence
-			newLine = ReplaceFirst(line, pattern, params.To)
+		// Perform replacements
+		for _, expr := range exprs {
+			if expr.global {
+				// Replace all occurrences
+				line = expr.pattern.ReplaceAllString(line, expr.replacement)
+			} else {
+				// Replace only first occurrence
+				line = ReplaceFirst(line, expr.pattern, expr.replacement)
+			}
 		}
 
-		_, err := fmt.Fprintln(writer, newLine)
+		_, err := fmt.Fprintln(writer, line)
 		if err != nil {
 			return err
 		}
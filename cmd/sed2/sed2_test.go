@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -286,7 +287,7 @@ func TestProcessFile_ReadOnly(t *testing.T) {
 		To:         "Universe",
 		SearchType: PatternTypeLiteral,
 		Global:     false,
-		InPlace:    false,
+		InPlace:    "",
 	}
 
 	pattern, err := regexp.Compile(regexp.QuoteMeta(params.From))
@@ -340,7 +341,7 @@ func TestProcessFile_InPlace(t *testing.T) {
 		To:         "Universe",
 		SearchType: PatternTypeLiteral,
 		Global:     false,
-		InPlace:    true,
+		InPlace:    "true",
 	}
 
 	pattern, err := regexp.Compile(regexp.QuoteMeta(params.From))
@@ -374,7 +375,7 @@ func TestProcessFile_FileNotFound(t *testing.T) {
 		To:         "TEST",
 		SearchType: PatternTypeLiteral,
 		Global:     false,
-		InPlace:    false,
+		InPlace:    "",
 	}
 
 	pattern, err := regexp.Compile(regexp.QuoteMeta(params.From))
@@ -524,7 +525,7 @@ func TestProcessFile_InPlace_MultipleLines(t *testing.T) {
 		To:         "PASS",
 		SearchType: PatternTypeLiteral,
 		Global:     true,
-		InPlace:    true,
+		InPlace:    "true",
 	}
 
 	pattern, err := regexp.Compile(regexp.QuoteMeta(params.From))
@@ -588,3 +589,269 @@ func TestProcessReader_RegexIgnoreCase(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, result)
 	}
 }
+
+func TestInPlaceMode(t *testing.T) {
+	tests := []struct {
+		value       string
+		wantInPlace bool
+		wantSuffix  string
+	}{
+		{"", false, ""},
+		{"false", false, ""},
+		{inPlaceNoBackup, true, ""},
+		{".bak", true, ".bak"},
+		{"~", true, "~"},
+	}
+
+	for _, tt := range tests {
+		params := &Params{InPlace: tt.value}
+		inPlace, suffix := params.inPlaceMode()
+		if inPlace != tt.wantInPlace || suffix != tt.wantSuffix {
+			t.Errorf("inPlaceMode(%q) = (%v, %q), want (%v, %q)", tt.value, inPlace, suffix, tt.wantInPlace, tt.wantSuffix)
+		}
+	}
+}
+
+func TestProcessFile_InPlaceWithBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "config.txt")
+	content := "port=80\nhost=localhost\n"
+	if err := os.WriteFile(file, []byte(content), 0640); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	params := &Params{From: "80", To: "8080", SearchType: PatternTypeLiteral, InPlace: ".bak"}
+	pattern := regexp.MustCompile(regexp.QuoteMeta(params.From))
+
+	if err := ProcessFile(file, pattern, params); err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+
+	edited, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(edited) != "port=8080\nhost=localhost\n" {
+		t.Errorf("Unexpected edited content %q", string(edited))
+	}
+
+	backup, err := os.ReadFile(file + ".bak")
+	if err != nil {
+		t.Fatalf("Expected backup file: %v", err)
+	}
+	if string(backup) != content {
+		t.Errorf("Expected backup to hold original content, got %q", string(backup))
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0640 {
+		t.Errorf("Expected mode 0640 to be preserved, got %v", info.Mode().Perm())
+	}
+
+	// No temp files left behind
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 2 {
+		t.Errorf("Expected only the file and its backup, got %d entries", len(entries))
+	}
+}
+
+func TestProcessFile_InPlaceMultipleFilesIndependent(t *testing.T) {
+	tmpDir := t.TempDir()
+	file1 := filepath.Join(tmpDir, "a.txt")
+	file2 := filepath.Join(tmpDir, "b.txt")
+	os.WriteFile(file1, []byte("a foo\n"), 0644)
+	os.WriteFile(file2, []byte("b foo foo\n"), 0644)
+
+	params := &Params{From: "foo", To: "bar", SearchType: PatternTypeLiteral, Global: true, InPlace: inPlaceNoBackup}
+	pattern := regexp.MustCompile("foo")
+
+	for _, f := range []string{file1, file2} {
+		if err := ProcessFile(f, pattern, params); err != nil {
+			t.Fatalf("ProcessFile(%s) failed: %v", f, err)
+		}
+	}
+
+	c1, _ := os.ReadFile(file1)
+	c2, _ := os.ReadFile(file2)
+	if string(c1) != "a bar\n" || string(c2) != "b bar bar\n" {
+		t.Errorf("Unexpected contents %q and %q", string(c1), string(c2))
+	}
+}
+
+func TestProcessFile_InPlaceSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "target.txt")
+	link := filepath.Join(tmpDir, "link.txt")
+	os.WriteFile(target, []byte("hello\n"), 0644)
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	params := &Params{From: "hello", To: "bye", SearchType: PatternTypeLiteral, InPlace: inPlaceNoBackup, FollowSymlinks: true}
+	pattern := regexp.MustCompile("hello")
+
+	// Default: the link target is edited and the link stays in place
+	if err := ProcessFile(link, pattern, params); err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if c, _ := os.ReadFile(target); string(c) != "bye\n" {
+		t.Errorf("Expected target to be edited, got %q", string(c))
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected link to remain a symlink")
+	}
+}
+
+func TestProcessFile_InPlaceSymlinkNoFollow(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "target.txt")
+	link := filepath.Join(tmpDir, "link.txt")
+	os.WriteFile(target, []byte("hello\n"), 0644)
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	params := &Params{From: "hello", To: "bye", SearchType: PatternTypeLiteral, InPlace: inPlaceNoBackup, FollowSymlinks: false}
+	pattern := regexp.MustCompile("hello")
+
+	// The link itself is replaced by a regular file, the target is untouched
+	if err := ProcessFile(link, pattern, params); err != nil {
+		t.Fatalf("ProcessFile failed: %v", err)
+	}
+	if c, _ := os.ReadFile(target); string(c) != "hello\n" {
+		t.Errorf("Target should be untouched, got %q", string(c))
+	}
+	info, err := os.Lstat(link)
+	if err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("Expected link path to become a regular file")
+	}
+	if c, _ := os.ReadFile(link); string(c) != "bye\n" {
+		t.Errorf("Expected edited content at link path, got %q", string(c))
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		name        string
+		expr        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"simple", "s/foo/bar/", "foo foo", "bar foo", false},
+		{"global flag", "s/foo/bar/g", "foo foo", "bar bar", false},
+		{"ignore case flag", "s/FOO/bar/gi", "foo Foo", "bar bar", false},
+		{"capture groups", "s/(\\w+)@example.com/$1@new.com/", "joe@example.com", "joe@new.com", false},
+		{"custom delimiter", "s|/usr/local|/opt|", "/usr/local/bin", "/opt/bin", false},
+		{"escaped delimiter", "s/a\\/b/x/", "a/b", "x", false},
+		{"missing closing delimiter", "s/foo/bar", "", "", true},
+		{"not a substitution", "d", "", "", true},
+		{"unknown flag", "s/foo/bar/x", "", "", true},
+		{"empty pattern", "s//bar/", "", "", true},
+		{"invalid regex", "s/(/x/", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseExpression(tt.expr, &Params{SearchType: PatternTypeRegex})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var output bytes.Buffer
+			if err := processExpressions(strings.NewReader(tt.input), &output, []expression{expr}); err != nil {
+				t.Fatalf("processExpressions failed: %v", err)
+			}
+			if output.String() != tt.expected+"\n" {
+				t.Errorf("Expected %q, got %q", tt.expected+"\n", output.String())
+			}
+		})
+	}
+}
+
+func TestBuildExpressions_InOrderWithScriptFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	script := filepath.Join(tmpDir, "edits.sed")
+	scriptContent := "# rename the product\ns/beta/gamma/\n\ns/gamma/delta/g\n"
+	if err := os.WriteFile(script, []byte(scriptContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params := &Params{
+		SearchType:  PatternTypeRegex,
+		Expressions: []string{"s/alpha/beta/g"},
+		ScriptFile:  script,
+	}
+
+	exprs, err := buildExpressions(params)
+	if err != nil {
+		t.Fatalf("buildExpressions failed: %v", err)
+	}
+	if len(exprs) != 3 {
+		t.Fatalf("Expected 3 expressions, got %d", len(exprs))
+	}
+
+	// alpha -> beta (-e, global), then first beta -> gamma, then gamma -> delta (script)
+	var output bytes.Buffer
+	if err := processExpressions(strings.NewReader("alpha beta gamma"), &output, exprs); err != nil {
+		t.Fatalf("processExpressions failed: %v", err)
+	}
+	if output.String() != "delta beta delta\n" {
+		t.Errorf("Unexpected output %q", output.String())
+	}
+}
+
+func TestBuildExpressions_Errors(t *testing.T) {
+	if _, err := buildExpressions(&Params{SearchType: PatternTypeRegex}); err == nil {
+		t.Error("Expected error for empty positional pattern")
+	}
+	if _, err := buildExpressions(&Params{SearchType: PatternTypeRegex, ScriptFile: "/nonexistent/script.sed"}); err == nil {
+		t.Error("Expected error for missing script file")
+	}
+	if _, err := buildExpressions(&Params{SearchType: PatternTypeRegex, Expressions: []string{"s/ok/fine/", "bogus"}}); err == nil {
+		t.Error("Expected error for invalid expression")
+	}
+}
+
+func TestProcessFile_InPlaceExpressions(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "app.conf")
+	os.WriteFile(file, []byte("debug=true\nlevel=info\n"), 0644)
+
+	params := &Params{
+		SearchType:  PatternTypeLiteral,
+		Expressions: []string{"s/debug=true/debug=false/", "s/info/warn/"},
+		InPlace:     ".orig",
+	}
+	exprs, err := buildExpressions(params)
+	if err != nil {
+		t.Fatalf("buildExpressions failed: %v", err)
+	}
+
+	if err := processFile(file, exprs, params); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if c, _ := os.ReadFile(file); string(c) != "debug=false\nlevel=warn\n" {
+		t.Errorf("Unexpected content %q", string(c))
+	}
+	if c, _ := os.ReadFile(file + ".orig"); string(c) != "debug=true\nlevel=info\n" {
+		t.Errorf("Unexpected backup content %q", string(c))
+	}
+}
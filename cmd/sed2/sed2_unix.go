@@ -0,0 +1,16 @@
+//go:build !windows
+
+package sed2
+
+import (
+	"os"
+	"syscall"
+)
+
+// preserveOwner gives f the owner and group of the file described by info.
+// This is best effort: unprivileged users can't always change ownership.
+func preserveOwner(f *os.File, info os.FileInfo) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		_ = f.Chown(int(stat.Uid), int(stat.Gid))
+	}
+}
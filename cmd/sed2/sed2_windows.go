@@ -0,0 +1,9 @@
+//go:build windows
+
+package sed2
+
+import "os"
+
+// preserveOwner is a no-op on Windows, where files inherit ACLs from their
+// directory rather than carrying a Unix owner and group.
+func preserveOwner(_ *os.File, _ os.FileInfo) {}
@@ -0,0 +1,80 @@
+package serve
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clfTimeFormat is the timestamp format of the Common Log Format.
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// accessLogger is middleware writing a line per request in Apache's Common
+// or, with combined set, Combined Log Format.
+type accessLogger struct {
+	next     http.Handler
+	out      io.Writer
+	combined bool
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+func (l *accessLogger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Capture the request as received, handlers may rewrite it
+	start := l.now()
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+	}
+	user := "-"
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = escapeLogField(u)
+	}
+	requestLine := escapeLogField(fmt.Sprintf("%s %s %s", r.Method, r.RequestURI, r.Proto))
+	referer, userAgent := r.Referer(), r.UserAgent()
+
+	rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+	l.next.ServeHTTP(rw, r)
+
+	size := "-"
+	if rw.bytes > 0 {
+		size = fmt.Sprint(rw.bytes)
+	}
+	line := fmt.Sprintf("%s - %s [%s] \"%s\" %d %s", host, user, start.Format(clfTimeFormat), requestLine, rw.status, size)
+	if l.combined {
+		line += fmt.Sprintf(" \"%s\" \"%s\"", orDash(escapeLogField(referer)), orDash(escapeLogField(userAgent)))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(l.out, line)
+}
+
+// escapeLogField escapes quotes, backslashes and non-printable bytes the
+// way Apache does, so fields can't break out of their quotes.
+func escapeLogField(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&sb, "\\x%02x", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,96 @@
+package serve
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLogger(t *testing.T) {
+	fixed := time.Date(2026, 10, 16, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.Error(w, "not found", http.StatusNotFound)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Write([]byte("hello"))
+			w.Write([]byte(" world"))
+		}
+	})
+
+	tests := []struct {
+		name     string
+		combined bool
+		path     string
+		setup    func(r *http.Request)
+		expected string
+	}{
+		{
+			name:     "common",
+			path:     "/index.html?x=1",
+			expected: `192.0.2.1 - - [16/Oct/2026:13:55:36 -0700] "GET /index.html?x=1 HTTP/1.1" 200 11`,
+		},
+		{
+			name:     "error status",
+			path:     "/missing",
+			expected: `192.0.2.1 - - [16/Oct/2026:13:55:36 -0700] "GET /missing HTTP/1.1" 404 10`,
+		},
+		{
+			name:     "no body",
+			path:     "/empty",
+			expected: `192.0.2.1 - - [16/Oct/2026:13:55:36 -0700] "GET /empty HTTP/1.1" 204 -`,
+		},
+		{
+			name:     "basic auth user",
+			path:     "/",
+			setup:    func(r *http.Request) { r.SetBasicAuth("alice", "secret") },
+			expected: `192.0.2.1 - alice [16/Oct/2026:13:55:36 -0700] "GET / HTTP/1.1" 200 11`,
+		},
+		{
+			name:     "combined",
+			combined: true,
+			path:     "/",
+			setup: func(r *http.Request) {
+				r.Header.Set("Referer", "http://example.com/")
+				r.Header.Set("User-Agent", `curl/8.0 "quoted"`)
+			},
+			expected: `192.0.2.1 - - [16/Oct/2026:13:55:36 -0700] "GET / HTTP/1.1" 200 11 "http://example.com/" "curl/8.0 \"quoted\""`,
+		},
+		{
+			name:     "combined without headers",
+			combined: true,
+			path:     "/",
+			expected: `192.0.2.1 - - [16/Oct/2026:13:55:36 -0700] "GET / HTTP/1.1" 200 11 "-" "-"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := &accessLogger{next: handler, out: &out, combined: tt.combined, now: func() time.Time { return fixed }}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+			logger.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got := strings.TrimSuffix(out.String(), "\n"); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEscapeLogField(t *testing.T) {
+	got := escapeLogField("a\"b\\c\nd")
+	expected := `a\"b\\c\x0ad`
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,213 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/net/websocket"
+)
+
+// liveReloadPath is where the injected script opens its websocket.
+const liveReloadPath = "/__livereload"
+
+// liveReloadDebounce groups the burst of events from saving or building a
+// site into a single reload.
+const liveReloadDebounce = 100 * time.Millisecond
+
+// liveReloadScript reloads the page when told to over the websocket. If
+// the server goes away it keeps reconnecting, and reloads once it's back.
+const liveReloadScript = `<script>
+(function () {
+  var url = (location.protocol === "https:" ? "wss://" : "ws://") + location.host + "` + liveReloadPath + `";
+  function connect(reconnecting) {
+    var ws = new WebSocket(url);
+    ws.onopen = function () { if (reconnecting) location.reload(); };
+    ws.onmessage = function () { location.reload(); };
+    ws.onclose = function () { setTimeout(function () { connect(true); }, 1000); };
+  }
+  connect(false);
+})();
+</script>
+`
+
+// liveReload tells connected browsers to reload when files under root
+// change.
+type liveReload struct {
+	root    string
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+func newLiveReload(root string) *liveReload {
+	return &liveReload{root: root, clients: map[chan struct{}]bool{}}
+}
+
+// notify tells every connected browser to reload.
+func (l *liveReload) notify() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.clients {
+		select {
+		case ch <- struct{}{}:
+		default: // A reload is already pending
+		}
+	}
+}
+
+// ServeHTTP serves the websocket the injected script connects to.
+func (l *liveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	websocket.Handler(func(ws *websocket.Conn) {
+		// The connection lives on past the server's request timeouts
+		_ = ws.SetDeadline(time.Time{})
+
+		ch := make(chan struct{}, 1)
+		l.mu.Lock()
+		l.clients[ch] = true
+		l.mu.Unlock()
+		defer func() {
+			l.mu.Lock()
+			delete(l.clients, ch)
+			l.mu.Unlock()
+		}()
+
+		// Nothing is expected from the browser, reading just notices it leaving
+		closed := make(chan struct{})
+		go func() {
+			var msg string
+			for websocket.Message.Receive(ws, &msg) == nil {
+			}
+			close(closed)
+		}()
+
+		select {
+		case <-ch:
+			_ = websocket.Message.Send(ws, "reload")
+		case <-closed:
+		case <-r.Context().Done():
+		}
+	}).ServeHTTP(w, r)
+}
+
+// watch calls notify when anything under root changes, until ctx is done.
+func (l *liveReload) watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	addRecursive := func(dir string) error {
+		return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return watcher.Add(path)
+			}
+			return nil
+		})
+	}
+	if err := addRecursive(l.root); err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("failed to watch %s: %w", l.root, err)
+	}
+
+	go func() {
+		defer func() { _ = watcher.Close() }()
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// Watch directories created after startup too
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := addRecursive(event.Name); err != nil {
+							_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to watch new directory %s: %v\n", event.Name, err)
+						}
+					}
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+					debounce = time.After(liveReloadDebounce)
+				}
+			case <-debounce:
+				debounce = nil
+				l.notify()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				_, _ = fmt.Fprintf(os.Stderr, "serve: watch error: %v\n", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// scriptInjector buffers successful text/html responses to add the live
+// reload script to them. Anything else passes straight through.
+type scriptInjector struct {
+	http.ResponseWriter
+	decided bool
+	inject  bool
+	buf     bytes.Buffer
+}
+
+func (s *scriptInjector) WriteHeader(code int) {
+	if s.decided {
+		return
+	}
+	s.decided = true
+	if code == http.StatusOK && strings.HasPrefix(s.Header().Get("Content-Type"), "text/html") {
+		s.inject = true
+		return
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *scriptInjector) Write(b []byte) (int, error) {
+	if !s.decided {
+		s.WriteHeader(http.StatusOK)
+	}
+	if s.inject {
+		return s.buf.Write(b)
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// finish writes a buffered HTML response with the script added.
+func (s *scriptInjector) finish() {
+	if !s.inject {
+		return
+	}
+	body := injectScript(s.buf.Bytes())
+	s.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	s.ResponseWriter.WriteHeader(http.StatusOK)
+	_, _ = s.ResponseWriter.Write(body)
+}
+
+// injectScript adds the live reload script before </body>, or at the end
+// of documents without one.
+func injectScript(html []byte) []byte {
+	i := bytes.LastIndex(bytes.ToLower(html), []byte("</body>"))
+	if i < 0 {
+		i = len(html)
+	}
+	out := make([]byte, 0, len(html)+len(liveReloadScript))
+	out = append(out, html[:i]...)
+	out = append(out, liveReloadScript...)
+	return append(out, html[i:]...)
+}
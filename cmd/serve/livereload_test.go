@@ -0,0 +1,153 @@
+package serve
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestInjectScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"before body", "<html><body>hi</body></html>", "<html><body>hi" + liveReloadScript + "</body></html>"},
+		{"upper case", "<HTML><BODY>hi</BODY></HTML>", "<HTML><BODY>hi" + liveReloadScript + "</BODY></HTML>"},
+		{"no body tag", "<p>fragment</p>", "<p>fragment</p>" + liveReloadScript},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(injectScript([]byte(tt.input))); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestScriptInjector(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html": "<html><body>index</body></html>",
+		"style.css":  "body { color: red }",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	fs := http.FileServer(http.Dir(dir))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		injector := &scriptInjector{ResponseWriter: w}
+		defer injector.finish()
+		fs.ServeHTTP(injector, r)
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantScript bool
+	}{
+		{"/", http.StatusOK, true},
+		{"/style.css", http.StatusOK, false},
+		{"/missing.html", http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			body := rec.Body.String()
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if got := strings.Contains(body, liveReloadPath); got != tt.wantScript {
+				t.Errorf("Expected script injected = %v, got body %q", tt.wantScript, body)
+			}
+			if cl := rec.Header().Get("Content-Length"); cl != "" && cl != strconv.Itoa(len(body)) {
+				t.Errorf("Expected Content-Length %d, got %s", len(body), cl)
+			}
+		})
+	}
+}
+
+func TestLiveReloadNotify(t *testing.T) {
+	live := newLiveReload(t.TempDir())
+	var log bytes.Buffer
+	// Through the same wrappers as in Run, which must pass the hijack on
+	handler := &accessLogger{out: &log, now: time.Now, next: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		live.ServeHTTP(&responseWriter{ResponseWriter: w, status: http.StatusOK}, r)
+	})}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + liveReloadPath
+	var conns []*websocket.Conn
+	for range 2 {
+		ws, err := websocket.Dial(wsURL, "", server.URL)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer ws.Close()
+		conns = append(conns, ws)
+	}
+
+	// Wait for both browsers to be registered
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		live.mu.Lock()
+		n := len(live.clients)
+		live.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 clients, got %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	live.notify()
+	for i, ws := range conns {
+		_ = ws.SetDeadline(time.Now().Add(2 * time.Second))
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			t.Fatalf("Client %d: Unexpected error: %v", i, err)
+		}
+		if msg != "reload" {
+			t.Errorf("Client %d: Expected %q, got %q", i, "reload", msg)
+		}
+	}
+
+	// Each connection ends after its reload
+	for i, ws := range conns {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != io.EOF {
+			t.Errorf("Client %d: Expected the connection to close, got %v", i, err)
+		}
+	}
+
+	// The access log line is written once the handler returns
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		handler.mu.Lock()
+		logged := log.String()
+		handler.mu.Unlock()
+		if strings.Count(logged, `"GET /__livereload HTTP/1.1" 101`) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the websockets in the access log, got %q", logged)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
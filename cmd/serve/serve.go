@@ -1,25 +1,44 @@
 package serve
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 type Params struct {
 	Dir     string `pos:"true" optional:"true" help:"Directory to serve." default:"."`
 	Port    int    `short:"p" help:"Port to listen on." default:"8080"`
 	Host    string `help:"Host interface to bind to." default:"localhost"`
-	SpaMode bool   `help:"Enable Single Page Application mode (redirect 404 to index.html)." default:"false"`
+	Spa     bool   `name:"spa" help:"Single Page Application mode: serve index.html for paths that don't exist, except paths with a file extension. Also accepted as --spa-mode." default:"false"`
 	NoCache bool   `help:"Disable browser caching." default:"false"`
+	Quiet   bool   `short:"q" help:"Don't log requests to stderr." default:"false"`
+
+	AccessLog string `optional:"true" help:"Append an Apache-style access log line per request to this file, or to stdout with '-' instead of the default log lines."`
+	LogFormat string `help:"Access log format: common or combined (adds referer and user agent)." default:"common" alts:"common,combined"`
+
+	Auth       string `optional:"true" env:"TOFU_SERVE_AUTH" help:"Require HTTP basic auth with these credentials, as user:pass. Can also be set with TOFU_SERVE_AUTH, to keep the password out of the process list."`
+	TlsCert    string `optional:"true" help:"Serve HTTPS with this certificate file (PEM). Requires --tls-key."`
+	TlsKey     string `optional:"true" help:"Private key file (PEM) for --tls-cert."`
+	SelfSigned bool   `optional:"true" help:"Serve HTTPS with an ephemeral self-signed certificate generated at startup."`
+
+	Upload     bool `optional:"true" help:"Accept uploads into the served directory: PUT a file to its path, or use the form at /upload."`
+	LiveReload bool `optional:"true" help:"Reload pages in the browser when files in the served directory change, by injecting a script into HTML responses."`
 
 	ReadTimeoutMillis  int64 `help:"Maximum duration for reading the entire request, including the body (ms)." default:"5000"`
 	WriteTimeoutMillis int64 `help:"Maximum duration before timing out writes of the response (ms)." default:"10000"`
@@ -32,6 +51,10 @@ func Cmd() *cobra.Command {
 		Use:         "serve",
 		Short:       "Instant static file server",
 		ParamEnrich: common.DefaultParamEnricher(),
+		PostCreateFunc: func(params *Params, cmd *cobra.Command) error {
+			cmd.Flags().SetNormalizeFunc(normalizeFlagName)
+			return nil
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if err := Run(cmd.Context(), params); err != nil {
 				_, _ = fmt.Fprintf(os.Stderr, "serve: %v\n", err)
@@ -41,6 +64,17 @@ func Cmd() *cobra.Command {
 	}.ToCobra()
 }
 
+// normalizeFlagName keeps --spa-mode working, the flag's earlier name.
+func normalizeFlagName(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "spa-mode" {
+		name = "spa"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// requestLog is where a line per request is written, unless --quiet.
+var requestLog io.Writer = os.Stderr
+
 func Run(ctx context.Context, params *Params) error {
 	absDir, err := filepath.Abs(params.Dir)
 	if err != nil {
@@ -51,10 +85,39 @@ func Run(ctx context.Context, params *Params) error {
 		return fmt.Errorf("directory does not exist: %s", absDir)
 	}
 
+	var auth *basicAuth
+	if params.Auth != "" {
+		if auth, err = parseBasicAuth(params.Auth); err != nil {
+			return err
+		}
+	}
+
+	cert, err := loadCertificate(params)
+	if err != nil {
+		return err
+	}
+
+	var uploads *uploader
+	if params.Upload {
+		uploads = &uploader{root: absDir}
+	}
+
+	var live *liveReload
+	if params.LiveReload {
+		live = newLiveReload(absDir)
+		if err := live.watch(ctx); err != nil {
+			return err
+		}
+	}
+
 	fs := http.FileServer(http.Dir(absDir))
 
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+	serveFile := func(w http.ResponseWriter, r *http.Request) {
+		if live != nil && r.Method == http.MethodGet {
+			injector := &scriptInjector{ResponseWriter: w}
+			defer injector.finish()
+			w = injector
+		}
 
 		// Headers
 		if params.NoCache {
@@ -63,46 +126,110 @@ func Run(ctx context.Context, params *Params) error {
 			w.Header().Set("Expires", "0")
 		}
 
-		// SPA handling
-		if params.SpaMode {
-			// Check if file exists, if not serve index.html
-			fPath := filepath.Join(absDir, r.URL.Path)
-			// Basic check: if it has no extension and doesn't exist, or if it explicitly doesn't exist and isn't an asset
-			// A simple robust way for SPA:
-			// If file exists, serve it.
-			// If not, serve index.html.
+		// Client-side routes don't exist as files, but asset paths like
+		// /app.js should still 404 when missing
+		if params.Spa && path.Ext(r.URL.Path) == "" {
+			fPath := filepath.Join(absDir, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
 			if _, err := os.Stat(fPath); os.IsNotExist(err) {
 				r.URL.Path = "/"
 			}
 		}
 
+		fs.ServeHTTP(w, r)
+	}
+
+	var logMu sync.Mutex
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Log the request as received, before any SPA fallback
+		start, method, urlPath := time.Now(), r.Method, r.URL.Path
+		remote := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			remote = host
+		}
+
 		// Wrap response writer to capture status code
 		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
-		fs.ServeHTTP(rw, r)
 
-		// Log
-		duration := time.Since(start)
-		fmt.Printf("[%d] %s %s (%v)\n", rw.status, r.Method, r.URL.Path, duration)
+		if auth != nil && !auth.authorized(r) {
+			auth.challenge(rw)
+		} else if live != nil && r.URL.Path == liveReloadPath {
+			live.ServeHTTP(rw, r)
+		} else if uploads != nil && uploads.handles(r) {
+			uploads.ServeHTTP(rw, r)
+		} else {
+			serveFile(rw, r)
+		}
+
+		if !params.Quiet && params.AccessLog != "-" {
+			duration := time.Since(start).Round(time.Microsecond)
+			logMu.Lock()
+			fmt.Fprintf(requestLog, "[%d] %s %s %d bytes (%v) %s\n", rw.status, method, urlPath, rw.bytes, duration, remote)
+			logMu.Unlock()
+		}
 	})
 
+	var root http.Handler = handler
+	if params.AccessLog != "" {
+		if params.LogFormat != "common" && params.LogFormat != "combined" {
+			return fmt.Errorf("unknown log format: %s (use common or combined)", params.LogFormat)
+		}
+		var out io.Writer = os.Stdout
+		if params.AccessLog != "-" {
+			f, err := os.OpenFile(params.AccessLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to open access log: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		root = &accessLogger{next: handler, out: out, combined: params.LogFormat == "combined", now: time.Now}
+	}
+
 	addr := fmt.Sprintf("%s:%d", params.Host, params.Port)
 	server := &http.Server{
 		Addr:           addr,
-		Handler:        handler,
+		Handler:        root,
 		ReadTimeout:    time.Duration(params.ReadTimeoutMillis) * time.Millisecond,
 		WriteTimeout:   time.Duration(params.WriteTimeoutMillis) * time.Millisecond,
 		IdleTimeout:    time.Duration(params.IdleTimeoutMillis) * time.Millisecond,
 		MaxHeaderBytes: params.MaxHeaderBytes,
 	}
+	scheme := "http"
+	if cert != nil {
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{*cert}, MinVersion: tls.VersionTLS12}
+		scheme = "https"
+	}
 
 	// Handle graceful shutdown
 	serverErr := make(chan error, 1)
 	go func() {
-		fmt.Printf("Serving %s at http://%s\n", absDir, addr)
-		if params.SpaMode {
-			fmt.Println("SPA Mode enabled (redirecting 404s to index.html)")
+		fmt.Printf("Serving %s at %s://%s\n", absDir, scheme, addr)
+		if params.Spa {
+			fmt.Println("SPA mode enabled (serving index.html for missing paths without a file extension)")
+		}
+		if params.SelfSigned {
+			fmt.Printf("Self-signed certificate SHA-256 fingerprint: %s\n", fingerprint(cert))
 		}
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if params.Upload {
+			fmt.Printf("Uploads enabled: PUT files or use %s://%s/upload\n", scheme, addr)
+			if auth == nil {
+				fmt.Println("Warning: anyone who can reach the server can upload files, consider --auth")
+			}
+		}
+		if params.LiveReload {
+			fmt.Println("Live reload enabled")
+		}
+		if auth != nil && cert == nil {
+			fmt.Println("Warning: basic auth without TLS sends the password in clear text, consider --self-signed")
+		}
+		var err error
+		if cert != nil {
+			// The certificate is already in TLSConfig
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			serverErr <- err
 		}
 		close(serverErr)
@@ -121,10 +248,33 @@ func Run(ctx context.Context, params *Params) error {
 	}
 }
 
-// responseWriter wraps http.ResponseWriter to capture status code
+// responseWriter wraps http.ResponseWriter to capture status code and
+// bytes written
 type responseWriter struct {
 	http.ResponseWriter
 	status int
+	bytes  int64
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack hands over the connection for websockets, which type assert
+// http.Hijacker rather than using http.ResponseController
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -2,11 +2,13 @@ package serve
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -35,17 +37,20 @@ func TestServeCommand(t *testing.T) {
 	// Let's try 0 to let OS choose, but my code uses int port.
 	// Let's pick a random high port.
 	port := 45678
+	accessLogPath := filepath.Join(tmpDir, "access.log")
 
 	params := &Params{
 		Port:               port,
 		Dir:                tmpDir,
 		Host:               "localhost",
-		SpaMode:            true,
+		Spa:                true,
 		NoCache:            true,
 		ReadTimeoutMillis:  1000,
 		WriteTimeoutMillis: 1000,
 		IdleTimeoutMillis:  1000,
 		MaxHeaderBytes:     1024,
+		AccessLog:          accessLogPath,
+		LogFormat:          "combined",
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -110,4 +115,271 @@ func TestServeCommand(t *testing.T) {
 	case <-time.After(2 * time.Second):
 		t.Errorf("Run did not exit")
 	}
+
+	// Test 5: Access log, with the SPA fallback logged as requested
+	logData, err := os.ReadFile(accessLogPath)
+	if err != nil {
+		t.Fatalf("Failed to read access log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(logData)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 access log lines, got %d: %q", len(lines), logData)
+	}
+	for i, expected := range []string{
+		`"GET / HTTP/1.1" 200 18 "-" "Go-http-client/1.1"`,
+		`"GET /other.html HTTP/1.1" 200 18 "-" "Go-http-client/1.1"`,
+		`"GET /missing-page HTTP/1.1" 200 18 "-" "Go-http-client/1.1"`,
+	} {
+		if !strings.Contains(lines[i], " - - [") || !strings.HasSuffix(lines[i], expected) {
+			t.Errorf("Expected access log line ending with %q, got %q", expected, lines[i])
+		}
+	}
+}
+
+func TestServeAuthOverTLS(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "secret.txt"), []byte("top secret"), 0644); err != nil {
+		t.Fatalf("Failed to create secret.txt: %v", err)
+	}
+
+	port := 45679
+	params := &Params{
+		Port:               port,
+		Dir:                tmpDir,
+		Host:               "localhost",
+		Auth:               "alice:s3cret",
+		SelfSigned:         true,
+		Upload:             true,
+		ReadTimeoutMillis:  1000,
+		WriteTimeoutMillis: 1000,
+		IdleTimeoutMillis:  1000,
+		MaxHeaderBytes:     1024,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- Run(ctx, params)
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	url := fmt.Sprintf("https://localhost:%d/secret.txt", port)
+
+	tests := []struct {
+		name       string
+		user, pass string
+		wantStatus int
+		wantBody   string
+	}{
+		{"no credentials", "", "", http.StatusUnauthorized, "Unauthorized\n"},
+		{"wrong password", "alice", "guess", http.StatusUnauthorized, "Unauthorized\n"},
+		{"wrong user", "bob", "s3cret", http.StatusUnauthorized, "Unauthorized\n"},
+		{"valid", "alice", "s3cret", http.StatusOK, "top secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", url, nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.TLS == nil {
+				t.Errorf("Expected a TLS connection")
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, body)
+			}
+			challenge := resp.Header.Get("WWW-Authenticate")
+			if tt.wantStatus == http.StatusUnauthorized && !strings.HasPrefix(challenge, "Basic realm=") {
+				t.Errorf("Expected a Basic WWW-Authenticate challenge, got %q", challenge)
+			}
+		})
+	}
+
+	// Uploads need the credentials too
+	uploadURL := fmt.Sprintf("https://localhost:%d/dropped.txt", port)
+	for _, withAuth := range []bool{false, true} {
+		req, _ := http.NewRequest("PUT", uploadURL, strings.NewReader("dropped"))
+		wantStatus := http.StatusUnauthorized
+		if withAuth {
+			req.SetBasicAuth("alice", "s3cret")
+			wantStatus = http.StatusCreated
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != wantStatus {
+			t.Errorf("Expected PUT status %d, got %d", wantStatus, resp.StatusCode)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(tmpDir, "dropped.txt")); err != nil || string(data) != "dropped" {
+		t.Errorf("Expected uploaded file, got %q, %v", data, err)
+	}
+
+	cancel()
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Errorf("Run returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("Run did not exit")
+	}
+}
+
+func TestServeInvalidSecurityParams(t *testing.T) {
+	tmpDir := t.TempDir()
+	tests := []struct {
+		name   string
+		params Params
+		errMsg string
+	}{
+		{"auth without colon", Params{Auth: "alice"}, "expected user:pass"},
+		{"auth without user", Params{Auth: ":pw"}, "expected user:pass"},
+		{"cert without key", Params{TlsCert: "cert.pem"}, "must be given together"},
+		{"self-signed with cert", Params{SelfSigned: true, TlsCert: "cert.pem", TlsKey: "key.pem"}, "cannot be combined"},
+		{"missing cert files", Params{TlsCert: filepath.Join(tmpDir, "cert.pem"), TlsKey: filepath.Join(tmpDir, "key.pem")}, "failed to load TLS certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Dir = tmpDir
+			err := Run(context.Background(), &tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestServeSpaAuthAndRequestLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "index.html"), []byte("<html>app</html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "assets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "assets", "app.js"), []byte("run()"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var logBuf strings.Builder
+	requestLog = &logBuf
+	t.Cleanup(func() { requestLog = os.Stderr })
+
+	port := 45680
+	params := &Params{
+		Port:               port,
+		Dir:                tmpDir,
+		Host:               "localhost",
+		Spa:                true,
+		Auth:               "alice:s3cret",
+		ReadTimeoutMillis:  1000,
+		WriteTimeoutMillis: 1000,
+		IdleTimeoutMillis:  1000,
+		MaxHeaderBytes:     1024,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- Run(ctx, params)
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	get := func(path string, auth bool) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("http://localhost:%d%s", port, path), nil)
+		if auth {
+			req.SetBasicAuth("alice", "s3cret")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	tests := []struct {
+		path       string
+		auth       bool
+		wantStatus int
+		wantBody   string
+	}{
+		// The fallback is behind auth too
+		{"/users/42", false, http.StatusUnauthorized, "Unauthorized\n"},
+		{"/users/42", true, http.StatusOK, "<html>app</html>"},
+		{"/assets/app.js", true, http.StatusOK, "run()"},
+		// Missing files with an extension are not routes
+		{"/assets/missing.js", true, http.StatusNotFound, "404 page not found\n"},
+		{"/../../etc/passwd.txt", true, http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		status, body := get(tt.path, tt.auth)
+		if status != tt.wantStatus || body != tt.wantBody {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.wantStatus, tt.wantBody, status, body)
+		}
+	}
+
+	cancel()
+	<-errChan
+
+	lines := strings.Split(strings.TrimSpace(logBuf.String()), "\n")
+	if len(lines) != len(tests) {
+		t.Fatalf("Expected %d log lines, got %q", len(tests), logBuf.String())
+	}
+	for i, prefix := range []string{
+		"[401] GET /users/42 13 bytes (",
+		"[200] GET /users/42 16 bytes (",
+		"[200] GET /assets/app.js 5 bytes (",
+		"[404] GET /assets/missing.js 19 bytes (",
+	} {
+		if !strings.HasPrefix(lines[i], prefix) || !strings.HasSuffix(lines[i], ") 127.0.0.1") && !strings.HasSuffix(lines[i], ") ::1") {
+			t.Errorf("Expected a log line like %q... 127.0.0.1, got %q", prefix, lines[i])
+		}
+	}
+
+	// --quiet logs nothing
+	logBuf.Reset()
+	params.Quiet, params.Port = true, port+1
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		errChan <- Run(ctx, params)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	port++
+	if status, _ := get("/", true); status != http.StatusOK {
+		t.Errorf("Expected 200, got %d", status)
+	}
+	cancel()
+	<-errChan
+	if logBuf.Len() != 0 {
+		t.Errorf("Expected no log lines with --quiet, got %q", logBuf.String())
+	}
+}
+
+func TestNormalizeFlagName(t *testing.T) {
+	if got := normalizeFlagName(nil, "spa-mode"); got != "spa" {
+		t.Errorf("Expected %q, got %q", "spa", got)
+	}
+	if got := normalizeFlagName(nil, "quiet"); got != "quiet" {
+		t.Errorf("Expected %q, got %q", "quiet", got)
+	}
 }
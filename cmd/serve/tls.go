@@ -0,0 +1,94 @@
+package serve
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+)
+
+// selfSignedValidity is how long a --self-signed certificate is valid. It
+// only lives as long as the server anyway.
+const selfSignedValidity = 30 * 24 * time.Hour
+
+// loadCertificate returns the certificate to serve HTTPS with, from
+// --tls-cert/--tls-key or generated for --self-signed, or nil for HTTP.
+func loadCertificate(params *Params) (*tls.Certificate, error) {
+	if params.SelfSigned {
+		if params.TlsCert != "" || params.TlsKey != "" {
+			return nil, fmt.Errorf("--self-signed cannot be combined with --tls-cert or --tls-key")
+		}
+		return selfSignedCert(params.Host, time.Now())
+	}
+	if params.TlsCert == "" && params.TlsKey == "" {
+		return nil, nil
+	}
+	if params.TlsCert == "" || params.TlsKey == "" {
+		return nil, fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+	cert, err := tls.LoadX509KeyPair(params.TlsCert, params.TlsKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+// selfSignedCert generates an ephemeral certificate for host, and for
+// localhost so local clients can connect whatever the bind address is.
+func selfSignedCert(host string, now time.Time) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "tofu serve", Organization: []string{"tofu"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(selfSignedValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !ip.IsLoopback() && !ip.IsUnspecified() {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	} else if host != "" && host != "localhost" {
+		template.DNSNames = append(template.DNSNames, host)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// fingerprint is the SHA-256 fingerprint of a certificate in the usual
+// colon separated form, for checking a self-signed certificate by eye.
+func fingerprint(cert *tls.Certificate) string {
+	sum := sha256.Sum256(cert.Certificate[0])
+	hexParts := make([]string, len(sum))
+	for i, b := range sum {
+		hexParts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hexParts, ":")
+}
@@ -0,0 +1,91 @@
+package serve
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfSignedCert(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		host     string
+		wantDNS  []string
+		wantIPs  []string
+		hostname string
+	}{
+		{"localhost", []string{"localhost"}, []string{"127.0.0.1", "::1"}, "localhost"},
+		{"files.lan", []string{"localhost", "files.lan"}, []string{"127.0.0.1", "::1"}, "files.lan"},
+		{"192.168.1.20", []string{"localhost"}, []string{"127.0.0.1", "::1", "192.168.1.20"}, "192.168.1.20"},
+		{"0.0.0.0", []string{"localhost"}, []string{"127.0.0.1", "::1"}, "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			cert, err := selfSignedCert(tt.host, now)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			leaf := cert.Leaf
+			if !slices.Equal(leaf.DNSNames, tt.wantDNS) {
+				t.Errorf("Expected DNS names %v, got %v", tt.wantDNS, leaf.DNSNames)
+			}
+			var ips []string
+			for _, ip := range leaf.IPAddresses {
+				ips = append(ips, ip.String())
+			}
+			if !slices.Equal(ips, tt.wantIPs) {
+				t.Errorf("Expected IPs %v, got %v", tt.wantIPs, ips)
+			}
+			if err := leaf.VerifyHostname(tt.hostname); err != nil {
+				t.Errorf("Expected certificate to be valid for %s: %v", tt.hostname, err)
+			}
+			if !leaf.NotAfter.After(now) || leaf.NotBefore.After(now) {
+				t.Errorf("Expected certificate to be valid now, got %v to %v", leaf.NotBefore, leaf.NotAfter)
+			}
+			if fp := fingerprint(cert); len(strings.Split(fp, ":")) != 32 {
+				t.Errorf("Expected 32 byte fingerprint, got %q", fp)
+			}
+		})
+	}
+}
+
+func TestLoadCertificateFromFiles(t *testing.T) {
+	generated, err := selfSignedCert("localhost", time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(generated.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: generated.Certificate[0]})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cert, err := loadCertificate(&Params{TlsCert: certPath, TlsKey: keyPath})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fingerprint(cert) != fingerprint(generated) {
+		t.Errorf("Expected the certificate from the files to be loaded")
+	}
+
+	if cert, err := loadCertificate(&Params{}); cert != nil || err != nil {
+		t.Errorf("Expected no certificate without TLS flags, got %v, %v", cert, err)
+	}
+}
@@ -0,0 +1,235 @@
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// uploadPage is the form served at /upload.
+const uploadPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Upload</title></head>
+<body>
+<h1>Upload files</h1>
+<form method="post" action="/upload" enctype="multipart/form-data">
+<input type="file" name="file" multiple>
+<button type="submit">Upload</button>
+</form>
+</body>
+</html>
+`
+
+// uploader saves files into the served directory for --upload: the body of
+// a PUT to the path it names, or the files of a form posted to /upload.
+type uploader struct {
+	root string
+}
+
+func (u *uploader) handles(r *http.Request) bool {
+	return r.Method == http.MethodPut || r.URL.Path == "/upload"
+}
+
+func (u *uploader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Uploads can take longer than the server timeouts allow for requests
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	switch {
+	case r.Method == http.MethodPut:
+		u.servePut(w, r)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, uploadPage)
+	case r.Method == http.MethodPost:
+		u.serveForm(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST, PUT")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// servePut stores the request body at the path of the URL, replacing any
+// file already there.
+func (u *uploader) servePut(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/") {
+		http.Error(w, "PUT needs a file name, not a directory", http.StatusBadRequest)
+		return
+	}
+	dest, err := uploadPath(u.root, r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, statErr := os.Stat(dest)
+	n, err := saveUpload(u.root, dest, r.Body)
+	if errors.Is(err, errOutsideRoot) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if os.IsNotExist(statErr) {
+		w.WriteHeader(http.StatusCreated)
+	}
+	fmt.Fprintf(w, "Saved %s (%d bytes)\n", r.URL.Path, n)
+}
+
+// serveForm stores the files of a multipart form in the served directory.
+// Parts are streamed to disk one by one, and files that already exist are
+// kept by saving the upload under a numbered name.
+func (u *uploader) serveForm(w http.ResponseWriter, r *http.Request) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var saved []string
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Browsers on Windows may send a full path, only its base is used
+		name := path.Base(strings.ReplaceAll(part.FileName(), `\`, "/"))
+		if part.FileName() == "" || name == "/" || name == "." || name == ".." {
+			part.Close()
+			continue
+		}
+
+		dest, err := uploadPath(u.root, name)
+		if err == nil {
+			dest = uniquePath(dest)
+		}
+		var n int64
+		if err == nil {
+			n, err = saveUpload(u.root, dest, part)
+		}
+		part.Close()
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errOutsideRoot) {
+				status = http.StatusForbidden
+			}
+			http.Error(w, fmt.Sprintf("%s: %v", name, err), status)
+			return
+		}
+		saved = append(saved, fmt.Sprintf("Saved %s (%d bytes)", filepath.Base(dest), n))
+	}
+
+	if len(saved) == 0 {
+		http.Error(w, "No files in upload", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintln(w, strings.Join(saved, "\n"))
+}
+
+// uploadPath resolves an upload name to a path in root, refusing names that
+// would end up outside it.
+func uploadPath(root, name string) (string, error) {
+	absRoot := filepath.Clean(root)
+	dest, err := filepath.Abs(filepath.Join(absRoot, filepath.Clean(filepath.FromSlash(name))))
+	if err != nil {
+		return "", fmt.Errorf("invalid file path: %s", name)
+	}
+
+	// Security check: ensure we're not writing outside the served directory
+	if !strings.HasPrefix(dest, absRoot+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path: %s", name)
+	}
+	return dest, nil
+}
+
+// uniquePath returns dest, or if that exists, the first of "name-1.ext",
+// "name-2.ext" and so on that doesn't.
+func uniquePath(dest string) string {
+	ext := filepath.Ext(dest)
+	base := strings.TrimSuffix(dest, ext)
+	candidate := dest
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// errOutsideRoot is returned for uploads that a symlink in the served
+// directory would send somewhere else.
+var errOutsideRoot = errors.New("upload path leads outside the served directory")
+
+// checkInRoot makes sure dir, once its symlinks are resolved, is still in
+// root. uploadPath only checks the path as given, so without this a link
+// like root/link -> /etc would let uploads write anywhere. dir may not exist
+// yet, in which case its closest existing parent is checked, before any
+// missing directories are created in it.
+func checkInRoot(root, dir string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	existing := dir
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return errOutsideRoot
+		}
+		existing = parent
+	}
+	realDir, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+	if realDir != realRoot && !strings.HasPrefix(realDir, realRoot+string(filepath.Separator)) {
+		return errOutsideRoot
+	}
+	return nil
+}
+
+// saveUpload writes src to dest through a temporary file in the same
+// directory, so an interrupted upload never leaves a partial file behind.
+func saveUpload(root, dest string, src io.Reader) (int64, error) {
+	if err := checkInRoot(root, filepath.Dir(dest)); err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dest)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,158 @@
+package serve
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUploadPut(t *testing.T) {
+	root := t.TempDir()
+	u := &uploader{root: root}
+
+	put := func(target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		u.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, target, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := put("/notes/today.txt", "first"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	if rec := put("/notes/today.txt", "second"); rec.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	data, err := os.ReadFile(filepath.Join(root, "notes", "today.txt"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Expected PUT to replace the file, got %q", data)
+	}
+
+	if rec := put("/notes/", "x"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a directory, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Join(root, "notes"))
+	if len(entries) != 1 {
+		t.Errorf("Expected only today.txt, got %v", entries)
+	}
+}
+
+func TestUploadPutThroughSymlink(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	u := &uploader{root: root}
+
+	for _, target := range []string{"/link/x.txt", "/link/new/x.txt"} {
+		rec := httptest.NewRecorder()
+		u.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, target, strings.NewReader("evil")))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status %d, got %d: %s", target, http.StatusForbidden, rec.Code, rec.Body.String())
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("Expected nothing written outside the served directory, got %v", entries)
+	}
+
+	// Links within the served directory are fine
+	if err := os.Mkdir(filepath.Join(root, "real"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "real"), filepath.Join(root, "inside")); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	u.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/inside/ok.txt", strings.NewReader("ok")))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+}
+
+func TestUploadForm(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("existing"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	u := &uploader{root: root}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, content := range map[string]string{"a.txt": "new a", `C:\Users\me\b.txt`: "b", "../../evil.txt": "evil"} {
+		fw, _ := mw.CreateFormFile("file", name)
+		fw.Write([]byte(content))
+	}
+	mw.WriteField("comment", "not a file")
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	u.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	expected := map[string]string{"a.txt": "existing", "a-1.txt": "new a", "b.txt": "b", "evil.txt": "evil"}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Errorf("Expected %s to be saved: %v", name, err)
+		} else if string(data) != content {
+			t.Errorf("Expected %s to contain %q, got %q", name, content, data)
+		}
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != len(expected) {
+		t.Errorf("Expected %d files, got %v", len(expected), entries)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "evil.txt")); err == nil {
+		t.Errorf("Expected no file outside the served directory")
+	}
+}
+
+func TestUploadFormPage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	(&uploader{root: t.TempDir()}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/upload", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `enctype="multipart/form-data"`) {
+		t.Errorf("Expected the upload form, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestUploadPath(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"file.txt", filepath.Join(root, "file.txt"), false},
+		{"/dir/file.txt", filepath.Join(root, "dir", "file.txt"), false},
+		{"/a/../b.txt", filepath.Join(root, "b.txt"), false},
+		{"../evil.txt", "", true},
+		{"dir/../../evil.txt", "", true},
+		{"/", "", true},
+		{".", "", true},
+	}
+	for _, tt := range tests {
+		got, err := uploadPath(root, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("uploadPath(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("uploadPath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
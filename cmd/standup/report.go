@@ -0,0 +1,196 @@
+package standup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+// standupConfig is the optional standup.json in the config dir, listing the
+// repos to report on when --repos isn't given.
+type standupConfig struct {
+	Repos []string `json:"repos"`
+}
+
+func configPath() string {
+	return filepath.Join(common.ConfigDir(), "standup.json")
+}
+
+func loadConfig(path string) (standupConfig, error) {
+	var cfg standupConfig
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// commit is a commit in a standup report.
+type commit struct {
+	Hash    string
+	Subject string
+}
+
+type repoCommits struct {
+	Name    string
+	Commits []commit
+}
+
+// lastWorkday returns the start of the previous weekday, so that a report
+// on Monday covers Friday.
+func lastWorkday(now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for {
+		day = day.AddDate(0, 0, -1)
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			return day
+		}
+	}
+}
+
+// findRepos returns the repos to report on: --repos, else those in the config
+// file, else the git repos directly under root, like git sync.
+func findRepos(params *Params, cfg standupConfig) ([]string, error) {
+	if len(params.Repos) > 0 {
+		return params.Repos, nil
+	}
+	if len(cfg.Repos) > 0 {
+		return cfg.Repos, nil
+	}
+
+	if isGitRepo(params.Root) {
+		return []string{params.Root}, nil
+	}
+	entries, err := os.ReadDir(params.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var repos []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if path := filepath.Join(params.Root, entry.Name()); isGitRepo(path) {
+			repos = append(repos, path)
+		}
+	}
+	return repos, nil
+}
+
+// isGitRepo also accepts a .git file, as in linked worktrees.
+func isGitRepo(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil
+}
+
+func git(repo string, args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// repoCommitsSince returns the non-merge commits by the repo's configured
+// git user in the window.
+func repoCommitsSince(repo, since, until string) ([]commit, error) {
+	author, _ := git(repo, "config", "user.email")
+	if author = strings.TrimSpace(author); author == "" {
+		author, _ = git(repo, "config", "user.name")
+		if author = strings.TrimSpace(author); author == "" {
+			return nil, fmt.Errorf("no git user.email or user.name configured")
+		}
+	}
+
+	args := []string{"log", "--all", "--no-merges", "--author=" + author, "--since=" + since, "--format=%h%x09%s"}
+	if until != "" {
+		args = append(args, "--until="+until)
+	}
+	out, err := git(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []commit
+	for line := range strings.SplitSeq(strings.TrimSpace(out), "\n") {
+		if hash, subject, ok := strings.Cut(line, "\t"); ok {
+			commits = append(commits, commit{Hash: hash, Subject: subject})
+		}
+	}
+	return commits, nil
+}
+
+// runReport prints a standup report with the commits since the last workday,
+// or --since. Repos that can't be read are reported as warnings on stderr.
+func runReport(params *Params, now time.Time, stdout, stderr io.Writer) error {
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", configPath(), err)
+	}
+	repos, err := findRepos(params, cfg)
+	if err != nil {
+		return err
+	}
+
+	since, label := params.Since, "since "+params.Since
+	if since == "" {
+		day := lastWorkday(now)
+		since, label = day.Format("2006-01-02 15:04:05 -0700"), "since "+day.Format("Mon 2006-01-02")
+	}
+	if params.Until != "" {
+		label += " until " + params.Until
+	}
+
+	var results []repoCommits
+	for _, repo := range repos {
+		name := filepath.Base(repo)
+		if abs, err := filepath.Abs(repo); err == nil {
+			name = filepath.Base(abs)
+		}
+		if !isGitRepo(repo) {
+			fmt.Fprintf(stderr, "standup: warning: %s: not a git repository\n", repo)
+			continue
+		}
+		commits, err := repoCommitsSince(repo, since, params.Until)
+		if err != nil {
+			fmt.Fprintf(stderr, "standup: warning: %s: %v\n", repo, err)
+			continue
+		}
+		if len(commits) > 0 {
+			results = append(results, repoCommits{Name: name, Commits: commits})
+		}
+	}
+
+	printReport(stdout, now, label, results)
+	return nil
+}
+
+func printReport(w io.Writer, now time.Time, label string, results []repoCommits) {
+	fmt.Fprintf(w, "Standup %s\n\n", now.Format("Mon 2006-01-02"))
+	fmt.Fprintf(w, "Done (%s):\n", label)
+	if len(results) == 0 {
+		fmt.Fprintln(w, "- (no commits)")
+	}
+	for _, repo := range results {
+		fmt.Fprintf(w, "- %s\n", repo.Name)
+		for _, c := range repo.Commits {
+			fmt.Fprintf(w, "  - %s (%s)\n", c.Subject, c.Hash)
+		}
+	}
+	fmt.Fprintln(w, "\nToday:\n-\n\nBlockers:\n-")
+}
@@ -0,0 +1,158 @@
+package standup
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLastWorkday(t *testing.T) {
+	tests := []struct {
+		now  string
+		want string
+	}{
+		{"2026-10-14 09:00", "2026-10-13"}, // Wednesday
+		{"2026-10-12 09:00", "2026-10-09"}, // Monday
+		{"2026-10-11 09:00", "2026-10-09"}, // Sunday
+		{"2026-10-10 09:00", "2026-10-09"}, // Saturday
+	}
+	for _, tt := range tests {
+		now, _ := time.ParseInLocation("2006-01-02 15:04", tt.now, time.Local)
+		if got := lastWorkday(now).Format("2006-01-02"); got != tt.want {
+			t.Errorf("lastWorkday(%s): expected %s, got %s", tt.now, tt.want, got)
+		}
+	}
+}
+
+// initRepo creates a repo with the given user, committing each message at
+// the given time.
+func initRepo(t *testing.T, dir, email string) func(message string, at time.Time, merge bool) {
+	t.Helper()
+	run := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	run(nil, "init", "-q", "-b", "main")
+	run(nil, "config", "user.email", email)
+	run(nil, "config", "user.name", "Test")
+	return func(message string, at time.Time, merge bool) {
+		date := at.Format(time.RFC3339)
+		env := []string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}
+		args := []string{"commit", "-q", "--allow-empty", "-m", message}
+		if merge {
+			run(env, "checkout", "-q", "-b", "side")
+			run(env, "commit", "-q", "--allow-empty", "-m", "side work")
+			run(env, "checkout", "-q", "main")
+			args = []string{"merge", "-q", "--no-ff", "-m", message, "side"}
+		}
+		run(env, args...)
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	now := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local) // Monday
+	friday := time.Date(2026, 10, 9, 14, 0, 0, 0, time.Local)
+
+	commitA := initRepo(t, filepath.Join(root, "alpha"), "me@example.com")
+	commitA("old work", friday.AddDate(0, 0, -2), false)
+	commitA("fix parser", friday, false)
+	commitA("merge side", friday.Add(time.Hour), true)
+
+	commitB := initRepo(t, filepath.Join(root, "beta"), "me@example.com")
+	commitB("add docs", friday.Add(2*time.Hour), false)
+
+	// Someone else's commit
+	commitC := initRepo(t, filepath.Join(root, "gamma"), "other@example.com")
+	commitC("not mine", friday, false)
+	if out, err := exec.Command("git", "-C", filepath.Join(root, "gamma"), "config", "user.email", "me@example.com").CombinedOutput(); err != nil {
+		t.Fatalf("git config failed: %v\n%s", err, out)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "not-a-repo"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := runReport(&Params{Git: true, Root: root}, now, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := stdout.String()
+	for _, want := range []string{
+		"Standup Mon 2026-10-12",
+		"Done (since Fri 2026-10-09):",
+		"- alpha\n  - side work (",
+		"  - fix parser (",
+		"- beta\n  - add docs (",
+		"Today:\n-\n\nBlockers:\n-\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"old work", "merge side", "gamma", "not mine"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("Expected no %q in:\n%s", unwanted, got)
+		}
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no warnings, got %q", stderr.String())
+	}
+
+	// --since/--until override the window, and bad repos are only warnings
+	stdout.Reset()
+	params := &Params{
+		Git:   true,
+		Repos: []string{filepath.Join(root, "alpha"), filepath.Join(root, "missing"), filepath.Join(root, "not-a-repo")},
+		Since: friday.AddDate(0, 0, -3).Format("2006-01-02"),
+		Until: friday.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+	if err := runReport(params, now, &stdout, &stderr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "old work") || strings.Contains(stdout.String(), "fix parser") {
+		t.Errorf("Expected only the old commit, got:\n%s", stdout.String())
+	}
+	if n := strings.Count(stderr.String(), "standup: warning:"); n != 2 {
+		t.Errorf("Expected 2 warnings, got %q", stderr.String())
+	}
+}
+
+func TestRunReportConfigRepos(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	if err := os.MkdirAll(filepath.Join(config, "tofu"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repos := []string{"/from/config"}
+	if err := os.WriteFile(configPath(), []byte(`{"repos": ["/from/config"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, err := findRepos(&Params{Root: t.TempDir()}, cfg)
+	if err != nil || strings.Join(got, ",") != strings.Join(repos, ",") {
+		t.Errorf("Expected %q from config, got %q, %v", repos, got, err)
+	}
+	got, err = findRepos(&Params{Repos: []string{"a", "b"}}, cfg)
+	if err != nil || strings.Join(got, ",") != "a,b" {
+		t.Errorf("Expected --repos to win, got %q, %v", got, err)
+	}
+}
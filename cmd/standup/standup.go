@@ -16,6 +16,12 @@ import (
 type Params struct {
 	Interval int  `short:"i" help:"Interval between reminders in minutes." default:"30"`
 	Quiet    bool `short:"q" help:"No bell sound." default:"false"`
+
+	Git   bool     `short:"g" help:"Print a standup report with your git commits since the last workday, instead of starting reminders." optional:"true"`
+	Repos []string `help:"Repositories to collect commits from with --git. Defaults to the repos in standup.json in the config dir, or the git repos in --root." optional:"true"`
+	Root  string   `help:"Workspace directory to scan for git repos with --git." default:"."`
+	Since string   `help:"Collect commits since this date, in any format git log accepts. Defaults to the start of the last workday." optional:"true"`
+	Until string   `help:"Collect commits until this date, in any format git log accepts." optional:"true"`
 }
 
 var reminders = []string{
@@ -51,11 +57,21 @@ var exercises = []string{
 
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
-		Use:         "standup",
-		Short:       "Periodic reminders to stand up and stretch",
-		Long:        "Reminds you to stand up and stretch at regular intervals. Your body will thank you.",
+		Use:   "standup",
+		Short: "Periodic reminders to stand up and stretch",
+		Long: `Reminds you to stand up and stretch at regular intervals. Your body will thank you.
+
+With --git, prints a report for the standup meeting instead, listing your
+commits since the last workday grouped by repository.`,
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
+			if params.Git {
+				if err := runReport(params, time.Now(), os.Stdout, os.Stderr); err != nil {
+					fmt.Fprintf(os.Stderr, "standup: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
 			Run(params)
 		},
 	}.ToCobra()
@@ -0,0 +1,260 @@
+package stopwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GiGurra/boa/pkg/boa"
+	"github.com/gigurra/tofu/cmd/common"
+	"github.com/spf13/cobra"
+)
+
+type NameParams struct {
+	Name string `pos:"true" optional:"true" help:"Name of the timer." default:"default"`
+}
+
+type ShowParams struct {
+	Name string `pos:"true" optional:"true" help:"Name of the timer." default:"default"`
+	JSON bool   `name:"json" short:"j" help:"Output as JSON, with RFC 3339 times and durations in seconds." optional:"true"`
+}
+
+type ListParams struct{}
+
+func startCmd() *cobra.Command {
+	return boa.CmdT[NameParams]{
+		Use:         "start [name]",
+		Short:       "Start a named background timer",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *NameParams, cmd *cobra.Command, args []string) {
+			if err := runStart(params, defaultStore(), time.Now(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "stopwatch: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func lapCmd() *cobra.Command {
+	return boa.CmdT[NameParams]{
+		Use:         "lap [name]",
+		Short:       "Record a lap on a named timer",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *NameParams, cmd *cobra.Command, args []string) {
+			if err := runLap(params, defaultStore(), time.Now(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "stopwatch: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func stopCmd() *cobra.Command {
+	return boa.CmdT[ShowParams]{
+		Use:         "stop [name]",
+		Short:       "Stop a named timer and show its time",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *ShowParams, cmd *cobra.Command, args []string) {
+			if err := runStop(params, defaultStore(), time.Now(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "stopwatch: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func showCmd() *cobra.Command {
+	return boa.CmdT[ShowParams]{
+		Use:         "show [name]",
+		Short:       "Show the time of a named timer",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *ShowParams, cmd *cobra.Command, args []string) {
+			if err := runShow(params, defaultStore(), time.Now(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "stopwatch: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func listCmd() *cobra.Command {
+	return boa.CmdT[ListParams]{
+		Use:         "list",
+		Short:       "List running named timers",
+		ParamEnrich: common.DefaultParamEnricher(),
+		RunFunc: func(params *ListParams, cmd *cobra.Command, args []string) {
+			if err := runList(params, defaultStore(), time.Now(), os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "stopwatch: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}.ToCobra()
+}
+
+func runStart(params *NameParams, st *store, now time.Time, out io.Writer) error {
+	timer, err := st.load(params.Name)
+	if err != nil {
+		return err
+	}
+	if timer != nil && timer.running() {
+		return fmt.Errorf("timer %q is already running since %s", params.Name, formatWallClock(timer.Start))
+	}
+	// A stopped timer of the same name is replaced
+	if err := st.save(&namedTimer{Name: params.Name, Start: now}); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Started %s at %s\n", params.Name, formatWallClock(now))
+	return nil
+}
+
+// loadRunning loads a timer that must exist and be running.
+func loadRunning(st *store, name string) (*namedTimer, error) {
+	timer, err := st.load(name)
+	if err != nil {
+		return nil, err
+	}
+	if timer == nil {
+		return nil, fmt.Errorf("no timer named %q, start one with: tofu stopwatch start %s", name, name)
+	}
+	if !timer.running() {
+		return nil, fmt.Errorf("timer %q is not running, it stopped at %s", name, formatWallClock(timer.Stopped))
+	}
+	return timer, nil
+}
+
+func runLap(params *NameParams, st *store, now time.Time, out io.Writer) error {
+	timer, err := loadRunning(st, params.Name)
+	if err != nil {
+		return err
+	}
+	timer.Laps = append(timer.Laps, now)
+	if err := st.save(timer); err != nil {
+		return err
+	}
+	totals := timer.lapTotals()
+	n := len(totals)
+	split := totals[n-1]
+	if n > 1 {
+		split -= totals[n-2]
+	}
+	fmt.Fprintf(out, "%s lap #%d: %s (total %s)\n", params.Name, n, formatDuration(split), formatDuration(totals[n-1]))
+	return nil
+}
+
+func runStop(params *ShowParams, st *store, now time.Time, out io.Writer) error {
+	timer, err := loadRunning(st, params.Name)
+	if err != nil {
+		return err
+	}
+	timer.Stopped = now
+	if err := st.save(timer); err != nil {
+		return err
+	}
+	return printTimer(out, timer, now, params.JSON)
+}
+
+func runShow(params *ShowParams, st *store, now time.Time, out io.Writer) error {
+	timer, err := st.load(params.Name)
+	if err != nil {
+		return err
+	}
+	if timer == nil {
+		return fmt.Errorf("no timer named %q", params.Name)
+	}
+	return printTimer(out, timer, now, params.JSON)
+}
+
+func runList(params *ListParams, st *store, now time.Time, out io.Writer) error {
+	timers, err := st.list()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	count := 0
+	for _, timer := range timers {
+		if !timer.running() {
+			continue
+		}
+		if count == 0 {
+			fmt.Fprintln(w, "NAME\tSTARTED\tELAPSED\tLAPS")
+		}
+		count++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", timer.Name, formatWallClock(timer.Start), formatDuration(timer.elapsed(now)), len(timer.Laps))
+	}
+	if count == 0 {
+		fmt.Fprintln(out, "No running timers")
+		return nil
+	}
+	return w.Flush()
+}
+
+type timerJSON struct {
+	Name           string    `json:"name"`
+	Running        bool      `json:"running"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end,omitzero"`
+	Elapsed        string    `json:"elapsed"`
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+	Laps           []lapJSON `json:"laps,omitempty"`
+}
+
+type lapJSON struct {
+	Lap          int       `json:"lap"`
+	At           time.Time `json:"at"`
+	SplitSeconds float64   `json:"split_seconds"`
+	TotalSeconds float64   `json:"total_seconds"`
+}
+
+func printTimer(out io.Writer, timer *namedTimer, now time.Time, asJSON bool) error {
+	elapsed := timer.elapsed(now)
+	totals := timer.lapTotals()
+
+	if asJSON {
+		result := timerJSON{
+			Name:           timer.Name,
+			Running:        timer.running(),
+			Start:          timer.Start,
+			End:            timer.Stopped,
+			Elapsed:        formatDuration(elapsed),
+			ElapsedSeconds: elapsed.Seconds(),
+		}
+		var prev time.Duration
+		for i, total := range totals {
+			result.Laps = append(result.Laps, lapJSON{
+				Lap:          i + 1,
+				At:           timer.Laps[i],
+				SplitSeconds: (total - prev).Seconds(),
+				TotalSeconds: total.Seconds(),
+			})
+			prev = total
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	status := "running"
+	if !timer.running() {
+		status = "stopped"
+	}
+	fmt.Fprintf(out, "%s: %s\n", timer.Name, status)
+	fmt.Fprintf(out, "  Start:   %s\n", formatWallClock(timer.Start))
+	if !timer.running() {
+		fmt.Fprintf(out, "  End:     %s\n", formatWallClock(timer.Stopped))
+	}
+	fmt.Fprintf(out, "  Elapsed: %s\n", formatDuration(elapsed))
+	if len(totals) > 0 {
+		fmt.Fprintln(out)
+		for _, line := range lapTable(totals, 0) {
+			fmt.Fprintln(out, line)
+		}
+	}
+	return nil
+}
+
+func formatWallClock(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
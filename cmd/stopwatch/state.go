@@ -0,0 +1,115 @@
+package stopwatch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+var timerNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// namedTimer is a background stopwatch. It only records wall-clock times, so
+// it keeps counting across shells and reboots without a running process.
+type namedTimer struct {
+	Name    string      `json:"name"`
+	Start   time.Time   `json:"start"`
+	Stopped time.Time   `json:"stopped,omitzero"`
+	Laps    []time.Time `json:"laps,omitempty"`
+}
+
+func (t *namedTimer) running() bool {
+	return t.Stopped.IsZero()
+}
+
+func (t *namedTimer) elapsed(now time.Time) time.Duration {
+	if !t.running() {
+		now = t.Stopped
+	}
+	return now.Sub(t.Start)
+}
+
+// lapTotals returns the elapsed time at each lap.
+func (t *namedTimer) lapTotals() []time.Duration {
+	totals := make([]time.Duration, len(t.Laps))
+	for i, at := range t.Laps {
+		totals[i] = at.Sub(t.Start)
+	}
+	return totals
+}
+
+// store keeps one state file per named timer.
+type store struct {
+	dir string
+}
+
+func defaultStore() *store {
+	return &store{dir: filepath.Join(common.ConfigDir(), "stopwatch")}
+}
+
+func (s *store) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+func validateName(name string) error {
+	if !timerNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid timer name %q, use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// load returns the named timer, or nil if there is none.
+func (s *store) load(name string) (*namedTimer, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	var timer namedTimer
+	if found, err := common.ReadJSONFile(s.path(name), &timer); !found {
+		return nil, err
+	}
+	return &timer, nil
+}
+
+// save replaces the timer's file atomically, as other shells may be reading
+// or saving it at the same time.
+func (s *store) save(timer *namedTimer) error {
+	if err := validateName(timer.Name); err != nil {
+		return err
+	}
+	return common.WriteJSONFile(s.path(timer.Name), timer)
+}
+
+// list returns all timers, oldest first.
+func (s *store) list() ([]*namedTimer, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var timers []*namedTimer
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() || validateName(name) != nil {
+			continue
+		}
+		timer, err := s.load(name)
+		if err != nil {
+			return nil, err
+		}
+		if timer != nil {
+			timers = append(timers, timer)
+		}
+	}
+	slices.SortFunc(timers, func(a, b *namedTimer) int {
+		return a.Start.Compare(b.Start)
+	})
+	return timers, nil
+}
@@ -17,10 +17,23 @@ type Params struct{}
 
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
-		Use:         "stopwatch",
-		Short:       "Simple stopwatch",
-		Long:        "A terminal stopwatch. Press Space to lap, Enter to pause/resume, Q to quit.",
+		Use:   "stopwatch",
+		Short: "Simple stopwatch",
+		Long: `A terminal stopwatch. Press Space or L to lap, Enter to pause/resume, Q to quit.
+
+Named timers run in the background instead, saved in the tofu config
+directory so they keep counting across shells and reboots:
+  tofu stopwatch start build
+  tofu stopwatch lap build
+  tofu stopwatch stop build`,
 		ParamEnrich: common.DefaultParamEnricher(),
+		SubCmds: []*cobra.Command{
+			startCmd(),
+			lapCmd(),
+			stopCmd(),
+			showCmd(),
+			listCmd(),
+		},
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			Run(params)
 		},
@@ -69,7 +82,7 @@ func Run(params *Params) {
 
 	fmt.Println("⏱️  STOPWATCH")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("Space: Lap | Enter: Pause/Resume | Q: Quit")
+	fmt.Println("Space/L: Lap | Enter: Pause/Resume | Q: Quit")
 	fmt.Println()
 
 	for {
@@ -80,7 +93,7 @@ func Run(params *Params) {
 			switch key {
 			case 'q', 'Q', 3: // q, Q, or Ctrl+C
 				return
-			case ' ': // Space - lap
+			case ' ', 'l', 'L': // Space or L - lap
 				if running {
 					elapsed := time.Since(startTime) - pausedDuration
 					laps = append(laps, elapsed)
@@ -105,36 +118,49 @@ func Run(params *Params) {
 				elapsed = pauseStart.Sub(startTime) - pausedDuration
 			}
 
-			// Format time
-			hours := int(elapsed.Hours())
-			minutes := int(elapsed.Minutes()) % 60
-			seconds := int(elapsed.Seconds()) % 60
-			millis := int(elapsed.Milliseconds()) % 1000
-
 			status := "▶"
 			if !running {
 				status = "⏸"
 			}
 
-			fmt.Printf("%s  %02d:%02d:%02d.%03d\033[K\n", status, hours, minutes, seconds, millis)
+			fmt.Printf("%s  %s\033[K\n", status, formatDuration(elapsed))
 
-			// Show laps
+			// Show the last 5 laps
 			if len(laps) > 0 {
-				fmt.Println("\nLaps:")
-				// Show last 5 laps
-				start := 0
-				if len(laps) > 5 {
-					start = len(laps) - 5
-				}
-				for i := start; i < len(laps); i++ {
-					lap := laps[i]
-					h := int(lap.Hours())
-					m := int(lap.Minutes()) % 60
-					s := int(lap.Seconds()) % 60
-					ms := int(lap.Milliseconds()) % 1000
-					fmt.Printf("  #%d  %02d:%02d:%02d.%03d\033[K\n", i+1, h, m, s, ms)
+				fmt.Print("\n\033[K")
+				for _, line := range lapTable(laps, 5) {
+					fmt.Printf("%s\033[K\n", line)
 				}
 			}
 		}
 	}
 }
+
+// lapTable formats laps, given as the elapsed time at each lap, as a table
+// with the split of each lap and the cumulative time. A positive last limits
+// it to that many laps.
+func lapTable(laps []time.Duration, last int) []string {
+	lines := []string{"  Lap  Split         Total"}
+	start := 0
+	if last > 0 && len(laps) > last {
+		start = len(laps) - last
+	}
+	for i := start; i < len(laps); i++ {
+		split := laps[i]
+		if i > 0 {
+			split -= laps[i-1]
+		}
+		lines = append(lines, fmt.Sprintf("  #%-3d %s  %s", i+1, formatDuration(split), formatDuration(laps[i])))
+	}
+	return lines
+}
+
+// formatDuration formats a duration as hh:mm:ss.mmm.
+func formatDuration(d time.Duration) string {
+	d = max(d, 0)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	millis := int(d.Milliseconds()) % 1000
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, millis)
+}
@@ -0,0 +1,152 @@
+package stopwatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                       "00:00:00.000",
+		1500 * time.Millisecond: "00:00:01.500",
+		time.Hour + 2*time.Minute + 3*time.Second: "01:02:03.000",
+		-time.Second: "00:00:00.000",
+	}
+	for d, want := range tests {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%v): expected %q, got %q", d, want, got)
+		}
+	}
+}
+
+func TestLapTable(t *testing.T) {
+	laps := []time.Duration{30 * time.Second, 70 * time.Second, 100 * time.Second}
+	want := []string{
+		"  Lap  Split         Total",
+		"  #2   00:00:40.000  00:01:10.000",
+		"  #3   00:00:30.000  00:01:40.000",
+	}
+	if got := lapTable(laps, 2); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	if got := lapTable(laps, 0); len(got) != 4 {
+		t.Errorf("Expected all 3 laps and a header, got %q", got)
+	}
+}
+
+func TestNamedTimer(t *testing.T) {
+	st := &store{dir: t.TempDir()}
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	var out bytes.Buffer
+
+	if err := runStart(&NameParams{Name: "build"}, st, start, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runStart(&NameParams{Name: "build"}, st, start, &out); err == nil {
+		t.Error("Expected error starting a running timer")
+	}
+
+	out.Reset()
+	if err := runLap(&NameParams{Name: "build"}, st, start.Add(30*time.Second), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := runLap(&NameParams{Name: "build"}, st, start.Add(50*time.Second), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "build lap #2: 00:00:20.000 (total 00:00:50.000)\n"; !strings.HasSuffix(out.String(), want) {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+
+	out.Reset()
+	if err := runShow(&ShowParams{Name: "build"}, st, start.Add(time.Minute), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"build: running", "Elapsed: 00:01:00.000", "#2   00:00:20.000  00:00:50.000"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	end := start.Add(90 * time.Second)
+	if err := runStop(&ShowParams{Name: "build", JSON: true}, st, end, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var result timerJSON
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", out.String(), err)
+	}
+	if result.Running || !result.Start.Equal(start) || !result.End.Equal(end) || result.ElapsedSeconds != 90 || result.Elapsed != "00:01:30.000" {
+		t.Errorf("Unexpected stop result: %+v", result)
+	}
+	if len(result.Laps) != 2 || result.Laps[1].SplitSeconds != 20 || result.Laps[1].TotalSeconds != 50 {
+		t.Errorf("Unexpected laps: %+v", result.Laps)
+	}
+
+	// A stopped timer keeps its time, and can't be stopped again
+	out.Reset()
+	if err := runShow(&ShowParams{Name: "build"}, st, end.Add(time.Hour), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "build: stopped") || !strings.Contains(out.String(), "Elapsed: 00:01:30.000") {
+		t.Errorf("Expected the stopped time, got:\n%s", out.String())
+	}
+	if err := runStop(&ShowParams{Name: "build"}, st, end, &out); err == nil {
+		t.Error("Expected error stopping a stopped timer")
+	}
+	if err := runLap(&NameParams{Name: "missing"}, st, end, &out); err == nil {
+		t.Error("Expected error for a missing timer")
+	}
+	if err := runStart(&NameParams{Name: "../escape"}, st, end, &out); err == nil {
+		t.Error("Expected error for an invalid name")
+	}
+
+	// Restarting replaces the stopped timer
+	if err := runStart(&NameParams{Name: "build"}, st, end, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	timer, err := st.load("build")
+	if err != nil || !timer.running() || len(timer.Laps) != 0 {
+		t.Errorf("Expected a fresh timer, got %+v, %v", timer, err)
+	}
+}
+
+func TestRunList(t *testing.T) {
+	st := &store{dir: t.TempDir()}
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	var out bytes.Buffer
+
+	if err := runList(&ListParams{}, st, now, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out.String() != "No running timers\n" {
+		t.Errorf("Expected no timers, got %q", out.String())
+	}
+
+	for i, name := range []string{"tests", "build", "done"} {
+		if err := runStart(&NameParams{Name: name}, st, now.Add(time.Duration(i)*time.Minute), &out); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := runStop(&ShowParams{Name: "done"}, st, now.Add(3*time.Minute), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	out.Reset()
+	if err := runList(&ListParams{}, st, now.Add(5*time.Minute), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "NAME") {
+		t.Fatalf("Expected a header and 2 running timers, got:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "tests") || !strings.Contains(lines[1], "00:05:00.000") {
+		t.Errorf("Expected tests first with 5 minutes, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "build") || !strings.Contains(lines[2], "00:04:00.000") {
+		t.Errorf("Expected build second with 4 minutes, got %q", lines[2])
+	}
+}
@@ -2,10 +2,14 @@ package tail
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/fsnotify/fsnotify"
@@ -15,8 +19,10 @@ import (
 
 type Params struct {
 	Files   []string `pos:"true" optional:"true" help:"Files to tail. If none specified, read from standard input."`
-	Lines   int      `short:"n" help:"Output the last N lines, instead of the last 10" default:"10"`
-	Follow  bool     `short:"f" help:"Output appended data as the file grows"`
+	Lines   string   `short:"n" help:"Output the last N lines, instead of the last 10; or use +N to output starting with line N" default:"10"`
+	Bytes   string   `short:"c" optional:"true" help:"Output the last N bytes (suffixes K, M, G allowed); or use +N to output starting with byte N"`
+	Follow  bool     `short:"f" help:"Output appended data as the file grows, restarting from its beginning if truncated"`
+	Retry   bool     `short:"F" help:"Like --follow, but follow the file by name: reopen it if replaced, and keep retrying if it is missing or becomes inaccessible"`
 	Quiet   bool     `short:"q" help:"Never output headers giving file names"`
 	Verbose bool     `short:"v" help:"Always output headers giving file names"`
 }
@@ -41,8 +47,10 @@ func Cmd() *cobra.Command {
 				}
 			}
 
-			if params.Lines < 0 {
-				params.Lines = 0
+			spec, err := parseTailSpec(params)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "tail: %v\n", err)
+				os.Exit(1)
 			}
 
 			// Header logic:
@@ -50,16 +58,54 @@ func Cmd() *cobra.Command {
 			// If Verbose, always print header.
 			printHeaders := (len(params.Files) > 1 && !params.Quiet) || params.Verbose
 
+			if params.Retry {
+				params.Follow = true
+			}
+
 			if params.Follow && !slices.Contains(params.Files, "-") {
-				runTailFollow(params, os.Stdout, os.Stderr, printHeaders)
+				runTailFollow(cmd.Context(), params, spec, os.Stdout, os.Stderr, printHeaders)
 			} else {
-				runTailStatic(params, os.Stdout, os.Stderr, printHeaders)
+				runTailStatic(params, spec, os.Stdout, os.Stderr, printHeaders)
 			}
 		},
 	}.ToCobra()
 }
 
-func runTailStatic(params *Params, stdout, stderr io.Writer, printHeaders bool) {
+// tailSpec describes which part of each input to output.
+type tailSpec struct {
+	count     int64
+	bytes     bool // count is in bytes rather than lines
+	fromStart bool // +N: output starting with line/byte N instead of the last N
+}
+
+// parseTailSpec parses --bytes (if given) or --lines. Counts accept size
+// suffixes like 1K or 5M, and a leading '+' counts from the start.
+func parseTailSpec(params *Params) (tailSpec, error) {
+	raw, what := params.Lines, "lines"
+	spec := tailSpec{}
+	if params.Bytes != "" {
+		raw, what = params.Bytes, "bytes"
+		spec.bytes = true
+	}
+
+	value := strings.TrimSpace(raw)
+	if rest, ok := strings.CutPrefix(value, "+"); ok {
+		spec.fromStart = true
+		value = rest
+	} else {
+		// GNU tail treats -n -N the same as -n N
+		value = strings.TrimPrefix(value, "-")
+	}
+
+	count, err := common.ParseSize(value)
+	if err != nil {
+		return tailSpec{}, fmt.Errorf("invalid number of %s: %q", what, raw)
+	}
+	spec.count = count
+	return spec, nil
+}
+
+func runTailStatic(params *Params, spec tailSpec, stdout, stderr io.Writer, printHeaders bool) {
 	for i, file := range params.Files {
 		if printHeaders {
 			if i > 0 {
@@ -69,25 +115,121 @@ func runTailStatic(params *Params, stdout, stderr io.Writer, printHeaders bool)
 		}
 
 		if file == "-" {
-			tailReader(os.Stdin, stdout, stderr, params.Lines)
+			tailInput(os.Stdin, stdout, stderr, spec)
 		} else {
 			f, err := os.Open(file)
 			if err != nil {
 				fmt.Fprintf(stderr, "tail: cannot open '%s' for reading: %v\n", file, err)
 				continue
 			}
-			tailReader(f, stdout, stderr, params.Lines)
+			tailInput(f, stdout, stderr, spec)
 			f.Close()
 		}
 	}
 }
 
+// tailInput writes the part of r selected by spec to stdout. It always
+// consumes r to EOF, which follow mode relies on.
+func tailInput(r io.Reader, stdout, stderr io.Writer, spec tailSpec) {
+	var err error
+	switch {
+	case spec.bytes && spec.fromStart:
+		if err = skipBytes(r, spec.count-1); err == nil {
+			_, err = io.Copy(stdout, r)
+		}
+	case spec.bytes:
+		err = tailBytes(r, stdout, spec.count)
+	case spec.fromStart:
+		err = skipLines(r, stdout, spec.count-1)
+	default:
+		tailReader(r, stdout, stderr, int(spec.count))
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "tail: error reading: %v\n", err)
+	}
+}
+
+// tailBytes writes the last n bytes of r. Regular files are seeked from the
+// end instead of being read in full; other inputs keep a sliding buffer.
+func tailBytes(r io.Reader, stdout io.Writer, n int64) error {
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			offset := max(info.Size()-n, 0)
+			if _, err := f.Seek(offset, io.SeekStart); err == nil {
+				_, err = io.Copy(stdout, f)
+				return err
+			}
+		}
+	}
+
+	var buf []byte
+	chunk := make([]byte, 32*1024)
+	for {
+		k, err := r.Read(chunk)
+		buf = append(buf, chunk[:k]...)
+		if excess := int64(len(buf)) - n; excess > int64(len(chunk)) {
+			buf = append(buf[:0], buf[excess:]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if excess := int64(len(buf)) - n; excess > 0 {
+		buf = buf[excess:]
+	}
+	_, err := stdout.Write(buf)
+	return err
+}
+
+// skipBytes discards the first n bytes of r, seeking when possible.
+func skipBytes(r io.Reader, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			if _, err := f.Seek(n, io.SeekCurrent); err == nil {
+				return nil
+			}
+		}
+	}
+	_, err := io.CopyN(io.Discard, r, n)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// skipLines discards the first n lines of r and copies the rest to stdout.
+func skipLines(r io.Reader, stdout io.Writer, n int64) error {
+	br := bufio.NewReader(r)
+	for skipped := int64(0); skipped < n; {
+		_, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		skipped++
+	}
+	_, err := io.Copy(stdout, br)
+	return err
+}
+
 func tailReader(r io.Reader, stdout, stderr io.Writer, n int) {
 	if n == 0 {
 		return
 	}
 
-	lines := make([]string, 0, n)
+	lines := make([]string, 0, min(n, 1024))
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
@@ -105,13 +247,19 @@ func tailReader(r io.Reader, stdout, stderr io.Writer, n int) {
 	}
 }
 
-func runTailFollow(params *Params, stdout, stderr io.Writer, printHeaders bool) {
-	type fileState struct {
-		name string
-		f    *os.File
-	}
+// followPollInterval is how often followed files are re-checked even without
+// filesystem events, as a fallback for filesystems that don't report changes.
+const followPollInterval = time.Second
+
+type followState struct {
+	name string
+	path string
+	f    *os.File
+	info os.FileInfo
+}
 
-	states := []*fileState{}
+func runTailFollow(ctx context.Context, params *Params, spec tailSpec, stdout, stderr io.Writer, printHeaders bool) {
+	states := []*followState{}
 	defer func() {
 		for _, s := range states {
 			if s.f != nil {
@@ -127,6 +275,8 @@ func runTailFollow(params *Params, stdout, stderr io.Writer, printHeaders bool)
 	}
 	defer watcher.Close()
 
+	watchedDirs := map[string]bool{}
+
 	for i, filename := range params.Files {
 		if printHeaders {
 			if i > 0 {
@@ -140,49 +290,73 @@ func runTailFollow(params *Params, stdout, stderr io.Writer, printHeaders bool)
 			continue
 		}
 
-		f, err := os.Open(filename)
+		path, err := filepath.Abs(filename)
 		if err != nil {
-			fmt.Fprintf(stderr, "tail: cannot open '%s' for reading: %v\n", filename, err)
-			continue
+			path = filepath.Clean(filename)
 		}
+		state := &followState{name: filename, path: path}
 
-		// Read last N lines
-		tailReader(f, stdout, stderr, params.Lines)
-
-		// Add to watcher
-		err = watcher.Add(filename)
+		f, err := os.Open(filename)
 		if err != nil {
-			fmt.Fprintf(stderr, "tail: error watching '%s': %v\n", filename, err)
-			f.Close()
-			continue
+			if !params.Retry {
+				fmt.Fprintf(stderr, "tail: cannot open '%s' for reading: %v\n", filename, err)
+				continue
+			}
+			fmt.Fprintf(stderr, "tail: cannot open '%s' for reading: %v; retrying\n", filename, err)
+		} else {
+			// Print the requested tail, leaving the file positioned at EOF
+			tailInput(f, stdout, stderr, spec)
+			state.f = f
+			state.info, _ = f.Stat()
+		}
+
+		// Watch the parent directory rather than the file itself, so that
+		// rotation (rename/remove + create) and late creation are noticed.
+		dir := filepath.Dir(path)
+		if !watchedDirs[dir] {
+			if err := watcher.Add(dir); err != nil {
+				fmt.Fprintf(stderr, "tail: error watching '%s': %v\n", filename, err)
+			} else {
+				watchedDirs[dir] = true
+			}
 		}
 
-		states = append(states, &fileState{name: filename, f: f})
+		states = append(states, state)
+	}
+
+	if len(states) == 0 {
+		return
 	}
 
 	lastPrintedFile := ""
+	check := func(s *followState) {
+		if printHeaders && lastPrintedFile != s.name {
+			// Only switch headers if there is something new to show
+			if !followHasNewData(s, params.Retry) {
+				return
+			}
+			fmt.Fprintf(stdout, "\n==> %s <==\n", s.name)
+			lastPrintedFile = s.name
+		}
+		followFile(s, stdout, stderr, params.Retry)
+	}
+
+	ticker := time.NewTicker(followPollInterval)
+	defer ticker.Stop()
 
 	// Watch loop
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case event, ok := <-watcher.Events:
 			if !ok {
 				return
 			}
-			if event.Op&fsnotify.Write == fsnotify.Write {
-				for _, s := range states {
-					if s.name == event.Name {
-						if printHeaders && lastPrintedFile != s.name {
-							fmt.Fprintf(stdout, "\n==> %s <==\n", s.name)
-							lastPrintedFile = s.name
-						}
-
-						// Copy from current offset to EOF
-						_, err := io.Copy(stdout, s.f)
-						if err != nil {
-							fmt.Fprintf(stderr, "tail: error reading from '%s': %v\n", s.name, err)
-						}
-					}
+			eventPath := filepath.Clean(event.Name)
+			for _, s := range states {
+				if s.path == eventPath {
+					check(s)
 				}
 			}
 		case err, ok := <-watcher.Errors:
@@ -190,6 +364,112 @@ func runTailFollow(params *Params, stdout, stderr io.Writer, printHeaders bool)
 				return
 			}
 			fmt.Fprintf(stderr, "tail: watcher error: %v\n", err)
+		case <-ticker.C:
+			for _, s := range states {
+				check(s)
+			}
+		}
+	}
+}
+
+// followHasNewData reports whether a followed file has changed in a way that
+// followFile would produce output or a notice for.
+func followHasNewData(s *followState, retry bool) bool {
+	var info os.FileInfo
+	var err error
+	if retry {
+		info, err = os.Stat(s.path)
+		if err == nil && (s.f == nil || !os.SameFile(s.info, info)) {
+			return true
+		}
+	} else {
+		// Without retry the open descriptor is followed, not the name
+		info, err = s.f.Stat()
+	}
+	if err != nil {
+		return false
+	}
+	pos, err := s.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false
+	}
+	return info.Size() != pos
+}
+
+// followFile prints whatever has been appended to a followed file since the
+// last call, rewinding to the start if the file was truncated. Without retry
+// the open descriptor is followed, like tail -f, so a file that is renamed or
+// removed keeps being read. With retry the file is followed by name: a
+// replacement is switched to once the old file is drained, and a file that
+// doesn't exist yet is picked up once it appears.
+func followFile(s *followState, stdout, stderr io.Writer, retry bool) {
+	if !retry {
+		if info, err := s.f.Stat(); err == nil {
+			rewindIfTruncated(s, info, stderr)
+		}
+		copyAppended(s, stdout, stderr)
+		return
+	}
+
+	info, statErr := os.Stat(s.path)
+
+	if s.f == nil {
+		if statErr != nil {
+			return
+		}
+		f, err := os.Open(s.path)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(stderr, "tail: '%s' has appeared; following new file\n", s.name)
+		s.f = f
+		s.info, _ = f.Stat()
+		copyAppended(s, stdout, stderr)
+		return
+	}
+
+	if statErr == nil && !os.SameFile(s.info, info) {
+		// Rotated: flush what was written to the old file before switching
+		copyAppended(s, stdout, stderr)
+		s.f.Close()
+		s.f = nil
+		f, err := os.Open(s.path)
+		if err != nil {
+			return
 		}
+		fmt.Fprintf(stderr, "tail: '%s' has been replaced; following new file\n", s.name)
+		s.f = f
+		s.info, _ = f.Stat()
+		copyAppended(s, stdout, stderr)
+		return
+	}
+
+	if statErr == nil && !rewindIfTruncated(s, info, stderr) {
+		return
+	}
+
+	// Also reached when the file was removed: keep draining the old descriptor
+	copyAppended(s, stdout, stderr)
+}
+
+// rewindIfTruncated seeks back to the start of a followed file that has
+// shrunk below the current offset. It returns false if seeking failed.
+func rewindIfTruncated(s *followState, info os.FileInfo, stderr io.Writer) bool {
+	pos, err := s.f.Seek(0, io.SeekCurrent)
+	if err != nil || info.Size() >= pos {
+		return true
+	}
+	fmt.Fprintf(stderr, "tail: %s: file truncated\n", s.name)
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		fmt.Fprintf(stderr, "tail: error seeking in '%s': %v\n", s.name, err)
+		return false
+	}
+	return true
+}
+
+func copyAppended(s *followState, stdout, stderr io.Writer) {
+	// Copy from current offset to EOF
+	if _, err := io.Copy(stdout, s.f); err != nil {
+		fmt.Fprintf(stderr, "tail: error reading from '%s': %v\n", s.name, err)
 	}
 }
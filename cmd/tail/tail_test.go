@@ -2,10 +2,13 @@ package tail
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestTailReader_Simple(t *testing.T) {
@@ -44,6 +47,15 @@ func TestTailReader_ZeroLines(t *testing.T) {
 	}
 }
 
+func mustParseSpec(t *testing.T, params *Params) tailSpec {
+	t.Helper()
+	spec, err := parseTailSpec(params)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return spec
+}
+
 func TestRunTailStatic_SingleFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	file := filepath.Join(tmpDir, "test.txt")
@@ -54,11 +66,11 @@ func TestRunTailStatic_SingleFile(t *testing.T) {
 
 	params := &Params{
 		Files: []string{file},
-		Lines: 2,
+		Lines: "2",
 	}
 
 	var stdout, stderr bytes.Buffer
-	runTailStatic(params, &stdout, &stderr, false)
+	runTailStatic(params, mustParseSpec(t, params), &stdout, &stderr, false)
 
 	expected := "C\nD\n"
 	if stdout.String() != expected {
@@ -80,12 +92,12 @@ func TestRunTailStatic_MultipleFiles(t *testing.T) {
 
 	params := &Params{
 		Files: []string{file1, file2},
-		Lines: 10,
+		Lines: "10",
 	}
 
 	var stdout, stderr bytes.Buffer
 	// printHeaders = true for multiple files usually
-	runTailStatic(params, &stdout, &stderr, true)
+	runTailStatic(params, mustParseSpec(t, params), &stdout, &stderr, true)
 
 	// Note: We expect headers
 	// implementation uses fmt.Fprintf(stdout, "==> %s <==\n", file)
@@ -116,7 +128,7 @@ func TestRunTailStatic_Quiet(t *testing.T) {
 
 	params := &Params{
 		Files: []string{file1, file2},
-		Lines: 10,
+		Lines: "10",
 		Quiet: true,
 	}
 
@@ -130,10 +142,308 @@ func TestRunTailStatic_Quiet(t *testing.T) {
 		t.Fatalf("Logic error in test setup: Quiet should force printHeaders false")
 	}
 
-	runTailStatic(params, &stdout, &stderr, printHeaders)
+	runTailStatic(params, mustParseSpec(t, params), &stdout, &stderr, printHeaders)
 
 	expected := "A\nB\n"
 	if stdout.String() != expected {
 		t.Errorf("Expected %q, got %q", expected, stdout.String())
 	}
 }
+
+// syncBuffer is a bytes.Buffer safe for concurrent use by the follow loop
+// and the test goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitForOutput(t *testing.T, buf *syncBuffer, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if strings.Contains(buf.String(), want) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %q, got %q", want, buf.String())
+}
+
+func appendToFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func startFollow(t *testing.T, params *Params) (*syncBuffer, *syncBuffer) {
+	t.Helper()
+	spec := mustParseSpec(t, params)
+	ctx, cancel := context.WithCancel(context.Background())
+	stdout, stderr := &syncBuffer{}, &syncBuffer{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runTailFollow(ctx, params, spec, stdout, stderr, false)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return stdout, stderr
+}
+
+func TestRunTailFollow_AppendTruncate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(file, []byte("old1\nold2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := startFollow(t, &Params{Files: []string{file}, Lines: "1", Follow: true})
+	waitForOutput(t, stdout, "old2\n")
+	if strings.Contains(stdout.String(), "old1") {
+		t.Errorf("expected only the last line initially, got %q", stdout.String())
+	}
+
+	appendToFile(t, file, "appended\n")
+	waitForOutput(t, stdout, "appended\n")
+
+	if err := os.WriteFile(file, []byte("fresh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForOutput(t, stdout, "fresh\n")
+	waitForOutput(t, stderr, "file truncated")
+
+}
+
+func TestRunTailFollow_RetryRotate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(file, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := startFollow(t, &Params{Files: []string{file}, Lines: "1", Follow: true, Retry: true})
+	waitForOutput(t, stdout, "old\n")
+
+	rotated := file + ".1"
+	if err := os.Rename(file, rotated); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("rotated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForOutput(t, stdout, "rotated\n")
+	waitForOutput(t, stderr, "has been replaced")
+}
+
+func TestRunTailFollow_DeletedFileKeepsDescriptor(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(file, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	stdout, stderr := startFollow(t, &Params{Files: []string{file}, Lines: "1", Follow: true})
+	waitForOutput(t, stdout, "old\n")
+
+	// Without -F, writes to the deleted file are still followed, and a new
+	// file with the same name is not picked up
+	if err := os.Remove(file); err != nil {
+		t.Skipf("Cannot remove a file that is open: %v", err)
+	}
+	if err := os.WriteFile(file, []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString("after delete\n"); err != nil {
+		t.Fatal(err)
+	}
+	waitForOutput(t, stdout, "after delete\n")
+	time.Sleep(2 * followPollInterval)
+
+	if strings.Contains(stdout.String(), "new") {
+		t.Errorf("expected the new file to be ignored, got %q", stdout.String())
+	}
+	if errOut := stderr.String(); strings.Contains(errOut, "has appeared") || strings.Contains(errOut, "has been replaced") {
+		t.Errorf("expected no reopen notice, got %q", errOut)
+	}
+}
+
+func TestRunTailFollow_RetryMissingFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "later.log")
+
+	stdout, stderr := startFollow(t, &Params{Files: []string{file}, Lines: "10", Follow: true, Retry: true})
+	waitForOutput(t, stderr, "retrying")
+
+	if err := os.WriteFile(file, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForOutput(t, stdout, "hello\n")
+	waitForOutput(t, stderr, "has appeared")
+}
+
+func TestRunTailFollow_MissingFileWithoutRetry(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "missing.log")
+
+	var stdout, stderr bytes.Buffer
+	// Returns immediately since there is nothing to follow
+	params := &Params{Files: []string{file}, Lines: "10", Follow: true}
+	runTailFollow(context.Background(), params, mustParseSpec(t, params), &stdout, &stderr, false)
+
+	if !strings.Contains(stderr.String(), "cannot open") {
+		t.Errorf("expected open error, got %q", stderr.String())
+	}
+}
+
+func TestParseTailSpec(t *testing.T) {
+	tests := []struct {
+		lines, bytes string
+		want         tailSpec
+	}{
+		{"10", "", tailSpec{count: 10}},
+		{"-5", "", tailSpec{count: 5}},
+		{"+10", "", tailSpec{count: 10, fromStart: true}},
+		{"10", "1K", tailSpec{count: 1024, bytes: true}},
+		{"10", "+5M", tailSpec{count: 5 * 1024 * 1024, bytes: true, fromStart: true}},
+	}
+	for _, tt := range tests {
+		got, err := parseTailSpec(&Params{Lines: tt.lines, Bytes: tt.bytes})
+		if err != nil {
+			t.Errorf("parseTailSpec(%q, %q): unexpected error: %v", tt.lines, tt.bytes, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTailSpec(%q, %q) = %+v, want %+v", tt.lines, tt.bytes, got, tt.want)
+		}
+	}
+
+	if _, err := parseTailSpec(&Params{Lines: "abc"}); err == nil {
+		t.Error("Expected error for invalid line count")
+	}
+}
+
+func TestTailInput_FromLine(t *testing.T) {
+	input := "Line1\nLine2\nLine3\nLine4"
+	tests := []struct {
+		count    int64
+		expected string
+	}{
+		{0, input},
+		{1, input},
+		{3, "Line3\nLine4"},
+		{10, ""},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		tailInput(strings.NewReader(input), &stdout, &stderr, tailSpec{count: tt.count, fromStart: true})
+		if stdout.String() != tt.expected {
+			t.Errorf("+%d: Expected %q, got %q", tt.count, tt.expected, stdout.String())
+		}
+	}
+}
+
+func TestTailInput_Bytes(t *testing.T) {
+	input := "0123456789"
+	tests := []struct {
+		spec     tailSpec
+		expected string
+	}{
+		{tailSpec{count: 3, bytes: true}, "789"},
+		{tailSpec{count: 100, bytes: true}, input},
+		{tailSpec{count: 0, bytes: true}, ""},
+		{tailSpec{count: 8, bytes: true, fromStart: true}, "789"},
+		{tailSpec{count: 100, bytes: true, fromStart: true}, ""},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		tailInput(strings.NewReader(input), &stdout, &stderr, tt.spec)
+		if stdout.String() != tt.expected {
+			t.Errorf("%+v: Expected %q, got %q", tt.spec, tt.expected, stdout.String())
+		}
+	}
+}
+
+func TestTailInput_BytesLargeStream(t *testing.T) {
+	// Larger than the internal read chunk, to exercise the sliding buffer
+	input := strings.Repeat("x", 100*1024) + "tail-end"
+	var stdout, stderr bytes.Buffer
+	tailInput(strings.NewReader(input), &stdout, &stderr, tailSpec{count: 8, bytes: true})
+	if stdout.String() != "tail-end" {
+		t.Errorf("Expected %q, got %q", "tail-end", stdout.String())
+	}
+}
+
+func TestRunTailStatic_BytesSeeksFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	file1 := filepath.Join(tmpDir, "1.txt")
+	file2 := filepath.Join(tmpDir, "2.txt")
+	if err := os.WriteFile(file1, []byte(strings.Repeat("a", 1<<20)+"END1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file2, []byte("xyEND2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params := &Params{Files: []string{file1, file2}, Lines: "10", Bytes: "5"}
+	var stdout, stderr bytes.Buffer
+	runTailStatic(params, mustParseSpec(t, params), &stdout, &stderr, true)
+
+	expected := "==> " + file1 + " <==\nEND1\n\n==> " + file2 + " <==\nEND2\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestRunTailStatic_FromLineMultipleFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	file1 := filepath.Join(tmpDir, "1.txt")
+	file2 := filepath.Join(tmpDir, "2.txt")
+	if err := os.WriteFile(file1, []byte("A\nB\nC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file2, []byte("D\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params := &Params{Files: []string{file1, file2}, Lines: "+2"}
+	var stdout, stderr bytes.Buffer
+	runTailStatic(params, mustParseSpec(t, params), &stdout, &stderr, true)
+
+	expected := "==> " + file1 + " <==\nB\nC\n\n==> " + file2 + " <==\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestRunTailFollow_BytesThenAppend(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(file, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _ := startFollow(t, &Params{Files: []string{file}, Lines: "10", Bytes: "4", Follow: true})
+	waitForOutput(t, stdout, "6789")
+
+	appendToFile(t, file, "more\n")
+	waitForOutput(t, stdout, "6789more\n")
+}
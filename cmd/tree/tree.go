@@ -1,10 +1,21 @@
 package tree
 
 import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/GiGurra/boa/pkg/boa"
@@ -13,15 +24,39 @@ import (
 )
 
 type Params struct {
-	Dir     string   `pos:"true" optional:"true" help:"Directory to start the tree from." default:"."`
-	Depth   int      `short:"L" help:"Descend only level directories deep." default:"-1"` // -1 means infinite depth
-	All     bool     `short:"a" help:"Do not ignore entries starting with ." default:"false"`
-	Exclude []string `help:"Exclude files matching the pattern." default:"[]"`
+	Dir       string   `pos:"true" optional:"true" help:"Directory to start the tree from." default:"."`
+	Depth     int      `short:"L" help:"Descend only level directories deep." default:"-1"` // -1 means infinite depth
+	All       bool     `short:"a" help:"Do not ignore entries starting with ." default:"false"`
+	Exclude   []string `help:"Exclude files matching the pattern." default:"[]"`
+	GitIgnore bool     `name:"gitignore" help:"Omit entries ignored by .gitignore files (root and nested)." default:"false"`
+	Size      bool     `short:"s" help:"Print the human-readable size of each entry." default:"false"`
+	Perms     bool     `short:"p" help:"Print the permissions of each entry." default:"false"`
+	Du        bool     `name:"du" help:"Print cumulative directory sizes (implies -s)." default:"false"`
+	FromStdin bool     `name:"from-stdin" help:"Render paths read from stdin (one per line) instead of reading the filesystem." default:"false"`
+	Checksum  string   `optional:"true" help:"Print a short hash of each file's contents (md5, sha1, sha256, sha512)." alts:"md5,sha1,sha256,sha512"`
+	Dedupe    bool     `help:"Mark files with the same contents as an earlier file (implies --checksum sha256)." default:"false"`
 }
 
+// checksumWidth is how many hex digits of a checksum are printed
+const checksumWidth = 12
+
 type counters struct {
 	dirs  int
 	files int
+	dups  int
+}
+
+// node is a displayed entry. Directories beyond the depth limit are not
+// expanded, but with --du their contents still count towards size.
+type node struct {
+	name     string
+	isDir    bool
+	info     fs.FileInfo // only set when sizes or permissions are shown
+	size     int64
+	checksum string // full hex digest, only set with --checksum
+	dupOf    string // path of the first file with the same checksum
+	expanded bool
+	children []*node
 }
 
 func Cmd() *cobra.Command {
@@ -39,6 +74,10 @@ func Cmd() *cobra.Command {
 }
 
 func Run(params *Params) error {
+	if params.FromStdin {
+		return runFromList(params, os.Stdin)
+	}
+
 	absDir, err := filepath.Abs(params.Dir)
 	if err != nil {
 		return fmt.Errorf("failed to resolve directory %s: %w", params.Dir, err)
@@ -55,31 +94,217 @@ func Run(params *Params) error {
 		return fmt.Errorf("not a directory: %s", absDir)
 	}
 
+	if params.Du {
+		params.Size = true
+	}
+	if params.Dedupe && params.Checksum == "" {
+		params.Checksum = "sha256"
+	}
+	if params.Checksum != "" {
+		if _, err := newHasher(params.Checksum); err != nil {
+			return err
+		}
+	}
+
+	var ignore *common.GitIgnore
+	if params.GitIgnore {
+		ignore = common.NewGitIgnore(absDir)
+	}
+
 	// Print root directory
 	fmt.Println(params.Dir)
 
+	nodes := readTree(absDir, "", 1, params, ignore)
+	if params.Dedupe {
+		h, _ := newHasher(params.Checksum)
+		markDuplicates(nodes, "", hex.EncodeToString(h.Sum(nil)), map[string]string{})
+	}
+
+	printWithSummary(nodes, params)
+	return nil
+}
+
+// printWithSummary prints the tree below the root line followed by the
+// directory and file counts.
+func printWithSummary(nodes []*node, params *Params) {
 	c := &counters{dirs: 1, files: 0}
-	printTree(absDir, "", 1, params, c)
+	printTree(nodes, "", params, c)
 
-	fmt.Printf("\n%d directories, %d files\n", c.dirs, c.files)
+	summary := fmt.Sprintf("%d directories, %d files", c.dirs, c.files)
+	if params.Du {
+		var total int64
+		for _, n := range nodes {
+			total += n.size
+		}
+		summary = fmt.Sprintf("%s used in %s", formatSize(total), summary)
+	}
+	if params.Dedupe {
+		summary += fmt.Sprintf(", %d duplicates", c.dups)
+	}
+	fmt.Printf("\n%s\n", summary)
+}
+
+// runFromList renders a list of paths, one per line, without touching the
+// filesystem. Directories are implied by paths below them or a trailing '/'.
+func runFromList(params *Params, r io.Reader) error {
+	if params.GitIgnore || params.Size || params.Perms || params.Du || params.Checksum != "" || params.Dedupe {
+		return errors.New("--gitignore, --size, --perms, --du, --checksum and --dedupe cannot be used with --from-stdin")
+	}
+
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		paths = append(paths, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	fmt.Println(params.Dir)
+	printWithSummary(buildTree(paths, params), params)
 	return nil
 }
 
-// printTree recursively prints directory contents in tree format.
-// prefix is the indentation string for the current level.
+// buildTree builds an in-memory trie of nodes from slash or OS separated
+// paths, applying the same filters as when reading the filesystem.
+func buildTree(paths []string, params *Params) []*node {
+	root := &node{isDir: true}
+	index := map[*node]map[string]*node{}
+
+	for _, p := range paths {
+		p = strings.TrimSuffix(p, "\r")
+		isDir := strings.HasSuffix(p, "/") || strings.HasSuffix(p, string(os.PathSeparator))
+		p = cleanRelPath(p)
+		if p == "" {
+			continue
+		}
+
+		segments := strings.Split(p, "/")
+		if !acceptPath(segments, isDir, params) {
+			continue
+		}
+
+		parent := root
+		for i, name := range segments {
+			if index[parent] == nil {
+				index[parent] = map[string]*node{}
+			}
+			child, ok := index[parent][name]
+			if !ok {
+				child = &node{name: name}
+				index[parent][name] = child
+				parent.children = append(parent.children, child)
+			}
+			if i < len(segments)-1 || isDir {
+				child.isDir = true
+			}
+			parent = child
+		}
+	}
+
+	sortAndExpand(root.children, 1, params)
+	return root.children
+}
+
+// cleanRelPath normalizes a listed path to a '/' separated path relative to
+// the tree root, dropping leading "./" and "/".
+func cleanRelPath(p string) string {
+	p = strings.TrimLeft(path.Clean(filepath.ToSlash(p)), "/")
+	if p == "." {
+		return ""
+	}
+	return p
+}
+
+// acceptPath applies hidden and exclude filters to every segment of a path.
+func acceptPath(segments []string, isDir bool, params *Params) bool {
+	for i, name := range segments {
+		dirPath := strings.Join(segments[:i], "/")
+		if isExcluded(name, dirPath, isDir || i < len(segments)-1, params) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortAndExpand orders entries by name like os.ReadDir and marks directories
+// within the depth limit as expanded.
+func sortAndExpand(nodes []*node, depth int, params *Params) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].name < nodes[j].name })
+	for _, n := range nodes {
+		if n.isDir {
+			n.expanded = params.Depth == -1 || depth < params.Depth
+			sortAndExpand(n.children, depth+1, params)
+		}
+	}
+}
+
+// readTree reads the filtered contents of dirPath. relDir is dirPath relative
+// to the tree root, used for .gitignore matching.
 // depth is the current depth (1-based, root children are depth 1).
-func printTree(dirPath string, prefix string, depth int, params *Params, c *counters) {
+func readTree(dirPath string, relDir string, depth int, params *Params, ignore *common.GitIgnore) []*node {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Warning: cannot read directory %s: %v\n", dirPath, err)
-		return
+		return nil
+	}
+
+	if ignore != nil {
+		if err := ignore.LoadDir(relDir); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: cannot read .gitignore in %s: %v\n", dirPath, err)
+		}
 	}
 
 	// Filter entries according to exclusion rules
-	filtered := filterEntries(entries, dirPath, params)
+	filtered := filterEntries(entries, dirPath, relDir, params, ignore)
+
+	needInfo := params.Size || params.Perms
+	nodes := make([]*node, 0, len(filtered))
+	for _, entry := range filtered {
+		n := &node{name: entry.Name(), isDir: entry.IsDir()}
+		if needInfo {
+			info, err := entry.Info()
+			if err != nil {
+				// Removed since the directory was read
+				continue
+			}
+			n.info = info
+			n.size = info.Size()
+		}
+		// Only hash files that are displayed, not those only read for --du
+		if params.Checksum != "" && entry.Type().IsRegular() && (params.Depth == -1 || depth <= params.Depth) {
+			sum, err := checksumFile(filepath.Join(dirPath, n.name), params.Checksum)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Warning: cannot hash %s: %v\n", filepath.Join(dirPath, n.name), err)
+			}
+			n.checksum = sum
+		}
+
+		if n.isDir {
+			// Recurse into subdirectory if within depth limit, or to sum up its size
+			n.expanded = params.Depth == -1 || depth < params.Depth
+			if n.expanded || params.Du {
+				n.children = readTree(filepath.Join(dirPath, n.name), path.Join(relDir, n.name), depth+1, params, ignore)
+			}
+			if params.Du {
+				// Only count contents, as directory entry sizes vary by filesystem
+				n.size = 0
+				for _, child := range n.children {
+					n.size += child.size
+				}
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
 
-	for i, entry := range filtered {
-		isLast := i == len(filtered)-1
+// printTree prints nodes in tree format.
+// prefix is the indentation string for the current level.
+func printTree(nodes []*node, prefix string, params *Params, c *counters) {
+	for i, n := range nodes {
+		isLast := i == len(nodes)-1
 
 		// Choose connector based on whether this is the last entry
 		connector := "├── "
@@ -87,13 +312,17 @@ func printTree(dirPath string, prefix string, depth int, params *Params, c *coun
 			connector = "└── "
 		}
 
-		fmt.Printf("%s%s%s\n", prefix, connector, entry.Name())
+		suffix := ""
+		if n.dupOf != "" {
+			suffix = "  (duplicate of " + n.dupOf + ")"
+			c.dups++
+		}
+		fmt.Printf("%s%s%s%s%s\n", prefix, connector, formatAttrs(n, params), n.name, suffix)
 
-		if entry.IsDir() {
+		if n.isDir {
 			c.dirs++
 
-			// Recurse into subdirectory if within depth limit
-			if params.Depth == -1 || depth < params.Depth {
+			if n.expanded {
 				// Extend prefix: use "│   " if more siblings follow, "    " if last
 				childPrefix := prefix
 				if isLast {
@@ -101,7 +330,7 @@ func printTree(dirPath string, prefix string, depth int, params *Params, c *coun
 				} else {
 					childPrefix += "│   "
 				}
-				printTree(filepath.Join(dirPath, entry.Name()), childPrefix, depth+1, params, c)
+				printTree(n.children, childPrefix, params, c)
 			}
 		} else {
 			c.files++
@@ -109,13 +338,110 @@ func printTree(dirPath string, prefix string, depth int, params *Params, c *coun
 	}
 }
 
+// formatAttrs returns the bracketed permissions/size column, if enabled.
+func formatAttrs(n *node, params *Params) string {
+	var attrs []string
+	if params.Perms {
+		attrs = append(attrs, n.info.Mode().String())
+	}
+	if params.Size {
+		attrs = append(attrs, fmt.Sprintf("%5s", formatSize(n.size)))
+	}
+	if params.Checksum != "" {
+		// Directories and unreadable files get a blank column to keep names aligned
+		attrs = append(attrs, fmt.Sprintf("%-*s", checksumWidth, n.checksum[:min(len(n.checksum), checksumWidth)]))
+	}
+	if len(attrs) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(attrs, " ") + "]  "
+}
+
+// checksumFile returns the hex digest of a file's contents, streamed
+// through the hasher.
+func checksumFile(filePath string, algo string) (string, error) {
+	h, err := newHasher(algo)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func newHasher(algo string) (hash.Hash, error) {
+	switch algo {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s (use md5, sha1, sha256 or sha512)", algo)
+	}
+}
+
+// markDuplicates points each file at the first file in display order with
+// the same checksum. Empty files are all identical and never marked.
+func markDuplicates(nodes []*node, relDir string, empty string, seen map[string]string) {
+	for _, n := range nodes {
+		relPath := path.Join(relDir, n.name)
+		if n.isDir {
+			if n.expanded {
+				markDuplicates(n.children, relPath, empty, seen)
+			}
+			continue
+		}
+		if n.checksum == "" || n.checksum == empty {
+			continue
+		}
+		if first, ok := seen[n.checksum]; ok {
+			n.dupOf = first
+		} else {
+			seen[n.checksum] = relPath
+		}
+	}
+}
+
+func formatSize(size int64) string {
+	units := []string{"", "K", "M", "G", "T", "P"}
+	value := float64(size)
+
+	for _, unit := range units {
+		if value < 1024 {
+			if unit == "" {
+				return fmt.Sprintf("%d", int(value))
+			}
+			if value < 10 {
+				return fmt.Sprintf("%.1f%s", value, unit)
+			}
+			return fmt.Sprintf("%.0f%s", value, unit)
+		}
+		value /= 1024
+	}
+	return fmt.Sprintf("%.0fE", value)
+}
+
 // filterEntries filters directory entries based on exclusion rules.
-func filterEntries(entries []fs.DirEntry, dirPath string, params *Params) []fs.DirEntry {
+func filterEntries(entries []fs.DirEntry, dirPath string, relDir string, params *Params, ignore *common.GitIgnore) []fs.DirEntry {
 	var filtered []fs.DirEntry
 	for _, entry := range entries {
-		if !isExcluded(entry.Name(), dirPath, entry.IsDir(), params) {
-			filtered = append(filtered, entry)
+		if isExcluded(entry.Name(), dirPath, entry.IsDir(), params) {
+			continue
+		}
+		if ignore != nil && ignore.Ignored(path.Join(relDir, entry.Name()), entry.IsDir()) {
+			continue
 		}
+		filtered = append(filtered, entry)
 	}
 	return filtered
 }
@@ -154,3 +154,245 @@ func TestTreeCommand(t *testing.T) {
 		t.Fatalf("Tree -L 1 output mismatch. Expected:\n%s\nGot:\n%s", expectedDepth1, string(out))
 	}
 }
+
+func captureRun(t *testing.T, params *Params) string {
+	t.Helper()
+	return captureStdout(t, func() error { return Run(params) })
+}
+
+func captureStdout(t *testing.T, run func() error) string {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	runErr := run()
+	os.Stdout = oldStdout
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	_ = r.Close()
+	if runErr != nil {
+		t.Fatalf("Run failed: %v", runErr)
+	}
+	return string(out)
+}
+
+func TestTreeGitIgnore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":              "node_modules/\n*.log\n",
+		"main.go":                 "package main",
+		"debug.log":               "log",
+		"node_modules/pkg/a.js":   "js",
+		"web/.gitignore":          "dist\n!keep.log\n",
+		"web/index.html":          "html",
+		"web/keep.log":            "log",
+		"web/dist/bundle.js":      "js",
+		"web/src/app.js":          "js",
+		"web/src/node_modules/x":  "x",
+		"web/src/dist/not-a-dir":  "dist in nested dir is ignored too",
+		"web/src/dist-notes.text": "not matched",
+	}
+	for name, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := captureRun(t, &Params{Dir: root, Depth: -1, GitIgnore: true})
+
+	expected := root + `
+├── main.go
+└── web
+    ├── index.html
+    ├── keep.log
+    └── src
+        ├── app.js
+        └── dist-notes.text
+
+3 directories, 5 files
+`
+	if out != expected {
+		t.Fatalf("Tree --gitignore output mismatch. Expected:\n%s\nGot:\n%s", expected, out)
+	}
+}
+
+func TestTreeSizesAndPermissions(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "big.bin"), make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sub", "small.txt"), []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(root, "sub", "small.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureRun(t, &Params{Dir: root, Depth: -1, Size: true, Perms: true})
+	for _, want := range []string{
+		"├── [-rw-r--r--  2.0K]  big.bin\n",
+		"    └── [-rw-------     5]  small.txt\n",
+		"2 directories, 2 files\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// --du sums directory contents, even beyond the depth limit
+	out = captureRun(t, &Params{Dir: root, Depth: 1, Du: true})
+	expected := root + `
+├── [ 2.0K]  big.bin
+└── [    5]  sub
+
+2.0K used in 2 directories, 1 files
+`
+	if out != expected {
+		t.Fatalf("Tree --du output mismatch. Expected:\n%s\nGot:\n%s", expected, out)
+	}
+}
+
+func TestTreeFromStdin(t *testing.T) {
+	input := strings.Join([]string{
+		"./src/main.go",
+		"src/util/strings.go",
+		"README.md",
+		"docs/",
+		"src/util/",
+		"src/.hidden",
+		"build/out.bin",
+		"",
+		"Makefile\r",
+		"src/main.go", // duplicates are merged
+	}, "\n")
+
+	out := captureStdout(t, func() error {
+		return runFromList(&Params{Dir: ".", Depth: -1, Exclude: []string{"build"}}, strings.NewReader(input))
+	})
+
+	expected := `.
+├── Makefile
+├── README.md
+├── docs
+└── src
+    ├── main.go
+    └── util
+        └── strings.go
+
+4 directories, 4 files
+`
+	if out != expected {
+		t.Fatalf("Tree --from-stdin output mismatch. Expected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	// Depth limit and hidden entries work as for directories on disk
+	out = captureStdout(t, func() error {
+		return runFromList(&Params{Dir: "files", Depth: 1, All: true}, strings.NewReader(input))
+	})
+	expected = `files
+├── Makefile
+├── README.md
+├── build
+├── docs
+└── src
+
+4 directories, 2 files
+`
+	if out != expected {
+		t.Fatalf("Tree --from-stdin -L 1 output mismatch. Expected:\n%s\nGot:\n%s", expected, out)
+	}
+}
+
+func TestTreeFromStdin_RejectsFilesystemFlags(t *testing.T) {
+	err := runFromList(&Params{Dir: ".", Depth: -1, Size: true}, strings.NewReader("a\n"))
+	if err == nil || !strings.Contains(err.Error(), "--from-stdin") {
+		t.Errorf("Expected --from-stdin error, got %v", err)
+	}
+}
+
+func TestTreeChecksumAndDedupe(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.txt":        "hello",
+		"b.txt":        "world",
+		"copy/a.txt":   "hello",
+		"copy/z.txt":   "hello",
+		"empty1":       "",
+		"empty2":       "",
+		"deep/x/y.txt": "world",
+	}
+	for name, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// sha256 of "hello" and "world", cut to 12 hex digits
+	const hello, world = "2cf24dba5fb0", "486ea46224d1"
+	const blank = "            "
+
+	out := captureRun(t, &Params{Dir: root, Depth: -1, Checksum: "sha256"})
+	expected := root + `
+├── [` + hello + `]  a.txt
+├── [` + world + `]  b.txt
+├── [` + blank + `]  copy
+│   ├── [` + hello + `]  a.txt
+│   └── [` + hello + `]  z.txt
+├── [` + blank + `]  deep
+│   └── [` + blank + `]  x
+│       └── [` + world + `]  y.txt
+├── [e3b0c44298fc]  empty1
+└── [e3b0c44298fc]  empty2
+
+4 directories, 7 files
+`
+	if out != expected {
+		t.Fatalf("Tree --checksum output mismatch. Expected:\n%s\nGot:\n%s", expected, out)
+	}
+
+	// --dedupe implies sha256, marks later copies and leaves empty files alone
+	out = captureRun(t, &Params{Dir: root, Depth: -1, Dedupe: true})
+	for _, want := range []string{
+		"├── [" + hello + "]  a.txt\n",
+		"│   ├── [" + hello + "]  a.txt  (duplicate of a.txt)\n",
+		"│   └── [" + hello + "]  z.txt  (duplicate of a.txt)\n",
+		"│       └── [" + world + "]  y.txt  (duplicate of b.txt)\n",
+		"├── [e3b0c44298fc]  empty1\n",
+		"└── [e3b0c44298fc]  empty2\n",
+		"\n4 directories, 7 files, 3 duplicates\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// Files beyond the depth limit are not hashed, so can't be duplicates
+	out = captureRun(t, &Params{Dir: root, Depth: 2, Dedupe: true, Du: true})
+	if strings.Contains(out, "y.txt") || !strings.HasSuffix(out, ", 2 duplicates\n") {
+		t.Errorf("Unexpected --dedupe -L 2 output:\n%s", out)
+	}
+}
+
+func TestTreeChecksumInvalid(t *testing.T) {
+	if err := Run(&Params{Dir: t.TempDir(), Depth: -1, Checksum: "crc32"}); err == nil {
+		t.Error("Expected error for unsupported checksum algorithm")
+	}
+	err := runFromList(&Params{Dir: ".", Depth: -1, Dedupe: true}, strings.NewReader("a\n"))
+	if err == nil || !strings.Contains(err.Error(), "--from-stdin") {
+		t.Errorf("Expected --from-stdin error, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultInterval is used when the other interval mode flags switch to
+// interval mode without an explicit --interval.
+const defaultInterval = 2 * time.Second
+
+// intervalMode reports whether the command should be rerun on an interval,
+// like Unix watch, instead of on file changes.
+func (p *Params) intervalMode() bool {
+	return p.Interval != 0 || p.Diff || p.OnChange != "" || p.stops()
+}
+
+// stops reports whether a condition is set for interval mode to stop on.
+func (p *Params) stops() bool {
+	return p.Until != "" || p.While != "" || p.ExitOnSuccess
+}
+
+// interruptedExitCode is returned when interval mode with a stop condition is
+// interrupted before the condition was met, as a shell does for Ctrl+C.
+const interruptedExitCode = 130
+
+// exitCode returns the exit code of a command that failed with err, or 1 if
+// it could not be run at all.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+// stopCondition is the compiled --until, --while and --exit-on-success.
+type stopCondition struct {
+	until     *regexp.Regexp
+	while     *regexp.Regexp
+	onSuccess bool
+}
+
+func newStopCondition(params *Params) (*stopCondition, error) {
+	c := &stopCondition{onSuccess: params.ExitOnSuccess}
+	var err error
+	if params.Until != "" {
+		if c.until, err = regexp.Compile(params.Until); err != nil {
+			return nil, fmt.Errorf("invalid --until regex: %w", err)
+		}
+	}
+	if params.While != "" {
+		if c.while, err = regexp.Compile(params.While); err != nil {
+			return nil, fmt.Errorf("invalid --while regex: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// met reports whether any of the conditions is met by a run's output and
+// exit code.
+func (c *stopCondition) met(out string, code int) bool {
+	return (c.until != nil && c.until.MatchString(out)) ||
+		(c.while != nil && !c.while.MatchString(out)) ||
+		(c.onSuccess && code == 0)
+}
+
+// commandRunner runs a shell command with the given standard input and
+// returns its combined output.
+type commandRunner func(ctx context.Context, command, stdin string) (string, error)
+
+func runShell(ctx context.Context, command, stdin string) (string, error) {
+	c := shellCommand(ctx, command)
+	c.Stdin = strings.NewReader(stdin)
+	out, err := c.CombinedOutput()
+	return string(out), err
+}
+
+// runInterval reruns params.Execute every interval and shows its latest
+// output. When screen is a terminal, it is cleared before each run and --diff
+// highlights changes; piped output gets no escape sequences. It runs until
+// ctx is cancelled or a stop condition is met, and returns the exit code for
+// watch to exit with: the last run's when a condition was met.
+func runInterval(ctx context.Context, params *Params, run commandRunner, screen io.Writer, terminal bool) (int, error) {
+	if params.Interval < 0 {
+		return 0, fmt.Errorf("--interval must be positive")
+	}
+	stop, err := newStopCondition(params)
+	if err != nil {
+		return 0, err
+	}
+	interrupted := 0
+	if params.stops() {
+		interrupted = interruptedExitCode
+	}
+	interval := time.Duration(params.Interval * float64(time.Second))
+	if interval == 0 {
+		interval = defaultInterval
+	}
+
+	var prev string
+	for iteration := 0; ; iteration++ {
+		out, err := run(ctx, params.Execute, "")
+		if ctx.Err() != nil {
+			return interrupted, nil
+		}
+
+		var sb strings.Builder
+		if terminal {
+			sb.WriteString("\033[H\033[2J")
+		}
+		fmt.Fprintf(&sb, "Every %s: %s    %s\n\n", interval, params.Execute, time.Now().Format("2006-01-02 15:04:05"))
+		if terminal && params.Diff && iteration > 0 {
+			sb.WriteString(highlightChanges(prev, out))
+		} else {
+			sb.WriteString(out)
+		}
+		if err != nil {
+			fmt.Fprintf(&sb, "\n(%v)\n", err)
+		}
+		_, _ = io.WriteString(screen, sb.String())
+
+		// The output is passed on to the on-change command's stdin
+		if iteration > 0 && out != prev && params.OnChange != "" {
+			hookOut, hookErr := run(ctx, params.OnChange, out)
+			fmt.Fprintf(screen, "\n--- on change: %s ---\n%s", params.OnChange, hookOut)
+			if hookErr != nil && ctx.Err() == nil {
+				fmt.Fprintf(screen, "(%v)\n", hookErr)
+			}
+		}
+		prev = out
+
+		if code := exitCode(err); stop.met(out, code) {
+			return code, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return interrupted, nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// highlightChanges shows cur with the characters that differ from prev, at
+// the same line and column, in reverse video like `watch -d`.
+func highlightChanges(prev, cur string) string {
+	prevLines := strings.Split(prev, "\n")
+	curLines := strings.Split(cur, "\n")
+
+	var sb strings.Builder
+	for i, line := range curLines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		var old []rune
+		if i < len(prevLines) {
+			old = []rune(prevLines[i])
+		}
+		inverse := false
+		for j, r := range []rune(line) {
+			changed := j >= len(old) || old[j] != r
+			if changed != inverse {
+				if changed {
+					sb.WriteString("\033[7m")
+				} else {
+					sb.WriteString("\033[0m")
+				}
+				inverse = changed
+			}
+			sb.WriteRune(r)
+		}
+		if inverse {
+			sb.WriteString("\033[0m")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,205 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// scriptedRunner returns the given outputs and exit codes for the watched
+// command in turn, cancelling the context once they run out. Other commands
+// are recorded.
+type scriptedRunner struct {
+	outputs []string
+	codes   []int // 0 if not given
+	cancel  context.CancelFunc
+	runs    int
+	hooks   []string // stdin of each other command run
+}
+
+func (s *scriptedRunner) run(ctx context.Context, command, stdin string) (string, error) {
+	if command != "watched" {
+		s.hooks = append(s.hooks, stdin)
+		return "hook ran\n", nil
+	}
+	if s.runs == len(s.outputs) {
+		s.cancel()
+		return "", ctx.Err()
+	}
+	s.runs++
+	if s.runs <= len(s.codes) && s.codes[s.runs-1] != 0 {
+		return s.outputs[s.runs-1], exitError(s.codes[s.runs-1])
+	}
+	return s.outputs[s.runs-1], nil
+}
+
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+func TestRunInterval_OnChange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := &scriptedRunner{outputs: []string{"a\n", "a\n", "b\n", "b\n", "c\n"}, cancel: cancel}
+
+	params := &Params{Execute: "watched", Interval: 0.001, OnChange: "hook"}
+	var screen bytes.Buffer
+	if _, err := runInterval(ctx, params, runner.run, &screen, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if runner.runs != 5 {
+		t.Errorf("Expected 5 runs, got %d", runner.runs)
+	}
+	// Not on the first run, and only when the output changed
+	if len(runner.hooks) != 2 || runner.hooks[0] != "b\n" || runner.hooks[1] != "c\n" {
+		t.Errorf("Expected on-change with [b c], got %q", runner.hooks)
+	}
+	if n := strings.Count(screen.String(), "--- on change: hook ---\nhook ran\n"); n != 2 {
+		t.Errorf("Expected on-change output twice, got %d times in:\n%s", n, screen.String())
+	}
+	if n := strings.Count(screen.String(), "Every 1ms: watched"); n != 5 {
+		t.Errorf("Expected 5 headers, got %d", n)
+	}
+	if strings.Contains(screen.String(), "\033[") {
+		t.Error("Expected no escape sequences without --diff or a terminal")
+	}
+}
+
+func TestRunInterval_Diff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := &scriptedRunner{outputs: []string{"count: 9\n", "count: 10\n"}, cancel: cancel}
+
+	params := &Params{Execute: "watched", Interval: 0.001, Diff: true}
+	var screen bytes.Buffer
+	if _, err := runInterval(ctx, params, runner.run, &screen, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	runs := strings.Split(screen.String(), "\033[H\033[2J")
+	if len(runs) != 3 {
+		t.Fatalf("Expected the screen cleared before each of 2 runs, got %q", screen.String())
+	}
+	if !strings.HasSuffix(runs[1], "\n\ncount: 9\n") {
+		t.Errorf("Expected the first run unhighlighted, got %q", runs[1])
+	}
+	if !strings.HasSuffix(runs[2], "\n\ncount: \033[7m10\033[0m\n") {
+		t.Errorf("Expected 10 highlighted, got %q", runs[2])
+	}
+}
+
+func TestRunInterval_DiffNotTerminal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := &scriptedRunner{outputs: []string{"count: 9\n", "count: 10\n"}, cancel: cancel}
+
+	params := &Params{Execute: "watched", Interval: 0.001, Diff: true}
+	var screen bytes.Buffer
+	if _, err := runInterval(ctx, params, runner.run, &screen, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(screen.String(), "\033[") {
+		t.Errorf("Expected no escape sequences in piped output, got %q", screen.String())
+	}
+	if !strings.HasSuffix(screen.String(), "\n\ncount: 10\n") {
+		t.Errorf("Expected the plain output, got %q", screen.String())
+	}
+}
+
+func TestRunInterval_Stop(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   Params
+		outputs  []string
+		codes    []int
+		wantRuns int
+		wantCode int
+	}{
+		{"until", Params{Until: `Ready`}, []string{"Pending", "Pending", "Ready", "Ready"}, nil, 3, 0},
+		{"until keeps exit code", Params{Until: `(?m)^done$`}, []string{"x", "done\n"}, []int{0, 3}, 2, 3},
+		{"while", Params{While: `Pending`}, []string{"Pending", "Running"}, []int{0, 4}, 2, 4},
+		{"exit on success", Params{ExitOnSuccess: true}, []string{"", "", "ok"}, []int{1, 2, 0}, 3, 0},
+		{"either condition", Params{Until: `ok`, ExitOnSuccess: true}, []string{"fail", "ok"}, []int{1, 5}, 2, 5},
+		{"interrupted", Params{Until: `never`}, []string{"a", "b"}, nil, 2, interruptedExitCode},
+		{"no condition interrupted", Params{Interval: 0.001}, []string{"a"}, []int{2}, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			runner := &scriptedRunner{outputs: tt.outputs, codes: tt.codes, cancel: cancel}
+
+			params := tt.params
+			params.Execute, params.Interval = "watched", 0.001
+			code, err := runInterval(ctx, &params, runner.run, &bytes.Buffer{}, false)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if runner.runs != tt.wantRuns {
+				t.Errorf("Expected %d runs, got %d", tt.wantRuns, runner.runs)
+			}
+			if code != tt.wantCode {
+				t.Errorf("Expected exit code %d, got %d", tt.wantCode, code)
+			}
+		})
+	}
+
+	if _, err := runInterval(context.Background(), &Params{Until: "("}, nil, &bytes.Buffer{}, false); err == nil {
+		t.Error("Expected error for invalid --until regex")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if code := exitCode(nil); code != 0 {
+		t.Errorf("Expected 0, got %d", code)
+	}
+	if code := exitCode(fmt.Errorf("wrapped: %w", exitError(7))); code != 7 {
+		t.Errorf("Expected 7, got %d", code)
+	}
+	if code := exitCode(errors.New("sh: not found")); code != 1 {
+		t.Errorf("Expected 1, got %d", code)
+	}
+}
+
+func TestHighlightChanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev, cur string
+		want      string
+	}{
+		{"unchanged", "abc\ndef", "abc\ndef", "abc\ndef"},
+		{"middle", "abcdef", "abXYef", "ab\033[7mXY\033[0mef"},
+		{"longer line", "ab", "abcd", "ab\033[7mcd\033[0m"},
+		{"new line", "ab", "ab\ncd", "ab\n\033[7mcd\033[0m"},
+		{"shorter", "abcd\nef", "ab", "ab"},
+		{"unicode", "temp: 20°C", "temp: 21°C", "temp: 2\033[7m1\033[0m°C"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightChanges(tt.prev, tt.cur); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIntervalMode(t *testing.T) {
+	if (&Params{}).intervalMode() {
+		t.Error("Expected file watching by default")
+	}
+	for _, p := range []*Params{{Interval: 1}, {Diff: true}, {OnChange: "x"}, {Until: "x"}, {While: "x"}, {ExitOnSuccess: true}} {
+		if !p.intervalMode() {
+			t.Errorf("Expected interval mode for %+v", p)
+		}
+	}
+	if _, err := runInterval(context.Background(), &Params{Interval: -1}, nil, &bytes.Buffer{}, false); err == nil {
+		t.Error("Expected error for negative interval")
+	}
+}
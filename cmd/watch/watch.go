@@ -17,6 +17,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/gigurra/tofu/cmd/common"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 type PatternType string
@@ -41,6 +42,14 @@ type Params struct {
 	MaxBackoffMillis int64       `optional:"true" help:"Maximum backoff duration in milliseconds." default:"10000"`
 	MaxRestarts      int         `optional:"true" help:"Maximum number of automatic restarts." default:"10"`
 	Dirs             []string    `pos:"true" optional:"true" help:"Directories to watch (defaults to current directory)." default:"."`
+
+	Interval float64 `short:"n" optional:"true" help:"Rerun the command every N seconds and show its output, like Unix watch, instead of watching files."`
+	Diff     bool    `short:"d" optional:"true" help:"Highlight what changed in the output since the previous run. Implies interval mode."`
+	OnChange string  `optional:"true" help:"Run this command, with the new output on stdin, whenever the output changes. Implies interval mode."`
+
+	Until         string `optional:"true" help:"Stop once the output matches this regex, exiting with the command's exit code. Implies interval mode."`
+	While         string `optional:"true" help:"Stop once the output no longer matches this regex, exiting with the command's exit code. Implies interval mode."`
+	ExitOnSuccess bool   `optional:"true" help:"Stop once the command exits with 0. Implies interval mode."`
 }
 
 type ProcessRunner interface {
@@ -69,6 +78,19 @@ func Cmd() *cobra.Command {
 		Short:       "Watch files and execute a command on change",
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
+			if params.intervalMode() {
+				ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				code, err := runInterval(ctx, params, runShell, os.Stdout, term.IsTerminal(int(os.Stdout.Fd())))
+				if err != nil {
+					_, _ = fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+					os.Exit(1)
+				}
+				if code != 0 {
+					os.Exit(code)
+				}
+				return
+			}
 			factory := NewProcessRunner(params)
 			if err := runWatch(cmd.Context(), params, factory); err != nil {
 				_, _ = fmt.Fprintf(os.Stderr, "watch: %v\n", err)
@@ -3,6 +3,7 @@
 package watch
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"syscall"
@@ -30,3 +31,14 @@ func NewProcessRunner(params *Params) func() ProcessRunner {
 		return &RealProcessRunner{cmd: c}
 	}
 }
+
+// shellCommand runs command through the shell in its own process group, so
+// that cancelling ctx also kills the processes it started.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	c := exec.CommandContext(ctx, "sh", "-c", command)
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+	return c
+}
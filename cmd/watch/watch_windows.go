@@ -3,6 +3,7 @@
 package watch
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"strconv"
@@ -35,3 +36,8 @@ func NewProcessRunner(params *Params) func() ProcessRunner {
 		return &RealProcessRunner{cmd: c}
 	}
 }
+
+// shellCommand runs command through cmd.exe.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd", "/C", command)
+}
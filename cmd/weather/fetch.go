@@ -0,0 +1,47 @@
+package weather
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+type fetcher struct {
+	client *http.Client
+	cache  *common.FileCache
+}
+
+// get returns the body of a successful GET request, from the cache if fresh.
+func (f *fetcher) get(url string) ([]byte, error) {
+	if data, ok := f.cache.Get(url); ok {
+		return data, nil
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// wttr.in uses User-Agent to detect terminal vs browser
+	req.Header.Set("User-Agent", "curl/7.68.0")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch weather: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	// A failing cache only costs another request next time
+	_ = f.cache.Put(url, data)
+	return data, nil
+}
@@ -0,0 +1,299 @@
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Forecast data comes from open-meteo.com, which needs no API key and, unlike
+// wttr.in, covers a full week.
+var (
+	geocodeURL  = "https://geocoding-api.open-meteo.com/v1/search"
+	forecastURL = "https://api.open-meteo.com/v1/forecast"
+)
+
+type place struct {
+	Name      string  `json:"name"`
+	Admin1    string  `json:"admin1"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+func (p place) String() string {
+	parts := []string{p.Name}
+	for _, s := range []string{p.Admin1, p.Country} {
+		if s != "" && s != p.Name {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+type forecastResponse struct {
+	Daily struct {
+		Time         []string  `json:"time"`
+		TempMin      []float64 `json:"temperature_2m_min"`
+		TempMax      []float64 `json:"temperature_2m_max"`
+		PrecipProb   []float64 `json:"precipitation_probability_max"`
+		WindSpeedMax []float64 `json:"wind_speed_10m_max"`
+	} `json:"daily"`
+	Hourly struct {
+		Time       []string  `json:"time"`
+		Temp       []float64 `json:"temperature_2m"`
+		PrecipProb []float64 `json:"precipitation_probability"`
+		WindSpeed  []float64 `json:"wind_speed_10m"`
+	} `json:"hourly"`
+}
+
+func runForecast(f *fetcher, location string, units unitSystem, days, hours int, asJSON bool, stdout io.Writer) error {
+	if location == "" {
+		return errors.New("a location is required for --days/--hours, pass one or save it with --set-default")
+	}
+
+	p, err := resolvePlace(f, location)
+	if err != nil {
+		return err
+	}
+
+	forecast, err := fetchForecast(f, p, units, days, hours)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(newForecastOutput(p, forecast, units))
+	}
+
+	fmt.Fprintf(stdout, "Forecast for %s\n", p)
+	if days > 0 {
+		fmt.Fprintln(stdout)
+		printDaily(stdout, forecast, units)
+	}
+	if hours > 0 {
+		fmt.Fprintln(stdout)
+		printHourly(stdout, forecast, units)
+	}
+	return nil
+}
+
+// resolvePlace turns a location into coordinates. "lat,lon" is used as is,
+// anything else is looked up by name.
+func resolvePlace(f *fetcher, location string) (place, error) {
+	if lat, lon, ok := parseCoordinates(location); ok {
+		return place{Name: location, Latitude: lat, Longitude: lon}, nil
+	}
+
+	q := url.Values{}
+	q.Set("name", location)
+	q.Set("count", "1")
+	q.Set("format", "json")
+	body, err := f.get(geocodeURL + "?" + q.Encode())
+	if err != nil {
+		return place{}, err
+	}
+
+	var resp struct {
+		Results []place `json:"results"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return place{}, fmt.Errorf("failed to parse geocoding response: %w", err)
+	}
+	if len(resp.Results) == 0 {
+		return place{}, fmt.Errorf("location not found: %s", location)
+	}
+	return resp.Results[0], nil
+}
+
+func parseCoordinates(s string) (float64, float64, bool) {
+	latStr, lonStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, false
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+func fetchForecast(f *fetcher, p place, units unitSystem, days, hours int) (*forecastResponse, error) {
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(p.Latitude, 'f', 4, 64))
+	q.Set("longitude", strconv.FormatFloat(p.Longitude, 'f', 4, 64))
+	q.Set("timezone", "auto")
+	if days > 0 {
+		q.Set("daily", "temperature_2m_min,temperature_2m_max,precipitation_probability_max,wind_speed_10m_max")
+		q.Set("forecast_days", strconv.Itoa(days))
+	}
+	if hours > 0 {
+		q.Set("hourly", "temperature_2m,precipitation_probability,wind_speed_10m")
+		q.Set("forecast_hours", strconv.Itoa(hours))
+	}
+	if units == imperial {
+		q.Set("temperature_unit", "fahrenheit")
+		q.Set("wind_speed_unit", "mph")
+	}
+
+	body, err := f.get(forecastURL + "?" + q.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var resp forecastResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse forecast response: %w", err)
+	}
+	return &resp, nil
+}
+
+// forecastOutput is the --json form of a forecast, with one object per day
+// or hour rather than open-meteo's column arrays. Values missing from the
+// end of a shorter series are null.
+type forecastOutput struct {
+	Location struct {
+		Name      string  `json:"name"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"location"`
+	Units struct {
+		Temperature string `json:"temperature"`
+		WindSpeed   string `json:"wind_speed"`
+	} `json:"units"`
+	Daily  []dailyForecast  `json:"daily,omitempty"`
+	Hourly []hourlyForecast `json:"hourly,omitempty"`
+}
+
+type dailyForecast struct {
+	Date                     string   `json:"date"`
+	TempMin                  *float64 `json:"temp_min"`
+	TempMax                  *float64 `json:"temp_max"`
+	PrecipitationProbability *float64 `json:"precipitation_probability"`
+	WindSpeedMax             *float64 `json:"wind_speed_max"`
+}
+
+type hourlyForecast struct {
+	Time                     string   `json:"time"`
+	Temp                     *float64 `json:"temp"`
+	PrecipitationProbability *float64 `json:"precipitation_probability"`
+	WindSpeed                *float64 `json:"wind_speed"`
+}
+
+func newForecastOutput(p place, fc *forecastResponse, units unitSystem) forecastOutput {
+	var out forecastOutput
+	out.Location.Name = p.String()
+	out.Location.Latitude = p.Latitude
+	out.Location.Longitude = p.Longitude
+	out.Units.Temperature = units.temperature()
+	out.Units.WindSpeed = units.windSpeed()
+
+	d := fc.Daily
+	for i, day := range d.Time {
+		out.Daily = append(out.Daily, dailyForecast{
+			Date:                     day,
+			TempMin:                  value(d.TempMin, i),
+			TempMax:                  value(d.TempMax, i),
+			PrecipitationProbability: value(d.PrecipProb, i),
+			WindSpeedMax:             value(d.WindSpeedMax, i),
+		})
+	}
+	h := fc.Hourly
+	for i, hour := range h.Time {
+		out.Hourly = append(out.Hourly, hourlyForecast{
+			Time:                     hour,
+			Temp:                     value(h.Temp, i),
+			PrecipitationProbability: value(h.PrecipProb, i),
+			WindSpeed:                value(h.WindSpeed, i),
+		})
+	}
+	return out
+}
+
+// value is values[i], or nil when the series is shorter, like cell.
+func value(values []float64, i int) *float64 {
+	if i >= len(values) {
+		return nil
+	}
+	return &values[i]
+}
+
+func printDaily(w io.Writer, fc *forecastResponse, units unitSystem) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tMIN\tMAX\tPRECIP\tWIND")
+	d := fc.Daily
+	for i, day := range d.Time {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			formatTime(day, "2006-01-02", "Mon 02 Jan"),
+			cell(d.TempMin, i, units.formatTemp),
+			cell(d.TempMax, i, units.formatTemp),
+			cell(d.PrecipProb, i, formatPercent),
+			cell(d.WindSpeedMax, i, units.formatWind))
+	}
+	_ = tw.Flush()
+}
+
+func printHourly(w io.Writer, fc *forecastResponse, units unitSystem) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tTEMP\tPRECIP\tWIND")
+	h := fc.Hourly
+	for i, hour := range h.Time {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			formatTime(hour, "2006-01-02T15:04", "Mon 15:04"),
+			cell(h.Temp, i, units.formatTemp),
+			cell(h.PrecipProb, i, formatPercent),
+			cell(h.WindSpeed, i, units.formatWind))
+	}
+	_ = tw.Flush()
+}
+
+// cell formats values[i], guarding against the API returning shorter series
+// than time stamps.
+func cell(values []float64, i int, format func(float64) string) string {
+	if i >= len(values) {
+		return "-"
+	}
+	return format(values[i])
+}
+
+func formatTime(s, layout, out string) string {
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return s
+	}
+	return t.Format(out)
+}
+
+// round avoids printing "-0" for small negative values.
+func round(v float64) float64 {
+	v = math.Round(v)
+	if v == 0 {
+		return 0
+	}
+	return v
+}
+
+func (u unitSystem) formatTemp(v float64) string {
+	return fmt.Sprintf("%.0f%s", round(v), u.temperature())
+}
+
+func (u unitSystem) formatWind(v float64) string {
+	return fmt.Sprintf("%.0f %s", round(v), u.windSpeed())
+}
+
+func formatPercent(v float64) string {
+	return fmt.Sprintf("%.0f%%", round(v))
+}
@@ -1,11 +1,16 @@
 package weather
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/GiGurra/boa/pkg/boa"
@@ -13,20 +18,37 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	maxForecastDays  = 7
+	maxForecastHours = maxForecastDays * 24
+	cacheTTL         = 10 * time.Minute
+)
+
 type Params struct {
-	Location string `pos:"true" optional:"true" help:"Location (city name, airport code, or coordinates)." default:""`
-	Format   string `short:"f" help:"Format: full, short, oneline." default:"short"`
-	Units    string `short:"u" help:"Units: metric (m), imperial (u)." default:"m"`
+	Location   string `pos:"true" optional:"true" help:"Location (city name, airport code, or coordinates). Defaults to the location saved with --set-default." default:""`
+	Format     string `short:"f" help:"Format: full, short, oneline." default:"short"`
+	Units      string `short:"u" help:"Units: metric (m), imperial (u)." default:"metric" alts:"metric,imperial" strict:"false"`
+	Days       int    `short:"d" help:"Show a daily forecast table for the next N days (max 7)." default:"0"`
+	Hours      int    `short:"H" help:"Show an hourly forecast table for the next N hours (max 168)." default:"0"`
+	SetDefault string `optional:"true" help:"Save a default location, used when no location is given."`
+	JSON       bool   `name:"json" help:"Print the weather as JSON for scripting, instead of text." default:"false"`
+}
+
+// wttrURL is the wttr.in endpoint, replaced in tests
+var wttrURL = "https://wttr.in/"
+
+type weatherConfig struct {
+	DefaultLocation string `json:"default_location,omitempty"`
 }
 
 func Cmd() *cobra.Command {
 	return boa.CmdT[Params]{
 		Use:         "weather",
 		Short:       "Display ASCII weather",
-		Long:        "Fetch and display weather using wttr.in. Shows ASCII art weather for any location.",
+		Long:        "Fetch and display weather using wttr.in. Shows ASCII art weather for any location.\n\nWith --days or --hours, a forecast table from open-meteo.com is shown instead. Responses are cached for 10 minutes.",
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
-			if err := Run(params); err != nil {
+			if err := Run(params, os.Stdout); err != nil {
 				fmt.Fprintf(os.Stderr, "weather: %v\n", err)
 				os.Exit(1)
 			}
@@ -34,59 +56,147 @@ func Cmd() *cobra.Command {
 	}.ToCobra()
 }
 
-func Run(params *Params) error {
-	// Build wttr.in URL
-	baseURL := "https://wttr.in/"
+func Run(params *Params, stdout io.Writer) error {
+	units, err := parseUnits(params.Units)
+	if err != nil {
+		return err
+	}
+	if params.Days < 0 || params.Days > maxForecastDays {
+		return fmt.Errorf("--days must be between 1 and %d", maxForecastDays)
+	}
+	if params.Hours < 0 || params.Hours > maxForecastHours {
+		return fmt.Errorf("--hours must be between 1 and %d", maxForecastHours)
+	}
+
+	configPath := filepath.Join(common.ConfigDir(), "weather.json")
+	if params.SetDefault != "" {
+		if err := saveConfig(configPath, weatherConfig{DefaultLocation: params.SetDefault}); err != nil {
+			return fmt.Errorf("failed to save default location: %w", err)
+		}
+		fmt.Fprintf(stdout, "Default location set to %s\n", params.SetDefault)
+		return nil
+	}
 
 	location := params.Location
+	if location == "" {
+		cfg, err := loadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		location = cfg.DefaultLocation
+	}
+
+	f := &fetcher{
+		client: &http.Client{Timeout: 10 * time.Second},
+		cache:  &common.FileCache{Dir: filepath.Join(common.CacheDir(), "weather"), TTL: cacheTTL},
+	}
+
+	if params.Days > 0 || params.Hours > 0 {
+		return runForecast(f, location, units, params.Days, params.Hours, params.JSON, stdout)
+	}
+	format := params.Format
+	if params.JSON {
+		format = "json"
+	}
+	return runWttr(f, location, format, units, stdout)
+}
+
+func runWttr(f *fetcher, location, format string, units unitSystem, stdout io.Writer) error {
 	if location != "" {
 		location = url.PathEscape(location)
 	}
 
 	// Build format string
-	var format string
-	switch params.Format {
+	var query string
+	switch format {
 	case "full":
-		format = ""
+		query = ""
 	case "oneline":
-		format = "?format=3"
+		query = "?format=3"
+	case "json":
+		// wttr.in's own JSON, with values in both unit systems
+		query = "?format=j1"
 	default: // short
-		format = "?0"
+		query = "?0"
 	}
 
 	// Add units
-	if format == "" {
-		format = "?" + params.Units
-	} else if format == "?0" {
-		format += "&" + params.Units
+	if query == "" {
+		query = "?" + units.wttrFlag()
+	} else if query == "?0" {
+		query += "&" + units.wttrFlag()
 	}
 
-	reqURL := baseURL + location + format
+	body, err := f.get(wttrURL + location + query)
+	if err != nil {
+		return err
+	}
+	if format == "json" && !bytes.HasSuffix(body, []byte("\n")) {
+		body = append(body, '\n')
+	}
+	_, err = stdout.Write(body)
+	return err
+}
+
+type unitSystem int
+
+const (
+	metric unitSystem = iota
+	imperial
+)
 
-	// Make request
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+func parseUnits(s string) (unitSystem, error) {
+	switch strings.ToLower(s) {
+	case "metric", "m":
+		return metric, nil
+	case "imperial", "u":
+		return imperial, nil
+	default:
+		return metric, fmt.Errorf("invalid units %q, expected metric or imperial", s)
 	}
+}
 
-	req, err := http.NewRequest("GET", reqURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+func (u unitSystem) wttrFlag() string {
+	if u == imperial {
+		return "u"
+	}
+	return "m"
+}
+
+func (u unitSystem) temperature() string {
+	if u == imperial {
+		return "°F"
 	}
+	return "°C"
+}
 
-	// wttr.in uses User-Agent to detect terminal vs browser
-	req.Header.Set("User-Agent", "curl/7.68.0")
+func (u unitSystem) windSpeed() string {
+	if u == imperial {
+		return "mph"
+	}
+	return "km/h"
+}
 
-	resp, err := client.Do(req)
+func loadConfig(path string) (weatherConfig, error) {
+	var cfg weatherConfig
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to fetch weather: %w", err)
+		return cfg, err
 	}
-	defer resp.Body.Close()
+	err = json.Unmarshal(data, &cfg)
+	return cfg, err
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("wttr.in returned status %d", resp.StatusCode)
+func saveConfig(path string, cfg weatherConfig) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
 	}
-
-	// Stream output
-	_, err = io.Copy(os.Stdout, resp.Body)
-	return err
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
 }
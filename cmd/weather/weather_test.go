@@ -0,0 +1,332 @@
+package weather
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gigurra/tofu/cmd/common"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected unitSystem
+	}{
+		{"metric", metric},
+		{"m", metric},
+		{"imperial", imperial},
+		{"U", imperial},
+	}
+	for _, tt := range tests {
+		got, err := parseUnits(tt.input)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("parseUnits(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+
+	if _, err := parseUnits("kelvin"); err == nil {
+		t.Error("Expected error for unknown units")
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	if lat, lon, ok := parseCoordinates("57.7, 11.97"); !ok || lat != 57.7 || lon != 11.97 {
+		t.Errorf("Expected 57.7,11.97, got %v,%v (ok=%v)", lat, lon, ok)
+	}
+	for _, s := range []string{"Gothenburg", "Washington, DC", "91,0"} {
+		if _, _, ok := parseCoordinates(s); ok {
+			t.Errorf("Expected %q not to parse as coordinates", s)
+		}
+	}
+}
+
+func TestConfig_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "weather.json")
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.DefaultLocation != "" {
+		t.Errorf("Expected empty config, got %+v", cfg)
+	}
+
+	if err := saveConfig(path, weatherConfig{DefaultLocation: "Gothenburg"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.DefaultLocation != "Gothenburg" {
+		t.Errorf("Expected %q, got %q", "Gothenburg", cfg.DefaultLocation)
+	}
+}
+
+func TestFetcher_Cache(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		fmt.Fprintf(w, "response %d", n)
+	}))
+	defer srv.Close()
+
+	cache := &common.FileCache{Dir: t.TempDir(), TTL: time.Minute}
+	f := &fetcher{client: srv.Client(), cache: cache}
+
+	for range 3 {
+		body, err := f.get(srv.URL + "/a")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(body) != "response 1" {
+			t.Errorf("Expected cached %q, got %q", "response 1", body)
+		}
+	}
+
+	// Different URLs are cached separately
+	if body, _ := f.get(srv.URL + "/b"); string(body) != "response 2" {
+		t.Errorf("Expected %q, got %q", "response 2", body)
+	}
+
+	// Expired entries are fetched again
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(cache.Path(srv.URL+"/a"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := f.get(srv.URL + "/a"); string(body) != "response 3" {
+		t.Errorf("Expected refetched %q, got %q", "response 3", body)
+	}
+}
+
+func TestFetcher_ErrorsAreNotCached(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	f := &fetcher{client: srv.Client(), cache: &common.FileCache{Dir: t.TempDir(), TTL: time.Minute}}
+	for range 2 {
+		if _, err := f.get(srv.URL); err == nil || !strings.Contains(err.Error(), "status 503") {
+			t.Errorf("Expected status error, got %v", err)
+		}
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests.Load())
+	}
+}
+
+// fakeOpenMeteo serves canned geocoding and forecast responses and records
+// the forecast query.
+func fakeOpenMeteo(t *testing.T, forecastQuery *string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			if r.URL.Query().Get("name") != "Gothenburg" {
+				fmt.Fprint(w, `{}`)
+				return
+			}
+			fmt.Fprint(w, `{"results":[{"name":"Gothenburg","admin1":"Västra Götaland","country":"Sweden","latitude":57.70716,"longitude":11.96679}]}`)
+		case "/forecast":
+			*forecastQuery = r.URL.RawQuery
+			fmt.Fprint(w, `{
+				"daily": {
+					"time": ["2026-10-16", "2026-10-17"],
+					"temperature_2m_min": [4.2, -0.4],
+					"temperature_2m_max": [11.6, 8.1],
+					"precipitation_probability_max": [40, 5],
+					"wind_speed_10m_max": [18.3, 22.9]
+				},
+				"hourly": {
+					"time": ["2026-10-16T14:00", "2026-10-16T15:00"],
+					"temperature_2m": [10.4, 9.8],
+					"precipitation_probability": [20, null],
+					"wind_speed_10m": [15.1]
+				}
+			}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	oldGeocode, oldForecast := geocodeURL, forecastURL
+	geocodeURL, forecastURL = srv.URL+"/search", srv.URL+"/forecast"
+	t.Cleanup(func() { geocodeURL, forecastURL = oldGeocode, oldForecast })
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+}
+
+func TestRun_Forecast(t *testing.T) {
+	var query string
+	fakeOpenMeteo(t, &query)
+
+	var stdout bytes.Buffer
+	err := Run(&Params{Location: "Gothenburg", Units: "metric", Days: 2, Hours: 2}, &stdout)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `Forecast for Gothenburg, Västra Götaland, Sweden
+
+DATE        MIN  MAX   PRECIP  WIND
+Fri 16 Oct  4°C  12°C  40%     18 km/h
+Sat 17 Oct  0°C  8°C   5%      23 km/h
+
+TIME       TEMP  PRECIP  WIND
+Fri 14:00  10°C  20%     15 km/h
+Fri 15:00  10°C  0%      -
+`
+	if stdout.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, stdout.String())
+	}
+	for _, want := range []string{"forecast_days=2", "forecast_hours=2", "latitude=57.7072"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("Expected forecast query to contain %q, got %q", want, query)
+		}
+	}
+	if strings.Contains(query, "fahrenheit") {
+		t.Errorf("Expected metric query, got %q", query)
+	}
+}
+
+func TestRun_ForecastImperial(t *testing.T) {
+	var query string
+	fakeOpenMeteo(t, &query)
+
+	var stdout bytes.Buffer
+	err := Run(&Params{Location: "57.7,11.97", Units: "imperial", Days: 1}, &stdout)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(query, "temperature_unit=fahrenheit") || !strings.Contains(query, "wind_speed_unit=mph") {
+		t.Errorf("Expected imperial units in query, got %q", query)
+	}
+	if !strings.Contains(stdout.String(), "12°F") || !strings.Contains(stdout.String(), "18 mph") {
+		t.Errorf("Expected imperial units in output, got:\n%s", stdout.String())
+	}
+	if strings.Contains(stdout.String(), "TIME") {
+		t.Errorf("Expected no hourly table without --hours, got:\n%s", stdout.String())
+	}
+}
+
+func TestRun_ForecastJSON(t *testing.T) {
+	var query string
+	fakeOpenMeteo(t, &query)
+
+	var stdout bytes.Buffer
+	err := Run(&Params{Location: "Gothenburg", Units: "metric", Days: 2, Hours: 2, JSON: true}, &stdout)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var out forecastOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, stdout.String())
+	}
+	if out.Location.Name != "Gothenburg, Västra Götaland, Sweden" || out.Location.Latitude != 57.70716 {
+		t.Errorf("Unexpected location: %+v", out.Location)
+	}
+	if out.Units.Temperature != "°C" || out.Units.WindSpeed != "km/h" {
+		t.Errorf("Unexpected units: %+v", out.Units)
+	}
+	if len(out.Daily) != 2 || out.Daily[1].Date != "2026-10-17" || *out.Daily[1].TempMin != -0.4 || *out.Daily[1].WindSpeedMax != 22.9 {
+		t.Errorf("Unexpected daily forecast: %s", stdout.String())
+	}
+	if len(out.Hourly) != 2 || *out.Hourly[0].Temp != 10.4 || out.Hourly[1].WindSpeed != nil {
+		t.Errorf("Unexpected hourly forecast: %s", stdout.String())
+	}
+	// Raw values are kept, not rounded like in the tables
+	if !strings.Contains(stdout.String(), `"temp_max": 11.6`) {
+		t.Errorf("Expected unrounded values, got:\n%s", stdout.String())
+	}
+}
+
+func TestRun_CurrentJSON(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Path + "?" + r.URL.RawQuery
+		fmt.Fprint(w, `{"current_condition":[{"temp_C":"12"}]}`)
+	}))
+	defer srv.Close()
+	oldWttr := wttrURL
+	wttrURL = srv.URL + "/"
+	defer func() { wttrURL = oldWttr }()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var stdout bytes.Buffer
+	if err := Run(&Params{Location: "New York", Format: "short", Units: "metric", JSON: true}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gotQuery != "/New York?format=j1" {
+		t.Errorf("Expected j1 format query, got %q", gotQuery)
+	}
+	if stdout.String() != `{"current_condition":[{"temp_C":"12"}]}`+"\n" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+}
+
+func TestRun_SetDefault(t *testing.T) {
+	var query string
+	fakeOpenMeteo(t, &query)
+
+	var stdout bytes.Buffer
+	if err := Run(&Params{Units: "metric", SetDefault: "Gothenburg"}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stdout.String() != "Default location set to Gothenburg\n" {
+		t.Errorf("Unexpected output: %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := Run(&Params{Units: "metric", Days: 1}, &stdout); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "Forecast for Gothenburg") {
+		t.Errorf("Expected forecast for saved location, got:\n%s", stdout.String())
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	var query string
+	fakeOpenMeteo(t, &query)
+
+	tests := []struct {
+		name    string
+		params  Params
+		wantErr string
+	}{
+		{"too many days", Params{Location: "Gothenburg", Units: "metric", Days: 8}, "--days"},
+		{"too many hours", Params{Location: "Gothenburg", Units: "metric", Hours: 169}, "--hours"},
+		{"no location", Params{Units: "metric", Days: 1}, "location is required"},
+		{"unknown location", Params{Location: "Nowhere", Units: "metric", Days: 1}, "location not found"},
+		{"bad units", Params{Location: "Gothenburg", Units: "kelvin"}, "invalid units"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := Run(&tt.params, &stdout)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
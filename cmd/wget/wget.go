@@ -2,6 +2,7 @@ package wget
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,19 +16,23 @@ import (
 	"github.com/GiGurra/boa/pkg/boa"
 	"github.com/gigurra/tofu/cmd/common"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 type Params struct {
 	URL        string   `pos:"true" help:"URL to download"`
 	Output     string   `short:"O" optional:"true" help:"Write output to file (use '-' for stdout)"`
 	Continue   bool     `short:"c" optional:"true" help:"Resume a partially downloaded file"`
-	Quiet      bool     `short:"q" optional:"true" help:"Quiet mode - no progress output"`
+	Quiet      bool     `short:"q" optional:"true" help:"Quiet mode - no progress or status output, for scripts"`
 	NoProgress bool     `optional:"true" help:"Disable progress bar but show other output"`
 	Insecure   bool     `short:"k" optional:"true" help:"Allow insecure server connections when using SSL"`
 	Timeout    int      `short:"T" optional:"true" help:"Set timeout in seconds" default:"30"`
 	Retries    int      `short:"t" optional:"true" help:"Set number of retries (0 for infinite)" default:"3"`
 	UserAgent  string   `short:"U" optional:"true" help:"Set User-Agent header"`
 	Headers    []string `short:"H" optional:"true" help:"Add custom header(s)"`
+	MaxRedirs  int      `optional:"true" help:"Maximum number of redirects to follow" default:"10"`
+	NoFollow   bool     `optional:"true" help:"Don't follow redirects, fail on a 3xx response instead"`
+	PrintFinal bool     `optional:"true" help:"Print the final URL after redirects (on stdout, or stderr when downloading to stdout)"`
 }
 
 func Cmd() *cobra.Command {
@@ -38,15 +43,17 @@ func Cmd() *cobra.Command {
 
 Features:
   - Auto-detect output filename from URL
-  - Progress bar showing download status
+  - Progress bar with rate and ETA when the size is known and stderr is a
+    terminal, periodic progress lines otherwise
   - Resume partially downloaded files with -c
-  - Follow redirects automatically
+  - Follow redirects, up to --max-redirs (default 10)
 
 Examples:
   tofu wget https://example.com/file.zip
   tofu wget -O output.zip https://example.com/file.zip
   tofu wget -c https://example.com/large-file.iso
-  tofu wget -q https://example.com/file.txt`,
+  tofu wget -q https://example.com/file.txt
+  tofu wget --print-final -O latest.tar.gz https://example.com/download/latest`,
 		ParamEnrich: common.DefaultParamEnricher(),
 		RunFunc: func(params *Params, cmd *cobra.Command, args []string) {
 			if params.URL == "" {
@@ -59,7 +66,7 @@ Examples:
 				params.URL = "https://" + params.URL
 			}
 
-			if err := runWget(params); err != nil {
+			if err := runWget(params, os.Stdout); err != nil {
 				fmt.Fprintf(os.Stderr, "wget: %v\n", err)
 				os.Exit(1)
 			}
@@ -67,7 +74,12 @@ Examples:
 	}.ToCobra()
 }
 
-func runWget(params *Params) error {
+var (
+	errTooManyRedirects    = errors.New("too many redirects")
+	errRedirectNotFollowed = errors.New("redirect not followed")
+)
+
+func runWget(params *Params, stdout io.Writer) error {
 	// Determine output filename
 	outputFile := params.Output
 	if outputFile == "" {
@@ -91,8 +103,14 @@ func runWget(params *Params) error {
 	client := &http.Client{
 		Timeout: time.Duration(params.Timeout) * time.Second,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 10 {
-				return fmt.Errorf("too many redirects")
+			if params.NoFollow {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > params.MaxRedirs {
+				return fmt.Errorf("%w (limit is %d, see --max-redirs)", errTooManyRedirects, params.MaxRedirs)
+			}
+			if !params.Quiet {
+				fmt.Fprintf(os.Stderr, "Redirected to: %s\n", req.URL)
 			}
 			return nil
 		},
@@ -119,14 +137,15 @@ func runWget(params *Params) error {
 			time.Sleep(time.Second * time.Duration(attempt)) // Exponential backoff
 		}
 
-		err := downloadFile(client, params, outputFile, existingSize, writeToStdout)
+		err := downloadFile(client, params, outputFile, existingSize, writeToStdout, stdout)
 		if err == nil {
 			return nil
 		}
 		lastErr = err
 
 		// Don't retry on certain errors
-		if strings.Contains(err.Error(), "404") ||
+		if errors.Is(err, errTooManyRedirects) || errors.Is(err, errRedirectNotFollowed) ||
+			strings.Contains(err.Error(), "404") ||
 			strings.Contains(err.Error(), "403") ||
 			strings.Contains(err.Error(), "401") {
 			return err
@@ -136,7 +155,7 @@ func runWget(params *Params) error {
 	return fmt.Errorf("failed after %d attempts: %w", params.Retries, lastErr)
 }
 
-func downloadFile(client *http.Client, params *Params, outputFile string, existingSize int64, writeToStdout bool) error {
+func downloadFile(client *http.Client, params *Params, outputFile string, existingSize int64, writeToStdout bool, stdout io.Writer) error {
 	req, err := http.NewRequest("GET", params.URL, nil)
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
@@ -172,35 +191,58 @@ func downloadFile(client *http.Client, params *Params, outputFile string, existi
 	}
 	defer resp.Body.Close()
 
+	if !params.Quiet {
+		fmt.Fprintf(os.Stderr, "HTTP response: %s\n", resp.Status)
+	}
+
 	// Check status code
 	if resp.StatusCode >= 400 {
 		return fmt.Errorf("server returned %s", resp.Status)
 	}
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		// Only reached with --no-follow
+		return fmt.Errorf("%w: server returned %s, location: %s", errRedirectNotFollowed, resp.Status, resp.Header.Get("Location"))
+	}
+
+	// Report where redirects ended up, once the download succeeded
+	printFinal := func() {
+		if !params.PrintFinal {
+			return
+		}
+		w := stdout
+		if writeToStdout {
+			w = os.Stderr
+		}
+		fmt.Fprintln(w, resp.Request.URL)
+	}
 
 	// Handle resume response
 	var totalSize int64
 	resuming := false
 	if resp.StatusCode == http.StatusPartialContent {
 		resuming = true
-		totalSize = existingSize + resp.ContentLength
+		if resp.ContentLength >= 0 {
+			totalSize = existingSize + resp.ContentLength
+		}
 		if !params.Quiet {
 			fmt.Fprintf(os.Stderr, "Resuming from byte %d\n", existingSize)
 		}
 	} else if resp.StatusCode == http.StatusOK {
-		totalSize = resp.ContentLength
-		existingSize = 0 // Server doesn't support resume, start fresh
+		totalSize = max(resp.ContentLength, 0) // -1 if unknown
+		existingSize = 0                       // Server doesn't support resume, start fresh
 	} else if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
 		// File already complete
 		if !params.Quiet {
 			fmt.Fprintf(os.Stderr, "File already complete\n")
 		}
+		printFinal()
 		return nil
 	}
 
 	// Open output file
 	var out io.Writer
 	if writeToStdout {
-		out = os.Stdout
+		out = stdout
 	} else {
 		flags := os.O_CREATE | os.O_WRONLY
 		if resuming {
@@ -220,15 +262,21 @@ func downloadFile(client *http.Client, params *Params, outputFile string, existi
 		}
 	}
 
-	// Wrap with progress if not quiet and not stdout
+	// Wrap with progress if not quiet and not stdout. The bar is redrawn in
+	// place, which only works on a terminal and needs a known size.
 	var reader io.Reader = resp.Body
 	if !params.Quiet && !params.NoProgress && !writeToStdout {
-		reader = &progressReader{
+		pr := &progressReader{
 			reader:     resp.Body,
+			out:        os.Stderr,
+			bar:        totalSize > 0 && term.IsTerminal(int(os.Stderr.Fd())),
 			total:      totalSize,
 			downloaded: existingSize,
-			lastPrint:  time.Now(),
+			resumedAt:  existingSize,
+			now:        time.Now,
 		}
+		defer pr.finish()
+		reader = pr
 	}
 
 	// Copy data
@@ -237,70 +285,106 @@ func downloadFile(client *http.Client, params *Params, outputFile string, existi
 		return fmt.Errorf("download error: %w", err)
 	}
 
-	// Print final newline after progress bar
-	if !params.Quiet && !params.NoProgress && !writeToStdout {
-		fmt.Fprintln(os.Stderr)
-	}
-
 	if !params.Quiet {
 		fmt.Fprintf(os.Stderr, "Downloaded: %s (%s)\n", outputFile, formatBytes(written+existingSize))
 	}
+	printFinal()
 
 	return nil
 }
 
+const (
+	barInterval  = 100 * time.Millisecond
+	lineInterval = 5 * time.Second
+)
+
+// progressReader reports download progress on out, either as a bar redrawn
+// in place or, when bar is false, as a line every lineInterval.
 type progressReader struct {
 	reader     io.Reader
-	total      int64
+	out        io.Writer
+	bar        bool
+	total      int64 // 0 if unknown
 	downloaded int64
-	lastPrint  time.Time
+	resumedAt  int64 // bytes present before this download, not counted in the rate
 	startTime  time.Time
+	lastPrint  time.Time
+	now        func() time.Time
 }
 
 func (pr *progressReader) Read(p []byte) (int, error) {
 	if pr.startTime.IsZero() {
-		pr.startTime = time.Now()
+		pr.startTime = pr.now()
+		pr.lastPrint = pr.startTime
 	}
 
 	n, err := pr.reader.Read(p)
 	pr.downloaded += int64(n)
 
-	// Update progress every 100ms
-	if time.Since(pr.lastPrint) > 100*time.Millisecond || err == io.EOF {
+	interval := lineInterval
+	if pr.bar {
+		interval = barInterval
+	}
+	if pr.now().Sub(pr.lastPrint) >= interval {
 		pr.printProgress()
-		pr.lastPrint = time.Now()
 	}
 
 	return n, err
 }
 
-func (pr *progressReader) printProgress() {
-	var percent float64
-	var bar string
-
-	if pr.total > 0 {
-		percent = float64(pr.downloaded) / float64(pr.total) * 100
-		barWidth := 30
-		filled := int(percent / 100 * float64(barWidth))
-		bar = strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled)
-	} else {
-		bar = strings.Repeat("?", 30)
+// finish prints the final state, ending the bar's line.
+func (pr *progressReader) finish() {
+	if pr.startTime.IsZero() {
+		return
 	}
+	pr.printProgress()
+	if pr.bar {
+		fmt.Fprintln(pr.out)
+	}
+}
 
-	// Calculate speed
-	elapsed := time.Since(pr.startTime).Seconds()
+func (pr *progressReader) printProgress() {
+	pr.lastPrint = pr.now()
+
+	// Calculate speed and remaining time
+	elapsed := pr.lastPrint.Sub(pr.startTime).Seconds()
 	var speed float64
 	if elapsed > 0 {
-		speed = float64(pr.downloaded) / elapsed
+		speed = float64(pr.downloaded-pr.resumedAt) / elapsed
+	}
+	eta := "--"
+	if pr.total > 0 && speed > 0 {
+		eta = formatETA(time.Duration(float64(max(pr.total-pr.downloaded, 0)) / speed * float64(time.Second)))
 	}
 
-	if pr.total > 0 {
-		fmt.Fprintf(os.Stderr, "\r[%s] %5.1f%% %s/s %s/%s",
+	switch {
+	case pr.bar:
+		percent := min(float64(pr.downloaded)/float64(pr.total)*100, 100)
+		barWidth := 30
+		filled := int(percent / 100 * float64(barWidth))
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled)
+		fmt.Fprintf(pr.out, "\r[%s] %5.1f%% %s/s %s/%s ETA %s\033[K",
 			bar, percent, formatBytes(int64(speed)),
-			formatBytes(pr.downloaded), formatBytes(pr.total))
-	} else {
-		fmt.Fprintf(os.Stderr, "\r[%s] %s %s/s",
-			bar, formatBytes(pr.downloaded), formatBytes(int64(speed)))
+			formatBytes(pr.downloaded), formatBytes(pr.total), eta)
+	case pr.total > 0:
+		percent := min(float64(pr.downloaded)/float64(pr.total)*100, 100)
+		fmt.Fprintf(pr.out, "%5.1f%% %s/%s %s/s ETA %s\n",
+			percent, formatBytes(pr.downloaded), formatBytes(pr.total), formatBytes(int64(speed)), eta)
+	default:
+		fmt.Fprintf(pr.out, "%s %s/s\n", formatBytes(pr.downloaded), formatBytes(int64(speed)))
+	}
+}
+
+// formatETA formats a remaining time like 1h02m, 3m05s or 42s.
+func formatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
 	}
 }
 
@@ -1,11 +1,17 @@
 package wget
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 func TestCmd(t *testing.T) {
@@ -125,7 +131,7 @@ func TestDownloadFile(t *testing.T) {
 		Retries: 1,
 	}
 
-	err := runWget(params)
+	err := runWget(params, io.Discard)
 	if err != nil {
 		t.Fatalf("runWget failed: %v", err)
 	}
@@ -162,7 +168,7 @@ func TestDownloadFileAutoFilename(t *testing.T) {
 		Retries: 1,
 	}
 
-	err := runWget(params)
+	err := runWget(params, io.Discard)
 	if err != nil {
 		t.Fatalf("runWget failed: %v", err)
 	}
@@ -189,7 +195,7 @@ func TestDownloadFile404(t *testing.T) {
 		Retries: 1,
 	}
 
-	err := runWget(params)
+	err := runWget(params, io.Discard)
 	if err == nil {
 		t.Error("expected error for 404 response")
 	}
@@ -211,14 +217,15 @@ func TestDownloadWithRedirect(t *testing.T) {
 	outputFile := filepath.Join(dir, "output.txt")
 
 	params := &Params{
-		URL:     server.URL + "/redirect",
-		Output:  outputFile,
-		Quiet:   true,
-		Timeout: 10,
-		Retries: 1,
+		URL:       server.URL + "/redirect",
+		Output:    outputFile,
+		Quiet:     true,
+		Timeout:   10,
+		Retries:   1,
+		MaxRedirs: 10,
 	}
 
-	err := runWget(params)
+	err := runWget(params, io.Discard)
 	if err != nil {
 		t.Fatalf("runWget failed: %v", err)
 	}
@@ -232,3 +239,179 @@ func TestDownloadWithRedirect(t *testing.T) {
 		t.Errorf("file content = %q, want %q", string(data), content)
 	}
 }
+
+// redirectServer redirects /hop/N to /hop/N-1, and /hop/0 to /final.
+func redirectServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n, ok := strings.CutPrefix(r.URL.Path, "/hop/"); ok {
+			hops, _ := strconv.Atoi(n)
+			next := "/final"
+			if hops > 0 {
+				next = "/hop/" + strconv.Itoa(hops-1)
+			}
+			http.Redirect(w, r, next, http.StatusFound)
+			return
+		}
+		w.Write([]byte("final content"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadRedirectLimit(t *testing.T) {
+	server := redirectServer(t)
+
+	tests := []struct {
+		name      string
+		path      string
+		maxRedirs int
+		noFollow  bool
+		errMsg    string // empty if the download should succeed
+	}{
+		{"within limit", "/hop/0", 3, false, ""},
+		{"exactly at limit", "/hop/2", 3, false, ""}, // 3 redirects
+		{"over limit", "/hop/3", 3, false, "too many redirects"},
+		{"zero allows none", "/hop/0", 0, false, "too many redirects"},
+		{"no follow", "/hop/0", 10, true, "redirect not followed: server returned 302 Found, location: /final"},
+		{"no follow without redirect", "/final", 10, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputFile := filepath.Join(t.TempDir(), "output.txt")
+			err := runWget(&Params{
+				URL:       server.URL + tt.path,
+				Output:    outputFile,
+				Quiet:     true,
+				Timeout:   10,
+				Retries:   3,
+				MaxRedirs: tt.maxRedirs,
+				NoFollow:  tt.noFollow,
+			}, io.Discard)
+
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if data, _ := os.ReadFile(outputFile); string(data) != "final content" {
+					t.Errorf("file content = %q, want %q", data, "final content")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+			if strings.Contains(fmt.Sprint(err), "failed after") {
+				t.Errorf("Expected redirect errors not to be retried, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDownloadPrintFinal(t *testing.T) {
+	server := redirectServer(t)
+
+	var stdout strings.Builder
+	err := runWget(&Params{
+		URL:        server.URL + "/hop/1",
+		Output:     filepath.Join(t.TempDir(), "output.txt"),
+		Quiet:      true,
+		Timeout:    10,
+		Retries:    1,
+		MaxRedirs:  10,
+		PrintFinal: true,
+	}, &stdout)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := server.URL + "/final\n"; stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+// slowReader advances a fake clock by a second on every read.
+type slowReader struct {
+	r   io.Reader
+	now time.Time
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	s.now = s.now.Add(time.Second)
+	return s.r.Read(p)
+}
+
+func newSlowReader(r io.Reader) *slowReader {
+	return &slowReader{r: r, now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (s *slowReader) clock() time.Time {
+	return s.now
+}
+
+func TestProgressReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		bar      bool
+		total    int64
+		expected string
+	}{
+		// 4 KB read in the first second, EOF in the next
+		{"bar", true, 4096,
+			"\r[==============================] 100.0% 4.0 KB/s 4.0 KB/4.0 KB ETA 0s\033[K" +
+				"\r[==============================] 100.0% 2.0 KB/s 4.0 KB/4.0 KB ETA 0s\033[K" +
+				"\r[==============================] 100.0% 2.0 KB/s 4.0 KB/4.0 KB ETA 0s\033[K\n"},
+		{"lines", false, 4096,
+			"100.0% 4.0 KB/4.0 KB 2.0 KB/s ETA 0s\n"},
+		{"unknown size", false, 0,
+			"4.0 KB 2.0 KB/s\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			r := newSlowReader(strings.NewReader(strings.Repeat("x", 4096)))
+			pr := &progressReader{reader: r, out: &out, bar: tt.bar, total: tt.total, now: r.clock}
+			if _, err := io.Copy(io.Discard, pr); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			pr.finish()
+			if got := out.String(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProgressReader_LinesArePeriodic(t *testing.T) {
+	var out strings.Builder
+	r := newSlowReader(iotest.OneByteReader(strings.NewReader(strings.Repeat("x", 100))))
+	pr := &progressReader{reader: r, out: &out, total: 100, now: r.clock}
+	io.Copy(io.Discard, pr)
+
+	// A byte a second, a line every 5 seconds
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("Expected 20 lines, got %d:\n%s", len(lines), out.String())
+	}
+	expected := "  5.0% 5 B/100 B 1 B/s ETA 1m35s"
+	if lines[0] != expected {
+		t.Errorf("Expected %q, got %q", expected, lines[0])
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{42 * time.Second, "42s"},
+		{185 * time.Second, "3m05s"},
+		{time.Hour + 2*time.Minute + 10*time.Second, "1h02m"},
+	}
+	for _, tt := range tests {
+		if got := formatETA(tt.d); got != tt.expected {
+			t.Errorf("formatETA(%v) = %q, want %q", tt.d, got, tt.expected)
+		}
+	}
+}